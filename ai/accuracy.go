@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"math"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// PlayerSummary aggregates one side's moves of a GameAnalysis.
+type PlayerSummary struct {
+	Moves int
+	// Accuracy is the Lichess-style game accuracy in percent: the average of a
+	// volatility-weighted mean and the harmonic mean of the move accuracies.
+	Accuracy             float64
+	AverageCentipawnLoss float64
+	Best                 int
+	Good                 int
+	Inaccuracies         int
+	Mistakes             int
+	Blunders             int
+}
+
+// winPercent converts a centipawn evaluation to the expected score in percent,
+// using the curve Lichess fitted to rated games.
+func winPercent(cp int) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(cp)))-1)
+}
+
+// moveAccuracy rates a move from 0 to 100 by how much it lowered the mover's
+// winning chances.
+func moveAccuracy(ma MoveAnalysis) float64 {
+	before := winPercent(perspective(ma.EvalBefore, ma.Color))
+	after := winPercent(perspective(ma.EvalAfter, ma.Color))
+	drop := max(before-after, 0)
+	return min(max(103.1668*math.Exp(-0.04354*drop)-3.1669, 0), 100)
+}
+
+// summarize fills in both players' summaries.
+func (a *GameAnalysis) summarize() {
+	if len(a.Moves) == 0 {
+		return
+	}
+	wins := make([]float64, len(a.Evals))
+	for i, cp := range a.Evals {
+		wins[i] = winPercent(cp)
+	}
+	weights := volatilityWeights(wins, len(a.Moves))
+
+	type totals struct {
+		loss, weighted, weights, inverse float64
+	}
+	var sums [2]totals
+	for i := range a.Moves {
+		m := a.Moves[i]
+		summary, t := &a.White, &sums[0]
+		if m.Color == engine.Black {
+			summary, t = &a.Black, &sums[1]
+		}
+		summary.Moves++
+		switch m.Class {
+		case ClassBest:
+			summary.Best++
+		case ClassGood:
+			summary.Good++
+		case ClassInaccuracy:
+			summary.Inaccuracies++
+		case ClassMistake:
+			summary.Mistakes++
+		case ClassBlunder:
+			summary.Blunders++
+		}
+		t.loss += float64(m.CentipawnLoss)
+		t.weighted += m.Accuracy * weights[i]
+		t.weights += weights[i]
+		t.inverse += 1 / max(m.Accuracy, 1)
+	}
+	for i, summary := range []*PlayerSummary{&a.White, &a.Black} {
+		if summary.Moves == 0 {
+			continue
+		}
+		t := sums[i]
+		n := float64(summary.Moves)
+		summary.AverageCentipawnLoss = t.loss / n
+		summary.Accuracy = (t.weighted/t.weights + n/t.inverse) / 2
+	}
+}
+
+// volatilityWeights weights each move by the standard deviation of the winning
+// chances in a window around it, so accuracy in sharp phases of the game counts
+// more than in quiet ones.
+func volatilityWeights(wins []float64, moves int) []float64 {
+	size := min(max(moves/10, 2), 8)
+	weights := make([]float64, moves)
+	for i := range weights {
+		// The first moves share the first full window
+		start := max(i+2-size, 0)
+		end := min(start+size, len(wins))
+		weights[i] = min(max(stddev(wins[start:end]), 0.5), 12)
+	}
+	return weights
+}
+
+// stddev returns the population standard deviation of xs.
+func stddev(xs []float64) float64 {
+	var mean float64
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	var variance float64
+	for _, x := range xs {
+		variance += (x - mean) * (x - mean)
+	}
+	return math.Sqrt(variance / float64(len(xs)))
+}
@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestWinPercent(t *testing.T) {
+	if got := winPercent(0); got != 50 {
+		t.Errorf("expected an even position at 50%%, got %v", got)
+	}
+	if got := winPercent(300); math.Abs(got-75.1) > 0.5 {
+		t.Errorf("expected about 75%% at +3, got %v", got)
+	}
+	if winPercent(-300)+winPercent(300) != 100 {
+		t.Errorf("expected the curve to be symmetric")
+	}
+}
+
+func TestMoveAccuracy(t *testing.T) {
+	perfect := moveAccuracy(MoveAnalysis{Color: engine.White, EvalBefore: 50, EvalAfter: 60})
+	if perfect < 99.9 {
+		t.Errorf("expected 100 for a move that kept the evaluation, got %v", perfect)
+	}
+	blunder := moveAccuracy(MoveAnalysis{Color: engine.Black, EvalBefore: 0, EvalAfter: 900})
+	if blunder > 20 {
+		t.Errorf("expected a low accuracy for dropping a piece, got %v", blunder)
+	}
+}
+
+func TestAnalyzeGame_Summary(t *testing.T) {
+	game := playSAN(t, engine.NewGame(), "e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "Qxf7#")
+	report, err := AnalyzeGame(context.Background(), game, AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeGame: %v", err)
+	}
+	white, black := report.White, report.Black
+	if white.Moves != 4 || black.Moves != 3 || black.Blunders != 1 {
+		t.Fatalf("unexpected summaries %+v %+v", white, black)
+	}
+	if white.Accuracy <= black.Accuracy || black.AverageCentipawnLoss <= white.AverageCentipawnLoss {
+		t.Errorf("expected White to have played better: %+v %+v", white, black)
+	}
+	if black.Accuracy <= 0 || white.Accuracy > 100 {
+		t.Errorf("accuracies out of range: %v %v", white.Accuracy, black.Accuracy)
+	}
+	if report.Moves[5].Accuracy > 20 {
+		t.Errorf("expected a low accuracy for the blunder, got %v", report.Moves[5].Accuracy)
+	}
+}
+
+func TestVolatilityWeights(t *testing.T) {
+	calm := volatilityWeights([]float64{50, 50, 50, 50, 50}, 4)
+	for _, w := range calm {
+		if w != 0.5 {
+			t.Errorf("expected the minimum weight in a calm game, got %v", calm)
+		}
+	}
+	sharp := volatilityWeights([]float64{50, 90, 10, 90, 10}, 4)
+	if sharp[2] != 12 {
+		t.Errorf("expected the maximum weight in a sharp game, got %v", sharp)
+	}
+}
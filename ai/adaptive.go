@@ -0,0 +1,100 @@
+package ai
+
+// AdaptivePolicy configures how AdaptiveStrength retunes an engine to its
+// opponent.
+type AdaptivePolicy struct {
+	// Band is how far, in centipawns, either side may be ahead before the engine's
+	// strength changes.
+	Band int
+	// Step is the Elo change per move spent outside the band.
+	Step int
+	// MinElo and MaxElo bound the adjusted rating.
+	MinElo, MaxElo int
+	// Window is the number of recent moves the opponent's accuracy is averaged over.
+	Window int
+}
+
+// DefaultAdaptivePolicy keeps the game within 1.5 pawns by moving 50 Elo at a
+// time, within the calibrated range of StrengthForElo.
+func DefaultAdaptivePolicy() AdaptivePolicy {
+	return AdaptivePolicy{
+		Band:   150,
+		Step:   50,
+		MinElo: strengthAnchors[0].Elo,
+		MaxElo: strengthAnchors[len(strengthAnchors)-1].Elo,
+		Window: 10,
+	}
+}
+
+// AdaptiveStrength models an engine's opponent from their graded moves (see
+// CheckMove) and adjusts the engine's target rating to keep the evaluation within
+// a band: weaker while the engine is ahead by more, stronger while it is behind.
+// It is not safe for concurrent use.
+type AdaptiveStrength struct {
+	policy     AdaptivePolicy
+	offset     int       // Elo added to the engine's nominal rating
+	accuracies []float64 // the opponent's recent move accuracies, oldest first
+	moves      int
+	eval       int // last evaluation after an opponent move, for the opponent
+}
+
+// NewAdaptiveStrength returns a model for a new game.
+func NewAdaptiveStrength(policy AdaptivePolicy) *AdaptiveStrength {
+	return &AdaptiveStrength{policy: policy}
+}
+
+// Record takes a graded move of the opponent and moves the rating offset by one
+// step if the evaluation after it is outside the band.
+func (a *AdaptiveStrength) Record(ma MoveAnalysis) {
+	a.moves++
+	a.accuracies = append(a.accuracies, ma.Accuracy)
+	if window := max(a.policy.Window, 1); len(a.accuracies) > window {
+		a.accuracies = a.accuracies[len(a.accuracies)-window:]
+	}
+	a.eval = perspective(ma.EvalAfter, ma.Color)
+
+	switch {
+	case a.eval < -a.policy.Band:
+		a.offset -= a.policy.Step
+	case a.eval > a.policy.Band:
+		a.offset += a.policy.Step
+	}
+	// Offsets beyond what any rating can use would only delay the way back
+	span := a.policy.MaxElo - a.policy.MinElo
+	a.offset = min(max(a.offset, -span), span)
+}
+
+// Elo returns the adjusted target rating for an engine whose nominal rating is
+// elo, within the policy's bounds.
+func (a *AdaptiveStrength) Elo(elo int) int {
+	return min(max(elo+a.offset, a.policy.MinElo), a.policy.MaxElo)
+}
+
+// Offset returns the current rating adjustment.
+func (a *AdaptiveStrength) Offset() int {
+	return a.offset
+}
+
+// Accuracy returns the opponent's average move accuracy over the recent window,
+// and 0 before any move was recorded.
+func (a *AdaptiveStrength) Accuracy() float64 {
+	if len(a.accuracies) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, acc := range a.accuracies {
+		sum += acc
+	}
+	return sum / float64(len(a.accuracies))
+}
+
+// Moves returns the number of opponent moves recorded.
+func (a *AdaptiveStrength) Moves() int {
+	return a.moves
+}
+
+// Eval returns the evaluation after the opponent's last recorded move, in
+// centipawns from the opponent's perspective.
+func (a *AdaptiveStrength) Eval() int {
+	return a.eval
+}
@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"math"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestAdaptiveStrength(t *testing.T) {
+	policy := DefaultAdaptivePolicy()
+	policy.Window = 2
+	a := NewAdaptiveStrength(policy)
+	if a.Elo(1400) != 1400 || a.Accuracy() != 0 {
+		t.Fatalf("expected no adjustment before any move, got %d", a.Elo(1400))
+	}
+
+	// Black struggles: White is a rook up after each of their moves
+	losing := MoveAnalysis{Color: engine.Black, EvalAfter: 500, Accuracy: 40}
+	a.Record(losing)
+	a.Record(losing)
+	if got := a.Elo(1400); got != 1400-2*policy.Step {
+		t.Errorf("expected the engine to ease off twice, got %d", got)
+	}
+
+	// Inside the band nothing changes
+	a.Record(MoveAnalysis{Color: engine.Black, EvalAfter: -100, Accuracy: 90})
+	if got := a.Elo(1400); got != 1400-2*policy.Step || a.Eval() != 100 {
+		t.Errorf("expected no change within the band, got %d at %d", got, a.Eval())
+	}
+	if math.Abs(a.Accuracy()-65) > 1e-9 || a.Moves() != 3 {
+		t.Errorf("expected the last two moves averaged, got %v over %d moves", a.Accuracy(), a.Moves())
+	}
+
+	// A player who gets ahead meets a stronger engine
+	winning := MoveAnalysis{Color: engine.White, EvalAfter: 400, Accuracy: 95}
+	for i := 0; i < 4; i++ {
+		a.Record(winning)
+	}
+	if got := a.Offset(); got != 2*policy.Step {
+		t.Errorf("expected the offset to recover and grow, got %d", got)
+	}
+
+	// The rating stays in the calibrated range, and so does the offset
+	for i := 0; i < 200; i++ {
+		a.Record(winning)
+	}
+	if got := a.Elo(2000); got != policy.MaxElo {
+		t.Errorf("expected the maximum rating, got %d", got)
+	}
+	a.Record(MoveAnalysis{Color: engine.White, EvalAfter: -400})
+	if got, want := a.Offset(), policy.MaxElo-policy.MinElo-policy.Step; got != want {
+		t.Errorf("expected a capped offset to come back at once, got %d want %d", got, want)
+	}
+}
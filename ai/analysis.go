@@ -0,0 +1,276 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// MoveClass classifies a move by how much it lost compared to the best move.
+type MoveClass string
+
+const (
+	// ClassBest is the engine's choice or as good as it.
+	ClassBest MoveClass = "best"
+	// ClassGood loses less than an inaccuracy.
+	ClassGood MoveClass = "good"
+	// ClassInaccuracy loses a little.
+	ClassInaccuracy MoveClass = "inaccuracy"
+	// ClassMistake loses clearly.
+	ClassMistake MoveClass = "mistake"
+	// ClassBlunder loses decisively.
+	ClassBlunder MoveClass = "blunder"
+)
+
+// Default centipawn loss thresholds of the move classes.
+const (
+	DefaultInaccuracyLoss = 50
+	DefaultMistakeLoss    = 100
+	DefaultBlunderLoss    = 300
+)
+
+// evalCap bounds the scores used for centipawn loss, so that missing a long mate in
+// an already won position does not count as a huge loss.
+const evalCap = 1000
+
+// AnalysisOptions configures AnalyzeGame. Zero values select the defaults.
+type AnalysisOptions struct {
+	// Engine searches each position; defaults to a medium MinimaxAI without an
+	// opening book.
+	Engine Analyzer
+	// Loss thresholds in centipawns (defaults 50, 100 and 300).
+	InaccuracyLoss int
+	MistakeLoss    int
+	BlunderLoss    int
+}
+
+// withDefaults fills in zero options.
+func (opts AnalysisOptions) withDefaults() AnalysisOptions {
+	if opts.Engine == nil {
+		minimax := NewMinimaxAI(DifficultyMedium)
+		minimax.SetOpeningBook(nil)
+		opts.Engine = minimax
+	}
+	if opts.InaccuracyLoss <= 0 {
+		opts.InaccuracyLoss = DefaultInaccuracyLoss
+	}
+	if opts.MistakeLoss <= 0 {
+		opts.MistakeLoss = DefaultMistakeLoss
+	}
+	if opts.BlunderLoss <= 0 {
+		opts.BlunderLoss = DefaultBlunderLoss
+	}
+	return opts
+}
+
+// MoveAnalysis is the verdict on one move of a game. Evaluations are in
+// centipawns from White's perspective, capped at ±1000.
+type MoveAnalysis struct {
+	Ply        int // 1 for the first move of the game
+	MoveNumber int
+	Color      engine.Color
+	Move       engine.Move
+	SAN        string
+	EvalBefore int
+	EvalAfter  int
+	// MateBefore and MateAfter are the mate distances found for the side to move
+	// (see Line.MateIn), 0 if none.
+	MateBefore int
+	MateAfter  int
+	// BestMove is the engine's choice in the position and BestLine its principal
+	// variation in SAN, starting with BestMove.
+	BestMove      engine.Move
+	BestLine      []string
+	CentipawnLoss int
+	Class         MoveClass
+	// Accuracy rates the move from 0 to 100 by the drop in the mover's winning
+	// chances, as Lichess does.
+	Accuracy float64
+}
+
+// GameAnalysis is the move-by-move report of AnalyzeGame.
+type GameAnalysis struct {
+	Moves []MoveAnalysis
+	// Evals holds the evaluation of every position from White's perspective,
+	// capped like MoveAnalysis, starting with the initial position; it has one
+	// more entry than Moves.
+	Evals []int
+	// White and Black summarize each side's moves.
+	White PlayerSummary
+	Black PlayerSummary
+	Info  SearchInfo // totals over all searches
+}
+
+// positionEval is the search result for one position of the game.
+type positionEval struct {
+	score int // side to move's perspective, capped
+	mate  int
+	line  Line
+}
+
+// AnalyzeGame evaluates every position of the game's move history, from its start
+// or starting FEN, and classifies each move by its centipawn loss: the difference
+// between the best move's evaluation and the played move's, from the mover's
+// perspective. The game itself is not modified.
+func AnalyzeGame(ctx context.Context, game *engine.Game, opts AnalysisOptions) (*GameAnalysis, error) {
+	opts = opts.withDefaults()
+	replay, err := replayStart(game)
+	if err != nil {
+		return nil, err
+	}
+	history := game.MoveHistory()
+	report := &GameAnalysis{Moves: make([]MoveAnalysis, 0, len(history))}
+
+	before, err := evaluatePosition(ctx, replay, opts, &report.Info)
+	if err != nil {
+		return nil, err
+	}
+	report.Evals = append(report.Evals, perspective(before.score, replay.ActiveColor()))
+	for i, move := range history {
+		ma, after, err := analyzeMove(ctx, replay, move, before, opts, &report.Info)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		ma.Ply = i + 1
+		report.Moves = append(report.Moves, ma)
+		report.Evals = append(report.Evals, ma.EvalAfter)
+		before = after
+	}
+	report.summarize()
+	return report, nil
+}
+
+// AnalyzeLastMove grades the last move of the game as AnalyzeGame does, but
+// searches only the positions before and after it. The game itself is not
+// modified.
+func AnalyzeLastMove(ctx context.Context, game *engine.Game, opts AnalysisOptions) (*MoveAnalysis, error) {
+	opts = opts.withDefaults()
+	history := game.MoveHistory()
+	if len(history) == 0 {
+		return nil, errors.New("no moves to analyze")
+	}
+	replay, err := replayStart(game)
+	if err != nil {
+		return nil, err
+	}
+	move := history[len(history)-1]
+	for i, earlier := range history[:len(history)-1] {
+		if err := replay.MakeMove(earlier); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+	var info SearchInfo
+	before, err := evaluatePosition(ctx, replay, opts, &info)
+	if err != nil {
+		return nil, err
+	}
+	ma, _, err := analyzeMove(ctx, replay, move, before, opts, &info)
+	if err != nil {
+		return nil, err
+	}
+	ma.Ply = len(history)
+	return &ma, nil
+}
+
+// analyzeMove plays move on game, whose position was evaluated as before, and
+// grades it. It returns the evaluation of the new position too.
+func analyzeMove(ctx context.Context, game *engine.Game, move engine.Move, before positionEval, opts AnalysisOptions, total *SearchInfo) (MoveAnalysis, positionEval, error) {
+	ma := MoveAnalysis{
+		MoveNumber: game.MoveCount(),
+		Color:      game.ActiveColor(),
+		Move:       move,
+		EvalBefore: perspective(before.score, game.ActiveColor()),
+		MateBefore: before.mate,
+	}
+	if len(before.line.Moves) > 0 {
+		ma.BestMove = before.line.Moves[0]
+		ma.BestLine, _ = game.SANLine(before.line.Moves)
+	}
+	if san, err := game.SANLine([]engine.Move{move}); err == nil {
+		ma.SAN = san[0]
+	}
+	if err := game.MakeMove(move); err != nil {
+		return ma, positionEval{}, err
+	}
+
+	after, err := evaluatePosition(ctx, game, opts, total)
+	if err != nil {
+		return ma, positionEval{}, err
+	}
+	ma.EvalAfter = perspective(after.score, game.ActiveColor())
+	ma.MateAfter = after.mate
+	if move.UCI() != ma.BestMove.UCI() {
+		// The played move's value for the mover is the negated reply score
+		ma.CentipawnLoss = max(before.score+after.score, 0)
+	}
+	ma.Class = classifyLoss(ma, opts)
+	ma.Accuracy = moveAccuracy(ma)
+	return ma, after, nil
+}
+
+// classifyLoss assigns the move class for a move's centipawn loss.
+func classifyLoss(ma MoveAnalysis, opts AnalysisOptions) MoveClass {
+	switch loss := ma.CentipawnLoss; {
+	case ma.Move.UCI() == ma.BestMove.UCI() || loss == 0:
+		return ClassBest
+	case loss >= opts.BlunderLoss:
+		return ClassBlunder
+	case loss >= opts.MistakeLoss:
+		return ClassMistake
+	case loss >= opts.InaccuracyLoss:
+		return ClassInaccuracy
+	default:
+		return ClassGood
+	}
+}
+
+// evaluatePosition scores the position for the side to move. Finished games are
+// scored by their result without searching.
+func evaluatePosition(ctx context.Context, game *engine.Game, opts AnalysisOptions, total *SearchInfo) (positionEval, error) {
+	switch game.Status() {
+	case engine.WhiteWins, engine.BlackWins:
+		return positionEval{score: -evalCap}, nil // the side to move was mated
+	case engine.Draw:
+		return positionEval{}, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return positionEval{}, err
+	}
+	lines, info, err := opts.Engine.Analyze(ctx, game, 1)
+	total.Nodes += info.Nodes
+	total.Elapsed += info.Elapsed
+	total.Depth = max(total.Depth, info.Depth)
+	if err != nil {
+		return positionEval{}, err
+	}
+	if len(lines) == 0 {
+		return positionEval{}, errors.New("engine returned no analysis")
+	}
+	return positionEval{
+		score: min(max(lines[0].Score, -evalCap), evalCap),
+		mate:  lines[0].MateIn(),
+		line:  lines[0],
+	}, nil
+}
+
+// perspective converts a score between White's perspective and color's; the
+// conversion is the same in both directions.
+func perspective(score int, color engine.Color) int {
+	if color == engine.Black {
+		return -score
+	}
+	return score
+}
+
+// replayStart returns a new game at the position the game started from.
+func replayStart(game *engine.Game) (*engine.Game, error) {
+	replay := engine.NewGameWithVariant(game.Variant())
+	if game.StartedFromFEN() {
+		if err := replay.ParseFEN(game.StartingFEN()); err != nil {
+			return nil, fmt.Errorf("starting position: %w", err)
+		}
+	}
+	return replay, nil
+}
@@ -0,0 +1,114 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// playSAN plays SAN moves on a new game.
+func playSAN(t *testing.T, game *engine.Game, moves ...string) *engine.Game {
+	t.Helper()
+	for _, san := range moves {
+		move, err := game.MoveFromSAN(san)
+		if err != nil {
+			t.Fatalf("%s: %v", san, err)
+		}
+		if err := game.MakeMove(move); err != nil {
+			t.Fatalf("%s: %v", san, err)
+		}
+	}
+	return game
+}
+
+func TestAnalyzeGame_ScholarsMate(t *testing.T) {
+	game := playSAN(t, engine.NewGame(), "e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "Qxf7#")
+	report, err := AnalyzeGame(context.Background(), game, AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeGame: %v", err)
+	}
+	if len(report.Moves) != 7 || len(report.Evals) != 8 {
+		t.Fatalf("expected 7 moves and 8 evaluations, got %d and %d", len(report.Moves), len(report.Evals))
+	}
+
+	blunder := report.Moves[5]
+	if blunder.SAN != "Nf6" || blunder.Color != engine.Black || blunder.MoveNumber != 3 {
+		t.Fatalf("unexpected move record %+v", blunder)
+	}
+	if blunder.Class != ClassBlunder || blunder.CentipawnLoss < DefaultBlunderLoss {
+		t.Errorf("expected Nf6 to be a blunder, got %s (%d)", blunder.Class, blunder.CentipawnLoss)
+	}
+	if blunder.MateAfter != 1 || len(blunder.BestLine) == 0 || blunder.BestLine[0] == "Nf6" {
+		t.Errorf("expected the mate threat and a better alternative, got %+v", blunder)
+	}
+
+	mate := report.Moves[6]
+	if mate.Class != ClassBest || mate.CentipawnLoss != 0 || mate.MateBefore != 1 {
+		t.Errorf("expected Qxf7# to be the best move, got %+v", mate)
+	}
+	if report.Evals[7] != evalCap {
+		t.Errorf("expected the final position to be won for White, got %d", report.Evals[7])
+	}
+	if report.Info.Nodes == 0 {
+		t.Errorf("expected search statistics")
+	}
+}
+
+func TestAnalyzeGame_FromFEN(t *testing.T) {
+	game := gameFromFEN(t, "4k3/8/8/8/8/8/4P3/4K3 w - - 0 40")
+	playSAN(t, game, "e4", "Kd7")
+	report, err := AnalyzeGame(context.Background(), game, AnalysisOptions{Engine: NewMCTSEngine(DifficultyBeginner)})
+	if err != nil {
+		t.Fatalf("AnalyzeGame: %v", err)
+	}
+	if len(report.Moves) != 2 || report.Moves[0].MoveNumber != 40 || report.Moves[1].SAN != "Kd7" {
+		t.Errorf("unexpected report %+v", report.Moves)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := AnalyzeGame(ctx, game, AnalysisOptions{}); err == nil {
+		t.Errorf("expected an error for a cancelled context")
+	}
+}
+
+func TestAnalyzeLastMove(t *testing.T) {
+	game := playSAN(t, engine.NewGame(), "e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6")
+	ma, err := AnalyzeLastMove(context.Background(), game, AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeLastMove: %v", err)
+	}
+	if ma.SAN != "Nf6" || ma.Ply != 6 || ma.Class != ClassBlunder || ma.MateAfter != 1 {
+		t.Errorf("expected Nf6 to be graded a blunder allowing mate, got %+v", ma)
+	}
+	if len(game.MoveHistory()) != 6 {
+		t.Errorf("expected the game to be left alone")
+	}
+
+	if _, err := AnalyzeLastMove(context.Background(), engine.NewGame(), AnalysisOptions{}); err == nil {
+		t.Errorf("expected an error for a game without moves")
+	}
+}
+
+func TestClassifyLoss(t *testing.T) {
+	opts := AnalysisOptions{InaccuracyLoss: 50, MistakeLoss: 100, BlunderLoss: 300}
+	move, other := engine.Move{From: engine.E2, To: engine.E4}, engine.Move{From: engine.D2, To: engine.D4}
+	tests := []struct {
+		loss int
+		best engine.Move
+		want MoveClass
+	}{
+		{0, other, ClassBest},
+		{80, move, ClassBest},
+		{20, other, ClassGood},
+		{50, other, ClassInaccuracy},
+		{150, other, ClassMistake},
+		{300, other, ClassBlunder},
+	}
+	for _, tt := range tests {
+		if got := classifyLoss(MoveAnalysis{Move: move, BestMove: tt.best, CentipawnLoss: tt.loss}, opts); got != tt.want {
+			t.Errorf("loss %d: got %s, want %s", tt.loss, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package ai
+
+import (
+	"bufio"
+	_ "embed" // for the built-in repertoire
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// Repertoire selects which lines of an opening book an engine plays.
+type Repertoire string
+
+const (
+	// RepertoireBalanced plays every line in the book.
+	RepertoireBalanced Repertoire = "balanced"
+	// RepertoireAggressive prefers gambits and sharp, double-edged openings.
+	RepertoireAggressive Repertoire = "aggressive"
+	// RepertoireSolid prefers classical, positionally sound openings.
+	RepertoireSolid Repertoire = "solid"
+)
+
+// ParseRepertoire converts a repertoire name into a Repertoire. The empty string
+// selects the balanced repertoire.
+func ParseRepertoire(name string) (Repertoire, error) {
+	switch r := Repertoire(strings.ToLower(strings.TrimSpace(name))); r {
+	case "":
+		return RepertoireBalanced, nil
+	case RepertoireBalanced, RepertoireAggressive, RepertoireSolid:
+		return r, nil
+	default:
+		return "", fmt.Errorf("unknown repertoire %q", name)
+	}
+}
+
+// BookMove is a candidate move stored in an opening book.
+type BookMove struct {
+	Move engine.Move
+	// Weight is the number of book lines continuing with the move.
+	Weight int
+	// Name is the name of the first book line playing the move.
+	Name string
+}
+
+// OpeningBook maps positions to the moves a repertoire plays from them. Positions
+// are keyed by Zobrist hash, so transpositions share their moves.
+type OpeningBook struct {
+	positions map[uint64][]BookMove
+}
+
+//go:embed openings.txt
+var builtinOpenings string
+
+var (
+	builtinBooksOnce sync.Once
+	builtinBooks     map[Repertoire]*OpeningBook
+)
+
+// BuiltinBook returns the embedded opening book for a repertoire. The books are
+// built on first use and shared; they must not be modified.
+func BuiltinBook(repertoire Repertoire) *OpeningBook {
+	builtinBooksOnce.Do(func() {
+		builtinBooks = make(map[Repertoire]*OpeningBook)
+		for _, r := range []Repertoire{RepertoireBalanced, RepertoireAggressive, RepertoireSolid} {
+			book, err := NewOpeningBook(strings.NewReader(builtinOpenings), r)
+			if err != nil {
+				panic("ai: invalid built-in opening book: " + err.Error())
+			}
+			builtinBooks[r] = book
+		}
+	})
+	if book, ok := builtinBooks[repertoire]; ok {
+		return book
+	}
+	return builtinBooks[RepertoireBalanced]
+}
+
+// NewOpeningBook reads an opening book with one line per variation in the form
+// "<styles> | <name> | <SAN moves>", where styles is a comma separated list of
+// repertoires the line belongs to. Blank lines and lines starting with '#' are
+// ignored. Only lines matching the repertoire are loaded; the balanced repertoire
+// loads them all.
+func NewOpeningBook(r io.Reader, repertoire Repertoire) (*OpeningBook, error) {
+	book := &OpeningBook{positions: make(map[uint64][]BookMove)}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "|")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected \"styles | name | moves\"", lineNo)
+		}
+		if !inRepertoire(fields[0], repertoire) {
+			continue
+		}
+		name := strings.TrimSpace(fields[1])
+		if err := book.addLine(name, strings.Fields(fields[2])); err != nil {
+			return nil, fmt.Errorf("line %d (%s): %w", lineNo, name, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// inRepertoire reports whether a line tagged with styles belongs to repertoire.
+func inRepertoire(styles string, repertoire Repertoire) bool {
+	if repertoire == RepertoireBalanced {
+		return true
+	}
+	for _, style := range strings.Split(styles, ",") {
+		if Repertoire(strings.TrimSpace(style)) == repertoire {
+			return true
+		}
+	}
+	return false
+}
+
+// addLine replays a line of SAN moves from the initial position, recording each
+// move in the book.
+func (b *OpeningBook) addLine(name string, sans []string) error {
+	game := engine.NewGame()
+	for _, san := range sans {
+		move, err := game.MoveFromSAN(san)
+		if err != nil {
+			return fmt.Errorf("move %q: %w", san, err)
+		}
+		b.add(game.Hash(), move, name)
+		if err := game.MakeMove(move); err != nil {
+			return fmt.Errorf("move %q: %w", san, err)
+		}
+	}
+	return nil
+}
+
+// add records move from the position with the given hash.
+func (b *OpeningBook) add(key uint64, move engine.Move, name string) {
+	moves := b.positions[key]
+	for i := range moves {
+		if moves[i].Move == move {
+			moves[i].Weight++
+			return
+		}
+	}
+	b.positions[key] = append(moves, BookMove{Move: move, Weight: 1, Name: name})
+}
+
+// Len returns the number of positions in the book.
+func (b *OpeningBook) Len() int {
+	return len(b.positions)
+}
+
+// Moves returns the book moves for the current position, or nil when the position
+// is out of book. Only standard chess positions are looked up.
+func (b *OpeningBook) Moves(game *engine.Game) []BookMove {
+	if b == nil || game.Variant() != engine.Standard {
+		return nil
+	}
+	moves := b.positions[game.Hash()]
+	// Guard against hash collisions with positions from other games
+	legal := make([]BookMove, 0, len(moves))
+	for _, m := range moves {
+		if game.IsLegalMove(m.Move) {
+			legal = append(legal, m)
+		}
+	}
+	if len(legal) == 0 {
+		return nil
+	}
+	return legal
+}
+
+// Opening names the opening of a game by the book line of its last move still in
+// book, or returns "" when the game left the book at once or started from a FEN.
+func (b *OpeningBook) Opening(game *engine.Game) string {
+	if b == nil || game.StartedFromFEN() {
+		return ""
+	}
+	replay := engine.NewGameWithVariant(game.Variant())
+	name := ""
+	for _, move := range game.MoveHistory() {
+		found := false
+		for _, m := range b.Moves(replay) {
+			if m.Move == move {
+				name, found = m.Name, true
+				break
+			}
+		}
+		if !found || replay.MakeMove(move) != nil {
+			break
+		}
+	}
+	return name
+}
+
+// Pick chooses a book move for the current position at random, weighted by how
+// many lines play it. ok is false when the position is out of book.
+func (b *OpeningBook) Pick(game *engine.Game, rng *rand.Rand) (move BookMove, ok bool) {
+	moves := b.Moves(game)
+	if len(moves) == 0 {
+		return BookMove{}, false
+	}
+	total := 0
+	for _, m := range moves {
+		total += m.Weight
+	}
+	n := rng.Intn(total)
+	for _, m := range moves {
+		if n < m.Weight {
+			return m, true
+		}
+		n -= m.Weight
+	}
+	return moves[len(moves)-1], true
+}
@@ -0,0 +1,169 @@
+package ai
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestBuiltinBook(t *testing.T) {
+	balanced := BuiltinBook(RepertoireBalanced)
+	if balanced.Len() < 300 {
+		t.Fatalf("expected several hundred book positions, got %d", balanced.Len())
+	}
+	aggressive, solid := BuiltinBook(RepertoireAggressive), BuiltinBook(RepertoireSolid)
+	if aggressive.Len() >= balanced.Len() || solid.Len() >= balanced.Len() {
+		t.Errorf("expected style repertoires to be subsets: aggressive %d solid %d balanced %d",
+			aggressive.Len(), solid.Len(), balanced.Len())
+	}
+	if BuiltinBook("unknown") != balanced {
+		t.Errorf("expected unknown repertoires to fall back to balanced")
+	}
+
+	// 1.f4 is only in the aggressive repertoire
+	game := engine.NewGame()
+	bird, _ := game.ParseMove("f2f4")
+	hasMove := func(book *OpeningBook) bool {
+		for _, m := range book.Moves(game) {
+			if m.Move == bird {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasMove(aggressive) || hasMove(solid) || !hasMove(balanced) {
+		t.Errorf("expected 1.f4 in the aggressive and balanced repertoires only")
+	}
+}
+
+func TestOpeningBookTranspositions(t *testing.T) {
+	book, err := NewOpeningBook(strings.NewReader(`
+# comment
+solid | A | d4 Nf6 c4 e6
+solid | B | c4 e6 d4 Nf6 Nc3
+`), RepertoireSolid)
+	if err != nil {
+		t.Fatalf("NewOpeningBook: %v", err)
+	}
+	game := engine.NewGame()
+	for _, san := range []string{"d4", "Nf6", "c4", "e6"} {
+		mv, _ := game.MoveFromSAN(san)
+		if err := game.MakeMove(mv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	moves := book.Moves(game)
+	if len(moves) != 1 || moves[0].Name != "B" {
+		t.Fatalf("expected line B to continue the transposed position, got %+v", moves)
+	}
+
+	if _, err := NewOpeningBook(strings.NewReader("solid | Bad | e4 e4"), RepertoireSolid); err == nil {
+		t.Errorf("expected an error for an illegal book move")
+	}
+	if _, err := NewOpeningBook(strings.NewReader("e4 e5"), RepertoireSolid); err == nil {
+		t.Errorf("expected an error for a malformed line")
+	}
+}
+
+func TestOpeningBookPickWeighted(t *testing.T) {
+	book, err := NewOpeningBook(strings.NewReader(`
+solid | A | e4 e5
+solid | B | e4 c5
+solid | C | d4 d5
+`), RepertoireBalanced)
+	if err != nil {
+		t.Fatalf("NewOpeningBook: %v", err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	counts := map[string]int{}
+	for i := 0; i < 300; i++ {
+		bm, ok := book.Pick(engine.NewGame(), rng)
+		if !ok {
+			t.Fatal("expected a book move")
+		}
+		counts[bm.Move.From.String()]++
+	}
+	if counts["e2"] <= counts["d2"] {
+		t.Errorf("expected 1.e4 (two lines) to be picked more often than 1.d4: %v", counts)
+	}
+
+	if _, ok := book.Pick(gameFromFEN(t, "k7/8/2K5/8/8/8/8/7R w - - 0 1"), rng); ok {
+		t.Errorf("expected no book move out of book")
+	}
+}
+
+func TestMinimaxAI_PlaysBookMoves(t *testing.T) {
+	minimax := NewMinimaxAI(DifficultyMedium)
+	minimax.SetRepertoire(RepertoireSolid)
+	game := engine.NewGame()
+	for ply := 0; ply < 6; ply++ {
+		inBook := len(BuiltinBook(RepertoireSolid).Moves(game)) > 0
+		move, info, err := minimax.GetBestMoveWithInfo(context.Background(), game)
+		if err != nil {
+			t.Fatalf("ply %d: %v", ply, err)
+		}
+		if info.Book != inBook {
+			t.Fatalf("ply %d: book %v, expected %v", ply, info.Book, inBook)
+		}
+		if err := game.MakeMove(move); err != nil {
+			t.Fatalf("ply %d: %s: %v", ply, move, err)
+		}
+	}
+
+	// Beginners and engines without a book always search
+	for _, m := range []*MinimaxAI{NewMinimaxAI(DifficultyBeginner), NewMinimaxAI(DifficultyEasy)} {
+		if m.difficulty == DifficultyEasy {
+			m.SetOpeningBook(nil)
+		}
+		if _, info, err := m.GetBestMoveWithInfo(context.Background(), engine.NewGame()); err != nil || info.Book {
+			t.Errorf("%s: expected a searched move, got %+v (%v)", m.difficulty, info, err)
+		}
+	}
+}
+
+func TestParseRepertoire(t *testing.T) {
+	for name, want := range map[string]Repertoire{"": RepertoireBalanced, "Solid": RepertoireSolid, " aggressive ": RepertoireAggressive} {
+		if got, err := ParseRepertoire(name); err != nil || got != want {
+			t.Errorf("ParseRepertoire(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseRepertoire("hypermodern"); err == nil {
+		t.Errorf("expected an error for an unknown repertoire")
+	}
+}
+
+func TestOpeningBookOpening(t *testing.T) {
+	book, err := NewOpeningBook(strings.NewReader(`
+balanced | Ruy Lopez | e4 e5 Nf3 Nc6 Bb5
+balanced | Sicilian Defense | e4 c5 Nf3 d6
+`), RepertoireBalanced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	play := func(sans ...string) *engine.Game {
+		game := engine.NewGame()
+		for _, san := range sans {
+			move, err := game.MoveFromSAN(san)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = game.MakeMove(move)
+		}
+		return game
+	}
+	for _, tc := range []struct {
+		moves []string
+		want  string
+	}{
+		{[]string{"e4", "c5", "Nf3", "Nc6", "d4"}, "Sicilian Defense"},
+		{[]string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6"}, "Ruy Lopez"},
+		{[]string{"d4", "d5"}, ""},
+	} {
+		if got := book.Opening(play(tc.moves...)); got != tc.want {
+			t.Errorf("%v: expected %q, got %q", tc.moves, tc.want, got)
+		}
+	}
+}
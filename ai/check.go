@@ -0,0 +1,31 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// CheckMove grades a move before it is played, for training modes that let a
+// player reconsider: it reports the evaluation swing and the engine's preferred
+// move, and whether the move is a mistake or blunder by the options' thresholds.
+// The game is not modified.
+func CheckMove(ctx context.Context, game *engine.Game, move engine.Move, opts AnalysisOptions) (MoveAnalysis, bool, error) {
+	if !game.IsLegalMove(move) {
+		return MoveAnalysis{}, false, fmt.Errorf("illegal move: %s", move)
+	}
+	opts = opts.withDefaults()
+	position := game.Clone()
+	var info SearchInfo
+	before, err := evaluatePosition(ctx, position, opts, &info)
+	if err != nil {
+		return MoveAnalysis{}, false, err
+	}
+	ma, _, err := analyzeMove(ctx, position, move, before, opts, &info)
+	if err != nil {
+		return MoveAnalysis{}, false, err
+	}
+	ma.Ply = len(game.MoveHistory()) + 1
+	return ma, ma.Class == ClassMistake || ma.Class == ClassBlunder, nil
+}
@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestCheckMove(t *testing.T) {
+	game := playSAN(t, engine.NewGame(), "e4", "e5", "Qh5", "Nc6", "Bc4")
+	fen := game.ToFEN()
+
+	blunder, _ := game.MoveFromSAN("Nf6")
+	ma, flagged, err := CheckMove(context.Background(), game, blunder, AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("CheckMove: %v", err)
+	}
+	if !flagged || ma.Class != ClassBlunder || ma.SAN != "Nf6" || ma.Ply != 6 {
+		t.Errorf("expected Nf6 to be flagged as a blunder, got %+v", ma)
+	}
+	if len(ma.BestLine) == 0 || ma.BestLine[0] == "Nf6" || ma.EvalAfter-ma.EvalBefore < DefaultBlunderLoss {
+		t.Errorf("expected a better alternative and a large swing, got %+v", ma)
+	}
+	if game.ToFEN() != fen || len(game.MoveHistory()) != 5 {
+		t.Errorf("CheckMove modified the game")
+	}
+
+	defense, _ := game.MoveFromSAN("g6")
+	if ma, flagged, err := CheckMove(context.Background(), game, defense, AnalysisOptions{}); err != nil || flagged {
+		t.Errorf("expected g6 to pass the check, got %+v, %v", ma, err)
+	}
+
+	illegal, _ := game.ParseMove("e2e4")
+	if _, _, err := CheckMove(context.Background(), game, illegal, AnalysisOptions{}); err == nil {
+		t.Errorf("expected an error for an illegal move")
+	}
+}
@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"container/list"
+	"sync"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// EvalCache is an Evaluator that remembers the scores of the most recently
+// evaluated positions, keyed by Zobrist hash, and evaluates others with the
+// wrapped Evaluator. It is safe for concurrent use, so one cache can serve every
+// search on a server.
+type EvalCache struct {
+	evaluator Evaluator
+	size      int
+
+	mu      sync.Mutex
+	entries map[uint64]*list.Element
+	order   *list.List // most recently used first
+	hits    uint64
+	misses  uint64
+}
+
+// evalCacheEntry is a cached score.
+type evalCacheEntry struct {
+	hash  uint64
+	score int
+}
+
+// EvalCacheStats reports how well an EvalCache is doing.
+type EvalCacheStats struct {
+	Size   int    // positions held
+	Hits   uint64 // evaluations answered from the cache
+	Misses uint64 // evaluations passed to the wrapped Evaluator
+}
+
+// NewEvalCache caches the scores of up to size positions evaluated by evaluator;
+// a nil evaluator is ClassicalEvaluator.
+func NewEvalCache(evaluator Evaluator, size int) *EvalCache {
+	if evaluator == nil {
+		evaluator = ClassicalEvaluator
+	}
+	return &EvalCache{
+		evaluator: evaluator,
+		size:      max(size, 1),
+		entries:   make(map[uint64]*list.Element),
+		order:     list.New(),
+	}
+}
+
+// Evaluate returns the cached score of the position, evaluating and storing it
+// first if needed. The least recently used position makes room when the cache is
+// full.
+func (c *EvalCache) Evaluate(game *engine.Game) int {
+	hash := game.Hash()
+	c.mu.Lock()
+	if elem, ok := c.entries[hash]; ok {
+		c.order.MoveToFront(elem)
+		c.hits++
+		score := elem.Value.(*evalCacheEntry).score
+		c.mu.Unlock()
+		return score
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	// Evaluate outside the lock; a concurrent miss on the same position just
+	// stores the same score twice
+	score := c.evaluator.Evaluate(game)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[hash]; ok {
+		c.order.MoveToFront(elem)
+		return score
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*evalCacheEntry).hash)
+	}
+	c.entries[hash] = c.order.PushFront(&evalCacheEntry{hash: hash, score: score})
+	return score
+}
+
+// Evaluator returns the wrapped Evaluator.
+func (c *EvalCache) Evaluator() Evaluator {
+	return c.evaluator
+}
+
+// Stats returns the cache's size and hit counts.
+func (c *EvalCache) Stats() EvalCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return EvalCacheStats{Size: c.order.Len(), Hits: c.hits, Misses: c.misses}
+}
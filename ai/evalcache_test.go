@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestEvalCache(t *testing.T) {
+	calls := 0
+	cache := NewEvalCache(EvaluatorFunc(func(game *engine.Game) int {
+		calls++
+		return game.Evaluate()
+	}), 2)
+
+	start := engine.NewGame()
+	e4 := engine.NewGame()
+	if err := e4.MakeMove(mustUCI(t, e4, "e2e4")); err != nil {
+		t.Fatal(err)
+	}
+	d4 := engine.NewGame()
+	if err := d4.MakeMove(mustUCI(t, d4, "d2d4")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cache.Evaluate(start), start.Evaluate(); got != want {
+		t.Fatalf("expected the wrapped evaluation %d, got %d", want, got)
+	}
+	cache.Evaluate(engine.NewGame()) // same position, different game
+	if calls != 1 {
+		t.Fatalf("expected a cache hit, got %d evaluations", calls)
+	}
+
+	// The least recently used position is evicted when the cache is full
+	cache.Evaluate(e4)
+	cache.Evaluate(start)
+	cache.Evaluate(d4)
+	if calls != 3 {
+		t.Fatalf("expected 3 evaluations, got %d", calls)
+	}
+	cache.Evaluate(start)
+	cache.Evaluate(e4)
+	if calls != 4 {
+		t.Errorf("expected only 1.e4 to be evaluated again, got %d evaluations", calls)
+	}
+	if stats := cache.Stats(); stats.Size != 2 || stats.Hits != 3 || stats.Misses != 4 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestEvalCache_SharedBySearches(t *testing.T) {
+	cache := NewEvalCache(nil, 100000)
+	game := gameFromFEN(t, "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+
+	var wg sync.WaitGroup
+	moves := make([]engine.Move, 4)
+	for i := range moves {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			minimax := NewMinimaxAI(DifficultyMedium)
+			minimax.SetOpeningBook(nil)
+			minimax.SetEvaluator(cache)
+			minimax.strength.ErrorRate = 0
+			moves[i], _ = minimax.GetBestMove(context.Background(), game.Clone())
+		}(i)
+	}
+	wg.Wait()
+	for _, move := range moves[1:] {
+		if move != moves[0] {
+			t.Errorf("expected cached searches to agree, got %s and %s", moves[0].UCI(), move.UCI())
+		}
+	}
+	if stats := cache.Stats(); stats.Hits == 0 || stats.Misses == 0 {
+		t.Errorf("expected searches to share evaluations, got %+v", stats)
+	}
+}
@@ -0,0 +1,22 @@
+package ai
+
+import "go.rumenx.com/chess/engine"
+
+// Evaluator scores positions for the engines' searches. Scores are in centipawns
+// from White's perspective, like engine.Game.Evaluate; the searches take care of
+// the side to move. Implementations must not modify the game.
+type Evaluator interface {
+	Evaluate(game *engine.Game) int
+}
+
+// EvaluatorFunc adapts an ordinary function to the Evaluator interface.
+type EvaluatorFunc func(game *engine.Game) int
+
+// Evaluate calls f(game).
+func (f EvaluatorFunc) Evaluate(game *engine.Game) int {
+	return f(game)
+}
+
+// ClassicalEvaluator is the engine's handcrafted evaluation: material, central
+// activity, pawn structure and specialized endgame terms.
+var ClassicalEvaluator Evaluator = EvaluatorFunc((*engine.Game).Evaluate)
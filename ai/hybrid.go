@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// HybridEngine plays the moves of a MinimaxAI and talks through an LLM, so games
+// stay strong while keeping the LLM's conversational personality. Reactions and
+// chat come from the LLM, and explanations follow the search's expected line.
+type HybridEngine struct {
+	*MinimaxAI
+	llm *LLMAIEngine
+}
+
+// NewHybridEngine pairs minimax, which chooses the moves, with llm, which does the
+// talking.
+func NewHybridEngine(minimax *MinimaxAI, llm *LLMAIEngine) *HybridEngine {
+	return &HybridEngine{MinimaxAI: minimax, llm: llm}
+}
+
+// LLM returns the engine doing the talking.
+func (h *HybridEngine) LLM() *LLMAIEngine {
+	return h.llm
+}
+
+// SetDifficulty sets the playing strength and the LLM's tone alike.
+func (h *HybridEngine) SetDifficulty(difficulty Difficulty) {
+	h.MinimaxAI.SetDifficulty(difficulty)
+	h.llm.SetDifficulty(difficulty)
+}
+
+// Chat answers a message from the player.
+func (h *HybridEngine) Chat(ctx context.Context, message string, game *engine.Game) (string, error) {
+	return h.llm.Chat(ctx, message, game)
+}
+
+// ReactToMove generates a reaction to the player's move.
+func (h *HybridEngine) ReactToMove(ctx context.Context, move engine.Move, game *engine.Game) (string, error) {
+	return h.llm.ReactToMove(ctx, move, game)
+}
+
+// ExplainMove explains a move in plain language by its expected line pv. Without
+// a line the position is searched for one first.
+func (h *HybridEngine) ExplainMove(ctx context.Context, game *engine.Game, move engine.Move, pv []engine.Move) (string, error) {
+	if len(pv) == 0 || pv[0] != move {
+		if child := game.Clone(); child.MakeMove(move) == nil && !child.IsGameOver() {
+			if lines, _, err := h.Analyze(ctx, child, 1); err == nil && len(lines) > 0 {
+				pv = append([]engine.Move{move}, lines[0].Moves...)
+			}
+		}
+	}
+	return h.llm.ExplainMove(ctx, game, move, pv)
+}
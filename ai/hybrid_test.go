@@ -0,0 +1,54 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestHybridEngine(t *testing.T) {
+	var prompts []string
+	llm, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x", ChatEnabled: true})
+	llm.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(r.Body)
+		prompts = append(prompts, string(body))
+		reply := `{"choices":[{"message":{"content":"Bold, but I have seen it before."}}]}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(reply)), Header: make(http.Header)}, nil
+	})}
+	hybrid := NewHybridEngine(NewMinimaxAI(DifficultyMedium), llm)
+
+	// Moves come from the search, without asking the LLM
+	game := gameFromFEN(t, "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1")
+	move, info, err := GetBestMoveWithInfo(context.Background(), hybrid, game)
+	if err != nil || move.String() != "a1a8" || info.Depth == 0 {
+		t.Fatalf("expected the searched mate Ra8#, got %v at depth %d: %v", move, info.Depth, err)
+	}
+	if len(prompts) != 0 {
+		t.Fatalf("expected no LLM call for a move, got %d", len(prompts))
+	}
+
+	// Talking is left to the LLM
+	reaction, err := hybrid.ReactToMove(context.Background(), move, game)
+	if err != nil || reaction != "Bold, but I have seen it before." {
+		t.Errorf("expected the LLM's reaction, got %q: %v", reaction, err)
+	}
+
+	// Explanations without a line follow the engine's search
+	start := engine.NewGame()
+	e4, _ := start.MoveFromSAN("e4")
+	if _, err := hybrid.ExplainMove(context.Background(), start, e4, nil); err != nil {
+		t.Fatalf("ExplainMove: %v", err)
+	}
+	if last := prompts[len(prompts)-1]; !contains(last, "expecting the line: e4 ") {
+		t.Errorf("expected a searched line after e4 in the prompt, got %s", last)
+	}
+
+	hybrid.SetDifficulty(DifficultyExpert)
+	if hybrid.GetDifficulty() != DifficultyExpert || hybrid.LLM().GetDifficulty() != DifficultyExpert {
+		t.Error("expected the difficulty to reach both engines")
+	}
+}
@@ -0,0 +1,61 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// languages maps the ISO 639-1 codes of the languages LLM replies can be asked
+// in to their English names, which the prompts use.
+var languages = map[string]string{
+	"ar": "Arabic",
+	"bg": "Bulgarian",
+	"cs": "Czech",
+	"de": "German",
+	"el": "Greek",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ro": "Romanian",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// LanguageCode returns the ISO 639-1 code of language, given as a code, a tag
+// such as "pt-BR" or an English name such as "German", or "" if it is not
+// supported.
+func LanguageCode(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if base, _, found := strings.Cut(strings.ReplaceAll(language, "_", "-"), "-"); found {
+		language = base
+	}
+	if _, ok := languages[language]; ok {
+		return language
+	}
+	for code, name := range languages {
+		if strings.EqualFold(name, language) {
+			return code
+		}
+	}
+	return ""
+}
+
+// LanguageInstruction returns the prompt sentence asking for replies in language,
+// or "" for English and unsupported languages.
+func LanguageInstruction(language string) string {
+	code := LanguageCode(language)
+	if code == "" || code == "en" {
+		return ""
+	}
+	return fmt.Sprintf("Reply in %s, keeping moves in standard algebraic notation.", languages[code])
+}
@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLanguageCode(t *testing.T) {
+	tests := map[string]string{
+		"de":        "de",
+		"pt-BR":     "pt",
+		"zh_Hant":   "zh",
+		" German ":  "de",
+		"bulgarian": "bg",
+		"EN":        "en",
+		"Klingon":   "",
+		"":          "",
+	}
+	for language, want := range tests {
+		if got := LanguageCode(language); got != want {
+			t.Errorf("LanguageCode(%q) = %q, want %q", language, got, want)
+		}
+	}
+}
+
+func TestLanguageInstruction(t *testing.T) {
+	if got := LanguageInstruction("es"); !strings.Contains(got, "Spanish") {
+		t.Errorf("expected a Spanish instruction, got %q", got)
+	}
+	for _, language := range []string{"", "en", "Klingon"} {
+		if got := LanguageInstruction(language); got != "" {
+			t.Errorf("expected no instruction for %q, got %q", language, got)
+		}
+	}
+}
+
+func TestLLMAIEngineLanguage(t *testing.T) {
+	llm, err := NewLLMAIEngine(LLMConfig{Provider: ProviderOllama})
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := gameFromFEN(t, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")
+	english := llm.cacheKey("reaction", game, "e2e4")
+	if strings.Contains(llm.getChatSystemPrompt(), "Reply in") {
+		t.Error("expected no language instruction in English")
+	}
+
+	llm.SetLanguage("fr")
+	for name, prompt := range map[string]string{
+		"chat":        llm.getChatSystemPrompt(),
+		"reaction":    llm.getReactionSystemPrompt(),
+		"explanation": llm.getExplanationSystemPrompt(),
+		"summary":     llm.getSummarySystemPrompt(),
+	} {
+		if !strings.HasSuffix(prompt, "Reply in French, keeping moves in standard algebraic notation.") {
+			t.Errorf("expected the %s prompt to ask for French", name)
+		}
+	}
+	if strings.Contains(llm.getSystemPrompt(), "French") {
+		t.Error("expected the move prompt to stay in English")
+	}
+	if llm.cacheKey("reaction", game, "e2e4") == english {
+		t.Error("expected reactions in another language to be cached apart")
+	}
+	if llm.cacheKey("move", game, "") != (llmCacheKey{kind: "move", provider: ProviderOllama, model: llm.GetModel(), fen: game.ToFEN(), difficulty: llm.GetDifficulty(),
+		personality: llm.config.Personality, temperature: llm.getTemperatureForDifficulty()}) {
+		t.Error("expected moves to be cached whatever the language")
+	}
+}
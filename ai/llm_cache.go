@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// LLMCache remembers LLM answers by provider, model, position, difficulty,
+// personality and temperature for a while, so common positions such as
+// well-known openings are not paid for again.
+// It is safe for concurrent use, so one cache can serve every LLM engine on a
+// server; a nil *LLMCache caches nothing.
+type LLMCache struct {
+	ttl  time.Duration
+	size int
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[llmCacheKey]*list.Element
+	order   *list.List // most recently used first
+	hits    uint64
+	misses  uint64
+}
+
+// llmCacheKey identifies an answer. Move is set for reactions to a move.
+type llmCacheKey struct {
+	kind        string // "move", "reaction" or "chat_reaction"
+	provider    LLMProvider
+	model       string
+	fen         string
+	difficulty  Difficulty
+	move        string
+	language    string  // of reactions
+	personality string  // prompt of engine answers, preset name of chat reactions
+	temperature float64 // of engine answers
+}
+
+// ReactionKey identifies a chat personality's reaction to a move, in the
+// position after it, for a chat service sharing an LLMCache.
+type ReactionKey struct {
+	Provider    string
+	Model       string
+	Personality string
+	Language    string
+	FEN         string
+	Move        string
+}
+
+// llmCacheEntry is a cached answer.
+type llmCacheEntry struct {
+	key     llmCacheKey
+	answer  string
+	expires time.Time
+}
+
+// LLMCacheStats reports how well an LLMCache is doing.
+type LLMCacheStats struct {
+	Size   int    // answers held, including expired ones not yet evicted
+	Hits   uint64 // questions answered from the cache
+	Misses uint64 // questions passed to the provider
+}
+
+// NewLLMCache keeps up to size answers for ttl each.
+func NewLLMCache(ttl time.Duration, size int) *LLMCache {
+	return &LLMCache{
+		ttl:     ttl,
+		size:    max(size, 1),
+		now:     time.Now,
+		entries: make(map[llmCacheKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns a live cached answer.
+func (c *LLMCache) get(key llmCacheKey) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if ok && c.now().Before(elem.Value.(*llmCacheEntry).expires) {
+		c.order.MoveToFront(elem)
+		c.hits++
+		return elem.Value.(*llmCacheEntry).answer, true
+	}
+	if ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+	c.misses++
+	return "", false
+}
+
+// put stores an answer, making room by dropping the least recently used one when
+// the cache is full.
+func (c *LLMCache) put(key llmCacheKey, answer string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*llmCacheEntry)
+		entry.answer, entry.expires = answer, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*llmCacheEntry).key)
+	}
+	c.entries[key] = c.order.PushFront(&llmCacheEntry{key: key, answer: answer, expires: expires})
+}
+
+// Reaction returns a live cached chat reaction, as stored by PutReaction.
+func (c *LLMCache) Reaction(key ReactionKey) (string, bool) {
+	return c.get(key.cacheKey())
+}
+
+// PutReaction stores a chat reaction.
+func (c *LLMCache) PutReaction(key ReactionKey, reaction string) {
+	c.put(key.cacheKey(), reaction)
+}
+
+func (k ReactionKey) cacheKey() llmCacheKey {
+	return llmCacheKey{
+		kind:        "chat_reaction",
+		provider:    LLMProvider(k.Provider),
+		model:       k.Model,
+		fen:         k.FEN,
+		move:        k.Move,
+		language:    LanguageCode(k.Language),
+		personality: k.Personality,
+	}
+}
+
+// Stats returns the cache's size and hit counts.
+func (c *LLMCache) Stats() LLMCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return LLMCacheStats{Size: c.order.Len(), Hits: c.hits, Misses: c.misses}
+}
+
+// SetCache shares cache between engines; nil disables caching.
+func (ai *LLMAIEngine) SetCache(cache *LLMCache) {
+	ai.cache = cache
+}
+
+// cacheKey returns the key of a question of kind about the position, asked in
+// the engine's personality and at its temperature.
+func (ai *LLMAIEngine) cacheKey(kind string, game *engine.Game, move string) llmCacheKey {
+	key := llmCacheKey{
+		kind:        kind,
+		provider:    ai.config.Provider,
+		model:       ai.config.Model,
+		fen:         game.ToFEN(),
+		difficulty:  ai.config.Difficulty,
+		move:        move,
+		personality: ai.config.Personality,
+		temperature: ai.getTemperatureForDifficulty(),
+	}
+	if kind != "move" {
+		key.language = LanguageCode(ai.config.Language)
+	}
+	return key
+}
@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestLLMCache(t *testing.T) {
+	var requests int
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		body := `{"choices":[{"message":{"role":"assistant","content":"{\"from\":\"e2\",\"to\":\"e4\",\"promotion\":\"\"}"}}]}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(body)), Header: make(http.Header)}, nil
+	})}
+	cache := NewLLMCache(time.Hour, 2)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	newEngine := func(difficulty Difficulty) *LLMAIEngine {
+		ai, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x", Difficulty: difficulty, ChatEnabled: true})
+		ai.httpClient = client
+		ai.SetCache(cache)
+		return ai
+	}
+
+	// Another engine at the same level reuses the move
+	for i := 0; i < 2; i++ {
+		if mv, err := newEngine(DifficultyEasy).GetBestMove(context.Background(), engine.NewGame()); err != nil || mv.UCI() != "e2e4" {
+			t.Fatalf("expected e2e4, got %s: %v", mv.UCI(), err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected one request, got %d", requests)
+	}
+
+	// A different level is asked again, and the oldest answer makes room for it
+	if _, err := newEngine(DifficultyHard).GetBestMove(context.Background(), engine.NewGame()); err != nil || requests != 2 {
+		t.Errorf("expected a request for another level, got %d: %v", requests, err)
+	}
+	game := engine.NewGame()
+	mv, _ := game.ParseMove("e2e4")
+	easy := newEngine(DifficultyEasy)
+	for i := 0; i < 2; i++ {
+		if _, err := easy.ReactToMove(context.Background(), mv, game); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("expected one request for the reaction, got %d in all", requests)
+	}
+	if stats := cache.Stats(); stats.Size != 2 || stats.Hits != 2 || stats.Misses != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// Answers expire
+	now = now.Add(2 * time.Hour)
+	if _, err := easy.ReactToMove(context.Background(), mv, game); err != nil || requests != 4 {
+		t.Errorf("expected an expired reaction to be asked again, got %d requests: %v", requests, err)
+	}
+
+	// Without a cache every question is asked
+	easy.SetCache(nil)
+	if _, err := easy.ReactToMove(context.Background(), mv, game); err != nil || requests != 5 {
+		t.Errorf("expected no caching, got %d requests: %v", requests, err)
+	}
+
+	// Another personality or temperature is asked again
+	moody := newEngine(DifficultyEasy)
+	moody.SetPersonality(Personality{Name: "moody", Prompt: "a moody player", Temperature: StrengthForDifficulty(DifficultyEasy).Temperature, ReactionRate: 1})
+	if _, err := moody.ReactToMove(context.Background(), mv, game); err != nil || requests != 6 {
+		t.Errorf("expected a request for another personality, got %d: %v", requests, err)
+	}
+	hot := newEngine(DifficultyEasy)
+	hot.SetTemperature(1.5)
+	if _, err := hot.ReactToMove(context.Background(), mv, game); err != nil || requests != 7 {
+		t.Errorf("expected a request for another temperature, got %d: %v", requests, err)
+	}
+}
@@ -7,11 +7,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
 	"go.rumenx.com/chess/engine"
 )
 
@@ -29,8 +32,17 @@ const (
 	ProviderXAI LLMProvider = "xai"
 	// ProviderDeepSeek uses DeepSeek models.
 	ProviderDeepSeek LLMProvider = "deepseek"
+	// ProviderOllama uses local models served by Ollama, without an API key.
+	ProviderOllama LLMProvider = "ollama"
+	// ProviderAzure uses OpenAI models deployed on Azure; Model is the deployment name.
+	ProviderAzure LLMProvider = "azure"
+	// ProviderOpenRouter routes one key to many vendors' models, e.g. "openai/gpt-4o-mini".
+	ProviderOpenRouter LLMProvider = "openrouter"
 )
 
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when none is set.
+const DefaultAzureAPIVersion = "2024-10-21"
+
 // LLMConfig represents configuration for LLM-powered AI.
 type LLMConfig struct {
 	Provider    LLMProvider `json:"provider"`
@@ -40,13 +52,45 @@ type LLMConfig struct {
 	Difficulty  Difficulty  `json:"difficulty"`
 	Personality string      `json:"personality"`
 	ChatEnabled bool        `json:"chat_enabled"`
+	// APIVersion is the Azure OpenAI API version (DefaultAzureAPIVersion if empty).
+	APIVersion string `json:"api_version,omitempty"`
+	// Temperature overrides the difficulty's sampling temperature if set.
+	Temperature *float64 `json:"temperature,omitempty"`
+	// Retry governs retries and the circuit breaker of provider calls
+	// (DefaultRetryPolicy if zero).
+	Retry RetryPolicy `json:"retry"`
+	// MoveRetries is how often an illegal move is re-prompted before falling back to
+	// RandomAI: DefaultLLMMoveRetries if zero, none if negative.
+	MoveRetries int `json:"move_retries"`
+	// Language is the language of chat, reactions, explanations and summaries, as
+	// accepted by LanguageCode; English if empty.
+	Language string `json:"language,omitempty"`
+	// ReactionRate is the share of the player's moves reacted to, from 0 to 1;
+	// every move if nil.
+	ReactionRate *float64 `json:"reaction_rate,omitempty"`
+	// MaxTokens caps the length of an answer (DefaultLLMMaxTokens if zero).
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// Timeout bounds each attempt of a provider call, within the deadline of
+	// its context if sooner (DefaultLLMTimeout if zero).
+	Timeout time.Duration `json:"timeout,omitempty"`
 }
 
+// DefaultLLMMoveRetries is the number of re-prompts after an illegal move.
+const DefaultLLMMoveRetries = 2
+
+// Defaults for the answer length and call timeout of LLM providers.
+const (
+	DefaultLLMMaxTokens = 200
+	DefaultLLMTimeout   = 30 * time.Second
+)
+
 // LLMAIEngine implements an AI engine powered by Large Language Models.
 type LLMAIEngine struct {
 	config     LLMConfig
 	httpClient *http.Client
 	context    []ChatMessage
+	cache      *LLMCache // answers shared between engines, or nil
+	logger     LLMLogger // logs exchanges with the provider, or nil
 }
 
 // ChatMessage represents a message in the conversation.
@@ -61,6 +105,70 @@ type OpenAIRequest struct {
 	Messages    []ChatMessage `json:"messages"`
 	Temperature float64       `json:"temperature"`
 	MaxTokens   int           `json:"max_tokens"`
+	// ResponseFormat requests structured output; nil leaves the reply free text.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// ResponseFormat selects structured output on OpenAI-compatible APIs: "json_object"
+// for any JSON object, or "json_schema" for replies that match JSONSchema.
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is a named schema for structured output.
+type JSONSchema struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
+}
+
+// structuredMove is the JSON move the LLM is asked for, e.g.
+// {"from": "e7", "to": "e8", "promotion": "q"}.
+type structuredMove struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Promotion string `json:"promotion"`
+}
+
+// moveResponseFormat holds the LLM to a structuredMove. Strict schemas require
+// every property, so a move without promotion has an empty one.
+var moveResponseFormat = &ResponseFormat{
+	Type: "json_schema",
+	JSONSchema: &JSONSchema{
+		Name:   "chess_move",
+		Strict: true,
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"from":      map[string]any{"type": "string", "description": "Origin square, e.g. e2"},
+				"to":        map[string]any{"type": "string", "description": "Destination square, e.g. e4"},
+				"promotion": map[string]any{"type": "string", "enum": []string{"", "q", "r", "b", "n"}},
+			},
+			"required":             []string{"from", "to", "promotion"},
+			"additionalProperties": false,
+		},
+	},
+}
+
+// moveTool is the function the LLM calls with its move on Anthropic and Gemini,
+// whose schema dialects lack strict mode, so promotion is optional.
+var moveTool = struct {
+	Name        string
+	Description string
+	Parameters  map[string]any
+}{
+	Name:        "play_move",
+	Description: "Play a chess move from the list of legal moves",
+	Parameters: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"from":      map[string]any{"type": "string", "description": "Origin square, e.g. e2"},
+			"to":        map[string]any{"type": "string", "description": "Destination square, e.g. e4"},
+			"promotion": map[string]any{"type": "string", "description": "Promotion piece, one of q, r, b or n", "enum": []string{"q", "r", "b", "n"}},
+		},
+		"required": []string{"from", "to"},
+	},
 }
 
 // OpenAIResponse represents an OpenAI API response.
@@ -79,12 +187,31 @@ type AnthropicRequest struct {
 	Messages  []ChatMessage `json:"messages"`
 	MaxTokens int           `json:"max_tokens"`
 	System    string        `json:"system,omitempty"`
+	// Tools and ToolChoice force a tool call for structured output.
+	Tools      []AnthropicTool      `json:"tools,omitempty"`
+	ToolChoice *AnthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// AnthropicTool is a tool Anthropic models may call, with its input schema.
+type AnthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+// AnthropicToolChoice selects the tool to call: "tool" with Name forces it.
+type AnthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
 }
 
-// AnthropicResponse represents an Anthropic API response.
+// AnthropicResponse represents an Anthropic API response. Content blocks are
+// "text", or "tool_use" with the tool's Input.
 type AnthropicResponse struct {
 	Content []struct {
-		Text string `json:"text"`
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input,omitempty"`
 	} `json:"content"`
 	Error *struct {
 		Message string `json:"message"`
@@ -103,14 +230,43 @@ type GeminiRequest struct {
 		Temperature *float64 `json:"temperature,omitempty"`
 		MaxTokens   *int     `json:"maxOutputTokens,omitempty"`
 	} `json:"generationConfig,omitempty"`
+	// Tools and ToolConfig force a function call for structured output.
+	Tools      []GeminiTool      `json:"tools,omitempty"`
+	ToolConfig *GeminiToolConfig `json:"toolConfig,omitempty"`
+}
+
+// GeminiTool declares functions Gemini models may call.
+type GeminiTool struct {
+	FunctionDeclarations []GeminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+// GeminiFunctionDeclaration is a function with its parameter schema.
+type GeminiFunctionDeclaration struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// GeminiToolConfig restricts function calling: mode "ANY" requires a call to
+// one of AllowedFunctionNames.
+type GeminiToolConfig struct {
+	FunctionCallingConfig struct {
+		Mode                 string   `json:"mode"`
+		AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+	} `json:"functionCallingConfig"`
 }
 
-// GeminiResponse represents a Gemini API response.
+// GeminiResponse represents a Gemini API response. Parts hold text or a
+// FunctionCall with its arguments.
 type GeminiResponse struct {
 	Candidates []struct {
 		Content struct {
 			Parts []struct {
-				Text string `json:"text"`
+				Text         string `json:"text"`
+				FunctionCall *struct {
+					Name string          `json:"name"`
+					Args json.RawMessage `json:"args"`
+				} `json:"functionCall,omitempty"`
 			} `json:"parts"`
 		} `json:"content"`
 	} `json:"candidates"`
@@ -119,12 +275,43 @@ type GeminiResponse struct {
 	} `json:"error,omitempty"`
 }
 
+// OllamaRequest represents an Ollama chat request.
+type OllamaRequest struct {
+	Model    string         `json:"model"`
+	Messages []ChatMessage  `json:"messages"`
+	Stream   bool           `json:"stream"`
+	Format   any            `json:"format,omitempty"` // "json" or a JSON schema
+	Options  *OllamaOptions `json:"options,omitempty"`
+}
+
+// OllamaOptions holds the model parameters of an Ollama request.
+type OllamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict"`
+}
+
+// OllamaResponse represents an Ollama chat response.
+type OllamaResponse struct {
+	Message ChatMessage `json:"message"`
+	Error   string      `json:"error,omitempty"`
+}
+
 // NewLLMAIEngine creates a new LLM-powered AI engine.
 func NewLLMAIEngine(cfg LLMConfig) (*LLMAIEngine, error) {
-	if cfg.APIKey == "" && cfg.Provider != ProviderDeepSeek {
+	if cfg.APIKey == "" && cfg.Provider != ProviderDeepSeek && cfg.Provider != ProviderOllama {
 		return nil, fmt.Errorf("API key is required for provider %s", cfg.Provider)
 	}
 
+	// Azure has no shared endpoint or model: both name the customer's deployment
+	if cfg.Provider == ProviderAzure {
+		if cfg.Endpoint == "" || cfg.Model == "" {
+			return nil, fmt.Errorf("endpoint and deployment name are required for provider %s", cfg.Provider)
+		}
+		if cfg.APIVersion == "" {
+			cfg.APIVersion = DefaultAzureAPIVersion
+		}
+	}
+
 	// Set default endpoints
 	if cfg.Endpoint == "" {
 		switch cfg.Provider {
@@ -138,6 +325,10 @@ func NewLLMAIEngine(cfg LLMConfig) (*LLMAIEngine, error) {
 			cfg.Endpoint = "https://api.x.ai/v1/chat/completions"
 		case ProviderDeepSeek:
 			cfg.Endpoint = "https://api.deepseek.com/v1/chat/completions"
+		case ProviderOllama:
+			cfg.Endpoint = "http://localhost:11434"
+		case ProviderOpenRouter:
+			cfg.Endpoint = "https://openrouter.ai/api/v1/chat/completions"
 		}
 	}
 
@@ -154,6 +345,10 @@ func NewLLMAIEngine(cfg LLMConfig) (*LLMAIEngine, error) {
 			cfg.Model = "grok-beta"
 		case ProviderDeepSeek:
 			cfg.Model = "deepseek-chat"
+		case ProviderOllama:
+			cfg.Model = "llama3.2"
+		case ProviderOpenRouter:
+			cfg.Model = "openai/gpt-4o-mini"
 		}
 	}
 
@@ -161,10 +356,27 @@ func NewLLMAIEngine(cfg LLMConfig) (*LLMAIEngine, error) {
 		cfg.Personality = "a friendly but competitive chess player"
 	}
 
+	if cfg.MoveRetries == 0 {
+		cfg.MoveRetries = DefaultLLMMoveRetries
+	}
+
+	if cfg.Retry == (RetryPolicy{}) {
+		cfg.Retry = DefaultRetryPolicy()
+	}
+
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = DefaultLLMMaxTokens
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultLLMTimeout
+	}
+
 	return &LLMAIEngine{
 		config: cfg,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   cfg.Timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport), // traces provider calls
 		},
 		context: make([]ChatMessage, 0),
 	}, nil
@@ -172,32 +384,44 @@ func NewLLMAIEngine(cfg LLMConfig) (*LLMAIEngine, error) {
 
 // GetBestMove returns the best move using LLM analysis.
 func (ai *LLMAIEngine) GetBestMove(ctx context.Context, game *engine.Game) (engine.Move, error) {
+	// A position seen before gets the same move without asking again
+	key := ai.cacheKey("move", game, "")
+	if uci, ok := ai.cache.get(key); ok {
+		if move, err := game.ParseMove(uci); err == nil && game.IsLegalMove(move) {
+			return move, nil
+		}
+	}
+
 	// Generate prompt for the LLM
 	prompt := ai.generateChessPrompt(game)
 
-	// Ask the LLM for a move
-	response, err := ai.askLLM(ctx, prompt, ai.getSystemPrompt())
-	if err != nil {
-		// Fallback to RandomAI if LLM fails
-		randomAI := NewRandomAI()
-		randomAI.SetDifficulty(ai.config.Difficulty)
-		return randomAI.GetBestMove(ctx, game)
-	}
+	// Ask the LLM for a move, re-prompting with the reason and the legal moves while
+	// its answer is not a legal move
+	request := prompt
+	for attempt := 0; ; attempt++ {
+		response, err := ai.askMove(ctx, request)
+		if err != nil {
+			break
+		}
 
-	// Parse the move from LLM response
-	move, err := ai.parseMoveFromResponse(response, game)
-	if err != nil {
-		// Fallback to RandomAI if parsing fails
-		randomAI := NewRandomAI()
-		randomAI.SetDifficulty(ai.config.Difficulty)
-		return randomAI.GetBestMove(ctx, game)
+		move, err := ai.parseMoveFromResponse(response, game)
+		if err == nil {
+			// Add this interaction to context for future moves
+			ai.addToContext("user", prompt)
+			ai.addToContext("assistant", response)
+			ai.cache.put(key, move.UCI())
+			return move, nil
+		}
+		if attempt >= ai.config.MoveRetries || ctx.Err() != nil {
+			break
+		}
+		request = ai.generateRetryPrompt(prompt, response, err, game)
 	}
 
-	// Add this interaction to context for future moves
-	ai.addToContext("user", prompt)
-	ai.addToContext("assistant", response)
-
-	return move, nil
+	// Fallback to RandomAI if the LLM fails or keeps answering illegal moves
+	randomAI := NewRandomAI()
+	randomAI.SetDifficulty(ai.config.Difficulty)
+	return randomAI.GetBestMove(ctx, game)
 }
 
 // GetDifficulty returns the current difficulty level.
@@ -210,6 +434,47 @@ func (ai *LLMAIEngine) SetDifficulty(difficulty Difficulty) {
 	ai.config.Difficulty = difficulty
 }
 
+// SetModel switches the provider model, e.g. from gpt-4o-mini to gpt-4o.
+func (ai *LLMAIEngine) SetModel(model string) {
+	ai.config.Model = model
+}
+
+// SetMaxTokens caps the length of answers; DefaultLLMMaxTokens if not positive.
+func (ai *LLMAIEngine) SetMaxTokens(maxTokens int) {
+	if maxTokens <= 0 {
+		maxTokens = DefaultLLMMaxTokens
+	}
+	ai.config.MaxTokens = maxTokens
+}
+
+// SetTimeout bounds each attempt of a provider call; DefaultLLMTimeout if not
+// positive.
+func (ai *LLMAIEngine) SetTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultLLMTimeout
+	}
+	ai.config.Timeout = timeout
+	ai.httpClient.Timeout = timeout
+}
+
+// SetTemperature overrides the difficulty's sampling temperature.
+func (ai *LLMAIEngine) SetTemperature(temperature float64) {
+	ai.config.Temperature = &temperature
+}
+
+// SetLanguage sets the language the LLM talks in, e.g. "de" or "Spanish".
+func (ai *LLMAIEngine) SetLanguage(language string) {
+	ai.config.Language = language
+}
+
+// SetPersonality plays a personality preset: its prompt, temperature and
+// reaction rate replace the engine's.
+func (ai *LLMAIEngine) SetPersonality(p Personality) {
+	ai.config.Personality = p.Prompt
+	ai.SetTemperature(p.Temperature)
+	ai.config.ReactionRate = &p.ReactionRate
+}
+
 // Chat provides conversational interaction with the AI.
 func (ai *LLMAIEngine) Chat(ctx context.Context, message string, game *engine.Game) (string, error) {
 	if !ai.config.ChatEnabled {
@@ -219,7 +484,7 @@ func (ai *LLMAIEngine) Chat(ctx context.Context, message string, game *engine.Ga
 	// Create a chess-aware chat prompt
 	chatPrompt := ai.generateChatPrompt(message, game)
 
-	response, err := ai.askLLM(ctx, chatPrompt, ai.getChatSystemPrompt())
+	response, err := ai.ask(ctx, "chat", chatPrompt, ai.getChatSystemPrompt())
 	if err != nil {
 		return "Sorry, I'm having trouble responding right now.", err
 	}
@@ -232,10 +497,18 @@ func (ai *LLMAIEngine) ReactToMove(ctx context.Context, move engine.Move, game *
 	if !ai.config.ChatEnabled {
 		return "", nil
 	}
+	if rate := ai.config.ReactionRate; rate != nil && rand.Float64() >= *rate {
+		return "", nil
+	}
+
+	key := ai.cacheKey("reaction", game, move.UCI())
+	if reaction, ok := ai.cache.get(key); ok {
+		return reaction, nil
+	}
 
 	prompt := ai.generateReactionPrompt(move, game)
 
-	response, err := ai.askLLM(ctx, prompt, ai.getReactionSystemPrompt())
+	response, err := ai.ask(ctx, "reaction", prompt, ai.getReactionSystemPrompt())
 	if err != nil {
 		return "", err
 	}
@@ -243,9 +516,33 @@ func (ai *LLMAIEngine) ReactToMove(ctx context.Context, move engine.Move, game *
 	// Don't return empty reactions
 	response = strings.TrimSpace(response)
 	if response == "" || strings.ToLower(response) == "no reaction" {
-		return "", nil
+		response = ""
+	}
+
+	ai.cache.put(key, response)
+	return response, nil
+}
+
+// ExplainMove asks the LLM to justify an engine's choice of move in plain language.
+// pv is the engine's expected line starting with move, so the explanation follows
+// the search instead of the model's own analysis. The game is not modified.
+func (ai *LLMAIEngine) ExplainMove(ctx context.Context, game *engine.Game, move engine.Move, pv []engine.Move) (string, error) {
+	if len(pv) == 0 || pv[0] != move {
+		pv = []engine.Move{move}
+	}
+	prompt, err := ai.generateExplanationPrompt(game, pv)
+	if err != nil {
+		return "", err
 	}
 
+	response, err := ai.ask(ctx, "explanation", prompt, ai.getExplanationSystemPrompt())
+	if err != nil {
+		return "", err
+	}
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return "", fmt.Errorf("empty explanation from %s", ai.config.Provider)
+	}
 	return response, nil
 }
 
@@ -254,22 +551,61 @@ func (ai *LLMAIEngine) GetProvider() LLMProvider {
 	return ai.config.Provider
 }
 
+// GetModel returns the model being used.
+func (ai *LLMAIEngine) GetModel() string {
+	return ai.config.Model
+}
+
 // askLLM sends a request to the configured LLM provider.
 func (ai *LLMAIEngine) askLLM(ctx context.Context, message, systemPrompt string) (string, error) {
 	switch ai.config.Provider {
-	case ProviderOpenAI, ProviderXAI, ProviderDeepSeek:
-		return ai.askOpenAICompatible(ctx, message, systemPrompt)
+	case ProviderOpenAI, ProviderXAI, ProviderDeepSeek, ProviderAzure, ProviderOpenRouter:
+		return ai.askOpenAICompatible(ctx, message, systemPrompt, nil)
 	case ProviderAnthropic:
-		return ai.askAnthropic(ctx, message, systemPrompt)
+		return ai.askAnthropic(ctx, message, systemPrompt, nil)
 	case ProviderGemini:
-		return ai.askGemini(ctx, message, systemPrompt)
+		return ai.askGemini(ctx, message, systemPrompt, nil)
+	case ProviderOllama:
+		return ai.askOllama(ctx, message, systemPrompt, nil)
 	default:
 		return "", fmt.Errorf("unsupported provider: %s", ai.config.Provider)
 	}
 }
 
-// askOpenAICompatible sends a request to OpenAI-compatible APIs.
-func (ai *LLMAIEngine) askOpenAICompatible(ctx context.Context, message, systemPrompt string) (string, error) {
+// askMove asks the LLM for a move as a structuredMove. OpenAI (also on Azure and
+// OpenRouter), xAI and Ollama are held to the move schema, Anthropic and Gemini
+// must call the move tool and DeepSeek, which has no schemas, must reply in JSON.
+func (ai *LLMAIEngine) askMove(ctx context.Context, prompt string) (string, error) {
+	systemPrompt := ai.getSystemPrompt()
+	return ai.logged(ctx, "move", prompt, systemPrompt, func() (string, error) {
+		switch ai.config.Provider {
+		case ProviderOpenAI, ProviderXAI, ProviderAzure, ProviderOpenRouter:
+			return ai.askOpenAICompatible(ctx, prompt, systemPrompt, moveResponseFormat)
+		case ProviderDeepSeek:
+			return ai.askOpenAICompatible(ctx, prompt, systemPrompt, &ResponseFormat{Type: "json_object"})
+		case ProviderOllama:
+			return ai.askOllama(ctx, prompt, systemPrompt, moveResponseFormat.JSONSchema.Schema)
+		case ProviderAnthropic:
+			return ai.askAnthropic(ctx, prompt, systemPrompt, &AnthropicTool{
+				Name:        moveTool.Name,
+				Description: moveTool.Description,
+				InputSchema: moveTool.Parameters,
+			})
+		case ProviderGemini:
+			return ai.askGemini(ctx, prompt, systemPrompt, &GeminiFunctionDeclaration{
+				Name:        moveTool.Name,
+				Description: moveTool.Description,
+				Parameters:  moveTool.Parameters,
+			})
+		default:
+			return ai.askLLM(ctx, prompt, systemPrompt)
+		}
+	})
+}
+
+// askOpenAICompatible sends a request to OpenAI-compatible APIs, with structured
+// output if format is set.
+func (ai *LLMAIEngine) askOpenAICompatible(ctx context.Context, message, systemPrompt string, format *ResponseFormat) (string, error) {
 	messages := []ChatMessage{
 		{Role: "system", Content: systemPrompt},
 	}
@@ -279,10 +615,11 @@ func (ai *LLMAIEngine) askOpenAICompatible(ctx context.Context, message, systemP
 	messages = append(messages, ChatMessage{Role: "user", Content: message})
 
 	request := OpenAIRequest{
-		Model:       ai.config.Model,
-		Messages:    messages,
-		Temperature: ai.getTemperatureForDifficulty(),
-		MaxTokens:   200,
+		Model:          ai.config.Model,
+		Messages:       messages,
+		Temperature:    ai.getTemperatureForDifficulty(),
+		MaxTokens:      ai.config.MaxTokens,
+		ResponseFormat: format,
 	}
 
 	reqBody, err := json.Marshal(request)
@@ -290,15 +627,25 @@ func (ai *LLMAIEngine) askOpenAICompatible(ctx context.Context, message, systemP
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", ai.config.Endpoint, bytes.NewBuffer(reqBody))
+	url := ai.config.Endpoint
+	if ai.config.Provider == ProviderAzure {
+		// Azure routes by deployment rather than by the model in the body
+		url = fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+			strings.TrimSuffix(ai.config.Endpoint, "/"), ai.config.Model, ai.config.APIVersion)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+ai.config.APIKey)
+	if ai.config.Provider == ProviderAzure {
+		httpReq.Header.Set("api-key", ai.config.APIKey)
+	} else {
+		httpReq.Header.Set("Authorization", "Bearer "+ai.config.APIKey)
+	}
 
-	resp, err := ai.httpClient.Do(httpReq)
+	resp, err := ai.doRequest(httpReq)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
@@ -325,8 +672,9 @@ func (ai *LLMAIEngine) askOpenAICompatible(ctx context.Context, message, systemP
 	return response.Choices[0].Message.Content, nil
 }
 
-// askAnthropic sends a request to Anthropic's API.
-func (ai *LLMAIEngine) askAnthropic(ctx context.Context, message, systemPrompt string) (string, error) {
+// askAnthropic sends a request to Anthropic's API. If tool is set the model must
+// call it, and its input is returned as JSON.
+func (ai *LLMAIEngine) askAnthropic(ctx context.Context, message, systemPrompt string, tool *AnthropicTool) (string, error) {
 	messages := []ChatMessage{}
 
 	// Add conversation history
@@ -336,9 +684,13 @@ func (ai *LLMAIEngine) askAnthropic(ctx context.Context, message, systemPrompt s
 	request := AnthropicRequest{
 		Model:     ai.config.Model,
 		Messages:  messages,
-		MaxTokens: 200,
+		MaxTokens: ai.config.MaxTokens,
 		System:    systemPrompt,
 	}
+	if tool != nil {
+		request.Tools = []AnthropicTool{*tool}
+		request.ToolChoice = &AnthropicToolChoice{Type: "tool", Name: tool.Name}
+	}
 
 	reqBody, err := json.Marshal(request)
 	if err != nil {
@@ -354,7 +706,7 @@ func (ai *LLMAIEngine) askAnthropic(ctx context.Context, message, systemPrompt s
 	httpReq.Header.Set("x-api-key", ai.config.APIKey)
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
 
-	resp, err := ai.httpClient.Do(httpReq)
+	resp, err := ai.doRequest(httpReq)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
@@ -378,11 +730,19 @@ func (ai *LLMAIEngine) askAnthropic(ctx context.Context, message, systemPrompt s
 		return "", fmt.Errorf("no response from API")
 	}
 
+	if tool != nil {
+		for _, block := range response.Content {
+			if block.Type == "tool_use" && len(block.Input) > 0 {
+				return string(block.Input), nil
+			}
+		}
+	}
 	return response.Content[0].Text, nil
 }
 
-// askGemini sends a request to Google's Gemini API.
-func (ai *LLMAIEngine) askGemini(ctx context.Context, message, systemPrompt string) (string, error) {
+// askGemini sends a request to Google's Gemini API. If function is set the model
+// must call it, and its arguments are returned as JSON.
+func (ai *LLMAIEngine) askGemini(ctx context.Context, message, systemPrompt string, function *GeminiFunctionDeclaration) (string, error) {
 	// Combine system prompt with message for Gemini
 	fullPrompt := fmt.Sprintf("%s\n\n%s", systemPrompt, message)
 
@@ -407,9 +767,15 @@ func (ai *LLMAIEngine) askGemini(ctx context.Context, message, systemPrompt stri
 			MaxTokens   *int     `json:"maxOutputTokens,omitempty"`
 		}{
 			Temperature: &[]float64{ai.getTemperatureForDifficulty()}[0],
-			MaxTokens:   &[]int{200}[0],
+			MaxTokens:   &[]int{ai.config.MaxTokens}[0],
 		},
 	}
+	if function != nil {
+		request.Tools = []GeminiTool{{FunctionDeclarations: []GeminiFunctionDeclaration{*function}}}
+		request.ToolConfig = &GeminiToolConfig{}
+		request.ToolConfig.FunctionCallingConfig.Mode = "ANY"
+		request.ToolConfig.FunctionCallingConfig.AllowedFunctionNames = []string{function.Name}
+	}
 
 	reqBody, err := json.Marshal(request)
 	if err != nil {
@@ -424,7 +790,7 @@ func (ai *LLMAIEngine) askGemini(ctx context.Context, message, systemPrompt stri
 
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := ai.httpClient.Do(httpReq)
+	resp, err := ai.doRequest(httpReq)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
@@ -448,15 +814,80 @@ func (ai *LLMAIEngine) askGemini(ctx context.Context, message, systemPrompt stri
 		return "", fmt.Errorf("no response from API")
 	}
 
+	if function != nil {
+		for _, part := range response.Candidates[0].Content.Parts {
+			if part.FunctionCall != nil && len(part.FunctionCall.Args) > 0 {
+				return string(part.FunctionCall.Args), nil
+			}
+		}
+	}
 	return response.Candidates[0].Content.Parts[0].Text, nil
 }
 
+// askOllama sends a request to an Ollama server's chat API, constraining the reply
+// to format ("json" or a JSON schema) if set.
+func (ai *LLMAIEngine) askOllama(ctx context.Context, message, systemPrompt string, format any) (string, error) {
+	messages := []ChatMessage{
+		{Role: "system", Content: systemPrompt},
+	}
+
+	// Add conversation history
+	messages = append(messages, ai.context...)
+	messages = append(messages, ChatMessage{Role: "user", Content: message})
+
+	request := OllamaRequest{
+		Model:    ai.config.Model,
+		Messages: messages,
+		Format:   format,
+		Options: &OllamaOptions{
+			Temperature: ai.getTemperatureForDifficulty(),
+			NumPredict:  ai.config.MaxTokens,
+		},
+	}
+
+	reqBody, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimSuffix(ai.config.Endpoint, "/") + "/api/chat"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := ai.doRequest(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var response OllamaResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if response.Error != "" {
+		return "", fmt.Errorf("API error: %s", response.Error)
+	}
+
+	if response.Message.Content == "" {
+		return "", fmt.Errorf("no response from API")
+	}
+
+	return response.Message.Content, nil
+}
+
 // getSystemPrompt returns the system prompt for chess move generation.
 func (ai *LLMAIEngine) getSystemPrompt() string {
-	personalityContext := ""
-	if ai.config.Personality != "" {
-		personalityContext = fmt.Sprintf("You have the personality of: %s. ", ai.config.Personality)
-	}
+	personalityContext := ai.personalityContext()
 
 	difficultyContext := ""
 	switch ai.config.Difficulty {
@@ -472,31 +903,24 @@ func (ai *LLMAIEngine) getSystemPrompt() string {
 		difficultyContext = "Play at an expert level with excellent tactical and strategic understanding. "
 	}
 
-	return fmt.Sprintf(`You are a chess AI opponent. %s%sYour task is to analyze the chess position and suggest the best move in algebraic notation.
+	return fmt.Sprintf(`You are a chess AI opponent. %s%sYour task is to analyze the chess position and suggest the best move.
 
 IMPORTANT RULES:
-1. Respond ONLY with a valid chess move in algebraic notation (e.g., "e4", "Nf3", "O-O", "Qxd5")
+1. Respond ONLY with a JSON object of the form {"from": "g1", "to": "f3", "promotion": ""}
 2. Do not include explanations, commentary, or extra text
-3. Ensure the move is legal in the current position
+3. Choose one of the legal moves listed in the position
 4. Consider the difficulty level in your move selection
-5. If you cannot determine a good move, respond with "random"
 
-The move should be in standard algebraic notation:
-- Pawn moves: e4, d5, exd5
-- Piece moves: Nf3, Bd3, Qh5
-- Castling: O-O (kingside), O-O-O (queenside)
-- Captures: Nxf3, Qxd5
-- Promotion: e8=Q
-- Check: Nf3+
-- Checkmate: Qh7#`, personalityContext, difficultyContext)
+The move is given by its squares:
+- "from": the square of the piece that moves, e.g. "e2"
+- "to": the square it moves to, e.g. "e4"
+- "promotion": "q", "r", "b" or "n" when a pawn promotes, otherwise ""
+- Castling is the king's move: {"from": "e1", "to": "g1", "promotion": ""}`, personalityContext, difficultyContext)
 }
 
 // getChatSystemPrompt returns the system prompt for chat interactions.
 func (ai *LLMAIEngine) getChatSystemPrompt() string {
-	personalityContext := ""
-	if ai.config.Personality != "" {
-		personalityContext = fmt.Sprintf("You have the personality of: %s. ", ai.config.Personality)
-	}
+	personalityContext := ai.personalityContext()
 
 	return fmt.Sprintf(`You are a chess AI opponent that can chat with players. %sYou are knowledgeable about chess, friendly, and engaging. You can:
 
@@ -506,22 +930,68 @@ func (ai *LLMAIEngine) getChatSystemPrompt() string {
 4. React to impressive or interesting moves
 5. Provide encouragement and maintain good sportsmanship
 
-Keep responses conversational, helpful, and appropriate for a chess game setting.`, personalityContext)
+Keep responses conversational, helpful, and appropriate for a chess game setting.`, personalityContext) + ai.languageSuffix()
+}
+
+// personalityContext returns the sentence giving the engine its personality, or
+// "" without one.
+func (ai *LLMAIEngine) personalityContext() string {
+	if ai.config.Personality == "" {
+		return ""
+	}
+	return fmt.Sprintf("You have the personality of: %s. ", ai.config.Personality)
 }
 
 // getReactionSystemPrompt returns the system prompt for move reactions.
 func (ai *LLMAIEngine) getReactionSystemPrompt() string {
-	return `You are a chess AI that reacts to moves made by your opponent. Provide brief, engaging reactions that show your chess understanding. Examples:
+	return `You are a chess AI that reacts to moves made by your opponent. ` + ai.personalityContext() + `Provide brief, engaging reactions that show your chess understanding. Examples:
 
 - For good moves: "Nice move!", "I didn't see that coming!", "Clever!"
 - For brilliant moves: "Wow! Brilliant!", "Outstanding!", "That's a beautiful move!"
 - For mistakes: "Interesting choice...", "Hmm, that gives me an opportunity"
 - For blunders: "Thank you for that!", "I'll take advantage of that"
 
-Keep reactions short (1-10 words), appropriate, and show chess personality. If the move is ordinary, respond with "no reaction" or leave empty.`
+Keep reactions short (1-10 words), appropriate, and show chess personality. If the move is ordinary, respond with "no reaction" or leave empty.` + ai.languageSuffix()
+}
+
+// getExplanationSystemPrompt returns the system prompt for move explanations.
+func (ai *LLMAIEngine) getExplanationSystemPrompt() string {
+	return `You are a chess coach explaining a move chosen by a chess engine. The engine's expected continuation is given; trust it rather than your own analysis. In two or three plain sentences, say what the move achieves and what the main line shows (threats, tactics, piece activity, king safety or endgame plans). Use standard algebraic notation, no lists, no headings, and do not suggest other moves.` + ai.languageSuffix()
+}
+
+// languageSuffix returns the instruction appended to the system prompts of text
+// replies when the engine talks in another language than English.
+func (ai *LLMAIEngine) languageSuffix() string {
+	if instruction := LanguageInstruction(ai.config.Language); instruction != "" {
+		return "\n\n" + instruction
+	}
+	return ""
+}
+
+// generateExplanationPrompt creates a prompt asking why the first move of pv is best.
+func (ai *LLMAIEngine) generateExplanationPrompt(game *engine.Game, pv []engine.Move) (string, error) {
+	line, err := game.SANLine(pv)
+	if err != nil {
+		return "", fmt.Errorf("invalid line to explain: %w", err)
+	}
+
+	activeColor := "White"
+	if game.ActiveColor() == engine.Black {
+		activeColor = "Black"
+	}
+
+	return fmt.Sprintf(`Current position (FEN %s):
+
+%s
+
+%s to move. The engine plays %s, expecting the line: %s
+
+Explain why %s is the best move:`, game.ToFEN(), ai.boardToString(game.Board()), activeColor, line[0], strings.Join(line, " "), line[0]), nil
 }
 
-// generateChessPrompt creates a prompt for chess move generation.
+// generateChessPrompt creates a prompt for chess move generation: the position as
+// FEN and as a board, recent moves, check status, the static evaluation and every
+// legal move, so the model chooses among legal moves rather than guessing.
 func (ai *LLMAIEngine) generateChessPrompt(game *engine.Game) string {
 	board := game.Board()
 
@@ -549,13 +1019,49 @@ func (ai *LLMAIEngine) generateChessPrompt(game *engine.Game) string {
 		activeColor = "Black"
 	}
 
+	checkString := ""
+	if game.Status() == engine.Check {
+		checkString = fmt.Sprintf("%s is in check.\n", activeColor)
+	}
+
+	eval := float64(ClassicalEvaluator.Evaluate(game)) / 100
+
 	return fmt.Sprintf(`Current chess position:
 
+FEN: %s
+
 %s
 
 %sActive color: %s
+%sStatic evaluation: %+.2f pawns (positive favours White)
+Legal moves: %s
 
-Provide your move in algebraic notation:`, boardString, historyString, activeColor)
+Provide your move as JSON:`, game.ToFEN(), boardString, historyString, activeColor, checkString, eval, formatLegalMoves(game))
+}
+
+// formatLegalMoves lists the legal moves by their squares with SAN, e.g.
+// "e2e4 (e4), g1f3 (Nf3)".
+func formatLegalMoves(game *engine.Game) string {
+	legal := game.GenerateLegalMoves(nil)
+	moves := make([]string, len(legal))
+	for i, move := range legal {
+		moves[i] = move.UCI()
+		if san, err := game.SANLine([]engine.Move{move}); err == nil {
+			moves[i] += " (" + san[0] + ")"
+		}
+	}
+	return strings.Join(moves, ", ")
+}
+
+// generateRetryPrompt repeats a move prompt after a rejected answer, with the
+// reason and the legal moves to choose from.
+func (ai *LLMAIEngine) generateRetryPrompt(prompt, response string, reason error, game *engine.Game) string {
+	return fmt.Sprintf(`%s
+
+Your answer %s was rejected: %v.
+The legal moves are: %s
+
+Provide one of them as JSON:`, prompt, strings.TrimSpace(response), reason, formatLegalMoves(game))
 }
 
 // generateChatPrompt creates a prompt for chat interactions.
@@ -580,52 +1086,16 @@ Provide a brief reaction to this move (or respond with "no reaction" if it's not
 
 // boardToString converts a chess board to a string representation.
 func (ai *LLMAIEngine) boardToString(board *engine.Board) string {
-	var sb strings.Builder
-
-	sb.WriteString("  a b c d e f g h\n")
-	for rank := 7; rank >= 0; rank-- {
-		sb.WriteString(fmt.Sprintf("%d ", rank+1))
-		for file := 0; file < 8; file++ {
-			square := engine.Square(rank*8 + file)
-			piece := board.GetPiece(square)
-
-			if piece.IsEmpty() {
-				sb.WriteString(". ")
-			} else {
-				symbol := ai.pieceToSymbol(piece)
-				sb.WriteString(symbol + " ")
-			}
-		}
-		sb.WriteString(fmt.Sprintf("%d\n", rank+1))
-	}
-	sb.WriteString("  a b c d e f g h")
-
-	return sb.String()
-}
-
-// pieceToSymbol converts a piece to its symbol representation.
-func (ai *LLMAIEngine) pieceToSymbol(piece engine.Piece) string {
-	symbols := map[engine.PieceType]string{
-		engine.King:   "K",
-		engine.Queen:  "Q",
-		engine.Rook:   "R",
-		engine.Bishop: "B",
-		engine.Knight: "N",
-		engine.Pawn:   "P",
-	}
-
-	symbol := symbols[piece.Type]
-	if piece.Color == engine.Black {
-		symbol = strings.ToLower(symbol)
-	}
-
-	return symbol
+	return strings.TrimSuffix(engine.NewBoardRenderer(engine.RenderOptions{Coordinates: true}).Render(board), "\n")
 }
 
 // parseMoveFromResponse extracts a chess move from LLM response.
 func (ai *LLMAIEngine) parseMoveFromResponse(response string, game *engine.Game) (engine.Move, error) {
 	// Clean the response
 	response = strings.TrimSpace(response)
+	if move, ok, err := parseStructuredMove(response, game); ok {
+		return move, err
+	}
 	response = strings.Trim(response, "\"'")
 
 	// If the LLM says "random", use random move
@@ -649,17 +1119,48 @@ func (ai *LLMAIEngine) parseMoveFromResponse(response string, game *engine.Game)
 		line = strings.TrimSuffix(line, "!")
 		line = strings.TrimSuffix(line, "?")
 
-		// Try to parse this as a move
+		// Try to parse this as a move, in coordinates or SAN
 		move, err := game.ParseMove(line)
 		if err == nil && game.IsLegalMove(move) {
 			return move, nil
 		}
+		move, err = game.MoveFromSAN(line)
+		if err == nil && game.IsLegalMove(move) {
+			return move, nil
+		}
 	}
 
 	// If we can't parse any move, return error
 	return engine.Move{}, fmt.Errorf("could not parse move from response: %s", response)
 }
 
+// parseStructuredMove resolves a structuredMove reply, which may be wrapped in a
+// code fence or surrounding text. ok is false if the response holds no such JSON
+// object; otherwise err tells why the move is not legal.
+func parseStructuredMove(response string, game *engine.Game) (move engine.Move, ok bool, err error) {
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return engine.Move{}, false, nil
+	}
+	var sm structuredMove
+	if json.Unmarshal([]byte(response[start:end+1]), &sm) != nil || sm.From == "" || sm.To == "" {
+		return engine.Move{}, false, nil
+	}
+
+	notation := strings.ToLower(strings.TrimSpace(sm.From) + strings.TrimSpace(sm.To) + strings.TrimSpace(sm.Promotion))
+	move, err = game.ParseMove(notation)
+	if err != nil {
+		return engine.Move{}, true, fmt.Errorf("invalid move %s: %w", notation, err)
+	}
+	if err := game.ExplainIllegalMove(move); err != nil {
+		return engine.Move{}, true, err
+	}
+	return move, true, nil
+}
+
+// maxContextMessages is the number of messages the conversation context keeps.
+const maxContextMessages = 10
+
 // addToContext adds a message to the conversation context.
 func (ai *LLMAIEngine) addToContext(role, content string) {
 	ai.context = append(ai.context, ChatMessage{
@@ -667,28 +1168,35 @@ func (ai *LLMAIEngine) addToContext(role, content string) {
 		Content: content,
 	})
 
-	// Keep context manageable (last 10 messages)
-	if len(ai.context) > 10 {
-		ai.context = ai.context[len(ai.context)-10:]
+	// Keep context manageable
+	if len(ai.context) > maxContextMessages {
+		ai.context = ai.context[len(ai.context)-maxContextMessages:]
 	}
 }
 
-// getTemperatureForDifficulty returns appropriate temperature for difficulty level.
+// History returns a copy of the conversation context, the exchanges the engine
+// remembers from its earlier moves, e.g. to persist it.
+func (ai *LLMAIEngine) History() []ChatMessage {
+	return append([]ChatMessage(nil), ai.context...)
+}
+
+// SetHistory restores a conversation context returned by History, keeping its
+// last messages.
+func (ai *LLMAIEngine) SetHistory(messages []ChatMessage) {
+	if len(messages) > maxContextMessages {
+		messages = messages[len(messages)-maxContextMessages:]
+	}
+	ai.context = append(make([]ChatMessage, 0, len(messages)), messages...)
+}
+
+// getTemperatureForDifficulty returns the calibrated sampling temperature for the
+// difficulty level: higher at lower levels for more creative, error-prone play.
+// An explicit Temperature takes precedence.
 func (ai *LLMAIEngine) getTemperatureForDifficulty() float64 {
-	switch ai.config.Difficulty {
-	case DifficultyBeginner:
-		return 1.2 // High creativity, more mistakes
-	case DifficultyEasy:
-		return 0.9 // Some creativity
-	case DifficultyMedium:
-		return 0.7 // Balanced
-	case DifficultyHard:
-		return 0.5 // More focused
-	case DifficultyExpert:
-		return 0.3 // Very focused
-	default:
-		return 0.7
+	if ai.config.Temperature != nil {
+		return *ai.config.Temperature
 	}
+	return StrengthForDifficulty(ai.config.Difficulty).Temperature
 }
 
 // NewLLMAIFromEnv creates an LLM AI engine from environment variables.
@@ -707,13 +1215,21 @@ func NewLLMAIFromEnv(provider string, difficulty Difficulty) (*LLMAIEngine, erro
 		envVar = "XAI_API_KEY"
 	case ProviderDeepSeek:
 		envVar = "DEEPSEEK_API_KEY"
+	case ProviderAzure:
+		envVar = "AZURE_OPENAI_API_KEY"
+	case ProviderOpenRouter:
+		envVar = "OPENROUTER_API_KEY"
+	case ProviderOllama:
+		// Local models need no key, only the server and model to use
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", provider)
 	}
 
-	apiKey = os.Getenv(envVar)
-	if apiKey == "" {
-		return nil, fmt.Errorf("environment variable %s is required for provider %s", envVar, provider)
+	if envVar != "" {
+		apiKey = os.Getenv(envVar)
+		if apiKey == "" {
+			return nil, fmt.Errorf("environment variable %s is required for provider %s", envVar, provider)
+		}
 	}
 
 	cfg := LLMConfig{
@@ -723,6 +1239,17 @@ func NewLLMAIFromEnv(provider string, difficulty Difficulty) (*LLMAIEngine, erro
 		ChatEnabled: true,
 		Personality: "a friendly but competitive chess player",
 	}
+	switch cfg.Provider {
+	case ProviderOllama:
+		cfg.Endpoint = os.Getenv("OLLAMA_ENDPOINT")
+		cfg.Model = os.Getenv("OLLAMA_MODEL")
+	case ProviderAzure:
+		cfg.Endpoint = os.Getenv("AZURE_OPENAI_ENDPOINT")
+		cfg.Model = os.Getenv("AZURE_OPENAI_DEPLOYMENT")
+		cfg.APIVersion = os.Getenv("AZURE_OPENAI_API_VERSION")
+	case ProviderOpenRouter:
+		cfg.Model = os.Getenv("OPENROUTER_MODEL")
+	}
 
 	return NewLLMAIEngine(cfg)
 }
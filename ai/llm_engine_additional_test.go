@@ -3,6 +3,7 @@ package ai
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"testing"
@@ -94,6 +95,83 @@ func TestLLMAIEngine_askGemini(t *testing.T) {
 	}
 }
 
+func TestLLMAIEngine_askOllama(t *testing.T) {
+	t.Setenv("OLLAMA_ENDPOINT", "http://gpu-box:11434/")
+	t.Setenv("OLLAMA_MODEL", "qwen2.5")
+	ai, err := NewLLMAIFromEnv("ollama", DifficultyMedium)
+	if err != nil {
+		t.Fatalf("expected Ollama without a key, got %v", err)
+	}
+
+	var request OllamaRequest
+	var url, auth string
+	ai.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		url, auth = r.URL.String(), r.Header.Get("Authorization")
+		request = OllamaRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		body := `{"message":{"role":"assistant","content":"{\"from\":\"d2\",\"to\":\"d4\",\"promotion\":\"\"}"},"done":true}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(body)), Header: make(http.Header)}, nil
+	})}
+
+	mv, err := ai.GetBestMove(context.Background(), engine.NewGame())
+	if err != nil || mv.UCI() != "d2d4" {
+		t.Fatalf("expected d2d4, got %s: %v", mv.UCI(), err)
+	}
+	if url != "http://gpu-box:11434/api/chat" || auth != "" {
+		t.Errorf("expected an unauthenticated chat request to the configured server, got %s (%q)", url, auth)
+	}
+	if request.Model != "qwen2.5" || request.Stream || request.Format == nil {
+		t.Errorf("expected a non-streaming request for the move schema, got %+v", request)
+	}
+
+	// Chat stays free text
+	if _, err := ai.Chat(context.Background(), "Hello", engine.NewGame()); err != nil || request.Format != nil {
+		t.Errorf("expected a free-text chat, got format %v: %v", request.Format, err)
+	}
+
+	ai.httpClient = newMockClient(`{"error":"model 'qwen2.5' not found"}`, 404)
+	if _, err := ai.askLLM(context.Background(), "test", ai.getChatSystemPrompt()); err == nil || !contains(err.Error(), "not found") {
+		t.Errorf("expected the server's error, got %v", err)
+	}
+}
+
+func TestLLMAIEngine_AzureAndOpenRouter(t *testing.T) {
+	if _, err := NewLLMAIEngine(LLMConfig{Provider: ProviderAzure, APIKey: "x", Model: "chess-gpt"}); err == nil {
+		t.Errorf("expected Azure to require an endpoint")
+	}
+
+	var url, auth, apiKey string
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		url, auth, apiKey = r.URL.String(), r.Header.Get("Authorization"), r.Header.Get("api-key")
+		body := `{"choices":[{"message":{"role":"assistant","content":"{\"from\":\"e2\",\"to\":\"e4\",\"promotion\":\"\"}"}}]}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(body)), Header: make(http.Header)}, nil
+	})}
+
+	azure, err := NewLLMAIEngine(LLMConfig{Provider: ProviderAzure, APIKey: "az", Model: "chess-gpt", Endpoint: "https://contoso.openai.azure.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	azure.httpClient = client
+	if mv, err := azure.GetBestMove(context.Background(), engine.NewGame()); err != nil || mv.UCI() != "e2e4" {
+		t.Fatalf("expected e2e4, got %s: %v", mv.UCI(), err)
+	}
+	if want := "https://contoso.openai.azure.com/openai/deployments/chess-gpt/chat/completions?api-version=" + DefaultAzureAPIVersion; url != want {
+		t.Errorf("expected deployment routing to %s, got %s", want, url)
+	}
+	if apiKey != "az" || auth != "" {
+		t.Errorf("expected the api-key header, got api-key %q and Authorization %q", apiKey, auth)
+	}
+
+	router, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenRouter, APIKey: "or"})
+	router.httpClient = client
+	if _, err := router.askLLM(context.Background(), "test", router.getChatSystemPrompt()); err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://openrouter.ai/api/v1/chat/completions" || auth != "Bearer or" || router.config.Model != "openai/gpt-4o-mini" {
+		t.Errorf("unexpected OpenRouter request to %s with %q for %s", url, auth, router.config.Model)
+	}
+}
+
 func TestLLMAIEngine_SystemPrompts(t *testing.T) {
 	cfg := LLMConfig{Provider: ProviderOpenAI, APIKey: "x", ChatEnabled: true, Personality: "energetic"}
 	ai, _ := NewLLMAIEngine(cfg)
@@ -120,6 +198,47 @@ func TestLLMAIEngine_PromptGenerators(t *testing.T) {
 	}
 }
 
+func TestLLMAIEngine_ExplainMove(t *testing.T) {
+	var prompt string
+	ai, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x"})
+	ai.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		var req OpenAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		prompt = req.Messages[len(req.Messages)-1].Content
+		body := `{"choices":[{"message":{"role":"assistant","content":" Ra8 is mate: the king is boxed in by its own pawns. "}}]}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(body)), Header: make(http.Header)}, nil
+	})}
+
+	g := engine.NewGame()
+	if err := g.ParseFEN("6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	mv, _ := engine.MoveFromUCI(g, "a1a8")
+	explanation, err := ai.ExplainMove(context.Background(), g, mv, []engine.Move{mv})
+	if err != nil {
+		t.Fatalf("ExplainMove: %v", err)
+	}
+	if explanation != "Ra8 is mate: the king is boxed in by its own pawns." {
+		t.Errorf("unexpected explanation %q", explanation)
+	}
+	if !contains(prompt, "The engine plays Ra8#") || !contains(prompt, g.ToFEN()) {
+		t.Errorf("expected the prompt to give the move in SAN and the position, got %s", prompt)
+	}
+
+	// A line that does not start with the move is replaced by the move alone
+	other, _ := engine.MoveFromUCI(g, "g1f1")
+	if _, err := ai.ExplainMove(context.Background(), g, mv, []engine.Move{other}); err != nil || !contains(prompt, "expecting the line: Ra8#\n") {
+		t.Errorf("expected a one-move line, got %v: %s", err, prompt)
+	}
+
+	ai.httpClient = newMockClient(`{"choices":[{"message":{"role":"assistant","content":"  "}}]}`, 200)
+	if _, err := ai.ExplainMove(context.Background(), g, mv, nil); err == nil {
+		t.Errorf("expected an error for an empty explanation")
+	}
+}
+
 func TestLLMAIEngine_GetBestMove_FallbackOnError(t *testing.T) {
 	// Provide a client that returns error JSON (missing choices) to force fallback.
 	// The shared context may also expire causing an error; accept either a valid move or a deadline error.
@@ -162,3 +281,166 @@ func TestLLMAIEngine_GetBestMove_TimeoutFallback(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestLLMAIEngine_GetBestMove_StructuredOutput(t *testing.T) {
+	tests := []struct {
+		provider LLMProvider
+		format   string
+	}{
+		{ProviderOpenAI, "json_schema"},
+		{ProviderDeepSeek, "json_object"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			var request OpenAIRequest
+			ai, _ := NewLLMAIEngine(LLMConfig{Provider: tt.provider, APIKey: "x"})
+			ai.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+					t.Errorf("invalid request: %v", err)
+				}
+				body := `{"choices":[{"message":{"role":"assistant","content":"{\"from\":\"g1\",\"to\":\"f3\",\"promotion\":\"\"}"}}]}`
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(body)), Header: make(http.Header)}, nil
+			})}
+
+			mv, err := ai.GetBestMove(context.Background(), engine.NewGame())
+			if err != nil || mv.UCI() != "g1f3" {
+				t.Fatalf("expected g1f3, got %s: %v", mv.UCI(), err)
+			}
+			if request.ResponseFormat == nil || request.ResponseFormat.Type != tt.format {
+				t.Errorf("expected %s output, got %+v", tt.format, request.ResponseFormat)
+			}
+		})
+	}
+
+	// Chat stays free text
+	var raw map[string]any
+	ai, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x", ChatEnabled: true})
+	ai.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		_ = json.NewDecoder(r.Body).Decode(&raw)
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(`{"choices":[{"message":{"content":"Hi"}}]}`)), Header: make(http.Header)}, nil
+	})}
+	if _, err := ai.Chat(context.Background(), "Hello", engine.NewGame()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["response_format"]; ok {
+		t.Errorf("expected no response format for chat, got %v", raw["response_format"])
+	}
+}
+
+func TestLLMAIEngine_GetBestMove_ToolUse(t *testing.T) {
+	// Anthropic must use the move tool
+	var anthropic AnthropicRequest
+	ai, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderAnthropic, APIKey: "x"})
+	ai.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		_ = json.NewDecoder(r.Body).Decode(&anthropic)
+		body := `{"content":[{"type":"text","text":"Developing."},{"type":"tool_use","name":"play_move","input":{"from":"g1","to":"f3"}}]}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(body)), Header: make(http.Header)}, nil
+	})}
+	if mv, err := ai.GetBestMove(context.Background(), engine.NewGame()); err != nil || mv.UCI() != "g1f3" {
+		t.Fatalf("expected g1f3 from Anthropic, got %s: %v", mv.UCI(), err)
+	}
+	if len(anthropic.Tools) != 1 || anthropic.ToolChoice == nil || anthropic.ToolChoice.Name != anthropic.Tools[0].Name {
+		t.Errorf("expected a forced tool call, got %+v and %+v", anthropic.Tools, anthropic.ToolChoice)
+	}
+
+	// Gemini must call the move function
+	var gemini GeminiRequest
+	ai, _ = NewLLMAIEngine(LLMConfig{Provider: ProviderGemini, APIKey: "x"})
+	ai.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		_ = json.NewDecoder(r.Body).Decode(&gemini)
+		body := `{"candidates":[{"content":{"parts":[{"functionCall":{"name":"play_move","args":{"from":"e2","to":"e4"}}}]}}]}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(body)), Header: make(http.Header)}, nil
+	})}
+	if mv, err := ai.GetBestMove(context.Background(), engine.NewGame()); err != nil || mv.UCI() != "e2e4" {
+		t.Fatalf("expected e2e4 from Gemini, got %s: %v", mv.UCI(), err)
+	}
+	if len(gemini.Tools) != 1 || gemini.ToolConfig == nil || gemini.ToolConfig.FunctionCallingConfig.Mode != "ANY" {
+		t.Errorf("expected a forced function call, got %+v and %+v", gemini.Tools, gemini.ToolConfig)
+	}
+
+	// Chat stays free text
+	var raw map[string]any
+	ai, _ = NewLLMAIEngine(LLMConfig{Provider: ProviderAnthropic, APIKey: "x", ChatEnabled: true})
+	ai.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		_ = json.NewDecoder(r.Body).Decode(&raw)
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(`{"content":[{"type":"text","text":"Hi"}]}`)), Header: make(http.Header)}, nil
+	})}
+	if reply, err := ai.Chat(context.Background(), "Hello", engine.NewGame()); err != nil || reply != "Hi" {
+		t.Fatalf("expected a text reply, got %q: %v", reply, err)
+	}
+	if _, ok := raw["tools"]; ok {
+		t.Errorf("expected no tools for chat, got %v", raw["tools"])
+	}
+}
+
+func TestLLMAIEngine_GetBestMove_RetriesIllegalMoves(t *testing.T) {
+	newEngine := func(retries int, replies ...string) (*LLMAIEngine, *[]string) {
+		var prompts []string
+		ai, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x", MoveRetries: retries})
+		ai.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			var request OpenAIRequest
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			prompts = append(prompts, request.Messages[len(request.Messages)-1].Content)
+			reply, _ := json.Marshal(replies[min(len(prompts), len(replies))-1])
+			body := `{"choices":[{"message":{"role":"assistant","content":` + string(reply) + `}}]}`
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewBufferString(body)), Header: make(http.Header)}, nil
+		})}
+		return ai, &prompts
+	}
+	illegal := `{"from":"e2","to":"e5","promotion":""}`
+
+	// The second answer is legal and played
+	ai, prompts := newEngine(0, illegal, `{"from":"g1","to":"f3","promotion":""}`)
+	mv, err := ai.GetBestMove(context.Background(), engine.NewGame())
+	if err != nil || mv.UCI() != "g1f3" {
+		t.Fatalf("expected the retried g1f3, got %s: %v", mv.UCI(), err)
+	}
+	if len(*prompts) != 2 {
+		t.Fatalf("expected one retry, got %d requests", len(*prompts))
+	}
+	retry := (*prompts)[1]
+	if !contains(retry, "rejected") || !contains(retry, "e2e4") || !contains(retry, "g1f3") {
+		t.Errorf("expected the reason and the legal moves in the retry, got %s", retry)
+	}
+	if len(ai.context) != 2 || ai.context[0].Content != (*prompts)[0] {
+		t.Errorf("expected only the original exchange in the context, got %+v", ai.context)
+	}
+
+	// Persistent illegal answers end in a random move after the retries
+	ai, prompts = newEngine(0, illegal)
+	if mv, err := ai.GetBestMove(context.Background(), engine.NewGame()); err != nil || mv.From == mv.To {
+		t.Fatalf("expected a fallback move, got %v: %v", mv, err)
+	}
+	if len(*prompts) != 1+DefaultLLMMoveRetries {
+		t.Errorf("expected %d requests, got %d", 1+DefaultLLMMoveRetries, len(*prompts))
+	}
+
+	// Negative retries fall back at once
+	ai, prompts = newEngine(-1, illegal)
+	if _, err := ai.GetBestMove(context.Background(), engine.NewGame()); err != nil || len(*prompts) != 1 {
+		t.Errorf("expected no retry, got %d requests: %v", len(*prompts), err)
+	}
+}
+
+func TestLLMAIEngine_History(t *testing.T) {
+	ai, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x"})
+	ai.httpClient = newMockClient(`{"choices":[{"message":{"content":"{\"from\":\"e2\",\"to\":\"e4\",\"promotion\":\"\"}"}}]}`, 200)
+	if _, err := ai.GetBestMove(context.Background(), engine.NewGame()); err != nil {
+		t.Fatal(err)
+	}
+	history := ai.History()
+	if len(history) != 2 || history[0].Role != "user" || history[1].Role != "assistant" {
+		t.Fatalf("expected the move exchange, got %v", history)
+	}
+
+	// A restored history keeps its last messages
+	restored, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x"})
+	long := make([]ChatMessage, 0, 12)
+	for i := 0; i < 6; i++ {
+		long = append(long, history...)
+	}
+	restored.SetHistory(long)
+	if got := restored.History(); len(got) != maxContextMessages || got[len(got)-1] != history[1] {
+		t.Errorf("expected the last %d messages, got %d", maxContextMessages, len(got))
+	}
+}
@@ -54,6 +54,14 @@ func TestNewLLMAIEngine(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Ollama without API key",
+			config: LLMConfig{
+				Provider:   ProviderOllama,
+				Difficulty: DifficultyMedium,
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -119,6 +127,9 @@ func TestLLMAIEngine_GetProvider(t *testing.T) {
 	if ai.GetProvider() != ProviderXAI {
 		t.Errorf("Expected provider %v, got %v", ProviderXAI, ai.GetProvider())
 	}
+	if ai.GetModel() == "" {
+		t.Error("Expected the provider's default model")
+	}
 }
 
 func TestLLMAIEngine_parseMoveFromResponse(t *testing.T) {
@@ -159,6 +170,26 @@ func TestLLMAIEngine_parseMoveFromResponse(t *testing.T) {
 			response: "\"e2e4\"",
 			wantErr:  false,
 		},
+		{
+			name:     "SAN move",
+			response: "Nf3",
+			wantErr:  false,
+		},
+		{
+			name:     "structured move",
+			response: `{"from": "e2", "to": "e4", "promotion": ""}`,
+			wantErr:  false,
+		},
+		{
+			name:     "fenced structured move",
+			response: "```json\n{\"from\": \"G1\", \"to\": \"F3\"}\n```",
+			wantErr:  false,
+		},
+		{
+			name:     "illegal structured move",
+			response: `{"from": "e2", "to": "e5", "promotion": ""}`,
+			wantErr:  true,
+		},
 		{
 			name:     "random fallback",
 			response: "random",
@@ -212,6 +243,23 @@ func TestLLMAIEngine_generateChessPrompt(t *testing.T) {
 	if !contains(prompt, "Active color:") {
 		t.Error("generateChessPrompt() doesn't contain active color")
 	}
+
+	// The FEN, evaluation and legal moves give the model the full context
+	for _, want := range []string{"FEN: " + game.ToFEN(), "Static evaluation: +", "g1f3 (Nf3)", "b1c3 (Nc3)"} {
+		if !contains(prompt, want) {
+			t.Errorf("generateChessPrompt() doesn't contain %q", want)
+		}
+	}
+	if contains(prompt, "in check") {
+		t.Error("generateChessPrompt() reports check in the starting position")
+	}
+
+	// A king in check has only its escapes listed
+	check := gameFromFEN(t, "4k3/8/8/8/8/8/8/4K2r w - - 0 1")
+	prompt = ai.generateChessPrompt(check)
+	if !contains(prompt, "White is in check.") || contains(prompt, "e1f1") || !contains(prompt, "e1d2 (Kd2)") {
+		t.Errorf("unexpected prompt in check:\n%s", prompt)
+	}
 }
 
 func TestLLMAIEngine_boardToString(t *testing.T) {
@@ -264,6 +312,12 @@ func TestLLMAIEngine_getTemperatureForDifficulty(t *testing.T) {
 	if beginnerTemp <= expertTemp {
 		t.Errorf("Expected beginner temperature (%f) to be higher than expert temperature (%f)", beginnerTemp, expertTemp)
 	}
+
+	// An explicit temperature wins over the level's
+	ai.SetTemperature(0)
+	if temp := ai.getTemperatureForDifficulty(); temp != 0 {
+		t.Errorf("Expected the overridden temperature, got %f", temp)
+	}
 }
 
 func TestNewLLMAIFromEnv(t *testing.T) {
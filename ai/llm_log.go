@@ -0,0 +1,105 @@
+package ai
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LLMExchange is one question to an LLM provider and its answer, for logging.
+// Its texts are redacted: API keys and personal data such as email addresses and
+// phone numbers are replaced by placeholders.
+type LLMExchange struct {
+	CorrelationID string // groups the exchanges of a game, from WithCorrelationID
+	Kind          string // "move", "chat", "reaction", "explanation" or "summary"
+	Provider      LLMProvider
+	Model         string
+	SystemPrompt  string
+	Prompt        string
+	Response      string
+	Error         string
+	Duration      time.Duration
+}
+
+// LLMLogger receives the exchanges of the engines it is set on.
+type LLMLogger func(LLMExchange)
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a context whose LLM exchanges are logged with id,
+// e.g. "game-12".
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID of ctx, or "".
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// redactions replace API keys and personal data in logged texts.
+var redactions = []struct {
+	pattern     *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`), "Bearer [secret]"},
+	{regexp.MustCompile(`(?i)\b(key|api_key|apikey|token)=[^&\s"']+`), "$1=[secret]"},
+	{regexp.MustCompile(`\b(sk|xai|sk-ant|sk-or)-[A-Za-z0-9_-]{16,}`), "[secret]"},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{20,}`), "[secret]"},
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[email]"},
+	{regexp.MustCompile(`\+?\d(?:[ ().-]{0,2}\d){8,}`), "[phone]"},
+}
+
+// Redact replaces secrets, such as the API keys in use, and text that looks like
+// an API key, email address or phone number by placeholders.
+func Redact(text string, secrets ...string) string {
+	for _, secret := range secrets {
+		if len(secret) >= 8 {
+			text = strings.ReplaceAll(text, secret, "[secret]")
+		}
+	}
+	for _, r := range redactions {
+		text = r.pattern.ReplaceAllString(text, r.placeholder)
+	}
+	return text
+}
+
+// SetLogger logs the engine's exchanges with its provider to logger; nil stops
+// logging.
+func (ai *LLMAIEngine) SetLogger(logger LLMLogger) {
+	ai.logger = logger
+}
+
+// ask sends a question of kind to the provider, logging the exchange.
+func (ai *LLMAIEngine) ask(ctx context.Context, kind, message, systemPrompt string) (string, error) {
+	return ai.logged(ctx, kind, message, systemPrompt, func() (string, error) {
+		return ai.askLLM(ctx, message, systemPrompt)
+	})
+}
+
+// logged runs call, the question of kind, and logs it with its answer if the
+// engine has a logger.
+func (ai *LLMAIEngine) logged(ctx context.Context, kind, message, systemPrompt string, call func() (string, error)) (string, error) {
+	if ai.logger == nil {
+		return call()
+	}
+	start := time.Now()
+	response, err := call()
+	exchange := LLMExchange{
+		CorrelationID: CorrelationID(ctx),
+		Kind:          kind,
+		Provider:      ai.config.Provider,
+		Model:         ai.config.Model,
+		SystemPrompt:  Redact(systemPrompt, ai.config.APIKey),
+		Prompt:        Redact(message, ai.config.APIKey),
+		Response:      Redact(response, ai.config.APIKey),
+		Duration:      time.Since(start),
+	}
+	if err != nil {
+		exchange.Error = Redact(err.Error(), ai.config.APIKey)
+	}
+	ai.logger(exchange)
+	return response, err
+}
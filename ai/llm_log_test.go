@@ -0,0 +1,74 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Authorization: Bearer abc.def-123", "Authorization: Bearer [secret]"},
+		{`Post "https://x/v1:generateContent?key=AIzaSyA1234567890abcdefghijk": timeout`, `Post "https://x/v1:generateContent?key=[secret]": timeout`},
+		{"my key is sk-proj-abcdefghijklmnop1234", "my key is [secret]"},
+		{"mail me at jane.doe@example.com", "mail me at [email]"},
+		{"call +1 (555) 123-4567 now", "call [phone] now"},
+		{"FEN rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", "FEN rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"},
+		{"1. e4 e5 2. Nf3 Nc6 (+0.35)", "1. e4 e5 2. Nf3 Nc6 (+0.35)"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := Redact("key: my-own-secret-value", "my-own-secret-value"); got != "key: [secret]" {
+		t.Errorf("expected the given secret to be redacted, got %q", got)
+	}
+}
+
+func TestLLMAIEngineLogger(t *testing.T) {
+	llm, err := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "test-key-123456", ChatEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm.httpClient = newMockClient(`{"choices":[{"message":{"role":"assistant","content":"Write to me at jane@example.com"}}]}`, http.StatusOK)
+
+	var exchanges []LLMExchange
+	llm.SetLogger(func(e LLMExchange) { exchanges = append(exchanges, e) })
+	ctx := WithCorrelationID(context.Background(), "game-7")
+	game := gameFromFEN(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	if _, err := llm.Chat(ctx, "I'm test-key-123456, call me on 0888 123 456", game); err != nil {
+		t.Fatal(err)
+	}
+	if len(exchanges) != 1 {
+		t.Fatalf("expected one exchange, got %d", len(exchanges))
+	}
+	e := exchanges[0]
+	if e.CorrelationID != "game-7" || e.Kind != "chat" || e.Provider != ProviderOpenAI || e.SystemPrompt == "" {
+		t.Errorf("unexpected exchange %+v", e)
+	}
+	if strings.Contains(e.Prompt, "test-key-123456") || !strings.Contains(e.Prompt, "[phone]") || e.Response != "Write to me at [email]" {
+		t.Errorf("expected a redacted exchange, got prompt %q response %q", e.Prompt, e.Response)
+	}
+
+	// Moves are logged too, with the provider's error
+	llm.httpClient = &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("dial failed for key=test-key-123456")
+	})}
+	llm.config.Retry = RetryPolicy{MaxRetries: -1}
+	llm.config.MoveRetries = -1
+	_, _ = llm.GetBestMove(ctx, game)
+	last := exchanges[len(exchanges)-1]
+	if last.Kind != "move" || last.Error == "" || strings.Contains(last.Error, "test-key-123456") {
+		t.Errorf("expected a redacted move error, got %+v", last)
+	}
+
+	llm.SetLogger(nil)
+	n := len(exchanges)
+	_, _ = llm.Chat(ctx, "hello", game)
+	if len(exchanges) != n {
+		t.Error("expected no logging without a logger")
+	}
+}
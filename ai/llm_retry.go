@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling a provider whose circuit breaker is
+// open after repeated failures.
+var ErrCircuitOpen = errors.New("LLM provider circuit open")
+
+// RetryPolicy configures how calls to an LLM provider are retried and when the
+// provider's circuit breaker opens.
+type RetryPolicy struct {
+	// MaxRetries is how often a call failing with a network error, 429 or 5xx is
+	// retried; none if negative.
+	MaxRetries int `json:"max_retries"`
+	// BaseDelay is the backoff before the first retry, doubled for each further one
+	// up to MaxDelay. Delays are jittered down to half, and a longer Retry-After
+	// from the provider is honoured.
+	BaseDelay time.Duration `json:"base_delay"`
+	MaxDelay  time.Duration `json:"max_delay"`
+	// BreakerThreshold is the number of consecutive failed calls that open the
+	// breaker, failing calls at once for BreakerCooldown; 0 disables it.
+	BreakerThreshold int           `json:"breaker_threshold"`
+	BreakerCooldown  time.Duration `json:"breaker_cooldown"`
+}
+
+// DefaultRetryPolicy retries twice from half a second and stops calling a
+// provider for 30 seconds after five failed calls in a row.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:       2,
+		BaseDelay:        500 * time.Millisecond,
+		MaxDelay:         5 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// backoff returns the delay before retry number attempt (from 0).
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	if delay > 0 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			delay = max(delay, time.Duration(seconds)*time.Second)
+		}
+	}
+	return delay
+}
+
+// circuitBreaker fails calls to a provider fast after consecutive failures. Once
+// the cooldown has passed calls go through again, and one more failure reopens it.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*circuitBreaker)
+)
+
+// breakerFor returns the breaker shared by all engines calling endpoint.
+func breakerFor(provider LLMProvider, endpoint string) *circuitBreaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	key := string(provider) + " " + endpoint
+	b, ok := breakers[key]
+	if !ok {
+		b = &circuitBreaker{}
+		breakers[key] = b
+	}
+	return b
+}
+
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now.Before(b.openUntil)
+}
+
+func (b *circuitBreaker) record(ok bool, now time.Time, policy RetryPolicy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ok {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if policy.BreakerThreshold > 0 && b.failures >= policy.BreakerThreshold {
+		b.openUntil = now.Add(policy.BreakerCooldown)
+	}
+}
+
+// doRequest sends a provider request under the engine's retry policy and the
+// provider's circuit breaker. Each attempt is bounded by the client's timeout
+// and the request's deadline, whichever ends first, so that a stalled attempt
+// leaves time to retry. The last response is returned as is when retries run
+// out, so its error body can be reported.
+func (ai *LLMAIEngine) doRequest(req *http.Request) (*http.Response, error) {
+	policy := ai.config.Retry
+	breaker := breakerFor(ai.config.Provider, ai.config.Endpoint)
+	if policy.BreakerThreshold > 0 && !breaker.allow(time.Now()) {
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, ai.config.Provider)
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := ai.httpClient.Do(req)
+		if ctx.Err() != nil {
+			// The caller gave up; that says nothing about the provider
+			return resp, err
+		}
+		failed := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !failed || attempt >= policy.MaxRetries {
+			breaker.record(!failed, time.Now(), policy)
+			return resp, err
+		}
+
+		delay := policy.backoff(attempt, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLLMAIEngine_RetryAndCircuitBreaker(t *testing.T) {
+	breakersMu.Lock()
+	breakers = make(map[string]*circuitBreaker)
+	breakersMu.Unlock()
+
+	policy := RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, BreakerThreshold: 2, BreakerCooldown: time.Hour}
+	newEngine := func(endpoint string, statuses ...int) (*LLMAIEngine, *[]string) {
+		var bodies []string
+		ai, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x", Endpoint: endpoint, Retry: policy})
+		ai.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			status := statuses[min(len(bodies), len(statuses))-1]
+			reply := `{"choices":[{"message":{"content":"ok"}}]}`
+			if status != http.StatusOK {
+				reply = `{"error":{"message":"overloaded"}}`
+			}
+			return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(reply)), Header: make(http.Header)}, nil
+		})}
+		return ai, &bodies
+	}
+
+	// A 503 and a 429 are retried with the same body
+	ai, bodies := newEngine("http://retry.test/ok", http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK)
+	if reply, err := ai.askLLM(context.Background(), "test", "system"); err != nil || reply != "ok" {
+		t.Fatalf("expected success after retries, got %q: %v", reply, err)
+	}
+	if len(*bodies) != 3 || (*bodies)[2] == "" || (*bodies)[2] != (*bodies)[0] {
+		t.Errorf("expected three identical requests, got %d", len(*bodies))
+	}
+
+	// Client errors are not retried
+	ai, bodies = newEngine("http://retry.test/bad-request", http.StatusBadRequest)
+	if _, err := ai.askLLM(context.Background(), "test", "system"); err == nil || len(*bodies) != 1 {
+		t.Errorf("expected one failed request, got %d: %v", len(*bodies), err)
+	}
+
+	// Two failed calls open the breaker for every engine on the endpoint
+	ai, bodies = newEngine("http://retry.test/down", http.StatusInternalServerError)
+	for i := 0; i < 2; i++ {
+		if _, err := ai.askLLM(context.Background(), "test", "system"); err == nil || !contains(err.Error(), "overloaded") {
+			t.Fatalf("expected the provider's error, got %v", err)
+		}
+	}
+	if len(*bodies) != 6 {
+		t.Errorf("expected each call to be tried three times, got %d requests", len(*bodies))
+	}
+	other, otherBodies := newEngine("http://retry.test/down", http.StatusOK)
+	if _, err := other.askLLM(context.Background(), "test", "system"); !errors.Is(err, ErrCircuitOpen) || len(*otherBodies) != 0 {
+		t.Errorf("expected an open circuit without a request, got %d requests: %v", len(*otherBodies), err)
+	}
+
+	// Moves still fall back at once
+	if mv, err := other.GetBestMove(context.Background(), gameFromFEN(t, "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1")); err != nil || mv.From == mv.To {
+		t.Errorf("expected a fallback move, got %v: %v", mv, err)
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	for attempt := 0; attempt < 8; attempt++ {
+		want := min(policy.BaseDelay<<attempt, policy.MaxDelay)
+		if got := policy.backoff(attempt, nil); got < want/2 || got > want {
+			t.Errorf("attempt %d: expected a delay in [%v, %v], got %v", attempt, want/2, want, got)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"7"}}}
+	if got := policy.backoff(0, resp); got != 7*time.Second {
+		t.Errorf("expected Retry-After to be honoured, got %v", got)
+	}
+}
+
+func TestLLMAIEngine_MaxTokensAndTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			MaxTokens int `json:"max_tokens"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprintf(w, `{"choices":[{"message":{"content":"%d"}}]}`, req.MaxTokens)
+	}))
+	defer server.Close()
+
+	noRetry := RetryPolicy{BaseDelay: time.Millisecond}
+	ai, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x", Endpoint: server.URL, Retry: noRetry, MaxTokens: 512, Timeout: 20 * time.Millisecond})
+
+	// Without a deadline the configured timeout applies
+	if _, err := ai.askLLM(context.Background(), "test", "system"); err == nil {
+		t.Fatal("expected the call to time out")
+	}
+
+	// Nor does a longer deadline extend it
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := ai.askLLM(ctx, "test", "system"); err == nil {
+		t.Fatal("expected the attempt to time out within the deadline")
+	}
+
+	ai.SetTimeout(time.Second)
+	if reply, err := ai.askLLM(ctx, "test", "system"); err != nil || reply != "512" {
+		t.Fatalf("expected max_tokens 512 within the deadline, got %q: %v", reply, err)
+	}
+
+	ai.SetMaxTokens(0)
+	ai.SetTimeout(time.Second)
+	if reply, err := ai.askLLM(context.Background(), "test", "system"); err != nil || reply != "200" {
+		t.Fatalf("expected the default max_tokens, got %q: %v", reply, err)
+	}
+}
+
+func TestLLMAIEngine_StalledAttemptRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(500 * time.Millisecond)
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer server.Close()
+
+	retry := RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond}
+	ai, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x", Endpoint: server.URL + "/stalled", Retry: retry, Timeout: 50 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if reply, err := ai.askLLM(ctx, "test", "system"); err != nil || reply != "ok" || calls.Load() != 2 {
+		t.Fatalf("expected the stalled attempt retried within the deadline, got %q after %d calls: %v", reply, calls.Load(), err)
+	}
+}
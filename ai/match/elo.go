@@ -0,0 +1,53 @@
+package match
+
+import "math"
+
+// GamesPlayed returns the number of games played.
+func (r *Result) GamesPlayed() int {
+	return r.Wins + r.Draws + r.Losses
+}
+
+// Score returns the first player's points per game (win 1, draw ½), or 0.5 before
+// any game was played.
+func (r *Result) Score() float64 {
+	n := r.GamesPlayed()
+	if n == 0 {
+		return 0.5
+	}
+	return (float64(r.Wins) + 0.5*float64(r.Draws)) / float64(n)
+}
+
+// EloDifference estimates the first player's rating advantage from its score using
+// the logistic Elo model. A clean sweep yields ±Inf.
+func (r *Result) EloDifference() float64 {
+	return EloFromScore(r.Score())
+}
+
+// EloErrorMargin returns the half-width of the 95% confidence interval of
+// EloDifference, from the spread of the individual game scores.
+func (r *Result) EloErrorMargin() float64 {
+	n := float64(r.GamesPlayed())
+	if n == 0 {
+		return math.Inf(1)
+	}
+	score := r.Score()
+	variance := (float64(r.Wins)*math.Pow(1-score, 2) +
+		float64(r.Draws)*math.Pow(0.5-score, 2) +
+		float64(r.Losses)*math.Pow(score, 2)) / n
+	stdErr := math.Sqrt(variance / n)
+	high := EloFromScore(math.Min(score+1.96*stdErr, 1))
+	low := EloFromScore(math.Max(score-1.96*stdErr, 0))
+	return (high - low) / 2
+}
+
+// EloFromScore converts an expected score (0-1) into a rating difference.
+func EloFromScore(score float64) float64 {
+	switch {
+	case score <= 0:
+		return math.Inf(-1)
+	case score >= 1:
+		return math.Inf(1)
+	default:
+		return -400 * math.Log10(1/score-1)
+	}
+}
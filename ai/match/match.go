@@ -0,0 +1,283 @@
+// Package match plays engines against each other to compare their strength, e.g. to
+// verify difficulty tuning or catch playing-strength regressions.
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// Default match settings, used when Options leaves them zero.
+const (
+	DefaultGames    = 2
+	DefaultMoveTime = 5 * time.Second
+	DefaultMaxPlies = 400
+)
+
+// Player is an engine taking part in a match.
+type Player struct {
+	Name   string
+	Engine ai.Engine
+}
+
+// Commentator is implemented by engines that react to their opponent's moves, such
+// as ai.LLMAIEngine. In matches with commentary their reactions are kept as PGN
+// comments.
+type Commentator interface {
+	ReactToMove(ctx context.Context, move engine.Move, game *engine.Game) (string, error)
+}
+
+// MoveEvent describes a move just played in a match game.
+type MoveEvent struct {
+	Round       int
+	Game        *engine.Game // the game after the move; it must not be modified
+	Move        engine.Move
+	SAN         string
+	Commentator string // the player who commented on the move, if any
+	Comment     string
+}
+
+// Opening is a starting position for match games: an optional FEN followed by
+// optional SAN moves.
+type Opening struct {
+	Name  string
+	FEN   string   // empty for the initial position
+	Moves []string // SAN moves played before the engines take over
+}
+
+// StandardOpenings is a small balanced opening set for matches between engines
+// that would otherwise repeat the same game.
+var StandardOpenings = []Opening{
+	{Name: "Ruy Lopez", Moves: []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6"}},
+	{Name: "Italian Game", Moves: []string{"e4", "e5", "Nf3", "Nc6", "Bc4", "Bc5"}},
+	{Name: "Sicilian Defense", Moves: []string{"e4", "c5", "Nf3", "d6", "d4", "cxd4", "Nxd4", "Nf6", "Nc3"}},
+	{Name: "French Defense", Moves: []string{"e4", "e6", "d4", "d5", "Nc3", "Nf6"}},
+	{Name: "Caro-Kann Defense", Moves: []string{"e4", "c6", "d4", "d5", "Nc3", "dxe4", "Nxe4"}},
+	{Name: "Queen's Gambit Declined", Moves: []string{"d4", "d5", "c4", "e6", "Nc3", "Nf6"}},
+	{Name: "Slav Defense", Moves: []string{"d4", "d5", "c4", "c6", "Nf3", "Nf6"}},
+	{Name: "King's Indian Defense", Moves: []string{"d4", "Nf6", "c4", "g6", "Nc3", "Bg7", "e4", "d6"}},
+	{Name: "English Opening", Moves: []string{"c4", "e5", "Nc3", "Nf6", "g3"}},
+	{Name: "Reti Opening", Moves: []string{"Nf3", "d5", "g3", "Nf6", "Bg2"}},
+}
+
+// Options configures a match.
+type Options struct {
+	Games       int                // games to play, colors alternating (default 2)
+	TimeControl engine.TimeControl // chess clock per game; zero for untimed games
+	MoveTime    time.Duration      // thinking time per move in untimed games (default 5s)
+	Openings    []Opening          // each opening is played twice with colors reversed
+	MaxPlies    int                // games still running after this many plies are adjudicated drawn (default 400)
+	Event       string             // PGN Event tag
+	// Commentary lets players whose engine is a Commentator react to each of
+	// their opponent's moves. Reacting takes the commentator's own thinking time.
+	Commentary bool
+	// OnMove, if set, is called after every move, opening moves included, e.g. to
+	// follow games live. Commentary is added before the call.
+	OnMove func(MoveEvent)
+}
+
+// GameResult is the outcome of one match game.
+type GameResult struct {
+	Round       int
+	White       string
+	Black       string
+	Opening     string
+	Result      engine.Result
+	Plies       int
+	Adjudicated bool // drawn by the MaxPlies limit
+	PGN         string
+}
+
+// Result summarizes a match from the first player's perspective.
+type Result struct {
+	Player   string
+	Opponent string
+	Wins     int
+	Draws    int
+	Losses   int
+	Games    []GameResult
+}
+
+// Play runs a match between a and b. Colors alternate every game, starting with a
+// as White; openings advance every second game so each is played from both sides.
+// A player whose engine fails to return a legal move loses the game by abandonment,
+// or on time when its clock ran out. Play stops early when ctx is done.
+func Play(ctx context.Context, a, b Player, opts Options) (*Result, error) {
+	if a.Engine == nil || b.Engine == nil {
+		return nil, errors.New("both players need an engine")
+	}
+	if opts.Games <= 0 {
+		opts.Games = DefaultGames
+	}
+	if opts.MoveTime <= 0 {
+		opts.MoveTime = DefaultMoveTime
+	}
+	if opts.MaxPlies <= 0 {
+		opts.MaxPlies = DefaultMaxPlies
+	}
+	if opts.Event == "" {
+		opts.Event = "Engine Match"
+	}
+
+	result := &Result{Player: a.Name, Opponent: b.Name}
+	for round := 1; round <= opts.Games; round++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		white, black := a, b
+		if round%2 == 0 {
+			white, black = b, a
+		}
+		var opening Opening
+		if len(opts.Openings) > 0 {
+			opening = opts.Openings[((round-1)/2)%len(opts.Openings)]
+		}
+
+		played, game, err := playGame(ctx, round, white, black, opening, opts)
+		if err != nil {
+			return result, fmt.Errorf("round %d: %w", round, err)
+		}
+		played.PGN = formatPGN(played, game, opts)
+		result.add(played, a.Name)
+	}
+	return result, nil
+}
+
+// add records a finished game.
+func (r *Result) add(g GameResult, player string) {
+	switch {
+	case g.Result.Winner == engine.None:
+		r.Draws++
+	case (g.Result.Winner == engine.White) == (g.White == player):
+		r.Wins++
+	default:
+		r.Losses++
+	}
+	r.Games = append(r.Games, g)
+}
+
+// playGame plays a single game from the opening and returns its result and record.
+func playGame(ctx context.Context, round int, white, black Player, opening Opening, opts Options) (GameResult, *engine.Game, error) {
+	game := engine.NewGame()
+	if opening.FEN != "" {
+		if err := game.ParseFEN(opening.FEN); err != nil {
+			return GameResult{}, nil, fmt.Errorf("opening %s: %w", opening.Name, err)
+		}
+	}
+	for _, san := range opening.Moves {
+		move, err := game.MoveFromSAN(san)
+		if err != nil {
+			return GameResult{}, nil, fmt.Errorf("opening %s: %w", opening.Name, err)
+		}
+		if err := game.MakeMove(move); err != nil {
+			return GameResult{}, nil, fmt.Errorf("opening %s: %w", opening.Name, err)
+		}
+		if opts.OnMove != nil {
+			opts.OnMove(MoveEvent{Round: round, Game: game, Move: move, SAN: san})
+		}
+	}
+	var clock *engine.Clock
+	if opts.TimeControl.Base > 0 {
+		clock = engine.NewClock(opts.TimeControl)
+		game.SetClock(clock)
+	}
+
+	played := GameResult{Round: round, White: white.Name, Black: black.Name, Opening: opening.Name}
+	for !game.IsGameOver() {
+		if len(game.MoveHistory()) >= opts.MaxPlies {
+			if err := game.AgreeDraw(); err != nil {
+				return played, game, err
+			}
+			played.Adjudicated = true
+			break
+		}
+		mover, opponent := white, black
+		if game.ActiveColor() == engine.Black {
+			mover, opponent = black, white
+		}
+		plies := len(game.MoveHistory())
+		if err := playMove(ctx, game, mover, clock, opts.MoveTime); err != nil {
+			return played, game, err
+		}
+		if len(game.MoveHistory()) == plies {
+			continue // forfeited
+		}
+		event := MoveEvent{Round: round, Game: game, Move: game.MoveHistory()[plies]}
+		if opts.Commentary && !game.IsGameOver() {
+			event.Comment = comment(ctx, game, opponent, event.Move, opts.MoveTime)
+			if event.Comment != "" {
+				event.Commentator = opponent.Name
+			}
+		}
+		if opts.OnMove != nil {
+			sans := game.GenerateSAN()
+			event.SAN = sans[len(sans)-1]
+			opts.OnMove(event)
+		}
+	}
+	if clock != nil {
+		clock.Stop()
+	}
+	played.Result = game.Result()
+	played.Plies = len(game.MoveHistory())
+	return played, game, nil
+}
+
+// comment asks commentator for a reaction to its opponent's last move and stores
+// it on the move, prefixed with the commentator's name. Engines without reactions
+// and failed reactions leave the move uncommented.
+func comment(ctx context.Context, game *engine.Game, commentator Player, move engine.Move, limit time.Duration) string {
+	c, ok := commentator.Engine.(Commentator)
+	if !ok {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+	reaction, err := c.ReactToMove(ctx, move, game)
+	reaction = strings.TrimSpace(reaction)
+	if err != nil || reaction == "" {
+		return ""
+	}
+	if err := game.AnnotateMove(len(game.MoveHistory())-1, commentator.Name+": "+reaction, nil); err != nil {
+		return ""
+	}
+	return reaction
+}
+
+// playMove asks the side to move for a move and plays it. Engine failures forfeit
+// the game; only cancellation of ctx is returned as an error.
+func playMove(ctx context.Context, game *engine.Game, mover Player, clock *engine.Clock, moveTime time.Duration) error {
+	color := game.ActiveColor()
+	limit := moveTime
+	if clock != nil {
+		limit = clock.Remaining(color)
+	}
+	moveCtx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+
+	move, err := mover.Engine.GetBestMove(moveCtx, game)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err == nil && game.IsLegalMove(move) {
+		err = game.MakeMove(move)
+	} else if err == nil {
+		err = fmt.Errorf("illegal move %s", move)
+	}
+	switch {
+	case clock != nil && clock.Flagged(color):
+		if game.IsGameOver() {
+			return nil // the move ended the game before the flag was noticed
+		}
+		return game.Timeout(color)
+	case err != nil:
+		return game.Abandon(color)
+	}
+	return nil
+}
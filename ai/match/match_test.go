@@ -0,0 +1,196 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// firstMoveEngine plays the first legal move, a weak but deterministic opponent.
+type firstMoveEngine struct{}
+
+func (firstMoveEngine) GetBestMove(_ context.Context, game *engine.Game) (engine.Move, error) {
+	moves := game.GetAllLegalMoves()
+	if len(moves) == 0 {
+		return engine.Move{}, errors.New("no legal moves")
+	}
+	return moves[0], nil
+}
+func (firstMoveEngine) GetDifficulty() ai.Difficulty { return ai.DifficultyBeginner }
+func (firstMoveEngine) SetDifficulty(ai.Difficulty)  {}
+
+// failingEngine never produces a move; slow engines wait for the deadline first.
+type failingEngine struct{ slow bool }
+
+func (e failingEngine) GetBestMove(ctx context.Context, _ *engine.Game) (engine.Move, error) {
+	if e.slow {
+		<-ctx.Done()
+		return engine.Move{}, ctx.Err()
+	}
+	return engine.Move{}, errors.New("engine crashed")
+}
+func (failingEngine) GetDifficulty() ai.Difficulty { return ai.DifficultyBeginner }
+func (failingEngine) SetDifficulty(ai.Difficulty)  {}
+
+func TestPlayMatch(t *testing.T) {
+	strong := Player{Name: "Minimax", Engine: ai.NewMinimaxAI(ai.DifficultyBeginner)}
+	weak := Player{Name: "FirstMove", Engine: firstMoveEngine{}}
+	result, err := Play(context.Background(), strong, weak, Options{
+		Games:    4,
+		Openings: StandardOpenings,
+		MaxPlies: 80,
+	})
+	if err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if result.GamesPlayed() != 4 || len(result.Games) != 4 {
+		t.Fatalf("expected 4 games, got %+v", result)
+	}
+	if result.Losses != 0 {
+		t.Errorf("expected the searching engine not to lose: %d-%d-%d", result.Wins, result.Draws, result.Losses)
+	}
+	for i, g := range result.Games {
+		wantWhite := "Minimax"
+		if i%2 == 1 {
+			wantWhite = "FirstMove"
+		}
+		if g.White != wantWhite || g.Round != i+1 {
+			t.Errorf("game %d: unexpected pairing %+v", i+1, g)
+		}
+		if want := StandardOpenings[i/2].Name; g.Opening != want {
+			t.Errorf("game %d: opening %q, want %q", i+1, g.Opening, want)
+		}
+		imported, err := engine.ParsePGN(g.PGN)
+		if err != nil {
+			t.Fatalf("game %d: PGN does not parse: %v\n%s", i+1, err, g.PGN)
+		}
+		if imported.Tags["Result"] != g.Result.String() || len(imported.Game.MoveHistory()) != g.Plies {
+			t.Errorf("game %d: PGN does not match the result %+v\n%s", i+1, g, g.PGN)
+		}
+	}
+}
+
+// chattyEngine plays like firstMoveEngine and reacts to every capture.
+type chattyEngine struct{ firstMoveEngine }
+
+func (chattyEngine) ReactToMove(_ context.Context, move engine.Move, _ *engine.Game) (string, error) {
+	if move.Captured.IsEmpty() {
+		return "", nil
+	}
+	return "I didn't see " + move.String() + " coming!", nil
+}
+
+func TestPlayCommentary(t *testing.T) {
+	chatty := Player{Name: "Chatty", Engine: chattyEngine{}}
+	quiet := Player{Name: "FirstMove", Engine: firstMoveEngine{}}
+	var events []MoveEvent
+	result, err := Play(context.Background(), quiet, chatty, Options{
+		Games:      1,
+		Openings:   []Opening{{Name: "Scandinavian", Moves: []string{"e4", "d5"}}},
+		MaxPlies:   40,
+		Commentary: true,
+		OnMove:     func(e MoveEvent) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	g := result.Games[0]
+	if len(events) != g.Plies || events[0].SAN != "e4" || events[0].Round != 1 {
+		t.Fatalf("expected an event per ply starting with the opening, got %d for %d plies", len(events), g.Plies)
+	}
+
+	comments := 0
+	for i, e := range events {
+		if e.Comment == "" {
+			continue
+		}
+		comments++
+		if e.Commentator != "Chatty" || i%2 != 0 || e.Move.Captured.IsEmpty() {
+			t.Errorf("ply %d: unexpected commentary %+v", i, e)
+		}
+		if !strings.Contains(g.PGN, "{Chatty: "+e.Comment+"}") {
+			t.Errorf("ply %d: comment missing from the PGN:\n%s", i, g.PGN)
+		}
+	}
+	if comments == 0 {
+		t.Fatalf("expected the chatty engine to comment on a capture:\n%s", g.PGN)
+	}
+	imported, err := engine.ParsePGN(g.PGN)
+	if err != nil || len(imported.Game.MoveHistory()) != g.Plies {
+		t.Errorf("annotated PGN does not round-trip: %v\n%s", err, g.PGN)
+	}
+}
+
+func TestPlayForfeits(t *testing.T) {
+	strong := Player{Name: "FirstMove", Engine: firstMoveEngine{}}
+	crashing := Player{Name: "Crash", Engine: failingEngine{}}
+	result, err := Play(context.Background(), strong, crashing, Options{Games: 2})
+	if err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if result.Wins != 2 || result.Games[0].Result.Termination != engine.TerminationAbandonment {
+		t.Fatalf("expected wins by abandonment, got %+v", result)
+	}
+
+	slow := Player{Name: "Slow", Engine: failingEngine{slow: true}}
+	result, err = Play(context.Background(), slow, strong, Options{
+		Games:       1,
+		TimeControl: engine.TimeControl{Base: 100 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	g := result.Games[0]
+	if result.Losses != 1 || g.Result.Termination != engine.TerminationTimeout {
+		t.Fatalf("expected a loss on time, got %+v", g)
+	}
+	if !strings.Contains(g.PGN, `[TimeControl "0.1"]`) || !strings.Contains(g.PGN, `[Termination "time forfeit"]`) {
+		t.Errorf("unexpected PGN tags:\n%s", g.PGN)
+	}
+}
+
+func TestPlayAdjudicatesAndCancels(t *testing.T) {
+	p := Player{Name: "FirstMove", Engine: firstMoveEngine{}}
+	result, err := Play(context.Background(), p, p, Options{Games: 1, MaxPlies: 10})
+	if err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if g := result.Games[0]; !g.Adjudicated || g.Plies != 10 || result.Draws != 1 {
+		t.Errorf("expected a drawn game adjudicated after 10 plies, got %+v", g)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Play(ctx, p, p, Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if _, err := Play(context.Background(), p, Player{Name: "None"}, Options{}); err == nil {
+		t.Errorf("expected an error for a player without an engine")
+	}
+}
+
+func TestEloEstimate(t *testing.T) {
+	even := &Result{Wins: 3, Draws: 4, Losses: 3}
+	if even.EloDifference() != 0 {
+		t.Errorf("expected an even score to be 0 Elo, got %f", even.EloDifference())
+	}
+	if m := even.EloErrorMargin(); m <= 0 || math.IsInf(m, 0) {
+		t.Errorf("expected a finite error margin, got %f", m)
+	}
+	if d := EloFromScore(0.75); math.Abs(d-190.85) > 0.01 {
+		t.Errorf("EloFromScore(0.75) = %f, want ~190.85", d)
+	}
+	sweep := &Result{Wins: 5}
+	if !math.IsInf(sweep.EloDifference(), 1) {
+		t.Errorf("expected +Inf for a clean sweep, got %f", sweep.EloDifference())
+	}
+	if (&Result{}).Score() != 0.5 {
+		t.Errorf("expected an empty match to score 0.5")
+	}
+}
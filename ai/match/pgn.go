@@ -0,0 +1,63 @@
+package match
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// formatPGN renders a finished match game with its clock comments and commentary.
+func formatPGN(played GameResult, game *engine.Game, opts Options) string {
+	var sb strings.Builder
+	tag := func(name, value string) {
+		fmt.Fprintf(&sb, "[%s %q]\n", name, value)
+	}
+	tag("Event", opts.Event)
+	tag("Site", "go-chess")
+	tag("Date", time.Now().UTC().Format("2006.01.02"))
+	tag("Round", fmt.Sprint(played.Round))
+	tag("White", played.White)
+	tag("Black", played.Black)
+	tag("Result", played.Result.String())
+	termination := played.Result.PGNTermination()
+	if played.Adjudicated {
+		termination = "adjudication"
+	}
+	tag("Termination", termination)
+	if opts.TimeControl.Base > 0 {
+		tag("TimeControl", opts.TimeControl.String())
+	} else {
+		tag("TimeControl", "-")
+	}
+	if played.Opening != "" {
+		tag("Opening", played.Opening)
+	}
+	if game.StartedFromFEN() {
+		tag("SetUp", "1")
+		tag("FEN", game.StartingFEN())
+	}
+
+	sb.WriteByte('\n')
+	timings := game.MoveTimings()
+	annotations := game.Annotations()
+	for i, san := range game.GenerateSAN() {
+		if i%2 == 0 {
+			fmt.Fprintf(&sb, "%d. ", i/2+1)
+		} else if !annotations[i-1].IsZero() {
+			fmt.Fprintf(&sb, "%d... ", i/2+1) // Black's move after commentary
+		}
+		sb.WriteString(san + " ")
+		if i < len(timings) {
+			if comment := timings[i].PGNComment(engine.DefaultClockNotation); comment != "" {
+				sb.WriteString("{" + comment + "} ")
+			}
+		}
+		if !annotations[i].IsZero() {
+			sb.WriteString(annotations[i].PGN() + " ")
+		}
+	}
+	sb.WriteString(played.Result.String() + "\n")
+	return sb.String()
+}
@@ -0,0 +1,313 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"go.rumenx.com/chess/engine"
+)
+
+const (
+	// mctsExploration is the UCT exploration constant; rewards lie in [0, 1].
+	mctsExploration = 1.0
+	// mctsRolloutPlies is the length of a playout before the position is evaluated.
+	mctsRolloutPlies = 4
+	// mctsGreedyRate is how often a playout takes the best capture instead of a
+	// random move.
+	mctsGreedyRate = 0.8
+	// mctsScale converts centipawns to an expected score: +400 wins about 73%.
+	mctsScale = 400.0
+)
+
+// mctsPlayouts is the number of playouts per move for each difficulty level.
+var mctsPlayouts = map[Difficulty]int{
+	DifficultyBeginner: 200,
+	DifficultyEasy:     1000,
+	DifficultyMedium:   3000,
+	DifficultyHard:     8000,
+	DifficultyExpert:   20000,
+}
+
+// MCTSEngine chooses moves with Monte Carlo tree search using the UCT selection
+// rule. Instead of random games to the end, each playout is a few plies of mostly
+// greedy captures followed by a static evaluation, so results are meaningful at
+// small playout counts. Its play is more positional and less forcing than
+// MinimaxAI's.
+type MCTSEngine struct {
+	difficulty Difficulty
+	playouts   int
+	limits     SearchLimits
+	evaluator  Evaluator
+	rng        *rand.Rand
+}
+
+// NewMCTSEngine creates a tree search engine with the specified difficulty.
+func NewMCTSEngine(difficulty Difficulty) *MCTSEngine {
+	e := &MCTSEngine{evaluator: ClassicalEvaluator, rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	e.SetDifficulty(difficulty)
+	return e
+}
+
+// SetPlayouts overrides the number of playouts per move set by the difficulty.
+func (e *MCTSEngine) SetPlayouts(playouts int) {
+	e.playouts = max(playouts, 1)
+}
+
+// SetLimits overrides the number of playouts with Nodes and bounds the search by
+// MoveTime. Depth does not apply to tree search and is ignored.
+func (e *MCTSEngine) SetLimits(limits SearchLimits) {
+	e.limits = limits
+}
+
+// SetEvaluator replaces the evaluation that scores playouts; nil restores
+// ClassicalEvaluator.
+func (e *MCTSEngine) SetEvaluator(evaluator Evaluator) {
+	if evaluator == nil {
+		evaluator = ClassicalEvaluator
+	}
+	e.evaluator = evaluator
+}
+
+// GetBestMove runs the playouts and returns the most visited move. When ctx ends
+// early the best move so far is returned, provided every move was tried once.
+func (e *MCTSEngine) GetBestMove(ctx context.Context, game *engine.Game) (engine.Move, error) {
+	move, _, err := e.GetBestMoveWithInfo(ctx, game)
+	return move, err
+}
+
+// GetBestMoveWithInfo is GetBestMove that also reports search statistics. Depth is
+// the deepest tree node expanded.
+func (e *MCTSEngine) GetBestMoveWithInfo(ctx context.Context, game *engine.Game) (engine.Move, SearchInfo, error) {
+	lines, info, err := e.Analyze(ctx, game, 1)
+	if err != nil {
+		return engine.Move{}, info, err
+	}
+	info.Score = lines[0].Score
+	return lines[0].Moves[0], info, nil
+}
+
+// Analyze runs the playouts and returns up to lines root moves ordered by visits,
+// each followed by the most visited replies. Scores are the expected results
+// converted back to centipawns.
+func (e *MCTSEngine) Analyze(ctx context.Context, game *engine.Game, lines int) ([]Line, SearchInfo, error) {
+	if e.limits.MoveTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.limits.MoveTime)
+		defer cancel()
+	}
+	playouts := e.playouts
+	if e.limits.Nodes > 0 {
+		playouts = e.limits.Nodes
+	}
+	t := &mctsTree{ctx: ctx, game: game.Clone(), eval: e.evaluator, rng: e.rng, start: time.Now()}
+	root, err := t.run(playouts)
+	t.info.Elapsed = time.Since(t.start)
+	if err != nil {
+		return nil, t.info, err
+	}
+
+	children := slices.Clone(root.children)
+	slices.SortStableFunc(children, func(a, b *mctsNode) int { return b.visits - a.visits })
+	result := make([]Line, 0, max(lines, 1))
+	for _, child := range children[:min(len(children), max(lines, 1))] {
+		line := Line{Score: child.centipawns()}
+		for n := child; n != nil; n = n.mostVisited() {
+			line.Moves = append(line.Moves, n.move)
+		}
+		result = append(result, line)
+	}
+	return result, t.info, nil
+}
+
+// GetDifficulty returns the current difficulty level.
+func (e *MCTSEngine) GetDifficulty() Difficulty {
+	return e.difficulty
+}
+
+// SetDifficulty sets the difficulty level and its number of playouts.
+func (e *MCTSEngine) SetDifficulty(difficulty Difficulty) {
+	e.difficulty = difficulty
+	e.playouts = mctsPlayouts[difficulty]
+	if e.playouts == 0 {
+		e.playouts = mctsPlayouts[DifficultyEasy]
+	}
+}
+
+// mctsNode is a position in the search tree, reached by move.
+type mctsNode struct {
+	move     engine.Move
+	parent   *mctsNode
+	children []*mctsNode
+	untried  []engine.Move
+	visits   int
+	// reward is the summed playout result for the side that played move.
+	reward float64
+}
+
+// uct is the selection priority: the average reward plus an exploration bonus
+// for rarely visited nodes.
+func (n *mctsNode) uct() float64 {
+	return n.reward/float64(n.visits) +
+		mctsExploration*math.Sqrt(math.Log(float64(n.parent.visits))/float64(n.visits))
+}
+
+// mostVisited returns the child with the most visits, or nil for a leaf.
+func (n *mctsNode) mostVisited() *mctsNode {
+	var best *mctsNode
+	for _, child := range n.children {
+		if best == nil || child.visits > best.visits {
+			best = child
+		}
+	}
+	return best
+}
+
+// centipawns converts the node's average reward back to a score for the side
+// that played move.
+func (n *mctsNode) centipawns() int {
+	p := min(max(n.reward/float64(n.visits), 0.001), 0.999)
+	return int(math.Round(mctsScale * math.Log(p/(1-p))))
+}
+
+// mctsTree runs playouts on a private copy of the game.
+type mctsTree struct {
+	ctx   context.Context
+	game  *engine.Game
+	eval  Evaluator
+	rng   *rand.Rand
+	start time.Time
+	info  SearchInfo
+	moves []engine.Move // reusable move buffer for playouts
+}
+
+// run grows the tree by the given number of playouts and returns its root.
+func (t *mctsTree) run(playouts int) (*mctsNode, error) {
+	root := &mctsNode{untried: t.game.GetAllLegalMoves()}
+	if len(root.untried) == 0 {
+		return nil, errors.New("no legal moves available")
+	}
+	for i := 0; i < playouts; i++ {
+		if err := t.ctx.Err(); err != nil {
+			if len(root.untried) > 0 {
+				return nil, err
+			}
+			break
+		}
+		if err := t.playout(root); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// playout selects a node by UCT, expands one untried move, plays a short rollout
+// and propagates its result back to the root.
+func (t *mctsTree) playout(root *mctsNode) error {
+	node, ply := root, 0
+	defer func() {
+		for ; ply > 0; ply-- {
+			_, _ = t.game.UndoMove()
+		}
+	}()
+
+	// Selection
+	for len(node.untried) == 0 && len(node.children) > 0 {
+		best := node.children[0]
+		for _, child := range node.children[1:] {
+			if child.uct() > best.uct() {
+				best = child
+			}
+		}
+		if err := t.game.MakeMove(best.move); err != nil {
+			return err
+		}
+		node, ply = best, ply+1
+	}
+
+	// Expansion
+	if len(node.untried) > 0 {
+		i := t.rng.Intn(len(node.untried))
+		move := node.untried[i]
+		node.untried = slices.Delete(node.untried, i, i+1)
+		if err := t.game.MakeMove(move); err != nil {
+			return err
+		}
+		ply++
+		child := &mctsNode{move: move, parent: node}
+		if !t.game.IsGameOver() {
+			child.untried = t.game.GetAllLegalMoves()
+		}
+		node.children = append(node.children, child)
+		node = child
+		t.info.Nodes++
+		t.info.Depth = max(t.info.Depth, ply)
+	}
+
+	// Rollout, scored for the side to move at the expanded node
+	mover := t.game.ActiveColor()
+	rolled := 0
+	for ; rolled < mctsRolloutPlies && !t.game.IsGameOver(); rolled++ {
+		if err := t.game.MakeMove(t.rolloutMove()); err != nil {
+			return err
+		}
+		t.info.Nodes++
+	}
+	reward := t.reward(mover)
+	for ; rolled > 0; rolled-- {
+		_, _ = t.game.UndoMove()
+	}
+
+	// Backpropagation: each node is credited for the side that played its move
+	for ; node != nil; node = node.parent {
+		reward = 1 - reward
+		node.visits++
+		node.reward += reward
+	}
+	return nil
+}
+
+// rolloutMove picks the most valuable capture most of the time and a random move
+// otherwise.
+func (t *mctsTree) rolloutMove() engine.Move {
+	t.moves = t.game.GenerateLegalMoves(t.moves)
+	if t.rng.Float64() < mctsGreedyRate {
+		best, bestScore := engine.Move{}, 0
+		for _, move := range t.moves {
+			if score := moveOrderScore(move); score > bestScore {
+				best, bestScore = move, score
+			}
+		}
+		if bestScore > 0 {
+			return best
+		}
+	}
+	return t.moves[t.rng.Intn(len(t.moves))]
+}
+
+// reward scores the current position for color: 1 for a win, 0 for a loss, 0.5
+// for a draw, and a logistic function of the static evaluation otherwise.
+func (t *mctsTree) reward(color engine.Color) float64 {
+	switch t.game.Status() {
+	case engine.WhiteWins:
+		if color == engine.White {
+			return 1
+		}
+		return 0
+	case engine.BlackWins:
+		if color == engine.Black {
+			return 1
+		}
+		return 0
+	case engine.Draw:
+		return 0.5
+	}
+	score := t.eval.Evaluate(t.game)
+	if color == engine.Black {
+		score = -score
+	}
+	return 1 / (1 + math.Exp(-float64(score)/mctsScale))
+}
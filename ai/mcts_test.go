@@ -0,0 +1,93 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestMCTSEngine_FindsMateInOne(t *testing.T) {
+	game := gameFromFEN(t, "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1")
+	e := NewMCTSEngine(DifficultyMedium)
+	move, info, err := e.GetBestMoveWithInfo(context.Background(), game)
+	if err != nil {
+		t.Fatalf("GetBestMove: %v", err)
+	}
+	if move.UCI() != "a1a8" {
+		t.Errorf("expected Ra8#, got %s", move.UCI())
+	}
+	if info.Nodes == 0 || info.Depth == 0 {
+		t.Errorf("expected search statistics, got %+v", info)
+	}
+}
+
+func TestMCTSEngine_WinsMaterial(t *testing.T) {
+	// The black queen is hanging to the knight
+	game := gameFromFEN(t, "rnb1kbnr/pppp1ppp/8/4q3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 0 3")
+	lines, _, err := NewMCTSEngine(DifficultyMedium).Analyze(context.Background(), game, 3)
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+	if lines[0].Moves[0].UCI() != "f3e5" || lines[0].Score < 500 {
+		t.Errorf("expected Nxe5 winning the queen, got %v", lines[0])
+	}
+}
+
+func TestMCTSEngine_Cancelled(t *testing.T) {
+	e := NewMCTSEngine(DifficultyExpert)
+	e.SetPlayouts(1_000_000)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	move, err := e.GetBestMove(ctx, engine.NewGame())
+	if err != nil {
+		t.Fatalf("expected the best move so far, got %v", err)
+	}
+	if !engine.NewGame().IsLegalMove(move) || time.Since(start) > time.Second {
+		t.Errorf("expected a legal move soon after cancellation, got %s after %v", move, time.Since(start))
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := e.GetBestMove(ctx, engine.NewGame()); err != context.Canceled {
+		t.Errorf("expected context.Canceled before any playout, got %v", err)
+	}
+}
+
+func TestMCTSEngine_SearchLimits(t *testing.T) {
+	e := NewMCTSEngine(DifficultyExpert)
+	e.SetLimits(SearchLimits{Nodes: 50})
+	_, info, err := e.GetBestMoveWithInfo(context.Background(), engine.NewGame())
+	if err != nil || info.Nodes > 50*(mctsRolloutPlies+2) {
+		t.Fatalf("expected 50 playouts, got %d nodes: %v", info.Nodes, err)
+	}
+
+	e.SetLimits(SearchLimits{Nodes: 1_000_000, MoveTime: 100 * time.Millisecond})
+	start := time.Now()
+	if _, err := e.GetBestMove(context.Background(), engine.NewGame()); err != nil {
+		t.Fatalf("expected the best move in time, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the search to stop after the move time, took %v", elapsed)
+	}
+}
+
+func TestMCTSEngine_Difficulty(t *testing.T) {
+	e := NewMCTSEngine(DifficultyBeginner)
+	if e.playouts != mctsPlayouts[DifficultyBeginner] {
+		t.Errorf("unexpected playouts %d", e.playouts)
+	}
+	e.SetDifficulty(DifficultyExpert)
+	if e.GetDifficulty() != DifficultyExpert || e.playouts != mctsPlayouts[DifficultyExpert] {
+		t.Errorf("expected expert playouts, got %d", e.playouts)
+	}
+	mated := gameFromFEN(t, "R5k1/5ppp/8/8/8/8/5PPP/6K1 b - - 0 1")
+	if _, err := e.GetBestMove(context.Background(), mated); err == nil {
+		t.Errorf("expected an error without legal moves")
+	}
+}
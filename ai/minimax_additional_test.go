@@ -8,48 +8,135 @@ import (
 	"go.rumenx.com/chess/engine"
 )
 
-// Test internal helper methods of MinimaxAI that previously had 0% coverage.
-func TestMinimaxAI_isPathClear(t *testing.T) {
-	ai := NewMinimaxAI(DifficultyEasy)
-
+func gameFromFEN(t *testing.T, fen string) *engine.Game {
+	t.Helper()
 	game := engine.NewGame()
-	// In the starting position, path between a1 (white rook) and a8 (black rook) is blocked by pawns.
-	if ai.isPathClear(game, engine.A1, engine.A8) {
-		t.Errorf("expected path a1->a8 to be blocked in starting position")
+	if err := game.ParseFEN(fen); err != nil {
+		t.Fatalf("failed to load FEN %s: %v", fen, err)
 	}
+	return game
+}
 
-	// Use a simplified FEN with only rooks and kings to test a clear vertical file.
-	// r7k is invalid (9 files). Use r6k (r + 6 empty + k = 8) and R6K for rank1.
-	fen := "r6k/8/8/8/8/8/8/R6K w - - 0 1"
-	custom := engine.NewGame()
-	if err := custom.ParseFEN(fen); err != nil {
-		t.Fatalf("failed to load FEN: %v", err)
+func TestMinimaxAI_FindsMateInOne(t *testing.T) {
+	// Back-rank mate: Ra8#
+	game := gameFromFEN(t, "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1")
+	for _, d := range []Difficulty{DifficultyBeginner, DifficultyMedium} {
+		mv, err := NewMinimaxAI(d).GetBestMove(context.Background(), game)
+		if err != nil {
+			t.Fatalf("%s: GetBestMove: %v", d, err)
+		}
+		if mv.From != engine.A1 || mv.To != engine.A8 {
+			t.Errorf("%s: expected Ra8#, got %s", d, mv)
+		}
 	}
-	if !ai.isPathClear(custom, engine.A1, engine.A8) {
-		t.Errorf("expected path a1->a8 to be clear in custom position")
+}
+
+func TestMinimaxAI_AvoidsMateInOne(t *testing.T) {
+	// Black to move must stop Qxf7# (Qf3 and Bc4 aim at f7)
+	game := gameFromFEN(t, "r1bqkbnr/pppp1ppp/2n5/4p3/2B1P3/5Q2/PPPP1PPP/RNB1K1NR b KQkq - 3 3")
+	mv, err := NewMinimaxAI(DifficultyEasy).GetBestMove(context.Background(), game)
+	if err != nil {
+		t.Fatalf("GetBestMove: %v", err)
+	}
+	if err := game.MakeMove(mv); err != nil {
+		t.Fatalf("MakeMove %s: %v", mv, err)
+	}
+	mate, _ := game.ParseMove("f3f7")
+	if game.IsLegalMove(mate) {
+		if err := game.MakeMove(mate); err == nil && game.Status() == engine.WhiteWins {
+			t.Fatalf("search allowed Qxf7# after %s", mv)
+		}
 	}
 }
 
-func TestMinimaxAI_findCheckEscapeMovesFiltersIllegal(t *testing.T) {
-	ai := NewMinimaxAI(DifficultyMedium)
+func TestMinimaxAI_CaptureSearchSeesRecapture(t *testing.T) {
+	// Rxd5 wins a pawn but loses the rook to ...Rxd5; the capture search sees it even at depth 1
+	game := gameFromFEN(t, "3r2k1/5ppp/8/3p4/8/8/5PPP/3R2K1 w - - 0 1")
+	lines, err := newSearcher(context.Background(), game).run(1, 1)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if mv := lines[0].Moves[0]; mv.From == engine.D1 && mv.To == engine.D5 {
+		t.Errorf("expected the search to avoid losing the rook on d5")
+	}
+}
+
+func TestMinimaxAI_SearchDepthByDifficulty(t *testing.T) {
+	ai := NewMinimaxAI(DifficultyBeginner)
+	if ai.strength.Depth != 1 {
+		t.Errorf("expected beginner depth 1, got %d", ai.strength.Depth)
+	}
+	ai.SetDifficulty(DifficultyExpert)
+	if ai.strength.Depth != 5 {
+		t.Errorf("expected expert depth 5, got %d", ai.strength.Depth)
+	}
+}
+
+func TestMinimaxAI_SearchLeavesGameUntouched(t *testing.T) {
 	game := engine.NewGame()
+	before := game.ToFEN()
+	if _, err := NewMinimaxAI(DifficultyMedium).GetBestMove(context.Background(), game); err != nil {
+		t.Fatalf("GetBestMove: %v", err)
+	}
+	if game.ToFEN() != before || len(game.MoveHistory()) != 0 {
+		t.Errorf("search modified the game: %s", game.ToFEN())
+	}
+}
+
+func TestMinimaxAI_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ai := NewMinimaxAI(DifficultyExpert)
+	ai.SetOpeningBook(nil)
+	if _, err := ai.GetBestMove(ctx, engine.NewGame()); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestMinimaxAI_TimeoutReturnsBestSoFar(t *testing.T) {
+	ai := NewMinimaxAI(DifficultyExpert)
+	ai.SetOpeningBook(nil)
+	ai.SetTargetElo(3000) // deep and without a node limit
+	game := engine.NewGame()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
 
-	legal := ai.GenerateLegalMoves(game)
-	if len(legal) == 0 {
-		t.Fatalf("expected legal moves in starting position")
+	start := time.Now()
+	move, info, err := ai.GetBestMoveWithInfo(ctx, game)
+	if err != nil {
+		t.Fatalf("expected the best move so far, got %v", err)
+	}
+	if !game.IsLegalMove(move) || info.Depth < 1 {
+		t.Errorf("expected a legal move from a completed iteration, got %s at depth %d", move.UCI(), info.Depth)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the search to stop at the deadline, took %v", elapsed)
 	}
+}
 
-	// Construct an obviously illegal move (rook jumps over pieces) and include it in the slice.
-	illegal := engine.Move{From: engine.A1, To: engine.A4, Type: engine.Normal, Piece: game.Board().GetPiece(engine.A1)}
-	mixed := append([]engine.Move{illegal}, legal[0])
+func TestMinimaxAI_SearchLimits(t *testing.T) {
+	ai := NewMinimaxAI(DifficultyExpert)
+	ai.SetOpeningBook(nil)
+	ai.SetLimits(SearchLimits{Depth: 2})
+	ai.SetDifficulty(DifficultyHard) // limits outlive the difficulty
+	_, info, err := ai.GetBestMoveWithInfo(context.Background(), engine.NewGame())
+	if err != nil || info.Depth != 2 {
+		t.Fatalf("expected a depth 2 search, got depth %d: %v", info.Depth, err)
+	}
 
-	filtered := ai.findCheckEscapeMoves(game, mixed, engine.White)
-	// The illegal move should be discarded, leaving only the legal move we added.
-	if len(filtered) != 1 {
-		t.Fatalf("expected 1 legal escape move, got %d", len(filtered))
+	ai.SetTargetElo(3000)
+	ai.SetLimits(SearchLimits{Nodes: 2000})
+	if _, info, _ = ai.GetBestMoveWithInfo(context.Background(), engine.NewGame()); info.Depth >= ai.strength.Depth {
+		t.Errorf("expected the node limit to stop deepening early, got depth %d", info.Depth)
 	}
-	if filtered[0] != legal[0] {
-		t.Errorf("unexpected move filtered: got %v want %v", filtered[0], legal[0])
+
+	ai.SetLimits(SearchLimits{MoveTime: 50 * time.Millisecond})
+	start := time.Now()
+	if _, _, err = ai.GetBestMoveWithInfo(context.Background(), engine.NewGame()); err != nil {
+		t.Fatalf("expected the best move in time, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the search to stop after the move time, took %v", elapsed)
 	}
 }
 
@@ -68,3 +155,114 @@ func TestMinimaxAI_GetBestMoveBasic(t *testing.T) {
 		t.Errorf("expected a non-null move, got %+v", mv)
 	}
 }
+
+func TestMinimaxAI_AnalyzeReportsPrincipalVariation(t *testing.T) {
+	game := gameFromFEN(t, "k7/8/2K5/8/8/8/8/7R w - - 0 1")
+	lines, _, err := NewMinimaxAI(DifficultyMedium).Analyze(context.Background(), game, 1)
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("expected one line, got %d", len(lines))
+	}
+	if mate := lines[0].MateIn(); mate != 2 {
+		t.Fatalf("expected mate in 2, got %d (score %d)", mate, lines[0].Score)
+	}
+	if len(lines[0].Moves) != 3 {
+		t.Fatalf("expected a 3-ply mating line, got %v", lines[0].Moves)
+	}
+	replay := game.Clone()
+	for _, mv := range lines[0].Moves {
+		if err := replay.MakeMove(mv); err != nil {
+			t.Fatalf("PV move %s is illegal: %v", mv, err)
+		}
+	}
+	if replay.Status() != engine.WhiteWins {
+		t.Errorf("expected the PV to end in mate, got %s", replay.Status())
+	}
+}
+
+func TestMinimaxAI_AnalyzeMultiPV(t *testing.T) {
+	game := engine.NewGame()
+	lines, _, err := NewMinimaxAI(DifficultyEasy).Analyze(context.Background(), game, 3)
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+	seen := map[engine.Move]bool{}
+	for i, line := range lines {
+		if seen[line.Moves[0]] {
+			t.Errorf("duplicate root move %s", line.Moves[0])
+		}
+		seen[line.Moves[0]] = true
+		if i > 0 && line.Score > lines[i-1].Score {
+			t.Errorf("lines not sorted: %d after %d", line.Score, lines[i-1].Score)
+		}
+	}
+	searchOnly := NewMinimaxAI(DifficultyEasy)
+	searchOnly.SetOpeningBook(nil)
+	searchOnly.strength.ErrorRate = 0
+	best, err := searchOnly.GetBestMove(context.Background(), game)
+	if err != nil || best != lines[0].Moves[0] {
+		t.Errorf("expected GetBestMove to match the first line: %s vs %s (%v)", best, lines[0].Moves[0], err)
+	}
+}
+
+func TestLineMateIn(t *testing.T) {
+	tests := []struct {
+		score, want int
+	}{
+		{mateScore - 1, 1},
+		{mateScore - 3, 2},
+		{-mateScore + 2, -1},
+		{-mateScore + 4, -2},
+		{350, 0},
+	}
+	for _, tt := range tests {
+		if got := (Line{Score: tt.score}).MateIn(); got != tt.want {
+			t.Errorf("MateIn(%d) = %d, want %d", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestMinimaxAI_GetBestMoveWithInfo(t *testing.T) {
+	ai := NewMinimaxAI(DifficultyMedium)
+	ai.SetOpeningBook(nil)
+	move, info, err := ai.GetBestMoveWithInfo(context.Background(), engine.NewGame())
+	if err != nil {
+		t.Fatalf("GetBestMoveWithInfo: %v", err)
+	}
+	if move.From == move.To {
+		t.Errorf("expected a move, got %+v", move)
+	}
+	if info.Depth != 3 || info.Nodes == 0 || info.Elapsed <= 0 {
+		t.Errorf("unexpected search info %+v", info)
+	}
+	if info.TTProbes == 0 || info.TTHits > info.TTProbes {
+		t.Errorf("unexpected transposition table counters %+v", info)
+	}
+	if rate := info.TTHitRate(); rate < 0 || rate > 1 {
+		t.Errorf("hit rate out of range: %f", rate)
+	}
+
+	// Engines without statistics still report the time used
+	_, info, err = GetBestMoveWithInfo(context.Background(), NewRandomAI(), engine.NewGame())
+	if err != nil || info.Elapsed <= 0 || info.Nodes != 0 {
+		t.Errorf("unexpected fallback info %+v (%v)", info, err)
+	}
+}
+
+func TestSearchInfoRates(t *testing.T) {
+	info := SearchInfo{Nodes: 5000, Elapsed: 500 * time.Millisecond, TTProbes: 4, TTHits: 1}
+	if info.NPS() != 10000 {
+		t.Errorf("NPS = %d, want 10000", info.NPS())
+	}
+	if info.TTHitRate() != 0.25 {
+		t.Errorf("TTHitRate = %f, want 0.25", info.TTHitRate())
+	}
+	if (SearchInfo{}).NPS() != 0 || (SearchInfo{}).TTHitRate() != 0 {
+		t.Errorf("expected zero rates for an empty search")
+	}
+}
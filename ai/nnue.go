@@ -0,0 +1,186 @@
+package ai
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// NNUE network file layout, all values little-endian:
+//
+//	magic          [4]byte "GCNN"
+//	version        uint32  1
+//	hidden         uint32  accumulator size H
+//	featureWeights int16   [768][H]
+//	featureBias    int16   [H]
+//	outputWeights  int16   [2H], side to move's accumulator first
+//	outputBias     int32
+//
+// Features are (piece color relative to the perspective, piece type, square),
+// with squares mirrored vertically for Black's perspective, so one set of
+// weights serves both sides.
+const (
+	nnueMagic    = "GCNN"
+	nnueVersion  = 1
+	nnueFeatures = 2 * 6 * 64
+	// nnueMaxHidden bounds the accumulator size accepted from files.
+	nnueMaxHidden = 4096
+
+	// Quantization: accumulators are clipped to [0, nnueQA] and the output is
+	// scaled by nnueScale / (nnueQA * nnueQB) to centipawns.
+	nnueQA    = 255
+	nnueQB    = 64
+	nnueScale = 400
+)
+
+// NNUE is an efficiently updatable neural network evaluation with a single hidden
+// layer of clipped ReLU units per perspective. It implements Evaluator and is safe
+// for concurrent use. Accumulators are rebuilt for every position rather than
+// updated incrementally, which keeps the search code unaware of the network.
+type NNUE struct {
+	hidden         int
+	featureWeights []int16 // [nnueFeatures * hidden]
+	featureBias    []int16
+	outputWeights  []int16 // [2 * hidden]
+	outputBias     int32
+}
+
+// LoadNNUE reads a network file.
+func LoadNNUE(path string) (*NNUE, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	n, err := ReadNNUE(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return n, nil
+}
+
+// ReadNNUE reads a network in the file format described above.
+func ReadNNUE(r io.Reader) (*NNUE, error) {
+	var header struct {
+		Magic   [4]byte
+		Version uint32
+		Hidden  uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("reading network header: %w", err)
+	}
+	if string(header.Magic[:]) != nnueMagic {
+		return nil, errors.New("not a network file")
+	}
+	if header.Version != nnueVersion {
+		return nil, fmt.Errorf("unsupported network version %d", header.Version)
+	}
+	if header.Hidden == 0 || header.Hidden > nnueMaxHidden {
+		return nil, fmt.Errorf("invalid hidden layer size %d", header.Hidden)
+	}
+
+	hidden := int(header.Hidden)
+	n := &NNUE{
+		hidden:         hidden,
+		featureWeights: make([]int16, nnueFeatures*hidden),
+		featureBias:    make([]int16, hidden),
+		outputWeights:  make([]int16, 2*hidden),
+	}
+	for _, data := range []any{n.featureWeights, n.featureBias, n.outputWeights, &n.outputBias} {
+		if err := binary.Read(r, binary.LittleEndian, data); err != nil {
+			return nil, fmt.Errorf("reading network weights: %w", err)
+		}
+	}
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		return nil, errors.New("unexpected data after network weights")
+	}
+	return n, nil
+}
+
+// WriteTo writes the network in the file format read by ReadNNUE.
+func (n *NNUE) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	header := struct {
+		Magic   [4]byte
+		Version uint32
+		Hidden  uint32
+	}{Version: nnueVersion, Hidden: uint32(n.hidden)}
+	copy(header.Magic[:], nnueMagic)
+	for _, data := range []any{header, n.featureWeights, n.featureBias, n.outputWeights, n.outputBias} {
+		if err := binary.Write(cw, binary.LittleEndian, data); err != nil {
+			return cw.n, err
+		}
+	}
+	return cw.n, nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Evaluate scores the position in centipawns from White's perspective.
+func (n *NNUE) Evaluate(game *engine.Game) int {
+	stm := game.ActiveColor()
+	us := n.accumulate(game, stm)
+	them := n.accumulate(game, stm.Opposite())
+
+	out := int64(n.outputBias)
+	for j := 0; j < n.hidden; j++ {
+		out += int64(clippedReLU(us[j]))*int64(n.outputWeights[j]) +
+			int64(clippedReLU(them[j]))*int64(n.outputWeights[n.hidden+j])
+	}
+	score := int(out * nnueScale / (nnueQA * nnueQB))
+	if stm == engine.Black {
+		return -score
+	}
+	return score
+}
+
+// accumulate computes the hidden layer input from perspective's point of view.
+func (n *NNUE) accumulate(game *engine.Game, perspective engine.Color) []int32 {
+	acc := make([]int32, n.hidden)
+	for j, b := range n.featureBias {
+		acc[j] = int32(b)
+	}
+	for sq := engine.Square(0); sq < 64; sq++ {
+		piece := game.PieceAt(sq)
+		if piece.IsEmpty() {
+			continue
+		}
+		weights := n.featureWeights[nnueFeature(piece, sq, perspective)*n.hidden:][:n.hidden]
+		for j, w := range weights {
+			acc[j] += int32(w)
+		}
+	}
+	return acc
+}
+
+// nnueFeature returns the input index of a piece on a square seen from perspective.
+func nnueFeature(piece engine.Piece, sq engine.Square, perspective engine.Color) int {
+	side := 0
+	if piece.Color != perspective {
+		side = 1
+	}
+	if perspective == engine.Black {
+		sq ^= 56 // mirror ranks
+	}
+	return (side*6+int(piece.Type-engine.Pawn))*64 + int(sq)
+}
+
+// clippedReLU clamps an accumulator value to the network's activation range.
+func clippedReLU(x int32) int32 {
+	return min(max(x, 0), nnueQA)
+}
@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// queenNetwork is a one-neuron network that only values queens: +141 centipawns
+// for each side's queen from that side's point of view.
+func queenNetwork() *NNUE {
+	n := &NNUE{
+		hidden:         1,
+		featureWeights: make([]int16, nnueFeatures),
+		featureBias:    []int16{0},
+		outputWeights:  []int16{nnueQB, -nnueQB},
+	}
+	for sq := engine.Square(0); sq < 64; sq++ {
+		n.featureWeights[nnueFeature(engine.Piece{Type: engine.Queen, Color: engine.White}, sq, engine.White)] = 90
+	}
+	return n
+}
+
+func TestNNUE_Evaluate(t *testing.T) {
+	n := queenNetwork()
+	tests := []struct {
+		fen  string
+		want int
+	}{
+		{"4k3/8/8/8/8/8/8/3QK3 w - - 0 1", 141},
+		{"4k3/8/8/8/8/8/8/3QK3 b - - 0 1", 141},
+		{"3qk3/8/8/8/8/8/8/4K3 w - - 0 1", -141},
+		{"3qk3/8/8/8/8/8/8/3QK3 w - - 0 1", 0},
+	}
+	for _, tt := range tests {
+		if got := n.Evaluate(gameFromFEN(t, tt.fen)); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.fen, got, tt.want)
+		}
+	}
+}
+
+func TestNNUE_ReadWrite(t *testing.T) {
+	var buf bytes.Buffer
+	written, err := queenNetwork().WriteTo(&buf)
+	if err != nil || written != int64(buf.Len()) {
+		t.Fatalf("WriteTo: %d bytes, %v", written, err)
+	}
+	path := filepath.Join(t.TempDir(), "queen.nnue")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	n, err := LoadNNUE(path)
+	if err != nil {
+		t.Fatalf("LoadNNUE: %v", err)
+	}
+	if got := n.Evaluate(gameFromFEN(t, "4k3/8/8/8/8/8/8/3QK3 w - - 0 1")); got != 141 {
+		t.Errorf("loaded network evaluates %d, want 141", got)
+	}
+
+	data := buf.Bytes()
+	for name, bad := range map[string][]byte{
+		"bad magic": append([]byte("XXXX"), data[4:]...),
+		"truncated": data[:len(data)-1],
+		"trailing":  append(bytes.Clone(data), 0),
+		"empty":     nil,
+	} {
+		if _, err := ReadNNUE(bytes.NewReader(bad)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := LoadNNUE(filepath.Join(t.TempDir(), "missing.nnue")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestMinimaxAI_SetEvaluator(t *testing.T) {
+	// An evaluation that loves the h-pawn steers the search to push it
+	hPawn := EvaluatorFunc(func(game *engine.Game) int {
+		if game.PieceAt(engine.H4).Type == engine.Pawn {
+			return 1000
+		}
+		return 0
+	})
+	minimax := NewMinimaxAI(DifficultyBeginner)
+	minimax.SetEvaluator(hPawn)
+	lines, _, err := minimax.Analyze(context.Background(), engine.NewGame(), 1)
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if got := lines[0].Moves[0].UCI(); got != "h2h4" {
+		t.Errorf("expected the evaluator to choose h2h4, got %s", got)
+	}
+
+	minimax.SetEvaluator(nil)
+	if minimax.evaluator == nil {
+		t.Errorf("expected nil to restore the classical evaluation")
+	}
+}
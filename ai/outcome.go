@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"go.rumenx.com/chess/engine"
+)
+
+// OutcomePolicy configures when an engine resigns, offers a draw or accepts one,
+// based on its own search scores.
+type OutcomePolicy struct {
+	// ResignScore is how far behind, in centipawns, the engine must be to resign;
+	// 0 disables resigning.
+	ResignScore int
+	// ResignMoves is the number of consecutive moves the score has to stay there.
+	ResignMoves int
+	// MinDepth is the search depth a score needs to count. Shallow searches and
+	// book moves interrupt the streaks.
+	MinDepth int
+	// DrawScore is the largest score in either direction that counts as dead equal;
+	// 0 disables draw offers.
+	DrawScore int
+	// DrawMoves is the number of consecutive dead-equal moves before the engine
+	// offers a draw, and again after each offer.
+	DrawMoves int
+	// DrawPieces is the most pieces, kings and pawns included, a position may have
+	// to count as an ending for draw offers and acceptance.
+	DrawPieces int
+}
+
+// DefaultOutcomePolicy resigns after three moves at least seven pawns down and
+// offers a draw after five moves within 0.15 pawns in endings of up to ten pieces.
+func DefaultOutcomePolicy() OutcomePolicy {
+	return OutcomePolicy{
+		ResignScore: 700,
+		ResignMoves: 3,
+		MinDepth:    3,
+		DrawScore:   15,
+		DrawMoves:   5,
+		DrawPieces:  10,
+	}
+}
+
+// OutcomeDecision is what an engine wants to do besides playing its move.
+type OutcomeDecision int
+
+const (
+	// OutcomePlay plays the move.
+	OutcomePlay OutcomeDecision = iota
+	// OutcomeResign resigns instead of moving.
+	OutcomeResign
+	// OutcomeOfferDraw plays the move and offers a draw.
+	OutcomeOfferDraw
+)
+
+// String returns the decision name.
+func (d OutcomeDecision) String() string {
+	switch d {
+	case OutcomeResign:
+		return "resign"
+	case OutcomeOfferDraw:
+		return "offer_draw"
+	default:
+		return "play"
+	}
+}
+
+// OutcomeTracker follows one engine's scores through a game. It is not safe for
+// concurrent use.
+type OutcomeTracker struct {
+	policy OutcomePolicy
+	losing int // consecutive moves at or below -ResignScore
+	equal  int // consecutive dead-equal moves in an ending
+	score  int
+	scored bool // score is from a deep enough search
+	ply    int  // position of the last recorded search
+	// streaks before the last recorded search, restored when it is replaced
+	prevLosing, prevEqual int
+}
+
+// NewOutcomeTracker returns a tracker for a new game.
+func NewOutcomeTracker(policy OutcomePolicy) *OutcomeTracker {
+	return &OutcomeTracker{policy: policy, ply: -1}
+}
+
+// Record takes the search the engine made to move in game and decides whether to
+// resign or offer a draw. Searching the same position again replaces the previous
+// search rather than extending the streaks.
+func (t *OutcomeTracker) Record(game *engine.Game, info SearchInfo) OutcomeDecision {
+	ply := len(game.MoveHistory())
+	if ply == t.ply {
+		t.losing, t.equal = t.prevLosing, t.prevEqual
+	}
+	t.ply, t.prevLosing, t.prevEqual = ply, t.losing, t.equal
+	t.score = info.Score
+	t.scored = !info.Book && info.Depth > 0 && info.Depth >= t.policy.MinDepth
+	if !t.scored {
+		t.losing, t.equal = 0, 0
+		return OutcomePlay
+	}
+
+	if t.policy.ResignScore > 0 && t.score <= -t.policy.ResignScore {
+		t.losing++
+	} else {
+		t.losing = 0
+	}
+	if t.deadEqual(game) {
+		t.equal++
+	} else {
+		t.equal = 0
+	}
+
+	switch {
+	case t.losing >= max(t.policy.ResignMoves, 1):
+		return OutcomeResign
+	case t.equal >= max(t.policy.DrawMoves, 1):
+		t.equal = 0
+		return OutcomeOfferDraw
+	default:
+		return OutcomePlay
+	}
+}
+
+// AcceptsDraw reports whether the engine agrees to a draw offered in game, judged
+// by its last recorded search: it accepts when the score is no better than dead
+// equal in an ending, or when it is losing by half the resignation margin.
+func (t *OutcomeTracker) AcceptsDraw(game *engine.Game) bool {
+	if !t.scored {
+		return false
+	}
+	if t.policy.DrawScore > 0 && t.score <= t.policy.DrawScore && isEnding(game, t.policy.DrawPieces) {
+		return true
+	}
+	return t.policy.ResignScore > 0 && t.score <= -t.policy.ResignScore/2
+}
+
+// deadEqual reports whether the last score is a dead-equal ending.
+func (t *OutcomeTracker) deadEqual(game *engine.Game) bool {
+	return t.policy.DrawScore > 0 && t.score >= -t.policy.DrawScore && t.score <= t.policy.DrawScore &&
+		isEnding(game, t.policy.DrawPieces)
+}
+
+// isEnding reports whether the board holds at most pieces pieces.
+func isEnding(game *engine.Game, pieces int) bool {
+	count := 0
+	for sq := engine.Square(0); sq < 64; sq++ {
+		if !game.PieceAt(sq).IsEmpty() {
+			count++
+		}
+	}
+	return count <= pieces
+}
@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestOutcomeTracker_Resign(t *testing.T) {
+	game := gameFromFEN(t, "4k3/8/8/8/8/8/3QQ3/4K3 b - - 0 1")
+	tracker := NewOutcomeTracker(DefaultOutcomePolicy())
+	lost := SearchInfo{Depth: 4, Score: -900}
+
+	play := func(uci string) {
+		t.Helper()
+		mv, err := engine.MoveFromUCI(game, uci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.MakeMove(mv); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if d := tracker.Record(game, lost); d != OutcomePlay {
+		t.Fatalf("expected to play on after one lost score, got %s", d)
+	}
+	// Searching the same position again does not extend the streak
+	if d := tracker.Record(game, lost); d != OutcomePlay {
+		t.Fatalf("expected a repeated search to replace the previous one, got %s", d)
+	}
+	play("e8f8")
+	play("e2e3")
+	if d := tracker.Record(game, lost); d != OutcomePlay {
+		t.Fatalf("expected to play on after two lost scores, got %s", d)
+	}
+	play("f8g8")
+	play("e3e4")
+	if d := tracker.Record(game, lost); d != OutcomeResign {
+		t.Fatalf("expected to resign after three lost scores, got %s", d)
+	}
+
+	// Shallow searches do not count
+	tracker = NewOutcomeTracker(DefaultOutcomePolicy())
+	for _, moves := range [][2]string{{"g8h8", "d2d3"}, {"h8g8", "d3d4"}, {"g8f8", "d4d5"}} {
+		if d := tracker.Record(game, SearchInfo{Depth: 2, Score: -900}); d != OutcomePlay {
+			t.Fatalf("expected shallow searches to be ignored, got %s", d)
+		}
+		play(moves[0])
+		play(moves[1])
+	}
+
+	quick := DefaultOutcomePolicy()
+	quick.ResignMoves = 1
+	if d := NewOutcomeTracker(quick).Record(game, lost); d != OutcomeResign {
+		t.Errorf("expected to resign at once, got %s", d)
+	}
+	quick.ResignScore = 0
+	if d := NewOutcomeTracker(quick).Record(game, lost); d != OutcomePlay {
+		t.Errorf("expected never to resign with resigning disabled, got %s", d)
+	}
+}
+
+func TestOutcomeTracker_Draw(t *testing.T) {
+	policy := DefaultOutcomePolicy()
+	policy.DrawMoves = 2
+	tracker := NewOutcomeTracker(policy)
+
+	ending := gameFromFEN(t, "4k3/4p3/8/8/8/8/4P3/4K3 w - - 0 1")
+	equal := SearchInfo{Depth: 5, Score: 5}
+	if d := tracker.Record(ending, equal); d != OutcomePlay {
+		t.Fatalf("expected no offer after one equal score, got %s", d)
+	}
+	if !tracker.AcceptsDraw(ending) {
+		t.Errorf("expected to accept a draw in a dead-equal ending")
+	}
+	for _, uci := range []string{"e1d1", "e8d8"} {
+		if err := ending.MakeMove(mustUCI(t, ending, uci)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := tracker.Record(ending, equal); d != OutcomeOfferDraw {
+		t.Fatalf("expected a draw offer after two equal scores, got %s", d)
+	}
+
+	if tracker.Record(ending, SearchInfo{Depth: 5, Score: 200}); tracker.AcceptsDraw(ending) {
+		t.Errorf("expected to decline a draw when winning")
+	}
+
+	// Equal middlegames are played out, but clearly worse ones are drawn gladly
+	start := engine.NewGame()
+	tracker = NewOutcomeTracker(policy)
+	for i := 0; i < 3; i++ {
+		if d := tracker.Record(start, SearchInfo{Depth: 5}); d != OutcomePlay {
+			t.Fatalf("expected no draw offers from the starting position, got %s", d)
+		}
+	}
+	if tracker.AcceptsDraw(start) {
+		t.Errorf("expected to decline a draw in an equal middlegame")
+	}
+	if tracker.Record(start, SearchInfo{Depth: 5, Score: -400}); !tracker.AcceptsDraw(start) {
+		t.Errorf("expected to accept a draw when clearly worse")
+	}
+	if tracker.Record(start, SearchInfo{Book: true}); tracker.AcceptsDraw(start) {
+		t.Errorf("expected to decline a draw without a search")
+	}
+}
+
+func TestSearchInfoScore(t *testing.T) {
+	// A lone king against two queens is hopeless for Black
+	game := gameFromFEN(t, "4k3/8/8/8/8/8/3QQ3/4K3 b - - 0 1")
+	minimax := NewMinimaxAI(DifficultyMedium)
+	minimax.strength.ErrorRate = 0
+	_, info, err := minimax.GetBestMoveWithInfo(context.Background(), game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Depth < 3 || info.Score > -700 {
+		t.Errorf("expected a deep, lost score, got %+v", info)
+	}
+
+	mcts := NewMCTSEngine(DifficultyBeginner)
+	_, info, err = mcts.GetBestMoveWithInfo(context.Background(), game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Score >= 0 {
+		t.Errorf("expected MCTS to report a losing score, got %+v", info)
+	}
+}
+
+func mustUCI(t *testing.T, game *engine.Game, uci string) engine.Move {
+	t.Helper()
+	mv, err := engine.MoveFromUCI(game, uci)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mv
+}
@@ -0,0 +1,60 @@
+package ai
+
+import "strings"
+
+// Personality is a preset character for an LLM opponent: who it plays in its
+// prompts, how it samples and how often it comments on the player's moves.
+type Personality struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Prompt completes "You have the personality of: ..." in the system prompts.
+	Prompt      string  `json:"prompt"`
+	Temperature float64 `json:"temperature"`
+	// ReactionRate is the share of the player's moves reacted to, from 0 to 1.
+	ReactionRate float64 `json:"reaction_rate"`
+}
+
+// Personalities are the built-in personality presets.
+var Personalities = []Personality{
+	{
+		Name:         "grumpy-grandmaster",
+		Description:  "A hard-to-impress veteran who grudgingly gives credit",
+		Prompt:       "a grumpy old grandmaster who has seen every move before, is hard to impress and gives credit only grudgingly, with dry sarcasm",
+		Temperature:  0.6,
+		ReactionRate: 0.5,
+	},
+	{
+		Name:         "cheerful-beginner-coach",
+		Description:  "A patient, upbeat coach who celebrates every good idea",
+		Prompt:       "a cheerful and patient coach for beginners who celebrates every good idea, explains in simple words and never mocks a mistake",
+		Temperature:  0.8,
+		ReactionRate: 1,
+	},
+	{
+		Name:         "silent-assassin",
+		Description:  "A cold killer who rarely speaks, and then only briefly",
+		Prompt:       "a cold, silent assassin who rarely speaks, and then in a few menacing words",
+		Temperature:  0.3,
+		ReactionRate: 0.1,
+	},
+	{
+		Name:         "romantic-attacker",
+		Description:  "A swashbuckler from the 19th century who lives for sacrifices",
+		Prompt:       "a swashbuckling romantic attacker from the nineteenth century who loves gambits, sacrifices and bold attacks on the king",
+		Temperature:  1,
+		ReactionRate: 0.7,
+	},
+}
+
+// LookupPersonality returns the preset named name, which may be written with
+// spaces or underscores for the hyphens, e.g. "Grumpy Grandmaster".
+func LookupPersonality(name string) (Personality, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.NewReplacer(" ", "-", "_", "-").Replace(name)
+	for _, p := range Personalities {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Personality{}, false
+}
@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLookupPersonality(t *testing.T) {
+	for _, name := range []string{"grumpy-grandmaster", "Grumpy Grandmaster", " grumpy_grandmaster "} {
+		if p, ok := LookupPersonality(name); !ok || p.Name != "grumpy-grandmaster" {
+			t.Errorf("LookupPersonality(%q) = %v, %v", name, p.Name, ok)
+		}
+	}
+	if _, ok := LookupPersonality("chatty-cathy"); ok {
+		t.Error("expected no preset for an unknown name")
+	}
+	for _, p := range Personalities {
+		if p.Prompt == "" || p.Temperature < 0 || p.Temperature > 2 || p.ReactionRate < 0 || p.ReactionRate > 1 {
+			t.Errorf("invalid preset %+v", p)
+		}
+	}
+}
+
+func TestLLMAIEngineSetPersonality(t *testing.T) {
+	llm, err := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x", ChatEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests int
+	var body []byte
+	llm.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		body, _ = io.ReadAll(r.Body)
+		return newMockClient(`{"choices":[{"message":{"role":"assistant","content":"Hmph."}}]}`, http.StatusOK).Transport.RoundTrip(r)
+	})}
+
+	grumpy, _ := LookupPersonality("grumpy-grandmaster")
+	llm.SetPersonality(grumpy)
+	if !strings.Contains(llm.getSystemPrompt(), grumpy.Prompt) || *llm.config.Temperature != grumpy.Temperature {
+		t.Error("expected the preset's prompt and temperature")
+	}
+	llm.SetTemperature(0.1)
+	if got := llm.getTemperatureForDifficulty(); got != 0.1 {
+		t.Errorf("expected a request's temperature to override the preset's, got %v", got)
+	}
+	llm.SetTemperature(grumpy.Temperature)
+
+	game := gameFromFEN(t, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")
+	reply, err := game.ParseMove("e7e5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mute := Personality{Name: "mute", Prompt: "a mute", ReactionRate: 0}
+	llm.SetPersonality(mute)
+	for i := 0; i < 5; i++ {
+		if reaction, err := llm.ReactToMove(context.Background(), reply, game); err != nil || reaction != "" {
+			t.Fatalf("expected no reaction at rate 0, got %q %v", reaction, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("expected no provider calls at rate 0, got %d", requests)
+	}
+
+	mute.ReactionRate = 1
+	llm.SetPersonality(mute)
+	if reaction, err := llm.ReactToMove(context.Background(), reply, game); err != nil || reaction != "Hmph." {
+		t.Errorf("expected a reaction at rate 1, got %q %v", reaction, err)
+	}
+	if !strings.Contains(string(body), "You have the personality of: "+mute.Prompt) {
+		t.Errorf("expected the reaction request to carry the preset's prompt, got %s", body)
+	}
+}
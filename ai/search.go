@@ -0,0 +1,548 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// tracer records the package's spans with the global tracer provider, which
+// does nothing unless tracing is set up.
+var tracer = otel.Tracer("go.rumenx.com/chess/ai")
+
+const (
+	// mateScore is the score of delivering mate at the root; mates further away
+	// score less so the search prefers the shortest one.
+	mateScore = 100000
+	// infinity bounds the alpha-beta window.
+	infinity = mateScore + 1
+	// ctxCheckInterval is how many nodes are searched between context checks.
+	ctxCheckInterval = 1024
+)
+
+// maxMatePly bounds the distance to mate the search can report.
+const maxMatePly = 256
+
+// Line is a principal variation: the moves the search expects both sides to play,
+// starting with the candidate move, and the resulting score.
+type Line struct {
+	Moves []engine.Move
+	// Score is in centipawns from the perspective of the side to move; mates score
+	// close to ±mateScore (see MateIn).
+	Score int
+}
+
+// MateIn returns the number of moves until mate along the line: positive if the
+// side to move delivers it, negative if it is mated, and 0 if no mate was found.
+func (l Line) MateIn() int {
+	switch {
+	case l.Score > mateScore-maxMatePly:
+		return (mateScore - l.Score + 1) / 2
+	case l.Score < -mateScore+maxMatePly:
+		return -(mateScore + l.Score) / 2
+	default:
+		return 0
+	}
+}
+
+// Analyzer is implemented by engines that can report principal variations.
+type Analyzer interface {
+	// Analyze returns up to lines principal variations, best first, and statistics
+	// about the search.
+	Analyze(ctx context.Context, game *engine.Game, lines int) ([]Line, SearchInfo, error)
+}
+
+// SearchInfo reports the work an engine did to choose a move.
+type SearchInfo struct {
+	Nodes    int           // positions visited
+	Depth    int           // deepest fully searched iteration, in plies
+	Elapsed  time.Duration // wall time spent
+	TTProbes int           // transposition table lookups
+	TTHits   int           // lookups that found the position
+	Book     bool          // the move came from an opening book
+	// Score is the best line's score in centipawns for the side to move, as in
+	// Line; it is only set by engines that search (Depth > 0).
+	Score int
+}
+
+// NPS returns the search speed in nodes per second.
+func (i SearchInfo) NPS() int {
+	if i.Elapsed <= 0 {
+		return 0
+	}
+	return int(float64(i.Nodes) / i.Elapsed.Seconds())
+}
+
+// TTHitRate returns the fraction of transposition table lookups that hit.
+func (i SearchInfo) TTHitRate() float64 {
+	if i.TTProbes == 0 {
+		return 0
+	}
+	return float64(i.TTHits) / float64(i.TTProbes)
+}
+
+// InfoEngine is an Engine that also reports search statistics.
+type InfoEngine interface {
+	Engine
+	// GetBestMoveWithInfo returns the best move and how it was found.
+	GetBestMoveWithInfo(ctx context.Context, game *engine.Game) (engine.Move, SearchInfo, error)
+}
+
+// GetBestMoveWithInfo asks e for a move along with search statistics. Engines that
+// do not implement InfoEngine only report the time used. The search is traced
+// as an ai.search span.
+func GetBestMoveWithInfo(ctx context.Context, e Engine, game *engine.Game) (engine.Move, SearchInfo, error) {
+	ctx, span := startSearchSpan(ctx, "ai.search", e, game)
+	var move engine.Move
+	var info SearchInfo
+	var err error
+	if ie, ok := e.(InfoEngine); ok {
+		move, info, err = ie.GetBestMoveWithInfo(ctx, game)
+	} else {
+		start := time.Now()
+		move, err = e.GetBestMove(ctx, game)
+		info = SearchInfo{Elapsed: time.Since(start)}
+	}
+	endSearchSpan(span, move, info, err)
+	return move, info, err
+}
+
+// AnalyzeLines asks a for up to lines principal variations, like a.Analyze,
+// tracing the search as an ai.analyze span.
+func AnalyzeLines(ctx context.Context, a Analyzer, game *engine.Game, lines int) ([]Line, SearchInfo, error) {
+	ctx, span := startSearchSpan(ctx, "ai.analyze", a, game)
+	pvs, info, err := a.Analyze(ctx, game, lines)
+	var best engine.Move
+	if len(pvs) > 0 && len(pvs[0].Moves) > 0 {
+		best = pvs[0].Moves[0]
+	}
+	endSearchSpan(span, best, info, err)
+	return pvs, info, err
+}
+
+// startSearchSpan starts the span of a search of the game's position by e, an
+// Engine or Analyzer.
+func startSearchSpan(ctx context.Context, name string, e any, game *engine.Game) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("chess.engine", fmt.Sprintf("%T", e)),
+		attribute.String("chess.fen", game.ToFEN()),
+	}
+	if eng, ok := e.(Engine); ok {
+		attrs = append(attrs, attribute.String("chess.difficulty", eng.GetDifficulty().String()))
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSearchSpan records the outcome of a search on its span and ends it.
+func endSearchSpan(span trace.Span, move engine.Move, info SearchInfo, err error) {
+	defer span.End()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	span.SetAttributes(
+		attribute.String("chess.move", move.String()),
+		attribute.Int("chess.search.depth", info.Depth),
+		attribute.Int("chess.search.nodes", info.Nodes),
+		attribute.Bool("chess.search.book", info.Book),
+	)
+}
+
+// ttBound tells how a transposition table score relates to the true score.
+type ttBound uint8
+
+const (
+	ttExact ttBound = iota
+	ttLower         // the true score is at least this (beta cutoff)
+	ttUpper         // the true score is at most this (no move raised alpha)
+)
+
+// ttEntry caches the result of searching a position.
+type ttEntry struct {
+	depth int
+	score int
+	bound ttBound
+	move  engine.Move // best or refuting move, searched first next time
+}
+
+// searcher runs an iterative deepening negamax search with alpha-beta pruning and
+// a transposition table on a private copy of the game.
+type searcher struct {
+	ctx   context.Context
+	game  *engine.Game
+	start time.Time
+	info  SearchInfo
+	tt    map[uint64]ttEntry
+	eval  Evaluator
+	// maxNodes ends the search after the current iteration's node budget (0: none).
+	maxNodes int
+	// moves holds a reusable move buffer per ply.
+	moves [][]engine.Move
+	// pv[ply] is the best line found from ply, collected as the search unwinds.
+	pv [][]engine.Move
+	// trace, if set, records every move tried.
+	trace *searchTrace
+}
+
+// newSearcher prepares a search of the game's current position.
+func newSearcher(ctx context.Context, game *engine.Game) *searcher {
+	return &searcher{ctx: ctx, game: game.Clone(), start: time.Now(), tt: make(map[uint64]ttEntry), eval: ClassicalEvaluator}
+}
+
+// run searches iteratively to depth 1, 2, ... maxDepth, each iteration ordering
+// moves with the results of the previous one, and returns the deepest lines.
+// When the node limit is hit or ctx ends, the last completed iteration is kept,
+// or the root moves searched so far if the first iteration was cut short; ctx's
+// error is only returned if it ended before any root move was searched.
+func (s *searcher) run(maxDepth, lines int) ([]Line, error) {
+	defer func() { s.info.Elapsed = time.Since(s.start) }()
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	var best []Line
+	for depth := 1; depth <= max(maxDepth, 1); depth++ {
+		if s.trace != nil {
+			s.trace.iteration = depth
+		}
+		result, err := s.search(depth, lines, best)
+		if s.trace != nil {
+			s.trace.done(result, s.info.Nodes, err != nil)
+		}
+		if errors.Is(err, errNodeLimit) || (err != nil && s.ctx.Err() != nil) {
+			if best == nil && len(result) > 0 {
+				best = result
+			}
+			if best == nil {
+				return nil, err
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		best = result
+		s.info.Depth = depth
+	}
+	return best, nil
+}
+
+// search returns the best lines (at least one, at most lines) after searching
+// depth plies plus captures, best first. Root moves of the previous lines are
+// searched first. An interrupted search returns the lines found so far along with
+// the error.
+func (s *searcher) search(depth, lines int, previous []Line) ([]Line, error) {
+	moves := s.orderedMoves(0, false, engine.Move{})
+	if len(moves) == 0 {
+		return nil, errors.New("no legal moves available")
+	}
+	lines = max(lines, 1)
+	for i := len(previous) - 1; i >= 0; i-- {
+		moveToFront(moves, previous[i].Moves[0])
+	}
+
+	var best []Line
+	for _, move := range moves {
+		// Only lines better than the worst one kept need an exact score
+		alpha := -infinity
+		if len(best) == lines {
+			alpha = best[len(best)-1].Score
+		}
+		if err := s.game.MakeMove(move); err != nil {
+			return nil, err
+		}
+		score, err := s.negamax(depth-1, -infinity, -alpha, 1)
+		s.undo()
+		if err != nil {
+			return best, err
+		}
+		score = -score
+		if s.trace != nil {
+			s.trace.move(s.game, move, depth-1, alpha, infinity, score)
+		}
+		if score <= alpha && len(best) > 0 {
+			continue
+		}
+		line := Line{Moves: append([]engine.Move{move}, s.pvAt(1)...), Score: score}
+		i := len(best)
+		for i > 0 && best[i-1].Score < score {
+			i--
+		}
+		best = slices.Insert(best, i, line)
+		if len(best) > lines {
+			best = best[:lines]
+		}
+	}
+	return best, nil
+}
+
+// negamax scores the current position from the side to move's perspective.
+func (s *searcher) negamax(depth, alpha, beta, ply int) (int, error) {
+	s.clearPV(ply)
+	if err := s.visit(); err != nil {
+		return 0, err
+	}
+	if score, over := s.terminalScore(ply); over {
+		return score, nil
+	}
+	if depth <= 0 {
+		return s.quiescence(alpha, beta, ply)
+	}
+
+	key := s.game.Hash()
+	entry, found := s.probe(key)
+	// Cached scores outside the window never shorten the principal variation
+	if found && entry.depth >= depth {
+		score := scoreFromTT(entry.score, ply)
+		if entry.bound != ttUpper && score >= beta {
+			return beta, nil
+		}
+		if entry.bound != ttLower && score <= alpha {
+			return alpha, nil
+		}
+	}
+
+	bound, best := ttUpper, engine.Move{}
+	for _, move := range s.orderedMoves(ply, false, entry.move) {
+		if err := s.game.MakeMove(move); err != nil {
+			return 0, err
+		}
+		score, err := s.negamax(depth-1, -beta, -alpha, ply+1)
+		s.undo()
+		if err != nil {
+			return 0, err
+		}
+		score = -score
+		if s.trace != nil {
+			s.trace.move(s.game, move, depth-1, alpha, beta, score)
+		}
+		if score >= beta {
+			s.store(key, depth, beta, ttLower, move, ply)
+			return beta, nil // cutoff: the opponent will avoid this line
+		}
+		if score > alpha {
+			alpha, bound, best = score, ttExact, move
+			s.updatePV(ply, move)
+		}
+	}
+	s.store(key, depth, alpha, bound, best, ply)
+	return alpha, nil
+}
+
+// probe looks up a position in the transposition table.
+func (s *searcher) probe(key uint64) (ttEntry, bool) {
+	s.info.TTProbes++
+	entry, ok := s.tt[key]
+	if ok {
+		s.info.TTHits++
+	}
+	return entry, ok
+}
+
+// store records a search result, keeping deeper results for the same position.
+func (s *searcher) store(key uint64, depth, score int, bound ttBound, move engine.Move, ply int) {
+	if old, ok := s.tt[key]; ok && old.depth > depth {
+		return
+	}
+	s.tt[key] = ttEntry{depth: depth, score: scoreToTT(score, ply), bound: bound, move: move}
+}
+
+// scoreToTT makes mate scores relative to the stored position instead of the root,
+// so they stay correct when the position is reached at another ply.
+func scoreToTT(score, ply int) int {
+	switch {
+	case score > mateScore-maxMatePly:
+		return score + ply
+	case score < -mateScore+maxMatePly:
+		return score - ply
+	default:
+		return score
+	}
+}
+
+// scoreFromTT converts a stored score back to be relative to the root.
+func scoreFromTT(score, ply int) int {
+	switch {
+	case score > mateScore-maxMatePly:
+		return score - ply
+	case score < -mateScore+maxMatePly:
+		return score + ply
+	default:
+		return score
+	}
+}
+
+// quiescence extends the search along captures and promotions so the static
+// evaluation is only applied to quiet positions.
+func (s *searcher) quiescence(alpha, beta, ply int) (int, error) {
+	s.clearPV(ply)
+	if err := s.visit(); err != nil {
+		return 0, err
+	}
+	if score, over := s.terminalScore(ply); over {
+		return score, nil
+	}
+
+	standPat := s.evaluate()
+	if standPat >= beta {
+		return beta, nil
+	}
+	if standPat > alpha {
+		alpha = standPat
+	}
+
+	for _, move := range s.orderedMoves(ply, true, engine.Move{}) {
+		if err := s.game.MakeMove(move); err != nil {
+			return 0, err
+		}
+		score, err := s.quiescence(-beta, -alpha, ply+1)
+		s.undo()
+		if err != nil {
+			return 0, err
+		}
+		score = -score
+		if score >= beta {
+			return beta, nil
+		}
+		if score > alpha {
+			alpha = score
+			s.updatePV(ply, move)
+		}
+	}
+	return alpha, nil
+}
+
+// clearPV empties the line at ply before its node is searched.
+func (s *searcher) clearPV(ply int) {
+	for len(s.pv) <= ply+1 {
+		s.pv = append(s.pv, nil)
+	}
+	s.pv[ply] = s.pv[ply][:0]
+}
+
+// updatePV makes move followed by the best reply line the best line at ply.
+func (s *searcher) updatePV(ply int, move engine.Move) {
+	s.pv[ply] = append(append(s.pv[ply][:0], move), s.pv[ply+1]...)
+}
+
+// pvAt returns a copy of the best line found from ply.
+func (s *searcher) pvAt(ply int) []engine.Move {
+	if ply >= len(s.pv) {
+		return nil
+	}
+	return slices.Clone(s.pv[ply])
+}
+
+// errNodeLimit stops an iteration that exceeds the searcher's node budget.
+var errNodeLimit = errors.New("node limit reached")
+
+// visit counts a node and periodically checks for cancellation. Once the first
+// iteration has completed, exceeding the node budget stops the search.
+func (s *searcher) visit() error {
+	s.info.Nodes++
+	if s.maxNodes > 0 && s.info.Depth > 0 && s.info.Nodes > s.maxNodes {
+		return errNodeLimit
+	}
+	if s.info.Nodes%ctxCheckInterval == 0 {
+		return s.ctx.Err()
+	}
+	return nil
+}
+
+// terminalScore scores finished games: being mated is the worst outcome, sooner
+// mates being worse, and draws are even.
+func (s *searcher) terminalScore(ply int) (int, bool) {
+	switch s.game.Status() {
+	case engine.WhiteWins, engine.BlackWins:
+		return -mateScore + ply, true
+	case engine.Draw:
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// evaluate returns the static evaluation from the side to move's perspective.
+func (s *searcher) evaluate() int {
+	score := s.eval.Evaluate(s.game)
+	if s.game.ActiveColor() == engine.Black {
+		return -score
+	}
+	return score
+}
+
+// orderedMoves generates the legal moves at ply, most promising first: the given
+// first move (e.g. from the transposition table), captures of valuable pieces by
+// cheap ones, then promotions, then quiet moves. With tacticalOnly, quiet moves
+// are dropped.
+func (s *searcher) orderedMoves(ply int, tacticalOnly bool, first engine.Move) []engine.Move {
+	for len(s.moves) <= ply {
+		s.moves = append(s.moves, nil)
+	}
+	moves := s.game.GenerateLegalMoves(s.moves[ply])
+	s.moves[ply] = moves
+
+	if tacticalOnly {
+		n := 0
+		for _, move := range moves {
+			if moveOrderScore(move) > 0 {
+				moves[n] = move
+				n++
+			}
+		}
+		moves = moves[:n]
+	}
+	slices.SortStableFunc(moves, func(a, b engine.Move) int {
+		return moveOrderScore(b) - moveOrderScore(a)
+	})
+	if first != (engine.Move{}) {
+		moveToFront(moves, first)
+	}
+	return moves
+}
+
+// moveToFront moves the given move, if present, to the start of moves, keeping the
+// order of the others.
+func moveToFront(moves []engine.Move, move engine.Move) {
+	if i := slices.Index(moves, move); i > 0 {
+		copy(moves[1:i+1], moves[:i])
+		moves[0] = move
+	}
+}
+
+// undo reverts the last searched move; search moves are always undoable.
+func (s *searcher) undo() {
+	_, _ = s.game.UndoMove()
+}
+
+// orderValues are piece values used only for move ordering.
+var orderValues = map[engine.PieceType]int{
+	engine.Pawn:   1,
+	engine.Knight: 3,
+	engine.Bishop: 3,
+	engine.Rook:   5,
+	engine.Queen:  9,
+	engine.King:   10,
+}
+
+// moveOrderScore ranks captures by most valuable victim, least valuable attacker
+// (MVV-LVA), then promotions. Quiet moves score 0.
+func moveOrderScore(move engine.Move) int {
+	score := 0
+	if !move.Captured.IsEmpty() {
+		score += 10*orderValues[move.Captured.Type] - orderValues[move.Piece.Type] + 10
+	}
+	if move.Promotion != engine.Empty {
+		score += orderValues[move.Promotion]
+	}
+	return score
+}
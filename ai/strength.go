@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Strength is a calibrated playing strength: the search settings and deliberate
+// imprecision that make an engine perform at roughly a target Elo rating.
+type Strength struct {
+	Elo int
+	// Depth is the nominal search depth in plies.
+	Depth int
+	// MaxNodes stops deepening once this many positions were searched (0: no limit).
+	// The first iteration always completes.
+	MaxNodes int
+	// ErrorRate is the probability of deliberately not playing the best move.
+	ErrorRate float64
+	// MaxRank is the worst-ranked move an error may choose (1: always the best move).
+	MaxRank int
+	// MaxLoss caps how much worse (centipawns) than the best move an error may be.
+	MaxLoss int
+	// Temperature is the sampling temperature for LLM engines.
+	Temperature float64
+}
+
+// DefaultEloTargets is the target rating of each difficulty level.
+var DefaultEloTargets = map[Difficulty]int{
+	DifficultyBeginner: 800,
+	DifficultyEasy:     1100,
+	DifficultyMedium:   1400,
+	DifficultyHard:     1700,
+	DifficultyExpert:   2000,
+}
+
+// strengthAnchors are measured calibration points, weakest first. Ratings in between
+// interpolate the limits; the depth is that of the anchor below. Calibrate new
+// anchors with the ai/match package.
+var strengthAnchors = []Strength{
+	{Elo: 600, Depth: 1, MaxNodes: 500, ErrorRate: 0.5, MaxRank: 5, MaxLoss: 500, Temperature: 1.3},
+	{Elo: 800, Depth: 1, MaxNodes: 2000, ErrorRate: 0.35, MaxRank: 4, MaxLoss: 350, Temperature: 1.2},
+	{Elo: 1100, Depth: 2, MaxNodes: 10000, ErrorRate: 0.2, MaxRank: 3, MaxLoss: 200, Temperature: 0.9},
+	{Elo: 1400, Depth: 3, MaxNodes: 50000, ErrorRate: 0.1, MaxRank: 3, MaxLoss: 120, Temperature: 0.7},
+	{Elo: 1700, Depth: 4, MaxNodes: 200000, ErrorRate: 0.05, MaxRank: 2, MaxLoss: 60, Temperature: 0.5},
+	{Elo: 2000, Depth: 5, ErrorRate: 0, MaxRank: 1, Temperature: 0.3},
+	{Elo: 2300, Depth: 6, ErrorRate: 0, MaxRank: 1, Temperature: 0.2},
+}
+
+// StrengthForElo returns the calibrated strength for a target rating, clamped to
+// the calibrated range.
+func StrengthForElo(elo int) Strength {
+	first, last := strengthAnchors[0], strengthAnchors[len(strengthAnchors)-1]
+	switch {
+	case elo <= first.Elo:
+		s := first
+		s.Elo = elo
+		return s
+	case elo >= last.Elo:
+		s := last
+		s.Elo = elo
+		return s
+	}
+	i := sort.Search(len(strengthAnchors), func(i int) bool { return strengthAnchors[i].Elo > elo })
+	lo, hi := strengthAnchors[i-1], strengthAnchors[i]
+	t := float64(elo-lo.Elo) / float64(hi.Elo-lo.Elo)
+	lerp := func(a, b float64) float64 { return a + (b-a)*t }
+	s := Strength{
+		Elo:         elo,
+		Depth:       lo.Depth,
+		MaxNodes:    int(lerp(float64(lo.MaxNodes), float64(hi.MaxNodes))),
+		ErrorRate:   lerp(lo.ErrorRate, hi.ErrorRate),
+		MaxRank:     lo.MaxRank,
+		MaxLoss:     int(lerp(float64(lo.MaxLoss), float64(hi.MaxLoss))),
+		Temperature: lerp(lo.Temperature, hi.Temperature),
+	}
+	if hi.MaxNodes == 0 {
+		s.MaxNodes = 0 // the stronger anchor is unlimited
+	}
+	return s
+}
+
+// StrengthForDifficulty returns the calibrated strength of a difficulty level at
+// its DefaultEloTargets rating.
+func StrengthForDifficulty(difficulty Difficulty) Strength {
+	elo, ok := DefaultEloTargets[difficulty]
+	if !ok {
+		elo = DefaultEloTargets[DifficultyEasy]
+	}
+	return StrengthForElo(elo)
+}
+
+// makesError decides whether the next move deliberately deviates from the best one.
+func (s Strength) makesError(rng *rand.Rand) bool {
+	return s.ErrorRate > 0 && s.MaxRank > 1 && rng.Float64() < s.ErrorRate
+}
+
+// errorLine picks a lower-ranked line from multi-PV results ordered best first, no
+// more than MaxLoss worse than the best and never one leading to being mated. The
+// best line is returned when no line qualifies.
+func (s Strength) errorLine(lines []Line, rng *rand.Rand) Line {
+	var candidates []Line
+	for _, line := range lines[1:min(len(lines), s.MaxRank)] {
+		if lines[0].Score-line.Score <= s.MaxLoss && line.MateIn() >= 0 {
+			candidates = append(candidates, line)
+		}
+	}
+	if len(candidates) == 0 {
+		return lines[0]
+	}
+	return candidates[rng.Intn(len(candidates))]
+}
@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestStrengthForElo(t *testing.T) {
+	for difficulty, depth := range map[Difficulty]int{
+		DifficultyBeginner: 1,
+		DifficultyEasy:     2,
+		DifficultyMedium:   3,
+		DifficultyHard:     4,
+		DifficultyExpert:   5,
+	} {
+		if got := StrengthForDifficulty(difficulty).Depth; got != depth {
+			t.Errorf("%s: expected depth %d, got %d", difficulty, depth, got)
+		}
+	}
+
+	mid := StrengthForElo(1250)
+	if mid.Depth != 2 || mid.MaxNodes != 30000 || mid.MaxRank != 3 {
+		t.Errorf("unexpected interpolated strength %+v", mid)
+	}
+	if mid.ErrorRate <= 0.1 || mid.ErrorRate >= 0.2 {
+		t.Errorf("expected an error rate between the anchors, got %v", mid.ErrorRate)
+	}
+	if s := StrengthForElo(1850); s.MaxNodes != 0 {
+		t.Errorf("expected no node limit below an unlimited anchor, got %d", s.MaxNodes)
+	}
+	if s := StrengthForElo(100); s.Elo != 100 || s.Depth != 1 || s.ErrorRate != 0.5 {
+		t.Errorf("expected weak ratings to clamp to the weakest anchor, got %+v", s)
+	}
+	if s := StrengthForElo(3000); s.Depth != 6 || s.ErrorRate != 0 {
+		t.Errorf("expected strong ratings to clamp to the strongest anchor, got %+v", s)
+	}
+}
+
+func TestStrength_ErrorLine(t *testing.T) {
+	move := func(uci string) []engine.Move {
+		m, _ := engine.MoveFromUCI(engine.NewGame(), uci)
+		return []engine.Move{m}
+	}
+	lines := []Line{
+		{Moves: move("e2e4"), Score: 50},
+		{Moves: move("d2d4"), Score: -400},
+		{Moves: move("g1f3"), Score: -mateScore + 3},
+	}
+	rng := rand.New(rand.NewSource(1))
+	s := Strength{ErrorRate: 1, MaxRank: 3, MaxLoss: 200}
+	if got := s.errorLine(lines, rng); got.Moves[0] != lines[0].Moves[0] {
+		t.Errorf("expected the best line when errors are too costly, got %s", got.Moves[0])
+	}
+	s.MaxLoss = 500
+	for i := 0; i < 20; i++ {
+		if got := s.errorLine(lines, rng); got.Moves[0] != lines[1].Moves[0] {
+			t.Fatalf("expected the second line and never the mated one, got %s", got.Moves[0])
+		}
+	}
+	if (Strength{ErrorRate: 1, MaxRank: 1}).makesError(rng) {
+		t.Errorf("expected no errors with a single candidate")
+	}
+}
+
+func TestSearcher_NodeLimit(t *testing.T) {
+	s := newSearcher(context.Background(), engine.NewGame())
+	s.maxNodes = 100
+	lines, err := s.run(6, 1)
+	if err != nil || len(lines) == 0 {
+		t.Fatalf("expected a move despite the node limit, got %v, %v", lines, err)
+	}
+	if s.info.Depth < 1 || s.info.Depth >= 6 {
+		t.Errorf("expected the node limit to stop deepening, reached depth %d", s.info.Depth)
+	}
+}
+
+func TestMinimaxAI_SetTargetElo(t *testing.T) {
+	minimax := NewMinimaxAI(DifficultyMedium)
+	minimax.SetTargetElo(2300)
+	if s := minimax.Strength(); s.Elo != 2300 || s.Depth != 6 {
+		t.Errorf("unexpected strength %+v", s)
+	}
+	if minimax.GetDifficulty() != DifficultyMedium {
+		t.Errorf("expected the difficulty to be unchanged")
+	}
+
+	minimax.SetEloTargets(map[Difficulty]int{DifficultyMedium: 800})
+	if s := minimax.Strength(); s.Elo != 800 || s.Depth != 1 {
+		t.Errorf("expected the configured target for medium, got %+v", s)
+	}
+	minimax.SetDifficulty(DifficultyHard)
+	if s := minimax.Strength(); s.Elo != DefaultEloTargets[DifficultyHard] {
+		t.Errorf("expected the default target for hard, got %+v", s)
+	}
+}
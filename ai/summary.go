@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// DefaultTurningPoints is the number of turning points a game summary covers.
+const DefaultTurningPoints = 3
+
+// TurningPoints returns up to n of the game's costliest mistakes and blunders, in
+// the order they were played.
+func (a *GameAnalysis) TurningPoints(n int) []MoveAnalysis {
+	var points []MoveAnalysis
+	for _, m := range a.Moves {
+		if m.Class == ClassMistake || m.Class == ClassBlunder {
+			points = append(points, m)
+		}
+	}
+	slices.SortStableFunc(points, func(x, y MoveAnalysis) int { return y.CentipawnLoss - x.CentipawnLoss })
+	points = points[:min(n, len(points))]
+	slices.SortFunc(points, func(x, y MoveAnalysis) int { return x.Ply - y.Ply })
+	return points
+}
+
+// DescribeGame summarizes a finished game in a few plain sentences from its
+// analysis: the opening if known, the result and its turning points. It is the
+// summary used when no LLM is available.
+func DescribeGame(game *engine.Game, report *GameAnalysis, opening string) string {
+	var sentences []string
+	if opening != "" {
+		sentences = append(sentences, fmt.Sprintf("The game opened with the %s.", opening))
+	}
+	points := report.TurningPoints(DefaultTurningPoints)
+	for i, m := range points {
+		prefix := "The turning point was"
+		if len(points) > 1 {
+			prefix = []string{"The first turning point was", "Then came", "Finally,"}[min(i, 2)]
+		}
+		sentences = append(sentences, fmt.Sprintf("%s %s.", prefix, describeTurningPoint(m)))
+	}
+	if len(points) == 0 && len(report.Moves) > 0 {
+		sentences = append(sentences, "Neither side made a serious mistake.")
+	}
+	sentences = append(sentences, describeResult(game)+".")
+	return strings.Join(sentences, " ")
+}
+
+// SummarizeGame asks the LLM for a short narrative of a finished game, built on the
+// analysis's turning points so the story matches what happened on the board.
+func (ai *LLMAIEngine) SummarizeGame(ctx context.Context, game *engine.Game, report *GameAnalysis, opening string) (string, error) {
+	response, err := ai.ask(ctx, "summary", generateSummaryPrompt(game, report, opening), ai.getSummarySystemPrompt())
+	if err != nil {
+		return "", err
+	}
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return "", fmt.Errorf("empty summary from %s", ai.config.Provider)
+	}
+	return response, nil
+}
+
+// getSummarySystemPrompt returns the system prompt for game summaries.
+func (ai *LLMAIEngine) getSummarySystemPrompt() string {
+	return `You are a chess journalist writing the summary of a finished game. Using only the facts given, tell the story of the game in one short paragraph of three to five sentences: the opening, the turning points and how the game ended. Use standard algebraic notation, no lists, no headings, and do not invent moves or evaluations.` + ai.languageSuffix()
+}
+
+// generateSummaryPrompt lists the facts of a finished game for its summary.
+func generateSummaryPrompt(game *engine.Game, report *GameAnalysis, opening string) string {
+	var sb strings.Builder
+	if opening != "" {
+		fmt.Fprintf(&sb, "Opening: %s\n", opening)
+	}
+	fmt.Fprintf(&sb, "Result: %s (%s)\n", game.Result(), describeResult(game))
+	fmt.Fprintf(&sb, "Accuracy: White %.0f%%, Black %.0f%%\n", report.White.Accuracy, report.Black.Accuracy)
+
+	sb.WriteString("Moves:")
+	for _, m := range report.Moves {
+		if m.Color == engine.White {
+			fmt.Fprintf(&sb, " %d.", m.MoveNumber)
+		}
+		sb.WriteString(" " + m.SAN)
+	}
+	sb.WriteString("\n")
+
+	if points := report.TurningPoints(DefaultTurningPoints); len(points) > 0 {
+		sb.WriteString("Turning points:\n")
+		for _, m := range points {
+			fmt.Fprintf(&sb, "- %s\n", describeTurningPoint(m))
+		}
+	} else {
+		sb.WriteString("Turning points: none, neither side made a serious mistake\n")
+	}
+	sb.WriteString("\nWrite the summary:")
+	return sb.String()
+}
+
+// describeTurningPoint describes a mistake, e.g. "23...Qxb2, a blunder that swung
+// the evaluation from +0.40 to -3.10 (Rd1 was better)".
+func describeTurningPoint(m MoveAnalysis) string {
+	number := fmt.Sprintf("%d.", m.MoveNumber)
+	if m.Color == engine.Black {
+		number = fmt.Sprintf("%d...", m.MoveNumber)
+	}
+	text := fmt.Sprintf("%s%s, a %s that swung the evaluation from %+.2f to %+.2f",
+		number, m.SAN, m.Class, float64(m.EvalBefore)/100, float64(m.EvalAfter)/100)
+	if len(m.BestLine) > 0 {
+		text += fmt.Sprintf(" (%s was better)", m.BestLine[0])
+	}
+	return text
+}
+
+// describeResult describes how a game ended, e.g. "White won by checkmate after
+// 34 moves".
+func describeResult(game *engine.Game) string {
+	result := game.Result()
+	moves := (len(game.MoveHistory()) + 1) / 2
+	switch {
+	case result.Termination == engine.TerminationNone:
+		return fmt.Sprintf("The game is still in progress after %d moves", moves)
+	case result.Winner == engine.None && result.Termination == engine.TerminationDraw:
+		return fmt.Sprintf("The game was drawn by %s after %d moves", strings.ReplaceAll(result.DrawReason.String(), "_", " "), moves)
+	case result.Winner == engine.None:
+		return fmt.Sprintf("The game was drawn by %s after %d moves", result.Termination, moves)
+	}
+	winner := "White"
+	if result.Winner == engine.Black {
+		winner = "Black"
+	}
+	how := map[engine.Termination]string{
+		engine.TerminationCheckmate:   "by checkmate",
+		engine.TerminationResignation: "by resignation",
+		engine.TerminationTimeout:     "on time",
+		engine.TerminationAbandonment: "by abandonment",
+	}[result.Termination]
+	return fmt.Sprintf("%s won %s after %d moves", winner, how, moves)
+}
@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestGameAnalysis_TurningPoints(t *testing.T) {
+	report := &GameAnalysis{Moves: []MoveAnalysis{
+		{Ply: 1, Class: ClassBest},
+		{Ply: 2, Class: ClassMistake, CentipawnLoss: 150},
+		{Ply: 3, Class: ClassInaccuracy, CentipawnLoss: 60},
+		{Ply: 4, Class: ClassBlunder, CentipawnLoss: 400},
+		{Ply: 5, Class: ClassBlunder, CentipawnLoss: 900},
+	}}
+	points := report.TurningPoints(2)
+	if len(points) != 2 || points[0].Ply != 4 || points[1].Ply != 5 {
+		t.Errorf("expected the two costliest mistakes in game order, got %+v", points)
+	}
+	if len((&GameAnalysis{}).TurningPoints(3)) != 0 {
+		t.Error("expected no turning points without moves")
+	}
+}
+
+func TestSummarizeGame(t *testing.T) {
+	// Fool's mate: 2.g4 lets the queen mate at once
+	game := engine.NewGame()
+	for _, san := range []string{"f3", "e5", "g4", "Qh4#"} {
+		move, _ := game.MoveFromSAN(san)
+		_ = game.MakeMove(move)
+	}
+	report, err := AnalyzeGame(context.Background(), game, AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeGame: %v", err)
+	}
+
+	described := DescribeGame(game, report, "Barnes Opening")
+	for _, want := range []string{"Barnes Opening", "2.g4, a blunder", "Black won by checkmate after 2 moves"} {
+		if !strings.Contains(described, want) {
+			t.Errorf("expected %q in %q", want, described)
+		}
+	}
+
+	var prompt string
+	ai, _ := NewLLMAIEngine(LLMConfig{Provider: ProviderOpenAI, APIKey: "x"})
+	mock := newMockClient(`{"choices":[{"message":{"content":" White weakened the king and paid at once. "}}]}`, 200)
+	ai.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(r.Body)
+		prompt = string(body)
+		return mock.Transport.RoundTrip(r)
+	})}
+	summary, err := ai.SummarizeGame(context.Background(), game, report, "Barnes Opening")
+	if err != nil || summary != "White weakened the king and paid at once." {
+		t.Fatalf("expected the trimmed summary, got %q: %v", summary, err)
+	}
+	for _, want := range []string{"Opening: Barnes Opening", "Result: 0-1", "1. f3 e5 2. g4 Qh4#", "2.g4, a blunder"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in the prompt %s", want, prompt)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// tablebaseTimeout bounds a tablebase probe.
+const tablebaseTimeout = 5 * time.Second
+
+// TablebaseMaxPieces is the most pieces, kings included, that tablebases cover.
+const TablebaseMaxPieces = 7
+
+// ErrNotInTablebase is returned by Probe for positions tablebases do not cover.
+var ErrNotInTablebase = errors.New("position not covered by tablebases")
+
+// TablebaseResult is a tablebase's verdict on a position.
+type TablebaseResult struct {
+	// Category is the outcome for the side to move: win, loss or draw, or
+	// cursed-win and blessed-loss for wins the 50-move rule turns into draws.
+	Category string
+	DTZ      *int   // plies to the next capture or pawn move, if known
+	DTM      *int   // plies to mate, if known
+	BestMove string // SAN; empty if the game is over
+}
+
+// Tablebase probes endgame tablebases through a Lichess-compatible HTTP API,
+// such as https://tablebase.lichess.ovh/standard.
+type Tablebase struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewTablebase creates a tablebase client for the API at endpoint.
+func NewTablebase(endpoint string) *Tablebase {
+	return &Tablebase{endpoint: endpoint, httpClient: &http.Client{Timeout: tablebaseTimeout, Transport: otelhttp.NewTransport(http.DefaultTransport)}}
+}
+
+// Probe looks the game's position up, returning ErrNotInTablebase if it has
+// too many pieces, castling rights or is not standard chess.
+func (tb *Tablebase) Probe(ctx context.Context, game *engine.Game) (TablebaseResult, error) {
+	if !game.TablebaseApplicable(TablebaseMaxPieces) {
+		return TablebaseResult{}, ErrNotInTablebase
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tb.endpoint+"?fen="+url.QueryEscape(game.ToFEN()), nil)
+	if err != nil {
+		return TablebaseResult{}, err
+	}
+	resp, err := tb.httpClient.Do(req)
+	if err != nil {
+		return TablebaseResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return TablebaseResult{}, fmt.Errorf("tablebase returned status %d", resp.StatusCode)
+	}
+
+	var probe struct {
+		Category string `json:"category"`
+		DTZ      *int   `json:"dtz"`
+		DTM      *int   `json:"dtm"`
+		Moves    []struct {
+			SAN string `json:"san"`
+		} `json:"moves"` // best first
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&probe); err != nil {
+		return TablebaseResult{}, fmt.Errorf("failed to decode tablebase response: %w", err)
+	}
+	if probe.Category == "" || probe.Category == "unknown" {
+		return TablebaseResult{}, ErrNotInTablebase
+	}
+	result := TablebaseResult{Category: probe.Category, DTZ: probe.DTZ, DTM: probe.DTM}
+	if len(probe.Moves) > 0 {
+		result.BestMove = probe.Moves[0].SAN
+	}
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestTablebaseProbe(t *testing.T) {
+	var probed string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probed = r.URL.Query().Get("fen")
+		_, _ = w.Write([]byte(`{"category":"win","dtz":1,"dtm":19,"moves":[{"uci":"a1a8","san":"Ra8#","category":"loss"}]}`))
+	}))
+	defer server.Close()
+	tb := NewTablebase(server.URL)
+
+	game := engine.NewGame()
+	if err := game.ParseFEN("6k1/8/6K1/8/8/8/8/R7 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := tb.Probe(context.Background(), game)
+	if err != nil {
+		t.Fatalf("Probe: %v", err)
+	}
+	if probed != game.ToFEN() || result.Category != "win" || *result.DTZ != 1 || *result.DTM != 19 || result.BestMove != "Ra8#" {
+		t.Errorf("unexpected probe of %q: %+v", probed, result)
+	}
+
+	probed = ""
+	if _, err := tb.Probe(context.Background(), engine.NewGame()); !errors.Is(err, ErrNotInTablebase) || probed != "" {
+		t.Errorf("expected the starting position not to be probed, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"encoding/json"
+	"io"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// TraceEvent is one line of a search trace, written as JSON Lines. A move event
+// reports a move tried in the tree; an iteration event closes each iteration of
+// iterative deepening with its best line.
+type TraceEvent struct {
+	// Iteration is the nominal depth of the iteration the event belongs to.
+	Iteration int `json:"iteration"`
+	// Path is the UCI moves from the searched position to the move tried, or the
+	// best line for iteration events.
+	Path []string `json:"path"`
+	// Depth is the depth left after the move; 0 means only captures were searched.
+	Depth int `json:"depth"`
+	// Alpha and Beta are the search window the move was tried in and Score is its
+	// result, all in centipawns from the perspective of the side making the move.
+	// For iteration events Score is the best line's score for the side to move.
+	Alpha int `json:"alpha"`
+	Beta  int `json:"beta"`
+	Score int `json:"score"`
+	// Result is "best" when the move raised alpha, "cutoff" when it reached beta so
+	// its siblings were skipped, and "fail_low" otherwise. Iteration events are
+	// "complete" or "interrupted".
+	Result string `json:"result"`
+	// Nodes is the number of positions visited so far (iteration events).
+	Nodes int `json:"nodes,omitempty"`
+}
+
+// searchTrace writes a searcher's trace. Tracing stops at the first write error.
+type searchTrace struct {
+	enc       *json.Encoder
+	rootPly   int // moves played before the searched position
+	iteration int
+	failed    bool
+}
+
+// newSearchTrace traces a search of game to w.
+func newSearchTrace(w io.Writer, game *engine.Game) *searchTrace {
+	return &searchTrace{enc: json.NewEncoder(w), rootPly: len(game.MoveHistory())}
+}
+
+// move records a move just undone in game. score is the move's result and alpha
+// and beta the window, all from the mover's perspective.
+func (t *searchTrace) move(game *engine.Game, move engine.Move, depth, alpha, beta, score int) {
+	result := "fail_low"
+	switch {
+	case score >= beta:
+		result = "cutoff"
+	case score > alpha:
+		result = "best"
+	}
+	history := game.MoveHistory()[t.rootPly:]
+	path := make([]string, 0, len(history)+1)
+	for _, m := range history {
+		path = append(path, m.UCI())
+	}
+	t.write(TraceEvent{
+		Iteration: t.iteration,
+		Path:      append(path, move.UCI()),
+		Depth:     depth,
+		Alpha:     alpha,
+		Beta:      beta,
+		Score:     score,
+		Result:    result,
+	})
+}
+
+// done records the end of an iteration with its best line, if any.
+func (t *searchTrace) done(lines []Line, nodes int, interrupted bool) {
+	event := TraceEvent{Iteration: t.iteration, Path: []string{}, Result: "complete", Nodes: nodes}
+	if interrupted {
+		event.Result = "interrupted"
+	}
+	if len(lines) > 0 {
+		for _, m := range lines[0].Moves {
+			event.Path = append(event.Path, m.UCI())
+		}
+		event.Score = lines[0].Score
+	}
+	t.write(event)
+}
+
+func (t *searchTrace) write(event TraceEvent) {
+	if t.failed {
+		return
+	}
+	if err := t.enc.Encode(event); err != nil {
+		t.failed = true
+	}
+}
@@ -0,0 +1,73 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestMinimaxAI_Trace(t *testing.T) {
+	// Back-rank mate: Ra8#
+	game := gameFromFEN(t, "6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1")
+	var trace bytes.Buffer
+	minimax := NewMinimaxAI(DifficultyMedium, WithTrace(&trace))
+	minimax.SetLimits(SearchLimits{Depth: 2})
+	minimax.strength.ErrorRate = 0
+	move, err := minimax.GetBestMove(context.Background(), game)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []TraceEvent
+	scanner := bufio.NewScanner(&trace)
+	for scanner.Scan() {
+		var event TraceEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("invalid trace line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	var iterations, cutoffs int
+	var last TraceEvent
+	for _, event := range events {
+		switch event.Result {
+		case "complete":
+			iterations++
+			last = event
+		case "cutoff":
+			cutoffs++
+			if event.Score < event.Beta {
+				t.Errorf("cutoff below beta: %+v", event)
+			}
+		case "best", "fail_low":
+		default:
+			t.Errorf("unexpected result %q", event.Result)
+		}
+		if event.Iteration < 1 || event.Iteration > 2 {
+			t.Errorf("unexpected iteration in %+v", event)
+		}
+	}
+	if iterations != 2 || cutoffs == 0 {
+		t.Fatalf("expected two iterations with cutoffs, got %d and %d in %d events", iterations, cutoffs, len(events))
+	}
+	if len(last.Path) == 0 || last.Path[0] != move.UCI() || last.Score < mateScore-maxMatePly {
+		t.Errorf("expected the trace to end with the mating line, got %+v", last)
+	}
+
+	// Root moves are traced with one-move paths
+	for _, event := range events {
+		if event.Iteration == 2 && len(event.Path) == 1 && event.Path[0] == "a1a8" && event.Result == "fail_low" {
+			t.Errorf("expected Ra8# to be the best root move, got %+v", event)
+		}
+	}
+
+	// Without a writer nothing is traced
+	minimax.SetTrace(nil)
+	trace.Reset()
+	if _, err := minimax.GetBestMove(context.Background(), game); err != nil || trace.Len() != 0 {
+		t.Errorf("expected no trace, got %d bytes: %v", trace.Len(), err)
+	}
+}
@@ -0,0 +1,370 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// uciHandshakeTimeout bounds the "uci"/"isready" exchange with a freshly started engine.
+const uciHandshakeTimeout = 10 * time.Second
+
+// uciStopTimeout bounds the wait for "bestmove" after a search is stopped.
+const uciStopTimeout = 500 * time.Millisecond
+
+// UCIEngine plays moves chosen by an external engine (e.g. Stockfish) speaking the
+// Universal Chess Interface over stdin/stdout. The process is started on the first
+// GetBestMove and reused until Close.
+type UCIEngine struct {
+	path       string
+	args       []string
+	difficulty Difficulty
+	limits     SearchLimits
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan string
+	name   string
+	skill  bool // engine advertises a "Skill Level" option
+	closed bool
+}
+
+// NewUCIEngine creates an engine backed by the UCI binary at path, started with args.
+func NewUCIEngine(path string, difficulty Difficulty, args ...string) *UCIEngine {
+	return &UCIEngine{path: path, args: args, difficulty: difficulty}
+}
+
+// uciMoveTime maps a difficulty level to the thinking time per move.
+func uciMoveTime(difficulty Difficulty) time.Duration {
+	switch difficulty {
+	case DifficultyBeginner:
+		return 50 * time.Millisecond
+	case DifficultyEasy:
+		return 100 * time.Millisecond
+	case DifficultyMedium:
+		return 300 * time.Millisecond
+	case DifficultyHard:
+		return time.Second
+	case DifficultyExpert:
+		return 3 * time.Second
+	default:
+		return 300 * time.Millisecond
+	}
+}
+
+// uciGo builds the "go" command for a difficulty level and search limits.
+func uciGo(difficulty Difficulty, limits SearchLimits) string {
+	moveTime := uciMoveTime(difficulty)
+	if limits.MoveTime > 0 {
+		moveTime = limits.MoveTime
+	}
+	command := fmt.Sprintf("go movetime %d", moveTime.Milliseconds())
+	if limits.Depth > 0 {
+		command += fmt.Sprintf(" depth %d", limits.Depth)
+	}
+	if limits.Nodes > 0 {
+		command += fmt.Sprintf(" nodes %d", limits.Nodes)
+	}
+	return command
+}
+
+// uciSkillLevel maps a difficulty level to the Stockfish-style "Skill Level" option (0-20).
+func uciSkillLevel(difficulty Difficulty) int {
+	switch difficulty {
+	case DifficultyBeginner:
+		return 0
+	case DifficultyEasy:
+		return 5
+	case DifficultyMedium:
+		return 10
+	case DifficultyHard:
+		return 15
+	default:
+		return 20
+	}
+}
+
+// Name returns the engine name reported in the UCI handshake ("id name"), or an
+// empty string before the engine has been started.
+func (e *UCIEngine) Name() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.name
+}
+
+// GetBestMove sends the game to the engine ("position fen ... moves ...") and asks it
+// to think for the difficulty's move time ("go movetime"), or as the search limits
+// say. When ctx ends first, the
+// search is stopped and the engine's best move so far is returned, unless it fails
+// to answer "stop" in time.
+func (e *UCIEngine) GetBestMove(ctx context.Context, game *engine.Game) (engine.Move, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.start(ctx); err != nil {
+		return engine.Move{}, err
+	}
+	if e.skill {
+		if err := e.send(fmt.Sprintf("setoption name Skill Level value %d", uciSkillLevel(e.difficulty))); err != nil {
+			return engine.Move{}, err
+		}
+	}
+	if err := e.send(uciPosition(game)); err != nil {
+		return engine.Move{}, err
+	}
+	if err := e.send(uciGo(e.difficulty, e.limits)); err != nil {
+		return engine.Move{}, err
+	}
+
+	line, err := e.waitFor(ctx, "bestmove")
+	if err != nil && ctx.Err() != nil && e.send("stop") == nil {
+		stopCtx, cancel := context.WithTimeout(context.Background(), uciStopTimeout)
+		line, err = e.waitFor(stopCtx, "bestmove")
+		cancel()
+		if err != nil {
+			err = ctx.Err()
+		}
+	}
+	if err != nil {
+		// The engine is still thinking or gone; start afresh next time
+		e.stop()
+		return engine.Move{}, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[1] == "(none)" || fields[1] == "0000" {
+		return engine.Move{}, errors.New("no legal moves available")
+	}
+	move, err := engine.MoveFromUCI(game, fields[1])
+	if err != nil {
+		return engine.Move{}, fmt.Errorf("uci engine returned an invalid move: %w", err)
+	}
+	return move, nil
+}
+
+// GetDifficulty returns the current difficulty level.
+func (e *UCIEngine) GetDifficulty() Difficulty {
+	return e.difficulty
+}
+
+// SetDifficulty sets the difficulty level, which controls the move time and skill level.
+func (e *UCIEngine) SetDifficulty(difficulty Difficulty) {
+	e.difficulty = difficulty
+}
+
+// SetLimits replaces the difficulty's move time and adds depth and node limits to
+// the engine's search ("go ... depth ... nodes").
+func (e *UCIEngine) SetLimits(limits SearchLimits) {
+	e.limits = limits
+}
+
+// Close asks the engine to quit and stops the process.
+func (e *UCIEngine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	e.stop()
+	return nil
+}
+
+// UCIPool shares a bounded number of UCIEngines of one binary, whose processes
+// live on between moves, so that a request does not start an engine of its own.
+// It is safe for concurrent use.
+type UCIPool struct {
+	path  string
+	args  []string
+	idle  chan *UCIEngine
+	slots chan struct{} // one per engine created
+}
+
+// NewUCIPool creates a pool of at most size engines of the UCI binary at path,
+// started with args as they are first needed.
+func NewUCIPool(path string, size int, args ...string) *UCIPool {
+	return &UCIPool{path: path, args: args, idle: make(chan *UCIEngine, size), slots: make(chan struct{}, size)}
+}
+
+// Get returns an idle engine set to difficulty, without search limits, or a new
+// one if the pool is not full. Otherwise it waits for one to be put back until
+// ctx ends.
+func (p *UCIPool) Get(ctx context.Context, difficulty Difficulty) (*UCIEngine, error) {
+	var e *UCIEngine
+	select {
+	case e = <-p.idle:
+	default:
+		select {
+		case e = <-p.idle:
+		case p.slots <- struct{}{}:
+			return NewUCIEngine(p.path, difficulty, p.args...), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	e.SetDifficulty(difficulty)
+	e.SetLimits(SearchLimits{})
+	return e, nil
+}
+
+// Put returns an engine from Get to the pool.
+func (p *UCIPool) Put(e *UCIEngine) {
+	p.idle <- e
+}
+
+// start launches the engine process and performs the UCI handshake if needed.
+func (e *UCIEngine) start(ctx context.Context) error {
+	if e.closed {
+		return errors.New("uci engine is closed")
+	}
+	if e.cmd != nil {
+		return nil
+	}
+	if e.path == "" {
+		return errors.New("no uci engine path configured")
+	}
+
+	cmd := exec.Command(e.path, e.args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open uci engine stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open uci engine stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start uci engine %s: %w", e.path, err)
+	}
+	lines := make(chan string, 64)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			lines <- strings.TrimSpace(scanner.Text())
+		}
+	}()
+	e.cmd, e.stdin, e.lines = cmd, stdin, lines
+
+	ctx, cancel := context.WithTimeout(ctx, uciHandshakeTimeout)
+	defer cancel()
+	if err := e.handshake(ctx); err != nil {
+		e.stop()
+		return fmt.Errorf("uci handshake failed: %w", err)
+	}
+	return nil
+}
+
+// handshake sends "uci" and "isready", recording the engine name and options.
+func (e *UCIEngine) handshake(ctx context.Context) error {
+	if err := e.send("uci"); err != nil {
+		return err
+	}
+	for {
+		line, err := e.next(ctx)
+		if err != nil {
+			return err
+		}
+		switch {
+		case strings.HasPrefix(line, "id name "):
+			e.name = strings.TrimPrefix(line, "id name ")
+		case strings.HasPrefix(line, "option name Skill Level "):
+			e.skill = true
+		}
+		if line == "uciok" {
+			break
+		}
+	}
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	_, err := e.waitFor(ctx, "readyok")
+	return err
+}
+
+// send writes one command line to the engine.
+func (e *UCIEngine) send(command string) error {
+	if _, err := io.WriteString(e.stdin, command+"\n"); err != nil {
+		return fmt.Errorf("failed to write to uci engine: %w", err)
+	}
+	return nil
+}
+
+// next returns the next line of engine output.
+func (e *UCIEngine) next(ctx context.Context) (string, error) {
+	select {
+	case line, ok := <-e.lines:
+		if !ok {
+			return "", errors.New("uci engine exited")
+		}
+		return line, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// waitFor skips engine output until a line starting with the given token.
+func (e *UCIEngine) waitFor(ctx context.Context, token string) (string, error) {
+	for {
+		line, err := e.next(ctx)
+		if err != nil {
+			return "", err
+		}
+		if line == token || strings.HasPrefix(line, token+" ") {
+			return line, nil
+		}
+	}
+}
+
+// stop shuts the process down, politely first.
+func (e *UCIEngine) stop() {
+	if e.cmd == nil {
+		return
+	}
+	_ = e.send("quit")
+	_ = e.stdin.Close()
+	go func(lines <-chan string) {
+		for range lines { // let the reader finish
+		}
+	}(e.lines)
+	done := make(chan struct{})
+	go func(cmd *exec.Cmd) {
+		_ = cmd.Wait()
+		close(done)
+	}(e.cmd)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		_ = e.cmd.Process.Kill()
+		<-done
+	}
+	e.cmd, e.stdin, e.lines = nil, nil, nil
+}
+
+// uciPosition describes the game as a UCI "position" command. Games played from the
+// initial position are sent as "startpos" plus their moves, so the engine sees the
+// repetition history; other games are sent as the current FEN.
+func uciPosition(game *engine.Game) string {
+	history := game.MoveHistory()
+	replay := engine.NewGame()
+	var sb strings.Builder
+	sb.WriteString("position startpos")
+	if len(history) > 0 {
+		sb.WriteString(" moves")
+	}
+	for _, move := range history {
+		if !replay.IsLegalMove(move) || replay.MakeMove(move) != nil {
+			return "position fen " + game.ToFEN()
+		}
+		sb.WriteString(" " + move.UCI())
+	}
+	if game.Variant() != engine.Standard || replay.ToFEN() != game.ToFEN() {
+		return "position fen " + game.ToFEN()
+	}
+	return sb.String()
+}
@@ -0,0 +1,231 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// TestUCIHelperProcess is not a real test: it acts as a minimal UCI engine when the
+// test binary is started by newFakeUCIEngine. It plays the first legal move, only
+// once told to "stop" in "think" mode and never in "hang" mode.
+func TestUCIHelperProcess(t *testing.T) {
+	mode := os.Getenv("GO_CHESS_UCI_HELPER")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	game := engine.NewGame()
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Println("id name FakeFish 1.0")
+			fmt.Println("option name Skill Level type spin default 20 min 0 max 20")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "position":
+			game = fakeUCIPosition(fields[1:])
+		case "go", "stop":
+			if mode == "hang" || (mode == "think") == (fields[0] == "go") {
+				continue
+			}
+			moves := game.GetAllLegalMoves()
+			fmt.Println("info depth 1 score cp 0")
+			if len(moves) == 0 {
+				fmt.Println("bestmove (none)")
+			} else {
+				fmt.Println("bestmove " + moves[0].UCI())
+			}
+		case "quit":
+			return
+		}
+	}
+}
+
+// fakeUCIPosition rebuilds the game from the arguments of a "position" command.
+func fakeUCIPosition(args []string) *engine.Game {
+	game := engine.NewGame()
+	i := 1
+	if args[0] == "fen" {
+		i = 7
+		if err := game.ParseFEN(strings.Join(args[1:7], " ")); err != nil {
+			panic(err)
+		}
+	}
+	if i < len(args) && args[i] == "moves" {
+		for _, uci := range args[i+1:] {
+			move, err := engine.MoveFromUCI(game, uci)
+			if err != nil {
+				panic(err)
+			}
+			if err := game.MakeMove(move); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return game
+}
+
+func newFakeUCIEngine(t *testing.T, mode string) *UCIEngine {
+	t.Helper()
+	t.Setenv("GO_CHESS_UCI_HELPER", mode)
+	e := NewUCIEngine(os.Args[0], DifficultyBeginner, "-test.run=^TestUCIHelperProcess$")
+	t.Cleanup(func() { _ = e.Close() })
+	return e
+}
+
+func TestUCIEngine_GetBestMove(t *testing.T) {
+	e := newFakeUCIEngine(t, "play")
+	game := engine.NewGame()
+	for ply := 0; ply < 4; ply++ {
+		move, err := e.GetBestMove(context.Background(), game)
+		if err != nil {
+			t.Fatalf("ply %d: GetBestMove: %v", ply, err)
+		}
+		if want := game.GetAllLegalMoves()[0]; move != want {
+			t.Fatalf("ply %d: expected %s, got %s", ply, want, move)
+		}
+		if err := game.MakeMove(move); err != nil {
+			t.Fatalf("ply %d: MakeMove: %v", ply, err)
+		}
+	}
+	if e.Name() != "FakeFish 1.0" {
+		t.Errorf("expected engine name from handshake, got %q", e.Name())
+	}
+
+	// Checkmated: the engine has no move to offer
+	mated := gameFromFEN(t, "R5k1/5ppp/8/8/8/8/5PPP/6K1 b - - 0 1")
+	if _, err := e.GetBestMove(context.Background(), mated); err == nil {
+		t.Errorf("expected an error without legal moves")
+	}
+}
+
+func TestUCIEngine_Cancelled(t *testing.T) {
+	e := newFakeUCIEngine(t, "hang")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := e.GetBestMove(ctx, engine.NewGame()); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	// The hung process was stopped and a fresh one is started on demand
+	t.Setenv("GO_CHESS_UCI_HELPER", "play")
+	if _, err := e.GetBestMove(context.Background(), engine.NewGame()); err != nil {
+		t.Errorf("expected the engine to restart: %v", err)
+	}
+}
+
+func TestUCIEngine_StoppedEarly(t *testing.T) {
+	e := newFakeUCIEngine(t, "think")
+	// Start the process up front so the deadline only covers the search
+	e.mu.Lock()
+	err := e.start(context.Background())
+	e.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	game := engine.NewGame()
+	move, err := e.GetBestMove(ctx, game)
+	if err != nil {
+		t.Fatalf("expected the best move so far after stopping, got %v", err)
+	}
+	if !game.IsLegalMove(move) {
+		t.Errorf("expected a legal move, got %s", move.UCI())
+	}
+}
+
+func TestUCIEngine_Errors(t *testing.T) {
+	if _, err := NewUCIEngine("", DifficultyMedium).GetBestMove(context.Background(), engine.NewGame()); err == nil {
+		t.Errorf("expected an error without a binary path")
+	}
+	if _, err := NewUCIEngine("/nonexistent/stockfish", DifficultyMedium).GetBestMove(context.Background(), engine.NewGame()); err == nil {
+		t.Errorf("expected an error for a missing binary")
+	}
+	closed := NewUCIEngine(os.Args[0], DifficultyMedium)
+	_ = closed.Close()
+	if _, err := closed.GetBestMove(context.Background(), engine.NewGame()); err == nil {
+		t.Errorf("expected an error after Close")
+	}
+}
+
+func TestUCIPool(t *testing.T) {
+	t.Setenv("GO_CHESS_UCI_HELPER", "play")
+	pool := NewUCIPool(os.Args[0], 1, "-test.run=^TestUCIHelperProcess$")
+	first, err := pool.Get(context.Background(), DifficultyHard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	first.SetLimits(SearchLimits{Depth: 3})
+	if _, err := first.GetBestMove(context.Background(), engine.NewGame()); err != nil {
+		t.Fatal(err)
+	}
+	process := first.cmd
+
+	// The pool is full until the engine is put back
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Get(ctx, DifficultyEasy); err != context.DeadlineExceeded {
+		t.Fatalf("expected to wait for the only engine, got %v", err)
+	}
+	pool.Put(first)
+	again, err := pool.Get(context.Background(), DifficultyEasy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != first || again.GetDifficulty() != DifficultyEasy || again.limits != (SearchLimits{}) {
+		t.Fatalf("expected the engine reset for reuse, got difficulty %v, limits %+v", again.GetDifficulty(), again.limits)
+	}
+	if _, err := again.GetBestMove(context.Background(), engine.NewGame()); err != nil || again.cmd != process {
+		t.Errorf("expected the running process reused (%v)", err)
+	}
+}
+
+func TestUCIGo(t *testing.T) {
+	if got := uciGo(DifficultyHard, SearchLimits{}); got != "go movetime 1000" {
+		t.Errorf("unexpected default command %q", got)
+	}
+	limits := SearchLimits{Depth: 12, Nodes: 50000, MoveTime: 250 * time.Millisecond}
+	if got := uciGo(DifficultyHard, limits); got != "go movetime 250 depth 12 nodes 50000" {
+		t.Errorf("unexpected limited command %q", got)
+	}
+}
+
+func TestUCIPosition(t *testing.T) {
+	game := engine.NewGame()
+	if got := uciPosition(game); got != "position startpos" {
+		t.Errorf("unexpected start position command %q", got)
+	}
+	for _, uci := range []string{"e2e4", "e7e5", "g1f3"} {
+		move, _ := engine.MoveFromUCI(game, uci)
+		if err := game.MakeMove(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := uciPosition(game); got != "position startpos moves e2e4 e7e5 g1f3" {
+		t.Errorf("unexpected position command %q", got)
+	}
+
+	game = gameFromFEN(t, "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1")
+	move, _ := engine.MoveFromUCI(game, "e2e4")
+	if err := game.MakeMove(move); err != nil {
+		t.Fatal(err)
+	}
+	if got := uciPosition(game); got != "position fen "+game.ToFEN() {
+		t.Errorf("unexpected position command %q", got)
+	}
+}
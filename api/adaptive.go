@@ -0,0 +1,71 @@
+package api
+
+import (
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// AdaptiveResponse reports how an adaptive game's AI is tuned to the player.
+type AdaptiveResponse struct {
+	EloOffset int     `json:"elo_offset"` // added to the requested level's target rating
+	Accuracy  float64 `json:"accuracy"`   // the player's average accuracy over recent moves, in percent
+	Moves     int     `json:"moves"`      // player moves graded so far
+	Eval      int     `json:"eval"`       // centipawns for the player after their last move
+}
+
+// adaptiveModel returns the player model of an adaptive game when mover is the
+// player rather than the AI, or nil.
+func (s *Server) adaptiveModel(gameID int, mover engine.Color) *ai.AdaptiveStrength {
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	metadata, exists := s.gameMetadata[gameID]
+	if !exists || metadata.adaptive == nil || metadata.AIColor == mover.String() {
+		return nil
+	}
+	return metadata.adaptive
+}
+
+// recordAdaptive feeds a graded player move to the game's model.
+func (s *Server) recordAdaptive(gameID int, model *ai.AdaptiveStrength, ma ai.MoveAnalysis) {
+	s.gamesMux.Lock()
+	model.Record(ma)
+	offset, accuracy := model.Offset(), model.Accuracy()
+	s.gamesMux.Unlock()
+	s.logger.Debug("Adapted AI strength",
+		zap.Int("game_id", gameID),
+		zap.Int("elo_offset", offset),
+		zap.Float64("accuracy", accuracy))
+}
+
+// applyAdaptiveStrength retunes a minimax AI, alone or in a hybrid engine, to the
+// player of an adaptive game and returns its target rating, or 0 if nothing was
+// changed. Other engines have no rating to tune and play at their level.
+func (s *Server) applyAdaptiveStrength(gameID int, aiEngine ai.Engine) int {
+	minimax, ok := aiEngine.(*ai.MinimaxAI)
+	if hybrid, isHybrid := aiEngine.(*ai.HybridEngine); isHybrid {
+		minimax, ok = hybrid.MinimaxAI, true
+	}
+	if !ok {
+		return 0
+	}
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	metadata, exists := s.gameMetadata[gameID]
+	if !exists || metadata.adaptive == nil {
+		return 0
+	}
+	elo := metadata.adaptive.Elo(minimax.Strength().Elo)
+	minimax.SetTargetElo(elo)
+	return elo
+}
+
+func adaptiveToResponse(model *ai.AdaptiveStrength) *AdaptiveResponse {
+	return &AdaptiveResponse{
+		EloOffset: model.Offset(),
+		Accuracy:  roundTenth(model.Accuracy()),
+		Moves:     model.Moves(),
+		Eval:      model.Eval(),
+	}
+}
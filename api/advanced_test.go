@@ -342,6 +342,57 @@ func TestGetAIMove(t *testing.T) {
 			t.Errorf("Expected %s in AI response", field)
 		}
 	}
+	// Search statistics
+	search, ok := aiResp["search"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected search info in AI response: %v", aiResp)
+	}
+	for _, field := range []string{"nodes", "depth", "nps", "tt_hit_rate", "time_ms"} {
+		if _, ok := search[field]; !ok {
+			t.Errorf("Expected %s in search info", field)
+		}
+	}
+}
+
+// TestApplyAIMove tests that the AI move endpoint plays the move with apply
+func TestApplyAIMove(t *testing.T) {
+	_, router := newTestServerAndRouter()
+	id := createGame(t, router)
+	base := "/api/games/" + itoa(id)
+
+	moveReq, _ := http.NewRequest("POST", base+"/moves", strings.NewReader(`{"from":"e2","to":"e4"}`))
+	moveReq.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), moveReq)
+
+	aiReq, _ := http.NewRequest("POST", base+"/ai-move", strings.NewReader(`{"engine":"random","apply":true}`))
+	aiReq.Header.Set("Content-Type", "application/json")
+	aiRR := httptest.NewRecorder()
+	router.ServeHTTP(aiRR, aiReq)
+	if aiRR.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Response: %s", aiRR.Code, aiRR.Body.String())
+	}
+
+	var aiResp struct {
+		Notation string       `json:"notation"`
+		Applied  bool         `json:"applied"`
+		Game     GameResponse `json:"game"`
+	}
+	if err := json.Unmarshal(aiRR.Body.Bytes(), &aiResp); err != nil || !aiResp.Applied {
+		t.Fatalf("Expected the AI move applied: %s", aiRR.Body.String())
+	}
+	history := aiResp.Game.MoveHistory
+	if len(history) != 2 || history[1].Notation != aiResp.Notation || aiResp.Game.ActiveColor != "white" {
+		t.Fatalf("Expected the AI move played in the returned game: %+v", aiResp.Game)
+	}
+
+	// It is the player's turn again, so a second request is refused
+	aiRR = httptest.NewRecorder()
+	aiReq, _ = http.NewRequest("POST", base+"/ai-move", strings.NewReader(`{"engine":"random","apply":true}`))
+	aiReq.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(aiRR, aiReq)
+	if aiRR.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 on the player's turn, got %d", aiRR.Code)
+	}
 }
 
 // TestGetAIMoveWrongTurn tests the AI move endpoint when it's not AI's turn
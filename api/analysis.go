@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// analysisTimeout bounds each search of an analysis without a movetime.
+const analysisTimeout = 10 * time.Second
+
+// analysisCandidates is the number of candidate moves an analysis reports.
+const analysisCandidates = 3
+
+// CandidateMoveResponse is one of the best moves in a position with its score.
+type CandidateMoveResponse struct {
+	Move    string  `json:"move"`           // SAN
+	Score   float64 `json:"score"`          // pawns, White's perspective
+	ScoreCp int     `json:"score_cp"`       // centipawns, White's perspective
+	Mate    int     `json:"mate,omitempty"` // moves to mate; positive if White mates
+}
+
+// TablebaseResponse is the tablebase verdict on a position.
+type TablebaseResponse struct {
+	Category string `json:"category"` // for the side to move: win, loss, draw, cursed-win or blessed-loss
+	DTZ      *int   `json:"dtz,omitempty"`
+	DTM      *int   `json:"dtm,omitempty"`
+	BestMove string `json:"best_move,omitempty"` // SAN
+}
+
+// analysisLimits reads an analysis's depth (plies) and movetime (milliseconds)
+// query parameters, writing an error response if they are invalid or exceed the
+// configured caps.
+func (s *Server) analysisLimits(c *gin.Context) (ai.SearchLimits, bool) {
+	var depth, moveTimeMs int
+	for _, param := range []struct {
+		name  string
+		value *int
+		max   int
+	}{
+		{"depth", &depth, s.config.AI.MaxDepth},
+		{"movetime", &moveTimeMs, int(s.config.AI.MaxThinkTime.Milliseconds())},
+	} {
+		if value := c.Query(param.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > param.max {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_search_limits",
+					Message: fmt.Sprintf("%s must be between 1 and %d", param.name, param.max),
+				})
+				return ai.SearchLimits{}, false
+			}
+			*param.value = n
+		}
+	}
+	return ai.SearchLimits{Depth: depth, MoveTime: time.Duration(moveTimeMs) * time.Millisecond}, true
+}
+
+// engineAnalysis adds the engine's view of a position, which the caller must
+// not change meanwhile, to an analysis: the best line and up to lineCount
+// principal variations, the top candidate moves, the threat the opponent would
+// play if it were their move, search statistics and, when configured and the
+// position qualifies, the tablebase verdict.
+func (s *Server) engineAnalysis(ctx context.Context, gameID int, game *engine.Game, limits ai.SearchLimits, lineCount int, analysis map[string]interface{}) {
+	analyzer := ai.NewMinimaxAI(ai.DifficultyMedium)
+	analyzer.SetEvaluator(s.evaluator)
+	analyzer.SetLimits(limits)
+	search := func(position *engine.Game, lines int) ([]ai.Line, ai.SearchInfo, error) {
+		ctx, cancel := context.WithTimeout(ctx, max(analysisTimeout, limits.MoveTime))
+		defer cancel()
+		return ai.AnalyzeLines(ctx, analyzer, position, lines)
+	}
+
+	lines, info, err := search(game, max(clampPVLines(lineCount), analysisCandidates))
+	if err == nil && len(lines) > 0 {
+		pv := pvResponse(game, lines)
+		analysis["pv"] = pv[:min(len(pv), clampPVLines(lineCount))]
+		analysis["best_line"] = pv[0]
+		candidates := make([]CandidateMoveResponse, 0, analysisCandidates)
+		for _, line := range pv[:min(len(pv), analysisCandidates)] {
+			if len(line.Moves) > 0 {
+				candidates = append(candidates, CandidateMoveResponse{Move: line.Moves[0], Score: line.Score, ScoreCp: line.ScoreCp, Mate: line.Mate})
+			}
+		}
+		analysis["candidates"] = candidates
+		analysis["search"] = searchInfoResponse(info)
+	}
+
+	// The threat is the opponent's best move after passing the turn to them
+	passed := game.Clone()
+	if passed.MakeNullMove() == nil {
+		if lines, _, err := search(passed, 1); err == nil && len(lines) > 0 {
+			analysis["threat"] = pvResponse(passed, lines)[0]
+		}
+	}
+
+	if s.tablebase != nil {
+		result, err := s.tablebase.Probe(ctx, game)
+		switch {
+		case err == nil:
+			analysis["tablebase"] = TablebaseResponse{Category: result.Category, DTZ: result.DTZ, DTM: result.DTM, BestMove: result.BestMove}
+		case !errors.Is(err, ai.ErrNotInTablebase):
+			s.logger.Warn("Tablebase probe failed", zap.Int("game_id", gameID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AnnotationRequest attaches commentary to a move, e.g. {"comment": "Loses a pawn",
+// "nags": [2]}. An empty comment without NAGs clears the annotation.
+type AnnotationRequest struct {
+	Comment string `json:"comment"`
+	NAGs    []int  `json:"nags,omitempty"`
+}
+
+// annotateMove sets the comment and NAGs of a move (0-based ply index) and returns the
+// annotated move. Finished games can still be annotated, e.g. during a post-game review.
+func (s *Server) annotateMove(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_move_index"})
+		return
+	}
+	var req AnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	if index < 0 || index >= len(game.MoveHistory()) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "move_not_found", Message: "move index out of range"})
+		return
+	}
+	if err := game.AnnotateMove(index, req.Comment, req.NAGs); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_annotation", Message: err.Error()})
+		return
+	}
+
+	s.logger.Info("Move annotated", zap.Int("game_id", gameID), zap.Int("index", index))
+	c.JSON(http.StatusOK, s.moveHistoryResponse(game)[index])
+}
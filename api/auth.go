@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+// apiKeyHeader carries a static API key; keys may also be sent as bearer tokens.
+const apiKeyHeader = "X-API-Key"
+
+// principal is the user an API key or JWT authenticated, with its scopes.
+type principal struct {
+	UserID string
+	Scopes []string
+}
+
+// has reports whether the principal was granted scope: admin grants every
+// scope, and play grants read.
+func (p principal) has(scope string) bool {
+	return slices.Contains(p.Scopes, config.ScopeAdmin) || slices.Contains(p.Scopes, scope) ||
+		(scope == config.ScopeRead && slices.Contains(p.Scopes, config.ScopePlay))
+}
+
+type principalKey struct{}
+
+// principalFrom returns the principal of an authenticated request's context.
+func principalFrom(ctx context.Context) (principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(principal)
+	return p, ok
+}
+
+// authenticatedUserID returns who authenticated a request, or "" without auth.
+func authenticatedUserID(c *gin.Context) string {
+	p, _ := principalFrom(c.Request.Context())
+	return p.UserID
+}
+
+// errInvalidToken is returned for bearer tokens that are neither API keys nor
+// valid JWTs.
+var errInvalidToken = errors.New("invalid or expired credentials")
+
+// authenticate requires every request but the health check and the OpenAPI
+// document to carry an API key, in the X-API-Key header or as a bearer token,
+// or a bearer JWT. WebSocket and event stream clients and board images, which
+// browsers cannot give headers, may pass it as ?access_token=.
+// Reading needs the read scope and anything else the play scope.
+func (s *Server) authenticate() gin.HandlerFunc {
+	auth := s.config.Server.Auth
+	return func(c *gin.Context) {
+		if c.FullPath() == "/health" || c.FullPath() == openAPIPath {
+			c.Next()
+			return
+		}
+		token := strings.TrimSpace(c.GetHeader(apiKeyHeader))
+		if token == "" {
+			token, _ = strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			token = strings.TrimSpace(token)
+		}
+		if token == "" && (strings.HasPrefix(c.Request.URL.Path, "/ws/") || routePath(c) == gameEventsPath || routePath(c) == gameImagePath) {
+			token = c.Query("access_token")
+		}
+		if token == "" {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "an API key or bearer token is required"})
+			return
+		}
+		p, err := authenticateToken(auth, token, time.Now())
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: err.Error()})
+			return
+		}
+		scope := config.ScopePlay
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = config.ScopeRead
+		}
+		if !p.has(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "insufficient_scope", Message: "this request needs the " + scope + " scope"})
+			return
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), principalKey{}, p))
+		c.Next()
+	}
+}
+
+// authenticateToken returns the principal of an API key, or else of a JWT.
+func authenticateToken(auth config.AuthConfig, token string, now time.Time) (principal, error) {
+	var match *config.APIKey
+	for i := range auth.APIKeys {
+		// Compare every key in constant time, so that timing tells nothing of them
+		if subtle.ConstantTimeCompare([]byte(auth.APIKeys[i].Key), []byte(token)) == 1 {
+			match = &auth.APIKeys[i]
+		}
+	}
+	if match != nil {
+		return principal{UserID: match.UserID, Scopes: match.Scopes}, nil
+	}
+	if auth.JWTSecret == "" || strings.Count(token, ".") != 2 {
+		return principal{}, errInvalidToken
+	}
+	return verifyJWT(auth, token, now)
+}
+
+// jwtClaims are the claims of a JWT the server reads.
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Scope     string          `json:"scope"` // space-separated
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"` // a string or a list of them
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+}
+
+// verifyJWT checks the HS256 signature and the claims of a JWT and returns its
+// principal.
+func verifyJWT(auth config.AuthConfig, token string, now time.Time) (principal, error) {
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return principal{}, errInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return principal{}, errInvalidToken
+	}
+	mac := hmac.New(sha256.New, []byte(auth.JWTSecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return principal{}, errInvalidToken
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil || claims.Subject == "" {
+		return principal{}, errInvalidToken
+	}
+	unix := float64(now.Unix())
+	if (claims.ExpiresAt != nil && unix >= *claims.ExpiresAt) || (claims.NotBefore != nil && unix < *claims.NotBefore) {
+		return principal{}, errInvalidToken
+	}
+	if auth.JWTIssuer != "" && claims.Issuer != auth.JWTIssuer {
+		return principal{}, errInvalidToken
+	}
+	if auth.JWTAudience != "" {
+		var audiences []string
+		if err := json.Unmarshal(claims.Audience, &audiences); err != nil {
+			var audience string
+			_ = json.Unmarshal(claims.Audience, &audience)
+			audiences = []string{audience}
+		}
+		if !slices.Contains(audiences, auth.JWTAudience) {
+			return principal{}, errInvalidToken
+		}
+	}
+	return principal{UserID: claims.Subject, Scopes: strings.Fields(claims.Scope)}, nil
+}
+
+// decodeJWTPart decodes a base64url-encoded JSON part of a JWT.
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// mayPlay returns the error for a request whose user may not change a game.
+// With auth on, only the game's owner, the players they invited and admins
+// may; games created without auth are open to anyone with the play scope.
+func (s *Server) mayPlay(ctx context.Context, gameID int) *ErrorResponse {
+	p, ok := principalFrom(ctx)
+	if !ok {
+		return nil
+	}
+	if !p.has(config.ScopePlay) {
+		return &ErrorResponse{Error: "insufficient_scope", Message: "this request needs the play scope"}
+	}
+	if p.has(config.ScopeAdmin) {
+		return nil
+	}
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	metadata := s.gameMetadata[gameID]
+	if metadata == nil || metadata.Owner == "" || metadata.Owner == p.UserID || slices.Contains(metadata.Players, p.UserID) {
+		return nil
+	}
+	return &ErrorResponse{Error: "not_a_player", Message: "only the game's owner and the players they invited may change it"}
+}
+
+// requireGamePlayer refuses requests that change a game to users who may not
+// play in it. The join token of a two-player game lets its holder play.
+func (s *Server) requireGamePlayer() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || !strings.HasPrefix(routePath(c), "/api/games/:id") {
+			return
+		}
+		if gameID, ok := s.resolveGameID(c.Param("id")); ok {
+			if s.playerColor(gameID, requestPlayerToken(c)) != "" {
+				return
+			}
+			if errResp := s.mayPlay(c.Request.Context(), gameID); errResp != nil {
+				c.AbortWithStatusJSON(http.StatusForbidden, *errResp)
+			}
+		}
+	}
+}
+
+// InvitePlayerRequest invites a user to play in a game.
+type InvitePlayerRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// invitePlayer lets another user move in a game. Only the game's owner and
+// admins may invite.
+func (s *Server) invitePlayer(c *gin.Context) {
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
+		return
+	}
+	var req InvitePlayerRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.UserID) == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "user_id is required"})
+		return
+	}
+	if len(req.UserID) > maxUserIDLength {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_user_id", Message: fmt.Sprintf("user IDs must be at most %d characters", maxUserIDLength)})
+		return
+	}
+
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	game, exists := s.games[gameID]
+	metadata := s.gameMetadata[gameID]
+	if !exists || metadata == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
+		return
+	}
+	if p, ok := principalFrom(c.Request.Context()); ok && !p.has(config.ScopeAdmin) && metadata.Owner != p.UserID {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "not_game_owner", Message: "only the game's owner may invite players"})
+		return
+	}
+	userID := strings.TrimSpace(req.UserID)
+	if userID != metadata.Owner && !slices.Contains(metadata.Players, userID) {
+		metadata.Players = append(metadata.Players, userID)
+	}
+	c.JSON(http.StatusOK, s.gameToResponseLocked(gameID, game))
+}
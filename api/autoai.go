@@ -0,0 +1,119 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// autoAIEngines are the engines that may reply automatically: those that need
+// nothing from a request.
+var autoAIEngines = []string{"minimax", "mcts", "random"}
+
+// Defaults of games whose AI replies automatically.
+const (
+	defaultAutoAIEngine = "minimax"
+	defaultAutoAILevel  = "medium"
+)
+
+// checkAutoAI validates the engine and level of a game's automatic AI replies,
+// writing a 400 response if either is unknown.
+func checkAutoAI(c *gin.Context, engineName, level string) bool {
+	if engineName != "" && !slices.Contains(autoAIEngines, engineName) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_engine", Message: "automatic replies are played by minimax, mcts or random"})
+		return false
+	}
+	if _, ok := parseLevel(level); level != "" && !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_level", Message: "level must be beginner, easy, medium, hard or expert"})
+		return false
+	}
+	return true
+}
+
+// scheduleAutoAI has the AI of a game with automatic replies play its move in
+// the background when it is its turn. The caller must hold the game's lock,
+// which the reply waits for.
+func (s *Server) scheduleAutoAI(gameID int, game *engine.Game) {
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	s.scheduleAutoAILocked(gameID, game)
+}
+
+// scheduleAutoAILocked is scheduleAutoAI for callers that hold gamesMux.
+func (s *Server) scheduleAutoAILocked(gameID int, game *engine.Game) {
+	metadata := s.gameMetadata[gameID]
+	due := metadata != nil && metadata.AutoAI && metadata.Lifecycle == StateActive &&
+		metadata.AIColor == game.ActiveColor().String() && !game.IsGameOver()
+	if lock := s.gameLocks[gameID]; due && lock != nil {
+		go s.playAutoAI(gameID, game, lock, len(game.MoveHistory()))
+	}
+}
+
+// playAutoAI plays the AI's reply in a game with automatic replies, unless the
+// game changed since the reply was scheduled at ply, and pushes the game to its
+// WebSocket clients. The AI resigns and offers draws as through ai-move.
+func (s *Server) playAutoAI(gameID int, game *engine.Game, lock sync.Locker, ply int) {
+	if err := lockGame(lock); err != nil {
+		s.logger.Warn("Failed to lock game for the AI's reply", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+	defer lock.Unlock()
+
+	s.gamesMux.RLock()
+	metadata := s.gameMetadata[gameID]
+	due := metadata != nil && metadata.AutoAI && metadata.Lifecycle == StateActive
+	engineName, level := defaultAutoAIEngine, defaultAutoAILevel
+	if due {
+		engineName, level = cmp.Or(metadata.AIEngine, engineName), cmp.Or(metadata.AILevel, level)
+	}
+	s.gamesMux.RUnlock()
+	if !due || game.IsGameOver() || len(game.MoveHistory()) != ply {
+		return
+	}
+
+	difficulty, _ := parseLevel(level)
+	var aiEngine ai.Engine
+	switch engineName {
+	case "minimax":
+		aiEngine = s.newMinimaxAI(difficulty)
+	case "mcts":
+		aiEngine = s.newMCTSEngine(difficulty)
+	default:
+		aiEngine = ai.NewRandomAI()
+	}
+	aiEngine.SetDifficulty(difficulty)
+	s.applyAdaptiveStrength(gameID, aiEngine)
+
+	ctx, cancel := context.WithTimeout(llmContext(context.Background(), gameID), s.applySearchLimits(aiEngine, ai.SearchLimits{}))
+	defer cancel()
+	move, info, err := ai.GetBestMoveWithInfo(ctx, aiEngine, game)
+	if err != nil {
+		s.logger.Error("Automatic AI reply failed", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+
+	decision := s.outcomeTracker(gameID).Record(game, info)
+	if decision == ai.OutcomeResign {
+		_ = game.Resign(game.ActiveColor())
+		s.finishIfOver(gameID, game)
+		s.logger.Info("AI resigned", zap.Int("game_id", gameID), zap.Int("score_cp", info.Score))
+		return
+	}
+	if decision == ai.OutcomeOfferDraw && s.config.AI.DrawOffers {
+		s.setDrawOffer(gameID, game.ActiveColor())
+	}
+	if _, err := s.playAIMoveLocked(gameID, game, move); err != nil {
+		s.logger.Error("AI move could not be played", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+	s.hub.broadcast(gameID, s.gameToResponse(gameID, game))
+	s.scheduleAutoAI(gameID, game) // after a conditional reply
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCachedResponses bounds the number of cached responses across all games.
+const maxCachedResponses = 4096
+
+// cachedResponse is a stored read-only response.
+type cachedResponse struct {
+	body        []byte
+	contentType string
+	etag        string
+	expires     time.Time
+}
+
+// responseCache caches read-only game responses per game. Each game has a version that
+// is bumped on mutation; responses rendered for an older version are never stored.
+type responseCache struct {
+	ttl      time.Duration
+	mu       sync.Mutex
+	entries  map[int]map[string]cachedResponse
+	versions map[int]uint64
+	size     int
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:      ttl,
+		entries:  make(map[int]map[string]cachedResponse),
+		versions: make(map[int]uint64),
+	}
+}
+
+// get returns a fresh cached response and the game's current version.
+func (rc *responseCache) get(gameID int, key string) (cachedResponse, uint64, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	entry, ok := rc.entries[gameID][key]
+	if ok && time.Now().After(entry.expires) {
+		delete(rc.entries[gameID], key)
+		rc.size--
+		ok = false
+	}
+	return entry, rc.versions[gameID], ok
+}
+
+// put stores a response unless the game was mutated since version was read.
+func (rc *responseCache) put(gameID int, version uint64, key string, entry cachedResponse) {
+	if rc.ttl <= 0 {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.versions[gameID] != version || rc.size >= maxCachedResponses {
+		return
+	}
+	if rc.entries[gameID] == nil {
+		rc.entries[gameID] = make(map[string]cachedResponse)
+	}
+	if _, exists := rc.entries[gameID][key]; !exists {
+		rc.size++
+	}
+	entry.expires = time.Now().Add(rc.ttl)
+	rc.entries[gameID][key] = entry
+}
+
+// invalidate drops all cached responses of a game.
+func (rc *responseCache) invalidate(gameID int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.versions[gameID]++
+	rc.size -= len(rc.entries[gameID])
+	delete(rc.entries, gameID)
+}
+
+// bufferedWriter captures a handler's response so it can be hashed and cached.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int)              { w.status = code }
+func (w *bufferedWriter) WriteHeaderNow()                   {}
+func (w *bufferedWriter) Write(b []byte) (int, error)       { return w.body.Write(b) }
+func (w *bufferedWriter) WriteString(s string) (int, error) { return w.body.WriteString(s) }
+func (w *bufferedWriter) Status() int                       { return w.status }
+func (w *bufferedWriter) Size() int                         { return w.body.Len() }
+func (w *bufferedWriter) Written() bool                     { return w.body.Len() > 0 }
+
+// cached serves read-only game endpoints from the response cache and adds ETag /
+// If-None-Match support. Responses are keyed by request URI within the game;
+// those of games whose clock is running are neither cached nor tagged.
+func (s *Server) cached() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		gameID, ok := s.resolveGameID(c.Param("id"))
+		if !ok || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		// A ticking clock makes every response stale as soon as it is written
+		if s.clockRunning(gameID) {
+			c.Next()
+			return
+		}
+		key := c.Request.URL.RequestURI()
+
+		entry, version, ok := s.cache.get(gameID, key)
+		if ok {
+			c.Header("X-Cache", "HIT")
+			s.writeCached(c, entry)
+			c.Abort()
+			return
+		}
+
+		original := c.Writer
+		bw := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = bw
+		c.Next()
+		c.Writer = original
+
+		if bw.status != http.StatusOK {
+			c.Writer.WriteHeader(bw.status)
+			_, _ = c.Writer.Write(bw.body.Bytes())
+			return
+		}
+		sum := sha1.Sum(bw.body.Bytes())
+		entry = cachedResponse{
+			body:        bw.body.Bytes(),
+			contentType: original.Header().Get("Content-Type"),
+			etag:        `"` + hex.EncodeToString(sum[:10]) + `"`,
+		}
+		s.cache.put(gameID, version, key, entry)
+		c.Header("X-Cache", "MISS")
+		s.writeCached(c, entry)
+	}
+}
+
+// clockRunning reports whether a game's clock is ticking.
+func (s *Server) clockRunning(gameID int) bool {
+	s.gamesMux.RLock()
+	game := s.games[gameID]
+	s.gamesMux.RUnlock()
+	return game != nil && game.Clock() != nil && game.Clock().Running()
+}
+
+// writeCached writes a cached response, answering 304 when the client's ETag matches.
+func (s *Server) writeCached(c *gin.Context, entry cachedResponse) {
+	c.Header("ETag", entry.etag)
+	// Clients always revalidate; the ETag makes that a cheap 304
+	c.Header("Cache-Control", "no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), entry.etag) {
+		c.Writer.WriteHeader(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	if entry.contentType != "" {
+		c.Header("Content-Type", entry.contentType)
+	}
+	c.Writer.WriteHeader(http.StatusOK)
+	_, _ = c.Writer.Write(entry.body)
+}
+
+// etagMatches reports whether an If-None-Match header matches etag.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidateOnMutation drops cached responses of a game after any mutating request
+// on /api/games/:id routes.
+func (s *Server) invalidateOnMutation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if c.Request.Method == http.MethodGet || !strings.HasPrefix(routePath(c), "/api/games/:id") {
+			return
+		}
+		if gameID, ok := s.resolveGameID(c.Param("id")); ok {
+			s.cache.invalidate(gameID)
+		}
+	}
+}
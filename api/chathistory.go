@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/chat"
+)
+
+// Page sizes of the chat history.
+const (
+	defaultChatHistoryLimit = 50
+	maxChatHistoryLimit     = 200
+)
+
+// ChatHistoryResponse is a page of a game's chat messages, oldest first.
+type ChatHistoryResponse struct {
+	GameID   int            `json:"game_id"`
+	Messages []chat.Message `json:"messages"`
+	Total    int            `json:"total"` // messages in the conversation
+	Offset   int            `json:"offset"`
+	Limit    int            `json:"limit"`
+	HasMore  bool           `json:"has_more"` // later messages follow the page
+}
+
+// getChatHistory returns a page of a game's chat messages, oldest first: ?limit=
+// messages (50 by default, at most 200) from ?offset=.
+func (s *Server) getChatHistory(c *gin.Context) {
+	gameID, _, _, ok := s.lookupGameForUpdate(c)
+	if !ok || !s.requireChat(c) {
+		return
+	}
+	offset, ok := pageParam(c, "offset", 0, -1)
+	if !ok {
+		return
+	}
+	limit, ok := pageParam(c, "limit", defaultChatHistoryLimit, maxChatHistoryLimit)
+	if !ok {
+		return
+	}
+
+	messages := s.chatService.GetConversationHistory(gameID)
+	total := len(messages)
+	// Offsets past the end are clamped before adding, so huge ones cannot overflow
+	start := min(offset, total)
+	end := min(start+limit, total)
+	c.JSON(http.StatusOK, ChatHistoryResponse{
+		GameID:   gameID,
+		Messages: append([]chat.Message{}, messages[start:end]...),
+		Total:    total,
+		Offset:   offset,
+		Limit:    limit,
+		HasMore:  end < total,
+	})
+}
+
+// clearChat deletes a game's chat conversation; the next message starts a new
+// one.
+func (s *Server) clearChat(c *gin.Context) {
+	gameID, _, _, ok := s.lookupGameForUpdate(c)
+	if !ok || !s.requireChat(c) {
+		return
+	}
+	s.chatService.ClearConversation(gameID)
+	s.logger.Info("Cleared chat", zap.Int("game_id", gameID))
+	c.Status(http.StatusNoContent)
+}
+
+// requireChat writes 503 chat_unavailable if the server has no chat service.
+func (s *Server) requireChat(c *gin.Context) bool {
+	if s.chatService == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "chat_unavailable", Message: "chat service unavailable"})
+		return false
+	}
+	return true
+}
+
+// pageParam parses the non-negative query parameter name, fallback if absent,
+// capped at limit unless limit is negative; it writes 400 invalid_pagination if
+// the value is not a non-negative number.
+func pageParam(c *gin.Context, name string, fallback, limit int) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_pagination",
+			Message: fmt.Sprintf("%s must be a non-negative number", name),
+		})
+		return 0, false
+	}
+	if limit >= 0 && value > limit {
+		value = limit
+	}
+	return value, true
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// GameSettingsRequest changes a game's settings; omitted fields are kept.
+type GameSettingsRequest struct {
+	// AutoCommentary has the AI react to every move played through the moves
+	// endpoint, pushing the reaction to WebSocket clients as a commentary message.
+	AutoCommentary *bool `json:"auto_commentary"`
+	// AutoAI has the AI reply on its own to every move played through the moves
+	// endpoint, with AIEngine at AILevel, pushing the game to WebSocket clients.
+	AutoAI   *bool   `json:"auto_ai"`
+	AIEngine *string `json:"ai_engine"`
+	AILevel  *string `json:"ai_level"`
+}
+
+// updateGameSettings changes a game's settings, e.g. {"auto_commentary": true},
+// and returns the game.
+func (s *Server) updateGameSettings(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+	var req GameSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	if req.AutoCommentary != nil && *req.AutoCommentary && !s.requireChat(c) {
+		return
+	}
+	if !checkAutoAI(c, deref(req.AIEngine), deref(req.AILevel)) {
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	s.gamesMux.Lock()
+	metadata := s.gameMetadata[gameID]
+	if req.AutoAI != nil && *req.AutoAI && metadata != nil && (metadata.isTwoPlayer() || metadata.Exhibition != nil) {
+		s.gamesMux.Unlock()
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "no_ai", Message: "the game has no AI to reply"})
+		return
+	}
+	if metadata != nil {
+		if req.AutoCommentary != nil {
+			metadata.AutoCommentary = *req.AutoCommentary
+		}
+		if req.AutoAI != nil {
+			metadata.AutoAI = *req.AutoAI
+		}
+		if req.AIEngine != nil {
+			metadata.AIEngine = *req.AIEngine
+		}
+		if req.AILevel != nil {
+			metadata.AILevel = *req.AILevel
+		}
+	}
+	s.scheduleAutoAILocked(gameID, game)
+	s.gamesMux.Unlock()
+
+	s.logger.Info("Updated game settings", zap.Int("game_id", gameID), zap.Boolp("auto_commentary", req.AutoCommentary), zap.Boolp("auto_ai", req.AutoAI))
+	c.JSON(http.StatusOK, s.gameToResponse(gameID, game))
+}
+
+// deref returns the value p points to, or the zero value.
+func deref[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
+
+// autoCommentary reports whether the AI comments on a move mover played in a
+// game: on every move but its own, with automatic commentary on.
+func (s *Server) autoCommentary(gameID int, mover engine.Color) bool {
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	metadata := s.gameMetadata[gameID]
+	return metadata != nil && metadata.AutoCommentary && metadata.AIColor != mover.String() && s.chatService != nil
+}
+
+// commentOnMove has the AI react to a move just played, in the background, and
+// pushes the reaction to the game's WebSocket clients. The caller holds the
+// game's lock; the reaction is generated from a copy of the game.
+func (s *Server) commentOnMove(gameID int, game *engine.Game, move engine.Move) {
+	position := game.Clone()
+	ply := len(position.MoveHistory())
+	go func() {
+		ctx := llmContext(context.Background(), gameID)
+		reaction, err := s.chatService.ReactToMove(ctx, gameID, move.String(), position, "", "")
+		if err != nil {
+			s.logger.Warn("Failed to comment on move", zap.Int("game_id", gameID), zap.Error(err))
+			return
+		}
+		if reaction.Message == "" {
+			return // the personality stays silent
+		}
+		s.hub.broadcast(gameID, CommentaryMessage{
+			Type:    "commentary",
+			GameID:  gameID,
+			Ply:     ply,
+			Player:  reaction.Personality,
+			Comment: reaction.Message,
+		})
+	}()
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// ConditionalMoveRequest registers a conditional line, e.g. {"moves": ["e5", "Nf3", "Nc6", "Bb5"]}:
+// if the opponent plays e5, answer Nf3; if they then play Nc6, answer Bb5.
+type ConditionalMoveRequest struct {
+	Moves  []string `json:"moves"`
+	Player string   `json:"player,omitempty"` // defaults to the side not to move
+}
+
+// ConditionalLineResponse represents a registered conditional line.
+type ConditionalLineResponse struct {
+	ID    int      `json:"id"`
+	Moves []string `json:"moves"` // SAN, alternating opponent move and reply
+}
+
+// addConditionalMoves validates and registers a conditional move line for a game.
+func (s *Server) addConditionalMoves(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	var req ConditionalMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	if !s.requireActive(c, gameID) {
+		return
+	}
+
+	player := engine.White
+	if game.ActiveColor() == engine.White {
+		player = engine.Black
+	}
+	if req.Player != "" && req.Player != player.String() {
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "not_waiting",
+			Message: "conditional moves can only be registered while the opponent is to move",
+		})
+		return
+	}
+	if !s.requireConditionalPlayer(c, gameID, player) {
+		return
+	}
+
+	s.gamesMux.Lock()
+	cm := s.conditionals[gameID]
+	if cm == nil || cm.Player() != player {
+		cm = engine.NewConditionalMoves(player)
+		s.conditionals[gameID] = cm
+	}
+	s.gamesMux.Unlock()
+
+	line, err := cm.Add(game, req.Moves)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_conditional_moves", Message: err.Error()})
+		return
+	}
+
+	s.logger.Info("Registered conditional moves",
+		zap.Int("game_id", gameID),
+		zap.String("player", player.String()),
+		zap.Strings("moves", line.SAN))
+	c.JSON(http.StatusCreated, ConditionalLineResponse{ID: line.ID, Moves: line.SAN})
+}
+
+// listConditionalMoves lists the pending conditional lines of a game.
+func (s *Server) listConditionalMoves(c *gin.Context) {
+	gameID, _, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+
+	s.gamesMux.RLock()
+	cm := s.conditionals[gameID]
+	s.gamesMux.RUnlock()
+	if cm != nil && !s.requireConditionalPlayer(c, gameID, cm.Player()) {
+		return
+	}
+
+	lines := []ConditionalLineResponse{}
+	player := ""
+	if cm != nil {
+		player = cm.Player().String()
+		for _, line := range cm.Lines() {
+			lines = append(lines, ConditionalLineResponse{ID: line.ID, Moves: line.SAN})
+		}
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"player": player,
+		"lines":  lines,
+		"count":  len(lines),
+	})
+}
+
+// deleteConditionalMoves removes a single conditional line.
+func (s *Server) deleteConditionalMoves(c *gin.Context) {
+	gameID, _, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+	lineID, err := strconv.Atoi(c.Param("lineId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_line_id"})
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+
+	s.gamesMux.RLock()
+	cm := s.conditionals[gameID]
+	s.gamesMux.RUnlock()
+	if cm != nil && !s.requireConditionalPlayer(c, gameID, cm.Player()) {
+		return
+	}
+
+	if cm == nil || !cm.Remove(lineID) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "conditional_line_not_found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// requireConditionalPlayer reports whether the request may see and change the
+// conditional moves of player, writing the error if not: in two-player games
+// only the holder of that color's player token may.
+func (s *Server) requireConditionalPlayer(c *gin.Context, gameID int, player engine.Color) bool {
+	s.gamesMux.RLock()
+	twoPlayer := s.gameMetadata[gameID].isTwoPlayer()
+	s.gamesMux.RUnlock()
+	if !twoPlayer {
+		return true
+	}
+	color, ok := s.requestPlayerColor(c, gameID)
+	if !ok {
+		return false
+	}
+	if color != player {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "not_your_conditional_moves",
+			Message: "conditional moves are registered by the player who is waiting",
+		})
+		return false
+	}
+	return true
+}
+
+// applyConditionalMoves plays a registered reply after the opponent's move, if any.
+// The caller must hold the per-game lock.
+func (s *Server) applyConditionalMoves(gameID int, game *engine.Game, played engine.Move) *engine.Move {
+	s.gamesMux.RLock()
+	cm := s.conditionals[gameID]
+	s.gamesMux.RUnlock()
+	if cm == nil {
+		return nil
+	}
+
+	reply, ok, err := cm.Apply(game, played)
+	if err != nil {
+		s.logger.Warn("Conditional reply failed", zap.Int("game_id", gameID), zap.Error(err))
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	s.logger.Info("Applied conditional move", zap.Int("game_id", gameID), zap.String("move", reply.String()))
+	return &reply
+}
+
+// lookupGameForUpdate resolves the :id parameter to a game and its per-game lock,
+// writing an error response on failure.
+func (s *Server) lookupGameForUpdate(c *gin.Context) (int, *engine.Game, sync.Locker, bool) {
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
+		return 0, nil, nil, false
+	}
+
+	s.gamesMux.RLock()
+	game, exists := s.games[gameID]
+	lock := s.gameLocks[gameID]
+	s.gamesMux.RUnlock()
+
+	if !exists || lock == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
+		return 0, nil, nil, false
+	}
+	return gameID, game, lock, true
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS answers: what browsers may send and read.
+const (
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders  = "Content-Type, Authorization, If-None-Match, X-User-ID, X-API-Key, X-Player-Token"
+	corsExposeHeaders = "ETag, Retry-After, Deprecation, Link"
+)
+
+// originAllowed reports whether a browser origin may use the API: it matches
+// an allowed origin exactly, ignoring case, or is a subdomain of a
+// "scheme://*.domain" one, or "*" allows all.
+func (s *Server) originAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range s.config.Server.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if scheme, domain, ok := strings.Cut(allowed, "://*."); ok {
+			if rest, ok := strings.CutPrefix(origin, scheme+"://"); ok && strings.HasSuffix(rest, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cors adds the CORS headers of ServerConfig to the responses to allowed
+// browser origins, and answers their preflight requests, which browsers may
+// cache for CORSMaxAge. Preflights from other origins get 403.
+func (s *Server) cors() gin.HandlerFunc {
+	cfg := s.config.Server
+	// Any origin never gets credentials; Config.Validate refuses asking for both
+	wildcard := slices.Contains(cfg.AllowedOrigins, "*")
+	credentials := cfg.CORSCredentials && !wildcard
+	maxAge := strconv.Itoa(int(cfg.CORSMaxAge.Seconds()))
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" { // not a browser's cross-origin request
+			c.Next()
+			return
+		}
+		c.Header("Vary", "Origin")
+		if !s.originAllowed(origin) {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+		if wildcard {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			// Credentials need the origin named
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if credentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		c.Header("Access-Control-Expose-Headers", corsExposeHeaders)
+
+		if c.Request.Method == http.MethodOptions {
+			c.Header("Access-Control-Allow-Methods", corsAllowMethods)
+			c.Header("Access-Control-Allow-Headers", corsAllowHeaders)
+			if cfg.CORSMaxAge > 0 {
+				c.Header("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkOrigin lets WebSockets be opened by clients that send no origin, from
+// the server's own origin, and, with CORS enabled, from allowed origins.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return s.config.Server.CORSEnabled && s.originAllowed(origin)
+}
@@ -0,0 +1,306 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// ClaimDrawRequest claims a draw, e.g. {"reason": "threefold_repetition"}. Without a
+// reason the first available claim is used.
+type ClaimDrawRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// claimDraw ends a game by a threefold repetition or fifty-move rule claim.
+func (s *Server) claimDraw(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	var req ClaimDrawRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+			return
+		}
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	if !s.requireActive(c, gameID) || !s.requirePlayerTurn(c, gameID, game) {
+		return
+	}
+
+	reason := engine.NoDraw
+	if req.Reason != "" {
+		parsed, err := engine.ParseDrawReason(req.Reason)
+		if err != nil || parsed.Automatic() {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_draw_reason",
+				Message: "reason must be threefold_repetition or fifty_move_rule",
+			})
+			return
+		}
+		reason = parsed
+	} else if claims := game.ClaimableDraws(); len(claims) > 0 {
+		reason = claims[0]
+	}
+
+	if err := game.ClaimDraw(reason); err != nil {
+		errorCode := "game_over"
+		if errors.Is(err, engine.ErrDrawNotClaimable) {
+			errorCode = "draw_not_claimable"
+		}
+		c.JSON(http.StatusConflict, ErrorResponse{Error: errorCode, Message: err.Error()})
+		return
+	}
+
+	s.finishIfOver(gameID, game)
+	s.logger.Info("Draw claimed", zap.Int("game_id", gameID), zap.String("reason", reason.String()))
+	c.JSON(http.StatusOK, s.gameToResponse(gameID, game))
+}
+
+// DrawOfferMessage is pushed to WebSocket clients when a draw is offered,
+// accepted or declined.
+type DrawOfferMessage struct {
+	Type   string `json:"type"` // always "draw_offer"
+	GameID int    `json:"game_id"`
+	Color  string `json:"color"`  // the color that offered the draw
+	Status string `json:"status"` // offered, accepted or declined
+}
+
+// DrawOfferResponse answers a draw offer: a two-player game's opponent answers
+// later, the AI at once.
+type DrawOfferResponse struct {
+	Status string       `json:"status"` // offered, accepted or declined
+	Game   GameResponse `json:"game"`
+}
+
+// resign ends a game with a loss for the requester's color.
+func (s *Server) resign(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	if !s.requireActive(c, gameID) {
+		return
+	}
+	color, ok := s.requestPlayerColor(c, gameID)
+	if !ok {
+		return
+	}
+	if err := game.Resign(color); err != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "game_over", Message: err.Error()})
+		return
+	}
+
+	s.clearDrawOffer(gameID)
+	s.finishIfOver(gameID, game)
+	s.logger.Info("Player resigned", zap.Int("game_id", gameID), zap.String("color", color.String()))
+	c.JSON(http.StatusOK, s.gameToResponse(gameID, game))
+}
+
+// offerDraw offers a draw for the requester's color. Offering while the
+// opponent's offer is pending agrees to it. The AI answers at once, judging by
+// its last search; an opponent answers with draw-accept or draw-decline, or by
+// moving.
+func (s *Server) offerDraw(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	if !s.requireActive(c, gameID) {
+		return
+	}
+	color, ok := s.requestPlayerColor(c, gameID)
+	if !ok {
+		return
+	}
+
+	s.gamesMux.RLock()
+	metadata := s.gameMetadata[gameID]
+	pending, twoPlayer := metadata.DrawOffer, metadata.isTwoPlayer()
+	s.gamesMux.RUnlock()
+	switch {
+	case pending == color.String():
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "draw_already_offered", Message: "your draw offer is pending"})
+		return
+	case pending == color.Opposite().String():
+		s.agreeDraw(c, gameID, game, color.Opposite())
+		return
+	case !twoPlayer && s.outcomeTracker(gameID).AcceptsDraw(game):
+		s.agreeDraw(c, gameID, game, color)
+		return
+	case !twoPlayer:
+		s.logger.Info("AI declined a draw offer", zap.Int("game_id", gameID))
+		s.hub.broadcast(gameID, DrawOfferMessage{Type: "draw_offer", GameID: gameID, Color: color.String(), Status: "declined"})
+		c.JSON(http.StatusOK, DrawOfferResponse{Status: "declined", Game: s.gameToResponse(gameID, game)})
+		return
+	}
+
+	s.setDrawOffer(gameID, color)
+	s.cache.invalidate(gameID)
+	s.logger.Info("Draw offered", zap.Int("game_id", gameID), zap.String("color", color.String()))
+	c.JSON(http.StatusOK, DrawOfferResponse{Status: "offered", Game: s.gameToResponse(gameID, game)})
+}
+
+// agreeDraw ends a game by agreeing to the draw the offerer offered and
+// writes the response to the offer or its acceptance.
+func (s *Server) agreeDraw(c *gin.Context, gameID int, game *engine.Game, offerer engine.Color) {
+	if err := game.AgreeDraw(); err != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "game_over", Message: err.Error()})
+		return
+	}
+	s.clearDrawOffer(gameID)
+	s.finishIfOver(gameID, game)
+	s.hub.broadcast(gameID, DrawOfferMessage{Type: "draw_offer", GameID: gameID, Color: offerer.String(), Status: "accepted"})
+	s.logger.Info("Draw offer accepted", zap.Int("game_id", gameID), zap.String("offered_by", offerer.String()))
+	if routePath(c) == "/api/games/:id/draw-offer" {
+		c.JSON(http.StatusOK, DrawOfferResponse{Status: "accepted", Game: s.gameToResponse(gameID, game)})
+		return
+	}
+	c.JSON(http.StatusOK, s.gameToResponse(gameID, game))
+}
+
+// pendingDrawOffer returns the color whose draw offer the requester may answer,
+// writing an error response if there is none.
+func (s *Server) pendingDrawOffer(c *gin.Context, gameID int) (engine.Color, bool) {
+	color, ok := s.requestPlayerColor(c, gameID)
+	if !ok {
+		return engine.None, false
+	}
+	s.gamesMux.RLock()
+	offered := s.gameMetadata[gameID].DrawOffer == color.Opposite().String()
+	s.gamesMux.RUnlock()
+	if !offered {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "no_draw_offer", Message: "there is no draw offer to answer"})
+		return engine.None, false
+	}
+	return color.Opposite(), true
+}
+
+// acceptDraw accepts the draw the opponent offered: the AI along with its last
+// move, or the other player of a two-player game.
+func (s *Server) acceptDraw(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	if !s.requireActive(c, gameID) {
+		return
+	}
+	offerer, ok := s.pendingDrawOffer(c, gameID)
+	if !ok {
+		return
+	}
+	s.agreeDraw(c, gameID, game, offerer)
+}
+
+// declineDraw declines the draw the opponent offered, and play goes on.
+func (s *Server) declineDraw(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	if !s.requireActive(c, gameID) {
+		return
+	}
+	offerer, ok := s.pendingDrawOffer(c, gameID)
+	if !ok {
+		return
+	}
+
+	s.clearDrawOffer(gameID)
+	s.cache.invalidate(gameID)
+	s.hub.broadcast(gameID, DrawOfferMessage{Type: "draw_offer", GameID: gameID, Color: offerer.String(), Status: "declined"})
+	s.logger.Info("Draw offer declined", zap.Int("game_id", gameID))
+	c.JSON(http.StatusOK, s.gameToResponse(gameID, game))
+}
+
+// outcomeTracker returns the tracker of the AI's resignation and draw decisions
+// in a game, creating it on first use. The caller must hold the game lock.
+func (s *Server) outcomeTracker(gameID int) *ai.OutcomeTracker {
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	metadata, exists := s.gameMetadata[gameID]
+	if !exists {
+		metadata = &GameMetadata{}
+		s.gameMetadata[gameID] = metadata
+	}
+	if metadata.outcome == nil {
+		policy := ai.DefaultOutcomePolicy()
+		policy.ResignScore = s.config.AI.ResignScore
+		metadata.outcome = ai.NewOutcomeTracker(policy)
+	}
+	return metadata.outcome
+}
+
+// resetOutcome forgets a pending draw offer and the AI's resignation and draw
+// streaks, e.g. when a new position is loaded.
+func (s *Server) resetOutcome(gameID int) {
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	if metadata, exists := s.gameMetadata[gameID]; exists {
+		metadata.DrawOffer = ""
+		metadata.outcome = nil
+	}
+}
+
+// setDrawOffer records a pending draw offer by color and tells the game's
+// WebSocket clients.
+func (s *Server) setDrawOffer(gameID int, color engine.Color) {
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	if metadata, exists := s.gameMetadata[gameID]; exists {
+		metadata.DrawOffer = color.String()
+		s.hub.broadcast(gameID, DrawOfferMessage{Type: "draw_offer", GameID: gameID, Color: color.String(), Status: "offered"})
+	}
+}
+
+// clearDrawOffer withdraws any pending draw offer.
+func (s *Server) clearDrawOffer(gameID int) {
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	if metadata, exists := s.gameMetadata[gameID]; exists {
+		metadata.DrawOffer = ""
+	}
+}
+
+// declineDrawByMoving withdraws a draw offer made by the opponent of mover, who
+// declined it by playing on.
+func (s *Server) declineDrawByMoving(gameID int, mover engine.Color) {
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	if metadata, exists := s.gameMetadata[gameID]; exists && metadata.DrawOffer != mover.String() {
+		metadata.DrawOffer = ""
+	}
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// GameEventMessage is pushed to WebSocket clients when a game emits an event.
+type GameEventMessage struct {
+	Type   string        `json:"type"`  // always "game_event"
+	Event  string        `json:"event"` // move_made, capture, check, promotion, game_ended
+	GameID int           `json:"game_id"`
+	Move   *MoveResponse `json:"move,omitempty"`
+	Status string        `json:"status"`
+	Ply    int           `json:"ply"`
+}
+
+// wsClientBuffer is the number of queued messages per WebSocket client; events for
+// clients that fall further behind are dropped.
+const wsClientBuffer = 32
+
+// wsWriteTimeout bounds a single WebSocket write.
+const wsWriteTimeout = 10 * time.Second
+
+// WebSocket keepalive: clients are pinged every wsPingPeriod and dropped when
+// nothing, not even a pong, arrives for wsPongWait.
+const (
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+)
+
+// wsMaxMessageSize bounds the messages WebSocket clients send.
+const wsMaxMessageSize = 64 << 10
+
+// ClockMessage is pushed to WebSocket clients with the clocks of a timed game
+// after each move.
+type ClockMessage struct {
+	Type   string `json:"type"` // always "clock"
+	GameID int    `json:"game_id"`
+	ClockResponse
+}
+
+// AdvisoryMessage is pushed to WebSocket clients when a move leaves a game
+// looking drawn, e.g. for clients to suggest a draw.
+type AdvisoryMessage struct {
+	Type     string           `json:"type"` // always "advisory"
+	GameID   int              `json:"game_id"`
+	Ply      int              `json:"ply"`
+	Advisory AdvisoryResponse `json:"advisory"`
+}
+
+// wsClient is a WebSocket connection subscribed to a game or a chat room. All writes go through send
+// so that only one goroutine ever writes to the connection.
+type wsClient struct {
+	send      chan interface{}
+	spectator bool // watches without taking part
+}
+
+// wsHub tracks WebSocket clients per topic: a game ID or a chat room ID.
+type wsHub[K comparable] struct {
+	mu      sync.Mutex
+	clients map[K]map[*wsClient]struct{}
+	// replay is how many of each topic's latest messages are kept, of those
+	// replayable accepts, for clients that join late.
+	replay     int
+	replayable func(msg interface{}) bool
+	recent     map[K][]interface{}
+}
+
+func newWSHub[K comparable]() *wsHub[K] {
+	return &wsHub[K]{clients: make(map[K]map[*wsClient]struct{}), recent: make(map[K][]interface{})}
+}
+
+// add registers a client for a topic.
+func (h *wsHub[K]) add(topic K) *wsClient {
+	return h.register(topic, &wsClient{send: make(chan interface{}, wsClientBuffer)})
+}
+
+// addSpectator registers a client that watches a topic without taking part.
+func (h *wsHub[K]) addSpectator(topic K) *wsClient {
+	return h.register(topic, &wsClient{send: make(chan interface{}, wsClientBuffer), spectator: true})
+}
+
+func (h *wsHub[K]) register(topic K, client *wsClient) *wsClient {
+	h.mu.Lock()
+	if h.clients[topic] == nil {
+		h.clients[topic] = make(map[*wsClient]struct{})
+	}
+	h.clients[topic][client] = struct{}{}
+	h.mu.Unlock()
+	return client
+}
+
+// remove unregisters a client and closes its send queue.
+func (h *wsHub[K]) remove(topic K, client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[topic][client]; !ok {
+		return
+	}
+	delete(h.clients[topic], client)
+	if len(h.clients[topic]) == 0 {
+		delete(h.clients, topic)
+	}
+	close(client.send)
+}
+
+// presence returns how many clients of a topic take part and how many watch.
+func (h *wsHub[K]) presence(topic K) (participants, spectators int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients[topic] {
+		if client.spectator {
+			spectators++
+		} else {
+			participants++
+		}
+	}
+	return participants, spectators
+}
+
+// recentMessages returns the messages kept for a topic, oldest first.
+func (h *wsHub[K]) recentMessages(topic K) []interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.recent[topic])
+}
+
+// forget drops the messages kept for a topic.
+func (h *wsHub[K]) forget(topic K) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.recent, topic)
+}
+
+// broadcast queues msg for every client of a topic without blocking, and keeps
+// it for clients that join later if the hub replays it.
+func (h *wsHub[K]) broadcast(topic K, msg interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.replay > 0 && (h.replayable == nil || h.replayable(msg)) {
+		recent := append(h.recent[topic], msg)
+		h.recent[topic] = recent[max(0, len(recent)-h.replay):]
+	}
+	for client := range h.clients[topic] {
+		select {
+		case client.send <- msg:
+		default: // slow client: drop rather than stall the game
+		}
+	}
+}
+
+// writePump writes queued messages to conn until the queue is closed, and
+// pings the client in between.
+func (c *wsClient) writePump(conn *websocket.Conn, logger *zap.Logger) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(msg); err != nil {
+				logger.Debug("WebSocket write failed", zap.Error(err))
+				conn.Close() // unblocks the reader, which removes the client
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				logger.Debug("WebSocket ping failed", zap.Error(err))
+				conn.Close()
+			}
+		}
+	}
+}
+
+// keepAlive has reads from conn fail once the client stops answering pings.
+func keepAlive(conn *websocket.Conn) {
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+}
+
+// observeGame subscribes the server to a game's events: they are logged and pushed to
+// the game's WebSocket clients, with the clocks of timed games and a draw advisory
+// once the game starts looking drawn, invalidate its cached responses and have it
+// saved.
+func (s *Server) observeGame(gameID int, game *engine.Game) {
+	advised := false
+	game.Subscribe(func(e engine.Event) {
+		s.cache.invalidate(gameID)
+		s.saveLater(gameID)
+		msg := GameEventMessage{
+			Type:   "game_event",
+			Event:  e.Type.String(),
+			GameID: gameID,
+			Status: e.Status.String(),
+			Ply:    e.Ply,
+		}
+		// Games ended by resignation, agreement or a claim have no move
+		if e.Move != (engine.Move{}) {
+			move := s.moveToResponse(e.Move)
+			msg.Move = &move
+		}
+		s.logger.Debug("Game event",
+			zap.Int("game_id", gameID),
+			zap.String("event", e.Type.String()),
+			zap.String("move", e.Move.String()))
+		s.hub.broadcast(gameID, msg)
+		if clock := clockResponse(game); clock != nil && e.Type == engine.EventMoveMade {
+			s.hub.broadcast(gameID, ClockMessage{Type: "clock", GameID: gameID, ClockResponse: *clock})
+		}
+		if e.Type == engine.EventMoveMade {
+			adv := drawAdvisoryResponse(game)
+			if adv != nil && !advised {
+				s.hub.broadcast(gameID, AdvisoryMessage{Type: "advisory", GameID: gameID, Ply: e.Ply, Advisory: *adv})
+			}
+			advised = adv != nil
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/ai/match"
+	"go.rumenx.com/chess/engine"
+)
+
+// Exhibition game settings.
+const (
+	exhibitionMoveTime        = time.Minute // per move, including the opponent's reaction
+	defaultExhibitionMaxPlies = 200
+)
+
+// ExhibitionPlayer is an LLM provider playing one side of an exhibition game.
+type ExhibitionPlayer struct {
+	Provider    string `json:"provider"`
+	Model       string `json:"model,omitempty"`       // from the provider's allowed models
+	Personality string `json:"personality,omitempty"` // preset the player talks and plays as
+}
+
+// ExhibitionRequest starts a game between two LLM providers.
+type ExhibitionRequest struct {
+	White    ExhibitionPlayer `json:"white"`
+	Black    ExhibitionPlayer `json:"black"`
+	Level    string           `json:"level,omitempty"`     // both players' difficulty (default medium)
+	MaxPlies int              `json:"max_plies,omitempty"` // plies before the game is adjudicated drawn (default 200)
+}
+
+// ExhibitionInfo names the players of an exhibition game as "provider model".
+type ExhibitionInfo struct {
+	White string `json:"white"`
+	Black string `json:"black"`
+}
+
+// CommentaryMessage is pushed to WebSocket clients when a player of an exhibition
+// game reacts to its opponent's move, or when the AI comments on a move of a game
+// with automatic commentary.
+type CommentaryMessage struct {
+	Type    string `json:"type"` // always "commentary"
+	GameID  int    `json:"game_id"`
+	Ply     int    `json:"ply"`    // the move commented on
+	Player  string `json:"player"` // the commentator: the exhibition player or the chat personality
+	Comment string `json:"comment"`
+}
+
+// startExhibition creates a game between two LLM providers and plays it in the
+// background. Moves and commentary reach the game's WebSocket clients as they
+// happen, and the PGN export carries the commentary.
+func (s *Server) startExhibition(c *gin.Context) {
+	if s.config.LLMAI.MaxExhibitions == 0 {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "exhibitions_disabled", Message: "exhibition games are not enabled on this server"})
+		return
+	}
+	var req ExhibitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	difficulty := ai.DifficultyMedium
+	if req.Level != "" {
+		var ok bool
+		if difficulty, ok = parseLevel(req.Level); !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_level", Message: fmt.Sprintf("unknown level %q", req.Level)})
+			return
+		}
+	}
+	if req.MaxPlies < 0 || req.MaxPlies > match.DefaultMaxPlies {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("max_plies must be between 1 and %d", match.DefaultMaxPlies),
+		})
+		return
+	}
+	if req.MaxPlies == 0 {
+		req.MaxPlies = defaultExhibitionMaxPlies
+	}
+
+	white, ok := s.exhibitionPlayer(c, req.White, difficulty)
+	if !ok {
+		return
+	}
+	black, ok := s.exhibitionPlayer(c, req.Black, difficulty)
+	if !ok {
+		return
+	}
+	if white.Name == black.Name {
+		white.Name += " (White)"
+		black.Name += " (Black)"
+	}
+
+	game := engine.NewGame()
+	s.gamesMux.Lock()
+	if len(s.exhibitions) >= s.config.LLMAI.MaxExhibitions {
+		s.gamesMux.Unlock()
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Error:   "too_many_exhibitions",
+			Message: fmt.Sprintf("at most %d exhibition games are played at once", s.config.LLMAI.MaxExhibitions),
+		})
+		return
+	}
+	gameID := s.registerGame(game, &GameMetadata{
+		Owner:      authenticatedUserID(c),
+		CreatedAt:  time.Now(),
+		Exhibition: &ExhibitionInfo{White: white.Name, Black: black.Name},
+	})
+	// Deleting the game stops the exhibition
+	ctx, stop := context.WithCancel(llmContext(context.Background(), gameID))
+	s.exhibitions[gameID] = stop
+	response := s.gameToResponseLocked(gameID, game)
+	s.gamesMux.Unlock()
+
+	s.logger.Info("Started exhibition game",
+		zap.Int("game_id", gameID),
+		zap.String("white", white.Name),
+		zap.String("black", black.Name))
+	go s.playExhibition(ctx, gameID, game, white, black, req.MaxPlies)
+	c.JSON(http.StatusAccepted, response)
+}
+
+// exhibitionPlayer creates the engine of an exhibition player, writing a 400
+// response if the provider is not usable, the model not allowed or the
+// personality unknown.
+func (s *Server) exhibitionPlayer(c *gin.Context, p ExhibitionPlayer, difficulty ai.Difficulty) (match.Player, bool) {
+	if !s.config.HasValidLLMProvider(p.Provider) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_provider",
+			Message: fmt.Sprintf("LLM provider %q is not available", p.Provider),
+		})
+		return match.Player{}, false
+	}
+	if !s.checkLLMOverrides(c, p.Provider, p.Model, nil) {
+		return match.Player{}, false
+	}
+	if _, ok := lookupPersonality(c, p.Personality); !ok {
+		return match.Player{}, false
+	}
+	llm, err := s.newLLMEngine(p.Provider, difficulty)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_provider", Message: err.Error()})
+		return match.Player{}, false
+	}
+	s.configureLLMEngine(llm, AIRequest{Model: p.Model, Personality: p.Personality})
+	return match.Player{Name: p.Provider + " " + llm.GetModel(), Engine: llm}, true
+}
+
+// playExhibition plays an exhibition game on a private board and mirrors each move
+// and comment into the server's game under its lock. Play stops if the game is
+// paused, changed by anyone else or deleted, which cancels ctx.
+func (s *Server) playExhibition(ctx context.Context, gameID int, game *engine.Game, white, black match.Player, maxPlies int) {
+	s.gamesMux.RLock()
+	lock := s.gameLocks[gameID]
+	cancel := s.exhibitions[gameID]
+	s.gamesMux.RUnlock()
+	defer s.endExhibition(gameID)
+	if lock == nil || cancel == nil { // deleted already
+		return
+	}
+
+	result, err := match.Play(ctx, white, black, match.Options{
+		Games:      1,
+		MoveTime:   exhibitionMoveTime,
+		MaxPlies:   maxPlies,
+		Event:      "LLM Exhibition",
+		Commentary: s.config.LLMAI.ChatEnabled,
+		OnMove: func(e match.MoveEvent) {
+			comment := s.moderator.Moderate(ctx, "commentary", e.Comment, zap.Int("game_id", gameID)).Text
+			lock.Lock()
+			defer lock.Unlock()
+			if s.lifecycleState(gameID) != StateActive || game.MakeMove(e.Move) != nil {
+				cancel()
+				return
+			}
+			if comment != "" {
+				ply := len(game.MoveHistory())
+				_ = game.AnnotateMove(ply-1, e.Commentator+": "+comment, nil)
+				s.cache.invalidate(gameID)
+				s.hub.broadcast(gameID, CommentaryMessage{
+					Type:    "commentary",
+					GameID:  gameID,
+					Ply:     ply,
+					Player:  e.Commentator,
+					Comment: comment,
+				})
+			}
+			s.finishIfOver(gameID, game)
+		},
+	})
+	if err != nil {
+		s.logger.Warn("Exhibition game stopped", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+
+	// Adjudicated and forfeited games end without a move
+	played := result.Games[0]
+	lock.Lock()
+	defer lock.Unlock()
+	if !game.IsGameOver() && s.lifecycleState(gameID) == StateActive {
+		switch played.Result.Termination {
+		case engine.TerminationAgreement:
+			_ = game.AgreeDraw()
+		case engine.TerminationAbandonment:
+			_ = game.Abandon(played.Result.Winner.Opposite())
+		}
+		s.finishIfOver(gameID, game)
+	}
+	s.logger.Info("Exhibition game finished",
+		zap.Int("game_id", gameID),
+		zap.String("result", played.Result.String()),
+		zap.Int("plies", played.Plies))
+}
+
+// endExhibition stops an exhibition game and frees its place for another.
+func (s *Server) endExhibition(gameID int) {
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	if stop, ok := s.exhibitions[gameID]; ok {
+		stop()
+		delete(s.exhibitions, gameID)
+	}
+}
+
+// parseLevel returns the difficulty named level.
+func parseLevel(level string) (ai.Difficulty, bool) {
+	for d := ai.DifficultyBeginner; d <= ai.DifficultyExpert; d++ {
+		if d.String() == level {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// isExhibition reports whether a game is played by two LLMs, so no one else may move.
+func (s *Server) isExhibition(gameID int) bool {
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	metadata, exists := s.gameMetadata[gameID]
+	return exists && metadata.Exhibition != nil
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// explanationTimeout bounds the LLM call that explains a hint.
+const explanationTimeout = 15 * time.Second
+
+// explainHint explains a hinted move: in plain language by the LLM when LLM AI is
+// enabled with a usable provider (the request's, else the default one), otherwise
+// or on failure by naming the move and the engine's expected line. The source is
+// "llm" or "engine".
+func (s *Server) explainHint(gameID int, req AIRequest, difficulty ai.Difficulty, game *engine.Game, move engine.Move, lines []ai.Line) (string, string) {
+	var pv []engine.Move
+	if len(lines) > 0 {
+		pv = lines[0].Moves
+	}
+
+	provider := req.Provider
+	if provider == "" {
+		provider = s.config.LLMAI.DefaultProvider
+	}
+	if s.config.HasValidLLMProvider(provider) {
+		llm, err := s.newLLMEngine(provider, difficulty)
+		if err == nil {
+			llm.SetLanguage(req.Language)
+			ctx, cancel := context.WithTimeout(llmContext(context.Background(), gameID), explanationTimeout)
+			var explanation string
+			explanation, err = llm.ExplainMove(ctx, game, move, pv)
+			cancel()
+			if err == nil {
+				return explanation, "llm"
+			}
+		}
+		s.logger.Warn("Failed to explain hint, using the engine line", zap.String("provider", provider), zap.Error(err))
+	}
+	return engineExplanation(game, move, pv), "engine"
+}
+
+// newLLMEngine creates an LLM engine for a configured provider.
+func (s *Server) newLLMEngine(provider string, difficulty ai.Difficulty) (*ai.LLMAIEngine, error) {
+	cfg, _ := s.config.GetLLMProviderConfig(provider)
+	llm, err := ai.NewLLMAIEngine(ai.LLMConfig{
+		Provider:    ai.LLMProvider(provider),
+		APIKey:      cfg.APIKey,
+		Model:       cfg.Model,
+		Endpoint:    cfg.Endpoint,
+		Difficulty:  difficulty,
+		Personality: cfg.Personality,
+		ChatEnabled: s.config.LLMAI.ChatEnabled,
+		APIVersion:  cfg.APIVersion,
+		MaxTokens:   cfg.MaxTokens,
+		Timeout:     cfg.Timeout,
+	})
+	if err == nil {
+		llm.SetLogger(s.llmLog)
+	}
+	return llm, err
+}
+
+// engineExplanation describes a move by its expected line, e.g. "Nf3 is the
+// engine's choice, expecting Nf3 Nc6 Bb5".
+func engineExplanation(game *engine.Game, move engine.Move, pv []engine.Move) string {
+	san, err := game.SANLine([]engine.Move{move})
+	if err != nil {
+		return fmt.Sprintf("AI suggests moving from %s to %s", move.From, move.To)
+	}
+	if len(pv) < 2 || pv[0] != move {
+		return san[0] + " is the engine's choice"
+	}
+	line, err := game.SANLine(pv)
+	if err != nil {
+		return san[0] + " is the engine's choice"
+	}
+	return fmt.Sprintf("%s is the engine's choice, expecting %s", san[0], strings.Join(line, " "))
+}
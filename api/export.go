@@ -0,0 +1,142 @@
+package api
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/config"
+	"go.rumenx.com/chess/engine"
+)
+
+// gamePGNExport returns the PGN of a game as the PGN endpoint serves it, with
+// summary, if any, as a comment after the moves.
+func (s *Server) gamePGNExport(gameID int, game *engine.Game, summary string) string {
+	movetext := pgnMovetext(game, nil)
+	if summary != "" {
+		movetext += engine.Annotation{Comment: summary}.PGN() + " "
+	}
+	movetext += game.Result().String()
+
+	var b strings.Builder
+	for _, tag := range s.pgnTags(gameID, game) {
+		fmt.Fprintf(&b, "[%s \"%s\"]\n", tag[0], tag[1])
+	}
+	b.WriteString("\n" + movetext + "\n")
+	return b.String()
+}
+
+// exportGames downloads the games matching the query as a multi-game PGN
+// file, or with format=zip as a zip archive of a PGN file per game. status
+// selects games in a lifecycle state and since those created since a time
+// (RFC 3339) or day (2006-01-02). With auth on, users export their own games,
+// admins everyone's.
+func (s *Server) exportGames(c *gin.Context) {
+	state := LifecycleState(c.Query("status"))
+	if _, known := lifecycleTransitions[state]; state != "" && !known {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_status", Message: "status must be a lifecycle state, e.g. finished"})
+		return
+	}
+	var since time.Time
+	if value := c.Query("since"); value != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			if since, err = time.Parse(time.DateOnly, value); err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_since", Message: "since must be an RFC 3339 time or a date such as 2025-01-31"})
+				return
+			}
+		}
+	}
+	format := c.DefaultQuery("format", "pgn")
+	if format != "pgn" && format != "zip" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_format", Message: "format must be pgn or zip"})
+		return
+	}
+	p, authenticated := principalFrom(c.Request.Context())
+	everyone := !authenticated || p.has(config.ScopeAdmin)
+
+	type exported struct {
+		id   int
+		game *engine.Game
+		lock sync.Locker
+	}
+	var games []exported
+	s.gamesMux.RLock()
+	for id, game := range s.games {
+		metadata := s.gameMetadata[id]
+		switch {
+		case metadata == nil:
+			continue
+		case state != "" && metadata.Lifecycle != state:
+			continue
+		case !since.IsZero() && metadata.CreatedAt.Before(since):
+			continue
+		case !everyone && metadata.Owner != p.UserID && !slices.Contains(metadata.Players, p.UserID):
+			continue
+		}
+		games = append(games, exported{id: id, game: game, lock: s.gameLocks[id]})
+	}
+	s.gamesMux.RUnlock()
+	slices.SortFunc(games, func(a, b exported) int { return a.id - b.id })
+
+	// The games are streamed one at a time, each written under its lock, so
+	// that exports of many games are not held in memory. Errors past the first
+	// game can only cut the download short.
+	pgn := func(g exported) (string, error) {
+		if err := lockGame(g.lock); err != nil {
+			return "", err
+		}
+		defer g.lock.Unlock()
+		return s.gamePGNExport(g.id, g.game, ""), nil
+	}
+	if format == "pgn" {
+		c.Header("Content-Disposition", `attachment; filename="games.pgn"`)
+		c.Header("Content-Type", "application/x-chess-pgn")
+		c.Status(http.StatusOK)
+		for i, g := range games {
+			text, err := pgn(g)
+			if err == nil && i > 0 {
+				_, err = io.WriteString(c.Writer, "\n")
+			}
+			if err == nil {
+				_, err = io.WriteString(c.Writer, text)
+			}
+			if err != nil {
+				s.logger.Warn("Failed to export games", zap.Int("game_id", g.id), zap.Error(err))
+				return
+			}
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="games.zip"`)
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	archive := zip.NewWriter(c.Writer)
+	for _, g := range games {
+		text, err := pgn(g)
+		var w io.Writer
+		if err == nil {
+			w, err = archive.Create("game-" + strconv.Itoa(g.id) + ".pgn")
+		}
+		if err == nil {
+			_, err = io.WriteString(w, text)
+		}
+		if err != nil {
+			s.logger.Warn("Failed to export games", zap.Int("game_id", g.id), zap.Error(err))
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		s.logger.Warn("Failed to export games", zap.Error(err))
+	}
+}
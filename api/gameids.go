@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"go.rumenx.com/chess/config"
+)
+
+// Games are kept by number, and every game has a UUID besides. Routes take
+// either unless the server hands out UUIDs, in which case they take only UUIDs,
+// so that a game can only be reached by someone given its link.
+
+// usesUUIDs reports whether games are identified by UUID in URLs.
+func (s *Server) usesUUIDs() bool {
+	return s.config.Server.GameIDs == config.GameIDsUUID
+}
+
+// indexGameLocked gives a game a UUID, unless it has one, and makes it
+// reachable by it. The caller must hold gamesMux for writing.
+func (s *Server) indexGameLocked(gameID int, metadata *GameMetadata) {
+	if metadata.UUID == "" {
+		metadata.UUID = uuid.NewString()
+	}
+	s.gameUUIDs[metadata.UUID] = gameID
+}
+
+// resolveGameID returns the number of the game a route's :id names. A UUID no
+// game has resolves to 0, which no game has either.
+func (s *Server) resolveGameID(param string) (int, bool) {
+	if id, err := uuid.Parse(param); err == nil {
+		s.gamesMux.RLock()
+		defer s.gamesMux.RUnlock()
+		return s.gameUUIDs[id.String()], true
+	}
+	if s.usesUUIDs() {
+		return 0, false
+	}
+	gameID, err := strconv.Atoi(param)
+	return gameID, err == nil
+}
+
+// gameIDParam resolves the :id parameter to a game number, writing an error
+// response if it is malformed.
+func (s *Server) gameIDParam(c *gin.Context) (int, bool) {
+	gameID, ok := s.resolveGameID(c.Param("id"))
+	if !ok {
+		message := ""
+		if s.usesUUIDs() {
+			message = "game IDs are UUIDs"
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id", Message: message})
+	}
+	return gameID, ok
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// reactionTimeout bounds the LLM call that reacts to the player's move.
+const reactionTimeout = 10 * time.Second
+
+// newHybridEngine pairs the minimax AI with the request's LLM provider, else the
+// default one, for reactions and explanations. Without a usable provider it is
+// plain minimax, which plays the same moves.
+func (s *Server) newHybridEngine(req AIRequest, difficulty ai.Difficulty) ai.Engine {
+	minimax := s.newMinimaxAI(difficulty)
+	provider := req.Provider
+	if provider == "" {
+		provider = s.config.LLMAI.DefaultProvider
+	}
+	if !s.config.HasValidLLMProvider(provider) {
+		return minimax
+	}
+	llmEngine, err := ai.NewLLMAIFromEnv(provider, difficulty)
+	if err != nil {
+		s.logger.Warn("Failed to create LLM AI engine, playing minimax without talk", zap.Error(err))
+		return minimax
+	}
+	s.configureLLMEngine(llmEngine, req)
+	return ai.NewHybridEngine(minimax, llmEngine)
+}
+
+// hybridReaction has a hybrid engine react to the last move of the game, the
+// player's, or returns "" if there is none to react to. The game must be a copy
+// no one else changes; the LLM call is too slow to hold the game's lock.
+func (s *Server) hybridReaction(gameID int, hybrid *ai.HybridEngine, game *engine.Game) string {
+	history := game.MoveHistory()
+	if len(history) == 0 {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(llmContext(context.Background(), gameID), reactionTimeout)
+	defer cancel()
+	reaction, err := hybrid.ReactToMove(ctx, history[len(history)-1], game)
+	if err != nil {
+		s.logger.Warn("Failed to react to the player's move", zap.Error(err))
+		return ""
+	}
+	return s.moderator.Moderate(ctx, "reaction", reaction).Text
+}
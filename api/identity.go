@@ -0,0 +1,59 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userIDHeader names the user a request is made for.
+const userIDHeader = "X-User-ID"
+
+// defaultUserID is the identity of requests that name no user.
+const defaultUserID = "player"
+
+// requestUserID returns who a request is made for: the user its API key or
+// JWT authenticated when auth is on, else the X-User-ID header, else the
+// user_id query parameter (browsers cannot set headers on WebSockets), else an
+// ID derived from the bearer token, else "player". It writes 400
+// invalid_user_id for an ID longer than 64 characters.
+func requestUserID(c *gin.Context) (string, bool) {
+	if userID := authenticatedUserID(c); userID != "" {
+		return userID, true
+	}
+	userID := strings.TrimSpace(c.GetHeader(userIDHeader))
+	if userID == "" {
+		userID = strings.TrimSpace(c.Query("user_id"))
+	}
+	if userID == "" {
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && strings.TrimSpace(token) != "" {
+			sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+			userID = "user-" + hex.EncodeToString(sum[:8])
+		}
+	}
+	if userID == "" {
+		return defaultUserID, true
+	}
+	if len(userID) > maxUserIDLength {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_user_id",
+			Message: fmt.Sprintf("user IDs must be at most %d characters", maxUserIDLength),
+		})
+		return "", false
+	}
+	return userID, true
+}
+
+// chatLimitKey returns who the chat limits count a request against: the
+// authenticated user, or else the client's address, since anyone may claim any
+// X-User-ID.
+func chatLimitKey(c *gin.Context) string {
+	if userID := authenticatedUserID(c); userID != "" {
+		return userID
+	}
+	return "ip:" + c.ClientIP()
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/render"
+)
+
+// gameImagePath is the route of a game's board image.
+const gameImagePath = "/api/games/:id/image"
+
+// getGameImage draws a game's position as an image, for link previews, chat
+// bots and emails: SVG, or PNG with format=png. move is the number of plies
+// played in the position, by default all of them; size is in pixels, theme
+// light or dark and orientation the side at the bottom. The last move and a
+// king in check are highlighted.
+func (s *Server) getGameImage(c *gin.Context) {
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
+		return
+	}
+	opts := render.Options{Size: render.DefaultSize, Theme: render.LightTheme}
+	if value := c.Query("size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < render.MinSize || size > render.MaxSize {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_size", Message: fmt.Sprintf("size must be %d to %d pixels", render.MinSize, render.MaxSize)})
+			return
+		}
+		opts.Size = size
+	}
+	if value := c.Query("theme"); value != "" {
+		theme, known := render.ThemeByName(value)
+		if !known {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_theme", Message: "theme must be light or dark"})
+			return
+		}
+		opts.Theme = theme
+	}
+	switch c.DefaultQuery("orientation", "white") {
+	case "white":
+	case "black":
+		opts.Flip = true
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_orientation", Message: "orientation must be white or black"})
+		return
+	}
+	format := c.DefaultQuery("format", "svg")
+	if format != "svg" && format != "png" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_format", Message: "format must be svg or png"})
+		return
+	}
+
+	s.gamesMux.RLock()
+	game, exists := s.games[gameID]
+	s.gamesMux.RUnlock()
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
+		return
+	}
+	if game, ok = positionParam(c, game, "move"); !ok {
+		return
+	}
+
+	if format == "svg" {
+		c.Data(http.StatusOK, "image/svg+xml", render.SVG(game, opts))
+		return
+	}
+	data, err := render.PNG(game, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "render_failed", Message: err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", data)
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// LifecycleState is the server-side lifecycle stage of a game, independent of the
+// position's engine.GameStatus.
+type LifecycleState string
+
+const (
+	// StateCreated is the initial state of a registered game.
+	StateCreated LifecycleState = "created"
+	// StateAwaitingPlayers means the game waits for its seats to be filled.
+	StateAwaitingPlayers LifecycleState = "awaiting_players"
+	// StateActive means moves may be played.
+	StateActive LifecycleState = "active"
+	// StatePaused means play is suspended and the clock is stopped.
+	StatePaused LifecycleState = "paused"
+	// StateFinished means the game has a result.
+	StateFinished LifecycleState = "finished"
+	// StateArchived means the game is kept read-only.
+	StateArchived LifecycleState = "archived"
+)
+
+// lifecycleTransitions lists the allowed transitions from each state.
+var lifecycleTransitions = map[LifecycleState][]LifecycleState{
+	StateCreated:         {StateAwaitingPlayers, StateActive, StateFinished},
+	StateAwaitingPlayers: {StateActive, StateFinished},
+	StateActive:          {StatePaused, StateFinished},
+	StatePaused:          {StateActive, StateFinished},
+	StateFinished:        {StateArchived},
+	StateArchived:        {},
+}
+
+// CanTransition reports whether a game may move from state st to state to.
+func (st LifecycleState) CanTransition(to LifecycleState) bool {
+	for _, allowed := range lifecycleTransitions[st] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// LifecycleMessage is pushed to WebSocket clients when a game changes lifecycle state.
+type LifecycleMessage struct {
+	Type   string `json:"type"` // always "lifecycle"
+	GameID int    `json:"game_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// transitionLocked moves a game to a new lifecycle state, logging the change and
+// notifying WebSocket clients. The caller must hold gamesMux for writing.
+func (s *Server) transitionLocked(gameID int, to LifecycleState) error {
+	metadata, exists := s.gameMetadata[gameID]
+	if !exists {
+		return fmt.Errorf("game %d not found", gameID)
+	}
+	from := metadata.Lifecycle
+	if !from.CanTransition(to) {
+		return fmt.Errorf("cannot move game from %s to %s", from, to)
+	}
+	metadata.Lifecycle = to
+
+	s.cache.invalidate(gameID)
+	s.saveLater(gameID)
+	s.logger.Info("Game lifecycle changed",
+		zap.Int("game_id", gameID),
+		zap.String("from", string(from)),
+		zap.String("to", string(to)))
+	s.hub.broadcast(gameID, LifecycleMessage{Type: "lifecycle", GameID: gameID, From: string(from), To: string(to)})
+	return nil
+}
+
+// transition is transitionLocked for callers that do not hold gamesMux.
+func (s *Server) transition(gameID int, to LifecycleState) error {
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	return s.transitionLocked(gameID, to)
+}
+
+// lifecycleState returns the lifecycle state of a game.
+func (s *Server) lifecycleState(gameID int) LifecycleState {
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	if metadata, exists := s.gameMetadata[gameID]; exists {
+		return metadata.Lifecycle
+	}
+	return ""
+}
+
+// requireActive writes a 409 response unless the game is active. The caller should
+// hold the per-game lock so the state cannot change before the mutation.
+func (s *Server) requireActive(c *gin.Context, gameID int) bool {
+	if errResp := s.activeError(gameID); errResp != nil {
+		c.JSON(http.StatusConflict, *errResp)
+		return false
+	}
+	return true
+}
+
+// activeError is requireActive returning the error of a game that is not
+// active, to be sent with a 409 status, instead of writing it.
+func (s *Server) activeError(gameID int) *ErrorResponse {
+	state := s.lifecycleState(gameID)
+	if state == StateActive {
+		return nil
+	}
+	return &ErrorResponse{
+		Error:   "game_not_active",
+		Message: fmt.Sprintf("game is %s", state),
+	}
+}
+
+// finishIfOver moves a game whose position has ended to the finished state.
+func (s *Server) finishIfOver(gameID int, game *engine.Game) {
+	if !game.IsGameOver() {
+		return
+	}
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	if metadata, exists := s.gameMetadata[gameID]; exists && metadata.Lifecycle.CanTransition(StateFinished) {
+		_ = s.transitionLocked(gameID, StateFinished)
+	}
+}
+
+// pauseGame suspends play and stops the clock.
+func (s *Server) pauseGame(c *gin.Context) {
+	s.changeLifecycle(c, StatePaused, func(game *engine.Game) {
+		if clock := game.Clock(); clock != nil {
+			clock.Stop()
+		}
+	})
+}
+
+// resumeGame resumes a paused game and restarts the clock of the side to move.
+func (s *Server) resumeGame(c *gin.Context) {
+	s.changeLifecycle(c, StateActive, func(game *engine.Game) {
+		if clock := game.Clock(); clock != nil && len(game.MoveHistory()) > 0 {
+			clock.Start(game.ActiveColor())
+		}
+	})
+}
+
+// archiveGame makes a finished game read-only.
+func (s *Server) archiveGame(c *gin.Context) {
+	s.changeLifecycle(c, StateArchived, nil)
+}
+
+// changeLifecycle validates and applies a transition requested through the API.
+// Only pause, resume and archive are client-driven; other transitions follow play.
+func (s *Server) changeLifecycle(c *gin.Context, to LifecycleState, apply func(*engine.Game)) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+
+	if err := s.transition(gameID, to); err != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "invalid_transition", Message: err.Error()})
+		return
+	}
+	if apply != nil {
+		apply(game)
+	}
+	if to == StateActive {
+		s.scheduleAutoAI(gameID, game)
+	}
+	c.JSON(http.StatusOK, s.gameToResponse(gameID, game))
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+)
+
+// llmContext returns a context whose LLM exchanges are logged with the game's
+// correlation ID, "game-<id>".
+func llmContext(ctx context.Context, gameID int) context.Context {
+	return ai.WithCorrelationID(ctx, fmt.Sprintf("game-%d", gameID))
+}
+
+// llmExchangeLogger logs LLM exchanges, already redacted, as structured entries.
+func llmExchangeLogger(logger *zap.Logger) ai.LLMLogger {
+	return func(e ai.LLMExchange) {
+		fields := []zap.Field{
+			zap.String("correlation_id", e.CorrelationID),
+			zap.String("kind", e.Kind),
+			zap.String("provider", string(e.Provider)),
+			zap.String("model", e.Model),
+			zap.String("system_prompt", e.SystemPrompt),
+			zap.String("prompt", e.Prompt),
+			zap.String("response", e.Response),
+			zap.Duration("duration", e.Duration),
+		}
+		if e.Error != "" {
+			logger.Warn("LLM exchange failed", append(fields, zap.String("error", e.Error))...)
+			return
+		}
+		logger.Info("LLM exchange", fields...)
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/chat"
+)
+
+// maxLLMTemperature bounds per-request sampling temperatures, as providers do.
+const maxLLMTemperature = 2.0
+
+// checkLLMOverrides validates a request's model and temperature for provider (the
+// default provider if empty), and writes 400 invalid_llm_override if either is not
+// allowed.
+func (s *Server) checkLLMOverrides(c *gin.Context, provider, model string, temperature *float64) bool {
+	return writeBadRequest(c, s.llmOverrideError(provider, model, temperature))
+}
+
+// llmOverrideError returns the invalid_llm_override error of a model and
+// temperature for provider, or nil if both are allowed.
+func (s *Server) llmOverrideError(provider, model string, temperature *float64) *ErrorResponse {
+	if provider == "" {
+		provider = s.config.LLMAI.DefaultProvider
+	}
+	if model != "" && !s.config.IsAllowedLLMModel(provider, model) {
+		return &ErrorResponse{
+			Error:   "invalid_llm_override",
+			Message: fmt.Sprintf("model %q is not allowed for provider %s", model, provider),
+		}
+	}
+	if temperature != nil && (*temperature < 0 || *temperature > maxLLMTemperature) {
+		return &ErrorResponse{
+			Error:   "invalid_llm_override",
+			Message: fmt.Sprintf("temperature must be between 0 and %g", maxLLMTemperature),
+		}
+	}
+	return nil
+}
+
+// writeBadRequest writes 400 with errResp unless it is nil, and reports whether
+// it is nil.
+func writeBadRequest(c *gin.Context, errResp *ErrorResponse) bool {
+	if errResp != nil {
+		c.JSON(http.StatusBadRequest, errResp)
+		return false
+	}
+	return true
+}
+
+// configureLLMEngine shares the server's LLM cache and exchange log with an engine
+// and applies its provider's answer length and timeout, then the request's
+// personality, model, temperature and language.
+func (s *Server) configureLLMEngine(llm *ai.LLMAIEngine, req AIRequest) {
+	llm.SetCache(s.llmCache)
+	llm.SetLogger(s.llmLog)
+	if cfg, ok := s.config.GetLLMProviderConfig(string(llm.GetProvider())); ok {
+		llm.SetMaxTokens(cfg.MaxTokens)
+		llm.SetTimeout(cfg.Timeout)
+	}
+	if p, ok := ai.LookupPersonality(req.Personality); ok {
+		llm.SetPersonality(p)
+	}
+	if req.Model != "" {
+		llm.SetModel(req.Model)
+	}
+	if req.Temperature != nil {
+		llm.SetTemperature(*req.Temperature)
+	}
+	llm.SetLanguage(req.Language)
+}
+
+// checkLanguage validates a request's reply language, and writes 400
+// invalid_language if it is not supported.
+func checkLanguage(c *gin.Context, language string) bool {
+	return writeBadRequest(c, languageError(language))
+}
+
+// languageError returns the invalid_language error of a reply language, or nil
+// if it is supported.
+func languageError(language string) *ErrorResponse {
+	if language != "" && ai.LanguageCode(language) == "" {
+		return &ErrorResponse{
+			Error:   "invalid_language",
+			Message: fmt.Sprintf("language %q is not supported", language),
+		}
+	}
+	return nil
+}
+
+// chatProvider validates a chat request's model, temperature, language,
+// personality and mode, and returns the provider to chat with: the request's, or the default one for a
+// chosen model.
+func (s *Server) chatProvider(c *gin.Context, req ChatRequest) (string, bool) {
+	provider, errResp := s.resolveChatProvider(req)
+	return provider, writeBadRequest(c, errResp)
+}
+
+// resolveChatProvider is chatProvider returning the error instead of writing it.
+func (s *Server) resolveChatProvider(req ChatRequest) (string, *ErrorResponse) {
+	provider := req.Provider
+	if req.Model != "" && provider == "" {
+		provider = s.config.LLMAI.DefaultProvider
+	}
+	if errResp := s.llmOverrideError(provider, req.Model, req.Temperature); errResp != nil {
+		return "", errResp
+	}
+	if errResp := languageError(req.Language); errResp != nil {
+		return "", errResp
+	}
+	if _, errResp := findPersonality(req.Personality); errResp != nil {
+		return "", errResp
+	}
+	if req.Temperature != nil {
+		return "", &ErrorResponse{
+			Error:   "invalid_llm_override",
+			Message: "temperature is not supported for chat",
+		}
+	}
+	if !chat.IsChatMode(req.Mode) {
+		return "", &ErrorResponse{
+			Error:   "invalid_chat_mode",
+			Message: fmt.Sprintf("unknown chat mode %q (use %q or %q)", req.Mode, chat.ModeChat, chat.ModeCoach),
+		}
+	}
+	return provider, nil
+}
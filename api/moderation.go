@@ -0,0 +1,47 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/chat"
+)
+
+// chatRejectedError returns the message_rejected error of a chat message the
+// input filter refused, with its reasons, or nil.
+func chatRejectedError(err error) *ErrorResponse {
+	var rejected *chat.MessageRejectedError
+	if !errors.As(err, &rejected) {
+		return nil
+	}
+	return &ErrorResponse{Error: "message_rejected", Message: rejected.Error(), Reason: strings.Join(rejected.Reasons, ",")}
+}
+
+// writeChatRejected writes 400 message_rejected if the input filter refused the
+// chat message, and reports whether it did.
+func writeChatRejected(c *gin.Context, err error) bool {
+	errResp := chatRejectedError(err)
+	if errResp == nil {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, errResp)
+	return true
+}
+
+// moderationEventLogger logs moderation events as structured entries.
+func moderationEventLogger(logger *zap.Logger) func(chat.ModerationEvent) {
+	return func(e chat.ModerationEvent) {
+		logger.Info("Moderation event",
+			zap.String("direction", e.Direction),
+			zap.String("kind", e.Kind),
+			zap.Int("game_id", e.GameID),
+			zap.String("room_id", e.RoomID),
+			zap.String("user_id", e.UserID),
+			zap.String("action", e.Action),
+			zap.Strings("reasons", e.Reasons))
+	}
+}
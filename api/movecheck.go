@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// moveCheckTimeout bounds the engine check of a move submitted with "check".
+const moveCheckTimeout = 10 * time.Second
+
+// MoveCheckResponse is returned instead of playing a move submitted with "check"
+// that the engine grades as a mistake or blunder. Resubmit the move without
+// "check" to play it anyway.
+type MoveCheckResponse struct {
+	Error         string   `json:"error"` // always "questionable_move"
+	Move          string   `json:"move"`  // SAN
+	Class         string   `json:"class"` // mistake or blunder
+	CentipawnLoss int      `json:"centipawn_loss"`
+	EvalBefore    int      `json:"eval_before"` // centipawns from White's perspective
+	EvalAfter     int      `json:"eval_after"`
+	BestMove      string   `json:"best_move,omitempty"` // SAN
+	BestLine      []string `json:"best_line,omitempty"`
+}
+
+// checkMove grades a move before it is played, or returns nil if the engine fails.
+func (s *Server) checkMove(ctx context.Context, gameID int, game *engine.Game, move engine.Move) *ai.MoveAnalysis {
+	ctx, cancel := context.WithTimeout(ctx, moveCheckTimeout)
+	defer cancel()
+	analyzer := s.newMinimaxAI(ai.DifficultyMedium)
+	analyzer.SetOpeningBook(nil)
+	ma, _, err := ai.CheckMove(ctx, game, move, ai.AnalysisOptions{Engine: analyzer})
+	if err != nil {
+		s.logger.Warn("Move check failed", zap.Int("game_id", gameID), zap.Error(err))
+		return nil
+	}
+	return &ma
+}
+
+// questionableMove returns the MoveCheckResponse refusing a checked move that
+// is a mistake or blunder, or nil. Unchecked moves (nil) go through.
+func questionableMove(ma *ai.MoveAnalysis) *MoveCheckResponse {
+	if ma == nil || (ma.Class != ai.ClassMistake && ma.Class != ai.ClassBlunder) {
+		return nil
+	}
+	resp := MoveCheckResponse{
+		Error:         "questionable_move",
+		Move:          ma.SAN,
+		Class:         string(ma.Class),
+		CentipawnLoss: ma.CentipawnLoss,
+		EvalBefore:    ma.EvalBefore,
+		EvalAfter:     ma.EvalAfter,
+		BestLine:      ma.BestLine,
+	}
+	if len(ma.BestLine) > 0 {
+		resp.BestMove = ma.BestLine[0]
+	}
+	return &resp
+}
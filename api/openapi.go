@@ -0,0 +1,346 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/chat"
+)
+
+// openAPIPath is the route of the API's OpenAPI document.
+const openAPIPath = "/openapi.json"
+
+// operation documents a route. Request and Response are zero values of the
+// types the handler binds and writes, nil for none: their schemas are derived
+// from the types, so the handlers' structs stay the only definition of the API.
+type operation struct {
+	Summary     string
+	Query       map[string]string // query parameters and what they do
+	Request     any
+	Response    any
+	Status      int    // success status, 200 if zero
+	ContentType string // for responses other than JSON
+}
+
+// operations documents every route of SetupRoutes, by method and path; the
+// routes of every API version share the unversioned path, /api/...
+var operations = map[string]operation{
+	"GET /health":         {Summary: "Server health, version and cache statistics"},
+	"GET /openapi.json":   {Summary: "This OpenAPI document"},
+	"GET /ws/games/:id":   {Summary: "WebSocket of a game's events, moves and chat", Query: map[string]string{"spectator": "true to watch without playing", "access_token": "credentials, for browsers"}, Status: http.StatusSwitchingProtocols},
+	"GET /ws/rooms/:room": {Summary: "WebSocket of a chat room", Query: map[string]string{"access_token": "credentials, for browsers"}, Status: http.StatusSwitchingProtocols},
+
+	"POST /api/games":                  {Summary: "Create a game", Request: GameCreateRequest{}, Response: GameResponse{}, Status: http.StatusCreated},
+	"POST /api/exhibitions":            {Summary: "Start an AI vs AI exhibition game", Request: ExhibitionRequest{}, Response: GameResponse{}, Status: http.StatusAccepted},
+	"POST /api/games/import":           {Summary: "Import a game from PGN", Request: GameImportRequest{}, Response: GameResponse{}, Status: http.StatusCreated},
+	"GET /api/games":                   {Summary: "List games", Query: map[string]string{"state": "lifecycle state to list"}},
+	"GET /api/games/export":            {Summary: "Download games as PGN or a zip of PGN files", Query: map[string]string{"status": "lifecycle state", "since": "RFC 3339 time or date of creation", "format": "pgn or zip"}, ContentType: "application/x-chess-pgn"},
+	"GET /api/games/:id":               {Summary: "Get a game", Query: map[string]string{"ply": "plies into the game to look at"}, Response: GameResponse{}},
+	"DELETE /api/games/:id":            {Summary: "Delete a game", Status: http.StatusNoContent},
+	"PATCH /api/games/:id":             {Summary: "Change a game's settings", Request: GameSettingsRequest{}, Response: GameResponse{}},
+	"POST /api/games/:id/players":      {Summary: "Invite a player", Request: InvitePlayerRequest{}, Response: GameResponse{}},
+	"POST /api/games/:id/join":         {Summary: "Take a seat in a two-player game", Response: JoinGameResponse{}},
+	"POST /api/games/:id/moves":        {Summary: "Play a move", Request: MoveRequest{}, Response: GameResponse{}},
+	"GET /api/games/:id/moves":         {Summary: "Get the move history"},
+	"GET /api/games/:id/events":        {Summary: "Stream the game's events as Server-Sent Events", Query: map[string]string{"access_token": "credentials, for EventSource"}, ContentType: "text/event-stream"},
+	"POST /api/games/:id/ai-move":      {Summary: "Have the AI move", Request: AIRequest{}},
+	"POST /api/games/:id/ai-hint":      {Summary: "Ask the AI for a hint", Request: AIRequest{}},
+	"POST /api/games/:id/claim-draw":   {Summary: "Claim a draw", Request: ClaimDrawRequest{}, Response: GameResponse{}},
+	"POST /api/games/:id/resign":       {Summary: "Resign", Response: GameResponse{}},
+	"POST /api/games/:id/draw-offer":   {Summary: "Offer a draw", Response: DrawOfferResponse{}},
+	"POST /api/games/:id/draw-accept":  {Summary: "Accept a draw offer", Response: GameResponse{}},
+	"POST /api/games/:id/draw-decline": {Summary: "Decline a draw offer", Response: GameResponse{}},
+	"POST /api/games/:id/undo":         {Summary: "Take back moves, or ask the opponent to", Request: UndoRequest{}, Response: TakebackResponse{}},
+	"POST /api/games/:id/undo/accept":  {Summary: "Accept a takeback request", Response: TakebackResponse{}},
+	"POST /api/games/:id/undo/decline": {Summary: "Decline a takeback request", Response: GameResponse{}},
+	"POST /api/games/:id/pause":        {Summary: "Pause a game", Response: GameResponse{}},
+	"POST /api/games/:id/resume":       {Summary: "Resume a paused game", Response: GameResponse{}},
+	"POST /api/games/:id/archive":      {Summary: "Archive a finished game", Response: GameResponse{}},
+
+	"POST /api/games/:id/conditional-moves":           {Summary: "Queue conditional moves", Request: ConditionalMoveRequest{}, Response: ConditionalLineResponse{}, Status: http.StatusCreated},
+	"GET /api/games/:id/conditional-moves":            {Summary: "List your conditional moves"},
+	"DELETE /api/games/:id/conditional-moves/:lineId": {Summary: "Delete a conditional line", Status: http.StatusNoContent},
+
+	"POST /api/games/:id/chat":                   {Summary: "Chat with the AI about the game", Request: ChatRequest{}, Response: ChatResponse{}},
+	"GET /api/games/:id/chat/history":            {Summary: "Get the game's chat history", Response: ChatHistoryResponse{}},
+	"DELETE /api/games/:id/chat":                 {Summary: "Clear the game's chat", Status: http.StatusNoContent},
+	"GET /api/games/:id/transcript":              {Summary: "Download the game with its chat", Query: map[string]string{"format": "text or markdown"}, ContentType: "text/plain"},
+	"POST /api/games/:id/react":                  {Summary: "Get the AI's reaction to a move", Request: ReactionRequest{}, Response: ReactionResponse{}},
+	"POST /api/chat":                             {Summary: "Chat with the AI outside a game", Request: ChatRequest{}, Response: ChatResponse{}},
+	"GET /api/personalities":                     {Summary: "List the AI personalities"},
+	"GET /api/rooms":                             {Summary: "List chat rooms"},
+	"POST /api/rooms":                            {Summary: "Create a chat room", Request: CreateRoomRequest{}, Response: chat.Room{}, Status: http.StatusCreated},
+	"GET /api/rooms/:room":                       {Summary: "Get a chat room", Response: chat.Room{}},
+	"POST /api/rooms/:room/join":                 {Summary: "Join a chat room", Response: chat.Room{}},
+	"POST /api/rooms/:room/leave":                {Summary: "Leave a chat room", Response: chat.Room{}},
+	"GET /api/rooms/:room/messages":              {Summary: "Get a room's latest messages", Query: map[string]string{"limit": "messages to return"}},
+	"POST /api/rooms/:room/messages":             {Summary: "Post to a chat room", Request: RoomMessageRequest{}, Response: RoomMessageResponse{}, Status: http.StatusCreated},
+	"GET /api/games/:id/legal-moves":             {Summary: "List the legal moves", Query: map[string]string{"ply": "plies into the game to look at"}},
+	"GET /api/games/:id/fen":                     {Summary: "Get the position in FEN", Query: map[string]string{"ply": "plies into the game to look at"}, Response: FENResponse{}},
+	"POST /api/games/:id/fen":                    {Summary: "Load a position from FEN", Request: FENRequest{}, Response: GameResponse{}},
+	"GET /api/games/:id/analysis":                {Summary: "Analyze the position", Query: map[string]string{"ply": "plies into the game to look at", "lines": "principal variations, 1 to 5", "depth": "search depth in plies", "movetime": "search time in milliseconds"}},
+	"GET /api/games/:id/pgn":                     {Summary: "Export the game as PGN", Query: map[string]string{"summary": "true to append the game summary"}, ContentType: "text/plain"},
+	"GET /api/games/:id/image":                   {Summary: "Draw the board as SVG or PNG", Query: map[string]string{"move": "plies into the game to draw", "size": "pixels, 128 to 2048", "theme": "light or dark", "orientation": "white or black", "format": "svg or png"}, ContentType: "image/svg+xml"},
+	"GET /api/games/:id/review":                  {Summary: "Review every move with the engine", Response: ReviewResponse{}},
+	"GET /api/games/:id/summary":                 {Summary: "Summarize a finished game", Query: map[string]string{"provider": "LLM provider to write it"}, Response: SummaryResponse{}},
+	"PUT /api/games/:id/moves/:index/annotation": {Summary: "Annotate a move", Request: AnnotationRequest{}, Response: []MoveResponse{}},
+
+	"POST /api/practice-sets":                               {Summary: "Create a practice set", Request: PracticeSetCreateRequest{}, Response: PracticeSet{}, Status: http.StatusCreated},
+	"GET /api/practice-sets":                                {Summary: "List practice sets"},
+	"GET /api/practice-sets/:id":                            {Summary: "Get a practice set", Response: PracticeSet{}},
+	"POST /api/practice-sets/:id/positions/:index/start":    {Summary: "Start a game from a practice position", Status: http.StatusCreated},
+	"POST /api/practice-sets/:id/positions/:index/complete": {Summary: "Mark a practice position done", Response: PracticeSet{}},
+	"GET /api/puzzles":                                      {Summary: "Get a puzzle near your rating", Query: map[string]string{"user_id": "solver, if not given by X-User-ID", "difficulty": "easiest, easy, normal, hard or hardest", "theme": "puzzle theme"}, Response: PuzzleResponse{}},
+	"GET /api/puzzles/themes":                               {Summary: "List puzzle themes"},
+	"GET /api/puzzles/ratings/:user":                        {Summary: "Get a solver's puzzle rating", Response: PuzzleRatingResponse{}},
+	"GET /api/puzzles/:id":                                  {Summary: "Get a puzzle", Response: PuzzleResponse{}},
+	"POST /api/puzzles/:id/attempts":                        {Summary: "Attempt a puzzle move", Request: PuzzleAttemptRequest{}, Response: PuzzleAttemptResponse{}},
+}
+
+// serveOpenAPI serves the OpenAPI document of the routes of r, built on the
+// first request.
+func (s *Server) serveOpenAPI(r *gin.Engine) gin.HandlerFunc {
+	var (
+		once sync.Once
+		doc  []byte
+	)
+	return func(c *gin.Context) {
+		once.Do(func() {
+			doc, _ = json.Marshal(s.openAPIDocument(r.Routes()))
+		})
+		c.Data(http.StatusOK, "application/json; charset=utf-8", doc)
+	}
+}
+
+// openAPIDocument describes routes as an OpenAPI 3 document.
+func (s *Server) openAPIDocument(routes gin.RoutesInfo) map[string]any {
+	schemas := &schemaSet{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+	errorRef := schemas.of(reflect.TypeOf(ErrorResponse{}))
+	paths := map[string]map[string]any{}
+	for _, route := range routes {
+		op := operations[route.Method+" "+unversionedPath(route.Path)]
+		pattern, params := openAPIPathParams(route.Path)
+		for _, name := range sortedKeys(op.Query) {
+			params = append(params, map[string]any{"name": name, "in": "query", "description": op.Query[name], "schema": map[string]any{"type": "string"}})
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		switch {
+		case op.Response != nil:
+			success["content"] = map[string]any{"application/json": map[string]any{"schema": schemas.of(reflect.TypeOf(op.Response))}}
+		case op.ContentType != "":
+			success["content"] = map[string]any{op.ContentType: map[string]any{"schema": map[string]any{"type": "string"}}}
+		case status != http.StatusNoContent && status != http.StatusSwitchingProtocols:
+			success["content"] = map[string]any{"application/json": map[string]any{"schema": map[string]any{"type": "object"}}}
+		}
+		operation := map[string]any{
+			"operationId": openAPIOperationID(route.Method, route.Path),
+			"summary":     op.Summary,
+			"tags":        []string{openAPITag(unversionedPath(route.Path))},
+			"responses": map[string]any{
+				strconv.Itoa(status): success,
+				"default":            map[string]any{"description": "Error", "content": map[string]any{"application/json": map[string]any{"schema": errorRef}}},
+			},
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if strings.HasPrefix(route.Path, legacyAPIPrefix+"/") && route.Path == unversionedPath(route.Path) {
+			operation["deprecated"] = true // a legacy alias of an /api/v1 route
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]any{"content": map[string]any{"application/json": map[string]any{"schema": schemas.of(reflect.TypeOf(op.Request))}}}
+		}
+		if paths[pattern] == nil {
+			paths[pattern] = map[string]any{}
+		}
+		paths[pattern][strings.ToLower(route.Method)] = operation
+	}
+
+	doc := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "go-chess API",
+			"version": APIVersion,
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas.schemas},
+	}
+	if s.config.Server.Auth.Enabled {
+		doc["components"].(map[string]any)["securitySchemes"] = map[string]any{
+			"apiKey": map[string]any{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+			"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+		}
+		doc["security"] = []map[string][]string{{"apiKey": {}}, {"bearer": {}}}
+	}
+	return doc
+}
+
+// routeParam matches the parameters of gin route paths.
+var routeParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPIPathParams converts a gin path such as /api/games/:id to OpenAPI's
+// /api/games/{id} and describes its parameters.
+func openAPIPathParams(route string) (string, []map[string]any) {
+	var params []map[string]any
+	for _, match := range routeParam.FindAllStringSubmatch(route, -1) {
+		params = append(params, map[string]any{"name": match[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+	}
+	return routeParam.ReplaceAllString(route, "{$1}"), params
+}
+
+// openAPIOperationID names an operation after its method and path, e.g.
+// getApiGamesIdMoves.
+func openAPIOperationID(method, route string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(route, func(r rune) bool { return r == '/' || r == ':' || r == '-' || r == '.' }) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// openAPITag groups routes by the resource they are under, e.g. games.
+func openAPITag(route string) string {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(route, "/api"), "/"), "/")
+	if parts[0] == "ws" || len(parts) == 1 {
+		return strings.TrimSuffix(parts[0], ".json")
+	}
+	return parts[0]
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaSet derives JSON schemas from Go types as encoding/json encodes them.
+// Named structs become components referred to by name.
+type schemaSet struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+// of returns the schema of t.
+func (ss *schemaSet) of(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType, t.Implements(jsonMarshalerType), reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return map[string]any{}
+	case t.Implements(textMarshalerType), reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": ss.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": ss.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return ss.object(t)
+		}
+		name, seen := ss.names[t]
+		if !seen {
+			name = t.Name()
+			if _, taken := ss.schemas[name]; taken {
+				pkg := path.Base(t.PkgPath())
+				name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+			}
+			ss.names[t] = name
+			ss.schemas[name] = map[string]any{} // placeholder for recursive types
+			ss.schemas[name] = ss.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]any{}
+}
+
+// object returns the schema of a struct's JSON object, with the fields of
+// embedded structs inlined. Fields bound as required are required.
+func (ss *schemaSet) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" {
+				embedded := field.Type
+				if embedded.Kind() == reflect.Pointer {
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					addFields(embedded)
+					continue
+				}
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			schema := ss.of(field.Type)
+			if strings.Contains(opts, "string") {
+				schema = map[string]any{"type": "string"}
+			}
+			properties[name] = schema
+			if strings.Contains(field.Tag.Get("binding"), "required") {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/config"
+	"go.rumenx.com/chess/engine"
+	"go.rumenx.com/chess/store"
+)
+
+// openStore opens the configured database, or returns nil if it is disabled or
+// fails to open, in which case games and conversations stay in memory.
+func openStore(cfg config.DatabaseConfig, logger *zap.Logger) store.Store {
+	if !cfg.Enabled {
+		return nil
+	}
+	db, err := store.Open(cfg)
+	if err != nil {
+		logger.Error("Failed to open database, keeping games and conversations in memory", zap.Error(err))
+		return nil
+	}
+	return db
+}
+
+// restoreLLMHistory gives an LLM engine what it remembered of the game before.
+func (s *Server) restoreLLMHistory(ctx context.Context, gameID int, aiEngine ai.Engine) {
+	llm, ok := aiEngine.(*ai.LLMAIEngine)
+	if !ok || s.store == nil {
+		return
+	}
+	history, err := s.store.LoadLLMHistory(ctx, gameID)
+	if err != nil {
+		s.logger.Warn("Failed to load LLM history", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+	llm.SetHistory(history)
+}
+
+// saveLLMHistory stores what an LLM engine remembers of the game.
+func (s *Server) saveLLMHistory(ctx context.Context, gameID int, aiEngine ai.Engine) {
+	llm, ok := aiEngine.(*ai.LLMAIEngine)
+	if !ok || s.store == nil {
+		return
+	}
+	if err := s.store.SaveLLMHistory(context.WithoutCancel(ctx), gameID, llm.History()); err != nil {
+		s.logger.Warn("Failed to save LLM history", zap.Int("game_id", gameID), zap.Error(err))
+	}
+}
+
+// forgetLLMSession removes the stored chat and LLM history of a game, so that a
+// game given its ID after a restart starts afresh.
+func (s *Server) forgetLLMSession(gameID int) {
+	if s.store == nil {
+		return
+	}
+	if s.chatService != nil {
+		s.chatService.ClearConversation(gameID)
+	}
+	if err := s.store.DeleteLLMHistory(context.Background(), gameID); err != nil {
+		s.logger.Warn("Failed to delete LLM history", zap.Int("game_id", gameID), zap.Error(err))
+	}
+}
+
+// gameSaver queues changed games for saving to the game store. Games are saved
+// one at a time, each as it is when its turn comes, so a later state is never
+// overwritten by an earlier one.
+type gameSaver struct {
+	games    store.GameStore
+	mu       sync.Mutex
+	pending  map[int]struct{}
+	versions map[int]gameVersion // of games shared with other servers
+	wake     chan struct{}
+	saving   sync.Mutex // held while games are saved
+	// unknownSynced is when a request for an unknown game last synced them all
+	unknownSynced time.Time
+}
+
+func newGameSaver(games store.GameStore) *gameSaver {
+	return &gameSaver{
+		games:    games,
+		pending:  make(map[int]struct{}),
+		versions: make(map[int]gameVersion),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// saveLater queues a game for saving, or for deleting from the store if it is
+// gone by then. It never blocks, so it may be called under any lock.
+func (s *Server) saveLater(gameID int) {
+	if s.saver == nil {
+		return
+	}
+	s.saver.mu.Lock()
+	s.saver.pending[gameID] = struct{}{}
+	s.saver.mu.Unlock()
+	select {
+	case s.saver.wake <- struct{}{}:
+	default: // already woken
+	}
+}
+
+// saveGames saves queued games whenever there are any.
+func (s *Server) saveGames() {
+	for range s.saver.wake {
+		s.flushGames()
+	}
+}
+
+// flushGames saves the queued games now.
+func (s *Server) flushGames() {
+	s.saver.saving.Lock()
+	defer s.saver.saving.Unlock()
+	s.saver.mu.Lock()
+	pending := s.saver.pending
+	s.saver.pending = make(map[int]struct{})
+	s.saver.mu.Unlock()
+
+	ctx := context.Background()
+	for gameID := range pending {
+		if s.coordinator != nil {
+			s.flushSharedGame(ctx, gameID)
+			continue
+		}
+		record, exists, err := s.gameRecord(gameID)
+		switch {
+		case err != nil:
+			s.logger.Warn("Failed to encode game", zap.Int("game_id", gameID), zap.Error(err))
+			continue
+		case exists:
+			err = s.saver.games.SaveGame(ctx, record)
+		default:
+			err = s.saver.games.DeleteGame(ctx, gameID)
+		}
+		if err != nil {
+			s.logger.Warn("Failed to save game", zap.Int("game_id", gameID), zap.Error(err))
+		}
+	}
+}
+
+// flushSharedGame saves a game shared with other servers by locking it, or
+// deletes it from the store if it is gone.
+func (s *Server) flushSharedGame(ctx context.Context, gameID int) {
+	s.gamesMux.RLock()
+	lock := s.gameLocks[gameID]
+	s.gamesMux.RUnlock()
+	if lock != nil {
+		if err := lockGame(lock); err != nil {
+			s.logger.Warn("Failed to save shared game", zap.Int("game_id", gameID), zap.Error(err))
+			return
+		}
+		lock.Unlock() // saves the game if it changed
+		return
+	}
+	if err := s.saver.games.DeleteGame(ctx, gameID); err != nil {
+		s.logger.Warn("Failed to delete game", zap.Int("game_id", gameID), zap.Error(err))
+	}
+	s.forgetGameVersion(gameID)
+}
+
+// saveOnMutation queues the game of every successful request that changes it
+// for saving. Games shared with other servers are saved before the response
+// is sent, so that the next request sees them whichever server it reaches.
+func (s *Server) saveOnMutation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if c.Request.Method == http.MethodGet || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		if strings.HasPrefix(routePath(c), "/api/games/:id") {
+			if gameID, ok := s.resolveGameID(c.Param("id")); ok {
+				s.saveLater(gameID)
+			}
+		}
+		if s.coordinator != nil {
+			s.flushGames()
+		}
+	}
+}
+
+// gameRecord returns the record of a game to save, or reports that the game no
+// longer exists.
+func (s *Server) gameRecord(gameID int) (*store.GameRecord, bool, error) {
+	s.gamesMux.RLock()
+	lock := s.gameLocks[gameID]
+	s.gamesMux.RUnlock()
+	if lock == nil {
+		return nil, false, nil
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	return s.gameRecordLocked(gameID)
+}
+
+// gameRecordLocked is gameRecord for callers that hold the game's lock.
+func (s *Server) gameRecordLocked(gameID int) (*store.GameRecord, bool, error) {
+	s.gamesMux.RLock()
+	game, exists := s.games[gameID]
+	record := &store.GameRecord{ID: gameID}
+	var err error
+	if metadata := s.gameMetadata[gameID]; exists && metadata != nil {
+		record.CreatedAt = metadata.CreatedAt
+		record.Metadata, err = json.Marshal(metadata)
+	}
+	s.gamesMux.RUnlock()
+	if !exists || err != nil {
+		return nil, false, err
+	}
+
+	record.PGN = gamePGN(game)
+	san := game.GenerateSAN()
+	for i, move := range game.MoveHistory() {
+		record.Moves = append(record.Moves, store.MoveRecord{UCI: move.UCI(), SAN: san[i]})
+	}
+	return record, true, nil
+}
+
+// gamePGN returns the PGN a game is saved as: the tags engine.ParsePGN needs to
+// restore its variant, starting position, clock and result, and its moves with
+// their clock comments and annotations.
+func gamePGN(game *engine.Game) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Variant \"%s\"]\n", pgnVariantName(game.Variant()))
+	if game.StartedFromFEN() && game.StartingFEN() != "" {
+		fmt.Fprintf(&b, "[SetUp \"1\"]\n[FEN \"%s\"]\n", game.StartingFEN())
+	}
+	if clock := game.Clock(); clock != nil {
+		fmt.Fprintf(&b, "[TimeControl \"%s\"]\n", clock.Control())
+	}
+	result := game.Result()
+	fmt.Fprintf(&b, "[Result \"%s\"]\n[Termination \"%s\"]\n", result, result.PGNTermination())
+	b.WriteString("\n" + pgnMovetext(game, nil) + result.String() + "\n")
+	return b.String()
+}
+
+// restoreGames loads the saved games, so that they carry on after a restart.
+// Clocks resume from the times left after the last moves.
+func (s *Server) restoreGames() {
+	records, err := s.saver.games.LoadGames(context.Background())
+	if err != nil {
+		s.logger.Error("Failed to load saved games", zap.Error(err))
+		return
+	}
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	for _, record := range records {
+		game, metadata, err := restoreGame(record)
+		if err != nil {
+			s.logger.Warn("Failed to restore game", zap.Int("game_id", record.ID), zap.Error(err))
+			continue
+		}
+		s.games[record.ID] = game
+		s.gameMetadata[record.ID] = metadata
+		s.indexGameLocked(record.ID, metadata)
+		s.gameLocks[record.ID] = s.newGameLock(record.ID)
+		s.observeGame(record.ID, game)
+		game.RecordTimestamps()
+		s.nextID = max(s.nextID, record.ID+1)
+		s.setGameVersion(record.ID, &record)
+	}
+	if len(s.games) > 0 {
+		s.logger.Info("Restored saved games", zap.Int("games", len(s.games)))
+	}
+}
+
+// restoreGame rebuilds a saved game and its metadata.
+func restoreGame(record store.GameRecord) (*engine.Game, *GameMetadata, error) {
+	imported, err := engine.ParsePGN(record.PGN)
+	if err != nil {
+		return nil, nil, err
+	}
+	game := imported.Game
+	metadata := &GameMetadata{CreatedAt: record.CreatedAt, Lifecycle: StateActive}
+	if len(record.Metadata) > 0 {
+		if err := json.Unmarshal(record.Metadata, metadata); err != nil {
+			return nil, nil, fmt.Errorf("invalid metadata: %w", err)
+		}
+	}
+	if metadata.Adaptive {
+		metadata.adaptive = ai.NewAdaptiveStrength(ai.DefaultAdaptivePolicy())
+	}
+
+	if control, ok := imported.Tags["TimeControl"]; ok {
+		tc, err := engine.ParseTimeControl(control)
+		if err != nil {
+			return nil, nil, err
+		}
+		clock := engine.NewClock(tc)
+		// The last timing of each side has its time left
+		mover, set := game.ActiveColor(), map[engine.Color]bool{}
+		for _, timing := range slices.Backward(game.MoveTimings()) {
+			mover = mover.Opposite()
+			if timing.HasClock && !set[mover] {
+				clock.SetRemaining(mover, timing.Clock)
+				set[mover] = true
+			}
+		}
+		game.SetClock(clock)
+		if metadata.Lifecycle != StateActive {
+			clock.Stop()
+		}
+	}
+	return game, metadata, nil
+}
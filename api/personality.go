@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/ai"
+)
+
+// listPersonalities lists the personality presets LLM opponents can play.
+func (s *Server) listPersonalities(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"personalities": ai.Personalities})
+}
+
+// lookupPersonality returns the preset a request names, the zero Personality if
+// it names none, and writes 400 invalid_personality for an unknown name.
+func lookupPersonality(c *gin.Context, name string) (ai.Personality, bool) {
+	p, errResp := findPersonality(name)
+	return p, writeBadRequest(c, errResp)
+}
+
+// findPersonality is lookupPersonality returning the error instead of writing it.
+func findPersonality(name string) (ai.Personality, *ErrorResponse) {
+	if name == "" {
+		return ai.Personality{}, nil
+	}
+	p, ok := ai.LookupPersonality(name)
+	if !ok {
+		return p, &ErrorResponse{
+			Error:   "invalid_personality",
+			Message: fmt.Sprintf("unknown personality %q (see GET /api/personalities)", name),
+		}
+	}
+	return p, nil
+}
+
+// gamePersonality returns the name of the preset the game's LLM plays, or "".
+func (s *Server) gamePersonality(gameID int) string {
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	if metadata := s.gameMetadata[gameID]; metadata != nil {
+		return metadata.Personality
+	}
+	return ""
+}
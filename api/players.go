@@ -0,0 +1,196 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// aiColorNone is the AI color of games between two people.
+const aiColorNone = "none"
+
+// playerTokenHeader carries the join token of a player of a two-player game.
+const playerTokenHeader = "X-Player-Token"
+
+// JoinGameResponse is the seat a player took in a two-player game.
+type JoinGameResponse struct {
+	Color string       `json:"color"`
+	Game  GameResponse `json:"game"`
+}
+
+// isTwoPlayer reports whether a game is played by two people.
+func (metadata *GameMetadata) isTwoPlayer() bool {
+	return metadata != nil && metadata.AIColor == aiColorNone
+}
+
+// newPlayerTokens gives a two-player game a join token per color, kept hashed
+// in its metadata, and returns the tokens.
+func newPlayerTokens(metadata *GameMetadata) (map[string]string, error) {
+	tokens := make(map[string]string, 2)
+	metadata.PlayerTokens = make(map[string]string, 2)
+	for _, color := range []string{"white", "black"} {
+		var random [24]byte
+		if _, err := rand.Read(random[:]); err != nil {
+			return nil, err
+		}
+		tokens[color] = hex.EncodeToString(random[:])
+		metadata.PlayerTokens[color] = hashPlayerToken(tokens[color])
+	}
+	return tokens, nil
+}
+
+func hashPlayerToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestPlayerToken returns the join token of a request: the X-Player-Token
+// header, else the player_token query parameter.
+func requestPlayerToken(c *gin.Context) string {
+	if token := strings.TrimSpace(c.GetHeader(playerTokenHeader)); token != "" {
+		return token
+	}
+	return strings.TrimSpace(c.Query("player_token"))
+}
+
+// playerColorLocked returns the color a join token seats in a two-player game,
+// or "". The caller must hold gamesMux.
+func (s *Server) playerColorLocked(gameID int, token string) string {
+	metadata := s.gameMetadata[gameID]
+	if token == "" || !metadata.isTwoPlayer() {
+		return ""
+	}
+	hash := hashPlayerToken(token)
+	color := ""
+	for c, h := range metadata.PlayerTokens {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			color = c
+		}
+	}
+	return color
+}
+
+// playerColor is playerColorLocked for callers that do not hold gamesMux.
+func (s *Server) playerColor(gameID int, token string) string {
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	return s.playerColorLocked(gameID, token)
+}
+
+// requestPlayerColor returns the color a request plays, writing an error
+// response if it plays none: in two-player games the color its join token
+// seats, in games against the AI the color the AI does not play.
+func (s *Server) requestPlayerColor(c *gin.Context, gameID int) (engine.Color, bool) {
+	color, status, errResp := s.tokenPlayerColor(gameID, requestPlayerToken(c))
+	if errResp != nil {
+		c.JSON(status, *errResp)
+		return engine.None, false
+	}
+	return color, true
+}
+
+// tokenPlayerColor is requestPlayerColor for the holder of a player token,
+// returning the error and its HTTP status if they play no color.
+func (s *Server) tokenPlayerColor(gameID int, token string) (engine.Color, int, *ErrorResponse) {
+	s.gamesMux.RLock()
+	metadata := s.gameMetadata[gameID]
+	exhibition := metadata != nil && metadata.Exhibition != nil
+	twoPlayer := metadata.isTwoPlayer()
+	aiColor := "black"
+	if metadata != nil && metadata.AIColor != "" {
+		aiColor = metadata.AIColor
+	}
+	color := s.playerColorLocked(gameID, token)
+	s.gamesMux.RUnlock()
+	switch {
+	case exhibition:
+		return engine.None, http.StatusConflict, &ErrorResponse{Error: "exhibition_game", Message: "exhibition games are played by their LLMs"}
+	case !twoPlayer && aiColor == "white":
+		return engine.Black, 0, nil
+	case !twoPlayer:
+		return engine.White, 0, nil
+	case token == "":
+		return engine.None, http.StatusUnauthorized, &ErrorResponse{Error: "player_token_required", Message: "two-player games are played with a player token"}
+	case color == "":
+		return engine.None, http.StatusForbidden, &ErrorResponse{Error: "invalid_player_token", Message: "the player token is not one of this game's"}
+	case color == "white":
+		return engine.White, 0, nil
+	}
+	return engine.Black, 0, nil
+}
+
+// requirePlayerTurn writes an error response unless the request may move in
+// the game now: in two-player games its join token must seat the side to move.
+// Games against the AI need no token.
+func (s *Server) requirePlayerTurn(c *gin.Context, gameID int, game *engine.Game) bool {
+	if status, errResp := s.playerTurnError(gameID, game, requestPlayerToken(c)); errResp != nil {
+		c.JSON(status, *errResp)
+		return false
+	}
+	return true
+}
+
+// playerTurnError is requirePlayerTurn for the holder of a player token,
+// returning the error and its HTTP status if they may not move now.
+func (s *Server) playerTurnError(gameID int, game *engine.Game, token string) (int, *ErrorResponse) {
+	s.gamesMux.RLock()
+	twoPlayer := s.gameMetadata[gameID].isTwoPlayer()
+	s.gamesMux.RUnlock()
+	if !twoPlayer {
+		return 0, nil
+	}
+	color, status, errResp := s.tokenPlayerColor(gameID, token)
+	if errResp != nil {
+		return status, errResp
+	}
+	if color != game.ActiveColor() {
+		return http.StatusConflict, &ErrorResponse{Error: "not_your_turn", Message: "it is " + game.ActiveColor().String() + "'s turn"}
+	}
+	return 0, nil
+}
+
+// joinGame seats the holder of a join token in a two-player game. The game
+// becomes active once both players joined. With auth on, the user joining
+// becomes one of the game's players.
+func (s *Server) joinGame(c *gin.Context) {
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
+		return
+	}
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	game, exists := s.games[gameID]
+	metadata := s.gameMetadata[gameID]
+	if !exists || metadata == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
+		return
+	}
+	if !metadata.isTwoPlayer() {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "not_two_player", Message: "only two-player games are joined"})
+		return
+	}
+	color := s.playerColorLocked(gameID, requestPlayerToken(c))
+	if color == "" {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "invalid_player_token", Message: "the player token is not one of this game's"})
+		return
+	}
+
+	if !slices.Contains(metadata.Joined, color) {
+		metadata.Joined = append(metadata.Joined, color)
+	}
+	if userID := authenticatedUserID(c); userID != "" && userID != metadata.Owner && !slices.Contains(metadata.Players, userID) {
+		metadata.Players = append(metadata.Players, userID)
+	}
+	if len(metadata.Joined) == 2 && metadata.Lifecycle == StateAwaitingPlayers {
+		_ = s.transitionLocked(gameID, StateActive)
+	}
+	c.JSON(http.StatusOK, JoinGameResponse{Color: color, Game: s.gameToResponseLocked(gameID, game)})
+}
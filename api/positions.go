@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// FENResponse is a game's position in FEN.
+type FENResponse struct {
+	FEN         string `json:"fen"`
+	Ply         int    `json:"ply"` // plies played in the position
+	ActiveColor string `json:"active_color"`
+}
+
+// positionParam returns the game as it stood after the number of plies in the
+// query parameter param, or the game itself without one. Invalid plies get a
+// 400 and false.
+func positionParam(c *gin.Context, game *engine.Game, param string) (*engine.Game, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return game, true
+	}
+	plies := len(game.MoveHistory())
+	ply, err := strconv.Atoi(value)
+	if err != nil || ply < 0 || ply > plies {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_" + param, Message: fmt.Sprintf("%s must be a ply from 0 to %d", param, plies)})
+		return nil, false
+	}
+	if ply == plies {
+		return game, true
+	}
+	position, err := positionAt(game, ply)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "replay_failed", Message: err.Error()})
+		return nil, false
+	}
+	return position, true
+}
+
+// positionAt replays the first plies of a game on a new game.
+func positionAt(game *engine.Game, plies int) (*engine.Game, error) {
+	replay := engine.NewGameWithVariant(game.Variant())
+	if game.StartedFromFEN() {
+		if err := replay.ParseFEN(game.StartingFEN()); err != nil {
+			return nil, fmt.Errorf("starting position: %w", err)
+		}
+	}
+	for _, move := range game.MoveHistory()[:plies] {
+		if err := replay.MakeMove(move); err != nil {
+			return nil, fmt.Errorf("replaying %s: %w", move, err)
+		}
+	}
+	return replay, nil
+}
+
+// getFEN returns a game's position in FEN, or with ?ply= the position after
+// that many plies, without the rest of the game state.
+func (s *Server) getFEN(c *gin.Context) {
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
+		return
+	}
+
+	s.gamesMux.RLock()
+	game, exists := s.games[gameID]
+	s.gamesMux.RUnlock()
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
+		return
+	}
+	if game, ok = positionParam(c, game, "ply"); !ok {
+		return
+	}
+	c.JSON(http.StatusOK, FENResponse{FEN: game.ToFEN(), Ply: len(game.MoveHistory()), ActiveColor: game.ActiveColor().String()})
+}
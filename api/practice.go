@@ -0,0 +1,311 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// PracticePositionRequest describes one position in a practice set creation request.
+type PracticePositionRequest struct {
+	FEN       string `json:"fen"`
+	Title     string `json:"title,omitempty"`
+	Goal      string `json:"goal,omitempty"`      // free text, e.g. "win this endgame"
+	Objective string `json:"objective,omitempty"` // "win", "draw" or empty for manual completion
+}
+
+// PracticeSetCreateRequest represents a practice set creation request.
+type PracticeSetCreateRequest struct {
+	Title     string                    `json:"title"`
+	Engine    string                    `json:"engine,omitempty"` // random, minimax, mcts, llm, hybrid (default minimax)
+	Level     string                    `json:"level,omitempty"`  // beginner ... expert (default medium)
+	Positions []PracticePositionRequest `json:"positions"`
+}
+
+// PracticePosition is a single training position and its progress.
+type PracticePosition struct {
+	Index       int        `json:"index"`
+	FEN         string     `json:"fen"`
+	Title       string     `json:"title,omitempty"`
+	Goal        string     `json:"goal,omitempty"`
+	Objective   string     `json:"objective,omitempty"`
+	PlayerColor string     `json:"player_color"`
+	GameID      int        `json:"game_id,omitempty"` // most recent attempt
+	Attempts    int        `json:"attempts"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// PracticeSet is a navigable collection of practice positions.
+type PracticeSet struct {
+	ID        int                 `json:"id"`
+	Title     string              `json:"title"`
+	Engine    string              `json:"engine"`
+	Level     string              `json:"level"`
+	Positions []*PracticePosition `json:"positions"`
+	Completed int                 `json:"completed"`
+	Total     int                 `json:"total"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// maxPracticePositions bounds the size of a single practice set.
+const maxPracticePositions = 200
+
+// createPracticeSet creates a practice set from a list of FEN positions.
+func (s *Server) createPracticeSet(c *gin.Context) {
+	var req PracticeSetCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	if len(req.Positions) == 0 || len(req.Positions) > maxPracticePositions {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("positions must contain between 1 and %d entries", maxPracticePositions),
+		})
+		return
+	}
+
+	switch req.Engine {
+	case "":
+		req.Engine = "minimax"
+	case "random", "minimax", "mcts", "llm", "hybrid":
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_engine", Message: req.Engine})
+		return
+	}
+	if req.Level == "" {
+		req.Level = "medium"
+	}
+
+	positions := make([]*PracticePosition, 0, len(req.Positions))
+	for i, p := range req.Positions {
+		game := engine.NewGame()
+		if err := game.ParseFEN(p.FEN); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_fen",
+				Message: fmt.Sprintf("position %d: %v", i, err),
+			})
+			return
+		}
+		if game.IsGameOver() {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_fen",
+				Message: fmt.Sprintf("position %d: game is already over", i),
+			})
+			return
+		}
+		switch p.Objective {
+		case "", "win", "draw":
+		default:
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_objective",
+				Message: fmt.Sprintf("position %d: objective must be win, draw or empty", i),
+			})
+			return
+		}
+		positions = append(positions, &PracticePosition{
+			Index:       i,
+			FEN:         p.FEN,
+			Title:       p.Title,
+			Goal:        p.Goal,
+			Objective:   p.Objective,
+			PlayerColor: game.ActiveColor().String(),
+		})
+	}
+
+	s.practiceMux.Lock()
+	set := &PracticeSet{
+		ID:        s.nextPracticeID,
+		Title:     req.Title,
+		Engine:    req.Engine,
+		Level:     req.Level,
+		Positions: positions,
+		Total:     len(positions),
+		CreatedAt: time.Now(),
+	}
+	s.practiceSets[set.ID] = set
+	s.nextPracticeID++
+	s.practiceMux.Unlock()
+
+	s.logger.Info("Created practice set", zap.Int("practice_set_id", set.ID), zap.Int("positions", set.Total))
+	c.JSON(http.StatusCreated, set)
+}
+
+// listPracticeSets lists all practice sets with their progress.
+func (s *Server) listPracticeSets(c *gin.Context) {
+	s.practiceMux.Lock()
+	sets := make([]*PracticeSet, 0, len(s.practiceSets))
+	for _, set := range s.practiceSets {
+		s.refreshPracticeProgress(set)
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].ID < sets[j].ID })
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"practice_sets": sets,
+		"count":         len(sets),
+	})
+	s.practiceMux.Unlock()
+}
+
+// getPracticeSet returns a practice set with up-to-date completion tracking.
+func (s *Server) getPracticeSet(c *gin.Context) {
+	set, ok := s.lookupPracticeSet(c)
+	if !ok {
+		return
+	}
+	s.practiceMux.Lock()
+	s.refreshPracticeProgress(set)
+	c.JSON(http.StatusOK, set)
+	s.practiceMux.Unlock()
+}
+
+// startPracticePosition spawns a fresh game from a practice position. The player takes
+// the side to move and the AI plays the other side with the set's engine and level.
+func (s *Server) startPracticePosition(c *gin.Context) {
+	set, ok := s.lookupPracticeSet(c)
+	if !ok {
+		return
+	}
+	pos, ok := s.lookupPracticePosition(c, set)
+	if !ok {
+		return
+	}
+
+	game := engine.NewGame()
+	if err := game.ParseFEN(pos.FEN); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "invalid_fen", Message: err.Error()})
+		return
+	}
+	aiColor := engine.Black.String()
+	if game.ActiveColor() == engine.Black {
+		aiColor = engine.White.String()
+	}
+
+	s.gamesMux.Lock()
+	gameID := s.registerGame(game, &GameMetadata{
+		AIColor:   aiColor,
+		AIEngine:  set.Engine,
+		AILevel:   set.Level,
+		Owner:     authenticatedUserID(c),
+		CreatedAt: time.Now(),
+	})
+	response := s.gameToResponseLocked(gameID, game)
+	s.gamesMux.Unlock()
+
+	s.practiceMux.Lock()
+	pos.GameID = gameID
+	pos.Attempts++
+	s.practiceMux.Unlock()
+
+	s.logger.Info("Started practice position",
+		zap.Int("practice_set_id", set.ID),
+		zap.Int("index", pos.Index),
+		zap.Int("game_id", gameID))
+	c.JSON(http.StatusCreated, map[string]interface{}{
+		"practice_set_id": set.ID,
+		"index":           pos.Index,
+		"engine":          set.Engine,
+		"level":           set.Level,
+		"game":            response,
+	})
+}
+
+// completePracticePosition marks a practice position as completed manually.
+func (s *Server) completePracticePosition(c *gin.Context) {
+	set, ok := s.lookupPracticeSet(c)
+	if !ok {
+		return
+	}
+	pos, ok := s.lookupPracticePosition(c, set)
+	if !ok {
+		return
+	}
+
+	s.practiceMux.Lock()
+	markPracticeCompleted(pos)
+	s.refreshPracticeProgress(set)
+	c.JSON(http.StatusOK, set)
+	s.practiceMux.Unlock()
+}
+
+// lookupPracticeSet resolves the :id parameter, writing an error response on failure.
+func (s *Server) lookupPracticeSet(c *gin.Context) (*PracticeSet, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_practice_set_id"})
+		return nil, false
+	}
+	s.practiceMux.RLock()
+	set, exists := s.practiceSets[id]
+	s.practiceMux.RUnlock()
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "practice_set_not_found"})
+		return nil, false
+	}
+	return set, true
+}
+
+// lookupPracticePosition resolves the :index parameter, writing an error response on failure.
+func (s *Server) lookupPracticePosition(c *gin.Context, set *PracticeSet) (*PracticePosition, bool) {
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 || index >= len(set.Positions) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "practice_position_not_found"})
+		return nil, false
+	}
+	return set.Positions[index], true
+}
+
+// refreshPracticeProgress auto-completes positions whose latest game reached the
+// objective and recomputes the completed counter. The caller must hold practiceMux.
+func (s *Server) refreshPracticeProgress(set *PracticeSet) {
+	completed := 0
+	for _, pos := range set.Positions {
+		if !pos.Completed && pos.Objective != "" && pos.GameID != 0 {
+			s.gamesMux.RLock()
+			game, lock := s.games[pos.GameID], s.gameLocks[pos.GameID]
+			s.gamesMux.RUnlock()
+			if game != nil && lock != nil {
+				lock.Lock()
+				status := game.Status()
+				lock.Unlock()
+				if practiceObjectiveMet(pos, status) {
+					markPracticeCompleted(pos)
+				}
+			}
+		}
+		if pos.Completed {
+			completed++
+		}
+	}
+	set.Completed = completed
+}
+
+// practiceObjectiveMet reports whether a finished game satisfies the position objective.
+func practiceObjectiveMet(pos *PracticePosition, status engine.GameStatus) bool {
+	switch pos.Objective {
+	case "win":
+		return (status == engine.WhiteWins && pos.PlayerColor == engine.White.String()) ||
+			(status == engine.BlackWins && pos.PlayerColor == engine.Black.String())
+	case "draw":
+		return status == engine.Draw
+	default:
+		return false
+	}
+}
+
+func markPracticeCompleted(pos *PracticePosition) {
+	if pos.Completed {
+		return
+	}
+	now := time.Now()
+	pos.Completed = true
+	pos.CompletedAt = &now
+}
@@ -0,0 +1,71 @@
+package api
+
+// PresenceMessage is pushed to a game's WebSocket clients whenever a client
+// connects or leaves, e.g. to show "3 watching".
+type PresenceMessage struct {
+	Type       string `json:"type"` // always "presence"
+	GameID     int    `json:"game_id"`
+	Players    int    `json:"players"`    // connected clients that may play
+	Spectators int    `json:"spectators"` // read-only clients, event streams included
+}
+
+// ReplayMessage follows the game state sent to a client that connects, with
+// the game's latest messages, oldest first, if the server keeps them.
+type ReplayMessage struct {
+	Type     string        `json:"type"` // always "replay"
+	GameID   int           `json:"game_id"`
+	Messages []interface{} `json:"messages"`
+}
+
+// replayableMessage reports whether a game message is kept for late clients:
+// not game states, which they get anyway, or presence, which changes as they
+// connect.
+func replayableMessage(msg interface{}) bool {
+	switch msg.(type) {
+	case GameResponse, PresenceMessage:
+		return false
+	}
+	return true
+}
+
+// broadcastPresence tells a game's WebSocket clients who is connected.
+func (s *Server) broadcastPresence(gameID int) {
+	players, spectators := s.hub.presence(gameID)
+	s.hub.broadcast(gameID, PresenceMessage{Type: "presence", GameID: gameID, Players: players, Spectators: spectators})
+}
+
+// wsSpectator reports whether a WebSocket client with a player token only
+// watches a game: in two-player games unless the token seats it, in
+// exhibition games always, and in games against the AI if it asked to.
+func (s *Server) wsSpectator(gameID int, token string, asked bool) bool {
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	metadata := s.gameMetadata[gameID]
+	switch {
+	case metadata != nil && metadata.Exhibition != nil:
+		return true
+	case metadata.isTwoPlayer():
+		return asked || s.playerColorLocked(gameID, token) == ""
+	}
+	return asked
+}
+
+// subscribe registers a WebSocket or event stream client of a game, queueing
+// the game's state and latest messages for it and telling the game's clients.
+// The returned function unregisters it.
+func (s *Server) subscribe(gameID int, spectator bool, state GameResponse) (*wsClient, func()) {
+	add := s.hub.add
+	if spectator {
+		add = s.hub.addSpectator
+	}
+	client := add(gameID)
+	client.send <- state
+	if recent := s.hub.recentMessages(gameID); len(recent) > 0 {
+		client.send <- ReplayMessage{Type: "replay", GameID: gameID, Messages: recent}
+	}
+	s.broadcastPresence(gameID)
+	return client, func() {
+		s.hub.remove(gameID, client)
+		s.broadcastPresence(gameID)
+	}
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/puzzle"
+)
+
+// Puzzle difficulties, as offsets from the solver's rating.
+var puzzleDifficulties = map[string]int{
+	"easiest": -600,
+	"easy":    -300,
+	"normal":  0,
+	"hard":    300,
+	"hardest": 600,
+}
+
+// maxUserIDLength bounds the user IDs puzzle ratings are tracked under.
+const maxUserIDLength = 64
+
+// maxPuzzleSolvers bounds the users puzzle ratings are kept for; beyond it the
+// least recently active solver is forgotten.
+const maxPuzzleSolvers = 10000
+
+// PuzzleResponse describes a puzzle without its solution.
+type PuzzleResponse struct {
+	ID          string   `json:"id"`
+	FEN         string   `json:"fen"`
+	Rating      int      `json:"rating"`
+	Themes      []string `json:"themes"`
+	PlayerColor string   `json:"player_color"`
+	Moves       int      `json:"moves"` // number of moves the solver has to find
+}
+
+// PuzzleAttemptRequest submits the solver's moves so far, in UCI or SAN, without
+// the opponent's replies.
+type PuzzleAttemptRequest struct {
+	UserID string   `json:"user_id,omitempty"` // rate the attempt for this user
+	Moves  []string `json:"moves"`
+}
+
+// PuzzleAttemptResponse reports how far an attempt got.
+type PuzzleAttemptResponse struct {
+	PuzzleID string `json:"puzzle_id"`
+	Result   string `json:"result"` // correct (keep going), solved or failed
+	Correct  int    `json:"correct"`
+	// Reply is the opponent's answer to play before the next move.
+	Reply string `json:"reply,omitempty"`
+	// Expected is the solution move a failed attempt missed.
+	Expected string `json:"expected,omitempty"`
+	// Solution is revealed once the attempt is over.
+	Solution []string `json:"solution,omitempty"`
+	// Rated is set when this attempt changed the user's rating; only the first
+	// finished attempt at a puzzle counts.
+	Rated        bool                  `json:"rated"`
+	RatingChange int                   `json:"rating_change,omitempty"`
+	Rating       *PuzzleRatingResponse `json:"rating,omitempty"`
+}
+
+// PuzzleRatingResponse is a user's Glicko puzzle rating.
+type PuzzleRatingResponse struct {
+	UserID    string `json:"user_id"`
+	Rating    int    `json:"rating"`
+	Deviation int    `json:"deviation"`
+	Attempts  int    `json:"attempts"`
+	Solved    int    `json:"solved"`
+}
+
+// puzzleSolver holds a user's puzzle rating and the puzzles they were rated on.
+type puzzleSolver struct {
+	rating     puzzle.Rating
+	rated      map[string]bool
+	lastActive time.Time
+}
+
+// nextPuzzle picks a puzzle near the user's rating, adjusted by difficulty and
+// optionally restricted to a theme. Puzzles the user was already rated on are
+// skipped while others remain.
+func (s *Server) nextPuzzle(c *gin.Context) {
+	userID, ok := puzzleUserID(c, c.Query("user_id"))
+	if !ok {
+		return
+	}
+	difficulty := c.DefaultQuery("difficulty", "normal")
+	offset, ok := puzzleDifficulties[difficulty]
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_difficulty",
+			Message: "difficulty must be easiest, easy, normal, hard or hardest",
+		})
+		return
+	}
+	theme := c.Query("theme")
+
+	s.puzzleMux.Lock()
+	solver := s.puzzleSolver(userID)
+	target := int(math.Round(solver.rating.Rating)) + offset
+	p, found := s.puzzles.Closest(target, theme, func(p *puzzle.Puzzle) bool { return solver.rated[p.ID] })
+	s.puzzleMux.Unlock()
+	if !found {
+		p, found = s.puzzles.Closest(target, theme, nil)
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "puzzle_not_found", Message: "no puzzles with theme " + theme})
+		return
+	}
+	c.JSON(http.StatusOK, puzzleToResponse(p))
+}
+
+// listPuzzleThemes lists the themes puzzles can be fetched by.
+func (s *Server) listPuzzleThemes(c *gin.Context) {
+	themes := s.puzzles.Themes()
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"themes": themes,
+		"count":  len(themes),
+	})
+}
+
+// getPuzzle returns a puzzle by id.
+func (s *Server) getPuzzle(c *gin.Context) {
+	p, ok := s.lookupPuzzle(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, puzzleToResponse(p))
+}
+
+// attemptPuzzle checks the solver's moves against the solution line. Clients
+// submit the moves made so far after each move and play the returned reply until
+// the puzzle is solved or failed; the user's rating is updated when the first
+// attempt at the puzzle finishes.
+func (s *Server) attemptPuzzle(c *gin.Context) {
+	p, ok := s.lookupPuzzle(c)
+	if !ok {
+		return
+	}
+	var req PuzzleAttemptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	userID, ok := puzzleUserID(c, req.UserID)
+	if !ok {
+		return
+	}
+	if len(req.Moves) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "moves must not be empty"})
+		return
+	}
+
+	progress, err := p.Check(req.Moves)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_move", Message: err.Error()})
+		return
+	}
+	response := PuzzleAttemptResponse{
+		PuzzleID: p.ID,
+		Result:   "correct",
+		Correct:  progress.Correct,
+		Reply:    progress.Reply,
+		Expected: progress.Expected,
+	}
+	switch {
+	case progress.Solved:
+		response.Result = "solved"
+	case progress.Failed:
+		response.Result = "failed"
+	}
+	if progress.Done() {
+		response.Solution = p.Solution
+	}
+
+	if userID != "" {
+		s.puzzleMux.Lock()
+		solver := s.puzzleSolver(userID)
+		if progress.Done() && !solver.rated[p.ID] {
+			before := solver.rating.Rating
+			solver.rating = solver.rating.Update(p.Rating, progress.Solved, time.Now())
+			solver.rated[p.ID] = true
+			response.Rated = true
+			response.RatingChange = int(math.Round(solver.rating.Rating)) - int(math.Round(before))
+		}
+		response.Rating = puzzleRatingToResponse(userID, solver.rating)
+		s.puzzleMux.Unlock()
+
+		if response.Rated {
+			s.logger.Info("Rated puzzle attempt",
+				zap.String("puzzle_id", p.ID),
+				zap.String("result", response.Result),
+				zap.Int("rating", response.Rating.Rating))
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getPuzzleRating returns a user's puzzle rating; users without attempts have the
+// initial rating.
+func (s *Server) getPuzzleRating(c *gin.Context) {
+	userID, ok := validPuzzleUserID(c, c.Param("user"))
+	if !ok {
+		return
+	}
+	rating := puzzle.NewRating()
+	s.puzzleMux.Lock()
+	if solver, ok := s.puzzleSolvers[userID]; ok {
+		rating = solver.rating
+	}
+	s.puzzleMux.Unlock()
+	c.JSON(http.StatusOK, puzzleRatingToResponse(userID, rating))
+}
+
+// puzzleSolver returns the rating state of a user, creating it on first use; the
+// empty user ID gets a throwaway initial rating. The caller must hold puzzleMux.
+func (s *Server) puzzleSolver(userID string) *puzzleSolver {
+	if userID == "" {
+		return &puzzleSolver{rating: puzzle.NewRating()}
+	}
+	solver, ok := s.puzzleSolvers[userID]
+	if !ok {
+		if len(s.puzzleSolvers) >= maxPuzzleSolvers {
+			s.evictPuzzleSolver()
+		}
+		solver = &puzzleSolver{rating: puzzle.NewRating(), rated: make(map[string]bool)}
+		s.puzzleSolvers[userID] = solver
+	}
+	solver.lastActive = time.Now()
+	return solver
+}
+
+// evictPuzzleSolver forgets the least recently active solver. The caller must
+// hold puzzleMux.
+func (s *Server) evictPuzzleSolver() {
+	oldest := ""
+	var oldestActive time.Time
+	for userID, solver := range s.puzzleSolvers {
+		if oldest == "" || solver.lastActive.Before(oldestActive) {
+			oldest, oldestActive = userID, solver.lastActive
+		}
+	}
+	delete(s.puzzleSolvers, oldest)
+}
+
+// lookupPuzzle resolves the :id parameter, writing an error response on failure.
+func (s *Server) lookupPuzzle(c *gin.Context) (*puzzle.Puzzle, bool) {
+	p, ok := s.puzzles.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "puzzle_not_found"})
+		return nil, false
+	}
+	return p, true
+}
+
+// puzzleUserID returns the user an attempt is rated for: the authenticated
+// user when auth is on, so nobody can play on another's rating, else the
+// validated userID, writing an error response on failure.
+func puzzleUserID(c *gin.Context, userID string) (string, bool) {
+	if authenticated := authenticatedUserID(c); authenticated != "" {
+		return authenticated, true
+	}
+	return validPuzzleUserID(c, userID)
+}
+
+// validPuzzleUserID validates a user ID, writing an error response on failure.
+func validPuzzleUserID(c *gin.Context, userID string) (string, bool) {
+	if len(userID) > maxUserIDLength {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_user_id",
+			Message: fmt.Sprintf("user_id must be at most %d characters", maxUserIDLength),
+		})
+		return "", false
+	}
+	return userID, true
+}
+
+func puzzleToResponse(p *puzzle.Puzzle) PuzzleResponse {
+	return PuzzleResponse{
+		ID:          p.ID,
+		FEN:         p.FEN,
+		Rating:      p.Rating,
+		Themes:      p.Themes,
+		PlayerColor: p.Color.String(),
+		Moves:       (len(p.Solution) + 1) / 2,
+	}
+}
+
+func puzzleRatingToResponse(userID string, r puzzle.Rating) *PuzzleRatingResponse {
+	return &PuzzleRatingResponse{
+		UserID:    userID,
+		Rating:    int(math.Round(r.Rating)),
+		Deviation: int(math.Round(r.Deviation)),
+		Attempts:  r.Attempts,
+		Solved:    r.Solved,
+	}
+}
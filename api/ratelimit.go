@@ -0,0 +1,34 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/chat"
+)
+
+// chatLimitError returns the chat_rate_limited error of a request a chat limit
+// refused, with the seconds until a retry may succeed, or nil.
+func chatLimitError(err error) (*ErrorResponse, int) {
+	var limited *chat.RateLimitError
+	if !errors.As(err, &limited) {
+		return nil, 0
+	}
+	return &ErrorResponse{Error: "chat_rate_limited", Message: limited.Error()}, int(math.Ceil(limited.RetryAfter.Seconds()))
+}
+
+// writeChatLimit writes 429 chat_rate_limited with a Retry-After header if a
+// chat limit refused the request, and reports whether it did.
+func writeChatLimit(c *gin.Context, err error) bool {
+	errResp, retryAfter := chatLimitError(err)
+	if errResp == nil {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, errResp)
+	return true
+}
@@ -0,0 +1,367 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/store"
+)
+
+// replicaLockTimeout bounds the wait for another server to release a game.
+// Past it requests for the game fail with 503 game_busy. A variable for tests.
+var replicaLockTimeout = 10 * time.Second
+
+// unknownGameSyncInterval is how often a UUID of no game known to this server
+// may have it load every shared game, so that requests for made-up UUIDs do
+// not each scan the store. A game another server just created may be missing
+// for that long.
+const unknownGameSyncInterval = time.Second
+
+// errGameBusy is returned by lockGame when another server held a game past
+// replicaLockTimeout.
+var errGameBusy = errors.New("the game is in use by another server; try again")
+
+// gameVersion is the saved state of a game this server last saw.
+type gameVersion struct {
+	updatedAt time.Time
+	digest    [sha256.Size]byte // of the PGN and metadata
+}
+
+// recordDigest returns the digest of what a game record saves.
+func recordDigest(record *store.GameRecord) [sha256.Size]byte {
+	return sha256.Sum256(append([]byte(record.PGN+"\x00"), record.Metadata...))
+}
+
+// newGameLock returns the lock of a new game: an in-process mutex, or a
+// replicaLock when servers share their games.
+func (s *Server) newGameLock(gameID int) sync.Locker {
+	if s.coordinator == nil {
+		return &sync.Mutex{}
+	}
+	return &replicaLock{s: s, gameID: gameID}
+}
+
+// replicaLock serializes a game across the servers sharing a store. Locking it
+// takes the game's lock in the store and brings the game up to date with what
+// other servers saved; unlocking saves the game if it changed, then releases
+// the lock.
+type replicaLock struct {
+	s      *Server
+	gameID int
+	local  sync.Mutex
+	unlock func() // releases the store's lock; nil if it is not held
+}
+
+// Lock waits as long as it takes to lock the game; lockGame gives up after
+// replicaLockTimeout.
+func (l *replicaLock) Lock() {
+	for {
+		err := l.lock()
+		if err == nil {
+			return
+		}
+		l.s.logger.Warn("Still waiting for a shared game", zap.Int("game_id", l.gameID), zap.Error(err))
+	}
+}
+
+// lock locks the game, or fails with errGameBusy if it is not released
+// within replicaLockTimeout.
+func (l *replicaLock) lock() error {
+	l.local.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), replicaLockTimeout)
+	defer cancel()
+	unlock, err := l.s.coordinator.LockGame(ctx, l.gameID)
+	if err != nil {
+		l.local.Unlock()
+		return fmt.Errorf("%w: %v", errGameBusy, err)
+	}
+	l.unlock = unlock
+	l.s.refreshGameLocked(l.gameID)
+	return nil
+}
+
+func (l *replicaLock) Unlock() {
+	l.s.storeGameLocked(l.gameID)
+	if l.unlock != nil {
+		l.unlock()
+		l.unlock = nil
+	}
+	l.local.Unlock()
+}
+
+// lockGame locks a game, failing with errGameBusy if another server holds it
+// past replicaLockTimeout rather than using it without the lock.
+func lockGame(lock sync.Locker) error {
+	if l, ok := lock.(*replicaLock); ok {
+		return l.lock()
+	}
+	lock.Lock()
+	return nil
+}
+
+// requireGameLock is lockGame for requests, writing 503 game_busy if the game
+// could not be locked.
+func (s *Server) requireGameLock(c *gin.Context, lock sync.Locker) bool {
+	if err := lockGame(lock); err != nil {
+		s.logger.Warn("Failed to lock shared game", zap.String("path", c.Request.URL.Path), zap.Error(err))
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "game_busy", Message: errGameBusy.Error()})
+		return false
+	}
+	return true
+}
+
+// refreshGameLocked replaces a game with the state another server saved since
+// this one last saw it, or removes it if another server deleted it. The caller
+// must hold the game's lock.
+func (s *Server) refreshGameLocked(gameID int) {
+	record, err := s.saver.games.LoadGame(context.Background(), gameID)
+	if err != nil {
+		s.logger.Warn("Failed to load shared game", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+	version, known := s.gameVersion(gameID)
+	switch {
+	case record == nil && known:
+		s.gamesMux.Lock()
+		s.removeGameLocked(gameID)
+		s.gamesMux.Unlock()
+		s.logger.Info("Shared game deleted by another server", zap.Int("game_id", gameID))
+		return
+	case record == nil || (known && record.UpdatedAt.Equal(version.updatedAt)):
+		return
+	}
+
+	restored, metadata, err := restoreGame(*record)
+	if err != nil {
+		s.logger.Warn("Failed to restore shared game", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+	s.gamesMux.Lock()
+	game, exists := s.games[gameID]
+	if exists {
+		game.Replace(restored)
+		if current := s.gameMetadata[gameID]; current != nil {
+			metadata.outcome, metadata.summary = current.outcome, current.summary
+			if current.adaptive != nil {
+				metadata.adaptive = current.adaptive
+			}
+			*current = *metadata
+		} else {
+			s.gameMetadata[gameID] = metadata
+		}
+	}
+	s.gamesMux.Unlock()
+	if !exists {
+		return
+	}
+	s.setGameVersion(gameID, record)
+	s.cache.invalidate(gameID)
+	s.hub.broadcast(gameID, s.gameToResponse(gameID, game))
+}
+
+// storeGameLocked saves a game if it changed since it was last saved or
+// loaded. The caller must hold the game's lock.
+func (s *Server) storeGameLocked(gameID int) {
+	record, exists, err := s.gameRecordLocked(gameID)
+	if err != nil {
+		s.logger.Warn("Failed to encode game", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+	if !exists {
+		return
+	}
+	if version, known := s.gameVersion(gameID); known && version.digest == recordDigest(record) {
+		return
+	}
+	if err := s.saver.games.SaveGame(context.Background(), record); err != nil {
+		s.logger.Warn("Failed to save game", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+	s.setGameVersion(gameID, record)
+}
+
+// deleteSharedGameLocked deletes a game shared with other servers from the
+// store. The caller must hold the game's lock, so that no server saves it
+// again in between.
+func (s *Server) deleteSharedGameLocked(gameID int) {
+	if s.coordinator == nil {
+		return
+	}
+	if err := s.saver.games.DeleteGame(context.Background(), gameID); err != nil {
+		s.logger.Warn("Failed to delete game", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+	s.forgetGameVersion(gameID)
+}
+
+// gameVersion returns the saved state of a game this server last saw.
+func (s *Server) gameVersion(gameID int) (gameVersion, bool) {
+	s.saver.mu.Lock()
+	defer s.saver.mu.Unlock()
+	version, ok := s.saver.versions[gameID]
+	return version, ok
+}
+
+// setGameVersion records a game's state as saved or loaded.
+func (s *Server) setGameVersion(gameID int, record *store.GameRecord) {
+	s.saver.mu.Lock()
+	defer s.saver.mu.Unlock()
+	s.saver.versions[gameID] = gameVersion{updatedAt: record.UpdatedAt, digest: recordDigest(record)}
+}
+
+// forgetGameVersion drops what this server saw of a deleted game.
+func (s *Server) forgetGameVersion(gameID int) {
+	if s.saver == nil {
+		return
+	}
+	s.saver.mu.Lock()
+	defer s.saver.mu.Unlock()
+	delete(s.saver.versions, gameID)
+}
+
+// nextGameID hands out the ID of a new game: the store's next one when servers
+// share their games, so that no two servers give out the same ID, or else this
+// server's. The caller must hold gamesMux for writing.
+func (s *Server) nextGameID() int {
+	if s.coordinator != nil {
+		gameID, err := s.coordinator.NextGameID(context.Background())
+		if err == nil {
+			s.nextID = max(s.nextID, gameID+1)
+			return gameID
+		}
+		s.logger.Warn("Failed to get a shared game ID, using a local one", zap.Error(err))
+	}
+	gameID := s.nextID
+	s.nextID++
+	return gameID
+}
+
+// adoptGameLocked registers a game another server saved. The caller must hold
+// gamesMux for writing.
+func (s *Server) adoptGameLocked(record *store.GameRecord) {
+	if _, exists := s.games[record.ID]; exists {
+		return
+	}
+	game, metadata, err := restoreGame(*record)
+	if err != nil {
+		s.logger.Warn("Failed to restore shared game", zap.Int("game_id", record.ID), zap.Error(err))
+		return
+	}
+	s.games[record.ID] = game
+	s.gameMetadata[record.ID] = metadata
+	s.indexGameLocked(record.ID, metadata)
+	s.gameLocks[record.ID] = s.newGameLock(record.ID)
+	s.observeGame(record.ID, game)
+	s.nextID = max(s.nextID, record.ID+1)
+	s.setGameVersion(record.ID, record)
+}
+
+// syncSharedGames brings the games of /api/games/:id and /ws/games/:id
+// requests up to date with the store before they are served, loading games
+// other servers created, and those of GET /api/games all at once.
+func (s *Server) syncSharedGames() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.coordinator == nil {
+			return
+		}
+		path := routePath(c)
+		if (path == "/api/games" || path == "/api/games/export") && c.Request.Method == http.MethodGet {
+			s.syncAllGames()
+			return
+		}
+		if !strings.HasPrefix(path, "/api/games/:id") && !strings.HasPrefix(path, "/ws/games/:id") {
+			return
+		}
+		switch gameID, ok := s.resolveGameID(c.Param("id")); {
+		case ok && gameID == 0: // a UUID of a game this server has not seen
+			if s.claimUnknownGameSync() {
+				s.syncAllGames()
+			}
+		case ok:
+			s.syncGame(gameID)
+		}
+	}
+}
+
+// claimUnknownGameSync reports whether a request for an unknown game may sync
+// every game, at most once per unknownGameSyncInterval.
+func (s *Server) claimUnknownGameSync() bool {
+	s.saver.mu.Lock()
+	defer s.saver.mu.Unlock()
+	if time.Since(s.saver.unknownSynced) < unknownGameSyncInterval {
+		return false
+	}
+	s.saver.unknownSynced = time.Now()
+	return true
+}
+
+// syncGame refreshes a game from the store, or loads it if this server does
+// not have it yet.
+func (s *Server) syncGame(gameID int) {
+	s.gamesMux.RLock()
+	lock := s.gameLocks[gameID]
+	s.gamesMux.RUnlock()
+	if lock != nil {
+		s.refreshGame(gameID, lock)
+		return
+	}
+	record, err := s.saver.games.LoadGame(context.Background(), gameID)
+	if err != nil {
+		s.logger.Warn("Failed to load shared game", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+	if record != nil {
+		s.gamesMux.Lock()
+		s.adoptGameLocked(record)
+		s.gamesMux.Unlock()
+	}
+}
+
+// syncAllGames loads the games other servers created, refreshes those they
+// changed and removes those they deleted.
+func (s *Server) syncAllGames() {
+	records, err := s.saver.games.LoadGames(context.Background())
+	if err != nil {
+		s.logger.Warn("Failed to load shared games", zap.Error(err))
+		return
+	}
+	saved := make(map[int]bool, len(records))
+	stale := make(map[int]sync.Locker)
+	s.gamesMux.Lock()
+	for i := range records {
+		record := &records[i]
+		saved[record.ID] = true
+		if lock, exists := s.gameLocks[record.ID]; !exists {
+			s.adoptGameLocked(record)
+		} else if version, known := s.gameVersion(record.ID); !known || !version.updatedAt.Equal(record.UpdatedAt) {
+			stale[record.ID] = lock
+		}
+	}
+	for gameID, lock := range s.gameLocks {
+		if _, known := s.gameVersion(gameID); known && !saved[gameID] {
+			stale[gameID] = lock // deleted, unless saved since
+		}
+	}
+	s.gamesMux.Unlock()
+	for gameID, lock := range stale {
+		s.refreshGame(gameID, lock)
+	}
+}
+
+// refreshGame brings a game up to date with the store by locking it.
+func (s *Server) refreshGame(gameID int, lock sync.Locker) {
+	if err := lockGame(lock); err != nil {
+		s.logger.Warn("Failed to refresh shared game", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
+	lock.Unlock()
+}
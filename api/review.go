@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// reviewTimeout bounds the whole-game analysis of a review.
+const reviewTimeout = 60 * time.Second
+
+// ReviewResponse is the engine review of a game's moves.
+type ReviewResponse struct {
+	GameID int                  `json:"game_id"`
+	White  PlayerReviewResponse `json:"white"`
+	Black  PlayerReviewResponse `json:"black"`
+	Moves  []ReviewMoveResponse `json:"moves"`
+	// Evals is the evaluation of every position, from the start, for an
+	// evaluation graph.
+	Evals []EvalPointResponse `json:"evals"`
+	// Improvements are the inaccuracies, mistakes and blunders with the
+	// engine's better line, costliest first.
+	Improvements []ImprovementResponse `json:"improvements"`
+	Cached       bool                  `json:"cached,omitempty"` // reviewed before with the same moves
+}
+
+// EvalPointResponse is the evaluation of the position after ply plies.
+type EvalPointResponse struct {
+	Ply  int `json:"ply"`
+	Eval int `json:"eval"`           // centipawns from White's perspective, capped at ±1000
+	Mate int `json:"mate,omitempty"` // moves to mate; positive if White mates
+}
+
+// ImprovementResponse suggests a better move than one played.
+type ImprovementResponse struct {
+	Ply           int      `json:"ply"`
+	MoveNumber    int      `json:"move_number"`
+	Color         string   `json:"color"`
+	SAN           string   `json:"san"`
+	Class         string   `json:"class"`
+	CentipawnLoss int      `json:"centipawn_loss"`
+	BestMove      string   `json:"best_move"`
+	BestLine      []string `json:"best_line"` // SAN, starting with BestMove
+}
+
+// cachedReview is the review of a game with the moves of key.
+type cachedReview struct {
+	key    string
+	review ReviewResponse
+}
+
+// reviewKey identifies a game's moves from its starting position, so that a
+// review is redone after moves are played or taken back.
+func reviewKey(game *engine.Game) string {
+	var b strings.Builder
+	b.WriteString(game.StartingFEN())
+	for _, move := range game.MoveHistory() {
+		b.WriteString(" " + move.UCI())
+	}
+	return b.String()
+}
+
+// reviewCache keeps the latest review of each game.
+type reviewCache struct {
+	mu      sync.Mutex
+	reviews map[int]cachedReview
+}
+
+func (rc *reviewCache) get(gameID int, key string) (ReviewResponse, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	cached, ok := rc.reviews[gameID]
+	return cached.review, ok && cached.key == key
+}
+
+func (rc *reviewCache) put(gameID int, key string, review ReviewResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.reviews[gameID] = cachedReview{key: key, review: review}
+}
+
+func (rc *reviewCache) forget(gameID int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	delete(rc.reviews, gameID)
+}
+
+// PlayerReviewResponse summarizes one side's play.
+type PlayerReviewResponse struct {
+	Accuracy             float64 `json:"accuracy"` // Lichess-style, 0-100
+	AverageCentipawnLoss float64 `json:"average_centipawn_loss"`
+	Inaccuracies         int     `json:"inaccuracies"`
+	Mistakes             int     `json:"mistakes"`
+	Blunders             int     `json:"blunders"`
+}
+
+// ReviewMoveResponse grades a single move.
+type ReviewMoveResponse struct {
+	Ply           int     `json:"ply"`
+	MoveNumber    int     `json:"move_number"`
+	Color         string  `json:"color"`
+	SAN           string  `json:"san"`
+	Class         string  `json:"class"` // best, good, inaccuracy, mistake, blunder
+	CentipawnLoss int     `json:"centipawn_loss"`
+	Accuracy      float64 `json:"accuracy"`
+	EvalAfter     int     `json:"eval_after"`          // centipawns from White's perspective
+	BestMove      string  `json:"best_move,omitempty"` // SAN of the engine's choice when it differs
+}
+
+// getGameReview analyzes every move of a game and reports accuracy, centipawn loss
+// and mistakes per player, the evaluation graph and better moves. The review
+// is kept until the game's moves change.
+func (s *Server) getGameReview(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	snapshot := game.Clone()
+	lock.Unlock()
+	key := reviewKey(snapshot)
+	if review, ok := s.reviews.get(gameID, key); ok {
+		review.Cached = true
+		c.JSON(http.StatusOK, review)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), reviewTimeout)
+	defer cancel()
+	analyzer := s.newMinimaxAI(ai.DifficultyMedium)
+	analyzer.SetOpeningBook(nil)
+	report, err := ai.AnalyzeGame(ctx, snapshot, ai.AnalysisOptions{Engine: analyzer})
+	if err != nil {
+		s.logger.Warn("Game review failed", zap.Int("game_id", gameID), zap.Error(err))
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "review_failed", Message: err.Error()})
+		return
+	}
+
+	resp := ReviewResponse{
+		GameID: gameID,
+		White:  playerReviewResponse(report.White),
+		Black:  playerReviewResponse(report.Black),
+		Moves:  make([]ReviewMoveResponse, 0, len(report.Moves)),
+	}
+	for _, m := range report.Moves {
+		resp.Moves = append(resp.Moves, reviewMoveResponse(m))
+		if m.Class != ai.ClassBest && m.Class != ai.ClassGood && len(m.BestLine) > 0 {
+			resp.Improvements = append(resp.Improvements, ImprovementResponse{
+				Ply:           m.Ply,
+				MoveNumber:    m.MoveNumber,
+				Color:         m.Color.String(),
+				SAN:           m.SAN,
+				Class:         string(m.Class),
+				CentipawnLoss: m.CentipawnLoss,
+				BestMove:      m.BestLine[0],
+				BestLine:      m.BestLine,
+			})
+		}
+	}
+	slices.SortStableFunc(resp.Improvements, func(a, b ImprovementResponse) int { return b.CentipawnLoss - a.CentipawnLoss })
+	resp.Evals = evalPoints(report)
+	s.reviews.put(gameID, key, resp)
+	c.JSON(http.StatusOK, resp)
+}
+
+// evalPoints returns the evaluation graph of a game analysis. Mates are found
+// for the side to move, before each move and after the last.
+func evalPoints(report *ai.GameAnalysis) []EvalPointResponse {
+	whiteMate := func(mate int, toMove engine.Color) int {
+		if toMove == engine.Black {
+			return -mate
+		}
+		return mate
+	}
+	points := make([]EvalPointResponse, len(report.Evals))
+	for ply, eval := range report.Evals {
+		points[ply] = EvalPointResponse{Ply: ply, Eval: eval}
+		switch {
+		case ply < len(report.Moves):
+			m := report.Moves[ply]
+			points[ply].Mate = whiteMate(m.MateBefore, m.Color)
+		case ply > 0:
+			m := report.Moves[ply-1]
+			points[ply].Mate = whiteMate(m.MateAfter, m.Color.Opposite())
+		}
+	}
+	return points
+}
+
+func reviewMoveResponse(m ai.MoveAnalysis) ReviewMoveResponse {
+	move := ReviewMoveResponse{
+		Ply:           m.Ply,
+		MoveNumber:    m.MoveNumber,
+		Color:         m.Color.String(),
+		SAN:           m.SAN,
+		Class:         string(m.Class),
+		CentipawnLoss: m.CentipawnLoss,
+		Accuracy:      roundTenth(m.Accuracy),
+		EvalAfter:     m.EvalAfter,
+	}
+	if m.Class != ai.ClassBest && len(m.BestLine) > 0 {
+		move.BestMove = m.BestLine[0]
+	}
+	return move
+}
+
+func playerReviewResponse(p ai.PlayerSummary) PlayerReviewResponse {
+	return PlayerReviewResponse{
+		Accuracy:             roundTenth(p.Accuracy),
+		AverageCentipawnLoss: roundTenth(p.AverageCentipawnLoss),
+		Inaccuracies:         p.Inaccuracies,
+		Mistakes:             p.Mistakes,
+		Blunders:             p.Blunders,
+	}
+}
+
+// roundTenth rounds to one decimal place for display.
+func roundTenth(x float64) float64 {
+	return math.Round(x*10) / 10
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/chat"
+	"go.rumenx.com/chess/config"
+)
+
+// CreateRoomRequest creates a chat room.
+type CreateRoomRequest struct {
+	ID   string `json:"id" binding:"required"` // 1 to 32 lowercase letters, digits, '-' or '_'
+	Name string `json:"name"`                  // defaults to the ID
+}
+
+// RoomMessageRequest posts a message to a chat room.
+type RoomMessageRequest struct {
+	Message string `json:"message"`
+}
+
+// RoomMessageResponse is a message posted to a chat room.
+type RoomMessageResponse struct {
+	chat.RoomMessage
+	Filtered []string `json:"filtered,omitempty"` // why the input filter matched the message
+}
+
+// RoomMessageFrame is pushed to a room's WebSocket clients for every message
+// posted to it, over REST or a socket.
+type RoomMessageFrame struct {
+	Type string `json:"type"` // always "room_message"
+	chat.RoomMessage
+}
+
+// RoomMemberFrame is pushed to a room's WebSocket clients when a user joins or
+// leaves it.
+type RoomMemberFrame struct {
+	Type   string `json:"type"`  // always "room_member"
+	Event  string `json:"event"` // "joined" or "left"
+	RoomID string `json:"room_id"`
+	UserID string `json:"user_id"`
+}
+
+// RoomStateFrame is the first message of a room's WebSocket: the room and its
+// latest messages.
+type RoomStateFrame struct {
+	Type     string             `json:"type"` // always "room_state"
+	Room     chat.Room          `json:"room"`
+	Messages []chat.RoomMessage `json:"messages"`
+}
+
+// roomHistoryLimit is how many messages the history of a room returns by
+// default.
+const roomHistoryLimit = 50
+
+// roomError returns the status and error response of a chat room error.
+func roomError(err error) (int, ErrorResponse) {
+	switch {
+	case errors.Is(err, chat.ErrRoomNotFound):
+		return http.StatusNotFound, ErrorResponse{Error: "room_not_found", Message: err.Error()}
+	case errors.Is(err, chat.ErrRoomExists):
+		return http.StatusConflict, ErrorResponse{Error: "room_exists", Message: err.Error()}
+	case errors.Is(err, chat.ErrInvalidRoomID):
+		return http.StatusBadRequest, ErrorResponse{Error: "invalid_room_id", Message: err.Error()}
+	case errors.Is(err, chat.ErrNotMember):
+		return http.StatusForbidden, ErrorResponse{Error: "not_a_member", Message: err.Error()}
+	case errors.Is(err, chat.ErrEmptyMessage), errors.Is(err, chat.ErrMessageTooLong):
+		return http.StatusBadRequest, ErrorResponse{Error: "invalid_message", Message: err.Error()}
+	case errors.Is(err, chat.ErrNameTooLong):
+		return http.StatusBadRequest, ErrorResponse{Error: "invalid_room_name", Message: err.Error()}
+	case errors.Is(err, chat.ErrTooManyRooms):
+		return http.StatusTooManyRequests, ErrorResponse{Error: "too_many_rooms", Message: err.Error()}
+	}
+	if errResp := chatRejectedError(err); errResp != nil {
+		return http.StatusBadRequest, *errResp
+	}
+	if errResp, _ := chatLimitError(err); errResp != nil {
+		return http.StatusTooManyRequests, *errResp
+	}
+	return http.StatusInternalServerError, ErrorResponse{Error: "room_failed", Message: err.Error()}
+}
+
+// writeRoomError writes the error response of a chat room error, with a
+// Retry-After header when a chat limit refused a message.
+func writeRoomError(c *gin.Context, err error) {
+	if writeChatLimit(c, err) {
+		return
+	}
+	status, errResp := roomError(err)
+	c.JSON(status, errResp)
+}
+
+// listRooms lists the chat rooms, the lobby first.
+func (s *Server) listRooms(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"rooms": s.rooms.List()})
+}
+
+// createRoom creates a chat room with the requesting user as its first member.
+func (s *Server) createRoom(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		return
+	}
+	var req CreateRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	room, err := s.rooms.Create(req.ID, req.Name, userID)
+	if err != nil {
+		writeRoomError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, room)
+}
+
+// getRoom returns a chat room with its members.
+func (s *Server) getRoom(c *gin.Context) {
+	room, err := s.rooms.Get(c.Param("room"))
+	if err != nil {
+		writeRoomError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+// joinRoom adds the requesting user to a chat room.
+func (s *Server) joinRoom(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		return
+	}
+	room, err := s.rooms.Join(c.Param("room"), userID)
+	if err != nil {
+		writeRoomError(c, err)
+		return
+	}
+	s.roomHub.broadcast(room.ID, RoomMemberFrame{Type: "room_member", Event: "joined", RoomID: room.ID, UserID: userID})
+	c.JSON(http.StatusOK, room)
+}
+
+// leaveRoom removes the requesting user from a chat room.
+func (s *Server) leaveRoom(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		return
+	}
+	room, err := s.rooms.Leave(c.Param("room"), userID)
+	if err != nil {
+		writeRoomError(c, err)
+		return
+	}
+	s.roomHub.broadcast(room.ID, RoomMemberFrame{Type: "room_member", Event: "left", RoomID: room.ID, UserID: userID})
+	c.JSON(http.StatusOK, room)
+}
+
+// getRoomMessages returns the latest messages of a chat room, oldest first:
+// ?limit of them, 50 by default.
+func (s *Server) getRoomMessages(c *gin.Context) {
+	limit := roomHistoryLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_limit", Message: "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+	roomID := c.Param("room")
+	messages, err := s.rooms.History(roomID, limit)
+	if err != nil {
+		writeRoomError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"room_id": roomID, "messages": messages})
+}
+
+// postRoomMessage posts a message of the requesting user, who must have joined
+// the room, and pushes it to the room's WebSocket clients.
+func (s *Server) postRoomMessage(c *gin.Context) {
+	userID, ok := requestUserID(c)
+	if !ok {
+		return
+	}
+	var req RoomMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	msg, filtered, err := s.postToRoom(c.Param("room"), userID, req.Message)
+	if err != nil {
+		writeRoomError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, RoomMessageResponse{RoomMessage: msg, Filtered: filtered})
+}
+
+// postToRoom screens a message with the chat input filter, posts it and
+// broadcasts it to the room's WebSocket clients.
+func (s *Server) postToRoom(roomID, userID, content string) (chat.RoomMessage, []string, error) {
+	var filtered []string
+	if s.chatService != nil {
+		var err error
+		if content, filtered, err = s.chatService.ScreenRoomMessage(roomID, userID, content); err != nil {
+			return chat.RoomMessage{}, nil, err
+		}
+	}
+	msg, err := s.rooms.Post(roomID, userID, content)
+	if err != nil {
+		return chat.RoomMessage{}, nil, err
+	}
+	s.roomHub.broadcast(roomID, RoomMessageFrame{Type: "room_message", RoomMessage: msg})
+	return msg, filtered, nil
+}
+
+// handleRoomWebSocket subscribes a client to a chat room. The user joins the
+// room for as long as the socket is open, unless they had joined it already,
+// and posts with {"type":"chat","message":"..."} frames.
+func (s *Server) handleRoomWebSocket(c *gin.Context) {
+	roomID := c.Param("room")
+	room, err := s.rooms.Get(roomID)
+	if err != nil {
+		writeRoomError(c, err)
+		return
+	}
+	userID, ok := requestUserID(c)
+	if !ok {
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		s.logger.Error("WebSocket upgrade failed", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	client := s.roomHub.add(roomID)
+	defer s.roomHub.remove(roomID, client)
+	go client.writePump(conn, s.logger)
+	keepAlive(conn)
+
+	joined := !slices.Contains(room.Members, userID)
+	if joined {
+		if room, err = s.rooms.Join(roomID, userID); err != nil {
+			return
+		}
+		defer func() {
+			if _, err := s.rooms.Leave(roomID, userID); err == nil {
+				s.roomHub.broadcast(roomID, RoomMemberFrame{Type: "room_member", Event: "left", RoomID: roomID, UserID: userID})
+			}
+		}()
+	}
+	messages, _ := s.rooms.History(roomID, roomHistoryLimit)
+	client.send <- RoomStateFrame{Type: "room_state", Room: room, Messages: messages}
+	if joined {
+		s.roomHub.broadcast(roomID, RoomMemberFrame{Type: "room_member", Event: "joined", RoomID: roomID, UserID: userID})
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				s.logger.Error("WebSocket error", zap.Error(err))
+			}
+			break
+		}
+		s.handleRoomWSMessage(c.Request.Context(), roomID, userID, client, data)
+	}
+}
+
+// handleRoomWSMessage posts the chat frames a room's WebSocket client sends,
+// if it may post; anything else is echoed back.
+func (s *Server) handleRoomWSMessage(ctx context.Context, roomID, userID string, client *wsClient, data []byte) {
+	var frame ChatFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		client.send <- ErrorFrame{Type: "error", ErrorResponse: ErrorResponse{Error: "invalid_message", Message: "messages must be JSON objects"}}
+		return
+	}
+	if frame.Type != "chat" {
+		client.send <- json.RawMessage(data)
+		return
+	}
+	if p, ok := principalFrom(ctx); ok && !p.has(config.ScopePlay) {
+		client.send <- ErrorFrame{Type: "error", ErrorResponse: ErrorResponse{Error: "insufficient_scope", Message: "posting needs the play scope"}}
+		return
+	}
+	if _, _, err := s.postToRoom(roomID, userID, frame.Message); err != nil {
+		_, errResp := roomError(err)
+		client.send <- ErrorFrame{Type: "error", ErrorResponse: errResp}
+	}
+}
@@ -4,45 +4,117 @@
 package api
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 
 	"go.rumenx.com/chess/ai"
 	"go.rumenx.com/chess/chat"
 	"go.rumenx.com/chess/config"
 	"go.rumenx.com/chess/engine"
+	"go.rumenx.com/chess/puzzle"
+	"go.rumenx.com/chess/store"
 )
 
 // GameResponse represents a game in API responses.
 type GameResponse struct {
-	ID          int            `json:"id"`
-	Status      string         `json:"status"`
-	ActiveColor string         `json:"active_color"`
-	AIColor     string         `json:"ai_color,omitempty"` // Which color the AI plays
-	Board       string         `json:"board"`
-	FEN         string         `json:"fen"` // Current position in FEN
-	MoveCount   int            `json:"move_count"`
-	MoveHistory []MoveResponse `json:"move_history"`
-	CreatedAt   time.Time      `json:"created_at"`
+	ID               int                       `json:"id,omitempty"`            // omitted when games are identified by UUID
+	UUID             string                    `json:"uuid,omitempty"`          // omitted from listings that would give away secret links
+	Owner            string                    `json:"owner,omitempty"`         // the user who created the game, with auth on
+	Players          []string                  `json:"players,omitempty"`       // users the owner invited to play
+	PlayerTokens     map[string]string         `json:"player_tokens,omitempty"` // join tokens by color; only when a two-player game is created
+	Joined           []string                  `json:"joined,omitempty"`        // colors whose players joined a two-player game
+	Status           string                    `json:"status"`
+	Lifecycle        string                    `json:"lifecycle"`
+	Winner           string                    `json:"winner,omitempty"`
+	Termination      string                    `json:"termination,omitempty"`     // checkmate, resignation, timeout, ...
+	DrawReason       string                    `json:"draw_reason,omitempty"`     // why a drawn game ended
+	ClaimableDraws   []string                  `json:"claimable_draws,omitempty"` // draws the side to move may claim
+	DrawOffer        string                    `json:"draw_offer,omitempty"`      // color with a pending draw offer
+	Takeback         *TakebackRequest          `json:"takeback,omitempty"`        // pending takeback request of a two-player game
+	ActiveColor      string                    `json:"active_color"`
+	AIColor          string                    `json:"ai_color,omitempty"` // Which color the AI plays
+	Variant          string                    `json:"variant"`
+	Board            string                    `json:"board"`
+	FEN              string                    `json:"fen"` // Current position in FEN
+	MoveCount        int                       `json:"move_count"`
+	HalfMoveClock    int                       `json:"halfmove_clock"`       // plies since the last capture or pawn move
+	EnPassant        string                    `json:"en_passant,omitempty"` // en passant target square
+	MoveHistory      []MoveResponse            `json:"move_history"`
+	Pockets          map[string]map[string]int `json:"pockets,omitempty"` // Crazyhouse pieces in hand per color
+	Advisories       []AdvisoryResponse        `json:"advisories,omitempty"`
+	Clock            *ClockResponse            `json:"clock,omitempty"`             // present for timed games
+	ConditionalReply *MoveResponse             `json:"conditional_reply,omitempty"` // pre-registered reply played after this move
+	Adaptive         *AdaptiveResponse         `json:"adaptive,omitempty"`          // present for adaptive games
+	Exhibition       *ExhibitionInfo           `json:"exhibition,omitempty"`        // present for LLM vs LLM games
+	Personality      string                    `json:"personality,omitempty"`       // preset the LLM plays
+	AutoCommentary   bool                      `json:"auto_commentary,omitempty"`   // the AI comments on every move
+	AutoAI           bool                      `json:"auto_ai,omitempty"`           // the AI replies on its own
+	AIEngine         string                    `json:"ai_engine,omitempty"`         // engine of automatic replies
+	AILevel          string                    `json:"ai_level,omitempty"`          // level of automatic replies
+	CreatedAt        time.Time                 `json:"created_at"`
+}
+
+// ClockResponse reports the remaining time of a timed game.
+type ClockResponse struct {
+	TimeControl string `json:"time_control"`
+	WhiteMs     int64  `json:"white_ms"`
+	BlackMs     int64  `json:"black_ms"`
+	Running     string `json:"running,omitempty"` // color whose clock is ticking
+}
+
+// AdvisoryResponse is a non-binding server hint, e.g. a "consider_draw" suggestion
+// for dead-drawn or shuffling positions.
+type AdvisoryResponse struct {
+	Type        string   `json:"type"`
+	Reasons     []string `json:"reasons"`
+	Evaluation  float64  `json:"evaluation"`
+	QuietPlies  int      `json:"quiet_plies"`
+	Repetitions int      `json:"repetitions"`
+}
+
+// EvaluationBreakdownResponse explains the evaluation term by term (centipawns from
+// White's perspective).
+type EvaluationBreakdownResponse struct {
+	Material int                         `json:"material"`
+	Center   int                         `json:"center"`
+	Pawns    map[string]PawnTermResponse `json:"pawns"` // doubled, isolated, backward, passed, chain, shield
+	Endgame  int                         `json:"endgame"`
+	Total    int                         `json:"total"`
+}
+
+// PawnTermResponse reports one pawn-structure term: pawns per side and its score.
+type PawnTermResponse struct {
+	White int `json:"white"`
+	Black int `json:"black"`
+	Score int `json:"score"`
 }
 
 // MoveResponse represents a move in API responses.
 type MoveResponse struct {
-	From      string `json:"from"`
-	To        string `json:"to"`
-	Type      string `json:"type"`
-	Piece     string `json:"piece"`
-	Captured  string `json:"captured,omitempty"`
-	Promotion string `json:"promotion,omitempty"`
-	Notation  string `json:"notation"`
+	From      string     `json:"from"`
+	To        string     `json:"to"`
+	Type      string     `json:"type"`
+	Piece     string     `json:"piece"`
+	Captured  string     `json:"captured,omitempty"`
+	Promotion string     `json:"promotion,omitempty"`
+	Notation  string     `json:"notation"`
+	ClockMs   *int64     `json:"clock_ms,omitempty"` // mover's remaining time after the move
+	PlayedAt  *time.Time `json:"played_at,omitempty"`
+	ThinkMs   *int64     `json:"think_ms,omitempty"` // time the mover spent on the move
+	Comment   string     `json:"comment,omitempty"`
+	NAGs      []int      `json:"nags,omitempty"`
 }
 
 // MoveRequest represents a move request.
@@ -51,24 +123,169 @@ type MoveRequest struct {
 	To        string `json:"to"`
 	Promotion string `json:"promotion,omitempty"`
 	Notation  string `json:"notation,omitempty"`
+	Check     bool   `json:"check,omitempty"` // refuse mistakes and blunders with 409 questionable_move
+	// ExpectedMoveCount is the length of the move history the client saw; if
+	// moves were played or taken back since, the move is refused with
+	// 409 stale_position instead of being played on a board the client
+	// did not see.
+	ExpectedMoveCount *int `json:"expected_move_count,omitempty"`
+}
+
+// FENRequest represents a request to load a position.
+type FENRequest struct {
+	FEN string `json:"fen"`
 }
 
 // AIRequest represents an AI move request.
 type AIRequest struct {
-	Level    string `json:"level"`    // beginner, easy, medium, hard, expert
-	Engine   string `json:"engine"`   // random, minimax, llm
-	Provider string `json:"provider"` // openai, anthropic, gemini, xai, deepseek (for LLM engine)
+	Level    string `json:"level"`           // beginner, easy, medium, hard, expert
+	Engine   string `json:"engine"`          // random, minimax, mcts, llm, hybrid, uci
+	Provider string `json:"provider"`        // openai, anthropic, gemini, xai, deepseek, azure, openrouter, ollama (for LLM engine)
+	Lines    int    `json:"lines,omitempty"` // principal variations to report in hints (1-5, minimax only)
+	// MaxDepth, MaxNodes and MoveTimeMs override the level's search limits for this
+	// request, up to the server's caps. Depth applies to minimax and UCI engines,
+	// nodes to minimax, MCTS (playouts) and UCI engines, and move time to all.
+	MaxDepth   *int `json:"max_depth,omitempty"`
+	MaxNodes   *int `json:"max_nodes,omitempty"`
+	MoveTimeMs *int `json:"movetime_ms,omitempty"`
+	// OfferDraw offers the AI a draw instead of asking for its move; the AI accepts
+	// when its search finds the position dead equal in an ending or clearly worse.
+	OfferDraw bool `json:"offer_draw,omitempty"`
+	// Apply plays the AI's move in the game under the game's lock, instead of
+	// leaving it to the client to play, and returns the game after it.
+	Apply bool `json:"apply,omitempty"`
+	// Model and Temperature override the LLM provider's model, which must be allowed
+	// in its configuration, and the level's sampling temperature (0-2).
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	// Language is the language of LLM reactions and hint explanations, e.g. "de"
+	// or "Spanish"; English if empty.
+	Language string `json:"language,omitempty"`
+	// Personality is the LLM's personality preset (see GET /api/personalities);
+	// the game's if empty.
+	Personality string `json:"personality,omitempty"`
+}
+
+// PVLineResponse is a principal variation: expected best play in SAN and its score.
+type PVLineResponse struct {
+	Moves   []string `json:"moves"`
+	Score   float64  `json:"score"`          // pawns, White's perspective
+	ScoreCp int      `json:"score_cp"`       // centipawns, White's perspective
+	Mate    int      `json:"mate,omitempty"` // moves to mate; positive if White mates
+}
+
+// SearchInfoResponse reports how an AI move was found.
+type SearchInfoResponse struct {
+	Nodes     int     `json:"nodes"`
+	Depth     int     `json:"depth"` // plies fully searched
+	NPS       int     `json:"nps"`
+	TTHitRate float64 `json:"tt_hit_rate"`
+	TimeMs    int64   `json:"time_ms"`
+	Book      bool    `json:"book,omitempty"` // move played from the opening book
+}
+
+// searchInfoResponse converts engine search statistics.
+func searchInfoResponse(info ai.SearchInfo) SearchInfoResponse {
+	return SearchInfoResponse{
+		Nodes:     info.Nodes,
+		Depth:     info.Depth,
+		NPS:       info.NPS(),
+		TTHitRate: info.TTHitRate(),
+		TimeMs:    info.Elapsed.Milliseconds(),
+		Book:      info.Book,
+	}
+}
+
+// newMinimaxAI creates a minimax engine with the configured evaluation, target
+// ratings and opening repertoire.
+func (s *Server) newMinimaxAI(difficulty ai.Difficulty) *ai.MinimaxAI {
+	minimax := ai.NewMinimaxAI(difficulty)
+	minimax.SetEvaluator(s.evaluator)
+	if repertoire, err := ai.ParseRepertoire(s.config.AI.Repertoire); err == nil {
+		minimax.SetRepertoire(repertoire)
+	}
+	if len(s.config.AI.DifficultyElo) > 0 {
+		targets := make(map[ai.Difficulty]int)
+		for d := ai.DifficultyBeginner; d <= ai.DifficultyExpert; d++ {
+			if elo, ok := s.config.AI.DifficultyElo[d.String()]; ok {
+				targets[d] = elo
+			}
+		}
+		minimax.SetEloTargets(targets)
+	}
+	return minimax
 }
 
+// newMCTSEngine creates a tree search engine with the configured evaluation.
+func (s *Server) newMCTSEngine(difficulty ai.Difficulty) *ai.MCTSEngine {
+	mcts := ai.NewMCTSEngine(difficulty)
+	mcts.SetEvaluator(s.evaluator)
+	return mcts
+}
+
+// maxPVLines caps multi-PV requests.
+const maxPVLines = 5
+
 // GameCreateRequest represents a game creation request.
 type GameCreateRequest struct {
-	AIColor string `json:"ai_color,omitempty"` // "white", "black", or empty for default (black)
+	AIColor     string `json:"ai_color,omitempty"`     // "white", "black", "none" for two players, or empty for default (black)
+	Variant     string `json:"variant,omitempty"`      // "standard" (default) or "crazyhouse"
+	TimeControl string `json:"time_control,omitempty"` // PGN form "seconds+increment", e.g. "300+3"
+	// Adaptive grades the player's moves and tunes the minimax AI's strength to
+	// keep the game balanced.
+	Adaptive bool `json:"adaptive,omitempty"`
+	// Personality is the preset the LLM plays in this game's chat, reactions and
+	// LLM moves, e.g. "grumpy-grandmaster" (see GET /api/personalities).
+	Personality string `json:"personality,omitempty"`
+	// AutoCommentary has the AI react to every move played through the moves
+	// endpoint and push the reaction to WebSocket clients.
+	AutoCommentary bool `json:"auto_commentary,omitempty"`
+	// AutoAI has the AI reply to every move played through the moves endpoint
+	// on its own, pushing the game to WebSocket clients, with AIEngine (minimax,
+	// mcts or random; minimax if empty) at AILevel (medium if empty).
+	AutoAI   bool   `json:"auto_ai,omitempty"`
+	AIEngine string `json:"ai_engine,omitempty"`
+	AILevel  string `json:"ai_level,omitempty"`
+}
+
+// GameImportRequest represents a PGN import request.
+type GameImportRequest struct {
+	PGN     string `json:"pgn"`
+	AIColor string `json:"ai_color,omitempty"`
 }
 
 // GameMetadata stores additional game information.
 type GameMetadata struct {
+	UUID      string    `json:"uuid"` // identifies the game in shareable links
 	AIColor   string    `json:"ai_color"`
+	Owner     string    `json:"owner,omitempty"`   // the user who created the game, with auth on
+	Players   []string  `json:"players,omitempty"` // users the owner invited to play
 	CreatedAt time.Time `json:"created_at"`
+	// PlayerTokens holds the SHA-256 of the join token of each color of a
+	// two-player game, and Joined the colors whose players joined.
+	PlayerTokens map[string]string `json:"player_tokens,omitempty"`
+	Joined       []string          `json:"joined,omitempty"`
+	Lifecycle    LifecycleState    `json:"lifecycle"`
+	DrawOffer    string            `json:"draw_offer,omitempty"` // color with a pending draw offer
+	// Takeback is a two-player game's pending takeback request.
+	Takeback *TakebackRequest `json:"takeback,omitempty"`
+	Adaptive bool             `json:"adaptive,omitempty"` // AI strength follows the player
+	// Personality names the preset the LLM plays, if any.
+	Personality string `json:"personality,omitempty"`
+	// Exhibition names the LLMs playing both sides of an exhibition game.
+	Exhibition *ExhibitionInfo `json:"exhibition,omitempty"`
+	// AutoCommentary has the AI comment on every move played through the moves
+	// endpoint.
+	AutoCommentary bool `json:"auto_commentary,omitempty"`
+	// AutoAI has the AI reply on its own, with AIEngine at AILevel, which are
+	// also those of ai-move requests that name no engine or level.
+	AutoAI   bool   `json:"auto_ai,omitempty"`
+	AIEngine string `json:"ai_engine,omitempty"`
+	AILevel  string `json:"ai_level,omitempty"`
+
+	outcome  *ai.OutcomeTracker   // the AI's resignation and draw decisions
+	adaptive *ai.AdaptiveStrength // model of the player in adaptive games
+	summary  *SummaryResponse     // post-game summary, once written
 }
 
 // ChatRequest represents a chat message request.
@@ -76,20 +293,38 @@ type ChatRequest struct {
 	Message  string `json:"message"`
 	Provider string `json:"provider,omitempty"` // LLM provider to use (openai, anthropic, gemini, xai)
 	APIKey   string `json:"api_key,omitempty"`  // Custom API key for this request
+	Model    string `json:"model,omitempty"`    // Model of the provider, from its allowed models
+	Language string `json:"language,omitempty"` // Reply language, e.g. "de" or "Spanish"; kept for the game
+	// Personality is a preset such as "grumpy-grandmaster"; kept for the game
+	Personality string `json:"personality,omitempty"`
+	// Temperature is validated like an AI request's, but the chat backend samples
+	// at its provider default and refuses it.
+	Temperature *float64 `json:"temperature,omitempty"`
+	// Mode "coach" answers with guiding questions and partial hints drawn from
+	// the engine's view of the position instead of answers
+	Mode string `json:"mode,omitempty"`
+
+	limitKey string // who the chat limits count the message against; see chatLimitKey
 }
 
 // Enhanced ChatResponse represents a chat message response.
 type ChatResponse struct {
 	Response    string                 `json:"response"`
+	UserID      string                 `json:"user_id"` // who the message was recorded for
 	Provider    string                 `json:"provider"`
 	GameContext map[string]interface{} `json:"game_context,omitempty"`
 	Suggestions []string               `json:"suggestions,omitempty"`
+	// Filtered says why the chat filter matched the message: "profanity",
+	// "aggression" or "link". The message was sent sanitized or unchanged,
+	// depending on the filter's action.
+	Filtered []string `json:"filtered,omitempty"`
 }
 
 // ErrorResponse represents an error response.
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
+	Reason  string `json:"reason,omitempty"` // machine-readable detail, e.g. why a move is illegal
 }
 
 // Server represents the HTTP API server (stateful per-process in-memory store).
@@ -98,11 +333,36 @@ type Server struct {
 	logger       *zap.Logger
 	games        map[int]*engine.Game
 	gameMetadata map[int]*GameMetadata
+	gameUUIDs    map[string]int // game numbers by UUID
 	gamesMux     sync.RWMutex
 	nextID       int
 	upgrader     websocket.Upgrader
 	chatService  *chat.ChatService
-	gameLocks    map[int]*sync.Mutex // per-game locks to avoid concurrent mutation races
+	moderator    *chat.Moderator     // screens LLM chat, reactions and commentary; nil when disabled
+	llmLog       ai.LLMLogger        // logs LLM exchanges; nil unless enabled
+	gameLocks    map[int]sync.Locker // per-game locks to avoid concurrent mutation races
+	conditionals map[int]*engine.ConditionalMoves
+	exhibitions  map[int]context.CancelFunc // stops the exhibition games being played
+	hub          *wsHub[int]                // WebSocket clients per game
+	rooms        *chat.Rooms                // lobby chat rooms, apart from the games' conversations
+	roomHub      *wsHub[string]             // WebSocket clients per chat room
+	cache        *responseCache
+	evaluator    ai.Evaluator      // position evaluation for the search engines
+	tablebase    *ai.Tablebase     // endgame tablebases for analysis, or nil
+	uciPool      *ai.UCIPool       // running processes of the UCI engine, or nil
+	reviews      *reviewCache      // the latest review of each game
+	llmCache     *ai.LLMCache      // LLM moves and reactions by position, or nil
+	store        store.Store       // keeps conversations and LLM histories across restarts, or nil
+	saver        *gameSaver        // saves games to the store in the background, or nil
+	coordinator  store.Coordinator // shares games with other servers on the store, or nil
+
+	practiceSets   map[int]*PracticeSet
+	practiceMux    sync.RWMutex
+	nextPracticeID int
+
+	puzzles       *puzzle.Collection
+	puzzleSolvers map[string]*puzzleSolver // by user ID
+	puzzleMux     sync.Mutex
 }
 
 // NewServer creates a new API server.
@@ -115,70 +375,223 @@ func NewServer(cfg *config.Config) *Server {
 		logger.Error("Failed to create chat service", zap.Error(err))
 		// Continue without chat service for now
 	}
+	var llmLog ai.LLMLogger
+	if cfg.LLMAI.LogExchanges {
+		llmLog = llmExchangeLogger(logger)
+		if chatService != nil {
+			chatService.SetExchangeLogger(llmLog)
+		}
+	}
+	var moderator *chat.Moderator
+	if moderation := cfg.LLMAI.Moderation; moderation.Enabled {
+		moderator = chat.NewModerator(chat.ModerationOptions{
+			BlockedWords: moderation.BlockedWords,
+			MaskedWords:  moderation.MaskedWords,
+			Endpoint:     moderation.Endpoint,
+			APIKey:       moderation.APIKey,
+			Replacement:  moderation.Replacement,
+		}, logger)
+		if chatService != nil {
+			chatService.SetModerator(moderator)
+			err := chatService.SetInputFilter(chat.InputFilterOptions{
+				Action:             moderation.InputAction,
+				Profanities:        moderation.Profanities,
+				AggressionPatterns: moderation.AggressionPatterns,
+			})
+			if err != nil {
+				logger.Error("Invalid chat input filter, chat messages are not filtered", zap.Error(err))
+			}
+			chatService.SetModerationEventHandler(moderationEventLogger(logger))
+		}
+	}
+	if chatService != nil {
+		limits := cfg.LLMAI.ChatLimits
+		chatService.SetLimits(chat.Limits{
+			UserMessagesPerMinute: limits.UserMessagesPerMinute,
+			GameMessagesPerMinute: limits.GameMessagesPerMinute,
+			UserTokensPerDay:      limits.UserTokensPerDay,
+			GameTokensPerDay:      limits.GameTokensPerDay,
+		})
+		chatService.SetHistoryBudget(cfg.LLMAI.ChatHistoryBudget)
+	}
+	rooms := chat.NewRooms()
+	rooms.SetLimits(chat.Limits{UserMessagesPerMinute: cfg.LLMAI.ChatLimits.UserMessagesPerMinute})
 
-	return &Server{
+	evaluator := ai.ClassicalEvaluator
+	if cfg.AI.EvalNetwork != "" {
+		network, err := ai.LoadNNUE(cfg.AI.EvalNetwork)
+		if err != nil {
+			logger.Error("Failed to load evaluation network, using classical evaluation", zap.Error(err))
+		} else {
+			evaluator = network
+		}
+	}
+	if cfg.AI.EnableCaching {
+		evaluator = ai.NewEvalCache(evaluator, cfg.AI.CacheSize)
+	}
+	var llmCache *ai.LLMCache
+	if cfg.LLMAI.Enabled && cfg.LLMAI.CacheTTL > 0 {
+		llmCache = ai.NewLLMCache(cfg.LLMAI.CacheTTL, cfg.LLMAI.CacheSize)
+	}
+	if chatService != nil {
+		chatService.SetCache(llmCache)
+	}
+	db := openStore(cfg.Database, logger)
+	if db != nil && chatService != nil {
+		chatService.SetConversationStore(db)
+	}
+
+	s := &Server{
 		config:       cfg,
 		logger:       logger,
 		games:        make(map[int]*engine.Game),
 		gameMetadata: make(map[int]*GameMetadata),
+		gameUUIDs:    make(map[string]int),
 		nextID:       1,
 		chatService:  chatService,
-		gameLocks:    make(map[int]*sync.Mutex),
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true // Allow all origins for demo purposes
-			},
-		},
-	}
+		moderator:    moderator,
+		llmLog:       llmLog,
+		gameLocks:    make(map[int]sync.Locker),
+		conditionals: make(map[int]*engine.ConditionalMoves),
+		exhibitions:  make(map[int]context.CancelFunc),
+		hub:          newWSHub[int](),
+		rooms:        rooms,
+		roomHub:      newWSHub[string](),
+		cache:        newResponseCache(cfg.Server.ResponseCacheTTL),
+		evaluator:    evaluator,
+		reviews:      &reviewCache{reviews: make(map[int]cachedReview)},
+		llmCache:     llmCache,
+		store:        db,
+
+		practiceSets:   make(map[int]*PracticeSet),
+		nextPracticeID: 1,
+
+		puzzles:       puzzle.Builtin(),
+		puzzleSolvers: make(map[string]*puzzleSolver),
+	}
+	if cfg.AI.TablebaseURL != "" {
+		s.tablebase = ai.NewTablebase(cfg.AI.TablebaseURL)
+	}
+	if cfg.AI.UCIPath != "" {
+		s.uciPool = ai.NewUCIPool(cfg.AI.UCIPath, cfg.AI.UCIEngines)
+	}
+	s.upgrader = websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	s.hub.replay, s.hub.replayable = cfg.Server.ReplayEvents, replayableMessage
+	if games, ok := db.(store.GameStore); ok {
+		s.saver = newGameSaver(games)
+		s.coordinator, _ = db.(store.Coordinator)
+		s.restoreGames()
+		go s.saveGames()
+	}
+	return s
 }
 
 // SetupRoutes sets up the API routes.
 func (s *Server) SetupRoutes(r *gin.Engine) {
-	// Enable CORS for development
-	r.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	if s.config.Tracing.Enabled {
+		r.Use(otelgin.Middleware(s.config.Tracing.ServiceName))
+	}
+	if s.config.Server.CORSEnabled {
+		r.Use(s.cors())
+	}
+	if s.config.Server.Auth.Enabled {
+		r.Use(s.authenticate())
+	}
 
-		c.Next()
-	})
+	s.apiRoutes(r.Group(apiV1Prefix))
+	s.apiRoutes(r.Group(legacyAPIPrefix, deprecatedAPI()))
+
+	// WebSocket endpoint
+	r.GET("/ws/games/:id", s.syncSharedGames(), s.handleWebSocket)
+	r.GET("/ws/rooms/:room", s.handleRoomWebSocket)
 
-	api := r.Group("/api")
+	// Health check
+	r.GET("/health", s.health)
+	r.GET(openAPIPath, s.serveOpenAPI(r))
+}
+
+// apiRoutes sets up the REST API routes of a version under api.
+func (s *Server) apiRoutes(api *gin.RouterGroup) {
+	api.Use(s.syncSharedGames(), s.requireGamePlayer(), s.invalidateOnMutation(), s.saveOnMutation())
 	{
 		// Game management
 		api.POST("/games", s.createGame)
-		api.GET("/games/:id", s.getGame)
+		api.POST("/exhibitions", s.startExhibition)
+		api.POST("/games/import", s.importGame)
+		api.GET("/games/:id", s.cached(), s.getGame)
 		api.DELETE("/games/:id", s.deleteGame)
+		api.PATCH("/games/:id", s.updateGameSettings)
 		api.GET("/games", s.listGames)
+		api.GET("/games/export", s.exportGames)
+		api.POST("/games/:id/players", s.invitePlayer)
+		api.POST("/games/:id/join", s.joinGame)
 
 		// Game actions
 		api.POST("/games/:id/moves", s.makeMove)
-		api.GET("/games/:id/moves", s.getMoveHistory)
+		api.GET("/games/:id/moves", s.cached(), s.getMoveHistory)
+		api.GET("/games/:id/events", s.gameEvents)
 		api.POST("/games/:id/ai-move", s.getAIMove)
 		api.POST("/games/:id/ai-hint", s.getAIHint)
+		api.POST("/games/:id/claim-draw", s.claimDraw)
+		api.POST("/games/:id/resign", s.resign)
+		api.POST("/games/:id/draw-offer", s.offerDraw)
+		api.POST("/games/:id/draw-accept", s.acceptDraw)
+		api.POST("/games/:id/draw-decline", s.declineDraw)
+		api.POST("/games/:id/undo", s.undo)
+		api.POST("/games/:id/undo/accept", s.acceptTakeback)
+		api.POST("/games/:id/undo/decline", s.declineTakeback)
+		api.POST("/games/:id/pause", s.pauseGame)
+		api.POST("/games/:id/resume", s.resumeGame)
+		api.POST("/games/:id/archive", s.archiveGame)
+
+		// Conditional moves (correspondence)
+		api.POST("/games/:id/conditional-moves", s.addConditionalMoves)
+		api.GET("/games/:id/conditional-moves", s.listConditionalMoves)
+		api.DELETE("/games/:id/conditional-moves/:lineId", s.deleteConditionalMoves)
 
 		// Chat functionality
 		api.POST("/games/:id/chat", s.chatWithAI)
+		api.GET("/games/:id/chat/history", s.getChatHistory)
+		api.DELETE("/games/:id/chat", s.clearChat)
+		api.GET("/games/:id/transcript", s.getTranscript)
 		api.POST("/games/:id/react", s.getAIReaction)
 		api.POST("/chat", s.generalChat) // General chat for demos
+		api.GET("/personalities", s.listPersonalities)
+
+		// Chat rooms (lobby)
+		api.GET("/rooms", s.listRooms)
+		api.POST("/rooms", s.createRoom)
+		api.GET("/rooms/:room", s.getRoom)
+		api.POST("/rooms/:room/join", s.joinRoom)
+		api.POST("/rooms/:room/leave", s.leaveRoom)
+		api.GET("/rooms/:room/messages", s.getRoomMessages)
+		api.POST("/rooms/:room/messages", s.postRoomMessage)
 
 		// Game analysis / export
-		api.GET("/games/:id/legal-moves", s.getLegalMoves)
+		api.GET("/games/:id/legal-moves", s.cached(), s.getLegalMoves)
+		api.GET("/games/:id/fen", s.cached(), s.getFEN)
 		api.POST("/games/:id/fen", s.loadFromFEN)
-		api.GET("/games/:id/analysis", s.analyzePosition)
-		api.GET("/games/:id/pgn", s.getPGN)
+		api.GET("/games/:id/analysis", s.cached(), s.analyzePosition)
+		api.GET("/games/:id/pgn", s.cached(), s.getPGN)
+		api.GET("/games/:id/image", s.cached(), s.getGameImage)
+		api.GET("/games/:id/review", s.cached(), s.getGameReview)
+		api.GET("/games/:id/summary", s.cached(), s.getGameSummary)
+		api.PUT("/games/:id/moves/:index/annotation", s.annotateMove)
+
+		// Practice sets
+		api.POST("/practice-sets", s.createPracticeSet)
+		api.GET("/practice-sets", s.listPracticeSets)
+		api.GET("/practice-sets/:id", s.getPracticeSet)
+		api.POST("/practice-sets/:id/positions/:index/start", s.startPracticePosition)
+		api.POST("/practice-sets/:id/positions/:index/complete", s.completePracticePosition)
+
+		// Puzzles
+		api.GET("/puzzles", s.nextPuzzle)
+		api.GET("/puzzles/themes", s.listPuzzleThemes)
+		api.GET("/puzzles/ratings/:user", s.getPuzzleRating)
+		api.GET("/puzzles/:id", s.getPuzzle)
+		api.POST("/puzzles/:id/attempts", s.attemptPuzzle)
 	}
-
-	// WebSocket endpoint
-	r.GET("/ws/games/:id", s.handleWebSocket)
-
-	// Health check
-	r.GET("/health", s.health)
 }
 
 // createGame creates a new chess game.
@@ -194,38 +607,149 @@ func (s *Server) createGame(c *gin.Context) {
 	}
 
 	// Validate AI color
-	if req.AIColor != "white" && req.AIColor != "black" {
+	if req.AIColor != "white" && req.AIColor != "black" && req.AIColor != aiColorNone {
 		req.AIColor = "black" // Default to black if invalid
 	}
+	if req.AIColor == aiColorNone && req.Adaptive {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "adaptive games are played against the AI"})
+		return
+	}
 
-	game := engine.NewGame()
-	gameID := s.nextID
-	s.nextID++
+	variant, err := engine.ParseVariant(req.Variant)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_variant", Message: err.Error()})
+		return
+	}
 
-	s.games[gameID] = game
-	s.gameMetadata[gameID] = &GameMetadata{
-		AIColor:   req.AIColor,
-		CreatedAt: time.Now(),
+	personality, ok := lookupPersonality(c, req.Personality)
+	if !ok {
+		return
+	}
+	if req.AutoCommentary && !s.requireChat(c) {
+		return
+	}
+	if req.AutoAI && req.AIColor == aiColorNone {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "two-player games have no AI to reply"})
+		return
+	}
+	if !checkAutoAI(c, req.AIEngine, req.AILevel) {
+		return
 	}
 
-	// initialize per-game lock
-	if s.gameLocks[gameID] == nil {
-		s.gameLocks[gameID] = &sync.Mutex{}
+	game := engine.NewGameWithVariant(variant)
+	if req.TimeControl != "" {
+		tc, err := engine.ParseTimeControl(req.TimeControl)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_time_control", Message: err.Error()})
+			return
+		}
+		game.SetClock(engine.NewClock(tc))
+	}
+	metadata := &GameMetadata{
+		AIColor:        req.AIColor,
+		Owner:          authenticatedUserID(c),
+		CreatedAt:      time.Now(),
+		Adaptive:       req.Adaptive,
+		Personality:    personality.Name,
+		AutoCommentary: req.AutoCommentary,
+		AutoAI:         req.AutoAI,
+		AIEngine:       req.AIEngine,
+		AILevel:        req.AILevel,
+	}
+	if req.Adaptive {
+		metadata.adaptive = ai.NewAdaptiveStrength(ai.DefaultAdaptivePolicy())
+	}
+	var playerTokens map[string]string
+	if metadata.isTwoPlayer() {
+		if playerTokens, err = newPlayerTokens(metadata); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "internal_error", Message: "failed to create player tokens"})
+			return
+		}
+	}
+	gameID := s.registerGame(game, metadata)
+	s.forgetLLMSession(gameID)
+	if personality.Name != "" && s.chatService != nil {
+		s.chatService.SetPersonality(gameID, personality.Name)
 	}
 
-	response := s.gameToResponse(gameID, game)
+	response := s.gameToResponseLocked(gameID, game)
+	response.PlayerTokens = playerTokens
+	s.scheduleAutoAILocked(gameID, game) // the AI opens as white
 
 	s.logger.Info("Created new game",
 		zap.Int("game_id", gameID),
-		zap.String("ai_color", req.AIColor))
+		zap.String("ai_color", req.AIColor),
+		zap.String("variant", variant.String()),
+		zap.Bool("adaptive", req.Adaptive))
 	c.JSON(http.StatusCreated, response)
 }
 
-// getGame retrieves a specific game.
-func (s *Server) getGame(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
+// importGame creates a game by replaying PGN movetext, keeping any %clk/%emt clock data.
+func (s *Server) importGame(c *gin.Context) {
+	var req GameImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.PGN == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "pgn is required"})
+		return
+	}
+	if req.AIColor != "white" && req.AIColor != "black" {
+		req.AIColor = "black"
+	}
+
+	imported, err := engine.ParsePGN(req.PGN)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_pgn", Message: err.Error()})
+		return
+	}
+
+	s.gamesMux.Lock()
+	gameID := s.registerGame(imported.Game, &GameMetadata{
+		AIColor:   req.AIColor,
+		Owner:     authenticatedUserID(c),
+		CreatedAt: time.Now(),
+	})
+	response := s.gameToResponseLocked(gameID, imported.Game)
+	s.gamesMux.Unlock()
+
+	s.logger.Info("Imported game from PGN",
+		zap.Int("game_id", gameID),
+		zap.Int("plies", len(imported.Game.MoveHistory())))
+	c.JSON(http.StatusCreated, response)
+}
+
+// registerGame stores a new game with its metadata and per-game lock and returns its ID.
+// The caller must hold gamesMux for writing.
+func (s *Server) registerGame(game *engine.Game, metadata *GameMetadata) int {
+	gameID := s.nextGameID()
+
+	s.games[gameID] = game
+	s.gameMetadata[gameID] = metadata
+	s.indexGameLocked(gameID, metadata)
+	s.observeGame(gameID, game)
+	game.RecordTimestamps()
+
+	// The AI fills the second seat, so games start active unless already over;
+	// two-player games wait for both players to join
+	metadata.Lifecycle = StateCreated
+	next := StateActive
+	if game.IsGameOver() {
+		next = StateFinished
+	} else if metadata.isTwoPlayer() {
+		next = StateAwaitingPlayers
+	}
+	_ = s.transitionLocked(gameID, next)
+
+	// initialize per-game lock
+	if s.gameLocks[gameID] == nil {
+		s.gameLocks[gameID] = s.newGameLock(gameID)
+	}
+	return gameID
+}
+
+// getGame retrieves a specific game, or with ?ply= the game as it stood after
+// that many plies.
+func (s *Server) getGame(c *gin.Context) {
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
@@ -237,6 +761,9 @@ func (s *Server) getGame(c *gin.Context) {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
 		return
 	}
+	if game, ok = positionParam(c, game, "ply"); !ok {
+		return
+	}
 
 	response := s.gameToResponse(gameID, game)
 	c.JSON(http.StatusOK, response)
@@ -244,35 +771,82 @@ func (s *Server) getGame(c *gin.Context) {
 
 // deleteGame deletes a specific game.
 func (s *Server) deleteGame(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
-	s.gamesMux.Lock()
-	defer s.gamesMux.Unlock()
-
-	if _, exists := s.games[gameID]; !exists {
+	// Lock the game first, across servers when they share their games, so that
+	// no move is made on it while it is deleted
+	s.gamesMux.RLock()
+	lock, exists := s.gameLocks[gameID]
+	s.gamesMux.RUnlock()
+	if !exists {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
 		return
 	}
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
 
-	delete(s.games, gameID)
-	delete(s.gameLocks, gameID)
+	s.gamesMux.Lock()
+	if _, exists := s.games[gameID]; !exists { // deleted by another server
+		s.gamesMux.Unlock()
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
+		return
+	}
+	s.removeGameLocked(gameID)
+	s.gamesMux.Unlock()
+	s.deleteSharedGameLocked(gameID)
+	s.forgetLLMSession(gameID)
 
 	s.logger.Info("Deleted game", zap.Int("game_id", gameID))
 	c.JSON(http.StatusNoContent, nil)
 }
 
-// listGames lists all active games.
+// removeGameLocked removes a game from the server. The caller must hold
+// gamesMux for writing.
+func (s *Server) removeGameLocked(gameID int) {
+	if metadata := s.gameMetadata[gameID]; metadata != nil {
+		delete(s.gameUUIDs, metadata.UUID)
+	}
+	delete(s.games, gameID)
+	delete(s.gameLocks, gameID)
+	delete(s.conditionals, gameID)
+	if stop, ok := s.exhibitions[gameID]; ok {
+		stop()
+		delete(s.exhibitions, gameID)
+	}
+	s.hub.forget(gameID)
+	s.reviews.forget(gameID)
+}
+
+// listGames lists all active games. When games are identified by UUID, their
+// links are secret: users with auth on see only their own games, admins all,
+// and without auth the games are listed without their UUIDs.
 func (s *Server) listGames(c *gin.Context) {
 	s.gamesMux.RLock()
 	defer s.gamesMux.RUnlock()
 
+	p, authenticated := principalFrom(c.Request.Context())
+	hideUUIDs := s.usesUUIDs() && !authenticated
+	ownOnly := s.usesUUIDs() && authenticated && !p.has(config.ScopeAdmin)
+	state := LifecycleState(c.Query("state"))
 	var games []GameResponse
 	for id, game := range s.games {
-		games = append(games, s.gameToResponse(id, game))
+		metadata, exists := s.gameMetadata[id]
+		if state != "" && (!exists || metadata.Lifecycle != state) {
+			continue
+		}
+		if ownOnly && (!exists || metadata.Owner != p.UserID && !slices.Contains(metadata.Players, p.UserID)) {
+			continue
+		}
+		response := s.gameToResponseLocked(id, game)
+		if hideUUIDs {
+			response.UUID = ""
+		}
+		games = append(games, response)
 	}
 
 	c.JSON(http.StatusOK, map[string]interface{}{
@@ -283,9 +857,8 @@ func (s *Server) listGames(c *gin.Context) {
 
 // makeMove makes a move in a game.
 func (s *Server) makeMove(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
@@ -308,9 +881,34 @@ func (s *Server) makeMove(c *gin.Context) {
 
 	// Serialize mutations for this specific game to prevent race conditions
 	if lock != nil {
-		lock.Lock()
+		if !s.requireGameLock(c, lock) {
+			return
+		}
 		defer lock.Unlock()
 	}
+	c.JSON(s.playMoveLocked(c.Request.Context(), gameID, game, requestPlayerToken(c), req))
+}
+
+// playMoveLocked plays the move of the holder of a player token, who needs
+// none in games against the AI. It returns the HTTP status and body of the
+// reply: the game, or why the move was refused. The caller must hold the
+// per-game lock.
+func (s *Server) playMoveLocked(ctx context.Context, gameID int, game *engine.Game, token string, req MoveRequest) (int, interface{}) {
+	if errResp := s.activeError(gameID); errResp != nil {
+		return http.StatusConflict, *errResp
+	}
+	if s.isExhibition(gameID) {
+		return http.StatusConflict, ErrorResponse{Error: "exhibition_game", Message: "exhibition games are played by their LLMs"}
+	}
+	if played := len(game.MoveHistory()); req.ExpectedMoveCount != nil && *req.ExpectedMoveCount != played {
+		return http.StatusConflict, ErrorResponse{
+			Error:   "stale_position",
+			Message: fmt.Sprintf("expected %d moves played, but the game has %d; reload it and try again", *req.ExpectedMoveCount, played),
+		}
+	}
+	if status, errResp := s.playerTurnError(gameID, game, token); errResp != nil {
+		return status, *errResp
+	}
 
 	// Parse the move (notation may be provided directly e.g. for castling)
 	var notation string
@@ -326,28 +924,65 @@ func (s *Server) makeMove(c *gin.Context) {
 	}
 
 	move, err := game.ParseMove(notation)
+	var illegal *engine.IllegalMoveError
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_move", Message: err.Error()})
-		return
+		if errors.As(err, &illegal) {
+			return http.StatusBadRequest, illegalMoveResponse(illegal)
+		}
+		return http.StatusBadRequest, ErrorResponse{Error: "invalid_move", Message: err.Error()}
+	}
+
+	// Grade the move first if asked to, or to model the player in adaptive games
+	mover := game.ActiveColor()
+	model := s.adaptiveModel(gameID, mover)
+	var checked *ai.MoveAnalysis
+	if (req.Check || model != nil) && game.IsLegalMove(move) {
+		checked = s.checkMove(ctx, gameID, game, move)
+	}
+	if resp := questionableMove(checked); req.Check && resp != nil {
+		return http.StatusConflict, *resp
 	}
 
 	// Make the move
 	if err := game.MakeMove(move); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "illegal_move", Message: err.Error()})
-		return
+		if errors.As(err, &illegal) {
+			return http.StatusBadRequest, illegalMoveResponse(illegal)
+		}
+		return http.StatusBadRequest, ErrorResponse{Error: "illegal_move", Message: err.Error()}
 	}
 
 	s.logger.Info("Move made", zap.Int("game_id", gameID), zap.String("move", move.String()))
+	s.declineDrawByMoving(gameID, mover)
+	s.withdrawTakeback(gameID)
+	if model != nil && checked != nil {
+		s.recordAdaptive(gameID, model, *checked)
+	}
+
+	if s.autoCommentary(gameID, mover) {
+		s.commentOnMove(gameID, game, move)
+	}
+	reply := s.applyConditionalMoves(gameID, game, move)
+	s.finishIfOver(gameID, game)
+	s.scheduleAutoAI(gameID, game)
 
 	response := s.gameToResponse(gameID, game)
-	c.JSON(http.StatusOK, response)
+	if reply != nil {
+		replyResp := s.moveToResponse(*reply)
+		response.ConditionalReply = &replyResp
+	}
+	for _, adv := range response.Advisories {
+		s.logger.Info("Game advisory",
+			zap.Int("game_id", gameID),
+			zap.String("type", adv.Type),
+			zap.Strings("reasons", adv.Reasons))
+	}
+	return http.StatusOK, response
 }
 
 // getMoveHistory retrieves the move history of a game.
 func (s *Server) getMoveHistory(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
@@ -360,12 +995,7 @@ func (s *Server) getMoveHistory(c *gin.Context) {
 		return
 	}
 
-	history := game.MoveHistory()
-	moves := make([]MoveResponse, len(history))
-
-	for i, move := range history {
-		moves[i] = s.moveToResponse(move)
-	}
+	moves := s.moveHistoryResponse(game)
 
 	c.JSON(http.StatusOK, map[string]interface{}{
 		"moves": moves,
@@ -375,23 +1005,27 @@ func (s *Server) getMoveHistory(c *gin.Context) {
 
 // getAIMove gets a move suggestion from the AI.
 func (s *Server) getAIMove(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
 	var req AIRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		req.Level = "medium"  // Default level
-		req.Engine = "random" // Default engine
+		req = AIRequest{}
 	}
 
 	s.gamesMux.RLock()
 	game, gameExists := s.games[gameID]
 	metadata, metadataExists := s.gameMetadata[gameID]
 	lock := s.gameLocks[gameID]
+	if metadataExists {
+		// The game's own engine and level, e.g. a practice set's, apply unless
+		// the request names others
+		req.Engine, req.Level = cmp.Or(req.Engine, metadata.AIEngine), cmp.Or(req.Level, metadata.AILevel)
+	}
 	s.gamesMux.RUnlock()
+	req.Engine, req.Level = cmp.Or(req.Engine, "random"), cmp.Or(req.Level, "medium")
 
 	if !gameExists {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
@@ -403,9 +1037,14 @@ func (s *Server) getAIMove(c *gin.Context) {
 	if metadataExists && metadata.AIColor != "" {
 		aiColor = metadata.AIColor
 	}
+	if aiColor == aiColorNone {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "no_ai", Message: "two-player games have no AI"})
+		return
+	}
 
 	// Validate that it's the AI's turn
 	currentColor := game.ActiveColor().String()
+	plies := len(game.MoveHistory())
 	if currentColor != aiColor {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "not_ai_turn",
@@ -431,6 +1070,20 @@ func (s *Server) getAIMove(c *gin.Context) {
 		difficulty = ai.DifficultyMedium
 	}
 
+	limits, ok := s.searchLimits(c, req)
+	if !ok {
+		return
+	}
+	if !s.checkLLMOverrides(c, req.Provider, req.Model, req.Temperature) || !checkLanguage(c, req.Language) {
+		return
+	}
+	if _, ok := lookupPersonality(c, req.Personality); !ok {
+		return
+	}
+	if req.Personality == "" {
+		req.Personality = s.gamePersonality(gameID)
+	}
+
 	// Create AI engine based on type
 	var aiEngine ai.Engine
 
@@ -443,37 +1096,104 @@ func (s *Server) getAIMove(c *gin.Context) {
 				s.logger.Warn("Failed to create LLM AI engine, falling back to random", zap.Error(err))
 				aiEngine = ai.NewRandomAI()
 			} else {
+				s.configureLLMEngine(llmEngine, req)
 				aiEngine = llmEngine
 			}
 		} else {
 			// Fallback to random if LLM not available
 			aiEngine = ai.NewRandomAI()
 		}
+	case "hybrid":
+		aiEngine = s.newHybridEngine(req, difficulty)
 	case "minimax":
-		aiEngine = ai.NewMinimaxAI(difficulty)
+		aiEngine = s.newMinimaxAI(difficulty)
+	case "mcts":
+		aiEngine = s.newMCTSEngine(difficulty)
+	case "uci":
+		if s.uciPool != nil {
+			uciEngine, ok := s.uciEngine(c, difficulty)
+			if !ok {
+				return
+			}
+			defer s.uciPool.Put(uciEngine)
+			aiEngine = uciEngine
+		} else {
+			// Fallback to random if no external engine is configured
+			s.logger.Warn("UCI engine requested but CHESS_AI_UCI_PATH is not set, falling back to random")
+			aiEngine = ai.NewRandomAI()
+		}
 	default:
 		aiEngine = ai.NewRandomAI()
 	}
 
 	aiEngine.SetDifficulty(difficulty)
+	targetElo := s.applyAdaptiveStrength(gameID, aiEngine)
 
-	// Bounded thinking time for AI computation.
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Bounded thinking time for AI computation. The search is traced as part of
+	// the request but finishes even if the client goes away.
+	ctx, cancel := context.WithTimeout(llmContext(context.WithoutCancel(c.Request.Context()), gameID), s.applySearchLimits(aiEngine, limits))
 	defer cancel()
 
-	// Serialize AI engine computation + potential future game mutation scope
+	// Serialize AI engine computation + potential future game mutation scope;
+	// the hybrid engine's reaction is generated after unlocking
+	unlock := func() {}
 	if lock != nil {
-		lock.Lock()
-		defer lock.Unlock()
+		if !s.requireGameLock(c, lock) {
+			return
+		}
+		unlock = sync.OnceFunc(lock.Unlock)
+		defer unlock()
+	}
+	if !s.requireActive(c, gameID) {
+		return
+	}
+	// Another request or an automatic reply may have moved while this one
+	// waited for the lock
+	if game.ActiveColor().String() != currentColor || len(game.MoveHistory()) != plies {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "game_changed", Message: "the game changed while the AI was asked to move; reload it and try again"})
+		return
 	}
 
-	// Get AI move (does not yet modify the game; separate call to makeMove endpoint will)
-	move, err := aiEngine.GetBestMove(ctx, game)
+	// Get AI move (played below only if the request applies it)
+	s.restoreLLMHistory(ctx, gameID, aiEngine)
+	move, info, err := ai.GetBestMoveWithInfo(ctx, aiEngine, game)
 	if err != nil {
 		s.logger.Error("AI move generation failed", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "ai_move_failed"})
 		return
 	}
+	s.saveLLMHistory(ctx, gameID, aiEngine)
+
+	// Let the AI resign, answer a draw offer or offer one itself
+	tracker := s.outcomeTracker(gameID)
+	decision := tracker.Record(game, info)
+	if req.OfferDraw && tracker.AcceptsDraw(game) {
+		_ = game.AgreeDraw()
+		s.clearDrawOffer(gameID)
+		s.finishIfOver(gameID, game)
+		s.logger.Info("AI accepted a draw offer", zap.Int("game_id", gameID))
+		c.JSON(http.StatusOK, map[string]interface{}{
+			"draw_accepted": true,
+			"engine":        req.Engine,
+			"game":          s.gameToResponse(gameID, game),
+		})
+		return
+	}
+	if decision == ai.OutcomeResign {
+		_ = game.Resign(game.ActiveColor())
+		s.finishIfOver(gameID, game)
+		s.logger.Info("AI resigned", zap.Int("game_id", gameID), zap.Int("score_cp", info.Score))
+		c.JSON(http.StatusOK, map[string]interface{}{
+			"resigned": true,
+			"engine":   req.Engine,
+			"game":     s.gameToResponse(gameID, game),
+		})
+		return
+	}
+	offersDraw := decision == ai.OutcomeOfferDraw && s.config.AI.DrawOffers
+	if offersDraw {
+		s.setDrawOffer(gameID, game.ActiveColor())
+	}
 
 	// Convert move to response format
 	moveResp := s.moveToResponse(move)
@@ -482,24 +1202,18 @@ func (s *Server) getAIMove(c *gin.Context) {
 	evalCp := game.Evaluate()
 	eval := float64(evalCp) / 100.0
 
-	// Evaluate position after suggested move via FEN reconstruction (avoids needing unexported copy())
+	// Evaluate position after suggested move on a clone, which keeps the repetition history
 	var evalAfterCp int
 	var evalAfter float64
-	fen := game.ToFEN()
-	tmp := engine.NewGame()
-	if err := tmp.ParseFEN(fen); err == nil {
-		if parsed, err2 := tmp.ParseMove(move.String()); err2 == nil {
-			if err3 := tmp.MakeMove(parsed); err3 == nil {
-				evalAfterCp = tmp.Evaluate()
-				evalAfter = float64(evalAfterCp) / 100.0
-			}
-		}
+	if tmp := game.Clone(); tmp.MakeMove(move) == nil {
+		evalAfterCp = tmp.Evaluate()
+		evalAfter = float64(evalAfterCp) / 100.0
 	}
 
 	evalDiffCp := evalAfterCp - evalCp
 	evalDiff := float64(evalDiffCp) / 100.0
 
-	c.JSON(http.StatusOK, map[string]interface{}{
+	response := map[string]interface{}{
 		"move":                moveResp,
 		"notation":            move.String(),
 		"level":               req.Level,
@@ -511,14 +1225,87 @@ func (s *Server) getAIMove(c *gin.Context) {
 		"evaluation_after_cp": evalAfterCp,
 		"evaluation_diff":     evalDiff,
 		"evaluation_diff_cp":  evalDiffCp,
-	})
+		"search":              searchInfoResponse(info),
+		"draw_offer":          offersDraw,
+	}
+	if targetElo > 0 {
+		response["target_elo"] = targetElo
+	}
+	if req.OfferDraw {
+		response["draw_accepted"] = false
+	}
+	hybrid, reacts := aiEngine.(*ai.HybridEngine)
+	var reactTo *engine.Game
+	if reacts {
+		reactTo = game.Clone()
+	}
+	if req.Apply && !s.applyAIMove(c, gameID, game, move, response) {
+		return
+	}
+	unlock()
+	if reacts {
+		if reaction := s.hybridReaction(gameID, hybrid, reactTo); reaction != "" {
+			response["reaction"] = reaction
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// uciEngine takes a UCI engine from the pool for a request, waiting up to the
+// longest think time for one to be free; it writes 503 engine_busy if none is.
+// The caller puts the engine back.
+func (s *Server) uciEngine(c *gin.Context, difficulty ai.Difficulty) (*ai.UCIEngine, bool) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.config.AI.MaxThinkTime)
+	defer cancel()
+	uciEngine, err := s.uciPool.Get(ctx, difficulty)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "engine_busy", Message: "every UCI engine is busy; try again"})
+		return nil, false
+	}
+	return uciEngine, true
+}
+
+// applyAIMove plays the AI's move in a game, answered by any conditional reply
+// of the player, and adds the game after it to the ai-move response. It writes
+// an error response if the move cannot be played. The caller must hold the
+// per-game lock.
+func (s *Server) applyAIMove(c *gin.Context, gameID int, game *engine.Game, move engine.Move, response map[string]interface{}) bool {
+	reply, err := s.playAIMoveLocked(gameID, game, move)
+	if err != nil {
+		s.logger.Error("AI move could not be played", zap.Int("game_id", gameID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "ai_move_failed", Message: err.Error()})
+		return false
+	}
+
+	state := s.gameToResponse(gameID, game)
+	if reply != nil {
+		replyResp := s.moveToResponse(*reply)
+		state.ConditionalReply = &replyResp
+	}
+	response["applied"] = true
+	response["game"] = state
+	return true
+}
+
+// playAIMoveLocked plays the AI's move in a game and any conditional reply of
+// the player to it, which it returns. The caller must hold the per-game lock.
+func (s *Server) playAIMoveLocked(gameID int, game *engine.Game, move engine.Move) (*engine.Move, error) {
+	mover := game.ActiveColor()
+	if err := game.MakeMove(move); err != nil {
+		return nil, err
+	}
+	s.logger.Info("AI move played", zap.Int("game_id", gameID), zap.String("move", move.String()))
+	s.declineDrawByMoving(gameID, mover)
+	s.withdrawTakeback(gameID)
+	reply := s.applyConditionalMoves(gameID, game, move)
+	s.finishIfOver(gameID, game)
+	return reply, nil
 }
 
 // getAIHint gets a move suggestion from the AI without making the move.
 func (s *Server) getAIHint(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
@@ -553,6 +1340,20 @@ func (s *Server) getAIHint(c *gin.Context) {
 		difficulty = ai.DifficultyMedium
 	}
 
+	limits, ok := s.searchLimits(c, req)
+	if !ok {
+		return
+	}
+	if !s.checkLLMOverrides(c, req.Provider, req.Model, req.Temperature) || !checkLanguage(c, req.Language) {
+		return
+	}
+	if _, ok := lookupPersonality(c, req.Personality); !ok {
+		return
+	}
+	if req.Personality == "" {
+		req.Personality = s.gamePersonality(gameID)
+	}
+
 	// Create AI engine
 	var aiEngine ai.Engine
 	switch req.Engine {
@@ -564,14 +1365,32 @@ func (s *Server) getAIHint(c *gin.Context) {
 				s.logger.Warn("Failed to create LLM AI engine, falling back to random", zap.Error(err))
 				aiEngine = ai.NewRandomAI()
 			} else {
+				s.configureLLMEngine(llmEngine, req)
 				aiEngine = llmEngine
 			}
 		} else {
 			// Fallback to random if LLM not available
 			aiEngine = ai.NewRandomAI()
 		}
+	case "hybrid":
+		aiEngine = s.newHybridEngine(req, difficulty)
 	case "minimax":
-		aiEngine = ai.NewMinimaxAI(difficulty)
+		aiEngine = s.newMinimaxAI(difficulty)
+	case "mcts":
+		aiEngine = s.newMCTSEngine(difficulty)
+	case "uci":
+		if s.uciPool != nil {
+			uciEngine, ok := s.uciEngine(c, difficulty)
+			if !ok {
+				return
+			}
+			defer s.uciPool.Put(uciEngine)
+			aiEngine = uciEngine
+		} else {
+			// Fallback to random if no external engine is configured
+			s.logger.Warn("UCI engine requested but CHESS_AI_UCI_PATH is not set, falling back to random")
+			aiEngine = ai.NewRandomAI()
+		}
 	default:
 		aiEngine = ai.NewRandomAI()
 	}
@@ -579,17 +1398,29 @@ func (s *Server) getAIHint(c *gin.Context) {
 	aiEngine.SetDifficulty(difficulty)
 
 	// Get the best move suggestion (without making it)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(llmContext(context.WithoutCancel(c.Request.Context()), gameID), s.applySearchLimits(aiEngine, limits))
 	defer cancel()
 
 	var bestMove engine.Move
-	if lock != nil {
-		lock.Lock()
+	var lines []ai.Line
+	var info ai.SearchInfo
+	var err error
+	// Search and explain a copy so that moves need not wait for the hint
+	if lock != nil && !s.requireGameLock(c, lock) {
+		return
 	}
-	bestMove, err = aiEngine.GetBestMove(ctx, game)
+	game = game.Clone()
 	if lock != nil {
 		lock.Unlock()
 	}
+	if analyzer, ok := aiEngine.(ai.Analyzer); ok {
+		lines, info, err = ai.AnalyzeLines(ctx, analyzer, game, clampPVLines(req.Lines))
+		if err == nil {
+			bestMove = lines[0].Moves[0]
+		}
+	} else {
+		bestMove, info, err = ai.GetBestMoveWithInfo(ctx, aiEngine, game)
+	}
 	if err != nil {
 		// Fallback: instead of pseudo-random time-based move (non-deterministic), return explicit no-hint
 		c.JSON(http.StatusServiceUnavailable, map[string]interface{}{
@@ -608,25 +1439,22 @@ func (s *Server) getAIHint(c *gin.Context) {
 
 	var afterEvalCp int
 	var afterEval float64
-	fen := game.ToFEN()
-	tmp := engine.NewGame()
-	if err := tmp.ParseFEN(fen); err == nil {
-		if parsed, err2 := tmp.ParseMove(bestMove.String()); err2 == nil {
-			if err3 := tmp.MakeMove(parsed); err3 == nil {
-				afterEvalCp = tmp.Evaluate()
-				afterEval = float64(afterEvalCp) / 100.0
-			}
-		}
+	if tmp := game.Clone(); tmp.MakeMove(bestMove) == nil {
+		afterEvalCp = tmp.Evaluate()
+		afterEval = float64(afterEvalCp) / 100.0
 	}
 
 	evalDiffCp := afterEvalCp - currentEvalCp
 	evalDiff := float64(evalDiffCp) / 100.0
 
+	explanation, explanationSource := s.explainHint(gameID, req, difficulty, game, bestMove, lines)
+
 	// Return the hint without making the move
 	hintResponse := map[string]interface{}{
 		"from":                bestMove.From.String(),
 		"to":                  bestMove.To.String(),
-		"explanation":         fmt.Sprintf("AI suggests moving from %s to %s", bestMove.From.String(), bestMove.To.String()),
+		"explanation":         explanation,
+		"explanation_source":  explanationSource,
 		"level":               req.Level,
 		"engine":              req.Engine,
 		"evaluation":          currentEval,
@@ -635,16 +1463,20 @@ func (s *Server) getAIHint(c *gin.Context) {
 		"evaluation_after_cp": afterEvalCp,
 		"evaluation_diff":     evalDiff,
 		"evaluation_diff_cp":  evalDiffCp,
+		"search":              searchInfoResponse(info),
+	}
+	if len(lines) > 0 {
+		hintResponse["pv"] = pvResponse(game, lines)
 	}
 
 	c.JSON(http.StatusOK, hintResponse)
 }
 
-// getLegalMoves gets all legal moves for the current position.
+// getLegalMoves gets all legal moves for the current position, or with ?ply=
+// for the position after that many plies.
 func (s *Server) getLegalMoves(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
@@ -656,6 +1488,9 @@ func (s *Server) getLegalMoves(c *gin.Context) {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
 		return
 	}
+	if game, ok = positionParam(c, game, "ply"); !ok {
+		return
+	}
 
 	// Generate all legal moves for the current position
 	legalMoves := s.generateAllLegalMoves(game)
@@ -679,16 +1514,12 @@ func (s *Server) generateAllLegalMoves(game *engine.Game) []engine.Move {
 
 // loadFromFEN loads a game position from FEN notation.
 func (s *Server) loadFromFEN(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
-	var req struct {
-		FEN string `json:"fen"`
-	}
-
+	var req FENRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
 		return
@@ -706,41 +1537,65 @@ func (s *Server) loadFromFEN(c *gin.Context) {
 	}
 
 	if lock != nil {
-		lock.Lock()
-	}
-	err = game.ParseFEN(req.FEN)
-	if lock != nil {
-		lock.Unlock()
+		if !s.requireGameLock(c, lock) {
+			return
+		}
+		defer lock.Unlock()
 	}
-	if err != nil {
+	// Validate before the state check so malformed input is always a 400
+	if err := engine.NewGame().ParseFEN(req.FEN); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_fen", Message: err.Error()})
 		return
 	}
+	if !s.requireActive(c, gameID) || !s.requirePlayerTurn(c, gameID, game) {
+		return
+	}
+	_ = game.ParseFEN(req.FEN)
+	s.resetOutcome(gameID)
+	s.finishIfOver(gameID, game)
 
 	// Return updated game state
 	response := s.gameToResponse(gameID, game)
 	c.JSON(http.StatusOK, response)
 }
 
-// analyzePosition analyzes the current position.
+// analyzePosition analyzes the current position, or with ?ply= the position
+// after that many plies: material, mobility and evaluation terms, and an engine
+// search limited by ?depth= and ?movetime= (see engineAnalysis).
 func (s *Server) analyzePosition(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
 	s.gamesMux.RLock()
 	game, exists := s.games[gameID]
+	lock := s.gameLocks[gameID]
 	s.gamesMux.RUnlock()
 
 	if !exists {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
 		return
 	}
+	limits, ok := s.analysisLimits(c)
+	if !ok {
+		return
+	}
+	// Analyze a copy so that moves need not wait for the analysis
+	if lock != nil && !s.requireGameLock(c, lock) {
+		return
+	}
+	game = game.Clone()
+	if lock != nil {
+		lock.Unlock()
+	}
+	if game, ok = positionParam(c, game, "ply"); !ok {
+		return
+	}
 
 	// Basic position analysis + material & mobility
-	evalCp := game.Evaluate() // centipawns from White perspective
+	breakdown := game.EvaluateBreakdown()
+	evalCp := breakdown.Total // centipawns from White perspective
 	eval := float64(evalCp) / 100.0
 
 	// Material breakdown
@@ -784,7 +1639,17 @@ func (s *Server) analyzePosition(c *gin.Context) {
 			"white": white,
 			"black": black,
 		},
-		"mobility": mobility,
+		"mobility":             mobility,
+		"evaluation_breakdown": evaluationBreakdownResponse(breakdown),
+		"material_signature":   game.MaterialSignature(),
+		"endgame":              breakdown.Class.String(),
+	}
+	if adv := drawAdvisoryResponse(game); adv != nil {
+		analysis["draw_advisory"] = adv
+	}
+	if !game.IsGameOver() {
+		lineCount, _ := strconv.Atoi(c.Query("lines"))
+		s.engineAnalysis(c.Request.Context(), gameID, game, limits, lineCount, analysis)
 	}
 
 	c.JSON(http.StatusOK, analysis)
@@ -792,99 +1657,224 @@ func (s *Server) analyzePosition(c *gin.Context) {
 
 // getPGN exports the game in PGN format.
 func (s *Server) getPGN(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
 	s.gamesMux.RLock()
 	game, exists := s.games[gameID]
-	metadata := s.gameMetadata[gameID]
 	s.gamesMux.RUnlock()
 	if !exists {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
 		return
 	}
 
-	// Basic Seven Tag Roster + optional SetUp/FEN if non-initial
+	var summary string
+	if c.Query("summary") == "true" {
+		summary = s.pgnSummary(c, gameID, game)
+	}
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.String(http.StatusOK, s.gamePGNExport(gameID, game, summary))
+}
+
+// pgnTags returns the PGN tag pairs of a game, as name and value: the Seven Tag
+// Roster and more, with SetUp/FEN if it did not start from the initial position.
+func (s *Server) pgnTags(gameID int, game *engine.Game) [][2]string {
+	s.gamesMux.RLock()
+	metadata := s.gameMetadata[gameID]
+	s.gamesMux.RUnlock()
+
 	created := time.Now().UTC()
 	if metadata != nil {
 		created = metadata.CreatedAt
 	}
 	dateStr := created.Format("2006.01.02")
 
-	// Determine result string
-	result := pgnResultString(game)
+	// Determine result and termination
+	outcome := game.Result()
+	result := outcome.String()
 
 	// Detect non-initial starting position using internal flag
 	gameFEN := game.ToFEN()
 	nonInitial := game.StartedFromFEN()
 
 	// Determine player names based on AI color
+	event := "Casual Game"
 	whiteName := "Player"
 	blackName := "AI"
 	if metadata != nil && metadata.AIColor == "white" {
 		whiteName = "AI"
 		blackName = "Player"
 	}
+	if metadata.isTwoPlayer() {
+		whiteName, blackName = "White", "Black"
+	}
+	if metadata != nil && metadata.Exhibition != nil {
+		event = "LLM Exhibition"
+		whiteName = metadata.Exhibition.White
+		blackName = metadata.Exhibition.Black
+	}
 
-	tags := []string{
-		"[Event \"Casual Game\"]",
-		"[Site \"Localhost\"]",
-		fmt.Sprintf("[Date \"%s\"]", dateStr),
-		"[Round \"-\"]",
-		fmt.Sprintf("[White \"%s\"]", whiteName),
-		fmt.Sprintf("[Black \"%s\"]", blackName),
-		fmt.Sprintf("[Result \"%s\"]", result),
-		"[Variant \"Standard\"]",
-		"[Annotator \"js-chess\"]",
+	tags := [][2]string{
+		{"Event", event},
+		{"Site", "Localhost"},
+		{"Date", dateStr},
+		{"Round", "-"},
+		{"White", whiteName},
+		{"Black", blackName},
+		{"Result", result},
+		{"Termination", outcome.PGNTermination()},
+		{"Variant", pgnVariantName(game.Variant())},
+		{"Annotator", "js-chess"},
+	}
+	if clock := game.Clock(); clock != nil {
+		tags = append(tags, [2]string{"TimeControl", clock.Control().String()})
 	}
 	if nonInitial {
-		tags = append(tags, "[SetUp \"1\"]")
-		tags = append(tags, fmt.Sprintf("[FEN \"%s\"]", gameFEN))
+		tags = append(tags, [2]string{"SetUp", "1"}, [2]string{"FEN", gameFEN})
 	}
+	return tags
+}
 
-	// Build movetext using SAN
+// pgnMovetext returns a game's moves in SAN with their clock comments and
+// annotations, without the result. The comments for a ply follow its move;
+// those for ply 0 come before the first move.
+func pgnMovetext(game *engine.Game, comments map[int][]string) string {
 	sanMoves := game.GenerateSAN()
+	timings := game.MoveTimings()
+	annotations := game.Annotations()
 	var movetext string
+	commented := func(ply int) bool {
+		return len(comments[ply]) > 0 || ply > 0 && !annotations[ply-1].IsZero()
+	}
+	for _, comment := range comments[0] {
+		movetext += engine.Annotation{Comment: comment}.PGN() + " "
+	}
 	for i, san := range sanMoves {
 		if i%2 == 0 { // white move number
 			movetext += fmt.Sprintf("%d. ", (i/2)+1)
+		} else if commented(i) {
+			movetext += fmt.Sprintf("%d... ", (i/2)+1) // black move after commentary
 		}
 		movetext += san + " "
+		if i < len(timings) {
+			if comment := timings[i].PGNComment(engine.DefaultClockNotation); comment != "" {
+				movetext += "{" + comment + "} "
+			}
+		}
+		if i < len(annotations) && !annotations[i].IsZero() {
+			movetext += annotations[i].PGN() + " "
+		}
+		for _, comment := range comments[i+1] {
+			movetext += engine.Annotation{Comment: comment}.PGN() + " "
+		}
+	}
+	return movetext
+}
+
+// searchLimits validates an AI request's search limit overrides against the
+// configured caps, writing an error response on failure.
+func (s *Server) searchLimits(c *gin.Context, req AIRequest) (ai.SearchLimits, bool) {
+	caps := s.config.AI
+	depth, nodes, moveTimeMs := deref(req.MaxDepth), deref(req.MaxNodes), deref(req.MoveTimeMs)
+	var problem string
+	switch {
+	case req.MaxDepth != nil && (depth < 1 || depth > caps.MaxDepth):
+		problem = fmt.Sprintf("max_depth must be between 1 and %d", caps.MaxDepth)
+	case req.MaxNodes != nil && (nodes < 1 || nodes > caps.MaxNodes):
+		problem = fmt.Sprintf("max_nodes must be between 1 and %d", caps.MaxNodes)
+	case req.MoveTimeMs != nil && (moveTimeMs < 1 || time.Duration(moveTimeMs)*time.Millisecond > caps.MaxThinkTime):
+		problem = fmt.Sprintf("movetime_ms must be between 1 and %d", caps.MaxThinkTime.Milliseconds())
+	}
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_search_limits", Message: problem})
+		return ai.SearchLimits{}, false
+	}
+	return ai.SearchLimits{
+		Depth:    depth,
+		Nodes:    nodes,
+		MoveTime: time.Duration(moveTimeMs) * time.Millisecond,
+	}, true
+}
+
+// applySearchLimits hands the limits to engines that take them and returns how
+// long the search may run: the move time for other engines, else the server's
+// maximum think time.
+func (s *Server) applySearchLimits(aiEngine ai.Engine, limits ai.SearchLimits) time.Duration {
+	if limiter, ok := aiEngine.(ai.Limiter); ok {
+		limiter.SetLimits(limits)
+	} else if limits.MoveTime > 0 {
+		return limits.MoveTime
 	}
-	movetext += result
+	return s.config.AI.MaxThinkTime
+}
+
+// clampPVLines limits a requested multi-PV count to 1..maxPVLines.
+func clampPVLines(n int) int {
+	return min(max(n, 1), maxPVLines)
+}
 
-	pgn := ""
-	for _, t := range tags {
-		pgn += t + "\n"
+// pvResponse converts search lines to SAN with scores from White's perspective.
+func pvResponse(game *engine.Game, lines []ai.Line) []PVLineResponse {
+	sign := 1
+	if game.ActiveColor() == engine.Black {
+		sign = -1
+	}
+	resp := make([]PVLineResponse, 0, len(lines))
+	for _, line := range lines {
+		san, _ := game.SANLine(line.Moves)
+		resp = append(resp, PVLineResponse{
+			Moves:   san,
+			Score:   float64(sign*line.Score) / 100.0,
+			ScoreCp: sign * line.Score,
+			Mate:    sign * line.MateIn(),
+		})
 	}
-	pgn += "\n" + movetext + "\n"
+	return resp
+}
 
-	c.Header("Content-Type", "text/plain; charset=utf-8")
-	c.String(http.StatusOK, pgn)
-}
-
-// pgnResultString maps internal status to PGN termination markers.
-func pgnResultString(game *engine.Game) string {
-	switch game.Status() {
-	case engine.WhiteWins:
-		return "1-0"
-	case engine.BlackWins:
-		return "0-1"
-	case engine.Draw:
-		return "1/2-1/2"
+// evaluationBreakdownResponse converts an engine evaluation breakdown.
+func evaluationBreakdownResponse(eb engine.EvaluationBreakdown) EvaluationBreakdownResponse {
+	term := func(t engine.PawnTerm) PawnTermResponse {
+		return PawnTermResponse{White: t.White, Black: t.Black, Score: t.Score}
+	}
+	return EvaluationBreakdownResponse{
+		Material: eb.Material,
+		Center:   eb.Center,
+		Pawns: map[string]PawnTermResponse{
+			"doubled":  term(eb.Pawns.Doubled),
+			"isolated": term(eb.Pawns.Isolated),
+			"backward": term(eb.Pawns.Backward),
+			"passed":   term(eb.Pawns.Passed),
+			"chain":    term(eb.Pawns.Chain),
+			"shield":   term(eb.Pawns.Shield),
+		},
+		Endgame: eb.Endgame,
+		Total:   eb.Total,
+	}
+}
+
+// illegalMoveResponse reports why a move was rejected.
+func illegalMoveResponse(err *engine.IllegalMoveError) ErrorResponse {
+	return ErrorResponse{Error: "illegal_move", Message: err.Error(), Reason: err.Reason.String()}
+}
+
+// pgnVariantName maps an engine variant to its PGN Variant tag value.
+func pgnVariantName(v engine.Variant) string {
+	switch v {
+	case engine.Crazyhouse:
+		return "Crazyhouse"
 	default:
-		return "*" // in progress or check
+		return "Standard"
 	}
 }
 
 // handleWebSocket handles WebSocket connections for real-time game updates.
 func (s *Server) handleWebSocket(c *gin.Context) {
-	gameID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_game_id"})
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
 		return
 	}
 
@@ -896,6 +1886,12 @@ func (s *Server) handleWebSocket(c *gin.Context) {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
 		return
 	}
+	userID, ok := requestUserID(c)
+	if !ok {
+		return
+	}
+	token := requestPlayerToken(c)
+	spectator := s.wsSpectator(gameID, token, c.Query("spectator") == "true")
 
 	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -903,29 +1899,24 @@ func (s *Server) handleWebSocket(c *gin.Context) {
 		return
 	}
 	defer conn.Close()
+	keepAlive(conn)
 
-	// Send initial game state
-	response := s.gameToResponse(gameID, game)
-	if err := conn.WriteJSON(response); err != nil {
-		s.logger.Error("Failed to send initial game state", zap.Error(err))
-		return
-	}
+	// All writes go through the client's queue, which starts with the game
+	// state; game events are broadcast to it
+	client, unsubscribe := s.subscribe(gameID, spectator, s.gameToResponse(gameID, game))
+	defer unsubscribe()
+	go client.writePump(conn, s.logger)
 
-	// Keep connection alive and handle messages
+	// Keep connection alive and handle messages one at a time
 	for {
-		var msg map[string]interface{}
-		if err := conn.ReadJSON(&msg); err != nil {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				s.logger.Error("WebSocket error", zap.Error(err))
 			}
 			break
 		}
-
-		// Echo the message back (placeholder for game update handling)
-		if err := conn.WriteJSON(msg); err != nil {
-			s.logger.Error("Failed to send WebSocket message", zap.Error(err))
-			break
-		}
+		s.handleWSMessage(c.Request.Context(), gameID, game, userID, chatLimitKey(c), token, client, data)
 	}
 }
 
@@ -939,24 +1930,43 @@ func (s *Server) health(c *gin.Context) {
 	s.gamesMux.RUnlock()
 
 	// NOTE: Update version when releasing; aligns with root project Option A tasks
-	c.JSON(http.StatusOK, map[string]interface{}{
+	response := map[string]interface{}{
 		"status":     "healthy",
 		"timestamp":  time.Now().UTC(),
 		"version":    APIVersion,
 		"game_count": gameCount,
-	})
+	}
+	if cache, ok := s.evaluator.(*ai.EvalCache); ok {
+		stats := cache.Stats()
+		response["eval_cache"] = map[string]interface{}{
+			"size":   stats.Size,
+			"hits":   stats.Hits,
+			"misses": stats.Misses,
+		}
+	}
+	if s.llmCache != nil {
+		stats := s.llmCache.Stats()
+		response["llm_cache"] = map[string]interface{}{
+			"size":   stats.Size,
+			"hits":   stats.Hits,
+			"misses": stats.Misses,
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // Helper methods
 
 // gameToResponse converts a game to API response format.
 func (s *Server) gameToResponse(id int, game *engine.Game) GameResponse {
-	history := game.MoveHistory()
-	moves := make([]MoveResponse, len(history))
+	s.gamesMux.RLock()
+	defer s.gamesMux.RUnlock()
+	return s.gameToResponseLocked(id, game)
+}
 
-	for i, move := range history {
-		moves[i] = s.moveToResponse(move)
-	}
+// gameToResponseLocked is gameToResponse for callers holding gamesMux.
+func (s *Server) gameToResponseLocked(id int, game *engine.Game) GameResponse {
+	moves := s.moveHistoryResponse(game)
 
 	// Get AI color from metadata
 	aiColor := "black" // Default
@@ -964,23 +1974,164 @@ func (s *Server) gameToResponse(id int, game *engine.Game) GameResponse {
 		aiColor = metadata.AIColor
 	}
 
-	// Get creation time from metadata
+	// Get creation time and lifecycle state from metadata
 	createdAt := time.Now().UTC()
+	lifecycle := ""
+	drawOffer := ""
+	var takeback *TakebackRequest
+	var adaptive *AdaptiveResponse
+	var exhibition *ExhibitionInfo
+	personality := ""
+	autoCommentary := false
+	autoAI, aiEngine, aiLevel := false, "", ""
+	gameUUID, owner := "", ""
+	var players, joined []string
 	if metadata, exists := s.gameMetadata[id]; exists {
+		gameUUID, owner, players, joined = metadata.UUID, metadata.Owner, metadata.Players, metadata.Joined
 		createdAt = metadata.CreatedAt
+		lifecycle = string(metadata.Lifecycle)
+		drawOffer = metadata.DrawOffer
+		takeback = metadata.Takeback
+		if metadata.adaptive != nil {
+			adaptive = adaptiveToResponse(metadata.adaptive)
+		}
+		exhibition = metadata.Exhibition
+		personality = metadata.Personality
+		autoCommentary = metadata.AutoCommentary
+		autoAI, aiEngine, aiLevel = metadata.AutoAI, metadata.AIEngine, metadata.AILevel
+	}
+
+	response := GameResponse{
+		ID:             id,
+		UUID:           gameUUID,
+		Owner:          owner,
+		Players:        players,
+		Joined:         joined,
+		Status:         game.Status().String(),
+		Lifecycle:      lifecycle,
+		DrawOffer:      drawOffer,
+		Takeback:       takeback,
+		ActiveColor:    game.ActiveColor().String(),
+		AIColor:        aiColor,
+		Variant:        game.Variant().String(),
+		Board:          game.Board().String(),
+		FEN:            game.ToFEN(),
+		MoveCount:      game.MoveCount(),
+		HalfMoveClock:  game.HalfMoveClock(),
+		MoveHistory:    moves,
+		Adaptive:       adaptive,
+		Exhibition:     exhibition,
+		Personality:    personality,
+		AutoCommentary: autoCommentary,
+		AutoAI:         autoAI,
+		AIEngine:       aiEngine,
+		AILevel:        aiLevel,
+		CreatedAt:      createdAt,
+	}
+	if s.usesUUIDs() {
+		response.ID = 0 // games are reached by UUID only
+	}
+	if sq, ok := game.EnPassantSquare(); ok {
+		response.EnPassant = sq.String()
+	}
+
+	if outcome := game.Result(); outcome.Termination != engine.TerminationNone {
+		response.Termination = outcome.Termination.String()
+		if outcome.Winner != engine.None {
+			response.Winner = outcome.Winner.String()
+		}
+	}
+	if reason := game.DrawReason(); reason != engine.NoDraw {
+		response.DrawReason = reason.String()
+	}
+	for _, claim := range game.ClaimableDraws() {
+		response.ClaimableDraws = append(response.ClaimableDraws, claim.String())
 	}
 
-	return GameResponse{
-		ID:          id,
-		Status:      game.Status().String(),
-		ActiveColor: game.ActiveColor().String(),
-		AIColor:     aiColor,
-		Board:       game.Board().String(),
-		FEN:         game.ToFEN(),
-		MoveCount:   game.MoveCount(),
-		MoveHistory: moves,
-		CreatedAt:   createdAt,
+	if adv := drawAdvisoryResponse(game); adv != nil {
+		response.Advisories = append(response.Advisories, *adv)
 	}
+
+	response.Clock = clockResponse(game)
+
+	if game.Variant() == engine.Crazyhouse {
+		response.Pockets = map[string]map[string]int{
+			engine.White.String(): pocketToResponse(game.Pocket(engine.White)),
+			engine.Black.String(): pocketToResponse(game.Pocket(engine.Black)),
+		}
+	}
+
+	return response
+}
+
+// drawAdvisoryResponse returns a consider_draw advisory if the game looks dead drawn.
+func drawAdvisoryResponse(game *engine.Game) *AdvisoryResponse {
+	adv := game.DrawAdvisory(engine.DefaultDrawAdvisoryOptions())
+	if !adv.ConsiderDraw {
+		return nil
+	}
+	return &AdvisoryResponse{
+		Type:        "consider_draw",
+		Reasons:     adv.Reasons,
+		Evaluation:  float64(adv.Evaluation) / 100.0,
+		QuietPlies:  adv.QuietPlies,
+		Repetitions: adv.Repetitions,
+	}
+}
+
+// pocketToResponse converts a Crazyhouse pocket to a piece-name -> count map.
+func pocketToResponse(p engine.Pocket) map[string]int {
+	counts := make(map[string]int)
+	for _, pt := range []engine.PieceType{engine.Pawn, engine.Knight, engine.Bishop, engine.Rook, engine.Queen} {
+		counts[pt.String()] = p.Count(pt)
+	}
+	return counts
+}
+
+// clockResponse returns the clocks of a timed game, or nil.
+func clockResponse(game *engine.Game) *ClockResponse {
+	clock := game.Clock()
+	if clock == nil {
+		return nil
+	}
+	response := &ClockResponse{
+		TimeControl: clock.Control().String(),
+		WhiteMs:     clock.Remaining(engine.White).Milliseconds(),
+		BlackMs:     clock.Remaining(engine.Black).Milliseconds(),
+	}
+	if clock.Running() {
+		response.Running = game.ActiveColor().String()
+	}
+	return response
+}
+
+// moveHistoryResponse converts the game's move history, including clock data for timed
+// games and when each move was played.
+func (s *Server) moveHistoryResponse(game *engine.Game) []MoveResponse {
+	history := game.MoveHistory()
+	timings := game.MoveTimings()
+	annotations := game.Annotations()
+	moves := make([]MoveResponse, len(history))
+	for i, move := range history {
+		moves[i] = s.moveToResponse(move)
+		if i < len(timings) && timings[i].HasClock {
+			ms := timings[i].Clock.Milliseconds()
+			moves[i].ClockMs = &ms
+		}
+		if i < len(timings) && !timings[i].PlayedAt.IsZero() {
+			playedAt := timings[i].PlayedAt.UTC()
+			moves[i].PlayedAt = &playedAt
+		}
+		if i < len(timings) && timings[i].HasElapsed {
+			ms := timings[i].Elapsed.Milliseconds()
+			moves[i].ThinkMs = &ms
+		}
+		if i < len(annotations) {
+			moves[i].Comment = annotations[i].Comment
+			moves[i].NAGs = annotations[i].NAGs
+		}
+	}
+	return moves
 }
 
 // moveToResponse converts a move to API response format.
@@ -1009,6 +2160,7 @@ type ReactionRequest struct {
 	Move     string `json:"move"`
 	Provider string `json:"provider,omitempty"` // LLM provider to use
 	APIKey   string `json:"api_key,omitempty"`  // Custom API key for this request
+	Language string `json:"language,omitempty"` // Reply language; the game's chat language if empty
 }
 
 // ReactionResponse represents the AI's reaction to a move
@@ -1020,9 +2172,8 @@ type ReactionResponse struct {
 
 // chatWithAI handles chat requests with the AI
 func (s *Server) chatWithAI(c *gin.Context) {
-	gameIDStr := c.Param("id")
-	gameID, err := strconv.Atoi(gameIDStr)
-	if err != nil {
+	gameID, ok := s.resolveGameID(c.Param("id"))
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
 		return
 	}
@@ -1048,9 +2199,60 @@ func (s *Server) chatWithAI(c *gin.Context) {
 		return
 	}
 
-	// Create enhanced move context from current game state
+	provider, ok := s.chatProvider(c, req)
+	if !ok {
+		return
+	}
+	userID, ok := requestUserID(c)
+	if !ok {
+		return
+	}
+
+	// Generate chat response using the chat service
+	req.limitKey = chatLimitKey(c)
+	response, err := s.chat(context.WithoutCancel(c.Request.Context()), gameID, game, userID, req, provider, nil)
+	if writeChatLimit(c, err) || writeChatRejected(c, err) {
+		return
+	}
+	if errors.Is(err, errGameBusy) {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "game_busy", Message: errGameBusy.Error()})
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to get chat response", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get AI response: %v", err)})
+		return
+	}
+
+	c.JSON(200, ChatResponse{
+		Response:    response.Message,
+		UserID:      userID,
+		Provider:    response.Personality, // Use the provider that was actually used
+		GameContext: response.GameContext,
+		Suggestions: response.Suggestions,
+		Filtered:    response.Filtered,
+	})
+}
+
+// chat sends a validated chat request of userID about a game to the chat
+// service, with the game's position as context. Non-nil onAccepted is called
+// with the message as stored once it is accepted. It fails with errGameBusy if the game
+// cannot be locked to copy it.
+func (s *Server) chat(ctx context.Context, gameID int, game *engine.Game, userID string, req ChatRequest, provider string, onAccepted func(chat.Message)) (*chat.ChatResponse, error) {
+	// Create enhanced move context from a copy of the game; finding the
+	// position's features and the reply take too long to hold its lock
 	var moveContext *chat.MoveContext
 	if game != nil {
+		s.gamesMux.RLock()
+		lock := s.gameLocks[gameID]
+		s.gamesMux.RUnlock()
+		if lock != nil {
+			if err := lockGame(lock); err != nil {
+				return nil, err
+			}
+			game = game.Clone()
+			lock.Unlock()
+		}
 		moveHistory := game.MoveHistory()
 		var lastMoveStr string
 		var capturedPiece string
@@ -1079,41 +2281,36 @@ func (s *Server) chatWithAI(c *gin.Context) {
 			LegalMoves:    legalMoveStrs,
 			InCheck:       game.Status() == engine.Check,
 			CapturedPiece: capturedPiece,
+			Features:      chat.PositionFeatures(ctx, game),
+		}
+		if req.Mode == chat.ModeCoach {
+			moveContext.CoachNotes = chat.CoachNotes(game)
 		}
 	}
 
 	// Create chat request for the service
 	chatReq := chat.ChatRequest{
-		GameID:   gameID,
-		Message:  req.Message,
-		UserID:   "player", // Default user ID
-		MoveData: moveContext,
-		Provider: req.Provider, // Pass through custom provider
-		APIKey:   req.APIKey,   // Pass through custom API key
-	}
-
-	// Generate chat response using the chat service
-	ctx := context.Background()
-	response, err := s.chatService.Chat(ctx, chatReq)
-	if err != nil {
-		s.logger.Error("Failed to get chat response", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get AI response: %v", err)})
-		return
-	}
-
-	c.JSON(200, ChatResponse{
-		Response:    response.Message,
-		Provider:    response.Personality, // Use the provider that was actually used
-		GameContext: response.GameContext,
-		Suggestions: response.Suggestions,
-	})
+		GameID:      gameID,
+		Message:     req.Message,
+		UserID:      userID,
+		MoveData:    moveContext,
+		Provider:    provider,   // Pass through custom provider
+		APIKey:      req.APIKey, // Pass through custom API key
+		Model:       req.Model,
+		Language:    req.Language,
+		Personality: req.Personality,
+		Mode:        req.Mode,
+		LimitKey:    req.limitKey,
+		OnAccepted:  onAccepted,
+	}
+
+	return s.chatService.Chat(llmContext(ctx, gameID), chatReq)
 }
 
 // getAIReaction handles requests for AI reactions to moves
 func (s *Server) getAIReaction(c *gin.Context) {
-	gameIDStr := c.Param("id")
-	gameID, err := strconv.Atoi(gameIDStr)
-	if err != nil {
+	gameID, ok := s.resolveGameID(c.Param("id"))
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
 		return
 	}
@@ -1140,15 +2337,24 @@ func (s *Server) getAIReaction(c *gin.Context) {
 	}
 
 	// Parse the move to validate it
-	_, err = game.ParseMove(req.Move)
+	_, err := game.ParseMove(req.Move)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid move format: %v", err)})
 		return
 	}
+	if !checkLanguage(c, req.Language) {
+		return
+	}
+	if req.Language != "" {
+		s.chatService.SetLanguage(gameID, req.Language)
+	}
 
 	// Generate reaction using the enhanced ReactToMove method
-	ctx := context.Background()
+	ctx := llmContext(context.WithoutCancel(c.Request.Context()), gameID)
 	response, err := s.chatService.ReactToMove(ctx, gameID, req.Move, game, req.Provider, req.APIKey)
+	if writeChatLimit(c, err) {
+		return
+	}
 	if err != nil {
 		s.logger.Error("Failed to get move reaction", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get AI reaction: %v", err)})
@@ -1175,19 +2381,36 @@ func (s *Server) generalChat(c *gin.Context) {
 		return
 	}
 
+	provider, ok := s.chatProvider(c, req)
+	if !ok {
+		return
+	}
+	userID, ok := requestUserID(c)
+	if !ok {
+		return
+	}
+
 	// Create chat request for general conversation
 	chatReq := chat.ChatRequest{
-		GameID:   0, // No game context
-		Message:  req.Message,
-		UserID:   "demo-user",
-		MoveData: nil,          // No move context
-		Provider: req.Provider, // Pass through custom provider
-		APIKey:   req.APIKey,   // Pass through custom API key
+		GameID:      0, // No game context
+		Message:     req.Message,
+		UserID:      userID,
+		MoveData:    nil,        // No move context
+		Provider:    provider,   // Pass through custom provider
+		APIKey:      req.APIKey, // Pass through custom API key
+		Model:       req.Model,
+		Language:    req.Language,
+		Personality: req.Personality,
+		Mode:        req.Mode,
+		LimitKey:    chatLimitKey(c),
 	}
 
 	// Generate response using the chat service
-	ctx := context.Background()
+	ctx := context.WithoutCancel(c.Request.Context())
 	response, err := s.chatService.Chat(ctx, chatReq)
+	if writeChatLimit(c, err) || writeChatRejected(c, err) {
+		return
+	}
 	if err != nil {
 		s.logger.Error("Failed to get chat response", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get AI response: %v", err)})
@@ -1196,8 +2419,10 @@ func (s *Server) generalChat(c *gin.Context) {
 
 	c.JSON(200, ChatResponse{
 		Response:    response.Message,
+		UserID:      userID,
 		Provider:    response.Personality,
 		GameContext: response.GameContext,
 		Suggestions: response.Suggestions,
+		Filtered:    response.Filtered,
 	})
 }
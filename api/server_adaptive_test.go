@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdaptiveGame(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("/api/games", `{"adaptive":true}`)
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+		t.Fatal(err)
+	}
+	if game.Adaptive == nil || game.Adaptive.Moves != 0 {
+		t.Fatalf("expected an adaptive game, got %s", rec.Body.String())
+	}
+	base := "/api/games/" + itoa(game.ID)
+
+	// Throwing the queen away leaves the player far behind, so the AI eases off
+	for _, m := range [][2]string{{"e2", "e4"}, {"e7", "e5"}, {"d1", "h5"}, {"g8", "f6"}} {
+		if rec := post(base+"/moves", `{"from":"`+m[0]+`","to":"`+m[1]+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("move %v: %d %s", m, rec.Code, rec.Body.String())
+		}
+	}
+	rec = post(base+"/moves", `{"from":"a2","to":"a3"}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+		t.Fatal(err)
+	}
+	if game.Adaptive == nil || game.Adaptive.Moves != 3 || game.Adaptive.EloOffset >= 0 || game.Adaptive.Eval > -150 {
+		t.Fatalf("expected the AI to ease off after the player dropped the queen, got %+v", game.Adaptive)
+	}
+	if game.Adaptive.Accuracy <= 0 || game.Adaptive.Accuracy >= 100 {
+		t.Errorf("expected a partial accuracy, got %v", game.Adaptive.Accuracy)
+	}
+
+	rec = post(base+"/ai-move", `{"engine":"minimax","level":"medium"}`)
+	var aiMove struct {
+		TargetElo int `json:"target_elo"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &aiMove); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("ai-move: %d %s", rec.Code, rec.Body.String())
+	}
+	if want := 1400 + game.Adaptive.EloOffset; aiMove.TargetElo != want {
+		t.Errorf("expected target Elo %d, got %d", want, aiMove.TargetElo)
+	}
+
+	// Ordinary games are not modelled
+	rec = post("/api/games", "")
+	if strings.Contains(rec.Body.String(), `"adaptive"`) {
+		t.Errorf("expected no adaptive state, got %s", rec.Body.String())
+	}
+}
@@ -2,14 +2,19 @@ package api
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.rumenx.com/chess/ai"
 	"go.rumenx.com/chess/config"
 )
 
@@ -85,11 +90,19 @@ func TestAnalyzePosition_Basic(t *testing.T) {
 	if err := json.Unmarshal(rec.Body.Bytes(), &data); err != nil {
 		t.Fatalf("unmarshal: %v", err)
 	}
-	for _, k := range []string{"status", "active_color", "move_count", "evaluation"} {
+	for _, k := range []string{"status", "active_color", "move_count", "evaluation", "evaluation_breakdown"} {
 		if _, ok := data[k]; !ok {
 			t.Fatalf("missing key %s in analysis", k)
 		}
 	}
+	breakdown := data["evaluation_breakdown"].(map[string]interface{})
+	pawns := breakdown["pawns"].(map[string]interface{})
+	if _, ok := pawns["passed"]; !ok {
+		t.Fatalf("missing passed pawn term in %v", breakdown)
+	}
+	if data["material_signature"] != "KQRRBBNNPPPPPPPPvKQRRBBNNPPPPPPPP" || data["endgame"] != "none" {
+		t.Fatalf("unexpected material classification: %v %v", data["material_signature"], data["endgame"])
+	}
 }
 
 // Test getAIHint fallback error path by requesting a hint; accept success or deterministic fallback.
@@ -115,3 +128,116 @@ func TestGetAIHint_FallbackOrSuccess(t *testing.T) {
 		t.Fatalf("hint request took unexpectedly long")
 	}
 }
+
+func TestGetAIHint_PrincipalVariations(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	body := []byte(`{"level":"easy","engine":"minimax","lines":2}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/ai-hint", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		From   string             `json:"from"`
+		To     string             `json:"to"`
+		PV     []PVLineResponse   `json:"pv"`
+		Search SearchInfoResponse `json:"search"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.PV) != 2 || len(resp.PV[0].Moves) == 0 {
+		t.Fatalf("expected two principal variations, got %+v", resp.PV)
+	}
+	if resp.PV[0].ScoreCp < resp.PV[1].ScoreCp {
+		t.Errorf("expected best line first for White: %+v", resp.PV)
+	}
+	if resp.Search.Depth != 2 || resp.Search.Nodes == 0 {
+		t.Errorf("expected search statistics for a depth 2 search, got %+v", resp.Search)
+	}
+}
+
+func TestGetAIHint_MCTS(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	body := []byte(`{"level":"beginner","engine":"mcts","lines":2}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/ai-hint", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		PV     []PVLineResponse   `json:"pv"`
+		Search SearchInfoResponse `json:"search"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.PV) != 2 || resp.Search.Nodes == 0 {
+		t.Fatalf("expected tree search lines and statistics, got %+v", resp)
+	}
+}
+
+func TestNewServer_EvalNetwork(t *testing.T) {
+	// A network with one neuron and all-zero weights evaluates every position as even
+	var file bytes.Buffer
+	file.WriteString("GCNN")
+	_ = binary.Write(&file, binary.LittleEndian, []uint32{1, 1})
+	_ = binary.Write(&file, binary.LittleEndian, make([]int16, 768+1+2))
+	_ = binary.Write(&file, binary.LittleEndian, int32(0))
+	path := filepath.Join(t.TempDir(), "zero.nnue")
+	if err := os.WriteFile(path, file.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The evaluation cache wraps whichever evaluation is used
+	evaluator := func(s *Server) ai.Evaluator {
+		if cache, ok := s.evaluator.(*ai.EvalCache); ok {
+			return cache.Evaluator()
+		}
+		return s.evaluator
+	}
+	cfg := config.Default()
+	cfg.AI.EvalNetwork = path
+	if _, ok := evaluator(NewServer(cfg)).(*ai.NNUE); !ok {
+		t.Errorf("expected the configured network to be used")
+	}
+	cfg.AI.EvalNetwork = filepath.Join(t.TempDir(), "missing.nnue")
+	if _, ok := evaluator(NewServer(cfg)).(*ai.NNUE); ok {
+		t.Errorf("expected the classical evaluation when the network fails to load")
+	}
+}
+
+func TestAnalyzePosition_PrincipalVariation(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/fen",
+		bytes.NewBufferString(`{"fen":"k7/8/2K5/8/8/8/8/7R w - - 0 1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("load FEN: %d %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/games/"+itoa(id)+"/analysis", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var data struct {
+		PV []PVLineResponse `json:"pv"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &data); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(data.PV) != 1 || data.PV[0].Mate != 2 || len(data.PV[0].Moves) != 3 {
+		t.Fatalf("expected a mate-in-2 principal variation, got %+v", data.PV)
+	}
+	if last := data.PV[0].Moves[2]; !strings.HasSuffix(last, "#") {
+		t.Errorf("expected the line to end in mate, got %v", data.PV[0].Moves)
+	}
+}
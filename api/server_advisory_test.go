@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.rumenx.com/chess/config"
+)
+
+func TestConsiderDrawAdvisoryOnRepetition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/games", nil))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d", rec.Code)
+	}
+
+	var game GameResponse
+	for _, mv := range []string{"g1f3", "g8f6", "f3g1", "f6g8"} {
+		body := []byte(`{"from":"` + mv[:2] + `","to":"` + mv[2:] + `"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/games/1/moves", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("move %s expected 200 got %d body=%s", mv, rec.Code, rec.Body.String())
+		}
+		game = GameResponse{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+
+	if len(game.Advisories) != 1 || game.Advisories[0].Type != "consider_draw" {
+		t.Fatalf("expected consider_draw advisory, got %+v", game.Advisories)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/games/1/analysis", nil))
+	var analysis map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &analysis); err != nil {
+		t.Fatalf("decode analysis: %v", err)
+	}
+	if _, ok := analysis["draw_advisory"]; !ok {
+		t.Fatalf("expected draw_advisory in analysis, got %v", analysis)
+	}
+}
+
+// TestConsiderDrawAdvisoryBroadcast verifies WebSocket clients hear once when
+// the game starts looking drawn.
+func TestConsiderDrawAdvisoryBroadcast(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	id := createGame(t, r)
+
+	u, _ := url.Parse(ts.URL)
+	c, _, err := websocket.DefaultDialer.Dial("ws://"+u.Host+"/ws/games/"+itoa(id), nil)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	moves := []string{"g1f3", "g8f6", "f3g1", "f6g8", "g1f3", "g8f6"}
+	for _, mv := range moves {
+		if rec := playerRequest(r, http.MethodPost, "/api/games/"+itoa(id)+"/moves", "", `{"notation":"`+mv+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("move %s: %d %s", mv, rec.Code, rec.Body.String())
+		}
+	}
+	var advisories []AdvisoryMessage
+	for {
+		var msg struct {
+			AdvisoryMessage
+			Event string `json:"event"`
+		}
+		if err := readGameFrame(c, &msg); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if msg.Type == "advisory" {
+			advisories = append(advisories, msg.AdvisoryMessage)
+		}
+		if msg.Type == "game_event" && msg.Event == "move_made" && msg.Ply == len(moves) {
+			break
+		}
+	}
+	if len(advisories) != 1 || advisories[0].Ply != 4 || advisories[0].Advisory.Type != "consider_draw" {
+		t.Fatalf("expected one consider_draw advisory after the repetition, got %+v", advisories)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+type engineAnalysisResponse struct {
+	PV         []PVLineResponse        `json:"pv"`
+	BestLine   *PVLineResponse         `json:"best_line"`
+	Candidates []CandidateMoveResponse `json:"candidates"`
+	Threat     *PVLineResponse         `json:"threat"`
+	Search     *SearchInfoResponse     `json:"search"`
+	Tablebase  *TablebaseResponse      `json:"tablebase"`
+}
+
+func TestAnalysisEngineLines(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := itoa(createGame(t, r))
+	// After 1. e4 e5 2. Bc4 Nc6 3. Qh5 White threatens Qxf7#
+	for _, move := range []string{"e2e4", "e7e5", "f1c4", "b8c6", "d1h5"} {
+		if rec := playerRequest(r, http.MethodPost, "/api/games/"+id+"/moves", "", `{"notation":"`+move+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("move %s: %d %s", move, rec.Code, rec.Body.String())
+		}
+	}
+
+	var analysis engineAnalysisResponse
+	rec := playerRequest(r, http.MethodGet, "/api/games/"+id+"/analysis?depth=3&movetime=5000", "", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &analysis); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("analysis: %d %s", rec.Code, rec.Body.String())
+	}
+	if analysis.BestLine == nil || len(analysis.BestLine.Moves) == 0 || len(analysis.PV) != 1 {
+		t.Fatalf("expected a best line and one PV, got %s", rec.Body.String())
+	}
+	if len(analysis.Candidates) != 3 || analysis.Candidates[0].Move != analysis.BestLine.Moves[0] {
+		t.Fatalf("expected three candidates led by the best move, got %+v", analysis.Candidates)
+	}
+	if analysis.Threat == nil || len(analysis.Threat.Moves) == 0 || analysis.Threat.Moves[0] != "Qxf7#" || analysis.Threat.Mate != 1 {
+		t.Fatalf("expected Qxf7# as the threat, got %+v", analysis.Threat)
+	}
+	if analysis.Search == nil || analysis.Search.Depth > 3 || analysis.Tablebase != nil {
+		t.Fatalf("expected a search of at most 3 plies and no tablebase, got %+v %+v", analysis.Search, analysis.Tablebase)
+	}
+
+	for _, query := range []string{"depth=0", "depth=99", "movetime=x", "movetime=999999999"} {
+		rec := playerRequest(r, http.MethodGet, "/api/games/"+id+"/analysis?"+query, "", "")
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_search_limits") {
+			t.Errorf("%s: expected invalid_search_limits, got %d %s", query, rec.Code, rec.Body.String())
+		}
+		if param, _, _ := strings.Cut(query, "="); !strings.Contains(rec.Body.String(), param+" must be") {
+			t.Errorf("%s: expected the error to name the %s parameter, got %s", query, param, rec.Body.String())
+		}
+	}
+}
+
+// TestAnalysisCopiesGameUnderLock verifies the analysis reads the game under
+// its lock, waiting for a move in progress, rather than the live game.
+func TestAnalysisCopiesGameUnderLock(t *testing.T) {
+	s, r := newTestServerAndRouter()
+	id := createGame(t, r)
+
+	s.gamesMux.RLock()
+	lock := s.gameLocks[id].(*sync.Mutex)
+	s.gamesMux.RUnlock()
+	lock.Lock()
+	done := make(chan int)
+	go func() {
+		done <- playerRequest(r, http.MethodGet, "/api/games/"+itoa(id)+"/analysis?depth=1", "", "").Code
+	}()
+	select {
+	case code := <-done:
+		t.Fatalf("expected the analysis to wait for the game's lock, got %d", code)
+	case <-time.After(50 * time.Millisecond):
+	}
+	lock.Unlock()
+	if code := <-done; code != http.StatusOK {
+		t.Fatalf("expected the analysis once the game is unlocked, got %d", code)
+	}
+}
+
+func TestAnalysisTablebase(t *testing.T) {
+	tablebase := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"category":"win","dtz":1,"dtm":1,"moves":[{"uci":"a1a8","san":"Ra8#"}]}`))
+	}))
+	defer tablebase.Close()
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.AI.TablebaseURL = tablebase.URL
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	id := itoa(createGame(t, r))
+	if rec := playerRequest(r, http.MethodPost, "/api/games/"+id+"/fen", "", `{"fen":"6k1/8/6K1/8/8/8/8/R7 w - - 0 1"}`); rec.Code != http.StatusOK {
+		t.Fatalf("load FEN: %d %s", rec.Code, rec.Body.String())
+	}
+	var analysis engineAnalysisResponse
+	rec := playerRequest(r, http.MethodGet, "/api/games/"+id+"/analysis?depth=2", "", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &analysis); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("analysis: %d %s", rec.Code, rec.Body.String())
+	}
+	if analysis.Tablebase == nil || analysis.Tablebase.Category != "win" || analysis.Tablebase.BestMove != "Ra8#" || *analysis.Tablebase.DTM != 1 {
+		t.Fatalf("expected the tablebase verdict, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+// signJWT returns an HS256 JWT of claims.
+func signJWT(secret string, claims map[string]any) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, _ := json.Marshal(claims)
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func authServer(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Server.Auth = config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKey{
+			{Key: "alice-key", UserID: "alice", Scopes: []string{config.ScopePlay}},
+			{Key: "bob-key", UserID: "bob", Scopes: []string{config.ScopeRead, config.ScopePlay}},
+			{Key: "viewer-key", UserID: "viewer", Scopes: []string{config.ScopeRead}},
+			{Key: "ops-key", UserID: "ops", Scopes: []string{config.ScopeAdmin}},
+		},
+		JWTSecret:   "jwt-secret",
+		JWTIssuer:   "https://auth.example.com",
+		JWTAudience: "chess",
+	}
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	return r
+}
+
+func authRequest(r *gin.Engine, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthRequiresCredentials(t *testing.T) {
+	r := authServer(t)
+	rec := authRequest(r, http.MethodGet, "/api/games", "", "")
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("expected 401 with a challenge, got %d", rec.Code)
+	}
+	if rec := authRequest(r, http.MethodGet, "/api/games", "wrong-key", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an unknown key, got %d", rec.Code)
+	}
+	if rec := authRequest(r, http.MethodGet, "/health", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected the health check open, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/games", nil)
+	req.Header.Set(apiKeyHeader, "viewer-key")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected the X-API-Key header accepted, got %d", rec.Code)
+	}
+	if rec := authRequest(r, http.MethodGet, "/ws/games/1", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected WebSockets to need credentials, got %d", rec.Code)
+	}
+	if rec := authRequest(r, http.MethodGet, "/ws/rooms/lobby?access_token=viewer-key", "", ""); rec.Code == http.StatusUnauthorized {
+		t.Error("expected WebSockets to take the access_token parameter")
+	}
+	if rec := authRequest(r, http.MethodGet, "/api/games/999/events?access_token=viewer-key", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected event streams to take the access_token parameter, got %d", rec.Code)
+	}
+	if rec := authRequest(r, http.MethodGet, "/api/games/999/image?access_token=viewer-key", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected board images to take the access_token parameter, got %d", rec.Code)
+	}
+	if rec := authRequest(r, http.MethodGet, "/api/games?access_token=viewer-key", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected other endpoints to ignore the access_token parameter, got %d", rec.Code)
+	}
+}
+
+func TestAuthScopesAndOwnership(t *testing.T) {
+	r := authServer(t)
+	if rec := authRequest(r, http.MethodPost, "/api/games", "viewer-key", ""); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "insufficient_scope") {
+		t.Fatalf("expected the read scope refused a new game, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := authRequest(r, http.MethodPost, "/api/games", "alice-key", "")
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil || rec.Code != http.StatusCreated || game.Owner != "alice" {
+		t.Fatalf("expected a game of alice's, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := authRequest(r, http.MethodGet, "/api/games/1", "alice-key", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected the play scope to read, got %d", rec.Code)
+	}
+
+	move := `{"from":"e2","to":"e4"}`
+	if rec := authRequest(r, http.MethodPost, "/api/games/1/moves", "bob-key", move); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "not_a_player") {
+		t.Fatalf("expected bob kept out of alice's game, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := authRequest(r, http.MethodPost, "/api/games/1/players", "bob-key", `{"user_id":"bob"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("expected bob unable to self-invite, got %d", rec.Code)
+	}
+	rec = authRequest(r, http.MethodPost, "/api/games/1/players", "alice-key", `{"user_id":"bob"}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil || rec.Code != http.StatusOK || len(game.Players) != 1 || game.Players[0] != "bob" {
+		t.Fatalf("expected bob invited, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := authRequest(r, http.MethodPost, "/api/games/1/moves", "bob-key", move); rec.Code != http.StatusOK {
+		t.Fatalf("expected an invited player to move, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := authRequest(r, http.MethodPost, "/api/games/1/players", "bob-key", `{"user_id":"carol"}`); rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "not_game_owner") {
+		t.Errorf("expected only the owner to invite, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// The authenticated user wins over a claimed one
+	req := httptest.NewRequest(http.MethodPost, "/api/rooms", strings.NewReader(`{"id":"club"}`))
+	req.Header.Set("Authorization", "Bearer alice-key")
+	req.Header.Set(userIDHeader, "mallory")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"created_by":"alice"`) {
+		t.Errorf("expected the room created by alice, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := authRequest(r, http.MethodDelete, "/api/games/1", "ops-key", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected an admin to delete any game, got %d", rec.Code)
+	}
+}
+
+func TestAuthJWT(t *testing.T) {
+	r := authServer(t)
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub":   "carol",
+			"scope": "read play",
+			"iss":   "https://auth.example.com",
+			"aud":   []string{"chess", "other"},
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	rec := authRequest(r, http.MethodPost, "/api/games", signJWT("jwt-secret", claims(nil)), "")
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil || rec.Code != http.StatusCreated || game.Owner != "carol" {
+		t.Fatalf("expected a game of carol's, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := authRequest(r, http.MethodPost, "/api/games", signJWT("jwt-secret", claims(map[string]any{"scope": "read"})), ""); rec.Code != http.StatusForbidden {
+		t.Errorf("expected the JWT's scopes applied, got %d", rec.Code)
+	}
+
+	for name, token := range map[string]string{
+		"expired":         signJWT("jwt-secret", claims(map[string]any{"exp": time.Now().Add(-time.Minute).Unix()})),
+		"not yet valid":   signJWT("jwt-secret", claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})),
+		"wrong secret":    signJWT("other-secret", claims(nil)),
+		"wrong issuer":    signJWT("jwt-secret", claims(map[string]any{"iss": "https://evil.example.com"})),
+		"wrong audience":  signJWT("jwt-secret", claims(map[string]any{"aud": "other"})),
+		"without subject": signJWT("jwt-secret", claims(map[string]any{"sub": ""})),
+		"unsigned":        base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + ".e30.",
+	} {
+		if rec := authRequest(r, http.MethodGet, "/api/games", token, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", name, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// waitForPlies polls a game until it has plies moves, failing after a second.
+func waitForPlies(t *testing.T, r *gin.Engine, id string, plies int) GameResponse {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/games/"+id, nil))
+		var game GameResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+			t.Fatal(err)
+		}
+		if len(game.MoveHistory) >= plies {
+			return game
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d plies, got %d", plies, len(game.MoveHistory))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAutoAIReplies(t *testing.T) {
+	s, r := newTestServerAndRouter()
+	rec := playerRequest(r, http.MethodPost, "/api/games", "", `{"auto_ai":true,"ai_engine":"random"}`)
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil || !game.AutoAI || game.AIEngine != "random" {
+		t.Fatalf("expected a game with automatic replies, got %d %s", rec.Code, rec.Body.String())
+	}
+	id := itoa(game.ID)
+	client := s.hub.add(game.ID)
+	defer s.hub.remove(game.ID, client)
+
+	if rec := playerRequest(r, http.MethodPost, "/api/games/"+id+"/moves", "", `{"notation":"e2e4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+	}
+	game = waitForPlies(t, r, id, 2)
+	if game.ActiveColor != "white" {
+		t.Errorf("expected the player's turn after the reply, got %s", game.ActiveColor)
+	}
+
+	// The reply reaches WebSocket clients as an event and as the game state
+	timeout := time.After(time.Second)
+	for pushed := false; !pushed; {
+		select {
+		case msg := <-client.send:
+			state, ok := msg.(GameResponse)
+			pushed = ok && len(state.MoveHistory) == 2
+		case <-timeout:
+			t.Fatal("expected the game pushed after the reply")
+		}
+	}
+}
+
+func TestAutoAISettings(t *testing.T) {
+	_, r := newTestServerAndRouter()
+
+	// The AI opens when it plays white
+	rec := playerRequest(r, http.MethodPost, "/api/games", "", `{"ai_color":"white","auto_ai":true,"ai_engine":"random"}`)
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+		t.Fatal(err)
+	}
+	waitForPlies(t, r, itoa(game.ID), 1)
+
+	for body, code := range map[string]int{
+		`{"auto_ai":true,"ai_engine":"llm"}`:      http.StatusBadRequest,
+		`{"auto_ai":true,"ai_level":"godlike"}`:   http.StatusBadRequest,
+		`{"ai_color":"none","auto_ai":true}`:      http.StatusBadRequest,
+		`{"auto_ai":true,"ai_engine":"minimax"}`:  http.StatusCreated,
+		`{"auto_ai":true,"ai_level":"beginner"}`:  http.StatusCreated,
+		`{"auto_ai":false,"ai_engine":"unknown"}`: http.StatusBadRequest,
+	} {
+		if rec := playerRequest(r, http.MethodPost, "/api/games", "", body); rec.Code != code {
+			t.Errorf("%s: expected %d, got %d", body, code, rec.Code)
+		}
+	}
+
+	// Turned on later, on the AI's turn, the AI replies at once
+	id := itoa(createGame(t, r))
+	playerRequest(r, http.MethodPost, "/api/games/"+id+"/moves", "", `{"notation":"d2d4"}`)
+	rec = playerRequest(r, http.MethodPatch, "/api/games/"+id, "", `{"auto_ai":true,"ai_engine":"random"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"auto_ai":true`) {
+		t.Fatalf("expected automatic replies turned on, got %d %s", rec.Code, rec.Body.String())
+	}
+	waitForPlies(t, r, id, 2)
+
+	two := createTwoPlayerGame(t, r)
+	if rec := playerRequest(r, http.MethodPatch, "/api/games/"+two.UUID, "", `{"auto_ai":true}`); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a two-player game, got %d", rec.Code)
+	}
+}
+
+func TestAIMoveApplyRace(t *testing.T) {
+	s, r := newTestServerAndRouter()
+	gameID := createGame(t, r)
+	id := itoa(gameID)
+	if rec := playerRequest(r, http.MethodPost, "/api/games/"+id+"/moves", "", `{"notation":"e2e4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Both requests find it the AI's turn, then wait for the game's lock
+	lock := s.gameLocks[gameID]
+	lock.Lock()
+	codes := make(chan int, 2)
+	for range 2 {
+		go func() {
+			codes <- playerRequest(r, http.MethodPost, "/api/games/"+id+"/ai-move", "", `{"engine":"random","apply":true}`).Code
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	lock.Unlock()
+
+	first, second := <-codes, <-codes
+	if min(first, second) != http.StatusOK || max(first, second) != http.StatusConflict {
+		t.Fatalf("expected one move and one 409, got %d and %d", first, second)
+	}
+	if game := waitForPlies(t, r, id, 2); len(game.MoveHistory) != 2 {
+		t.Errorf("expected only the AI's reply to be played, got %d plies", len(game.MoveHistory))
+	}
+}
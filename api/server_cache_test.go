@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.rumenx.com/chess/config"
+)
+
+func TestResponseCacheAndETag(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	path := "/api/games/" + itoa(id)
+
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	if first.Code != http.StatusOK || first.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("expected 200 MISS, got %d %q", first.Code, first.Header().Get("X-Cache"))
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header")
+	}
+
+	second := get("")
+	if second.Header().Get("X-Cache") != "HIT" || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected identical cached response, got %q", second.Header().Get("X-Cache"))
+	}
+	if notModified := get(etag); notModified.Code != http.StatusNotModified || notModified.Body.Len() != 0 {
+		t.Fatalf("expected 304 with empty body, got %d", notModified.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path+"/moves", strings.NewReader(`{"from":"e2","to":"e4"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("move status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	after := get(etag)
+	if after.Code != http.StatusOK || after.Header().Get("X-Cache") != "MISS" || after.Header().Get("ETag") == etag {
+		t.Fatalf("expected fresh response after move, got %d %q", after.Code, after.Header().Get("X-Cache"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/games/9999", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound || rec.Header().Get("ETag") != "" {
+		t.Fatalf("expected uncached 404, got %d", rec.Code)
+	}
+}
+
+func TestResponseCacheDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Server.ResponseCacheTTL = 0
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := createGame(t, r)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/games/"+itoa(id)+"/pgn", nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Header().Get("X-Cache") != "MISS" || rec.Header().Get("ETag") == "" {
+			t.Fatalf("request %d: expected uncached response with ETag, got %q", i, rec.Header().Get("X-Cache"))
+		}
+	}
+}
+
+func TestResponseCacheSkipsRunningClocks(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	rec := playerRequest(r, http.MethodPost, "/api/games", "", `{"time_control":"300+2"}`)
+	var created GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/games/" + itoa(created.ID)
+	if rec := playerRequest(r, http.MethodPost, path+"/moves", "", `{"from":"e2","to":"e4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for i := 0; i < 2; i++ {
+		rec := playerRequest(r, http.MethodGet, path, "", "")
+		var game GameResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil || game.Clock == nil || game.Clock.Running != "black" {
+			t.Fatalf("expected black's clock running, got %s", rec.Body.String())
+		}
+		if rec.Header().Get("X-Cache") != "" || rec.Header().Get("ETag") != "" {
+			t.Fatalf("request %d: expected no caching while the clock runs, got %q %q", i, rec.Header().Get("X-Cache"), rec.Header().Get("ETag"))
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+// TestChatCopiesGameUnderLock verifies chat reads the game's position under its
+// lock, waiting for a move in progress, rather than from the live game.
+func TestChatCopiesGameUnderLock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	if s.chatService == nil {
+		t.Skip("chat service unavailable")
+	}
+	s.chatService.SetChatbotForTesting(cannedChatbot{reply: "Castle soon."})
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := createGame(t, r)
+
+	s.gamesMux.RLock()
+	lock := s.gameLocks[id].(*sync.Mutex)
+	s.gamesMux.RUnlock()
+	lock.Lock()
+	done := make(chan int)
+	go func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/chat", strings.NewReader(`{"message":"Plans?"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		done <- rec.Code
+	}()
+	select {
+	case code := <-done:
+		t.Fatalf("expected chat to wait for the game's lock, got %d", code)
+	case <-time.After(50 * time.Millisecond):
+	}
+	lock.Unlock()
+	if code := <-done; code != http.StatusOK {
+		t.Fatalf("expected the chat answered once the game is unlocked, got %d", code)
+	}
+}
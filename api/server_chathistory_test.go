@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/chat"
+	"go.rumenx.com/chess/config"
+)
+
+func TestChatHistoryEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	if s.chatService == nil {
+		t.Skip("chat service unavailable")
+	}
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := createGame(t, r)
+
+	conversations := chat.NewMemoryStore()
+	conversation := &chat.Conversation{GameID: id}
+	for i := 0; i < 5; i++ {
+		conversation.Messages = append(conversation.Messages, chat.Message{ID: fmt.Sprintf("m%d", i), Type: "user", Content: "hi"})
+	}
+	if err := conversations.SaveConversation(context.Background(), conversation); err != nil {
+		t.Fatal(err)
+	}
+	s.chatService.SetConversationStore(conversations)
+
+	get := func(query string) (int, ChatHistoryResponse) {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/games/"+itoa(id)+"/chat/history"+query, nil))
+		var resp ChatHistoryResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	code, page := get("?limit=2&offset=1")
+	if code != http.StatusOK || len(page.Messages) != 2 || page.Messages[0].ID != "m1" || page.Total != 5 || !page.HasMore {
+		t.Fatalf("expected messages m1 and m2 of 5, got %d %+v", code, page)
+	}
+	if _, page = get("?offset=3"); len(page.Messages) != 2 || page.HasMore || page.Limit != defaultChatHistoryLimit {
+		t.Errorf("expected the last two messages, got %+v", page)
+	}
+	if _, page = get("?offset=9"); len(page.Messages) != 0 || page.Messages == nil {
+		t.Errorf("expected an empty page past the end, got %+v", page)
+	}
+	if code, page = get("?offset=9223372036854775807"); code != http.StatusOK || len(page.Messages) != 0 {
+		t.Errorf("expected an empty page for the largest offset, got %d %+v", code, page)
+	}
+	if code, _ = get("?limit=-1"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative limit, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/games/"+itoa(id)+"/chat", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete chat: %d %s", rec.Code, rec.Body.String())
+	}
+	if _, page = get(""); page.Total != 0 {
+		t.Errorf("expected no messages after clearing, got %+v", page)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/games/999/chat/history", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown game, got %d", rec.Code)
+	}
+}
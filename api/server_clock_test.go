@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.rumenx.com/chess/config"
+)
+
+func TestTimedGamePGNClockRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(`{"time_control":"300+3"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if game.Clock == nil || game.Clock.TimeControl != "300+3" || game.Clock.Running != "white" {
+		t.Fatalf("expected running 300+3 clock, got %+v", game.Clock)
+	}
+
+	for _, mv := range []string{"e2e4", "e7e5"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/1/moves", strings.NewReader(`{"from":"`+mv[:2]+`","to":"`+mv[2:]+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("move %s expected 200 got %d body=%s", mv, rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	if last := game.MoveHistory[len(game.MoveHistory)-1]; last.ClockMs == nil || *last.ClockMs <= 300000 {
+		t.Fatalf("expected remaining time with increment on last move, got %+v", last.ClockMs)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/games/1/pgn", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	pgn := rec.Body.String()
+	if !strings.Contains(pgn, `[TimeControl "300+3"]`) || !strings.Contains(pgn, "{[%clk 0:05:0") {
+		t.Fatalf("expected clock data in PGN, got %s", pgn)
+	}
+
+	// Import the exported PGN and export it again: clock comments must survive
+	body, _ := json.Marshal(GameImportRequest{PGN: pgn})
+	req = httptest.NewRequest(http.MethodPost, "/api/games/import", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("import expected 201 got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var imported GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &imported); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(imported.MoveHistory) != 2 || imported.MoveHistory[0].ClockMs == nil {
+		t.Fatalf("expected imported moves with clock data, got %+v", imported.MoveHistory)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/games/2/pgn", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	movetext := func(p string) string { return p[strings.Index(p, "\n\n"):] }
+	if movetext(rec.Body.String()) != movetext(pgn) {
+		t.Fatalf("movetext changed on round trip:\n%s\nvs\n%s", movetext(rec.Body.String()), movetext(pgn))
+	}
+}
+
+func TestCreateGameRejectsInvalidTimeControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(`{"time_control":"fast"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/games/import", strings.NewReader(`{"pgn":"1. e4 e5 2. Ke3"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_pgn") {
+		t.Fatalf("expected invalid_pgn, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMoveTimestamps(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := itoa(createGame(t, r))
+	before := time.Now()
+	var game GameResponse
+	for _, move := range []string{"e2e4", "e7e5"} {
+		rec := playerRequest(r, http.MethodPost, "/api/games/"+id+"/moves", "", `{"notation":"`+move+`"}`)
+		if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("move %s: %d %s", move, rec.Code, rec.Body.String())
+		}
+	}
+	for i, move := range game.MoveHistory {
+		if move.PlayedAt == nil || move.PlayedAt.Before(before) || move.ThinkMs == nil || *move.ThinkMs < 0 || move.ClockMs != nil {
+			t.Fatalf("expected ply %d timestamped without a clock, got %+v", i, move)
+		}
+	}
+
+	// Timestamps are exported as [%ts] and survive an import
+	pgn := playerRequest(r, http.MethodGet, "/api/games/"+id+"/pgn", "", "").Body.String()
+	if !strings.Contains(pgn, "[%ts "+game.MoveHistory[0].PlayedAt.UTC().Format("2006-01-02T15:04:05")) {
+		t.Fatalf("expected timestamps in PGN, got %s", pgn)
+	}
+	body, _ := json.Marshal(GameImportRequest{PGN: pgn})
+	rec := playerRequest(r, http.MethodPost, "/api/games/import", "", string(body))
+	var imported GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &imported); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("import: %d %s", rec.Code, rec.Body.String())
+	}
+	if played := imported.MoveHistory[1].PlayedAt; played == nil || !played.Equal(game.MoveHistory[1].PlayedAt.Truncate(time.Millisecond)) {
+		t.Fatalf("expected the imported move's timestamp kept, got %v", played)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+// promptRecorder is a chatbot that records its prompts.
+type promptRecorder struct{ prompts []string }
+
+func (p *promptRecorder) Ask(_ context.Context, prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	return "What is the knight on f3 looking at?", nil
+}
+
+func TestChatCoachMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	if s.chatService == nil {
+		t.Skip("chat service unavailable")
+	}
+	recorder := &promptRecorder{}
+	s.chatService.SetChatbotForTesting(recorder)
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := createGame(t, r)
+
+	post := func(path, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for _, move := range []string{`{"from":"e2","to":"e4"}`, `{"from":"e7","to":"e5"}`, `{"from":"g1","to":"f3"}`} {
+		if code := post("/moves", move); code != http.StatusOK {
+			t.Fatalf("move %s: %d", move, code)
+		}
+	}
+
+	if code := post("/chat", `{"message":"What should I do?","mode":"coach"}`); code != http.StatusOK {
+		t.Fatalf("expected 200 for coach mode, got %d", code)
+	}
+	if prompt := recorder.prompts[0]; !strings.Contains(prompt, "guiding questions") ||
+		!strings.Contains(prompt, "- black's pawn on e5 is attacked by knight on f3 and not defended.") {
+		t.Errorf("expected a coaching prompt with the hanging pawn, got %q", prompt)
+	}
+	if code := post("/chat", `{"message":"Hi","mode":"oracle"}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown mode, got %d", code)
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestAutoCommentary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	if s.chatService == nil {
+		t.Skip("chat service unavailable")
+	}
+	s.chatService.SetChatbotForTesting(cannedChatbot{reply: "A classical start."})
+	r := gin.New()
+	s.SetupRoutes(r)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	send := func(method, path, body string) (int, GameResponse) {
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var game GameResponse
+		_ = json.NewDecoder(resp.Body).Decode(&game)
+		return resp.StatusCode, game
+	}
+	code, game := send(http.MethodPost, "/api/games", `{"auto_commentary":true}`)
+	if code != http.StatusCreated || !game.AutoCommentary {
+		t.Fatalf("expected a game with automatic commentary, got %d %+v", code, game)
+	}
+
+	u, _ := url.Parse(ts.URL)
+	c, _, err := websocket.DefaultDialer.Dial("ws://"+u.Host+"/ws/games/"+itoa(game.ID), nil)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var initial map[string]interface{}
+	if err := readGameFrame(c, &initial); err != nil {
+		t.Fatalf("read initial: %v", err)
+	}
+
+	commentary := func() CommentaryMessage {
+		t.Helper()
+		for {
+			var msg CommentaryMessage
+			if err := readGameFrame(c, &msg); err != nil {
+				t.Fatalf("expected commentary: %v", err)
+			}
+			if msg.Type == "commentary" {
+				return msg
+			}
+		}
+	}
+	if code, _ := send(http.MethodPost, "/api/games/"+itoa(game.ID)+"/moves", `{"from":"e2","to":"e4"}`); code != http.StatusOK {
+		t.Fatalf("move: %d", code)
+	}
+	if msg := commentary(); msg.Ply != 1 || msg.Comment == "" || msg.GameID != game.ID {
+		t.Fatalf("expected commentary on the first move, got %+v", msg)
+	}
+
+	// The AI's own moves get none
+	for _, move := range []string{"e7e5", "g1f3"} {
+		if code, _ := send(http.MethodPost, "/api/games/"+itoa(game.ID)+"/moves", `{"notation":"`+move+`"}`); code != http.StatusOK {
+			t.Fatalf("move %s: %d", move, code)
+		}
+	}
+	if msg := commentary(); msg.Ply != 3 {
+		t.Fatalf("expected commentary on the player's second move only, got %+v", msg)
+	}
+
+	if code, game = send(http.MethodPatch, "/api/games/"+itoa(game.ID), `{"auto_commentary":false}`); code != http.StatusOK || game.AutoCommentary {
+		t.Errorf("expected automatic commentary switched off, got %d %+v", code, game)
+	}
+	if code, _ = send(http.MethodPatch, "/api/games/99", `{"auto_commentary":true}`); code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown game, got %d", code)
+	}
+}
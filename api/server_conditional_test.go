@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.rumenx.com/chess/config"
+)
+
+func TestConditionalMovesEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	do(http.MethodPost, "/api/games", "")
+	if rec := do(http.MethodPost, "/api/games/1/moves", `{"from":"e2","to":"e4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move expected 200 got %d", rec.Code)
+	}
+
+	// White registers: if 1...e5 then 2.Nf3; if 1...c5 then 2.c3
+	if rec := do(http.MethodPost, "/api/games/1/conditional-moves", `{"moves":["e5","Nf3"]}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d body=%s", rec.Code, rec.Body.String())
+	}
+	rec := do(http.MethodPost, "/api/games/1/conditional-moves", `{"moves":["c5","c3"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var line ConditionalLineResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &line); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec := do(http.MethodPost, "/api/games/1/conditional-moves", `{"moves":["e5","Nc3"]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected conflicting line rejected, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/games/1/conditional-moves", `{"moves":["Nf6","e5"],"player":"black"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for the side to move, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodDelete, "/api/games/1/conditional-moves/"+itoa(line.ID), ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", rec.Code)
+	}
+	rec = do(http.MethodGet, "/api/games/1/conditional-moves", "")
+	if !strings.Contains(rec.Body.String(), `"count":1`) {
+		t.Fatalf("expected one remaining line, got %s", rec.Body.String())
+	}
+
+	// Black plays e5 and the reply is applied automatically
+	rec = do(http.MethodPost, "/api/games/1/moves", `{"from":"e7","to":"e5"}`)
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if game.ConditionalReply == nil || game.ConditionalReply.From != "g1" || game.ConditionalReply.To != "f3" {
+		t.Fatalf("expected Nf3 conditional reply, got %+v", game.ConditionalReply)
+	}
+	if len(game.MoveHistory) != 3 || game.ActiveColor != "black" {
+		t.Fatalf("expected reply in history and black to move, got %d moves, %s to move", len(game.MoveHistory), game.ActiveColor)
+	}
+}
+
+func TestConditionalMovesTwoPlayer(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	game := createTwoPlayerGame(t, r)
+	white, black := game.PlayerTokens["white"], game.PlayerTokens["black"]
+	base := "/api/v1/games/" + game.UUID
+	for _, token := range []string{white, black} {
+		if rec := playerRequest(r, http.MethodPost, base+"/join", token, ""); rec.Code != http.StatusOK {
+			t.Fatalf("join: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/moves", white, `{"notation":"e2e4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Black is to move, so only white may register conditional moves
+	line := `{"moves":["e7e5","g1f3"]}`
+	if rec := playerRequest(r, http.MethodPost, base+"/conditional-moves", "", line); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a player token, got %d", rec.Code)
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/conditional-moves", black, line); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for the opponent, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/conditional-moves", white, line); rec.Code != http.StatusCreated {
+		t.Fatalf("expected white's line registered, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodGet, base+"/conditional-moves", black, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("expected the opponent kept from white's lines, got %d", rec.Code)
+	}
+	if rec := playerRequest(r, http.MethodDelete, base+"/conditional-moves/1", black, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("expected the opponent kept from deleting white's lines, got %d", rec.Code)
+	}
+
+	// Loading a position takes the token of the side to move
+	fen := `{"fen":"4k3/8/8/8/8/8/8/4K3 w - - 0 1"}`
+	if rec := playerRequest(r, http.MethodPost, base+"/fen", "", fen); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 loading a FEN without a player token, got %d", rec.Code)
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/fen", white, fen); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 loading a FEN off turn, got %d", rec.Code)
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/fen", black, fen); rec.Code != http.StatusOK {
+		t.Errorf("expected the side to move to load a FEN, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func corsRequest(r *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/games", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func corsServer(t *testing.T, configure func(*config.ServerConfig)) (*Server, *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	configure(&cfg.Server)
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	return s, r
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	_, r := corsServer(t, func(*config.ServerConfig) {})
+	rec := corsRequest(r, http.MethodOptions, "https://app.example.com")
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("expected any origin allowed, got %d %v", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Max-Age") != "600" || rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("expected preflights cached for 10 minutes without credentials, got %v", rec.Header())
+	}
+	if rec := corsRequest(r, http.MethodGet, ""); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected no CORS headers without an origin")
+	}
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	s, r := corsServer(t, func(cfg *config.ServerConfig) {
+		cfg.AllowedOrigins = []string{"https://chess.example.com", "https://*.example.org"}
+		cfg.CORSCredentials = true
+		cfg.CORSMaxAge = time.Hour
+	})
+	for _, origin := range []string{"https://chess.example.com", "HTTPS://Chess.Example.com", "https://play.example.org"} {
+		rec := corsRequest(r, http.MethodOptions, origin)
+		if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != origin ||
+			rec.Header().Get("Access-Control-Allow-Credentials") != "true" || rec.Header().Get("Access-Control-Max-Age") != "3600" {
+			t.Errorf("%s: expected the origin allowed with credentials, got %d %v", origin, rec.Code, rec.Header())
+		}
+	}
+	for _, origin := range []string{"https://evil.com", "https://example.org", "http://play.example.org", "https://chess.example.com.evil.com"} {
+		if rec := corsRequest(r, http.MethodOptions, origin); rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected the preflight refused, got %d", origin, rec.Code)
+		}
+		if rec := corsRequest(r, http.MethodGet, origin); rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("%s: expected no CORS headers, got %d %v", origin, rec.Code, rec.Header())
+		}
+	}
+	rec := corsRequest(r, http.MethodGet, "https://chess.example.com")
+	if rec.Header().Get("Vary") != "Origin" || rec.Header().Get("Access-Control-Expose-Headers") == "" {
+		t.Errorf("expected Vary and exposed headers, got %v", rec.Header())
+	}
+
+	ws := func(origin string) bool {
+		req := httptest.NewRequest(http.MethodGet, "http://chess.internal:8080/ws/games/1", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		return s.checkOrigin(req)
+	}
+	if !ws("") || !ws("http://chess.internal:8080") || !ws("https://play.example.org") {
+		t.Error("expected WebSockets from no origin, the server's own and allowed origins")
+	}
+	if ws("https://evil.com") {
+		t.Error("expected WebSockets refused from other origins")
+	}
+}
+
+func TestCORSDisabled(t *testing.T) {
+	s, r := corsServer(t, func(cfg *config.ServerConfig) { cfg.CORSEnabled = false })
+	if rec := corsRequest(r, http.MethodGet, "https://app.example.com"); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected no CORS headers when disabled")
+	}
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:8080/ws/games/1", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	if s.checkOrigin(req) {
+		t.Error("expected cross-origin WebSockets refused when CORS is disabled")
+	}
+}
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestClaimDrawEndpoint(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	base := "/api/games/" + itoa(id)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, base+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("/claim-draw", ""); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "draw_not_claimable") {
+		t.Fatalf("expected 409 draw_not_claimable, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := post("/claim-draw", `{"reason":"stalemate"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for automatic reason, got %d", rec.Code)
+	}
+
+	var state GameResponse
+	for i := 0; i < 2; i++ {
+		for _, m := range [][2]string{{"g1", "f3"}, {"g8", "f6"}, {"f3", "g1"}, {"f6", "g8"}} {
+			rec := post("/moves", `{"from":"`+m[0]+`","to":"`+m[1]+`"}`)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("move %v: %d %s", m, rec.Code, rec.Body.String())
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if len(state.ClaimableDraws) != 1 || state.ClaimableDraws[0] != "threefold_repetition" {
+		t.Fatalf("expected threefold claim in game state, got %v", state.ClaimableDraws)
+	}
+
+	rec := post("/claim-draw", `{"reason":"threefold_repetition"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("claim status %d: %s", rec.Code, rec.Body.String())
+	}
+	state = GameResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != "draw" || state.DrawReason != "threefold_repetition" || len(state.ClaimableDraws) != 0 {
+		t.Fatalf("unexpected state after claim: %+v", state)
+	}
+	if state.Lifecycle != "finished" {
+		t.Fatalf("expected finished lifecycle, got %q", state.Lifecycle)
+	}
+	if rec := post("/claim-draw", ""); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "game_not_active") {
+		t.Fatalf("expected 409 game_not_active, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAIResignsHopelessGame(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	base := "/api/games/" + itoa(id)
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, base+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Black's lone king against White's full army
+	if rec := post("/fen", `{"fen":"4k3/8/8/8/8/8/PPPPPPPP/RNBQKBNR b KQ - 0 1"}`); rec.Code != http.StatusOK {
+		t.Fatalf("load FEN: %d %s", rec.Code, rec.Body.String())
+	}
+	for i, push := range []string{"a2a3", "h2h3", "b2b3"} {
+		rec := post("/ai-move", `{"engine":"minimax","level":"medium"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ai-move %d: %d %s", i, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Resigned bool         `json:"resigned"`
+			Move     MoveResponse `json:"move"`
+			Game     GameResponse `json:"game"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Resigned {
+			if i < 2 {
+				t.Fatalf("expected the AI to play on for three moves, resigned after %d", i+1)
+			}
+			if resp.Game.Status != "white_wins" || resp.Game.Termination != "resignation" || resp.Game.Lifecycle != "finished" {
+				t.Fatalf("unexpected state after resignation: %+v", resp.Game)
+			}
+			return
+		}
+		if rec := post("/moves", `{"from":"`+resp.Move.From+`","to":"`+resp.Move.To+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("AI move: %d %s", rec.Code, rec.Body.String())
+		}
+		if rec := post("/moves", `{"notation":"`+push+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("move %s: %d %s", push, rec.Code, rec.Body.String())
+		}
+	}
+	t.Fatalf("expected the AI to resign")
+}
+
+func TestAIDrawOffers(t *testing.T) {
+	s, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	base := "/api/games/" + itoa(id)
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, base+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Book positions are not judged, so the offer is declined and the AI moves
+	if rec := post("/moves", `{"notation":"e2e4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+	}
+	rec := post("/ai-move", `{"engine":"minimax","level":"medium","offer_draw":true}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"draw_accepted":false`) || !strings.Contains(rec.Body.String(), `"move"`) {
+		t.Fatalf("expected a declined offer with a move, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := post("/draw-accept", ""); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "no_draw_offer") {
+		t.Fatalf("expected 409 no_draw_offer, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// A pending AI offer is shown in the game state and withdrawn when the
+	// opponent plays on
+	if rec := post("/fen", `{"fen":"4k3/4p3/8/8/8/8/4P3/4K3 w - - 0 1"}`); rec.Code != http.StatusOK {
+		t.Fatalf("load FEN: %d %s", rec.Code, rec.Body.String())
+	}
+	s.setDrawOffer(id, engine.Black)
+	var state GameResponse
+	rec = post("/moves", `{"notation":"e1d1"}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil || state.DrawOffer != "" {
+		t.Fatalf("expected the offer to be declined by moving, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// In a dead-equal ending the AI accepts
+	rec = post("/ai-move", `{"engine":"minimax","level":"medium","offer_draw":true}`)
+	var accepted struct {
+		DrawAccepted bool         `json:"draw_accepted"`
+		Game         GameResponse `json:"game"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &accepted); err != nil || !accepted.DrawAccepted {
+		t.Fatalf("expected the AI to accept, got %d %s", rec.Code, rec.Body.String())
+	}
+	if accepted.Game.Status != "draw" || accepted.Game.Termination != "agreement" {
+		t.Fatalf("unexpected state after the draw: %+v", accepted.Game)
+	}
+}
+
+func TestAcceptDrawEndpoint(t *testing.T) {
+	s, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	s.setDrawOffer(id, engine.Black)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/games/"+itoa(id), nil))
+	var state GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil || state.DrawOffer != "black" {
+		t.Fatalf("expected the pending offer in the game state, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/draw-accept", nil))
+	state = GameResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("accept: %d %s", rec.Code, rec.Body.String())
+	}
+	if state.Status != "draw" || state.Termination != "agreement" || state.DrawOffer != "" || state.Lifecycle != "finished" {
+		t.Fatalf("unexpected state after accepting: %+v", state)
+	}
+}
+
+func TestResignEndpoint(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	base := "/api/games/" + itoa(id)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, base+"/resign", nil))
+	var state GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("resign: %d %s", rec.Code, rec.Body.String())
+	}
+	// The AI plays black, so the player resigned for white
+	if state.Status != "black_wins" || state.Termination != "resignation" || state.Lifecycle != "finished" {
+		t.Fatalf("unexpected state after resigning: %+v", state)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, base+"/pgn", nil))
+	if !strings.Contains(rec.Body.String(), `[Result "0-1"]`) || !strings.Contains(rec.Body.String(), `[Termination "normal"]`) {
+		t.Errorf("expected the resignation in the PGN tags: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, base+"/resign", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 resigning a finished game, got %d", rec.Code)
+	}
+}
+
+func TestDrawOfferToAI(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+
+	// The AI has not judged the position yet, so it declines
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/draw-offer", nil))
+	var resp DrawOfferResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("offer: %d %s", rec.Code, rec.Body.String())
+	}
+	if resp.Status != "declined" || resp.Game.Status != "in_progress" || resp.Game.DrawOffer != "" {
+		t.Fatalf("expected the AI to decline, got %+v", resp)
+	}
+}
+
+func TestTwoPlayerDrawOffers(t *testing.T) {
+	s, r := newTestServerAndRouter()
+	game := createTwoPlayerGame(t, r)
+	white, black := game.PlayerTokens["white"], game.PlayerTokens["black"]
+	base := "/api/games/" + game.UUID
+	playerRequest(r, http.MethodPost, base+"/join", white, "")
+	playerRequest(r, http.MethodPost, base+"/join", black, "")
+	gameID, _ := s.resolveGameID(game.UUID)
+	client := s.hub.add(gameID)
+	defer s.hub.remove(gameID, client)
+
+	if rec := playerRequest(r, http.MethodPost, base+"/draw-offer", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 offering without a token, got %d", rec.Code)
+	}
+	rec := playerRequest(r, http.MethodPost, base+"/draw-offer", white, "")
+	var resp DrawOfferResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Status != "offered" || resp.Game.DrawOffer != "white" {
+		t.Fatalf("expected white's offer pending, got %d %s", rec.Code, rec.Body.String())
+	}
+	if msg, ok := (<-client.send).(DrawOfferMessage); !ok || msg.Color != "white" || msg.Status != "offered" {
+		t.Errorf("expected the offer broadcast, got %#v", msg)
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/draw-offer", white, ""); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 offering twice, got %d", rec.Code)
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/draw-accept", white, ""); rec.Code != http.StatusConflict {
+		t.Errorf("expected white unable to accept their own offer, got %d", rec.Code)
+	}
+
+	rec = playerRequest(r, http.MethodPost, base+"/draw-decline", black, "")
+	var state GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil || rec.Code != http.StatusOK || state.DrawOffer != "" {
+		t.Fatalf("expected black to decline, got %d %s", rec.Code, rec.Body.String())
+	}
+	if msg, ok := (<-client.send).(DrawOfferMessage); !ok || msg.Status != "declined" {
+		t.Errorf("expected the decline broadcast, got %#v", msg)
+	}
+
+	playerRequest(r, http.MethodPost, base+"/draw-offer", black, "")
+	rec = playerRequest(r, http.MethodPost, base+"/draw-accept", white, "")
+	state = GameResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("accept: %d %s", rec.Code, rec.Body.String())
+	}
+	if state.Status != "draw" || state.Termination != "agreement" || state.Lifecycle != "finished" {
+		t.Fatalf("unexpected state after the draw: %+v", state)
+	}
+	if rec := playerRequest(r, http.MethodGet, base+"/pgn", "", ""); !strings.Contains(rec.Body.String(), `[Result "1/2-1/2"]`) {
+		t.Errorf("expected the draw in the PGN: %s", rec.Body.String())
+	}
+}
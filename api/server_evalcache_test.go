@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/config"
+)
+
+func TestEvalCacheSharedAcrossGames(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	hint := func(id int) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/ai-hint",
+			strings.NewReader(`{"engine":"minimax","level":"easy"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ai-hint: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	health := func() map[string]float64 {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var resp struct {
+			EvalCache map[string]float64 `json:"eval_cache"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.EvalCache
+	}
+
+	hint(createGame(t, r))
+	first := health()
+	if first["misses"] == 0 || first["size"] == 0 {
+		t.Fatalf("expected the first search to fill the cache, got %v", first)
+	}
+	// Hints search without the opening book, so the same search in another game
+	// finds every position already evaluated
+	hint(createGame(t, r))
+	if second := health(); second["misses"] != first["misses"] {
+		t.Errorf("expected the second game to reuse the cached evaluations, got %v then %v", first, second)
+	}
+}
+
+func TestEvalCacheDisabled(t *testing.T) {
+	cfg := config.Default()
+	cfg.AI.EnableCaching = false
+	if _, ok := NewServer(cfg).evaluator.(*ai.EvalCache); ok {
+		t.Errorf("expected no evaluation cache when caching is disabled")
+	}
+}
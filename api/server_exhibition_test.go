@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestExhibitionGame(t *testing.T) {
+	// Both knights hop out and back until the game is adjudicated
+	moves := []string{"g1f3", "g8f6", "f3g1", "f6g8", "g1f3", "g8f6"}
+	var ply atomic.Int32
+	release := make(chan struct{})
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		content := "Nice move!"
+		if body["format"] != nil {
+			<-release
+			move := moves[int(ply.Add(1)-1)%len(moves)]
+			content = fmt.Sprintf(`{"from":%q,"to":%q,"promotion":""}`, move[:2], move[2:])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": content}})
+	}))
+	defer llm.Close()
+	t.Setenv("OLLAMA_ENDPOINT", llm.URL)
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.Enabled = true
+	cfg.LLMAI.ChatEnabled = true
+	cfg.LLMAI.MaxExhibitions = 1
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, body := range []string{
+		`{"white":{"provider":"ollama"},"black":{"provider":"openai"}}`,
+		`{"white":{"provider":"ollama"},"black":{"provider":"ollama","model":"unlisted"}}`,
+		`{"white":{"provider":"ollama"},"black":{"provider":"ollama"},"level":"grandmaster"}`,
+	} {
+		if rec := post("/api/exhibitions", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d %s", body, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := post("/api/exhibitions", `{"white":{"provider":"ollama"},"black":{"provider":"ollama"},"level":"easy","max_plies":6}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("start exhibition: %d %s", rec.Code, rec.Body.String())
+	}
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+		t.Fatal(err)
+	}
+	if game.Exhibition == nil || game.Exhibition.White != "ollama llama3.2 (White)" {
+		t.Fatalf("expected the players to be named, got %+v", game.Exhibition)
+	}
+
+	// No one else may move in an exhibition game
+	rec = post("/api/games/"+itoa(game.ID)+"/moves", `{"from":"e2","to":"e4"}`)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "exhibition_game") {
+		t.Errorf("expected 409 exhibition_game, got %d %s", rec.Code, rec.Body.String())
+	}
+	close(release)
+
+	// Wait on the lifecycle, as reading the game while it is played would race
+	deadline := time.Now().Add(10 * time.Second)
+	for s.lifecycleState(game.ID) != StateFinished {
+		if time.Now().After(deadline) {
+			t.Fatalf("exhibition did not finish after %d moves", ply.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/games/"+itoa(game.ID), nil))
+	_ = json.Unmarshal(rec.Body.Bytes(), &game)
+
+	if len(game.MoveHistory) != 6 || game.Termination != "agreement" {
+		t.Errorf("expected a draw adjudicated after 6 plies, got %d plies and %q", len(game.MoveHistory), game.Termination)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/games/"+itoa(game.ID)+"/pgn", nil))
+	pgn := rec.Body.String()
+	for _, want := range []string{`[Event "LLM Exhibition"]`, `[Black "ollama llama3.2 (Black)"]`, "{ollama llama3.2 (Black): Nice move!}"} {
+		if !strings.Contains(pgn, want) {
+			t.Errorf("expected %s in the PGN:\n%s", want, pgn)
+		}
+	}
+}
+
+func TestExhibitionLimits(t *testing.T) {
+	release := make(chan struct{})
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": `{"from":"e2","to":"e4","promotion":""}`}})
+	}))
+	defer llm.Close()
+	defer close(release)
+	t.Setenv("OLLAMA_ENDPOINT", llm.URL)
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.Enabled = true
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	body := `{"white":{"provider":"ollama"},"black":{"provider":"ollama"}}`
+
+	if rec := playerRequest(r, http.MethodPost, "/api/v1/exhibitions", "", body); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected exhibitions disabled by default, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	s.config.LLMAI.MaxExhibitions = 1
+	rec := playerRequest(r, http.MethodPost, "/api/v1/exhibitions", "", body)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("start exhibition: %d %s", rec.Code, rec.Body.String())
+	}
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+		t.Fatal(err)
+	}
+	if rec := playerRequest(r, http.MethodPost, "/api/v1/exhibitions", "", body); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 with one exhibition running, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Deleting the game stops its exhibition
+	if rec := playerRequest(r, http.MethodDelete, "/api/v1/games/"+itoa(game.ID), "", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body.String())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.gamesMux.RLock()
+		running := len(s.exhibitions)
+		s.gamesMux.RUnlock()
+		if running == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the exhibition stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rec := playerRequest(r, http.MethodPost, "/api/v1/exhibitions", "", body); rec.Code != http.StatusAccepted {
+		t.Errorf("expected another exhibition to start, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMakeMove_ExpectedMoveCount(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := itoa(createGame(t, r))
+	path := "/api/v1/games/" + id + "/moves"
+
+	if rec := playerRequest(r, http.MethodPost, path, "", `{"notation":"e2e4","expected_move_count":0}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected the first move to be played, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// A client that still sees the starting position is refused
+	rec := playerRequest(r, http.MethodPost, path, "", `{"notation":"d7d5","expected_move_count":0}`)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), `"stale_position"`) {
+		t.Fatalf("expected 409 stale_position, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := playerRequest(r, http.MethodPost, path, "", `{"notation":"d7d5","expected_move_count":1}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected the move on the current board, got %d %s", rec.Code, rec.Body.String())
+	}
+	// Without it moves are played as before
+	if rec := playerRequest(r, http.MethodPost, path, "", `{"notation":"e4d5"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected the move without a count, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestAIHintExplanation(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Messages []struct{ Content string } `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !strings.Contains(req.Messages[len(req.Messages)-1].Content, "The engine plays Ra8#") {
+			http.Error(w, `{"error":{"message":"unexpected prompt"}}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Ra8 mates on the back rank."}}]}`))
+	}))
+	defer llm.Close()
+
+	hint := func(s *Server) map[string]interface{} {
+		t.Helper()
+		r := gin.New()
+		s.SetupRoutes(r)
+		id := createGame(t, r)
+		base := "/api/games/" + itoa(id)
+		req := httptest.NewRequest(http.MethodPost, base+"/fen", strings.NewReader(`{"fen":"6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		req = httptest.NewRequest(http.MethodPost, base+"/ai-hint", strings.NewReader(`{"engine":"minimax","level":"medium"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ai-hint: %d %s", rec.Code, rec.Body.String())
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	cfg := config.Default()
+	cfg.LLMAI.Enabled = true
+	cfg.LLMAI.DefaultProvider = "openai"
+	cfg.LLMAI.Providers["openai"] = config.LLMProviderConfig{APIKey: "test", Endpoint: llm.URL}
+	resp := hint(NewServer(cfg))
+	if resp["explanation"] != "Ra8 mates on the back rank." || resp["explanation_source"] != "llm" {
+		t.Errorf("expected the LLM's explanation, got %v (%v)", resp["explanation"], resp["explanation_source"])
+	}
+
+	// Without a working LLM the engine's line explains the move
+	cfg.LLMAI.Providers["openai"] = config.LLMProviderConfig{APIKey: "test", Endpoint: llm.URL + "/missing"}
+	resp = hint(NewServer(cfg))
+	if resp["explanation"] != "Ra8# is the engine's choice" || resp["explanation_source"] != "engine" {
+		t.Errorf("expected the engine's explanation, got %v (%v)", resp["explanation"], resp["explanation_source"])
+	}
+	resp = hint(NewServer(config.Default()))
+	if resp["explanation_source"] != "engine" {
+		t.Errorf("expected the engine's explanation with LLM AI disabled, got %v", resp["explanation_source"])
+	}
+}
+
+// TestAIHintExplainedUnlocked verifies a hint is explained from a copy of the
+// game, so that moves are played while the LLM answers.
+func TestAIHintExplainedUnlocked(t *testing.T) {
+	called, release := make(chan struct{}), make(chan struct{})
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(called)
+		<-release
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Ra8 mates on the back rank."}}]}`))
+	}))
+	defer llm.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.Enabled = true
+	cfg.LLMAI.DefaultProvider = "openai"
+	cfg.LLMAI.Providers["openai"] = config.LLMProviderConfig{APIKey: "test", Endpoint: llm.URL}
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := createGame(t, r)
+	base := "/api/games/" + itoa(id)
+	playerRequest(r, http.MethodPost, base+"/fen", "", `{"fen":"6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1"}`)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- playerRequest(r, http.MethodPost, base+"/ai-hint", "", `{"engine":"minimax","level":"medium"}`)
+	}()
+	<-called
+	if rec := playerRequest(r, http.MethodPost, base+"/moves", "", `{"notation":"h2h3"}`); rec.Code != http.StatusOK {
+		t.Errorf("expected a move while the hint is explained, got %d %s", rec.Code, rec.Body.String())
+	}
+	close(release)
+	rec := <-done
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Ra8 mates") {
+		t.Errorf("expected the hint explained, got %d %s", rec.Code, rec.Body.String())
+	}
+}
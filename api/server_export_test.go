@@ -0,0 +1,93 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestExportGames(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	for range 3 {
+		createGame(t, r)
+	}
+	playerRequest(r, http.MethodPost, "/api/games/1/moves", "", `{"notation":"e2e4"}`)
+	if rec := playerRequest(r, http.MethodPost, "/api/games/2/resign", "", ""); rec.Code != http.StatusOK {
+		t.Fatalf("resign: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := playerRequest(r, http.MethodGet, "/api/games/export", "", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-chess-pgn" {
+		t.Fatalf("expected a PGN download, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if n := strings.Count(rec.Body.String(), "[Event "); n != 3 || !strings.Contains(rec.Body.String(), "1. e4") {
+		t.Fatalf("expected the three games, got %d:\n%s", n, rec.Body.String())
+	}
+
+	rec = playerRequest(r, http.MethodGet, "/api/games/export?status=finished&since="+time.Now().Add(-time.Hour).UTC().Format(time.RFC3339), "", "")
+	if n := strings.Count(rec.Body.String(), "[Event "); n != 1 || !strings.Contains(rec.Body.String(), `[Result "0-1"]`) {
+		t.Fatalf("expected the resigned game alone, got %d:\n%s", n, rec.Body.String())
+	}
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+	if rec := playerRequest(r, http.MethodGet, "/api/games/export?since="+tomorrow, "", ""); rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("expected no games created since tomorrow, got %d %s", rec.Code, rec.Body.String())
+	}
+	for _, query := range []string{"status=over", "since=yesterday", "format=tar"} {
+		if rec := playerRequest(r, http.MethodGet, "/api/games/export?"+query, "", ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+
+	rec = playerRequest(r, http.MethodGet, "/api/games/export?format=zip", "", "")
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil || len(archive.File) != 3 || archive.File[0].Name != "game-1.pgn" {
+		t.Fatalf("expected a zip of three games, got %d %v", rec.Code, err)
+	}
+	f, _ := archive.File[0].Open()
+	pgn, _ := io.ReadAll(f)
+	if !strings.Contains(string(pgn), "1. e4") {
+		t.Errorf("expected the first game's moves, got %s", pgn)
+	}
+}
+
+func TestExportOwnGames(t *testing.T) {
+	r := authServer(t)
+	authRequest(r, http.MethodPost, "/api/games", "alice-key", "")
+	authRequest(r, http.MethodPost, "/api/games", "bob-key", "")
+
+	for key, want := range map[string]int{"alice-key": 1, "viewer-key": 0, "ops-key": 2} {
+		rec := authRequest(r, http.MethodGet, "/api/games/export", key, "")
+		if n := strings.Count(rec.Body.String(), "[Event "); rec.Code != http.StatusOK || n != want {
+			t.Errorf("%s: expected %d games, got %d (%d)", key, want, n, rec.Code)
+		}
+	}
+}
+
+func TestExportGamesWhilePlayed(t *testing.T) {
+	r := gameIDsServer(t, config.GameIDsUUID)
+	_, game := gameIDsRequest(t, r, http.MethodPost, "/api/games", "")
+
+	// Moves made during the export do not race with it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, move := range []string{"g1f3", "g8f6", "f3g1", "f6g8"} {
+			playerRequest(r, http.MethodPost, "/api/games/"+game.UUID+"/moves", "", `{"notation":"`+move+`"}`)
+		}
+	}()
+	rec := playerRequest(r, http.MethodGet, "/api/games/export?format=zip", "", "")
+	<-done
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil || len(archive.File) != 1 {
+		t.Fatalf("expected a zip of the game, got %d %v", rec.Code, err)
+	}
+	if name := archive.File[0].Name; name != "game-1.pgn" {
+		t.Errorf("expected the entry named by game number, not its link, got %s", name)
+	}
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"go.rumenx.com/chess/config"
+)
+
+func gameIDsServer(t *testing.T, strategy string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Server.GameIDs = strategy
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	return r
+}
+
+func gameIDsRequest(t *testing.T, r *gin.Engine, method, path, body string) (*httptest.ResponseRecorder, GameResponse) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var game GameResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &game)
+	return rec, game
+}
+
+func TestSequentialGameIDs(t *testing.T) {
+	r := gameIDsServer(t, config.GameIDsSequential)
+	_, created := gameIDsRequest(t, r, http.MethodPost, "/api/games", "")
+	if created.ID != 1 {
+		t.Fatalf("expected game 1, got %d", created.ID)
+	}
+	if _, err := uuid.Parse(created.UUID); err != nil {
+		t.Fatalf("expected a UUID, got %q", created.UUID)
+	}
+
+	for _, path := range []string{"/api/games/1", "/api/games/" + created.UUID} {
+		if rec, game := gameIDsRequest(t, r, http.MethodGet, path, ""); rec.Code != http.StatusOK || game.UUID != created.UUID {
+			t.Errorf("GET %s: expected the game, got %d %s", path, rec.Code, rec.Body.String())
+		}
+	}
+	if rec, _ := gameIDsRequest(t, r, http.MethodGet, "/api/games/"+uuid.NewString(), ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown UUID, got %d", rec.Code)
+	}
+	if rec, _ := gameIDsRequest(t, r, http.MethodGet, "/api/games/abc", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed ID, got %d", rec.Code)
+	}
+}
+
+func TestUUIDGameIDs(t *testing.T) {
+	r := gameIDsServer(t, config.GameIDsUUID)
+	rec, created := gameIDsRequest(t, r, http.MethodPost, "/api/games", "")
+	if rec.Code != http.StatusCreated || created.UUID == "" {
+		t.Fatalf("expected a game with a UUID, got %d %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), `"id"`) {
+		t.Errorf("expected no game number in %s", rec.Body.String())
+	}
+
+	rec, _ = gameIDsRequest(t, r, http.MethodGet, "/api/games/1", "")
+	var errResp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil || rec.Code != http.StatusBadRequest || errResp.Error != "invalid_game_id" {
+		t.Errorf("expected game numbers refused, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	path := "/api/games/" + created.UUID
+	if rec, _ := gameIDsRequest(t, r, http.MethodPost, path+"/moves", `{"from":"e2","to":"e4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected a move by UUID, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec, game := gameIDsRequest(t, r, http.MethodGet, "/api/games/"+strings.ToUpper(created.UUID), ""); rec.Code != http.StatusOK || game.MoveCount != 1 {
+		t.Errorf("expected the game by its UUID in any case, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec, _ := gameIDsRequest(t, r, http.MethodDelete, path, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected the game deleted, got %d", rec.Code)
+	}
+	if rec, _ := gameIDsRequest(t, r, http.MethodGet, path, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after the delete, got %d", rec.Code)
+	}
+}
+
+func TestUUIDGameListing(t *testing.T) {
+	r := gameIDsServer(t, config.GameIDsUUID)
+	_, created := gameIDsRequest(t, r, http.MethodPost, "/api/games", "")
+	rec, _ := gameIDsRequest(t, r, http.MethodGet, "/api/games", "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), created.UUID) || !strings.Contains(rec.Body.String(), `"count":1`) {
+		t.Errorf("expected the game listed without its link, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// With auth on users list their own games, with their links
+	cfg := config.Default()
+	cfg.Server.GameIDs = config.GameIDsUUID
+	cfg.Server.Auth = config.AuthConfig{Enabled: true, APIKeys: []config.APIKey{
+		{Key: "alice-key", UserID: "alice", Scopes: []string{config.ScopeRead, config.ScopePlay}},
+		{Key: "bob-key", UserID: "bob", Scopes: []string{config.ScopeRead, config.ScopePlay}},
+		{Key: "ops-key", UserID: "ops", Scopes: []string{config.ScopeAdmin}},
+	}}
+	s := NewServer(cfg)
+	r = gin.New()
+	s.SetupRoutes(r)
+	var game GameResponse
+	if err := json.Unmarshal(authRequest(r, http.MethodPost, "/api/v1/games", "alice-key", "").Body.Bytes(), &game); err != nil || game.UUID == "" {
+		t.Fatalf("create: %v %+v", err, game)
+	}
+	for key, want := range map[string]bool{"alice-key": true, "bob-key": false, "ops-key": true} {
+		rec := authRequest(r, http.MethodGet, "/api/v1/games", key, "")
+		if got := strings.Contains(rec.Body.String(), game.UUID); got != want {
+			t.Errorf("%s: expected the game listed %v, got %d %s", key, want, rec.Code, rec.Body.String())
+		}
+	}
+}
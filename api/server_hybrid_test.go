@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestHybridEngineAIMove(t *testing.T) {
+	var moveRequests atomic.Int32
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["format"] != nil {
+			moveRequests.Add(1)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": "The king's pawn, how classical!"}})
+	}))
+	defer llm.Close()
+	t.Setenv("OLLAMA_ENDPOINT", llm.URL)
+
+	for _, enabled := range []bool{true, false} {
+		gin.SetMode(gin.TestMode)
+		cfg := config.Default()
+		cfg.LLMAI.Enabled = enabled
+		s := NewServer(cfg)
+		r := gin.New()
+		s.SetupRoutes(r)
+
+		id := createGame(t, r)
+		post := func(path, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			return rec
+		}
+		if rec := post("/moves", `{"from":"e2","to":"e4"}`); rec.Code != http.StatusOK {
+			t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+		}
+		rec := post("/ai-move", `{"engine":"hybrid","provider":"ollama","level":"easy"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ai-move: %d %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Reaction string             `json:"reaction"`
+			Search   SearchInfoResponse `json:"search"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Search.Depth == 0 && !resp.Search.Book {
+			t.Errorf("expected a searched or book move, got %v", resp.Search)
+		}
+		if want := map[bool]string{true: "The king's pawn, how classical!", false: ""}[enabled]; resp.Reaction != want {
+			t.Errorf("LLM enabled %v: expected reaction %q, got %q", enabled, want, resp.Reaction)
+		}
+	}
+	if n := moveRequests.Load(); n != 0 {
+		t.Errorf("expected the LLM not to be asked for moves, got %d requests", n)
+	}
+}
+
+// TestHybridReactionUnlocked verifies the hybrid engine's reaction is generated
+// after the game's lock is released.
+func TestHybridReactionUnlocked(t *testing.T) {
+	var s *Server
+	var id int
+	var lockedDuringReaction atomic.Bool
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.gamesMux.RLock()
+		lock := s.gameLocks[id].(*sync.Mutex)
+		s.gamesMux.RUnlock()
+		if lock.TryLock() {
+			lock.Unlock()
+		} else {
+			lockedDuringReaction.Store(true)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": "Bold!"}})
+	}))
+	defer llm.Close()
+	t.Setenv("OLLAMA_ENDPOINT", llm.URL)
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.Enabled = true
+	s = NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	id = createGame(t, r)
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	post("/moves", `{"from":"e2","to":"e4"}`)
+	rec := post("/ai-move", `{"engine":"hybrid","provider":"ollama","level":"easy","apply":true}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"reaction":"Bold!"`) {
+		t.Fatalf("expected the applied move with a reaction, got %d %s", rec.Code, rec.Body.String())
+	}
+	if lockedDuringReaction.Load() {
+		t.Error("expected the game unlocked while the LLM reacted")
+	}
+}
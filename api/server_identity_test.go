@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestChatUserIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	if s.chatService == nil {
+		t.Skip("chat service unavailable")
+	}
+	s.chatService.SetChatbotForTesting(cannedChatbot{reply: "Good question."})
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := createGame(t, r)
+
+	chat := func(header, value string) (int, ChatResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/chat", strings.NewReader(`{"message":"Hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		var resp ChatResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	if _, resp := chat(userIDHeader, "alice"); resp.UserID != "alice" {
+		t.Errorf("expected alice from the header, got %q", resp.UserID)
+	}
+	_, first := chat("Authorization", "Bearer secret-token")
+	_, second := chat("Authorization", "Bearer secret-token")
+	if !strings.HasPrefix(first.UserID, "user-") || first.UserID != second.UserID || strings.Contains(first.UserID, "secret") {
+		t.Errorf("expected a stable ID derived from the token, got %q and %q", first.UserID, second.UserID)
+	}
+	if _, resp := chat("", ""); resp.UserID != defaultUserID {
+		t.Errorf("expected the default user, got %q", resp.UserID)
+	}
+	if code, _ := chat(userIDHeader, strings.Repeat("x", maxUserIDLength+1)); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a long user ID, got %d", code)
+	}
+
+	var senders []string
+	for _, message := range s.chatService.GetConversationHistory(id) {
+		if message.Type == "user" {
+			senders = append(senders, message.UserID)
+		}
+	}
+	if len(senders) != 4 || senders[0] != "alice" || senders[3] != defaultUserID {
+		t.Errorf("expected the senders recorded on the messages, got %v", senders)
+	}
+}
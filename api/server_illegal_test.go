@@ -0,0 +1,34 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIllegalMoveReasons(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+
+	for body, want := range map[string]string{
+		`{"from":"a1","to":"a2"}`: "own_piece",
+		`{"from":"e7","to":"e5"}`: "wrong_turn",
+		`{"from":"e2","to":"e5"}`: "invalid_pattern",
+		`{"notation":"O-O"}`:      "castling_blocked",
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/moves", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		var resp ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusBadRequest || resp.Error != "illegal_move" || resp.Reason != want {
+			t.Errorf("%s: expected illegal_move/%s, got %d %+v", body, want, rec.Code, resp)
+		}
+	}
+}
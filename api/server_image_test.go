@@ -0,0 +1,61 @@
+package api
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGameImage(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := itoa(createGame(t, r))
+	for _, move := range []string{"e2e4", "f7f6", "d2d4", "g7g5", "d1h5"} {
+		if rec := playerRequest(r, http.MethodPost, "/api/games/"+id+"/moves", "", `{"notation":"`+move+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("move %s: %d %s", move, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := playerRequest(r, http.MethodGet, "/api/games/"+id+"/image?size=256&theme=dark", "", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("expected an SVG, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	svg := rec.Body.String()
+	if !strings.Contains(svg, `width="256"`) || !strings.Contains(svg, `fill="#e03030"`) {
+		t.Fatalf("expected a dark 256px board with the mated king highlighted, got %.200s", svg)
+	}
+
+	// Before the first move nothing is highlighted; the dark theme has no red
+	rec = playerRequest(r, http.MethodGet, "/api/games/"+id+"/image?move=0&theme=dark", "", "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `fill="#e03030"`) || strings.Contains(rec.Body.String(), `fill="#3ca0c8"`) {
+		t.Fatalf("expected the starting position without highlights, got %d", rec.Code)
+	}
+
+	rec = playerRequest(r, http.MethodGet, "/api/games/"+id+"/image?format=png&size=160&move=3&orientation=black", "", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("expected a PNG, got %d %s", rec.Code, rec.Body.String())
+	}
+	img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil || img.Bounds().Dx() != 160 {
+		t.Fatalf("expected a 160px PNG: %v", err)
+	}
+
+	for query, code := range map[string]string{
+		"move=6":             "invalid_move",
+		"move=-1":            "invalid_move",
+		"size=16":            "invalid_size",
+		"size=big":           "invalid_size",
+		"theme=blue":         "invalid_theme",
+		"format=gif":         "invalid_format",
+		"orientation=random": "invalid_orientation",
+	} {
+		rec := playerRequest(r, http.MethodGet, "/api/games/"+id+"/image?"+query, "", "")
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), code) {
+			t.Errorf("%s: expected %s, got %d %s", query, code, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := playerRequest(r, http.MethodGet, "/api/games/999/image", "", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown game, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestLanguageOfHintExplanation(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct{ Role, Content string } `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		content := "e4 takes the centre."
+		if strings.Contains(req.Messages[0].Content, "Reply in German") {
+			content = "e4 besetzt das Zentrum."
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": content}})
+	}))
+	defer llm.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.Enabled = true
+	cfg.LLMAI.DefaultProvider = "ollama"
+	cfg.LLMAI.Providers["ollama"] = config.LLMProviderConfig{Endpoint: llm.URL, Model: "llama3.2"}
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := createGame(t, r)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for language, want := range map[string]string{"": "e4 takes the centre.", "de-DE": "e4 besetzt das Zentrum."} {
+		rec := post("/ai-hint", `{"engine":"minimax","level":"easy","language":"`+language+`"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ai-hint: %d %s", rec.Code, rec.Body.String())
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp["explanation"] != want {
+			t.Errorf("language %q: expected explanation %q, got %v", language, want, resp["explanation"])
+		}
+	}
+
+	for _, path := range []string{"/ai-hint", "/chat", "/react"} {
+		rec := post(path, `{"engine":"minimax","message":"hi","move":"e2e4","language":"Klingon"}`)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_language") {
+			t.Errorf("%s: expected 400 invalid_language, got %d %s", path, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLifecycleTransitions(t *testing.T) {
+	if !StateCreated.CanTransition(StateAwaitingPlayers) || !StatePaused.CanTransition(StateActive) {
+		t.Fatal("expected valid transitions")
+	}
+	if StateFinished.CanTransition(StateActive) || StateArchived.CanTransition(StateFinished) || StateActive.CanTransition(StateCreated) {
+		t.Fatal("expected invalid transitions to be rejected")
+	}
+}
+
+func TestLifecycleEndpoints(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	base := "/api/games/" + itoa(id)
+
+	do := func(method, path, body string) (*httptest.ResponseRecorder, GameResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		var state GameResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &state)
+		return rec, state
+	}
+	move := func(from, to string) *httptest.ResponseRecorder {
+		rec, _ := do(http.MethodPost, base+"/moves", `{"from":"`+from+`","to":"`+to+`"}`)
+		return rec
+	}
+
+	if _, state := do(http.MethodGet, base, ""); state.Lifecycle != "active" {
+		t.Fatalf("expected new game to be active, got %q", state.Lifecycle)
+	}
+	if rec, state := do(http.MethodPost, base+"/pause", ""); rec.Code != http.StatusOK || state.Lifecycle != "paused" {
+		t.Fatalf("pause: %d %q", rec.Code, state.Lifecycle)
+	}
+	if rec := move("f2", "f3"); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "game_not_active") {
+		t.Fatalf("expected move on paused game to be rejected, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec, _ := do(http.MethodPost, base+"/pause", ""); rec.Code != http.StatusConflict {
+		t.Fatalf("expected invalid transition, got %d", rec.Code)
+	}
+	if rec, state := do(http.MethodPost, base+"/resume", ""); rec.Code != http.StatusOK || state.Lifecycle != "active" {
+		t.Fatalf("resume: %d %q", rec.Code, state.Lifecycle)
+	}
+	if rec, _ := do(http.MethodPost, base+"/archive", ""); rec.Code != http.StatusConflict {
+		t.Fatalf("expected active game archive to be rejected, got %d", rec.Code)
+	}
+
+	// Fool's mate finishes the game
+	for _, m := range [][2]string{{"f2", "f3"}, {"e7", "e5"}, {"g2", "g4"}, {"d8", "h4"}} {
+		if rec := move(m[0], m[1]); rec.Code != http.StatusOK {
+			t.Fatalf("move %v: %d %s", m, rec.Code, rec.Body.String())
+		}
+	}
+	if _, state := do(http.MethodGet, base, ""); state.Lifecycle != "finished" || state.Status != "black_wins" {
+		t.Fatalf("expected finished game, got %q / %q", state.Lifecycle, state.Status)
+	}
+	if rec, state := do(http.MethodPost, base+"/archive", ""); rec.Code != http.StatusOK || state.Lifecycle != "archived" {
+		t.Fatalf("archive: %d %q", rec.Code, state.Lifecycle)
+	}
+
+	createGame(t, r)
+	req := httptest.NewRequest(http.MethodGet, "/api/games?state=archived", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var list struct {
+		Games []GameResponse `json:"games"`
+		Count int            `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Count != 1 || list.Games[0].ID != id {
+		t.Fatalf("expected only the archived game, got %+v", list)
+	}
+}
+
+// TestLifecycleReadUnderLock verifies game responses read the lifecycle state
+// under gamesMux, racing with pauses and resumes under -race.
+func TestLifecycleReadUnderLock(t *testing.T) {
+	s, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	s.gamesMux.RLock()
+	game := s.games[id]
+	s.gamesMux.RUnlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			_ = s.transition(id, StatePaused)
+			_ = s.transition(id, StateActive)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if state := s.gameToResponse(id, game).Lifecycle; state != "active" && state != "paused" {
+			t.Fatalf("unexpected lifecycle %q", state)
+		}
+	}
+	<-done
+}
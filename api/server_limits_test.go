@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAISearchLimits(t *testing.T) {
+	s, r := newTestServerAndRouter()
+	s.config.AI.MaxDepth = 4
+	id := createGame(t, r)
+	base := "/api/games/" + itoa(id)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, base+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The opening book answers the start position, so search after 1.h4
+	if rec := post("/moves", `{"from":"h2","to":"h4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+	}
+	rec := post("/ai-move", `{"engine":"minimax","level":"expert","max_depth":2}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ai-move: %d %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Search SearchInfoResponse `json:"search"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Search.Depth != 2 {
+		t.Errorf("expected a depth 2 search, got %+v", resp.Search)
+	}
+
+	rec = post("/ai-hint", `{"engine":"mcts","level":"expert","max_nodes":100,"movetime_ms":500}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ai-hint: %d %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Search.Nodes == 0 || resp.Search.Nodes > 100*6 {
+		t.Errorf("expected about 100 playouts, got %+v", resp.Search)
+	}
+
+	for _, body := range []string{
+		`{"engine":"minimax","max_depth":5}`,
+		`{"engine":"minimax","max_depth":-1}`,
+		`{"engine":"minimax","max_depth":0}`,
+		`{"engine":"minimax","max_nodes":0}`,
+		`{"engine":"minimax","movetime_ms":0}`,
+		`{"engine":"minimax","max_nodes":999999999}`,
+		`{"engine":"minimax","movetime_ms":3600000}`,
+	} {
+		for _, path := range []string{"/ai-move", "/ai-hint"} {
+			rec := post(path, body)
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_search_limits") {
+				t.Errorf("%s %s: expected 400 invalid_search_limits, got %d %s", path, body, rec.Code, rec.Body.String())
+			}
+		}
+	}
+}
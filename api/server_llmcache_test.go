@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestLLMCacheSharedAcrossGames(t *testing.T) {
+	var moveRequests atomic.Int32
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		content := "e4 grabs the centre."
+		if body["format"] != nil {
+			moveRequests.Add(1)
+			content = `{"from":"e2","to":"e4","promotion":""}`
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": content}})
+	}))
+	defer llm.Close()
+	t.Setenv("OLLAMA_ENDPOINT", llm.URL)
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.Enabled = true
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	for i := 0; i < 2; i++ {
+		id := createGame(t, r)
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/ai-hint",
+			strings.NewReader(`{"engine":"llm","provider":"ollama","level":"easy"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "e4") {
+			t.Fatalf("ai-hint: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	if n := moveRequests.Load(); n != 1 {
+		t.Errorf("expected the second game to reuse the cached move, got %d move requests", n)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var resp struct {
+		LLMCache map[string]float64 `json:"llm_cache"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.LLMCache["hits"] != 1 || resp.LLMCache["size"] != 1 {
+		t.Errorf("expected one cached move and one hit, got %v", resp.LLMCache)
+	}
+}
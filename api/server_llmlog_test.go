@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestLLMExchangeLogging(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": "e4 takes the centre, ask me at coach@example.com"}})
+	}))
+	defer llm.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	if NewServer(cfg).llmLog != nil {
+		t.Fatal("expected LLM exchange logging to be off by default")
+	}
+	cfg.LLMAI.Enabled = true
+	cfg.LLMAI.LogExchanges = true
+	cfg.LLMAI.DefaultProvider = "ollama"
+	cfg.LLMAI.Providers["ollama"] = config.LLMProviderConfig{Endpoint: llm.URL, Model: "llama3.2"}
+	s := NewServer(cfg)
+	if s.llmLog == nil {
+		t.Fatal("expected LLM exchange logging when enabled")
+	}
+	core, logs := observer.New(zap.InfoLevel)
+	s.llmLog = llmExchangeLogger(zap.New(core))
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	id := createGame(t, r)
+	req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/ai-hint", strings.NewReader(`{"engine":"minimax","level":"easy"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ai-hint: %d %s", rec.Code, rec.Body.String())
+	}
+
+	entries := logs.FilterMessage("LLM exchange").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected one logged exchange, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["correlation_id"] != "game-"+itoa(id) || fields["kind"] != "explanation" || fields["provider"] != "ollama" {
+		t.Errorf("unexpected exchange fields %v", fields)
+	}
+	if fields["response"] != "e4 takes the centre, ask me at [email]" || !strings.Contains(fields["prompt"].(string), "FEN") {
+		t.Errorf("expected the redacted prompt and response, got %v", fields)
+	}
+}
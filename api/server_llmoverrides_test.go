@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestLLMOverrides(t *testing.T) {
+	var last map[string]any
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		content := "A fine move."
+		if body["format"] != nil {
+			last = body
+			content = `{"from":"e2","to":"e4","promotion":""}`
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": content}})
+	}))
+	defer llm.Close()
+	t.Setenv("OLLAMA_ENDPOINT", llm.URL)
+	t.Setenv("OLLAMA_ALLOWED_MODELS", "qwen2.5, mistral")
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.Enabled = true
+	cfg.LLMAI.CacheTTL = 0
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := createGame(t, r)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	hint := "/api/games/" + itoa(id) + "/ai-hint"
+
+	rec := post(hint, `{"engine":"llm","provider":"ollama","model":"mistral","temperature":0.3}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ai-hint: %d %s", rec.Code, rec.Body.String())
+	}
+	options, _ := last["options"].(map[string]any)
+	if last["model"] != "mistral" || options["temperature"] != 0.3 {
+		t.Errorf("expected the requested model and temperature, got %v", last)
+	}
+
+	for _, body := range []string{
+		`{"engine":"llm","provider":"ollama","model":"gpt-4o"}`,
+		`{"engine":"llm","provider":"ollama","temperature":2.5}`,
+		`{"engine":"llm","provider":"ollama","temperature":-1}`,
+		`{"engine":"llm","model":"mistral"}`, // not allowed for the default provider
+	} {
+		rec := post(hint, body)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_llm_override") {
+			t.Errorf("%s: expected 400 invalid_llm_override, got %d %s", body, rec.Code, rec.Body.String())
+		}
+	}
+
+	// The configured model is always allowed
+	if rec := post(hint, `{"engine":"llm","provider":"ollama","model":"llama3.2"}`); rec.Code != http.StatusOK || last["model"] != "llama3.2" {
+		t.Errorf("expected the configured model, got %d with %v", rec.Code, last["model"])
+	}
+
+	for _, body := range []string{
+		`{"message":"Hi","provider":"openai","model":"gpt-4-turbo"}`,
+		`{"message":"Hi","temperature":0.5}`,
+	} {
+		rec := post("/api/games/"+itoa(id)+"/chat", body)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_llm_override") {
+			t.Errorf("%s: expected 400 invalid_llm_override, got %d %s", body, rec.Code, rec.Body.String())
+		}
+	}
+}
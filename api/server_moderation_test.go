@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestModeratedHybridReaction(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": "Damn, the king's pawn!"}})
+	}))
+	defer llm.Close()
+	t.Setenv("OLLAMA_ENDPOINT", llm.URL)
+
+	for _, blocked := range []bool{false, true} {
+		gin.SetMode(gin.TestMode)
+		cfg := config.Default()
+		cfg.LLMAI.Enabled = true
+		cfg.LLMAI.Moderation.MaskedWords = []string{"damn"}
+		if blocked {
+			cfg.LLMAI.Moderation.BlockedWords = []string{"damn"}
+		}
+		s := NewServer(cfg)
+		r := gin.New()
+		s.SetupRoutes(r)
+
+		id := createGame(t, r)
+		post := func(path, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			return rec
+		}
+		if rec := post("/moves", `{"from":"e2","to":"e4"}`); rec.Code != http.StatusOK {
+			t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+		}
+		rec := post("/ai-move", `{"engine":"hybrid","provider":"ollama","level":"easy"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ai-move: %d %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Reaction string `json:"reaction"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		want := "****, the king's pawn!"
+		if blocked {
+			want = cfg.LLMAI.Moderation.Replacement
+		}
+		if resp.Reaction != want {
+			t.Errorf("blocked %v: expected reaction %q, got %q", blocked, want, resp.Reaction)
+		}
+	}
+}
+
+func TestChatInputFilter(t *testing.T) {
+	for _, action := range []string{"reject", "sanitize"} {
+		gin.SetMode(gin.TestMode)
+		cfg := config.Default()
+		cfg.LLMAI.Moderation.InputAction = action
+		s := NewServer(cfg)
+		if s.chatService == nil {
+			t.Skip("chat service unavailable")
+		}
+		s.chatService.SetChatbotForTesting(cannedChatbot{reply: "Let's talk about the position."})
+		r := gin.New()
+		s.SetupRoutes(r)
+
+		id := createGame(t, r)
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/chat", strings.NewReader(`{"message":"What a shit move"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if action == "reject" {
+			var errResp ErrorResponse
+			_ = json.Unmarshal(rec.Body.Bytes(), &errResp)
+			if rec.Code != http.StatusBadRequest || errResp.Error != "message_rejected" || errResp.Reason != "profanity" {
+				t.Errorf("reject: expected 400 message_rejected, got %d %s", rec.Code, rec.Body.String())
+			}
+			continue
+		}
+		var resp ChatResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusOK || len(resp.Filtered) != 1 || resp.Filtered[0] != "profanity" {
+			t.Errorf("sanitize: expected 200 with the filter's reasons, got %d %s", rec.Code, rec.Body.String())
+		}
+		if history := s.chatService.GetConversationHistory(id); history[1].Content != "What a **** move" {
+			t.Errorf("sanitize: expected the sanitized message stored, got %q", history[1].Content)
+		}
+	}
+}
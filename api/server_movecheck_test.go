@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMakeMove_Check(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/moves", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	for _, m := range []string{"e2e4", "e7e5", "d1h5", "b8c6", "f1c4"} {
+		if rec := post(`{"notation":"` + m + `","check":true}`); rec.Code != http.StatusOK {
+			t.Fatalf("move %s: %d %s", m, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := post(`{"from":"g8","to":"f6","check":true}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for Nf6??, got %d %s", rec.Code, rec.Body.String())
+	}
+	var warning MoveCheckResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &warning); err != nil {
+		t.Fatal(err)
+	}
+	if warning.Error != "questionable_move" || warning.Class != "blunder" || warning.Move != "Nf6" || warning.BestMove == "" {
+		t.Errorf("unexpected warning %+v", warning)
+	}
+
+	// Confirming without the check plays the move
+	if rec := post(`{"from":"g8","to":"f6"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected the unchecked move to be played, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	routes := map[string]bool{}
+	for _, route := range r.Routes() {
+		key := route.Method + " " + unversionedPath(route.Path)
+		routes[key] = true
+		if operations[key].Summary == "" {
+			t.Errorf("route %s %s is not documented", route.Method, route.Path)
+		}
+	}
+	for key := range operations {
+		if !routes[key] {
+			t.Errorf("documented operation %s has no route", key)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	rec := playerRequest(r, http.MethodGet, openAPIPath, "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Info       struct{ Version string }             `json:"info"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+				Required   []string       `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" || doc.Info.Version != APIVersion {
+		t.Fatalf("unexpected header %s %s", doc.OpenAPI, doc.Info.Version)
+	}
+
+	move := doc.Paths["/api/games/{id}/moves"]["post"]
+	if move == nil || move["requestBody"] == nil {
+		t.Fatalf("expected POST /api/games/{id}/moves with a request body, got %v", move)
+	}
+	if _, ok := doc.Components.Schemas["MoveRequest"].Properties["notation"]; !ok {
+		t.Errorf("expected MoveRequest to have notation, got %v", doc.Components.Schemas["MoveRequest"])
+	}
+	if required := doc.Components.Schemas["InvitePlayerRequest"].Required; len(required) != 1 || required[0] != "user_id" {
+		t.Errorf("expected InvitePlayerRequest to require user_id, got %v", required)
+	}
+
+	// Every reference resolves
+	for _, ref := range strings.Split(rec.Body.String(), `"$ref":"#/components/schemas/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("unresolved reference to %s", name)
+		}
+	}
+}
+
+func TestOpenAPIDocumentIsPublic(t *testing.T) {
+	r := authServer(t)
+	rec := authRequest(r, http.MethodGet, openAPIPath, "", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"securitySchemes"`) {
+		t.Fatalf("expected the document with security schemes without credentials, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+	"go.rumenx.com/chess/engine"
+)
+
+func TestLLMSessionPersistence(t *testing.T) {
+	var mu sync.Mutex
+	var moveMessages []int
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []json.RawMessage `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		moveMessages = append(moveMessages, len(body.Messages))
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": `{"from":"e2","to":"e4","promotion":""}`}})
+	}))
+	defer llm.Close()
+	t.Setenv("OLLAMA_ENDPOINT", llm.URL)
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.Enabled = true
+	cfg.LLMAI.CacheTTL = 0
+	cfg.Database.Enabled = true
+	cfg.Database.ConnectionString = filepath.Join(t.TempDir(), "chess.db")
+	s := NewServer(cfg)
+	if s.store == nil {
+		t.Fatal("expected a store when the database is enabled")
+	}
+	defer s.store.Close()
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(`{"ai_color":"white","personality":"romantic-attacker"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create game: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Each request builds a new engine, which remembers the game's earlier moves
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/1/ai-move", strings.NewReader(`{"engine":"llm","provider":"ollama","level":"easy"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ai-move: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	if len(moveMessages) != 2 || moveMessages[1] != moveMessages[0]+2 {
+		t.Errorf("expected the second move request to carry the first exchange, got %v messages", moveMessages)
+	}
+
+	// Another server on the database carries on with the game and its conversation
+	s.flushGames()
+	restarted := NewServer(cfg)
+	defer restarted.store.Close()
+	if conversation := restarted.chatService.GetConversation(1); conversation == nil || conversation.Personality != "romantic-attacker" {
+		t.Fatalf("expected the stored conversation, got %+v", conversation)
+	}
+	if _, exists := restarted.games[1]; !exists {
+		t.Fatal("expected the game restored")
+	}
+
+	// and new games follow the restored ones
+	r = gin.New()
+	restarted.SetupRoutes(r)
+	if id := createGame(t, r); id != 2 {
+		t.Fatalf("expected the new game to get ID 2, got %d", id)
+	}
+	if history, err := restarted.store.LoadLLMHistory(context.Background(), 2); err != nil || history != nil {
+		t.Errorf("expected no LLM history for the new game, got %v: %v", history, err)
+	}
+}
+
+// TestGamePersistence restores games, their settings, lifecycle, clocks and
+// annotations on another server on the same database, and forgets deleted ones.
+func TestGamePersistence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Database.Enabled = true
+	cfg.Database.ConnectionString = filepath.Join(t.TempDir(), "chess.db")
+	s := NewServer(cfg)
+	if s.saver == nil {
+		t.Fatal("expected games saved when the database is enabled")
+	}
+	defer s.store.Close()
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	do := func(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code >= http.StatusBadRequest {
+			t.Fatalf("%s %s: %d %s", method, path, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+	do(r, http.MethodPost, "/api/games", `{"ai_color":"white","time_control":"300+2","personality":"silent-assassin"}`)
+	for _, move := range []string{`{"from":"e2","to":"e4"}`, `{"from":"e7","to":"e5"}`} {
+		do(r, http.MethodPost, "/api/games/1/moves", move)
+	}
+	do(r, http.MethodPut, "/api/games/1/moves/0/annotation", `{"comment":"Best by test"}`)
+	do(r, http.MethodPost, "/api/games/1/pause", "")
+	do(r, http.MethodPost, "/api/games", "")
+	do(r, http.MethodDelete, "/api/games/2", "")
+	s.flushGames()
+
+	restarted := NewServer(cfg)
+	defer restarted.store.Close()
+	if _, exists := restarted.games[2]; exists {
+		t.Error("expected the deleted game to stay deleted")
+	}
+	game, metadata := restarted.games[1], restarted.gameMetadata[1]
+	if game == nil || len(game.MoveHistory()) != 2 || game.ToFEN() != s.games[1].ToFEN() {
+		t.Fatalf("expected the game restored after 1. e4 e5, got %v", game)
+	}
+	if metadata.UUID == "" || metadata.UUID != s.gameMetadata[1].UUID || restarted.gameUUIDs[metadata.UUID] != 1 {
+		t.Errorf("expected the game's UUID kept, got %q", metadata.UUID)
+	}
+	if metadata.AIColor != "white" || metadata.Personality != "silent-assassin" || metadata.Lifecycle != StatePaused || !metadata.CreatedAt.Equal(s.gameMetadata[1].CreatedAt) {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+	if game.Annotations()[0].Comment != "Best by test" {
+		t.Errorf("expected the annotation restored, got %+v", game.Annotations())
+	}
+	clock := game.Clock()
+	if clock == nil || clock.Running() || (clock.Remaining(engine.Black)-s.games[1].Clock().Remaining(engine.Black)).Abs() > time.Second {
+		t.Errorf("expected the paused clock restored with black's time left")
+	}
+
+	r = gin.New()
+	restarted.SetupRoutes(r)
+	do(r, http.MethodPost, "/api/games/1/resume", "")
+	do(r, http.MethodPost, "/api/games/1/moves", `{"from":"g1","to":"f3"}`)
+	restarted.flushGames()
+	saved, err := restarted.saver.games.LoadGame(context.Background(), 1)
+	if err != nil || saved == nil || len(saved.Moves) != 3 || saved.Moves[2].SAN != "Nf3" || saved.Moves[2].UCI != "g1f3" {
+		t.Fatalf("expected the move history saved, got %+v: %v", saved, err)
+	}
+}
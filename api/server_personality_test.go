@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/config"
+)
+
+func TestGamePersonality(t *testing.T) {
+	var mu sync.Mutex
+	var systemPrompts []string
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct{ Role, Content string } `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		systemPrompts = append(systemPrompts, req.Messages[0].Content)
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": `{"from":"e2","to":"e4","promotion":""}`}})
+	}))
+	defer llm.Close()
+	t.Setenv("OLLAMA_ENDPOINT", llm.URL)
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.Enabled = true
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/api/personalities", "")
+	var list struct{ Personalities []ai.Personality }
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Personalities) != len(ai.Personalities) {
+		t.Fatalf("personalities: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodPost, "/api/games", `{"personality":"chatty-cathy"}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_personality") {
+		t.Errorf("expected 400 invalid_personality, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodPost, "/api/games", `{"ai_color":"white","personality":"Grumpy Grandmaster"}`)
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil || game.Personality != "grumpy-grandmaster" {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodPost, "/api/games/"+itoa(game.ID)+"/ai-move", `{"engine":"llm","provider":"ollama","level":"easy"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ai-move: %d %s", rec.Code, rec.Body.String())
+	}
+	grumpy, _ := ai.LookupPersonality("grumpy-grandmaster")
+	mu.Lock()
+	defer mu.Unlock()
+	if len(systemPrompts) == 0 || !strings.Contains(systemPrompts[0], grumpy.Prompt) {
+		t.Errorf("expected the LLM to play the game's personality, got %q", systemPrompts)
+	}
+}
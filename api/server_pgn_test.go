@@ -71,6 +71,9 @@ func TestPGNEndpointBasic(t *testing.T) {
 		}
 	}
 	// Accept either coordinate or SAN notation for first move (now SAN: e4)
+	if !strings.Contains(pgn, `[Termination "unterminated"]`) {
+		t.Errorf("PGN missing Termination tag for an unfinished game, got: %s", pgn)
+	}
 	if !strings.Contains(pgn, "1. e4") && !strings.Contains(pgn, "1. e2e4") {
 		t.Errorf("PGN missing first move sequence, got: %s", pgn)
 	}
@@ -81,3 +84,41 @@ func TestPGNEndpointBasic(t *testing.T) {
 		}
 	}
 }
+
+func TestAnnotateMoveEndpoint(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	for _, mv := range []string{`{"from":"e2","to":"e4"}`, `{"from":"e7","to":"e5"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/moves", strings.NewReader(mv))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("move %s: status %d body=%s", mv, rec.Code, rec.Body.String())
+		}
+	}
+
+	annotate := func(index, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/games/"+itoa(id)+"/moves/"+index+"/annotation", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := annotate("0", `{"comment":"King's pawn","nags":[1]}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"nags":[1]`) {
+		t.Fatalf("annotate: status %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := annotate("5", `{"comment":"x"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing move, got %d", rec.Code)
+	}
+	if rec := annotate("1", `{"nags":[300]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid NAG, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/games/"+itoa(id)+"/pgn", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if !regexp.MustCompile(`1\. e4 \{[^}]*\} \$1 \{King's pawn\} 1\.\.\. e5 \{[^}]*\} \*`).MatchString(rec.Body.String()) {
+		t.Fatalf("annotation missing from PGN:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func playerRequest(r *gin.Engine, method, path, playerToken, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if playerToken != "" {
+		req.Header.Set(playerTokenHeader, playerToken)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func createTwoPlayerGame(t *testing.T, r *gin.Engine) GameResponse {
+	t.Helper()
+	rec := playerRequest(r, http.MethodPost, "/api/games", "", `{"ai_color":"none"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+func TestTwoPlayerGame(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	game := createTwoPlayerGame(t, r)
+	white, black := game.PlayerTokens["white"], game.PlayerTokens["black"]
+	if white == "" || black == "" || white == black {
+		t.Fatalf("expected distinct tokens for both colors, got %v", game.PlayerTokens)
+	}
+	if game.AIColor != "none" || game.Lifecycle != string(StateAwaitingPlayers) {
+		t.Fatalf("expected a game awaiting players, got ai_color %q lifecycle %q", game.AIColor, game.Lifecycle)
+	}
+	base := "/api/games/" + game.UUID
+
+	// Tokens are shown only once, and stored hashed
+	rec := playerRequest(r, http.MethodGet, base, "", "")
+	if strings.Contains(rec.Body.String(), white) || strings.Contains(rec.Body.String(), "player_tokens") {
+		t.Errorf("expected tokens kept out of game responses: %s", rec.Body.String())
+	}
+
+	move := `{"from":"e2","to":"e4"}`
+	if rec := playerRequest(r, http.MethodPost, base+"/moves", white, move); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 before both players joined, got %d", rec.Code)
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/join", "not-a-token", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for an unknown token, got %d", rec.Code)
+	}
+	rec = playerRequest(r, http.MethodPost, base+"/join", white, "")
+	var joined JoinGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &joined); err != nil || rec.Code != http.StatusOK || joined.Color != "white" {
+		t.Fatalf("expected white to join, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if joined.Game.Lifecycle != string(StateAwaitingPlayers) {
+		t.Errorf("expected the game to wait for black, got %s", joined.Game.Lifecycle)
+	}
+	rec = playerRequest(r, http.MethodPost, base+"/join", black, "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &joined); err != nil || joined.Color != "black" || joined.Game.Lifecycle != string(StateActive) {
+		t.Fatalf("expected the game active once black joined, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := playerRequest(r, http.MethodPost, base+"/moves", "", move); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a player token, got %d", rec.Code)
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/moves", black, move); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "not_your_turn") {
+		t.Errorf("expected black refused on white's turn, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/moves", white, move); rec.Code != http.StatusOK {
+		t.Fatalf("expected white's move played, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/moves", black, `{"from":"e7","to":"e5"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected black's move played, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/ai-move", white, ""); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "no_ai") {
+		t.Errorf("expected no AI in a two-player game, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodGet, base+"/pgn", "", ""); !strings.Contains(rec.Body.String(), `[White "White"]`) {
+		t.Errorf("expected no AI named in the PGN: %s", rec.Body.String())
+	}
+}
+
+func TestTwoPlayerGameOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	if rec := playerRequest(r, http.MethodPost, "/api/games", "", `{"ai_color":"none","adaptive":true}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected adaptive two-player games refused, got %d", rec.Code)
+	}
+	rec := playerRequest(r, http.MethodPost, "/api/games", "", `{"ai_color":"white"}`)
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil || game.PlayerTokens != nil {
+		t.Fatalf("expected no tokens for a game against the AI: %s", rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodPost, "/api/games/"+game.UUID+"/join", "x", ""); rec.Code != http.StatusConflict {
+		t.Errorf("expected games against the AI not joinable, got %d", rec.Code)
+	}
+}
+
+func TestTwoPlayerGameWithAuth(t *testing.T) {
+	r := authServer(t)
+	rec := authRequest(r, http.MethodPost, "/api/games", "alice-key", `{"ai_color":"none"}`)
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	base := "/api/games/" + game.UUID
+
+	// bob was not invited, but holds black's token
+	req := httptest.NewRequest(http.MethodPost, base+"/join", nil)
+	req.Header.Set("Authorization", "Bearer bob-key")
+	req.Header.Set(playerTokenHeader, game.PlayerTokens["black"])
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var joined JoinGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &joined); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the token holder to join, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(joined.Game.Players) != 1 || joined.Game.Players[0] != "bob" {
+		t.Errorf("expected bob among the players, got %v", joined.Game.Players)
+	}
+	if rec := authRequest(r, http.MethodPost, base+"/join", "bob-key", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("expected joining without a token refused, got %d", rec.Code)
+	}
+}
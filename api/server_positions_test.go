@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPositionAtPly(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := itoa(createGame(t, r))
+	for _, move := range []string{"e2e4", "e7e5", "g1f3"} {
+		if rec := playerRequest(r, http.MethodPost, "/api/games/"+id+"/moves", "", `{"notation":"`+move+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("move %s: %d %s", move, rec.Code, rec.Body.String())
+		}
+	}
+
+	var fen FENResponse
+	rec := playerRequest(r, http.MethodGet, "/api/games/"+id+"/fen", "", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &fen); err != nil || fen.Ply != 3 || fen.ActiveColor != "black" || !strings.HasPrefix(fen.FEN, "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/") {
+		t.Fatalf("expected the current FEN, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = playerRequest(r, http.MethodGet, "/api/games/"+id+"/fen?ply=1", "", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &fen); err != nil || fen.FEN != "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1" || fen.Ply != 1 {
+		t.Fatalf("expected the FEN after 1. e4, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	var game GameResponse
+	rec = playerRequest(r, http.MethodGet, "/api/games/"+id+"?ply=2", "", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil || len(game.MoveHistory) != 2 || game.ActiveColor != "white" || game.Lifecycle == "" {
+		t.Fatalf("expected the game after two plies with its metadata, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	var legal struct {
+		Count int `json:"count"`
+	}
+	rec = playerRequest(r, http.MethodGet, "/api/games/"+id+"/legal-moves?ply=0", "", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &legal); err != nil || legal.Count != 20 {
+		t.Fatalf("expected the 20 moves of the starting position, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = playerRequest(r, http.MethodGet, "/api/games/"+id+"/analysis?ply=0", "", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"move_count":1`) || !strings.Contains(rec.Body.String(), `"active_color":"white"`) {
+		t.Fatalf("expected the starting position analyzed, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	for _, path := range []string{"/fen?ply=4", "/fen?ply=-1", "?ply=x", "/legal-moves?ply=9", "/analysis?ply=9"} {
+		rec := playerRequest(r, http.MethodGet, "/api/games/"+id+path, "", "")
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_ply") {
+			t.Errorf("%s: expected invalid_ply, got %d %s", path, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestPositionAtPlyFromFEN(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := itoa(createGame(t, r))
+	start := "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"
+	if rec := playerRequest(r, http.MethodPost, "/api/games/"+id+"/fen", "", `{"fen":"`+start+`"}`); rec.Code != http.StatusOK {
+		t.Fatalf("load FEN: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodPost, "/api/games/"+id+"/moves", "", `{"notation":"e2e4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+	}
+	var fen FENResponse
+	rec := playerRequest(r, http.MethodGet, "/api/games/"+id+"/fen?ply=0", "", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &fen); err != nil || fen.FEN != start {
+		t.Fatalf("expected the loaded starting position, got %d %s", rec.Code, rec.Body.String())
+	}
+}
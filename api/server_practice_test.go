@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.rumenx.com/chess/config"
+)
+
+func TestPracticeSetLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	body := `{"title":"Basic endgames","engine":"random","positions":[
+		{"fen":"7k/8/6K1/8/8/8/8/R7 w - - 0 1","title":"Back rank mate","goal":"win this endgame","objective":"win"},
+		{"fen":"4k3/8/8/8/8/8/4P3/4K3 b - - 0 1","title":"Hold the draw","objective":"draw"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/practice-sets", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var set PracticeSet
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if set.Total != 2 || set.Positions[1].PlayerColor != "black" {
+		t.Fatalf("unexpected practice set: %+v", set)
+	}
+
+	// Start the first position: player is White, the AI takes Black
+	req = httptest.NewRequest(http.MethodPost, "/api/practice-sets/1/positions/0/start", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var started struct {
+		Game GameResponse `json:"game"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &started); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if started.Game.AIColor != "black" {
+		t.Fatalf("expected AI to play black, got %q", started.Game.AIColor)
+	}
+
+	// Deliver mate and check automatic completion
+	req = httptest.NewRequest(http.MethodPost, "/api/games/"+strconv.Itoa(started.Game.ID)+"/moves", strings.NewReader(`{"from":"a1","to":"a8"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("mate move expected 200 got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/practice-sets/1", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !set.Positions[0].Completed || set.Completed != 1 {
+		t.Fatalf("expected first position completed, got %+v", set.Positions[0])
+	}
+
+	// Manual completion of the second position
+	req = httptest.NewRequest(http.MethodPost, "/api/practice-sets/1/positions/1/complete", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if set.Completed != 2 {
+		t.Fatalf("expected both positions completed, got %d", set.Completed)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/practice-sets/1/positions/5/start", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for out-of-range index, got %d", rec.Code)
+	}
+}
+
+func TestPracticeSetRejectsInvalidFEN(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/practice-sets", strings.NewReader(`{"positions":[{"fen":"not a fen"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_fen") {
+		t.Fatalf("expected invalid_fen, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPracticeSetEngineAndLevel(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	body := `{"title":"Rook endings","engine":"minimax","level":"easy","positions":[{"fen":"7k/8/6K1/8/8/8/8/R7 w - - 0 1"}]}`
+	if rec := playerRequest(r, http.MethodPost, "/api/v1/practice-sets", "", body); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec := playerRequest(r, http.MethodPost, "/api/v1/practice-sets/1/positions/0/start", "", "")
+	var started struct {
+		Game GameResponse `json:"game"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &started); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	base := "/api/v1/games/" + strconv.Itoa(started.Game.ID)
+	if rec := playerRequest(r, http.MethodPost, base+"/moves", "", `{"notation":"a1a2"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// The AI plays with the set's engine and level unless the request overrides them
+	rec = playerRequest(r, http.MethodPost, base+"/ai-move", "", "")
+	var reply struct {
+		Engine string `json:"engine"`
+		Level  string `json:"level"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil || reply.Engine != "minimax" || reply.Level != "easy" {
+		t.Fatalf("expected the set's minimax at easy, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = playerRequest(r, http.MethodPost, base+"/ai-move", "", `{"engine":"random"}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil || reply.Engine != "random" || reply.Level != "easy" {
+		t.Fatalf("expected the requested engine, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func attemptPuzzleRequest(t *testing.T, r *gin.Engine, id, body string) (int, PuzzleAttemptResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/puzzles/"+id+"/attempts", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var resp PuzzleAttemptResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestNextPuzzle(t *testing.T) {
+	_, r := newTestServerAndRouter()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/puzzles?theme=fork", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	var p PuzzleResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "knight-fork" || p.PlayerColor != "white" || p.Moves != 2 || p.FEN == "" {
+		t.Fatalf("unexpected puzzle %+v", p)
+	}
+	if strings.Contains(rec.Body.String(), "c7a8") {
+		t.Errorf("expected the solution to stay hidden, got %s", rec.Body.String())
+	}
+
+	// A new solver is rated 1500; the easiest puzzles are near 900
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/puzzles?difficulty=easiest", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || p.Rating > 1000 {
+		t.Fatalf("expected an easy puzzle, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	for url, code := range map[string]int{
+		"/api/puzzles?difficulty=insane":                  http.StatusBadRequest,
+		"/api/puzzles?theme=no-such-theme":                http.StatusNotFound,
+		"/api/puzzles/no-such-puzzle":                     http.StatusNotFound,
+		"/api/puzzles/smothered-mate":                     http.StatusOK,
+		"/api/puzzles?user_id=" + strings.Repeat("x", 65): http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		if rec.Code != code {
+			t.Errorf("%s: expected %d, got %d", url, code, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/puzzles/themes", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"smotheredMate"`) {
+		t.Errorf("expected the theme list, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAttemptPuzzle(t *testing.T) {
+	_, r := newTestServerAndRouter()
+
+	code, resp := attemptPuzzleRequest(t, r, "smothered-mate", `{"user_id":"alice","moves":["Qg8+"]}`)
+	if code != http.StatusOK || resp.Result != "correct" || resp.Reply != "f8g8" || resp.Rated || resp.Solution != nil {
+		t.Fatalf("expected a correct first move, got %d %+v", code, resp)
+	}
+	code, resp = attemptPuzzleRequest(t, r, "smothered-mate", `{"user_id":"alice","moves":["Qg8+","Nf7#"]}`)
+	if code != http.StatusOK || resp.Result != "solved" || !resp.Rated || resp.RatingChange <= 0 || len(resp.Solution) != 3 {
+		t.Fatalf("expected a rated solve, got %d %+v", code, resp)
+	}
+	rating := resp.Rating.Rating
+
+	// Only the first finished attempt is rated
+	_, resp = attemptPuzzleRequest(t, r, "smothered-mate", `{"user_id":"alice","moves":["h6f7"]}`)
+	if resp.Result != "failed" || resp.Expected != "d5g8" || resp.Rated || resp.Rating.Rating != rating {
+		t.Fatalf("expected an unrated failure, got %+v", resp)
+	}
+
+	_, resp = attemptPuzzleRequest(t, r, "knight-fork", `{"user_id":"bob","moves":["b5d6"]}`)
+	if resp.Result != "failed" || !resp.Rated || resp.RatingChange >= 0 || resp.Rating.Attempts != 1 {
+		t.Fatalf("expected a rated failure, got %+v", resp)
+	}
+
+	// Without a user the attempt is checked but not rated
+	_, resp = attemptPuzzleRequest(t, r, "back-rank", `{"moves":["d1d8"]}`)
+	if resp.Result != "solved" || resp.Rated || resp.Rating != nil {
+		t.Fatalf("expected an anonymous solve, got %+v", resp)
+	}
+
+	for body, want := range map[string]int{
+		`{"moves":[]}`:           http.StatusBadRequest,
+		`{"moves":["d5h1"]}`:     http.StatusBadRequest,
+		`{"moves":["h6f7","x"]}`: http.StatusBadRequest,
+		`not json`:               http.StatusBadRequest,
+	} {
+		if code, _ := attemptPuzzleRequest(t, r, "smothered-mate", body); code != want {
+			t.Errorf("%s: expected %d, got %d", body, want, code)
+		}
+	}
+	if code, _ := attemptPuzzleRequest(t, r, "missing", `{"moves":["e2e4"]}`); code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing puzzle, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/puzzles/ratings/alice", nil))
+	var pr PuzzleRatingResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &pr); err != nil || pr.Rating != rating || pr.Attempts != 1 || pr.Solved != 1 {
+		t.Fatalf("unexpected rating %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Solved puzzles are not served again while others remain
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/puzzles?user_id=alice&theme=smotheredMate", nil))
+	var p PuzzleResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || p.ID != "smothered-mate-b" {
+		t.Errorf("expected the unsolved smothered mate, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAttemptPuzzleAuthenticated(t *testing.T) {
+	r := authServer(t)
+
+	// The attempt is rated for the authenticated user, whatever user_id says
+	rec := authRequest(r, http.MethodPost, "/api/v1/puzzles/back-rank/attempts", "bob-key", `{"user_id":"alice","moves":["d1d8"]}`)
+	var resp PuzzleAttemptResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp.Rated || resp.Rating.UserID != "bob" {
+		t.Fatalf("expected bob's rating updated, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = authRequest(r, http.MethodGet, "/api/v1/puzzles/ratings/alice", "bob-key", "")
+	var rating PuzzleRatingResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &rating); err != nil || rating.Attempts != 0 {
+		t.Errorf("expected alice's rating untouched, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPuzzleSolversBounded(t *testing.T) {
+	s, _ := newTestServerAndRouter()
+	s.puzzleMux.Lock()
+	defer s.puzzleMux.Unlock()
+	for i := 0; i < maxPuzzleSolvers; i++ {
+		s.puzzleSolver("user-" + itoa(i))
+	}
+	s.puzzleSolvers["user-0"].lastActive = s.puzzleSolvers["user-0"].lastActive.Add(-time.Hour)
+	s.puzzleSolver("newcomer")
+	if len(s.puzzleSolvers) != maxPuzzleSolvers {
+		t.Fatalf("expected %d solvers, got %d", maxPuzzleSolvers, len(s.puzzleSolvers))
+	}
+	if _, ok := s.puzzleSolvers["user-0"]; ok {
+		t.Error("expected the least recently active solver forgotten")
+	}
+}
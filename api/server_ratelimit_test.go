@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestChatRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.ChatLimits = config.ChatLimitsConfig{UserMessagesPerMinute: 1}
+	s := NewServer(cfg)
+	if s.chatService == nil {
+		t.Skip("chat service unavailable")
+	}
+	s.chatService.SetChatbotForTesting(cannedChatbot{reply: "Castle soon."})
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := createGame(t, r)
+
+	chat := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/games/"+itoa(id)+"/chat", strings.NewReader(`{"message":"Hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(userIDHeader, userID)
+		req.RemoteAddr = "127.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := chat("alice"); rec.Code != http.StatusOK {
+		t.Fatalf("expected the first message answered, got %d", rec.Code)
+	}
+	rec := chat("alice")
+	var errResp ErrorResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &errResp)
+	if rec.Code != http.StatusTooManyRequests || errResp.Error != "chat_rate_limited" || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 chat_rate_limited with Retry-After, got %d %+v %v", rec.Code, errResp, rec.Header())
+	}
+	// Anonymous clients are counted by address, whatever user they claim to be
+	if rec := chat("bob"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected another claimed user from the same address limited, got %d", rec.Code)
+	}
+
+	// The limit also applies to chat over the game's WebSocket
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	c, _, err := websocket.DefaultDialer.Dial("ws://"+u.Host+"/ws/games/"+itoa(id)+"?user_id=alice", nil)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var initial map[string]interface{}
+	if err := readGameFrame(c, &initial); err != nil {
+		t.Fatalf("read initial: %v", err)
+	}
+	if err := c.WriteJSON(map[string]string{"type": "chat", "message": "Hi"}); err != nil {
+		t.Fatalf("write chat: %v", err)
+	}
+	var frame ErrorFrame
+	if err := readGameFrame(c, &frame); err != nil || frame.Type != "error" || frame.Error != "chat_rate_limited" {
+		t.Fatalf("expected only a chat_rate_limited error frame, got %+v (%v)", frame, err)
+	}
+}
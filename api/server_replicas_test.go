@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestSharedGames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	redis := miniredis.RunT(t)
+	cfg := config.Default()
+	cfg.Database.Enabled = true
+	cfg.Database.Driver = "redis"
+	cfg.Database.ConnectionString = "redis://" + redis.Addr() + "/0"
+
+	// Two servers behind a load balancer
+	replica := func() (*Server, *gin.Engine) {
+		s := NewServer(cfg)
+		if s.coordinator == nil {
+			t.Fatal("expected a Redis store to share games")
+		}
+		t.Cleanup(func() { s.store.Close() })
+		r := gin.New()
+		s.SetupRoutes(r)
+		return s, r
+	}
+	a, ra := replica()
+	_, rb := replica()
+	if _, ok := a.newGameLock(1).(*replicaLock); !ok {
+		t.Fatal("expected shared games locked across servers")
+	}
+
+	do := func(r *gin.Engine, method, path, body string, status int) GameResponse {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Fatalf("%s %s: expected %d, got %d %s", method, path, status, rec.Code, rec.Body.String())
+		}
+		var game GameResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &game)
+		return game
+	}
+
+	if game := do(ra, http.MethodPost, "/api/games", `{"ai_color":"white"}`, http.StatusCreated); game.ID != 1 {
+		t.Fatalf("expected game 1, got %d", game.ID)
+	}
+	if game := do(rb, http.MethodPost, "/api/games", "", http.StatusCreated); game.ID != 2 {
+		t.Fatalf("expected the servers to hand out distinct IDs, got %d", game.ID)
+	}
+
+	// Each server plays on the state the other saved
+	do(rb, http.MethodPost, "/api/games/1/moves", `{"from":"e2","to":"e4"}`, http.StatusOK)
+	if game := do(ra, http.MethodGet, "/api/games/1", "", http.StatusOK); game.MoveCount != 1 {
+		t.Fatalf("expected the other server's move, got %d moves", game.MoveCount)
+	}
+	do(ra, http.MethodPost, "/api/games/1/moves", `{"from":"e7","to":"e5"}`, http.StatusOK)
+	if game := do(rb, http.MethodGet, "/api/games/1", "", http.StatusOK); game.MoveCount != 2 || game.AIColor != "white" {
+		t.Fatalf("expected 1. e4 e5 with the AI playing white, got %d moves and AI color %q", game.MoveCount, game.AIColor)
+	}
+
+	// Deletes reach the other server too
+	do(ra, http.MethodDelete, "/api/games/2", "", http.StatusNoContent)
+	do(rb, http.MethodGet, "/api/games/2", "", http.StatusNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/games", nil)
+	rec := httptest.NewRecorder()
+	rb.ServeHTTP(rec, req)
+	var list struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || list.Count != 1 {
+		t.Errorf("expected one shared game listed, got %s", rec.Body.String())
+	}
+	if redis.Exists("chess:lock:game:1") {
+		t.Error("expected no game lock left held")
+	}
+}
+
+func TestSharedGameBusy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	redis := miniredis.RunT(t)
+	cfg := config.Default()
+	cfg.Database.Enabled = true
+	cfg.Database.Driver = "redis"
+	cfg.Database.ConnectionString = "redis://" + redis.Addr() + "/0"
+	s := NewServer(cfg)
+	t.Cleanup(func() { s.store.Close() })
+	r := gin.New()
+	s.SetupRoutes(r)
+	timeout := replicaLockTimeout
+	replicaLockTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { replicaLockTimeout = timeout })
+
+	id := itoa(createGame(t, r))
+	// Another server holds the game
+	if err := redis.Set("chess:lock:game:"+id, "other-server"); err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range []struct{ method, path, body string }{
+		{http.MethodPost, "/api/v1/games/" + id + "/moves", `{"notation":"e2e4"}`},
+		{http.MethodDelete, "/api/v1/games/" + id, ""},
+	} {
+		rec := playerRequest(r, req.method, req.path, "", req.body)
+		if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "game_busy") {
+			t.Errorf("%s %s: expected 503 game_busy, got %d %s", req.method, req.path, rec.Code, rec.Body.String())
+		}
+	}
+
+	redis.Del("chess:lock:game:" + id)
+	if rec := playerRequest(r, http.MethodPost, "/api/v1/games/"+id+"/moves", "", `{"notation":"e2e4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected the move once the game is released, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodDelete, "/api/v1/games/"+id, "", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected the game deleted, got %d", rec.Code)
+	}
+	if redis.Exists("chess:game:" + id) {
+		t.Error("expected the game deleted from the store")
+	}
+}
+
+func TestUnknownGameSyncLimited(t *testing.T) {
+	s := &Server{saver: newGameSaver(nil)}
+	if !s.claimUnknownGameSync() {
+		t.Fatal("expected the first unknown game to sync the games")
+	}
+	if s.claimUnknownGameSync() {
+		t.Error("expected another unknown game right after not to")
+	}
+	s.saver.unknownSynced = time.Now().Add(-unknownGameSyncInterval)
+	if !s.claimUnknownGameSync() {
+		t.Error("expected a sync once the interval passed")
+	}
+}
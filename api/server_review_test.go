@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGameReviewEndpoint(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	base := "/api/games/" + itoa(id)
+	for _, m := range []string{"e2e4", "e7e5", "d1h5", "b8c6", "f1c4", "g8f6", "h5f7"} {
+		req := httptest.NewRequest(http.MethodPost, base+"/moves", strings.NewReader(`{"notation":"`+m+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("move %s: %d %s", m, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, base+"/review", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	var review ReviewResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &review); err != nil {
+		t.Fatal(err)
+	}
+	if len(review.Moves) != 7 || review.Black.Blunders != 1 || review.White.Accuracy <= review.Black.Accuracy {
+		t.Fatalf("unexpected review %+v", review)
+	}
+	if nf6 := review.Moves[5]; nf6.SAN != "Nf6" || nf6.Class != "blunder" || nf6.Color != "black" || nf6.BestMove == "" {
+		t.Errorf("unexpected verdict on Nf6: %+v", nf6)
+	}
+	if len(review.Evals) != 8 || review.Evals[0].Ply != 0 || review.Evals[6].Mate != 1 || review.Evals[7].Eval != 1000 {
+		t.Errorf("expected an evaluation graph ending in White's mate, got %+v", review.Evals)
+	}
+	if len(review.Improvements) == 0 || review.Improvements[0].SAN != "Nf6" || review.Improvements[0].BestMove != review.Moves[5].BestMove || len(review.Improvements[0].BestLine) == 0 {
+		t.Errorf("expected Nf6 as the costliest move to improve, got %+v", review.Improvements)
+	}
+	if review.Cached {
+		t.Error("expected the first review not to be cached")
+	}
+
+	// The review is kept until the moves change
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, base+"/review?again=1", nil))
+	var again ReviewResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &again); err != nil || !again.Cached || len(again.Moves) != 7 {
+		t.Errorf("expected the cached review, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/games/999/review", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing game, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.rumenx.com/chess/chat"
+	"go.rumenx.com/chess/config"
+)
+
+// roomRequest serves a request to a chat room endpoint as userID.
+func roomRequest(r *gin.Engine, method, path, userID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(userIDHeader, userID)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestRooms covers creating, joining, posting to and leaving chat rooms.
+func TestRooms(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.LLMAI.Moderation.InputAction = "sanitize"
+	s := NewServer(cfg)
+	if s.chatService == nil {
+		t.Skip("chat service unavailable")
+	}
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	if rec := roomRequest(r, http.MethodPost, "/api/rooms", "alice", `{"id":"endgames","name":"Endgame study"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected the room created, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := roomRequest(r, http.MethodPost, "/api/rooms", "bob", `{"id":"endgames"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for an existing room, got %d", rec.Code)
+	}
+	rec := roomRequest(r, http.MethodGet, "/api/rooms", "bob", "")
+	var list struct {
+		Rooms []struct{ ID string } `json:"rooms"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Rooms) != 2 || list.Rooms[0].ID != "lobby" {
+		t.Fatalf("expected the lobby and endgames, got %s", rec.Body.String())
+	}
+
+	var errResp ErrorResponse
+	rec = roomRequest(r, http.MethodPost, "/api/rooms/endgames/messages", "bob", `{"message":"hello"}`)
+	if _ = json.Unmarshal(rec.Body.Bytes(), &errResp); rec.Code != http.StatusForbidden || errResp.Error != "not_a_member" {
+		t.Fatalf("expected 403 not_a_member, got %d %s", rec.Code, rec.Body.String())
+	}
+	roomRequest(r, http.MethodPost, "/api/rooms/endgames/join", "bob", "")
+	rec = roomRequest(r, http.MethodPost, "/api/rooms/endgames/messages", "bob", `{"message":"Lucena is a shit position"}`)
+	var posted RoomMessageResponse
+	if _ = json.Unmarshal(rec.Body.Bytes(), &posted); rec.Code != http.StatusCreated || posted.Content != "Lucena is a **** position" || len(posted.Filtered) != 1 {
+		t.Fatalf("expected the sanitized message posted, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = roomRequest(r, http.MethodGet, "/api/rooms/endgames/messages?limit=10", "carol", "")
+	if !strings.Contains(rec.Body.String(), `"user_id":"bob"`) {
+		t.Fatalf("expected bob's message in the history, got %s", rec.Body.String())
+	}
+	if rec := roomRequest(r, http.MethodPost, "/api/rooms/endgames/leave", "carol", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-member leaving, got %d", rec.Code)
+	}
+	if rec := roomRequest(r, http.MethodGet, "/api/rooms/nowhere", "carol", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown room, got %d", rec.Code)
+	}
+
+	// Posting is limited per user like game chat
+	s.rooms.SetLimits(chat.Limits{UserMessagesPerMinute: 1})
+	roomRequest(r, http.MethodPost, "/api/rooms/endgames/messages", "bob", `{"message":"Philidor next"}`)
+	rec = roomRequest(r, http.MethodPost, "/api/rooms/endgames/messages", "bob", `{"message":"and Vancura"}`)
+	if _ = json.Unmarshal(rec.Body.Bytes(), &errResp); rec.Code != http.StatusTooManyRequests || errResp.Error != "chat_rate_limited" || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 chat_rate_limited with Retry-After, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestRoomWebSocket verifies room messages and membership changes are pushed to
+// every socket of the room.
+func TestRoomWebSocket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	dial := func(userID string) (*websocket.Conn, RoomStateFrame) {
+		u, _ := url.Parse(ts.URL)
+		wsURL := url.URL{Scheme: "ws", Host: u.Host, Path: "/ws/rooms/lobby", RawQuery: "user_id=" + userID}
+		c, _, err := websocket.DefaultDialer.Dial(wsURL.String(), nil)
+		if err != nil {
+			t.Fatalf("dial websocket: %v", err)
+		}
+		c.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var state RoomStateFrame
+		if err := c.ReadJSON(&state); err != nil || state.Type != "room_state" {
+			t.Fatalf("expected the room state, got %+v (%v)", state, err)
+		}
+		return c, state
+	}
+	alice, _ := dial("alice")
+	defer alice.Close()
+	var joined RoomMemberFrame
+	if err := alice.ReadJSON(&joined); err != nil || joined.Event != "joined" || joined.UserID != "alice" {
+		t.Fatalf("expected alice's own join, got %+v (%v)", joined, err)
+	}
+	bob, state := dial("bob")
+	if len(state.Room.Members) != 2 {
+		t.Fatalf("expected alice and bob in the lobby, got %v", state.Room.Members)
+	}
+	if err := alice.ReadJSON(&joined); err != nil || joined.UserID != "bob" {
+		t.Fatalf("expected bob's join, got %+v (%v)", joined, err)
+	}
+	if err := bob.ReadJSON(&joined); err != nil || joined.UserID != "bob" {
+		t.Fatalf("expected bob's own join, got %+v (%v)", joined, err)
+	}
+
+	if err := bob.WriteJSON(map[string]string{"type": "chat", "message": "Anyone up for blitz?"}); err != nil {
+		t.Fatalf("write chat: %v", err)
+	}
+	for _, c := range []*websocket.Conn{alice, bob} {
+		var msg RoomMessageFrame
+		if err := c.ReadJSON(&msg); err != nil || msg.Type != "room_message" || msg.UserID != "bob" || msg.Content != "Anyone up for blitz?" {
+			t.Fatalf("expected bob's message, got %+v (%v)", msg, err)
+		}
+	}
+
+	bob.Close()
+	var left RoomMemberFrame
+	if err := alice.ReadJSON(&left); err != nil || left.Event != "left" || left.UserID != "bob" {
+		t.Fatalf("expected bob to leave when the socket closes, got %+v (%v)", left, err)
+	}
+}
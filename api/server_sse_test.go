@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGameEventStream verifies the event stream sends the game, then the game's
+// WebSocket messages as named events.
+func TestGameEventStream(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	id := itoa(createGame(t, r))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/games/"+id+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, ct)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	next := func() (string, string) {
+		var name, data string
+		for lines.Scan() {
+			line := lines.Text()
+			if line == "" && name == "presence" {
+				name = ""
+			} else if line == "" && name != "" {
+				return name, data
+			}
+			if v, ok := strings.CutPrefix(line, "event:"); ok {
+				name = v
+			} else if v, ok := strings.CutPrefix(line, "data:"); ok {
+				data = v
+			}
+		}
+		t.Fatalf("stream ended: %v", lines.Err())
+		return "", ""
+	}
+
+	if name, data := next(); name != "game" || !strings.Contains(data, `"id":`+id) {
+		t.Fatalf("expected the game first, got %s %s", name, data)
+	}
+	playerRequest(r, http.MethodPost, "/api/games/"+id+"/moves", "", `{"notation":"e2e4"}`)
+	name, data := next()
+	var event GameEventMessage
+	if err := json.Unmarshal([]byte(data), &event); err != nil || name != "game_event" || event.Event != "move_made" || event.Move.Notation != "e2e4" {
+		t.Fatalf("expected the move's event, got %s %s", name, data)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/games/999/events", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing game, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestGameSummaryEndpoint(t *testing.T) {
+	var llmRequests atomic.Int32
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		llmRequests.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{
+			"role": "assistant", "content": "Black {fell} for the Scholar's Mate after 3...Nf6.",
+		}})
+	}))
+	defer llm.Close()
+	t.Setenv("OLLAMA_ENDPOINT", llm.URL)
+
+	for _, enabled := range []bool{false, true} {
+		gin.SetMode(gin.TestMode)
+		cfg := config.Default()
+		cfg.LLMAI.Enabled = enabled
+		cfg.LLMAI.DefaultProvider = "ollama"
+		s := NewServer(cfg)
+		r := gin.New()
+		s.SetupRoutes(r)
+
+		id := createGame(t, r)
+		base := "/api/games/" + itoa(id)
+		get := func(path string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, base+path, nil))
+			return rec
+		}
+		if rec := get("/summary"); rec.Code != http.StatusConflict {
+			t.Fatalf("expected 409 before the game ended, got %d %s", rec.Code, rec.Body.String())
+		}
+		for _, m := range []string{"e2e4", "e7e5", "d1h5", "b8c6", "f1c4", "g8f6", "h5f7"} {
+			req := httptest.NewRequest(http.MethodPost, base+"/moves", strings.NewReader(`{"notation":"`+m+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("move %s: %d %s", m, rec.Code, rec.Body.String())
+			}
+		}
+
+		rec := get("/summary")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("summary: %d %s", rec.Code, rec.Body.String())
+		}
+		var summary SummaryResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+			t.Fatal(err)
+		}
+		if summary.Result != "1-0" || len(summary.TurningPoints) == 0 || summary.TurningPoints[0].SAN != "Nf6" {
+			t.Errorf("unexpected summary %+v", summary)
+		}
+		wantSource, wantText := "engine", "White won by checkmate after 4 moves."
+		if enabled {
+			wantSource, wantText = "llm", "Black {fell} for the Scholar's Mate after 3...Nf6."
+		}
+		if summary.Source != wantSource || !strings.Contains(summary.Summary, wantText) {
+			t.Errorf("expected the %s summary %q, got %s %q", wantSource, wantText, summary.Source, summary.Summary)
+		}
+
+		pgn := get("/pgn?summary=true").Body.String()
+		if !strings.Contains(pgn, "Qxf7# {") || !strings.HasSuffix(strings.TrimSpace(pgn), "} 1-0") || strings.Contains(pgn, "{fell}") {
+			t.Errorf("expected the escaped summary as a trailing comment:\n%s", pgn)
+		}
+	}
+	if n := llmRequests.Load(); n != 1 {
+		t.Errorf("expected the LLM summary to be written once, got %d requests", n)
+	}
+}
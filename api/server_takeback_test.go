@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUndoAgainstAI(t *testing.T) {
+	s, r := newTestServerAndRouter()
+	id := createGame(t, r)
+	base := "/api/games/" + itoa(id)
+
+	if rec := playerRequest(r, http.MethodPost, base+"/undo", "", ""); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "no_moves") {
+		t.Fatalf("expected 409 no_moves, got %d %s", rec.Code, rec.Body.String())
+	}
+	for _, move := range []string{"e2e4", "e7e5", "g1f3"} {
+		if rec := playerRequest(r, http.MethodPost, base+"/moves", "", `{"notation":"`+move+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("move %s: %d %s", move, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/undo", "", `{"plies":4}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 taking back more plies than played, got %d", rec.Code)
+	}
+
+	// The player just moved: only their move is taken back
+	rec := playerRequest(r, http.MethodPost, base+"/undo", "", "")
+	var resp TakebackResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("undo: %d %s", rec.Code, rec.Body.String())
+	}
+	if resp.Status != "undone" || len(resp.Undone) != 1 || resp.Undone[0].From != "g1" || len(resp.Game.MoveHistory) != 2 || resp.Game.ActiveColor != "white" {
+		t.Fatalf("expected g1f3 taken back, got %+v", resp)
+	}
+
+	// On the player's turn, the AI's reply goes too
+	s.setDrawOffer(id, s.games[id].ActiveColor().Opposite())
+	rec = playerRequest(r, http.MethodPost, base+"/undo", "", "")
+	resp = TakebackResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Undone) != 2 || len(resp.Game.MoveHistory) != 0 {
+		t.Fatalf("expected two plies taken back, got %d %s", rec.Code, rec.Body.String())
+	}
+	if resp.Game.DrawOffer != "" {
+		t.Errorf("expected the draw offer dropped with the position, got %q", resp.Game.DrawOffer)
+	}
+}
+
+func TestTwoPlayerTakeback(t *testing.T) {
+	s, r := newTestServerAndRouter()
+	game := createTwoPlayerGame(t, r)
+	white, black := game.PlayerTokens["white"], game.PlayerTokens["black"]
+	base := "/api/games/" + game.UUID
+	playerRequest(r, http.MethodPost, base+"/join", white, "")
+	playerRequest(r, http.MethodPost, base+"/join", black, "")
+	playerRequest(r, http.MethodPost, base+"/moves", white, `{"notation":"e2e4"}`)
+	gameID, _ := s.resolveGameID(game.UUID)
+	client := s.hub.add(gameID)
+	defer s.hub.remove(gameID, client)
+
+	rec := playerRequest(r, http.MethodPost, base+"/undo", white, "")
+	var resp TakebackResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusAccepted || resp.Status != "requested" {
+		t.Fatalf("expected the takeback requested, got %d %s", rec.Code, rec.Body.String())
+	}
+	if resp.Game.Takeback == nil || resp.Game.Takeback.Color != "white" || resp.Game.Takeback.Plies != 1 || len(resp.Game.MoveHistory) != 1 {
+		t.Fatalf("expected white's request pending, got %+v", resp.Game)
+	}
+	if msg, ok := (<-client.send).(TakebackMessage); !ok || msg.Status != "requested" {
+		t.Errorf("expected the request broadcast, got %#v", msg)
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/undo", black, ""); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 with a request pending, got %d", rec.Code)
+	}
+	if rec := playerRequest(r, http.MethodPost, base+"/undo/accept", white, ""); rec.Code != http.StatusConflict {
+		t.Errorf("expected white unable to accept their own request, got %d", rec.Code)
+	}
+
+	// Declined, then asked again and accepted
+	if rec := playerRequest(r, http.MethodPost, base+"/undo/decline", black, ""); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `"takeback"`) {
+		t.Fatalf("expected black to decline, got %d %s", rec.Code, rec.Body.String())
+	}
+	playerRequest(r, http.MethodPost, base+"/undo", white, "")
+	rec = playerRequest(r, http.MethodPost, base+"/undo/accept", black, "")
+	resp = TakebackResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("accept: %d %s", rec.Code, rec.Body.String())
+	}
+	if resp.Status != "accepted" || len(resp.Game.MoveHistory) != 0 || resp.Game.ActiveColor != "white" || resp.Game.Takeback != nil {
+		t.Fatalf("expected e2e4 taken back, got %+v", resp)
+	}
+
+	// Moving declines a pending request
+	playerRequest(r, http.MethodPost, base+"/moves", white, `{"notation":"d2d4"}`)
+	playerRequest(r, http.MethodPost, base+"/undo", white, "")
+	rec = playerRequest(r, http.MethodPost, base+"/moves", black, `{"notation":"d7d5"}`)
+	var state GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil || state.Takeback != nil {
+		t.Errorf("expected the request dropped by moving, got %s", rec.Body.String())
+	}
+}
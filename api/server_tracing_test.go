@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestTracingAIHint(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Tracing.Enabled = true
+	s := NewServer(cfg)
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := itoa(createGame(t, r))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/games/"+id+"/ai-hint", strings.NewReader(`{"engine":"minimax","level":"beginner"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a hint, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	var request, search sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "/api/v1/games/:id/ai-hint", "POST /api/v1/games/:id/ai-hint":
+			request = span
+		case "ai.analyze":
+			search = span
+		}
+	}
+	if request == nil || search == nil {
+		t.Fatalf("expected request and search spans, got %d spans", len(recorder.Ended()))
+	}
+	if search.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("expected the search to be traced within the request")
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestGameTranscript(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	if s.chatService == nil {
+		t.Skip("chat service unavailable")
+	}
+	s.chatService.SetChatbotForTesting(cannedChatbot{reply: "Control the centre."})
+	r := gin.New()
+	s.SetupRoutes(r)
+	id := createGame(t, r)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/games/"+itoa(id)+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(userIDHeader, "alice")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s: %d %s", method, path, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+	do(http.MethodPost, "/moves", `{"from":"e2","to":"e4"}`)
+	do(http.MethodPost, "/moves", `{"from":"e7","to":"e5"}`)
+	do(http.MethodPost, "/chat", `{"message":"What now?"}`)
+	do(http.MethodPost, "/moves", `{"from":"g1","to":"f3"}`)
+
+	rec := do(http.MethodGet, "/transcript", "")
+	markdown := rec.Body.String()
+	for _, want := range []string{
+		"# Casual Game: Player vs AI",
+		"**1. e4 e5**\n\n> **alice:** What now?\n\n> **AI:** Control the centre.\n\n**2. Nf3**",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("expected %q in the Markdown transcript:\n%s", want, markdown)
+		}
+	}
+	if !regexp.MustCompile("```pgn\n1\\. e4 \\{[^}]*\\} e5 \\{[^}]*\\} 2\\. Nf3 \\{[^}]*\\} \\*\n```").MatchString(markdown) {
+		t.Errorf("expected the timed moves in the transcript's PGN:\n%s", markdown)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/markdown") {
+		t.Errorf("expected Markdown, got %s", ct)
+	}
+
+	pgn := do(http.MethodGet, "/transcript?format=pgn", "").Body.String()
+	if !strings.Contains(pgn, "[White \"Player\"]") ||
+		!regexp.MustCompile(`e5 \{[^}]*\} \{alice: What now\?\} \{AI: Control the centre\.\} 2\. Nf3`).MatchString(pgn) {
+		t.Errorf("expected the chat as PGN comments:\n%s", pgn)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/games/"+itoa(id)+"/transcript?format=html", nil)
+	bad := httptest.NewRecorder()
+	r.ServeHTTP(bad, req)
+	if bad.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", bad.Code)
+	}
+}
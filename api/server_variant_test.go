@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.rumenx.com/chess/config"
+)
+
+func TestCrazyhouseGameExposesPockets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(`{"variant":"crazyhouse"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	var game GameResponse
+	for _, mv := range []string{"e2e4", "d7d5", "e4d5"} {
+		body := []byte(`{"from":"` + mv[:2] + `","to":"` + mv[2:] + `"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/games/1/moves", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("move %s expected 200 got %d body=%s", mv, rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+
+	if game.Variant != "crazyhouse" {
+		t.Fatalf("expected crazyhouse variant, got %q", game.Variant)
+	}
+	if game.Pockets["white"]["pawn"] != 1 {
+		t.Fatalf("expected white pocket pawn, got %v", game.Pockets)
+	}
+
+	// Drop via notation
+	req = httptest.NewRequest(http.MethodPost, "/api/games/1/moves", strings.NewReader(`{"notation":"Q@a5"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected empty-pocket drop rejected, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/games/1/pgn", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `[Variant "Crazyhouse"]`) {
+		t.Fatalf("expected crazyhouse variant tag, got %s", rec.Body.String())
+	}
+}
+
+func TestCreateGameRejectsUnknownVariant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServer(config.Default())
+	r := gin.New()
+	s.SetupRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/games", strings.NewReader(`{"variant":"atomic"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown variant, got %d", rec.Code)
+	}
+}
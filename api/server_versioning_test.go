@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPIVersions(t *testing.T) {
+	_, r := newTestServerAndRouter()
+	rec := playerRequest(r, http.MethodPost, "/api/v1/games", "", "")
+	if rec.Code != http.StatusCreated || rec.Header().Get("Deprecation") != "" {
+		t.Fatalf("expected a game without deprecation, got %d %q", rec.Code, rec.Header().Get("Deprecation"))
+	}
+	id := itoa(createGame(t, r))
+
+	rec = playerRequest(r, http.MethodGet, "/api/games/"+id, "", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Deprecation"), "@") {
+		t.Fatalf("expected the legacy route to be deprecated, got %d %q", rec.Code, rec.Header().Get("Deprecation"))
+	}
+	if link := rec.Header().Get("Link"); link != `</api/v1/games/`+id+`>; rel="successor-version"` {
+		t.Errorf("expected a link to the versioned route, got %q", link)
+	}
+
+	// Both versions share games and their cached responses are invalidated by
+	// changes through either
+	if rec := playerRequest(r, http.MethodGet, "/api/v1/games/"+id+"/fen", "", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ply":0`) {
+		t.Fatalf("expected the starting position, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodPost, "/api/games/"+id+"/moves", "", `{"notation":"e2e4"}`); rec.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := playerRequest(r, http.MethodGet, "/api/v1/games/"+id+"/fen", "", ""); !strings.Contains(rec.Body.String(), `"ply":1`) {
+		t.Errorf("expected the move through the legacy route, got %s", rec.Body.String())
+	}
+}
+
+func TestAPIVersionsAuth(t *testing.T) {
+	r := authServer(t)
+	if rec := authRequest(r, http.MethodGet, "/api/v1/games", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", rec.Code)
+	}
+	rec := authRequest(r, http.MethodPost, "/api/v1/games", "alice-key", "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if rec := authRequest(r, http.MethodGet, "/api/v1/games/1/image?access_token=bob-key", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected the image with an access token, got %d", rec.Code)
+	}
+	if rec := authRequest(r, http.MethodPost, "/api/v1/games/1/moves", "bob-key", `{"notation":"e2e4"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a user who is not a player, got %d", rec.Code)
+	}
+}
+
+func TestUnversionedPath(t *testing.T) {
+	for route, want := range map[string]string{
+		"/api/v1/games/:id": "/api/games/:id",
+		"/api/games/:id":    "/api/games/:id",
+		"/api/v1":           "/api",
+		"/api/v10/games":    "/api/v10/games",
+		"/ws/games/:id":     "/ws/games/:id",
+	} {
+		if got := unversionedPath(route); got != want {
+			t.Errorf("unversionedPath(%q) = %q, want %q", route, got, want)
+		}
+	}
+}
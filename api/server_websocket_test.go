@@ -1,10 +1,13 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +16,22 @@ import (
 	"go.rumenx.com/chess/config"
 )
 
+// readGameFrame reads the next message of a game's WebSocket into v, skipping
+// presence updates.
+func readGameFrame(c *websocket.Conn, v interface{}) error {
+	for {
+		_, data, err := c.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var frame wsFrame
+		if json.Unmarshal(data, &frame) == nil && frame.Type == "presence" {
+			continue
+		}
+		return json.Unmarshal(data, v)
+	}
+}
+
 // TestWebSocketConnection verifies the websocket endpoint upgrades and returns initial game state.
 func TestWebSocketConnection(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -56,23 +75,327 @@ func TestWebSocketConnection(t *testing.T) {
 	// Read initial message
 	c.SetReadDeadline(time.Now().Add(2 * time.Second))
 	var initial map[string]interface{}
-	if err := c.ReadJSON(&initial); err != nil {
+	if err := readGameFrame(c, &initial); err != nil {
 		t.Fatalf("read initial: %v", err)
 	}
 	if initial["id"].(float64) != float64(resp.ID) {
 		t.Fatalf("expected id %d, got %v", resp.ID, initial["id"])
 	}
 
-	// Send echo payload
-	msg := map[string]interface{}{"ping": "pong"}
-	if err := c.WriteJSON(msg); err != nil {
-		t.Fatalf("write json: %v", err)
+	// Pings are answered; messages of unknown type are not echoed
+	for msg, want := range map[string]string{`{"type":"ping"}`: "pong", `{"ping":"pong"}`: "error"} {
+		if err := c.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		var reply map[string]interface{}
+		if err := readGameFrame(c, &reply); err != nil {
+			t.Fatalf("read reply: %v", err)
+		}
+		if reply["type"] != want {
+			t.Fatalf("expected %s for %s, got %v", want, msg, reply)
+		}
+	}
+}
+
+// TestWebSocketReceivesGameEvents verifies moves are pushed to subscribed clients.
+func TestWebSocketReceivesGameEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := NewServer(config.Default())
+	r := gin.New()
+	srv.SetupRoutes(r)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/api/games", "application/json", nil)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+	resp.Body.Close()
+
+	u, _ := url.Parse(ts.URL)
+	wsURL := url.URL{Scheme: "ws", Host: u.Host, Path: "/ws/games/1"}
+	c, _, err := websocket.DefaultDialer.Dial(wsURL.String(), nil)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer c.Close()
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var initial map[string]interface{}
+	if err := readGameFrame(c, &initial); err != nil {
+		t.Fatalf("read initial: %v", err)
+	}
+
+	for _, body := range []string{`{"from":"e2","to":"e4"}`, `{"from":"d7","to":"d5"}`, `{"from":"e4","to":"d5"}`} {
+		resp, err := http.Post(ts.URL+"/api/games/1/moves", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("move: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	var events []string
+	for len(events) < 4 {
+		var msg GameEventMessage
+		if err := readGameFrame(c, &msg); err != nil {
+			t.Fatalf("read event (got %v so far): %v", events, err)
+		}
+		if msg.Type != "game_event" || msg.GameID != 1 {
+			t.Fatalf("unexpected message %+v", msg)
+		}
+		events = append(events, msg.Event)
+	}
+	if events[3] != "capture" {
+		t.Fatalf("expected capture event, got %v", events)
+	}
+}
+
+// cannedChatbot always gives the same reply.
+type cannedChatbot struct{ reply string }
+
+func (b cannedChatbot) Ask(_ context.Context, _ string) (string, error) { return b.reply, nil }
+
+// TestWebSocketChat verifies chat frames are answered with replies to every
+// client of the game, and that bad ones get an error frame.
+func TestWebSocketChat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := NewServer(config.Default())
+	if srv.chatService == nil {
+		t.Skip("chat service unavailable")
+	}
+	srv.chatService.SetChatbotForTesting(cannedChatbot{reply: "Develop your knights first."})
+	r := gin.New()
+	srv.SetupRoutes(r)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	id := createGame(t, r)
+
+	dial := func(userID string) *websocket.Conn {
+		u, _ := url.Parse(ts.URL)
+		wsURL := url.URL{Scheme: "ws", Host: u.Host, Path: "/ws/games/" + strconv.Itoa(id), RawQuery: "user_id=" + userID}
+		c, _, err := websocket.DefaultDialer.Dial(wsURL.String(), nil)
+		if err != nil {
+			t.Fatalf("dial websocket: %v", err)
+		}
+		c.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var initial map[string]interface{}
+		if err := readGameFrame(c, &initial); err != nil {
+			t.Fatalf("read initial: %v", err)
+		}
+		return c
+	}
+	player, spectator := dial("alice"), dial("spectator-1")
+	defer player.Close()
+	defer spectator.Close()
+
+	if err := player.WriteJSON(map[string]string{"type": "chat", "message": "What now?"}); err != nil {
+		t.Fatalf("write chat: %v", err)
+	}
+	for _, c := range []*websocket.Conn{player, spectator} {
+		var user ChatMessageFrame
+		if err := readGameFrame(c, &user); err != nil || user.Type != "chat_message" || user.Role != "user" || user.UserID != "alice" || user.Content != "What now?" {
+			t.Fatalf("expected the player's message, got %+v (%v)", user, err)
+		}
+		var reply ChatMessageFrame
+		if err := readGameFrame(c, &reply); err != nil || reply.ChatID != user.ChatID || reply.Type != "chat_message" || reply.Role != "ai" || reply.Content != "Develop your knights first." || reply.ReplyTo == "" {
+			t.Fatalf("expected the AI reply to chat %s, got %+v (%v)", user.ChatID, reply, err)
+		}
+	}
+
+	if err := player.WriteJSON(map[string]string{"type": "chat", "message": "Hi", "language": "klingon"}); err != nil {
+		t.Fatalf("write chat: %v", err)
 	}
-	var echo map[string]interface{}
-	if err := c.ReadJSON(&echo); err != nil {
-		t.Fatalf("read echo: %v", err)
+	var errFrame ErrorFrame
+	if err := readGameFrame(player, &errFrame); err != nil || errFrame.Type != "error" || errFrame.Error != "invalid_language" {
+		t.Fatalf("expected an invalid_language error frame, got %+v (%v)", errFrame, err)
+	}
+	if history := srv.chatService.GetConversationHistory(id); len(history) != 3 {
+		t.Errorf("expected the welcome, question and reply in the history, got %d messages", len(history))
+	}
+}
+
+// TestWebSocketMoves verifies moves played over the socket are answered with the
+// game, pushed to every client with the clocks, and refused like over HTTP.
+func TestWebSocketMoves(t *testing.T) {
+	srv, r := newTestServerAndRouter()
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	rec := playerRequest(r, http.MethodPost, "/api/games", "", `{"time_control":"300+2"}`)
+	var created GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	two := createTwoPlayerGame(t, r)
+
+	dial := func(id, query string) *websocket.Conn {
+		u, _ := url.Parse(ts.URL)
+		wsURL := url.URL{Scheme: "ws", Host: u.Host, Path: "/ws/games/" + id, RawQuery: query}
+		c, _, err := websocket.DefaultDialer.Dial(wsURL.String(), nil)
+		if err != nil {
+			t.Fatalf("dial websocket: %v", err)
+		}
+		c.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var initial map[string]interface{}
+		if err := readGameFrame(c, &initial); err != nil {
+			t.Fatalf("read initial: %v", err)
+		}
+		return c
+	}
+	player, spectator := dial(itoa(created.ID), ""), dial(itoa(created.ID), "")
+	defer player.Close()
+	defer spectator.Close()
+
+	if err := player.WriteJSON(map[string]string{"type": "move", "from": "e2", "to": "e4"}); err != nil {
+		t.Fatalf("write move: %v", err)
+	}
+	var clock ClockMessage
+	for clock.Type != "clock" {
+		if err := readGameFrame(spectator, &clock); err != nil {
+			t.Fatalf("expected the clocks pushed: %v", err)
+		}
+	}
+	if clock.GameID != created.ID || clock.Running != "black" || clock.WhiteMs <= 300000 {
+		t.Errorf("expected white's increment and black's clock running, got %+v", clock)
+	}
+	for {
+		var reply map[string]interface{}
+		if err := readGameFrame(player, &reply); err != nil {
+			t.Fatalf("expected the game after the move: %v", err)
+		}
+		if history, ok := reply["move_history"].([]interface{}); ok {
+			if len(history) != 1 || reply["active_color"] != "black" {
+				t.Fatalf("expected e2e4 played, got %v", reply)
+			}
+			break
+		}
+	}
+
+	// Refused moves get an error frame, as do two-player moves without a token,
+	// which only spectators lack
+	if err := player.WriteJSON(map[string]string{"type": "move", "notation": "e2e4"}); err != nil {
+		t.Fatalf("write move: %v", err)
+	}
+	var frame ErrorFrame
+	if err := readGameFrame(player, &frame); err != nil || frame.Type != "error" || frame.Error != "illegal_move" {
+		t.Errorf("expected an illegal_move error, got %+v (%v)", frame, err)
+	}
+	gameID, _ := srv.resolveGameID(two.UUID)
+	white, black := two.PlayerTokens["white"], two.PlayerTokens["black"]
+	playerRequest(r, http.MethodPost, "/api/games/"+two.UUID+"/join", white, "")
+	playerRequest(r, http.MethodPost, "/api/games/"+two.UUID+"/join", black, "")
+	for query, want := range map[string]string{"": "spectator", "player_token=" + black: "not_your_turn"} {
+		c := dial(itoa(gameID), query)
+		if err := c.WriteJSON(map[string]string{"type": "move", "notation": "e2e4"}); err != nil {
+			t.Fatalf("write move: %v", err)
+		}
+		frame = ErrorFrame{}
+		if err := readGameFrame(c, &frame); err != nil || frame.Error != want {
+			t.Errorf("%q: expected %s, got %+v (%v)", query, want, frame, err)
+		}
+		c.Close()
+	}
+}
+
+// TestWebSocketSpectators verifies spectators cannot move or chat, presence
+// counts are pushed as clients come and go, late clients get the latest
+// messages, and two-player games seat only clients with a player token.
+func TestWebSocketSpectators(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Server.ReplayEvents = 2
+	srv := NewServer(cfg)
+	r := gin.New()
+	srv.SetupRoutes(r)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	id := createGame(t, r)
+	for _, move := range []string{"e2e4", "e7e5"} {
+		playerRequest(r, http.MethodPost, "/api/games/"+itoa(id)+"/moves", "", `{"notation":"`+move+`"}`)
+	}
+
+	dial := func(query string) *websocket.Conn {
+		u, _ := url.Parse(ts.URL)
+		wsURL := url.URL{Scheme: "ws", Host: u.Host, Path: "/ws/games/" + strconv.Itoa(id), RawQuery: query}
+		c, _, err := websocket.DefaultDialer.Dial(wsURL.String(), nil)
+		if err != nil {
+			t.Fatalf("dial websocket: %v", err)
+		}
+		c.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return c
+	}
+	presence := func(c *websocket.Conn) PresenceMessage {
+		t.Helper()
+		for {
+			var msg PresenceMessage
+			if err := c.ReadJSON(&msg); err != nil {
+				t.Fatalf("expected presence: %v", err)
+			}
+			if msg.Type == "presence" {
+				return msg
+			}
+		}
+	}
+
+	player := dial("")
+	defer player.Close()
+	var state GameResponse
+	if err := player.ReadJSON(&state); err != nil || len(state.MoveHistory) != 2 {
+		t.Fatalf("expected the game first, got %+v (%v)", state, err)
+	}
+	var replay ReplayMessage
+	if err := player.ReadJSON(&replay); err != nil || replay.Type != "replay" || len(replay.Messages) != 2 {
+		t.Fatalf("expected the last two messages replayed, got %+v (%v)", replay, err)
+	}
+	if last := replay.Messages[1].(map[string]interface{}); last["event"] != "move_made" || last["ply"] != float64(2) {
+		t.Errorf("expected black's move last, got %v", last)
+	}
+	if msg := presence(player); msg.Players != 1 || msg.Spectators != 0 {
+		t.Errorf("expected the player alone, got %+v", msg)
+	}
+
+	spectator := dial("spectator=true")
+	if msg := presence(player); msg.Players != 1 || msg.Spectators != 1 {
+		t.Errorf("expected one watching, got %+v", msg)
+	}
+	if err := spectator.WriteJSON(map[string]string{"type": "move", "notation": "g1f3"}); err != nil {
+		t.Fatalf("write move: %v", err)
+	}
+	var frame ErrorFrame
+	for frame.Type != "error" {
+		if err := readGameFrame(spectator, &frame); err != nil {
+			t.Fatalf("expected an error frame: %v", err)
+		}
+	}
+	if frame.Error != "spectator" {
+		t.Errorf("expected spectators unable to move, got %+v", frame)
+	}
+	if err := spectator.WriteJSON(map[string]string{"type": "chat", "message": "nice"}); err != nil {
+		t.Fatalf("write chat: %v", err)
+	}
+	frame = ErrorFrame{}
+	for frame.Type != "error" {
+		if err := readGameFrame(spectator, &frame); err != nil {
+			t.Fatalf("expected an error frame: %v", err)
+		}
+	}
+	if frame.Error != "spectator" {
+		t.Errorf("expected spectators unable to chat, got %+v", frame)
+	}
+	spectator.Close()
+	if msg := presence(player); msg.Players != 1 || msg.Spectators != 0 {
+		t.Errorf("expected the spectator gone, got %+v", msg)
+	}
+
+	two := createTwoPlayerGame(t, r)
+	twoID, _ := srv.resolveGameID(two.UUID)
+	id = twoID
+	watcher := dial("")
+	defer watcher.Close()
+	if msg := presence(watcher); msg.Players != 0 || msg.Spectators != 1 {
+		t.Errorf("expected a tokenless client watching, got %+v", msg)
 	}
-	if echo["ping"] != "pong" {
-		t.Fatalf("expected echo pong, got %v", echo["ping"])
+	white := dial("player_token=" + two.PlayerTokens["white"])
+	defer white.Close()
+	if msg := presence(watcher); msg.Players != 1 || msg.Spectators != 1 {
+		t.Errorf("expected white seated, got %+v", msg)
 	}
 }
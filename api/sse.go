@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gameEventsPath is the route of a game's event stream.
+const gameEventsPath = "/api/games/:id/events"
+
+// sseHeartbeat is how often an idle event stream gets a comment, so that
+// proxies do not close it.
+const sseHeartbeat = 30 * time.Second
+
+// gameEvents streams a game's WebSocket messages as Server-Sent Events, for
+// clients behind proxies that cannot upgrade to WebSockets. Each message is an
+// event named after its type, e.g. game_event or clock; game states, the first
+// of which is sent on connecting, are "game" events. Streams are spectators.
+func (s *Server) gameEvents(c *gin.Context) {
+	gameID, ok := s.gameIDParam(c)
+	if !ok {
+		return
+	}
+	s.gamesMux.RLock()
+	game, exists := s.games[gameID]
+	s.gamesMux.RUnlock()
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "game_not_found"})
+		return
+	}
+
+	client, unsubscribe := s.subscribe(gameID, true, s.gameToResponse(gameID, game))
+	defer unsubscribe()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // nginx would buffer the stream
+	// The stream outlives the server's write timeout; each write gets its own
+	rc := http.NewResponseController(c.Writer)
+	send := func(write func()) bool {
+		_ = rc.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		write()
+		return rc.Flush() == nil
+	}
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case msg, ok := <-client.send:
+			if !ok {
+				return
+			}
+			name, data, err := sseEvent(msg)
+			if err != nil {
+				continue
+			}
+			if !send(func() { c.SSEvent(name, data) }) {
+				return
+			}
+		case <-heartbeat.C:
+			if !send(func() { _, _ = io.WriteString(c.Writer, ": heartbeat\n\n") }) {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// sseEvent encodes a WebSocket message as the data of an event named after its
+// type, or "game" for the game state, which has none.
+func sseEvent(msg interface{}) (string, json.RawMessage, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return "", nil, err
+	}
+	var frame wsFrame
+	if json.Unmarshal(data, &frame) != nil || frame.Type == "" {
+		return "game", data, nil
+	}
+	return frame.Type, data, nil
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// summaryTimeout bounds the analysis and the LLM call of a game summary.
+const summaryTimeout = 90 * time.Second
+
+// SummaryResponse is the post-game summary of a finished game.
+type SummaryResponse struct {
+	GameID        int                  `json:"game_id"`
+	Summary       string               `json:"summary"`
+	Source        string               `json:"source"` // "llm", or "engine" without a usable LLM
+	Opening       string               `json:"opening,omitempty"`
+	Result        string               `json:"result"`
+	TurningPoints []ReviewMoveResponse `json:"turning_points"`
+}
+
+// getGameSummary returns a narrative summary of a finished game, written by the
+// LLM provider of the "provider" query parameter, else the default one.
+func (s *Server) getGameSummary(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+	summary, err := s.gameSummary(c.Request.Context(), gameID, game, lock, c.Query("provider"))
+	if err != nil {
+		s.logger.Warn("Game summary failed", zap.Int("game_id", gameID), zap.Error(err))
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "summary_failed", Message: err.Error()})
+		return
+	}
+	if summary == nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "game_not_finished", Message: "summaries are written once the game has ended"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// gameSummary writes the summary of a finished game, or returns the one written
+// before; it returns nil for games still in progress. The turning points come
+// from an engine review of the game and the opening from the built-in book.
+func (s *Server) gameSummary(ctx context.Context, gameID int, game *engine.Game, lock sync.Locker, provider string) (*SummaryResponse, error) {
+	if err := lockGame(lock); err != nil {
+		return nil, err
+	}
+	snapshot := game.Clone()
+	lock.Unlock()
+	if !snapshot.IsGameOver() {
+		return nil, nil
+	}
+	s.gamesMux.RLock()
+	metadata := s.gameMetadata[gameID]
+	var previous *SummaryResponse
+	if metadata != nil {
+		previous = metadata.summary
+	}
+	s.gamesMux.RUnlock()
+	if previous != nil {
+		return previous, nil
+	}
+
+	ctx, cancel := context.WithTimeout(llmContext(ctx, gameID), summaryTimeout)
+	defer cancel()
+	analyzer := s.newMinimaxAI(ai.DifficultyMedium)
+	analyzer.SetOpeningBook(nil)
+	report, err := ai.AnalyzeGame(ctx, snapshot, ai.AnalysisOptions{Engine: analyzer})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &SummaryResponse{
+		GameID:        gameID,
+		Source:        "engine",
+		Opening:       ai.BuiltinBook(ai.RepertoireBalanced).Opening(snapshot),
+		Result:        snapshot.Result().String(),
+		TurningPoints: []ReviewMoveResponse{},
+	}
+	for _, m := range report.TurningPoints(ai.DefaultTurningPoints) {
+		summary.TurningPoints = append(summary.TurningPoints, reviewMoveResponse(m))
+	}
+	summary.Summary = ai.DescribeGame(snapshot, report, summary.Opening)
+
+	if provider == "" {
+		provider = s.config.LLMAI.DefaultProvider
+	}
+	usable := s.config.HasValidLLMProvider(provider)
+	if usable {
+		llm, err := s.newLLMEngine(provider, ai.DifficultyMedium)
+		if err == nil {
+			var text string
+			if text, err = llm.SummarizeGame(ctx, snapshot, report, summary.Opening); err == nil {
+				summary.Summary, summary.Source = text, "llm"
+			}
+		}
+		if err != nil {
+			s.logger.Warn("Failed to write the game summary, using the engine's", zap.String("provider", provider), zap.Error(err))
+		}
+	}
+
+	// A failed LLM summary is tried again next time
+	if summary.Source == "llm" || !usable {
+		s.gamesMux.Lock()
+		if metadata != nil {
+			metadata.summary = summary
+		}
+		s.gamesMux.Unlock()
+	}
+	s.logger.Info("Game summary written", zap.Int("game_id", gameID), zap.String("source", summary.Source))
+	return summary, nil
+}
+
+// pgnSummary returns the summary to append to a finished game's PGN, or "" if the
+// game is in progress or it could not be written.
+func (s *Server) pgnSummary(c *gin.Context, gameID int, game *engine.Game) string {
+	s.gamesMux.RLock()
+	lock := s.gameLocks[gameID]
+	s.gamesMux.RUnlock()
+	if lock == nil {
+		return ""
+	}
+	summary, err := s.gameSummary(c.Request.Context(), gameID, game, lock, c.Query("provider"))
+	if err != nil {
+		s.logger.Warn("Game summary failed", zap.Int("game_id", gameID), zap.Error(err))
+	}
+	if summary == nil {
+		return ""
+	}
+	return summary.Summary
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// UndoRequest takes back moves. Without plies, the player's last move is taken
+// back along with the reply to it, if any, so that it is their turn again.
+type UndoRequest struct {
+	Plies int `json:"plies,omitempty"`
+}
+
+// TakebackRequest is a pending request of a two-player game's player to take
+// moves back.
+type TakebackRequest struct {
+	Color string `json:"color"` // the color that asked
+	Plies int    `json:"plies"`
+}
+
+// TakebackResponse reports a takeback: requested of the opponent of a
+// two-player game, or undone.
+type TakebackResponse struct {
+	Status string         `json:"status"`           // requested, accepted or undone
+	Undone []MoveResponse `json:"undone,omitempty"` // the moves taken back, last first
+	Game   GameResponse   `json:"game"`
+}
+
+// TakebackMessage is pushed to WebSocket clients when a takeback is requested,
+// accepted, declined or made.
+type TakebackMessage struct {
+	Type   string `json:"type"` // always "takeback"
+	GameID int    `json:"game_id"`
+	Color  string `json:"color"` // the color that asked
+	Plies  int    `json:"plies"`
+	Status string `json:"status"` // requested, accepted, declined or undone
+}
+
+// undo takes moves back. Against the AI they are taken back at once; in
+// two-player games the opponent is asked, and answers with undo/accept or
+// undo/decline, or declines by moving.
+func (s *Server) undo(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+	var req UndoRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+			return
+		}
+	}
+	if req.Plies < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_plies", Message: "plies must be positive"})
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	if !s.requireActive(c, gameID) {
+		return
+	}
+	color, ok := s.requestPlayerColor(c, gameID)
+	if !ok {
+		return
+	}
+	played := len(game.MoveHistory())
+	if played == 0 {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "no_moves", Message: "there is no move to take back"})
+		return
+	}
+	plies := req.Plies
+	if plies == 0 {
+		plies = 1
+		if game.ActiveColor() == color {
+			plies = 2 // the opponent replied
+		}
+		plies = min(plies, played)
+	}
+	if plies > played {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_plies", Message: fmt.Sprintf("only %d plies were played", played)})
+		return
+	}
+
+	s.gamesMux.Lock()
+	metadata := s.gameMetadata[gameID]
+	twoPlayer := metadata.isTwoPlayer()
+	pending := twoPlayer && metadata.Takeback != nil
+	if twoPlayer && !pending {
+		metadata.Takeback = &TakebackRequest{Color: color.String(), Plies: plies}
+	}
+	s.gamesMux.Unlock()
+	if pending {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "takeback_pending", Message: "a takeback request is pending"})
+		return
+	}
+	if twoPlayer {
+		s.cache.invalidate(gameID)
+		s.hub.broadcast(gameID, TakebackMessage{Type: "takeback", GameID: gameID, Color: color.String(), Plies: plies, Status: "requested"})
+		s.logger.Info("Takeback requested", zap.Int("game_id", gameID), zap.String("color", color.String()), zap.Int("plies", plies))
+		c.JSON(http.StatusAccepted, TakebackResponse{Status: "requested", Game: s.gameToResponse(gameID, game)})
+		return
+	}
+
+	undone := s.takeBack(gameID, game, color, plies, "undone")
+	c.JSON(http.StatusOK, TakebackResponse{Status: "undone", Undone: undone, Game: s.gameToResponse(gameID, game)})
+}
+
+// pendingTakeback returns the takeback request of a two-player game's
+// opponent of the requester, writing an error response if there is none.
+func (s *Server) pendingTakeback(c *gin.Context, gameID int) (*TakebackRequest, bool) {
+	color, ok := s.requestPlayerColor(c, gameID)
+	if !ok {
+		return nil, false
+	}
+	s.gamesMux.RLock()
+	takeback := s.gameMetadata[gameID].Takeback
+	s.gamesMux.RUnlock()
+	if takeback == nil || takeback.Color != color.Opposite().String() {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "no_takeback_request", Message: "there is no takeback request to answer"})
+		return nil, false
+	}
+	return takeback, true
+}
+
+// acceptTakeback takes back the moves the opponent asked to.
+func (s *Server) acceptTakeback(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	if !s.requireActive(c, gameID) {
+		return
+	}
+	takeback, ok := s.pendingTakeback(c, gameID)
+	if !ok {
+		return
+	}
+	color := engine.White
+	if takeback.Color == engine.Black.String() {
+		color = engine.Black
+	}
+	undone := s.takeBack(gameID, game, color, takeback.Plies, "accepted")
+	c.JSON(http.StatusOK, TakebackResponse{Status: "accepted", Undone: undone, Game: s.gameToResponse(gameID, game)})
+}
+
+// declineTakeback refuses the opponent's takeback request.
+func (s *Server) declineTakeback(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	if !s.requireActive(c, gameID) {
+		return
+	}
+	takeback, ok := s.pendingTakeback(c, gameID)
+	if !ok {
+		return
+	}
+
+	s.withdrawTakeback(gameID)
+	s.cache.invalidate(gameID)
+	s.hub.broadcast(gameID, TakebackMessage{Type: "takeback", GameID: gameID, Color: takeback.Color, Plies: takeback.Plies, Status: "declined"})
+	s.logger.Info("Takeback declined", zap.Int("game_id", gameID))
+	c.JSON(http.StatusOK, s.gameToResponse(gameID, game))
+}
+
+// takeBack undoes the last plies of a game that color asked to take back,
+// returning the moves undone, last first. Draw offers, the takeback request,
+// the AI's resignation and draw streaks and conditional moves, all made for
+// the position left, are dropped; a running clock passes to the side to move.
+// The caller must hold the per-game lock.
+func (s *Server) takeBack(gameID int, game *engine.Game, color engine.Color, plies int, status string) []MoveResponse {
+	undone := make([]MoveResponse, 0, plies)
+	for range plies {
+		move, err := game.UndoMove()
+		if err != nil {
+			break
+		}
+		undone = append(undone, s.moveToResponse(move))
+	}
+	if clock := game.Clock(); clock != nil && clock.Running() {
+		clock.Start(game.ActiveColor())
+	}
+
+	s.resetOutcome(gameID)
+	s.withdrawTakeback(gameID)
+	s.gamesMux.Lock()
+	delete(s.conditionals, gameID)
+	s.gamesMux.Unlock()
+
+	s.cache.invalidate(gameID)
+	s.hub.broadcast(gameID, TakebackMessage{Type: "takeback", GameID: gameID, Color: color.String(), Plies: len(undone), Status: status})
+	s.hub.broadcast(gameID, s.gameToResponse(gameID, game))
+	s.logger.Info("Moves taken back", zap.Int("game_id", gameID), zap.Int("plies", len(undone)))
+	s.scheduleAutoAI(gameID, game)
+	return undone
+}
+
+// withdrawTakeback drops a pending takeback request, e.g. when the opponent
+// plays on instead of answering it.
+func (s *Server) withdrawTakeback(gameID int) {
+	s.gamesMux.Lock()
+	defer s.gamesMux.Unlock()
+	if metadata, exists := s.gameMetadata[gameID]; exists {
+		metadata.Takeback = nil
+	}
+}
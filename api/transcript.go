@@ -0,0 +1,151 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go.rumenx.com/chess/chat"
+	"go.rumenx.com/chess/engine"
+)
+
+// Formats of a game transcript.
+const (
+	transcriptMarkdown = "markdown"
+	transcriptPGN      = "pgn"
+)
+
+// transcriptLine is a chat message of a transcript, placed after the ply
+// (0 before the first move) it was sent at.
+type transcriptLine struct {
+	ply     int
+	speaker string
+	text    string
+}
+
+// getTranscript exports a game's moves with its chat merged in, as Markdown
+// (?format=markdown, the default) or as PGN with the messages as comments
+// (?format=pgn), so that a coaching session can be saved and shared.
+func (s *Server) getTranscript(c *gin.Context) {
+	gameID, game, lock, ok := s.lookupGameForUpdate(c)
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", transcriptMarkdown)
+	if format != transcriptMarkdown && format != transcriptPGN {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_format", Message: "format must be markdown or pgn"})
+		return
+	}
+
+	var messages []chat.Message
+	if s.chatService != nil {
+		messages = s.chatService.GetConversationHistory(gameID)
+	}
+	if !s.requireGameLock(c, lock) {
+		return
+	}
+	defer lock.Unlock()
+	lines := transcriptLines(messages, len(game.MoveHistory()))
+	tags := s.pgnTags(gameID, game)
+
+	filename := fmt.Sprintf("game-%d-transcript", gameID)
+	if format == transcriptPGN {
+		comments := make(map[int][]string)
+		for _, line := range lines {
+			comments[line.ply] = append(comments[line.ply], line.speaker+": "+line.text)
+		}
+		var b strings.Builder
+		for _, tag := range tags {
+			fmt.Fprintf(&b, "[%s \"%s\"]\n", tag[0], tag[1])
+		}
+		b.WriteString("\n" + pgnMovetext(game, comments) + game.Result().String() + "\n")
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename+".pgn"))
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(b.String()))
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename+".md"))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(markdownTranscript(game, tags, lines)))
+}
+
+// transcriptLines returns the user and AI messages of a conversation with the
+// plies they were sent at: the move count of a message's game state, or else
+// that of the message before it, at most plies.
+func transcriptLines(messages []chat.Message, plies int) []transcriptLine {
+	var lines []transcriptLine
+	ply := 0
+	for _, msg := range messages {
+		switch count := msg.GameState["move_count"].(type) {
+		case int:
+			ply = count
+		case float64: // decoded from JSON by a conversation store
+			ply = int(count)
+		}
+		ply = min(max(ply, 0), plies)
+		switch msg.Type {
+		case "user":
+			speaker := msg.UserID
+			if speaker == "" {
+				speaker = defaultUserID
+			}
+			lines = append(lines, transcriptLine{ply: ply, speaker: speaker, text: msg.Content})
+		case "ai":
+			lines = append(lines, transcriptLine{ply: ply, speaker: "AI", text: msg.Content})
+		}
+	}
+	return lines
+}
+
+// markdownTranscript renders a game's tags, then its moves in SAN, with the chat
+// quoted after the move it followed and annotations in italics, then its PGN.
+func markdownTranscript(game *engine.Game, tags [][2]string, lines []transcriptLine) string {
+	tag := make(map[string]string, len(tags))
+	for _, t := range tags {
+		tag[t[0]] = t[1]
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: %s vs %s\n\n", tag["Event"], tag["White"], tag["Black"])
+	fmt.Fprintf(&b, "- Date: %s\n- Variant: %s\n- Result: %s (%s)\n", tag["Date"], tag["Variant"], tag["Result"], tag["Termination"])
+	if fen, ok := tag["FEN"]; ok {
+		fmt.Fprintf(&b, "- Starting position: `%s`\n", fen)
+	}
+	b.WriteString("\n## Moves and chat\n")
+
+	sanMoves := game.GenerateSAN()
+	annotations := game.Annotations()
+	var moves []string // moves since the last chat or annotation
+	flush := func() {
+		if len(moves) > 0 {
+			fmt.Fprintf(&b, "\n**%s**\n", strings.Join(moves, " "))
+			moves = nil
+		}
+	}
+	quote := func(ply int) {
+		for len(lines) > 0 && lines[0].ply <= ply { // earlier plies after an undo
+			flush()
+			fmt.Fprintf(&b, "\n> **%s:** %s\n", lines[0].speaker, strings.ReplaceAll(lines[0].text, "\n", "\n> "))
+			lines = lines[1:]
+		}
+	}
+	quote(0)
+	for i, san := range sanMoves {
+		switch {
+		case i%2 == 0:
+			moves = append(moves, fmt.Sprintf("%d. %s", i/2+1, san))
+		case len(moves) == 0:
+			moves = append(moves, fmt.Sprintf("%d... %s", i/2+1, san))
+		default:
+			moves = append(moves, san)
+		}
+		if i < len(annotations) && annotations[i].Comment != "" {
+			flush()
+			fmt.Fprintf(&b, "\n*%s*\n", annotations[i].Comment)
+		}
+		quote(i + 1)
+	}
+	flush()
+
+	fmt.Fprintf(&b, "\n## PGN\n\n```pgn\n%s%s\n```\n", pgnMovetext(game, nil), tag["Result"])
+	return b.String()
+}
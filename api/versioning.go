@@ -0,0 +1,46 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The REST API is served under apiV1Prefix. legacyAPIPrefix serves the same
+// routes for clients written before versioning, with deprecation headers.
+const (
+	apiV1Prefix     = "/api/v1"
+	legacyAPIPrefix = "/api"
+)
+
+// legacyAPIDeprecatedAt is when the unversioned routes were deprecated.
+var legacyAPIDeprecatedAt = time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC)
+
+// unversionedPath returns a route with its API version left out, so that
+// /api/v1/games/:id and its legacy alias /api/games/:id are both
+// /api/games/:id.
+func unversionedPath(route string) string {
+	if rest, ok := strings.CutPrefix(route, apiV1Prefix); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+		return legacyAPIPrefix + rest
+	}
+	return route
+}
+
+// routePath returns the unversioned route of a request, for middleware that
+// applies to routes of every version.
+func routePath(c *gin.Context) string {
+	return unversionedPath(c.FullPath())
+}
+
+// deprecatedAPI marks responses of the legacy routes deprecated (RFC 9745),
+// linking the route of the current version that replaces them.
+func deprecatedAPI() gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", legacyAPIDeprecatedAt.Unix())
+	return func(c *gin.Context) {
+		successor := apiV1Prefix + strings.TrimPrefix(c.Request.URL.Path, legacyAPIPrefix)
+		c.Header("Deprecation", deprecation)
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+	}
+}
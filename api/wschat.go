@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/chat"
+	"go.rumenx.com/chess/engine"
+)
+
+// ChatFrame is a chat message a WebSocket client sends to its game:
+// {"type":"chat","message":"..."} with the other fields of a ChatRequest.
+type ChatFrame struct {
+	Type string `json:"type"` // always "chat"
+	ChatRequest
+}
+
+// ChatMessageFrame is pushed to a game's WebSocket clients for every chat
+// message sent over a socket: the player's once the chat filter and limits
+// accept it, sanitized if need be, and the AI's reply when it is complete.
+type ChatMessageFrame struct {
+	Type        string   `json:"type"` // always "chat_message"
+	GameID      int      `json:"game_id"`
+	ChatID      string   `json:"chat_id"`           // shared by a message, the tokens and the reply to it
+	Role        string   `json:"role"`              // "user" or "ai"
+	UserID      string   `json:"user_id,omitempty"` // who sent a user message
+	Content     string   `json:"content"`
+	MessageID   string   `json:"message_id,omitempty"` // the message's ID in the chat history
+	ReplyTo     string   `json:"reply_to,omitempty"`   // the ID of the player's message an AI message answers
+	Personality string   `json:"personality,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// ErrorFrame is sent to the WebSocket client whose message failed.
+type ErrorFrame struct {
+	Type string `json:"type"` // always "error"
+	ErrorResponse
+}
+
+// wsChat broadcasts the chat message of userID to the game's WebSocket clients,
+// then the AI's reply, or returns the error for the sender.
+func (s *Server) wsChat(ctx context.Context, gameID int, game *engine.Game, userID string, req ChatRequest) *ErrorResponse {
+	if s.chatService == nil {
+		return &ErrorResponse{Error: "chat_unavailable", Message: "chat service unavailable"}
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		return &ErrorResponse{Error: "invalid_chat", Message: "message is required"}
+	}
+	provider, errResp := s.resolveChatProvider(req)
+	if errResp != nil {
+		return errResp
+	}
+
+	chatID := fmt.Sprintf("chat_%d_%d", gameID, time.Now().UnixNano())
+	accepted := func(message chat.Message) {
+		s.hub.broadcast(gameID, ChatMessageFrame{Type: "chat_message", GameID: gameID, ChatID: chatID, Role: "user", UserID: userID, Content: message.Content, MessageID: message.ID})
+	}
+	response, err := s.chat(ctx, gameID, game, userID, req, provider, accepted)
+	if errResp, _ := chatLimitError(err); errResp != nil {
+		return errResp
+	}
+	if errResp := chatRejectedError(err); errResp != nil {
+		return errResp
+	}
+	if errors.Is(err, errGameBusy) {
+		return &ErrorResponse{Error: "game_busy", Message: errGameBusy.Error()}
+	}
+	if err != nil {
+		s.logger.Error("Failed to get chat response", zap.Int("game_id", gameID), zap.Error(err))
+		return &ErrorResponse{Error: "chat_failed", Message: fmt.Sprintf("Failed to get AI response: %v", err)}
+	}
+	s.hub.broadcast(gameID, ChatMessageFrame{
+		Type:        "chat_message",
+		GameID:      gameID,
+		ChatID:      chatID,
+		Role:        "ai",
+		Content:     response.Message,
+		MessageID:   response.ReplyID,
+		ReplyTo:     response.MessageID,
+		Personality: response.Personality,
+		Suggestions: response.Suggestions,
+	})
+	return nil
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// wsFrame is the envelope of the messages a game's WebSocket clients send:
+// its type is subscribe, move, chat or ping.
+type wsFrame struct {
+	Type string `json:"type"`
+}
+
+// MoveFrame is a move a WebSocket client plays: {"type":"move","from":"e2","to":"e4"}
+// with the other fields of a MoveRequest. In two-player games the player token
+// the client connected with seats the player.
+type MoveFrame struct {
+	Type string `json:"type"` // always "move"
+	MoveRequest
+}
+
+// MoveCheckFrame is sent to the WebSocket client whose move was refused as a
+// mistake or blunder because it asked for the move to be checked.
+type MoveCheckFrame struct {
+	Type string `json:"type"` // always "error"
+	MoveCheckResponse
+}
+
+// PongFrame answers a client's ping frame, for clients that cannot send
+// WebSocket pings, such as browsers.
+type PongFrame struct {
+	Type string `json:"type"` // always "pong"
+}
+
+// handleWSMessage handles a message the WebSocket client of userID sent about
+// a game. A subscribe frame is answered with the game's state, a ping with a
+// pong; moves and chat messages of users who may play in the game are played
+// and answered over the socket, chat counted against the chat limits as
+// limitKey. Spectators only watch. Anything else gets an error frame.
+func (s *Server) handleWSMessage(ctx context.Context, gameID int, game *engine.Game, userID, limitKey, token string, client *wsClient, data []byte) {
+	var frame wsFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		client.send <- ErrorFrame{Type: "error", ErrorResponse: ErrorResponse{Error: "invalid_message", Message: "messages must be JSON objects"}}
+		return
+	}
+	switch frame.Type {
+	case "subscribe":
+		client.send <- s.gameToResponse(gameID, game)
+		return
+	case "ping":
+		client.send <- PongFrame{Type: "pong"}
+		return
+	case "move", "chat":
+	default:
+		client.send <- ErrorFrame{Type: "error", ErrorResponse: ErrorResponse{Error: "unknown_message_type", Message: "messages are of type subscribe, move, chat or ping"}}
+		return
+	}
+
+	if client.spectator {
+		client.send <- ErrorFrame{Type: "error", ErrorResponse: ErrorResponse{Error: "spectator", Message: "spectators cannot " + frame.Type}}
+		return
+	}
+
+	// As over HTTP, a player token lets its holder play without being invited
+	if s.playerColor(gameID, token) == "" {
+		if errResp := s.mayPlay(ctx, gameID); errResp != nil {
+			client.send <- ErrorFrame{Type: "error", ErrorResponse: *errResp}
+			return
+		}
+	}
+	if frame.Type == "move" {
+		var move MoveFrame
+		if err := json.Unmarshal(data, &move); err != nil {
+			client.send <- ErrorFrame{Type: "error", ErrorResponse: ErrorResponse{Error: "invalid_request", Message: err.Error()}}
+			return
+		}
+		client.send <- s.wsMove(ctx, gameID, game, token, move.MoveRequest)
+		return
+	}
+	var chatFrame ChatFrame
+	if err := json.Unmarshal(data, &chatFrame); err != nil {
+		client.send <- ErrorFrame{Type: "error", ErrorResponse: ErrorResponse{Error: "invalid_request", Message: err.Error()}}
+		return
+	}
+	chatFrame.limitKey = limitKey
+	if errResp := s.wsChat(ctx, gameID, game, userID, chatFrame.ChatRequest); errResp != nil {
+		client.send <- ErrorFrame{Type: "error", ErrorResponse: *errResp}
+	}
+}
+
+// wsMove plays a move sent over a WebSocket by the holder of a player token,
+// returning the frame for the sender: the game after the move, or the error.
+// The game's clients are told of the move by its events.
+func (s *Server) wsMove(ctx context.Context, gameID int, game *engine.Game, token string, req MoveRequest) interface{} {
+	s.gamesMux.RLock()
+	lock := s.gameLocks[gameID]
+	s.gamesMux.RUnlock()
+	if lock != nil {
+		if err := lockGame(lock); err != nil {
+			return ErrorFrame{Type: "error", ErrorResponse: ErrorResponse{Error: "game_busy", Message: errGameBusy.Error()}}
+		}
+		defer lock.Unlock()
+	}
+
+	_, body := s.playMoveLocked(ctx, gameID, game, token, req)
+	switch body := body.(type) {
+	case ErrorResponse:
+		return ErrorFrame{Type: "error", ErrorResponse: body}
+	case MoveCheckResponse:
+		return MoveCheckFrame{Type: "error", MoveCheckResponse: body}
+	}
+	return body
+}
@@ -0,0 +1,69 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// Chat modes of a ChatRequest. The default mode answers questions directly.
+const (
+	ModeChat = "chat"
+	// ModeCoach guides the player with questions and partial hints instead.
+	ModeCoach = "coach"
+)
+
+// IsChatMode reports whether mode is a known chat mode; empty is the default.
+func IsChatMode(mode string) bool {
+	return mode == "" || mode == ModeChat || mode == ModeCoach
+}
+
+// CoachNotes describes what the engine sees in the position for the side to
+// move: whether it is in check, its pieces the opponent wins by taking, and the
+// opponent's pieces it wins by taking.
+func CoachNotes(game *engine.Game) []string {
+	toMove := game.ActiveColor()
+	var notes []string
+	if game.Status() == engine.Check {
+		notes = append(notes, fmt.Sprintf("The %s king is in check.", toMove))
+	}
+	for _, threat := range game.HangingPieces(toMove) {
+		notes = append(notes, fmt.Sprintf("%s's %s is attacked by %s and %s.",
+			toMove, describePiece(game, threat.Square), describePiece(game, threat.Attackers[0]), defenceNote(threat)))
+	}
+	for _, threat := range game.HangingPieces(toMove.Opposite()) {
+		notes = append(notes, fmt.Sprintf("%s's %s can be won: it is attacked by %s and %s.",
+			toMove.Opposite(), describePiece(game, threat.Square), describePiece(game, threat.Attackers[0]), defenceNote(threat)))
+	}
+	return notes
+}
+
+// describePiece names the piece on sq, e.g. "knight on f3".
+func describePiece(game *engine.Game, sq engine.Square) string {
+	return fmt.Sprintf("%s on %s", game.Board().GetPiece(sq).Type, sq)
+}
+
+// defenceNote says why a hanging piece is lost.
+func defenceNote(threat engine.Threat) string {
+	if len(threat.Defenders) == 0 {
+		return "not defended"
+	}
+	return "defended, but worth more than its attacker"
+}
+
+// coachInstruction asks the AI to coach rather than answer, with what the
+// engine sees in the position for it to lead the player to.
+func coachInstruction(moveData *MoveContext) string {
+	var b strings.Builder
+	b.WriteString("\n\nCoach mode: do not give the answer or name the best move. Reply with one or two guiding questions and at most a partial hint, such as \"What is attacking your knight?\", so the player finds it themselves.")
+	if moveData == nil || len(moveData.CoachNotes) == 0 {
+		b.WriteString(" The engine sees no hanging pieces; ask about threats, plans and candidate moves.")
+		return b.String()
+	}
+	b.WriteString("\nWhat the engine sees, for you to lead the player to without revealing it:")
+	for _, note := range moveData.CoachNotes {
+		b.WriteString("\n- " + note)
+	}
+	return b.String()
+}
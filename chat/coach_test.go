@@ -0,0 +1,59 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestCoachNotes(t *testing.T) {
+	g := engine.NewGame()
+	for _, uci := range []string{"e2e4", "e7e5", "g1f3", "d7d6", "f1c4", "c8g4"} {
+		move, err := engine.MoveFromUCI(g, uci)
+		if err != nil {
+			t.Fatalf("parse %s: %v", uci, err)
+		}
+		if err := g.MakeMove(move); err != nil {
+			t.Fatalf("apply %s: %v", uci, err)
+		}
+	}
+	// White to move: nothing of White's hangs, but the c4 bishop eyes f7, defended
+	// only by the king, and the e5 pawn is defended
+	if notes := CoachNotes(g); len(notes) != 0 {
+		t.Fatalf("expected no notes, got %q", notes)
+	}
+
+	move, _ := engine.MoveFromUCI(g, "c4f7")
+	if err := g.MakeMove(move); err != nil {
+		t.Fatal(err)
+	}
+	notes := CoachNotes(g)
+	if len(notes) == 0 || notes[0] != "The black king is in check." {
+		t.Fatalf("expected the check noted first, got %q", notes)
+	}
+	if !strings.Contains(strings.Join(notes, "\n"), "white's bishop on f7 can be won: it is attacked by king on e8 and not defended.") {
+		t.Errorf("expected the undefended bishop noted, got %q", notes)
+	}
+}
+
+func TestChatService_CoachMode(t *testing.T) {
+	svc := newTestService(t)
+	recorder := &promptRecorder{}
+	svc.SetChatbotForTesting(recorder)
+
+	moveData := &MoveContext{CoachNotes: []string{"white's knight on f3 is attacked by pawn on e4 and not defended."}}
+	if _, err := svc.Chat(context.Background(), ChatRequest{GameID: 10, Message: "What now?", Mode: ModeCoach, MoveData: moveData}); err != nil {
+		t.Fatalf("Chat error: %v", err)
+	}
+	if _, err := svc.Chat(context.Background(), ChatRequest{GameID: 10, Message: "And now?", MoveData: moveData}); err != nil {
+		t.Fatalf("Chat error: %v", err)
+	}
+	if coach := recorder.prompts[0]; !strings.Contains(coach, "guiding questions") || !strings.Contains(coach, "- white's knight on f3 is attacked") {
+		t.Errorf("expected a coaching prompt with the engine's notes, got %q", coach)
+	}
+	if strings.Contains(recorder.prompts[1], "Coach mode") {
+		t.Errorf("expected a plain prompt outside coach mode, got %q", recorder.prompts[1])
+	}
+}
@@ -61,7 +61,7 @@ func TestCreateCustomChatbot(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			chatbot, err := service.createCustomChatbot(tt.provider, tt.apiKey)
+			chatbot, err := service.createCustomChatbot(tt.provider, tt.apiKey, "")
 
 			if tt.wantErr {
 				if err == nil {
@@ -113,7 +113,7 @@ func TestChatWithCustomAPIKey(t *testing.T) {
 		t.Logf("Expected failure without real API key: %v", err)
 
 		// Verify the conversation was still created
-		if conv, exists := service.conversations[req.GameID]; !exists {
+		if conv := service.GetConversation(req.GameID); conv == nil {
 			t.Error("Expected conversation to be created even on API failure")
 		} else {
 			if len(conv.Messages) == 0 {
@@ -397,7 +397,7 @@ func TestBuildContextualMessage(t *testing.T) {
 	}
 
 	userMessage := "What should I play in response to e4?"
-	contextualMessage := service.buildContextualMessage(userMessage, conversation, moveData)
+	contextualMessage := service.buildContextualMessage("", userMessage, conversation, moveData)
 
 	if contextualMessage == "" {
 		t.Error("Expected non-empty contextual message")
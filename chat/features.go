@@ -0,0 +1,150 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// Kinds of PositionFeature.
+const (
+	FeatureCheck          = "check"           // the side to move is in check
+	FeaturePin            = "pin"             // the piece on Square is pinned to its king
+	FeatureHanging        = "hanging"         // the piece of the side to move on Square can be won
+	FeatureWinningCapture = "winning_capture" // Move wins material (see Game.CaptureGain)
+	FeatureLosingCapture  = "losing_capture"  // Move captures, but loses material
+	FeatureMistake        = "mistake"         // the last move, Move, was a mistake or a blunder
+)
+
+// PositionFeature is something in a position worth asking the AI about. Chat
+// turns features into follow-up suggestions such as "Why does Nxe5 fail?".
+type PositionFeature struct {
+	Kind   string `json:"kind"`
+	Square string `json:"square,omitempty"`
+	Move   string `json:"move,omitempty"` // in SAN
+}
+
+// PositionFeatures finds what a game's position offers to ask about: a check,
+// pins, the side to move's pieces that can be won, the captures that win or
+// lose material, and whether the last move was a mistake. Features of
+// finished games are not looked for. The game must not change meanwhile; pass
+// a copy of a game others play.
+func PositionFeatures(ctx context.Context, game *engine.Game) []PositionFeature {
+	if game.Status() != engine.InProgress && game.Status() != engine.Check {
+		return nil
+	}
+	toMove := game.ActiveColor()
+	var features []PositionFeature
+	if last := lastMoveMistake(ctx, game); last != "" {
+		features = append(features, PositionFeature{Kind: FeatureMistake, Move: last})
+	}
+	if game.Status() == engine.Check {
+		features = append(features, PositionFeature{Kind: FeatureCheck})
+	}
+
+	legal := game.GetAllLegalMoves()
+	if capture := winningCapture(game, legal); capture != "" {
+		features = append(features, PositionFeature{Kind: FeatureWinningCapture, Move: capture})
+	}
+	for _, threat := range game.HangingPieces(toMove) {
+		features = append(features, PositionFeature{Kind: FeatureHanging, Square: threat.Square.String()})
+	}
+	for _, pin := range append(game.Pins(toMove.Opposite()), game.Pins(toMove)...) {
+		features = append(features, PositionFeature{Kind: FeaturePin, Square: pin.Square.String()})
+	}
+	if capture := losingCapture(game, legal); capture != "" {
+		features = append(features, PositionFeature{Kind: FeatureLosingCapture, Move: capture})
+	}
+	return features
+}
+
+// mistakeSearch bounds the searches that grade the last move, which run for
+// every chat message: shallow enough to stay cheap, deep enough to see a
+// piece dropped or a mate in one allowed.
+var mistakeSearch = ai.SearchLimits{Depth: 2, MoveTime: 100 * time.Millisecond}
+
+// lastMoveMistake returns the last move in SAN if a quick search grades it a
+// mistake or a blunder.
+func lastMoveMistake(ctx context.Context, game *engine.Game) string {
+	if len(game.MoveHistory()) == 0 {
+		return ""
+	}
+	minimax := ai.NewMinimaxAI(ai.DifficultyEasy)
+	minimax.SetOpeningBook(nil)
+	minimax.SetLimits(mistakeSearch)
+	analysis, err := ai.AnalyzeLastMove(ctx, game, ai.AnalysisOptions{Engine: minimax})
+	if err != nil || analysis.Class != ai.ClassMistake && analysis.Class != ai.ClassBlunder {
+		return ""
+	}
+	return analysis.SAN
+}
+
+// evenTrade bounds, in centipawns, the material a capture may win or lose and
+// still count as a trade, such as a bishop for a knight.
+const evenTrade = 50
+
+// winningCapture returns the legal capture, in SAN, that wins the most material,
+// if any does.
+func winningCapture(game *engine.Game, legal []engine.Move) string {
+	best, bestGain := "", evenTrade
+	for _, move := range legal {
+		if gain := game.CaptureGain(move); gain > bestGain {
+			best, bestGain = moveSAN(game, move), gain
+		}
+	}
+	return best
+}
+
+// losingCapture returns the legal capture, in SAN, that loses the most material,
+// if any does.
+func losingCapture(game *engine.Game, legal []engine.Move) string {
+	worst, worstGain := "", -evenTrade
+	for _, move := range legal {
+		if gain := game.CaptureGain(move); gain < worstGain {
+			worst, worstGain = moveSAN(game, move), gain
+		}
+	}
+	return worst
+}
+
+// moveSAN returns a legal move of the game in SAN.
+func moveSAN(game *engine.Game, move engine.Move) string {
+	san, err := game.SANLine([]engine.Move{move})
+	if err != nil {
+		return move.String()
+	}
+	return san[0]
+}
+
+// featureSuggestions turns position features into follow-up questions in the
+// language of p.
+func featureSuggestions(p phrases, features []PositionFeature) []string {
+	var suggestions []string
+	for _, feature := range features {
+		var suggestion string
+		switch feature.Kind {
+		case FeatureCheck:
+			suggestion = p.check
+		case FeaturePin:
+			suggestion = fmt.Sprintf(p.pin, feature.Square)
+		case FeatureHanging:
+			suggestion = fmt.Sprintf(p.hanging, feature.Square)
+		case FeatureWinningCapture:
+			suggestion = fmt.Sprintf(p.winningCapture, feature.Move)
+		case FeatureLosingCapture:
+			suggestion = fmt.Sprintf(p.losingCapture, feature.Move)
+		case FeatureMistake:
+			suggestion = fmt.Sprintf(p.mistake, feature.Move)
+		default:
+			continue
+		}
+		if !slices.Contains(suggestions, suggestion) {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
@@ -0,0 +1,77 @@
+package chat
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// play plays UCI moves on a new game.
+func play(t *testing.T, moves ...string) *engine.Game {
+	t.Helper()
+	g := engine.NewGame()
+	for _, uci := range moves {
+		move, err := g.ParseMove(uci)
+		if err != nil {
+			t.Fatalf("%s: %v", uci, err)
+		}
+		if err := g.MakeMove(move); err != nil {
+			t.Fatalf("%s: %v", uci, err)
+		}
+	}
+	return g
+}
+
+func TestPositionFeatures(t *testing.T) {
+	if features := PositionFeatures(context.Background(), engine.NewGame()); len(features) != 0 {
+		t.Fatalf("expected nothing to ask about in the starting position, got %+v", features)
+	}
+
+	tests := []struct {
+		name  string
+		moves []string
+		want  []PositionFeature
+	}{
+		{"check", []string{"e2e4", "e7e5", "g1f3", "d7d6", "f1b5"}, []PositionFeature{{Kind: FeatureCheck}}},
+		{"pin and losing capture", []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5", "d7d6"}, []PositionFeature{
+			{Kind: FeaturePin, Square: "c6"},
+			{Kind: FeatureLosingCapture, Move: "Nxe5"},
+		}},
+		{"blunder", []string{"e2e4", "e7e5", "d1h5", "b8c6", "f1c4", "g8f6"}, []PositionFeature{
+			{Kind: FeatureMistake, Move: "Nf6"},
+			{Kind: FeatureWinningCapture, Move: "Bxf7+"},
+			{Kind: FeaturePin, Square: "f7"},
+			{Kind: FeatureHanging, Square: "h5"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features := PositionFeatures(context.Background(), play(t, tt.moves...))
+			for _, want := range tt.want {
+				if !slices.Contains(features, want) {
+					t.Errorf("expected %+v, got %+v", want, features)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateSuggestions_Features(t *testing.T) {
+	svc := newTestService(t)
+	moveData := &MoveContext{MoveCount: 6, Features: []PositionFeature{
+		{Kind: FeatureMistake, Move: "Nf6"},
+		{Kind: FeaturePin, Square: "c6"},
+	}}
+	suggestions := svc.generateSuggestions(svc.StartConversation(1), moveData)
+	if len(suggestions) != 3 || suggestions[0] != "What was wrong with Nf6?" || suggestions[1] != "What about the pin on c6?" {
+		t.Errorf("expected the position's questions first, got %v", suggestions)
+	}
+
+	conv := svc.StartConversation(2)
+	conv.Language = "de"
+	if suggestions := svc.generateSuggestions(conv, moveData); suggestions[0] != "Was war falsch an Nf6?" {
+		t.Errorf("expected German suggestions, got %v", suggestions)
+	}
+}
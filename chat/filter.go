@@ -0,0 +1,223 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Actions of the input filter on a player's message that matches it.
+const (
+	// FilterReject refuses the message with a *MessageRejectedError.
+	FilterReject = "reject"
+	// FilterSanitize stars out profanities and aggression and removes links,
+	// then sends the rest.
+	FilterSanitize = "sanitize"
+	// FilterWarn sends the message unchanged; only the moderation event records it.
+	FilterWarn = "warn"
+)
+
+// DefaultProfanities and DefaultAggressionPatterns are used when the message
+// filtering config lists none.
+var (
+	DefaultProfanities        = []string{"fuck", "fucking", "shit", "bitch", "asshole", "bastard", "cunt", "dick", "motherfucker"}
+	DefaultAggressionPatterns = []string{`\bi(?:'ll| will)? kill you\b`, `\bi hate you\b`, `\bshut up\b`, `\bgo to hell\b`, `\bidiot\b`, `\bmoron\b`}
+)
+
+// linkReplacement stands in for links a sanitizing filter removes.
+const linkReplacement = "[link removed]"
+
+// ErrMessageRejected is wrapped by the errors of messages the input filter rejects.
+var ErrMessageRejected = errors.New("message rejected by the chat filter")
+
+// MessageRejectedError reports why the input filter rejected a message.
+type MessageRejectedError struct {
+	Reasons []string // "profanity", "aggression" or "link"
+}
+
+func (e *MessageRejectedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrMessageRejected, strings.Join(e.Reasons, ", "))
+}
+
+func (e *MessageRejectedError) Unwrap() error { return ErrMessageRejected }
+
+// InputFilterOptions configures the filter of players' chat messages.
+type InputFilterOptions struct {
+	Action string // FilterReject, FilterSanitize or FilterWarn; empty disables the filter
+	// Profanities are words matched whole and case-insensitively, and
+	// AggressionPatterns case-insensitive regular expressions. Either replaces
+	// the list of the go-chatbot message filtering config when not empty.
+	Profanities        []string
+	AggressionPatterns []string
+}
+
+// IsFilterAction reports whether action is a known input filter action; empty
+// disables the filter.
+func IsFilterAction(action string) bool {
+	switch action {
+	case "", FilterReject, FilterSanitize, FilterWarn:
+		return true
+	default:
+		return false
+	}
+}
+
+// inputFilter screens players' chat messages before they reach the
+// conversation and the LLM.
+type inputFilter struct {
+	action      string
+	profanities []*regexp.Regexp
+	aggression  []*regexp.Regexp
+	link        *regexp.Regexp // nil if links pass
+}
+
+// inputFilterResult is the outcome of screening a message.
+type inputFilterResult struct {
+	text    string   // the message to send
+	reasons []string // why it matched, in the order of the Reasons of MessageRejectedError
+}
+
+// SetInputFilter filters players' chat messages with the profanities,
+// aggression patterns and link pattern of the service's go-chatbot message
+// filtering config, lists of opts replacing the config's, and the defaults
+// applying where both are empty. Chat then returns a
+// *MessageRejectedError for a message the reject action refuses, and send
+// sanitized messages in place of the originals.
+func (cs *ChatService) SetInputFilter(opts InputFilterOptions) error {
+	if !IsFilterAction(opts.Action) {
+		return fmt.Errorf("unknown input filter action %q", opts.Action)
+	}
+	if opts.Action == "" {
+		cs.inputFilter = nil
+		return nil
+	}
+	filtering := cs.config.MessageFiltering
+	if len(opts.Profanities) > 0 {
+		filtering.Profanities = opts.Profanities
+	}
+	if len(opts.AggressionPatterns) > 0 {
+		filtering.AggressionPatterns = opts.AggressionPatterns
+	}
+
+	filter := &inputFilter{action: opts.Action, profanities: wordPatterns(orDefault(filtering.Profanities, DefaultProfanities))}
+	for _, pattern := range orDefault(filtering.AggressionPatterns, DefaultAggressionPatterns) {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return fmt.Errorf("invalid aggression pattern %q: %w", pattern, err)
+		}
+		filter.aggression = append(filter.aggression, re)
+	}
+	if filtering.LinkPattern != "" {
+		re, err := regexp.Compile("(?i)" + filtering.LinkPattern)
+		if err != nil {
+			return fmt.Errorf("invalid link pattern %q: %w", filtering.LinkPattern, err)
+		}
+		filter.link = re
+	}
+	cs.config.MessageFiltering = filtering
+	cs.inputFilter = filter
+	return nil
+}
+
+// orDefault returns list, or defaults if it is empty.
+func orDefault(list, defaults []string) []string {
+	if len(list) == 0 {
+		return defaults
+	}
+	return list
+}
+
+// screen matches text against the filter and sanitizes it if that is the
+// filter's action. A nil filter passes everything.
+func (f *inputFilter) screen(text string) inputFilterResult {
+	result := inputFilterResult{text: text}
+	if f == nil {
+		return result
+	}
+	replace := func(reason string, patterns []*regexp.Regexp, with func(string) string) {
+		matched := false
+		for _, pattern := range patterns {
+			if pattern.MatchString(result.text) {
+				matched = true
+				if f.action == FilterSanitize {
+					result.text = pattern.ReplaceAllStringFunc(result.text, with)
+				}
+			}
+		}
+		if matched {
+			result.reasons = append(result.reasons, reason)
+		}
+	}
+	stars := func(match string) string { return strings.Repeat("*", len([]rune(match))) }
+	replace("profanity", f.profanities, stars)
+	replace("aggression", f.aggression, stars)
+	if f.link != nil {
+		replace("link", []*regexp.Regexp{f.link}, func(string) string { return linkReplacement })
+	}
+	return result
+}
+
+// screenInput screens a player's message, recording a match as an input
+// moderation event, and returns the message to send with the reasons it
+// matched, or a *MessageRejectedError.
+func (cs *ChatService) screenInput(event ModerationEvent, text string) (string, []string, error) {
+	filtered := cs.inputFilter.screen(text)
+	if len(filtered.reasons) == 0 {
+		return text, nil, nil
+	}
+	event.Direction, event.Action, event.Reasons = "input", cs.inputFilter.action, filtered.reasons
+	cs.emitModeration(event)
+	if cs.inputFilter.action == FilterReject {
+		return "", nil, &MessageRejectedError{Reasons: filtered.reasons}
+	}
+	return filtered.text, filtered.reasons, nil
+}
+
+// ScreenRoomMessage screens a message userID posts to a chat room like a chat
+// message of a game, and returns the message to post with the reasons the
+// filter matched, or a *MessageRejectedError.
+func (cs *ChatService) ScreenRoomMessage(roomID, userID, text string) (string, []string, error) {
+	return cs.screenInput(ModerationEvent{Kind: "room", RoomID: roomID, UserID: userID}, text)
+}
+
+// ModerationEvent records a player message the input filter matched or an LLM
+// response the moderator filtered.
+type ModerationEvent struct {
+	Direction string    `json:"direction"` // "input" for players' messages, "output" for LLM responses
+	Kind      string    `json:"kind"`      // "chat", "reaction" or "room"
+	GameID    int       `json:"game_id"`
+	RoomID    string    `json:"room_id,omitempty"` // the chat room of a room message
+	UserID    string    `json:"user_id,omitempty"` // who sent an input message
+	Action    string    `json:"action"`            // the input filter action, or "block" or "mask" for output
+	Reasons   []string  `json:"reasons"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SetModerationEventHandler passes every moderation event to handler; nil stops
+// passing them. The handler is called on the goroutine of the chat request.
+func (cs *ChatService) SetModerationEventHandler(handler func(ModerationEvent)) {
+	cs.moderationEvents = handler
+}
+
+// emitModeration passes event to the moderation event handler, if any.
+func (cs *ChatService) emitModeration(event ModerationEvent) {
+	if cs.moderationEvents == nil {
+		return
+	}
+	event.Timestamp = time.Now()
+	cs.moderationEvents(event)
+}
+
+// emitOutputModeration records an LLM response the moderator filtered.
+func (cs *ChatService) emitOutputModeration(kind string, gameID int, result ModerationResult) {
+	if !result.Filtered() {
+		return
+	}
+	action := "mask"
+	if result.Blocked {
+		action = "block"
+	}
+	cs.emitModeration(ModerationEvent{Direction: "output", Kind: kind, GameID: gameID, Action: action, Reasons: result.Reasons})
+}
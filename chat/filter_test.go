@@ -0,0 +1,100 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChatService_InputFilter(t *testing.T) {
+	tests := []struct {
+		action   string
+		wantErr  bool
+		wantText string
+	}{
+		{FilterReject, true, ""},
+		{FilterSanitize, false, "*******, this **** position again? See [link removed]"},
+		{FilterWarn, false, "Shut up, this shit position again? See https://example.com/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			svc := newTestService(t)
+			recorder := &promptRecorder{}
+			svc.SetChatbotForTesting(recorder)
+			if err := svc.SetInputFilter(InputFilterOptions{Action: tt.action}); err != nil {
+				t.Fatal(err)
+			}
+			var events []ModerationEvent
+			svc.SetModerationEventHandler(func(e ModerationEvent) { events = append(events, e) })
+
+			resp, err := svc.Chat(context.Background(), ChatRequest{GameID: 8, UserID: "bob", Message: "Shut up, this shit position again? See https://example.com/x"})
+			wantReasons := []string{"profanity", "aggression", "link"}
+			if len(events) != 1 || events[0].Direction != "input" || events[0].UserID != "bob" || events[0].Action != tt.action ||
+				!slices.Equal(events[0].Reasons, wantReasons) {
+				t.Errorf("expected one input event, got %+v", events)
+			}
+			if tt.wantErr {
+				var rejected *MessageRejectedError
+				if !errors.As(err, &rejected) || !errors.Is(err, ErrMessageRejected) || len(recorder.prompts) != 0 {
+					t.Fatalf("expected the message rejected before the LLM, got %v", err)
+				}
+				if svc.GetConversation(8) != nil {
+					t.Errorf("expected nothing stored for a rejected message")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Chat error: %v", err)
+			}
+			if !slices.Equal(resp.Filtered, wantReasons) {
+				t.Errorf("expected the reasons in the response, got %v", resp.Filtered)
+			}
+			history := svc.GetConversationHistory(8)
+			if history[1].Content != tt.wantText || !strings.Contains(recorder.prompts[0], tt.wantText) {
+				t.Errorf("expected %q stored and sent, got %q", tt.wantText, history[1].Content)
+			}
+		})
+	}
+}
+
+func TestChatService_InputFilterOptions(t *testing.T) {
+	svc := newTestService(t)
+	if err := svc.SetInputFilter(InputFilterOptions{Action: "ban"}); err == nil {
+		t.Errorf("expected an error for an unknown action")
+	}
+	if err := svc.SetInputFilter(InputFilterOptions{Action: FilterSanitize, AggressionPatterns: []string{"("}}); err == nil {
+		t.Errorf("expected an error for an invalid pattern")
+	}
+
+	// Custom lists replace the defaults, and clean messages pass
+	if err := svc.SetInputFilter(InputFilterOptions{Action: FilterSanitize, Profanities: []string{"drat"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.inputFilter.screen("Drat, and shit"); got.text != "****, and shit" || !slices.Equal(got.reasons, []string{"profanity"}) {
+		t.Errorf("expected only the custom word starred, got %+v", got)
+	}
+	if got := svc.inputFilter.screen("Is Nf3 better than Nc3?"); got.reasons != nil {
+		t.Errorf("expected a clean message to pass, got %+v", got)
+	}
+
+	if err := svc.SetInputFilter(InputFilterOptions{}); err != nil || svc.inputFilter != nil {
+		t.Errorf("expected an empty action to remove the filter")
+	}
+}
+
+func TestChatService_OutputModerationEvents(t *testing.T) {
+	svc := newTestService(t)
+	svc.SetChatbotForTesting(&mockChatbot{reply: "Damn, nice move"})
+	svc.SetModerator(NewModerator(ModerationOptions{MaskedWords: []string{"damn"}}, nil))
+	var events []ModerationEvent
+	svc.SetModerationEventHandler(func(e ModerationEvent) { events = append(events, e) })
+
+	if _, err := svc.Chat(context.Background(), ChatRequest{GameID: 9, Message: "Hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Direction != "output" || events[0].Action != "mask" || events[0].Kind != "chat" || events[0].GameID != 9 {
+		t.Errorf("expected a masked output event, got %+v", events)
+	}
+}
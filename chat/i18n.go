@@ -0,0 +1,211 @@
+package chat
+
+import "go.rumenx.com/chess/ai"
+
+// phrases are the canned strings of the chat in one language.
+type phrases struct {
+	welcome     []string
+	suggestions []string // general follow-up questions
+	opening     string   // follow-up questions by game phase
+	middlegame  string
+	endgame     string
+	// follow-up questions about position features (see PositionFeature), with
+	// %s for the square or the move
+	check          string
+	pin            string
+	hanging        string
+	winningCapture string
+	losingCapture  string
+	mistake        string
+}
+
+// catalog holds the canned strings by ISO 639-1 code. Languages without an entry
+// get English ones, while the LLM still replies in them.
+var catalog = map[string]phrases{
+	"en": {
+		welcome: []string{
+			"Hello! I'm your AI chess companion. Ready for a great game? 😊",
+			"Welcome to our chess match! I'm excited to play and chat with you. 🎯",
+			"Hi there! Let's have some fun with chess. Feel free to ask me anything about the game! ♟️",
+			"Greetings, chess friend! I'm here to play, chat, and maybe share some chess wisdom. 🤔",
+			"Hello! Ready to make some great moves? I love discussing chess strategy and tactics! ⚡",
+		},
+		suggestions: []string{
+			"What do you think about this position?",
+			"Any tips for improvement?",
+			"What's your favorite opening?",
+			"How would you rate my play so far?",
+		},
+		opening:        "Tell me about this opening",
+		middlegame:     "Any tactical opportunities here?",
+		endgame:        "How's my endgame technique?",
+		check:          "How do I get out of this check?",
+		pin:            "What about the pin on %s?",
+		hanging:        "Is the piece on %s in danger?",
+		winningCapture: "Can I win material with %s?",
+		losingCapture:  "Why does %s fail?",
+		mistake:        "What was wrong with %s?",
+	},
+	"es": {
+		welcome: []string{
+			"¡Hola! Soy tu compañero de ajedrez. ¿Listo para una gran partida? 😊",
+			"¡Bienvenido a nuestra partida! Me encanta jugar y charlar contigo. 🎯",
+			"¡Hola! Divirtámonos con el ajedrez. ¡Pregúntame lo que quieras sobre la partida! ♟️",
+		},
+		suggestions: []string{
+			"¿Qué opinas de esta posición?",
+			"¿Algún consejo para mejorar?",
+			"¿Cuál es tu apertura favorita?",
+			"¿Cómo valorarías mi juego hasta ahora?",
+		},
+		opening:        "Háblame de esta apertura",
+		middlegame:     "¿Hay alguna oportunidad táctica aquí?",
+		endgame:        "¿Qué tal mi técnica de finales?",
+		check:          "¿Cómo salgo de este jaque?",
+		pin:            "¿Qué hay de la clavada en %s?",
+		hanging:        "¿Corre peligro la pieza de %s?",
+		winningCapture: "¿Puedo ganar material con %s?",
+		losingCapture:  "¿Por qué falla %s?",
+		mistake:        "¿Qué tenía de malo %s?",
+	},
+	"fr": {
+		welcome: []string{
+			"Bonjour ! Je suis ton compagnon d'échecs. Prêt pour une belle partie ? 😊",
+			"Bienvenue dans notre partie ! J'ai hâte de jouer et de discuter avec toi. 🎯",
+			"Salut ! Amusons-nous aux échecs. N'hésite pas à me poser des questions sur la partie ! ♟️",
+		},
+		suggestions: []string{
+			"Que penses-tu de cette position ?",
+			"Des conseils pour progresser ?",
+			"Quelle est ton ouverture préférée ?",
+			"Comment évalues-tu mon jeu jusqu'ici ?",
+		},
+		opening:        "Parle-moi de cette ouverture",
+		middlegame:     "Y a-t-il une occasion tactique ici ?",
+		endgame:        "Que vaut ma technique de finale ?",
+		check:          "Comment sortir de cet échec ?",
+		pin:            "Que penser du clouage en %s ?",
+		hanging:        "La pièce en %s est-elle en danger ?",
+		winningCapture: "Puis-je gagner du matériel avec %s ?",
+		losingCapture:  "Pourquoi %s ne marche-t-il pas ?",
+		mistake:        "Qu'est-ce qui n'allait pas avec %s ?",
+	},
+	"de": {
+		welcome: []string{
+			"Hallo! Ich bin dein Schachbegleiter. Bereit für eine tolle Partie? 😊",
+			"Willkommen zu unserer Partie! Ich freue mich aufs Spielen und Plaudern. 🎯",
+			"Hi! Lass uns Spaß am Schach haben. Frag mich gern alles über die Partie! ♟️",
+		},
+		suggestions: []string{
+			"Was hältst du von dieser Stellung?",
+			"Hast du Tipps, wie ich besser werde?",
+			"Was ist deine Lieblingseröffnung?",
+			"Wie bewertest du mein bisheriges Spiel?",
+		},
+		opening:        "Erzähl mir etwas über diese Eröffnung",
+		middlegame:     "Gibt es hier taktische Möglichkeiten?",
+		endgame:        "Wie ist meine Endspieltechnik?",
+		check:          "Wie komme ich aus diesem Schach?",
+		pin:            "Was ist mit der Fesselung auf %s?",
+		hanging:        "Ist die Figur auf %s in Gefahr?",
+		winningCapture: "Gewinne ich mit %s Material?",
+		losingCapture:  "Warum scheitert %s?",
+		mistake:        "Was war falsch an %s?",
+	},
+	"it": {
+		welcome: []string{
+			"Ciao! Sono il tuo compagno di scacchi. Pronto per una bella partita? 😊",
+			"Benvenuto alla nostra partita! Non vedo l'ora di giocare e chiacchierare. 🎯",
+			"Ciao! Divertiamoci con gli scacchi. Chiedimi pure qualsiasi cosa sulla partita! ♟️",
+		},
+		suggestions: []string{
+			"Cosa pensi di questa posizione?",
+			"Qualche consiglio per migliorare?",
+			"Qual è la tua apertura preferita?",
+			"Come valuti il mio gioco finora?",
+		},
+		opening:        "Parlami di questa apertura",
+		middlegame:     "Ci sono opportunità tattiche qui?",
+		endgame:        "Com'è la mia tecnica nei finali?",
+		check:          "Come esco da questo scacco?",
+		pin:            "E l'inchiodatura in %s?",
+		hanging:        "Il pezzo in %s è in pericolo?",
+		winningCapture: "Posso guadagnare materiale con %s?",
+		losingCapture:  "Perché %s non funziona?",
+		mistake:        "Cosa c'era di sbagliato in %s?",
+	},
+	"pt": {
+		welcome: []string{
+			"Olá! Sou o teu companheiro de xadrez. Pronto para uma grande partida? 😊",
+			"Bem-vindo à nossa partida! Estou animado para jogar e conversar. 🎯",
+			"Oi! Vamos nos divertir com o xadrez. Pergunte-me o que quiser sobre a partida! ♟️",
+		},
+		suggestions: []string{
+			"O que acha desta posição?",
+			"Alguma dica para melhorar?",
+			"Qual é a sua abertura favorita?",
+			"Como avalia o meu jogo até agora?",
+		},
+		opening:        "Fale-me sobre esta abertura",
+		middlegame:     "Há alguma oportunidade tática aqui?",
+		endgame:        "Como está a minha técnica de finais?",
+		check:          "Como saio deste xeque?",
+		pin:            "E a cravada em %s?",
+		hanging:        "A peça em %s está em perigo?",
+		winningCapture: "Posso ganhar material com %s?",
+		losingCapture:  "Por que %s não funciona?",
+		mistake:        "O que havia de errado com %s?",
+	},
+	"ru": {
+		welcome: []string{
+			"Привет! Я твой шахматный компаньон. Готов к отличной партии? 😊",
+			"Добро пожаловать на нашу партию! Буду рад сыграть и поболтать. 🎯",
+			"Привет! Давай повеселимся за доской. Спрашивай о партии что угодно! ♟️",
+		},
+		suggestions: []string{
+			"Что ты думаешь об этой позиции?",
+			"Есть советы, как играть лучше?",
+			"Какой твой любимый дебют?",
+			"Как ты оцениваешь мою игру?",
+		},
+		opening:        "Расскажи мне об этом дебюте",
+		middlegame:     "Есть ли здесь тактические возможности?",
+		endgame:        "Как моя техника эндшпиля?",
+		check:          "Как уйти от этого шаха?",
+		pin:            "Что насчёт связки на %s?",
+		hanging:        "Фигура на %s в опасности?",
+		winningCapture: "Выигрывает ли %s материал?",
+		losingCapture:  "Почему %s не проходит?",
+		mistake:        "Чем плох ход %s?",
+	},
+	"bg": {
+		welcome: []string{
+			"Здравей! Аз съм твоят шахматен партньор. Готов ли си за страхотна партия? 😊",
+			"Добре дошъл в нашата партия! Радвам се да играем и да си поговорим. 🎯",
+			"Здрасти! Нека се забавляваме с шах. Питай ме каквото искаш за партията! ♟️",
+		},
+		suggestions: []string{
+			"Какво мислиш за тази позиция?",
+			"Имаш ли съвети как да играя по-добре?",
+			"Кое е любимото ти откриване?",
+			"Как оценяваш играта ми досега?",
+		},
+		opening:        "Разкажи ми за това откриване",
+		middlegame:     "Има ли тактически възможности тук?",
+		endgame:        "Как е техниката ми в ендшпила?",
+		check:          "Как да изляза от този шах?",
+		pin:            "Какво ще кажеш за свързването на %s?",
+		hanging:        "В опасност ли е фигурата на %s?",
+		winningCapture: "Печели ли %s материал?",
+		losingCapture:  "Защо %s не минава?",
+		mistake:        "Какво беше лошото на %s?",
+	},
+}
+
+// phrasesFor returns the canned strings for language, English if it has none.
+func phrasesFor(language string) phrases {
+	if p, ok := catalog[ai.LanguageCode(language)]; ok {
+		return p
+	}
+	return catalog["en"]
+}
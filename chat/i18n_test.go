@@ -0,0 +1,73 @@
+package chat
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+type promptRecorder struct{ prompts []string }
+
+func (p *promptRecorder) Ask(_ context.Context, prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	return "¡Buena jugada!", nil
+}
+
+func TestChatService_Language(t *testing.T) {
+	svc := newTestService(t)
+	recorder := &promptRecorder{}
+	svc.SetChatbotForTesting(recorder)
+
+	resp, err := svc.Chat(context.Background(), ChatRequest{GameID: 3, Message: "Hola", Language: "es", MoveData: &MoveContext{MoveCount: 2}})
+	if err != nil {
+		t.Fatalf("Chat error: %v", err)
+	}
+	if !strings.HasSuffix(recorder.prompts[0], "Reply in Spanish, keeping moves in standard algebraic notation.") {
+		t.Errorf("expected the prompt to ask for Spanish:\n%s", recorder.prompts[0])
+	}
+	if !slices.Contains(catalog["es"].welcome, svc.GetConversationHistory(3)[0].Content) {
+		t.Errorf("expected a Spanish welcome, got %q", svc.GetConversationHistory(3)[0].Content)
+	}
+	if len(resp.Suggestions) == 0 || !slices.Contains(catalog["es"].suggestions, resp.Suggestions[0]) {
+		t.Errorf("expected Spanish suggestions, got %v", resp.Suggestions)
+	}
+
+	// Reactions keep the conversation's language
+	g := engine.NewGame()
+	mv, _ := g.ParseMove("e2e4")
+	if err := g.MakeMove(mv); err != nil {
+		t.Fatalf("apply move: %v", err)
+	}
+	if _, err := svc.ReactToMove(context.Background(), 3, mv.String(), g, "", ""); err != nil {
+		t.Fatalf("ReactToMove error: %v", err)
+	}
+	if !strings.Contains(recorder.prompts[1], "Spanish") {
+		t.Errorf("expected the reaction prompt to ask for Spanish:\n%s", recorder.prompts[1])
+	}
+
+	svc.SetLanguage(3, "")
+	if _, err := svc.ReactToMove(context.Background(), 3, mv.String(), g, "", ""); err != nil {
+		t.Fatalf("ReactToMove error: %v", err)
+	}
+	if strings.Contains(recorder.prompts[2], "Reply in") {
+		t.Errorf("expected an English reaction prompt:\n%s", recorder.prompts[2])
+	}
+}
+
+func TestPhrasesFor(t *testing.T) {
+	if phrasesFor("de-AT").opening != catalog["de"].opening {
+		t.Error("expected German phrases for de-AT")
+	}
+	// Languages without canned strings fall back to English
+	if phrasesFor("ja").opening != catalog["en"].opening || phrasesFor("").opening != catalog["en"].opening {
+		t.Error("expected English phrases as the fallback")
+	}
+	for code, p := range catalog {
+		if len(p.welcome) == 0 || len(p.suggestions) < 3 || p.opening == "" || p.middlegame == "" || p.endgame == "" {
+			t.Errorf("incomplete phrases for %s", code)
+		}
+	}
+}
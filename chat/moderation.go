@@ -0,0 +1,173 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.uber.org/zap"
+)
+
+// moderationTimeout bounds a call to the moderation endpoint.
+const moderationTimeout = 5 * time.Second
+
+// ModerationOptions configures a Moderator.
+type ModerationOptions struct {
+	BlockedWords []string // responses containing one are replaced as a whole
+	MaskedWords  []string // starred out of responses
+	Endpoint     string   // OpenAI-compatible moderation endpoint, optional
+	APIKey       string   // bearer token for the endpoint
+	Replacement  string   // shown instead of a blocked response
+}
+
+// Moderator screens LLM output before it reaches players. Words match whole and
+// case-insensitively. A response the endpoint flags is blocked; if the endpoint
+// fails the response passes on the word lists alone.
+type Moderator struct {
+	blocked     []*regexp.Regexp
+	masked      []*regexp.Regexp
+	endpoint    string
+	apiKey      string
+	replacement string
+	httpClient  *http.Client
+	logger      *zap.Logger
+}
+
+// ModerationResult is the outcome of screening a response.
+type ModerationResult struct {
+	Text    string   // the response to show
+	Blocked bool     // Text is the replacement message
+	Masked  bool     // words were starred out of Text
+	Reasons []string // the matched words and flagged categories
+}
+
+// Filtered reports whether the response was changed.
+func (r ModerationResult) Filtered() bool { return r.Blocked || r.Masked }
+
+// NewModerator creates a moderator; it logs filtered content to logger.
+func NewModerator(opts ModerationOptions, logger *zap.Logger) *Moderator {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Moderator{
+		blocked:     wordPatterns(opts.BlockedWords),
+		masked:      wordPatterns(opts.MaskedWords),
+		endpoint:    opts.Endpoint,
+		apiKey:      opts.APIKey,
+		replacement: opts.Replacement,
+		httpClient:  &http.Client{Timeout: moderationTimeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		logger:      logger,
+	}
+}
+
+// wordPatterns compiles whole-word, case-insensitive patterns for words.
+func wordPatterns(words []string) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			patterns = append(patterns, regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(word)+`\b`))
+		}
+	}
+	return patterns
+}
+
+// Moderate screens text, logging it with kind (e.g. "chat" or "reaction") and
+// fields when it is filtered. A nil Moderator passes everything.
+func (m *Moderator) Moderate(ctx context.Context, kind, text string, fields ...zap.Field) ModerationResult {
+	result := ModerationResult{Text: text}
+	if m == nil || strings.TrimSpace(text) == "" {
+		return result
+	}
+
+	for _, pattern := range m.blocked {
+		if match := pattern.FindString(text); match != "" {
+			result.Reasons = append(result.Reasons, strings.ToLower(match))
+		}
+	}
+	if len(result.Reasons) == 0 && m.endpoint != "" {
+		categories, err := m.flaggedCategories(ctx, text)
+		if err != nil {
+			m.logger.Warn("Moderation endpoint failed, using the word lists only", zap.String("kind", kind), zap.Error(err))
+		}
+		result.Reasons = categories
+	}
+	if len(result.Reasons) > 0 {
+		result.Text, result.Blocked = m.replacement, true
+	} else {
+		for _, pattern := range m.masked {
+			masked := pattern.ReplaceAllStringFunc(result.Text, func(word string) string {
+				result.Reasons = append(result.Reasons, strings.ToLower(word))
+				return strings.Repeat("*", len([]rune(word)))
+			})
+			result.Text = masked
+		}
+		result.Masked = len(result.Reasons) > 0
+	}
+
+	if result.Filtered() {
+		m.logger.Warn("Filtered LLM output", append(fields,
+			zap.String("kind", kind),
+			zap.Bool("blocked", result.Blocked),
+			zap.Strings("reasons", result.Reasons),
+			zap.String("original", text))...)
+	}
+	return result
+}
+
+// flaggedCategories asks the moderation endpoint about text and returns the
+// categories it was flagged for, if any.
+func (m *Moderator) flaggedCategories(ctx context.Context, text string) ([]string, error) {
+	body, err := json.Marshal(map[string]string{"input": text})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation endpoint returned status %d", resp.StatusCode)
+	}
+
+	var moderation struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&moderation); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	var categories []string
+	for _, result := range moderation.Results {
+		if !result.Flagged {
+			continue
+		}
+		for category, flagged := range result.Categories {
+			if flagged {
+				categories = append(categories, category)
+			}
+		}
+		if len(categories) == 0 {
+			categories = append(categories, "flagged")
+		}
+	}
+	sort.Strings(categories)
+	return categories, nil
+}
@@ -0,0 +1,98 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestModeratorWordLists(t *testing.T) {
+	m := NewModerator(ModerationOptions{
+		BlockedWords: []string{"idiot"},
+		MaskedWords:  []string{"damn", " "},
+		Replacement:  "Let's talk chess.",
+	}, nil)
+
+	tests := []struct {
+		name, text, want string
+		blocked, masked  bool
+	}{
+		{"clean", "Nice fork on e5!", "Nice fork on e5!", false, false},
+		{"masked", "Damn, that fork hurts.", "****, that fork hurts.", false, true},
+		{"blocked", "Only an IDIOT plays f3.", "Let's talk chess.", true, false},
+		{"whole words only", "Idiotic? No, idiosyncratic.", "Idiotic? No, idiosyncratic.", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := m.Moderate(context.Background(), "chat", tt.text)
+			if result.Text != tt.want || result.Blocked != tt.blocked || result.Masked != tt.masked {
+				t.Errorf("Moderate(%q) = %+v, want %q blocked=%v masked=%v", tt.text, result, tt.want, tt.blocked, tt.masked)
+			}
+		})
+	}
+
+	var nilModerator *Moderator
+	if result := nilModerator.Moderate(context.Background(), "chat", "damn"); result.Text != "damn" || result.Filtered() {
+		t.Errorf("expected a nil moderator to pass everything, got %+v", result)
+	}
+}
+
+func TestModeratorEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct{ Input string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		flagged := strings.Contains(body.Input, "threat")
+		_ = json.NewEncoder(w).Encode(map[string]any{"results": []map[string]any{{
+			"flagged":    flagged,
+			"categories": map[string]bool{"violence": flagged, "hate": false},
+		}}})
+	}))
+	defer server.Close()
+
+	m := NewModerator(ModerationOptions{Endpoint: server.URL, APIKey: "key", Replacement: "Let's talk chess."}, nil)
+	if result := m.Moderate(context.Background(), "chat", "A quiet move."); result.Filtered() {
+		t.Errorf("expected clean text to pass, got %+v", result)
+	}
+	result := m.Moderate(context.Background(), "chat", "A threat to the king.")
+	if !result.Blocked || len(result.Reasons) != 1 || result.Reasons[0] != "violence" {
+		t.Errorf("expected the flagged text to be blocked for violence, got %+v", result)
+	}
+
+	// A failing endpoint lets the response through
+	m = NewModerator(ModerationOptions{Endpoint: server.URL, Replacement: "Let's talk chess."}, nil)
+	if result := m.Moderate(context.Background(), "chat", "A threat to the king."); result.Filtered() {
+		t.Errorf("expected the response to pass when the endpoint fails, got %+v", result)
+	}
+}
+
+func TestChatService_ModeratesResponses(t *testing.T) {
+	svc := newTestService(t)
+	svc.SetChatbotForTesting(&mockChatbot{reply: "Damn, what a move!"})
+	svc.SetModerator(NewModerator(ModerationOptions{MaskedWords: []string{"damn"}}, nil))
+
+	g := engine.NewGame()
+	mv, _ := g.ParseMove("e2e4")
+	if err := g.MakeMove(mv); err != nil {
+		t.Fatalf("apply move: %v", err)
+	}
+	resp, err := svc.ReactToMove(context.Background(), 7, mv.String(), g, "", "")
+	if err != nil {
+		t.Fatalf("ReactToMove error: %v", err)
+	}
+	if resp.Message != "****, what a move!" {
+		t.Errorf("expected the reaction to be masked, got %q", resp.Message)
+	}
+	history := svc.GetConversationHistory(7)
+	if last := history[len(history)-1]; last.Content != resp.Message {
+		t.Errorf("expected the moderated reaction in the history, got %q", last.Content)
+	}
+}
@@ -0,0 +1,53 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+func TestChatService_Personality(t *testing.T) {
+	svc := newTestService(t)
+	recorder := &promptRecorder{}
+	svc.SetChatbotForTesting(recorder)
+	grumpy, _ := ai.LookupPersonality("grumpy-grandmaster")
+
+	resp, err := svc.Chat(context.Background(), ChatRequest{GameID: 4, Message: "Hi", Personality: "Grumpy Grandmaster"})
+	if err != nil {
+		t.Fatalf("Chat error: %v", err)
+	}
+	if resp.Personality != grumpy.Name || !strings.Contains(recorder.prompts[0], "Stay in character as "+grumpy.Prompt) {
+		t.Errorf("expected the grumpy grandmaster, got %q:\n%s", resp.Personality, recorder.prompts[0])
+	}
+
+	g := engine.NewGame()
+	mv, _ := g.ParseMove("e2e4")
+	if err := g.MakeMove(mv); err != nil {
+		t.Fatalf("apply move: %v", err)
+	}
+
+	// The silent assassin rarely reacts, and never asks the LLM when it does not
+	svc.SetPersonality(4, "silent-assassin")
+	silent := 0
+	for i := 0; i < 50; i++ {
+		resp, err := svc.ReactToMove(context.Background(), 4, mv.String(), g, "", "")
+		if err != nil {
+			t.Fatalf("ReactToMove error: %v", err)
+		}
+		if resp.Message == "" {
+			silent++
+		}
+	}
+	if silent < 30 || len(recorder.prompts) != 1+50-silent {
+		t.Errorf("expected mostly silence, got %d silent reactions and %d prompts", silent, len(recorder.prompts))
+	}
+
+	svc.SetPersonality(4, "")
+	resp, err = svc.ReactToMove(context.Background(), 4, mv.String(), g, "", "")
+	if err != nil || resp.Message == "" || resp.Personality != "observant_chess_coach" {
+		t.Errorf("expected the default coach to react, got %+v %v", resp, err)
+	}
+}
@@ -0,0 +1,196 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limits bounds chat use per user and per game; zero fields are unlimited.
+// Tokens are estimated from the length of prompts and replies, about four
+// characters each, and counted per UTC day.
+type Limits struct {
+	UserMessagesPerMinute int
+	GameMessagesPerMinute int
+	UserTokensPerDay      int
+	GameTokensPerDay      int
+}
+
+// ErrRateLimited is wrapped by the errors of requests refused by the limits.
+var ErrRateLimited = errors.New("chat rate limit exceeded")
+
+// RateLimitError reports the limit that refused a request.
+type RateLimitError struct {
+	Scope      string        // "user" or "game"
+	Limit      string        // "messages_per_minute" or "tokens_per_day"
+	RetryAfter time.Duration // until the limit allows another request
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %s %s, retry in %s", ErrRateLimited, e.Scope, e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Unwrap() error { return ErrRateLimited }
+
+// tokenUsage counts the tokens of a user or game on one day.
+type tokenUsage struct {
+	day    string
+	tokens int
+}
+
+// rateLimiter enforces Limits on the chat service. Counts are kept in memory,
+// per server, and those of users and games idle for a minute, or since the
+// previous day, are dropped every minute.
+type rateLimiter struct {
+	mu       sync.Mutex
+	limits   Limits
+	now      func() time.Time
+	messages map[string][]time.Time // "user:<id>" or "game:<id>" -> send times within the last minute
+	tokens   map[string]*tokenUsage
+	swept    time.Time // when idle counts were last dropped
+}
+
+func newRateLimiter(limits Limits) *rateLimiter {
+	return &rateLimiter{
+		limits:   limits,
+		now:      time.Now,
+		messages: make(map[string][]time.Time),
+		tokens:   make(map[string]*tokenUsage),
+	}
+}
+
+// limitKey is a user's or a game's counter with its limits.
+type limitKey struct {
+	scope, key        string
+	messagesPerMinute int
+	tokensPerDay      int
+}
+
+// keys returns the counters of a game and, unless userID is empty, a user.
+func (l *rateLimiter) keys(userID string, gameID int) []limitKey {
+	keys := []limitKey{{"game", "game:" + strconv.Itoa(gameID), l.limits.GameMessagesPerMinute, l.limits.GameTokensPerDay}}
+	if userID != "" {
+		keys = append(keys, limitKey{"user", "user:" + userID, l.limits.UserMessagesPerMinute, l.limits.UserTokensPerDay})
+	}
+	return keys
+}
+
+// allowMessage records a chat message of a user in a game, or returns a
+// *RateLimitError if a limit refuses it. A nil rateLimiter allows everything.
+func (l *rateLimiter) allowMessage(userID string, gameID int) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+	keys := l.keys(userID, gameID)
+	for _, k := range keys {
+		if err := l.checkTokens(k, now); err != nil {
+			return err
+		}
+		if k.messagesPerMinute <= 0 {
+			continue
+		}
+		recent := l.recentMessages(k.key, now)
+		if len(recent) >= k.messagesPerMinute {
+			return &RateLimitError{Scope: k.scope, Limit: "messages_per_minute", RetryAfter: recent[0].Add(time.Minute).Sub(now)}
+		}
+	}
+	for _, k := range keys {
+		if k.messagesPerMinute > 0 {
+			l.messages[k.key] = append(l.recentMessages(k.key, now), now)
+		}
+	}
+	return nil
+}
+
+// allowTokens returns a *RateLimitError if a game's token quota is spent.
+func (l *rateLimiter) allowTokens(gameID int) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.checkTokens(l.keys("", gameID)[0], l.now())
+}
+
+// spend counts tokens used for a user, if any, and a game.
+func (l *rateLimiter) spend(userID string, gameID int, tokens int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	day := l.now().UTC().Format(time.DateOnly)
+	for _, k := range l.keys(userID, gameID) {
+		if k.tokensPerDay <= 0 {
+			continue
+		}
+		usage := l.tokens[k.key]
+		if usage == nil || usage.day != day {
+			usage = &tokenUsage{day: day}
+			l.tokens[k.key] = usage
+		}
+		usage.tokens += tokens
+	}
+}
+
+// checkTokens returns a *RateLimitError if the key's quota for today is spent.
+func (l *rateLimiter) checkTokens(k limitKey, now time.Time) error {
+	usage := l.tokens[k.key]
+	if k.tokensPerDay <= 0 || usage == nil || usage.day != now.UTC().Format(time.DateOnly) || usage.tokens < k.tokensPerDay {
+		return nil
+	}
+	tomorrow := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	return &RateLimitError{Scope: k.scope, Limit: "tokens_per_day", RetryAfter: tomorrow.Sub(now)}
+}
+
+// sweep drops the counts of keys without messages in the last minute and the
+// token usage of past days, at most once a minute.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	for key := range l.messages {
+		l.recentMessages(key, now)
+	}
+	today := now.UTC().Format(time.DateOnly)
+	for key, usage := range l.tokens {
+		if usage.day != today {
+			delete(l.tokens, key)
+		}
+	}
+}
+
+// recentMessages returns the key's send times within the last minute.
+func (l *rateLimiter) recentMessages(key string, now time.Time) []time.Time {
+	times := l.messages[key]
+	for len(times) > 0 && !times[0].After(now.Add(-time.Minute)) {
+		times = times[1:]
+	}
+	if len(times) == 0 {
+		delete(l.messages, key)
+	}
+	return times
+}
+
+// estimateTokens approximates the tokens of text, about four characters each.
+func estimateTokens(text string) int {
+	return (len([]rune(text)) + 3) / 4
+}
+
+// SetLimits bounds chat use per user and per game; the zero Limits removes all
+// limits. Chat returns a *RateLimitError when a limit refuses a
+// message, and ReactToMove when a game's token quota is spent.
+func (cs *ChatService) SetLimits(limits Limits) {
+	if limits == (Limits{}) {
+		cs.limiter = nil
+		return
+	}
+	cs.limiter = newRateLimiter(limits)
+}
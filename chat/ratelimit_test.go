@@ -0,0 +1,110 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestRateLimiter_MessagesPerMinute(t *testing.T) {
+	limiter := newRateLimiter(Limits{UserMessagesPerMinute: 2, GameMessagesPerMinute: 3})
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	for _, userID := range []string{"alice", "alice"} {
+		if err := limiter.allowMessage(userID, 1); err != nil {
+			t.Fatalf("expected %s's message allowed: %v", userID, err)
+		}
+		now = now.Add(10 * time.Second)
+	}
+	var limited *RateLimitError
+	if err := limiter.allowMessage("alice", 2); !errors.As(err, &limited) || limited.Scope != "user" || limited.RetryAfter != 40*time.Second {
+		t.Fatalf("expected alice limited for 40s, got %v", err)
+	}
+	if err := limiter.allowMessage("bob", 1); err != nil {
+		t.Fatalf("expected bob's message allowed: %v", err)
+	}
+	if err := limiter.allowMessage("carol", 1); !errors.As(err, &limited) || limited.Scope != "game" {
+		t.Fatalf("expected game 1 limited, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := limiter.allowMessage("alice", 1); err != nil {
+		t.Errorf("expected the limits to reset after a minute: %v", err)
+	}
+}
+
+func TestRateLimiter_TokensPerDay(t *testing.T) {
+	limiter := newRateLimiter(Limits{UserTokensPerDay: 100, GameTokensPerDay: 1000})
+	now := time.Date(2026, 10, 15, 18, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	limiter.spend("alice", 1, 100)
+	var limited *RateLimitError
+	if err := limiter.allowMessage("alice", 2); !errors.As(err, &limited) || limited.Limit != "tokens_per_day" || limited.RetryAfter != 6*time.Hour {
+		t.Fatalf("expected alice out of tokens until midnight, got %v", err)
+	}
+	if err := limiter.allowTokens(1); err != nil {
+		t.Fatalf("expected game 1 within its quota: %v", err)
+	}
+	limiter.spend("", 1, 900)
+	if err := limiter.allowTokens(1); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected game 1 out of tokens, got %v", err)
+	}
+
+	now = now.Add(6 * time.Hour)
+	if err := limiter.allowMessage("alice", 1); err != nil {
+		t.Errorf("expected the quotas to reset the next day: %v", err)
+	}
+}
+
+func TestChatService_Limits(t *testing.T) {
+	svc := newTestService(t)
+	svc.SetChatbotForTesting(&mockChatbot{reply: "Nice move!"})
+	svc.SetLimits(Limits{UserMessagesPerMinute: 1, GameTokensPerDay: 1})
+
+	if _, err := svc.Chat(context.Background(), ChatRequest{GameID: 11, UserID: "alice", Message: "Hi"}); err != nil {
+		t.Fatalf("Chat error: %v", err)
+	}
+	accepted := false
+	_, err := svc.Chat(context.Background(), ChatRequest{GameID: 12, UserID: "alice", Message: "Again", OnAccepted: func(Message) { accepted = true }})
+	if !errors.Is(err, ErrRateLimited) || accepted {
+		t.Fatalf("expected the second message refused before it is accepted, got %v", err)
+	}
+	if history := svc.GetConversationHistory(12); len(history) > 1 {
+		t.Errorf("expected the refused message kept out of the conversation, got %+v", history)
+	}
+
+	// The chat spent game 11's token quota, so reactions are refused too
+	g := engine.NewGame()
+	mv, _ := g.ParseMove("e2e4")
+	if err := g.MakeMove(mv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ReactToMove(context.Background(), 11, mv.String(), g, "", ""); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected the reaction refused, got %v", err)
+	}
+}
+
+func TestRateLimiter_DropsIdleCounts(t *testing.T) {
+	limiter := newRateLimiter(Limits{UserMessagesPerMinute: 5, UserTokensPerDay: 100})
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	for _, userID := range []string{"alice", "bob", "carol"} {
+		if err := limiter.allowMessage(userID, 1); err != nil {
+			t.Fatal(err)
+		}
+		limiter.spend(userID, 1, 10)
+	}
+
+	now = now.Add(24 * time.Hour)
+	if err := limiter.allowMessage("dave", 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(limiter.messages) != 1 || len(limiter.tokens) != 0 {
+		t.Errorf("expected only dave's count kept, got %d message and %d token counts", len(limiter.messages), len(limiter.tokens))
+	}
+}
@@ -0,0 +1,244 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LobbyRoom is the ID of the room every Rooms starts with.
+const LobbyRoom = "lobby"
+
+// Limits of chat rooms.
+const (
+	MaxRoomMessageLength = 1000 // characters of a room message
+	MaxRoomNameLength    = 64   // characters of a room name
+	MaxRooms             = 1000 // rooms a Rooms holds, the lobby included
+	roomHistorySize      = 200  // messages a room keeps; older ones are dropped
+)
+
+// Errors of chat rooms.
+var (
+	ErrRoomNotFound   = errors.New("room not found")
+	ErrRoomExists     = errors.New("room already exists")
+	ErrInvalidRoomID  = errors.New("room IDs are 1 to 32 lowercase letters, digits, '-' or '_'")
+	ErrNotMember      = errors.New("not a member of the room")
+	ErrEmptyMessage   = errors.New("message is required")
+	ErrMessageTooLong = fmt.Errorf("messages are at most %d characters", MaxRoomMessageLength)
+	ErrNameTooLong    = fmt.Errorf("room names are at most %d characters", MaxRoomNameLength)
+	ErrTooManyRooms   = fmt.Errorf("no more than %d rooms can be created", MaxRooms)
+)
+
+// roomIDPattern matches valid room IDs, which appear in URLs.
+var roomIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Room is a chat room shared by players across games, such as the lobby.
+type Room struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by,omitempty"` // empty for the lobby
+	CreatedAt time.Time `json:"created_at"`
+	Members   []string  `json:"members"` // user IDs, sorted
+}
+
+// RoomMessage is a message posted to a room.
+type RoomMessage struct {
+	ID        string    `json:"id"`
+	RoomID    string    `json:"room_id"`
+	UserID    string    `json:"user_id"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// room is the state of a Room.
+type room struct {
+	Room
+	members  map[string]struct{}
+	messages []RoomMessage // the latest roomHistorySize
+	nextID   int
+}
+
+// snapshot returns a copy of the room with its members.
+func (r *room) snapshot() Room {
+	snapshot := r.Room
+	snapshot.Members = make([]string, 0, len(r.members))
+	for member := range r.members {
+		snapshot.Members = append(snapshot.Members, member)
+	}
+	sort.Strings(snapshot.Members)
+	return snapshot
+}
+
+// Rooms holds chat rooms, apart from the conversations of games. Rooms are
+// kept in memory, per server, and safe for concurrent use.
+type Rooms struct {
+	mu      sync.Mutex
+	rooms   map[string]*room
+	limiter *rateLimiter // nil when posting is unlimited
+	now     func() time.Time
+}
+
+// NewRooms returns Rooms with the lobby.
+func NewRooms() *Rooms {
+	rs := &Rooms{rooms: make(map[string]*room), now: time.Now}
+	rs.rooms[LobbyRoom] = rs.newRoom(LobbyRoom, "Lobby", "")
+	return rs
+}
+
+// SetLimits bounds the messages a user posts per minute, to all rooms together,
+// to the UserMessagesPerMinute of limits; zero is unlimited. Post returns a
+// *RateLimitError when the limit refuses a message.
+func (rs *Rooms) SetLimits(limits Limits) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.limiter = nil
+	if limits.UserMessagesPerMinute > 0 {
+		rs.limiter = newRateLimiter(Limits{UserMessagesPerMinute: limits.UserMessagesPerMinute})
+	}
+}
+
+func (rs *Rooms) newRoom(id, name, createdBy string) *room {
+	return &room{
+		Room:    Room{ID: id, Name: name, CreatedBy: createdBy, CreatedAt: rs.now()},
+		members: make(map[string]struct{}),
+	}
+}
+
+// Create creates a room, named id if name is empty, with userID as its first
+// member.
+func (rs *Rooms) Create(id, name, userID string) (Room, error) {
+	if !roomIDPattern.MatchString(id) {
+		return Room{}, ErrInvalidRoomID
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = id
+	}
+	if len([]rune(name)) > MaxRoomNameLength {
+		return Room{}, ErrNameTooLong
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if _, exists := rs.rooms[id]; exists {
+		return Room{}, ErrRoomExists
+	}
+	if len(rs.rooms) >= MaxRooms {
+		return Room{}, ErrTooManyRooms
+	}
+	r := rs.newRoom(id, name, userID)
+	r.members[userID] = struct{}{}
+	rs.rooms[id] = r
+	return r.snapshot(), nil
+}
+
+// List returns the rooms, the lobby first and the rest by ID.
+func (rs *Rooms) List() []Room {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rooms := make([]Room, 0, len(rs.rooms))
+	for _, r := range rs.rooms {
+		rooms = append(rooms, r.snapshot())
+	}
+	sort.Slice(rooms, func(i, j int) bool {
+		if (rooms[i].ID == LobbyRoom) != (rooms[j].ID == LobbyRoom) {
+			return rooms[i].ID == LobbyRoom
+		}
+		return rooms[i].ID < rooms[j].ID
+	})
+	return rooms
+}
+
+// Get returns a room.
+func (rs *Rooms) Get(id string) (Room, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r, ok := rs.rooms[id]
+	if !ok {
+		return Room{}, ErrRoomNotFound
+	}
+	return r.snapshot(), nil
+}
+
+// Join adds userID to a room's members; joining twice is harmless.
+func (rs *Rooms) Join(id, userID string) (Room, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r, ok := rs.rooms[id]
+	if !ok {
+		return Room{}, ErrRoomNotFound
+	}
+	r.members[userID] = struct{}{}
+	return r.snapshot(), nil
+}
+
+// Leave removes userID from a room's members. The room stays when it empties.
+func (rs *Rooms) Leave(id, userID string) (Room, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r, ok := rs.rooms[id]
+	if !ok {
+		return Room{}, ErrRoomNotFound
+	}
+	if _, member := r.members[userID]; !member {
+		return Room{}, ErrNotMember
+	}
+	delete(r.members, userID)
+	return r.snapshot(), nil
+}
+
+// Post adds a message of a member to a room and returns it.
+func (rs *Rooms) Post(id, userID, content string) (RoomMessage, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return RoomMessage{}, ErrEmptyMessage
+	}
+	if len([]rune(content)) > MaxRoomMessageLength {
+		return RoomMessage{}, ErrMessageTooLong
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r, ok := rs.rooms[id]
+	if !ok {
+		return RoomMessage{}, ErrRoomNotFound
+	}
+	if _, member := r.members[userID]; !member {
+		return RoomMessage{}, ErrNotMember
+	}
+	// Rooms have no game; the limiter counts the user alone
+	if err := rs.limiter.allowMessage(userID, 0); err != nil {
+		return RoomMessage{}, err
+	}
+	r.nextID++
+	msg := RoomMessage{
+		ID:        fmt.Sprintf("%s_%d", id, r.nextID),
+		RoomID:    id,
+		UserID:    userID,
+		Content:   content,
+		Timestamp: rs.now(),
+	}
+	r.messages = append(r.messages, msg)
+	if len(r.messages) > roomHistorySize {
+		r.messages = r.messages[len(r.messages)-roomHistorySize:]
+	}
+	return msg, nil
+}
+
+// History returns the latest limit messages of a room, oldest first; a
+// non-positive limit returns all it keeps.
+func (rs *Rooms) History(id string, limit int) ([]RoomMessage, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	r, ok := rs.rooms[id]
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+	messages := r.messages
+	if limit > 0 && len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return append([]RoomMessage(nil), messages...), nil
+}
@@ -0,0 +1,99 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRooms_Membership(t *testing.T) {
+	rooms := NewRooms()
+	if list := rooms.List(); len(list) != 1 || list[0].ID != LobbyRoom {
+		t.Fatalf("expected only the lobby, got %+v", list)
+	}
+
+	if _, err := rooms.Create("Blitz Fans", "", "alice"); !errors.Is(err, ErrInvalidRoomID) {
+		t.Fatalf("expected an invalid room ID, got %v", err)
+	}
+	room, err := rooms.Create("blitz", "  Blitz fans ", "alice")
+	if err != nil || room.Name != "Blitz fans" || room.CreatedBy != "alice" || len(room.Members) != 1 {
+		t.Fatalf("expected alice's room, got %+v (%v)", room, err)
+	}
+	if _, err := rooms.Create("blitz", "", "bob"); !errors.Is(err, ErrRoomExists) {
+		t.Fatalf("expected the room to exist, got %v", err)
+	}
+	if list := rooms.List(); len(list) != 2 || list[0].ID != LobbyRoom || list[1].ID != "blitz" {
+		t.Fatalf("expected the lobby, then blitz, got %+v", list)
+	}
+
+	rooms.Join("blitz", "bob")
+	room, _ = rooms.Join("blitz", "bob")
+	if strings.Join(room.Members, ",") != "alice,bob" {
+		t.Fatalf("expected alice and bob once each, got %v", room.Members)
+	}
+	if _, err := rooms.Leave("blitz", "carol"); !errors.Is(err, ErrNotMember) {
+		t.Fatalf("expected carol not to be a member, got %v", err)
+	}
+	if room, _ = rooms.Leave("blitz", "alice"); strings.Join(room.Members, ",") != "bob" {
+		t.Fatalf("expected bob left, got %v", room.Members)
+	}
+	if _, err := rooms.Join("rapid", "bob"); !errors.Is(err, ErrRoomNotFound) {
+		t.Fatalf("expected no rapid room, got %v", err)
+	}
+}
+
+func TestRooms_Messages(t *testing.T) {
+	rooms := NewRooms()
+	if _, err := rooms.Post(LobbyRoom, "alice", "hi"); !errors.Is(err, ErrNotMember) {
+		t.Fatalf("expected non-members refused, got %v", err)
+	}
+	rooms.Join(LobbyRoom, "alice")
+	if _, err := rooms.Post(LobbyRoom, "alice", "   "); !errors.Is(err, ErrEmptyMessage) {
+		t.Fatalf("expected empty messages refused, got %v", err)
+	}
+	if _, err := rooms.Post(LobbyRoom, "alice", strings.Repeat("a", MaxRoomMessageLength+1)); !errors.Is(err, ErrMessageTooLong) {
+		t.Fatalf("expected long messages refused, got %v", err)
+	}
+
+	for i := 0; i < roomHistorySize+5; i++ {
+		if _, err := rooms.Post(LobbyRoom, "alice", " gg "); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, _ := rooms.History(LobbyRoom, 0)
+	if len(history) != roomHistorySize || history[0].ID != "lobby_6" || history[0].Content != "gg" {
+		t.Fatalf("expected the latest %d messages, from lobby_6, got %d from %+v", roomHistorySize, len(history), history[0])
+	}
+	if latest, _ := rooms.History(LobbyRoom, 2); len(latest) != 2 || latest[1].ID != history[len(history)-1].ID {
+		t.Fatalf("expected the latest two messages, got %+v", latest)
+	}
+}
+
+func TestRooms_Limits(t *testing.T) {
+	rooms := NewRooms()
+	if _, err := rooms.Create("long", strings.Repeat("n", MaxRoomNameLength+1), "alice"); !errors.Is(err, ErrNameTooLong) {
+		t.Fatalf("expected long names refused, got %v", err)
+	}
+	for i := len(rooms.List()); i < MaxRooms; i++ {
+		if _, err := rooms.Create(fmt.Sprint("room-", i), "", "alice"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := rooms.Create("one-more", "", "alice"); !errors.Is(err, ErrTooManyRooms) {
+		t.Fatalf("expected rooms capped at %d, got %v", MaxRooms, err)
+	}
+
+	rooms.SetLimits(Limits{UserMessagesPerMinute: 2, GameMessagesPerMinute: 1})
+	rooms.Join(LobbyRoom, "alice")
+	rooms.Join(LobbyRoom, "bob")
+	rooms.Post(LobbyRoom, "alice", "one")
+	rooms.Post("room-1", "alice", "two")
+	var limited *RateLimitError
+	if _, err := rooms.Post(LobbyRoom, "alice", "three"); !errors.As(err, &limited) || limited.Scope != "user" {
+		t.Fatalf("expected alice's third message in a minute refused, got %v", err)
+	}
+	if _, err := rooms.Post(LobbyRoom, "bob", "hi"); err != nil {
+		t.Fatalf("expected bob still allowed, got %v", err)
+	}
+}
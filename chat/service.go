@@ -4,20 +4,29 @@
 package chat
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"math/rand"
 	"os"
 	"strings"
-	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	gochatbot "go.rumenx.com/chatbot"
 	"go.rumenx.com/chatbot/config"
 	"go.rumenx.com/chatbot/models"
+	"go.rumenx.com/chess/ai"
 	"go.rumenx.com/chess/engine"
 	"go.uber.org/zap"
 )
 
+// tracer records the package's spans with the global tracer provider.
+var tracer = otel.Tracer("go.rumenx.com/chess/chat")
+
 // ChatbotClient is a minimal interface the underlying chatbot must satisfy.
 type ChatbotClient interface {
 	Ask(ctx context.Context, prompt string) (string, error)
@@ -32,26 +41,38 @@ func (a *chatbotAdapter) Ask(ctx context.Context, prompt string) (string, error)
 
 // ChatService represents the chess AI chatbot service.
 type ChatService struct {
-	chatbot       ChatbotClient
-	config        *config.Config
-	logger        *zap.Logger
-	conversations map[int]*Conversation // gameID -> conversation
-	mu            sync.RWMutex
+	chatbot          ChatbotClient
+	config           *config.Config
+	logger           *zap.Logger
+	conversations    ConversationStore // gameID -> conversation
+	limiter          *rateLimiter      // nil when chat is unlimited
+	historyBudget    int               // characters of history in prompts, see SetHistoryBudget
+	moderator        *Moderator
+	inputFilter      *inputFilter          // screens players' messages, or nil
+	moderationEvents func(ModerationEvent) // see SetModerationEventHandler, or nil
+	exchangeLog      ai.LLMLogger          // logs prompts and responses, or nil
+	cache            *ai.LLMCache          // reactions by position, see SetCache, or nil
 }
 
 // Conversation represents a chat conversation for a specific game.
 type Conversation struct {
-	GameID    int                    `json:"game_id"`
-	Messages  []Message              `json:"messages"`
-	Context   map[string]interface{} `json:"context"`
-	CreatedAt time.Time              `json:"created_at"`
-	UpdatedAt time.Time              `json:"updated_at"`
+	GameID      int                    `json:"game_id"`
+	Language    string                 `json:"language,omitempty"`    // of replies and canned strings, English if empty
+	Personality string                 `json:"personality,omitempty"` // preset the AI plays, the configured one if empty
+	Messages    []Message              `json:"messages"`
+	Summary     string                 `json:"summary,omitempty"`    // of the conversation's first Summarized messages
+	Summarized  int                    `json:"summarized,omitempty"` // messages the summary covers
+	Context     map[string]interface{} `json:"context"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+	Version     int                    `json:"version,omitempty"` // times saved, see ConversationStore
 }
 
 // Message represents a single chat message.
 type Message struct {
 	ID        string                 `json:"id"`
-	Type      string                 `json:"type"` // "user", "ai", "system"
+	Type      string                 `json:"type"`              // "user", "ai", "system"
+	UserID    string                 `json:"user_id,omitempty"` // who sent a user message
 	Content   string                 `json:"content"`
 	GameState map[string]interface{} `json:"game_state,omitempty"`
 	Timestamp time.Time              `json:"timestamp"`
@@ -59,34 +80,49 @@ type Message struct {
 
 // ChatRequest represents a request to the chat service.
 type ChatRequest struct {
-	GameID   int          `json:"game_id"`
-	Message  string       `json:"message"`
-	UserID   string       `json:"user_id,omitempty"`
-	MoveData *MoveContext `json:"move_data,omitempty"`
-	Provider string       `json:"provider,omitempty"` // Override default provider
-	APIKey   string       `json:"api_key,omitempty"`  // Custom API key for this request
+	GameID      int          `json:"game_id"`
+	Message     string       `json:"message"`
+	UserID      string       `json:"user_id,omitempty"`
+	MoveData    *MoveContext `json:"move_data,omitempty"`
+	Provider    string       `json:"provider,omitempty"`    // Override default provider
+	APIKey      string       `json:"api_key,omitempty"`     // Custom API key for this request
+	Model       string       `json:"model,omitempty"`       // Override the provider's model
+	Language    string       `json:"language,omitempty"`    // Reply language, e.g. "de" or "Spanish"
+	Personality string       `json:"personality,omitempty"` // Preset such as "grumpy-grandmaster", kept for the game
+	Mode        string       `json:"mode,omitempty"`        // ModeCoach for hints instead of answers
+	// LimitKey is who the per-user limits count the message against, such as
+	// the client's address for users who are not signed in; UserID if empty.
+	LimitKey string `json:"-"`
+	// OnAccepted, if set, is called with the message as stored, sanitized by
+	// the input filter if need be, once the filter and the limits have let it
+	// through and it is in the conversation, before the AI is asked.
+	OnAccepted func(Message) `json:"-"`
 }
 
 // ChatResponse represents a response from the chat service.
 type ChatResponse struct {
 	Message     string                 `json:"message"`
 	MessageID   string                 `json:"message_id"`
+	ReplyID     string                 `json:"reply_id,omitempty"` // ID of the AI's message
 	Personality string                 `json:"personality"`
 	GameContext map[string]interface{} `json:"game_context,omitempty"`
 	Suggestions []string               `json:"suggestions,omitempty"`
+	Filtered    []string               `json:"filtered,omitempty"` // why the input filter matched the player's message
 	Timestamp   time.Time              `json:"timestamp"`
 }
 
 // MoveContext provides context about recent moves for the AI.
 type MoveContext struct {
-	LastMove      string   `json:"last_move"`
-	MoveCount     int      `json:"move_count"`
-	CurrentPlayer string   `json:"current_player"`
-	GameStatus    string   `json:"game_status"`
-	Position      string   `json:"position"`                 // FEN notation
-	LegalMoves    []string `json:"legal_moves"`              // Available legal moves
-	InCheck       bool     `json:"in_check"`                 // Whether current player is in check
-	CapturedPiece string   `json:"captured_piece,omitempty"` // Last captured piece
+	LastMove      string            `json:"last_move"`
+	MoveCount     int               `json:"move_count"`
+	CurrentPlayer string            `json:"current_player"`
+	GameStatus    string            `json:"game_status"`
+	Position      string            `json:"position"`                 // FEN notation
+	LegalMoves    []string          `json:"legal_moves"`              // Available legal moves
+	InCheck       bool              `json:"in_check"`                 // Whether current player is in check
+	CapturedPiece string            `json:"captured_piece,omitempty"` // Last captured piece
+	CoachNotes    []string          `json:"coach_notes,omitempty"`    // What the engine sees, for coach mode (see CoachNotes)
+	Features      []PositionFeature `json:"features,omitempty"`       // What to suggest asking about (see PositionFeatures)
 }
 
 // NewChatService creates a new chat service instance.
@@ -155,7 +191,7 @@ Avoid:
 			},
 			Profanities:        []string{}, // Use default filter
 			AggressionPatterns: []string{}, // Use default filter
-			LinkPattern:        `https?://[\w\.-]+`,
+			LinkPattern:        `https?://\S+`,
 		},
 	}
 
@@ -190,15 +226,17 @@ Avoid:
 		chatbot:       &chatbotAdapter{base: chatbot},
 		config:        cfg,
 		logger:        logger,
-		conversations: make(map[int]*Conversation),
+		conversations: NewMemoryStore(),
+		historyBudget: DefaultHistoryBudget,
 	}
 
 	logger.Info("Chat service initialized", zap.String("model", cfg.Model))
 	return service, nil
 }
 
-// createCustomChatbot creates a chatbot instance with custom API key and provider.
-func (cs *ChatService) createCustomChatbot(provider, apiKey string) (ChatbotClient, error) {
+// createCustomChatbot creates a chatbot instance with custom API key and provider,
+// and model if not empty.
+func (cs *ChatService) createCustomChatbot(provider, apiKey, model string) (ChatbotClient, error) {
 	if provider == "" {
 		return nil, fmt.Errorf("provider is required")
 	}
@@ -238,13 +276,26 @@ func (cs *ChatService) createCustomChatbot(provider, apiKey string) (ChatbotClie
 		return nil, fmt.Errorf("unsupported provider: %s", provider)
 	}
 
+	if model != "" {
+		switch strings.ToLower(provider) {
+		case "openai":
+			cfg.OpenAI.Model = model
+		case "anthropic":
+			cfg.Anthropic.Model = model
+		case "gemini":
+			cfg.Gemini.Model = model
+		case "xai":
+			cfg.XAI.Model = model
+		}
+	}
+
 	// Create model and chatbot
-	model, err := models.NewFromConfig(cfg)
+	llm, err := models.NewFromConfig(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create custom model: %w", err)
 	}
 
-	chatbot, err := gochatbot.New(cfg, gochatbot.WithModel(model))
+	chatbot, err := gochatbot.New(cfg, gochatbot.WithModel(llm))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create custom chatbot: %w", err)
 	}
@@ -252,22 +303,102 @@ func (cs *ChatService) createCustomChatbot(provider, apiKey string) (ChatbotClie
 	return &chatbotAdapter{base: chatbot}, nil
 }
 
+// ask asks chatbot prompt, a question of kind, and logs the exchange if an
+// exchange logger is set. The question is traced as a chat.ask span.
+func (cs *ChatService) ask(ctx context.Context, chatbot ChatbotClient, kind, provider, apiKey, model, prompt string) (string, error) {
+	if provider == "" {
+		provider = cs.config.Model
+	}
+	ctx, span := tracer.Start(ctx, "chat.ask", trace.WithAttributes(
+		attribute.String("chat.kind", kind),
+		attribute.String("llm.provider", provider),
+		attribute.String("llm.model", model),
+	))
+	defer span.End()
+	response, err := cs.askLogged(ctx, chatbot, kind, provider, apiKey, model, prompt)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return response, err
+}
+
+// askLogged asks chatbot prompt for ask, logging the exchange.
+func (cs *ChatService) askLogged(ctx context.Context, chatbot ChatbotClient, kind, provider, apiKey, model, prompt string) (string, error) {
+	if cs.exchangeLog == nil {
+		return chatbot.Ask(ctx, prompt)
+	}
+	secrets := []string{apiKey, cs.configuredAPIKey(provider)}
+	start := time.Now()
+	response, err := chatbot.Ask(ctx, prompt)
+	exchange := ai.LLMExchange{
+		CorrelationID: ai.CorrelationID(ctx),
+		Kind:          kind,
+		Provider:      ai.LLMProvider(provider),
+		Model:         model,
+		Prompt:        ai.Redact(prompt, secrets...),
+		Response:      ai.Redact(response, secrets...),
+		Duration:      time.Since(start),
+	}
+	if err != nil {
+		exchange.Error = ai.Redact(err.Error(), secrets...)
+	}
+	cs.exchangeLog(exchange)
+	return response, err
+}
+
+// SetExchangeLogger logs the prompts and responses of chat and reactions, with
+// secrets and personal data redacted, to logger; nil stops logging.
+func (cs *ChatService) SetExchangeLogger(logger ai.LLMLogger) { cs.exchangeLog = logger }
+
+// SetCache shares an LLM cache for reactions to moves; nil disables caching.
+func (cs *ChatService) SetCache(cache *ai.LLMCache) { cs.cache = cache }
+
+// configuredAPIKey returns the service's own API key for provider.
+func (cs *ChatService) configuredAPIKey(provider string) string {
+	switch strings.ToLower(provider) {
+	case "openai":
+		return cs.config.OpenAI.APIKey
+	case "anthropic":
+		return cs.config.Anthropic.APIKey
+	case "gemini":
+		return cs.config.Gemini.APIKey
+	case "xai":
+		return cs.config.XAI.APIKey
+	default:
+		return ""
+	}
+}
+
 // StartConversation creates a new conversation for a game.
 func (cs *ChatService) StartConversation(gameID int) *Conversation {
-	cs.mu.Lock()
+	return cs.startConversation(gameID, "")
+}
+
+// startConversation creates a new conversation for a game in language,
+// replacing any it had.
+func (cs *ChatService) startConversation(gameID int, language string) *Conversation {
+	return cs.update(context.Background(), gameID, language, func(conversation *Conversation) {
+		version := conversation.Version
+		*conversation = *cs.newConversation(gameID, language)
+		conversation.Version = version
+	})
+}
+
+// newConversation creates a conversation for a game in language, with a welcome
+// message, without saving it.
+func (cs *ChatService) newConversation(gameID int, language string) *Conversation {
 	conversation := &Conversation{
 		GameID:    gameID,
+		Language:  language,
 		Messages:  make([]Message, 0),
 		Context:   make(map[string]interface{}),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	cs.conversations[gameID] = conversation
-	cs.mu.Unlock()
-
 	// Add welcome message
-	welcomeMsg := cs.generateWelcomeMessage()
+	welcomeMsg := cs.generateWelcomeMessage(language)
 	cs.addMessage(conversation, "ai", welcomeMsg, nil)
 
 	cs.logger.Info("Started new conversation", zap.Int("game_id", gameID))
@@ -276,37 +407,78 @@ func (cs *ChatService) StartConversation(gameID int) *Conversation {
 
 // Chat processes a chat message and returns AI response.
 func (cs *ChatService) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
-	// Get or create conversation
-	conversation, exists := cs.conversations[req.GameID]
-	if !exists {
-		conversation = cs.StartConversation(req.GameID)
+	message, filtered, err := cs.screenInput(ModerationEvent{Kind: "chat", GameID: req.GameID, UserID: req.UserID}, req.Message)
+	if err != nil {
+		return nil, err
+	}
+	req.Message = message
+	limitKey := cmp.Or(req.LimitKey, req.UserID)
+	if err := cs.limiter.allowMessage(limitKey, req.GameID); err != nil {
+		return nil, err
 	}
 
-	// Add user message to conversation
-	messageID := cs.addMessage(conversation, "user", req.Message, req.MoveData)
+	// Add user message to the conversation, started if needed
+	var messageID string
+	var accepted Message
+	conversation := cs.update(ctx, req.GameID, req.Language, func(conversation *Conversation) {
+		if req.Language != "" {
+			conversation.Language = req.Language
+		}
+		if req.Personality != "" {
+			conversation.Personality = req.Personality
+		}
+		messageID = cs.addUserMessage(conversation, req.UserID, req.Message, req.MoveData)
+		accepted = conversation.Messages[len(conversation.Messages)-1]
+	})
 
-	// Build context for AI
-	contextualMessage := cs.buildContextualMessage(req.Message, conversation, req.MoveData)
+	if req.OnAccepted != nil {
+		req.OnAccepted(accepted)
+	}
 
-	// Get chatbot instance (custom or default)
-	chatbot, err := cs.createCustomChatbot(req.Provider, req.APIKey)
+	// Get chatbot instance (custom or default). Another model needs its own
+	// chatbot, with the configured key unless the request brings one
+	provider, apiKey := req.Provider, req.APIKey
+	if req.Model != "" {
+		if provider == "" {
+			provider = cs.config.Model
+		}
+		if apiKey == "" {
+			apiKey = cs.configuredAPIKey(provider)
+		}
+	}
+	chatbot, err := cs.createCustomChatbot(provider, apiKey, req.Model)
 	if err != nil {
 		cs.logger.Error("Failed to create custom chatbot", zap.Error(err))
 		chatbot = cs.chatbot // Fallback to default
 	}
 
+	// Build context for AI, summarizing older messages of long conversations
+	conversation = cs.summarize(ctx, chatbot, provider, apiKey, req.Model, limitKey, conversation)
+	contextualMessage := cs.buildContextualMessage(req.UserID, req.Message, conversation, req.MoveData)
+
+	if req.Mode == ModeCoach {
+		contextualMessage += coachInstruction(req.MoveData)
+	}
+
 	// Get AI response
-	response, err := chatbot.Ask(ctx, contextualMessage)
+	response, err := cs.ask(ctx, chatbot, "chat", provider, apiKey, req.Model, contextualMessage)
 	if err != nil {
 		cs.logger.Error("Failed to get AI response", zap.Error(err))
 		return nil, fmt.Errorf("failed to get AI response: %w", err)
 	}
+	cs.limiter.spend(limitKey, req.GameID, estimateTokens(contextualMessage)+estimateTokens(response))
 
 	// Clean up response (remove any unwanted formatting)
 	cleanResponse := cs.cleanResponse(response)
+	moderated := cs.moderator.Moderate(ctx, "chat", cleanResponse, zap.Int("game_id", req.GameID))
+	cs.emitOutputModeration("chat", req.GameID, moderated)
+	cleanResponse = moderated.Text
 
 	// Add AI response to conversation
-	cs.addMessage(conversation, "ai", cleanResponse, nil)
+	var replyID string
+	conversation = cs.update(ctx, req.GameID, conversation.Language, func(conversation *Conversation) {
+		replyID = cs.addMessage(conversation, "ai", cleanResponse, nil)
+	})
 
 	// Generate suggestions for follow-up
 	suggestions := cs.generateSuggestions(conversation, req.MoveData)
@@ -314,18 +486,21 @@ func (cs *ChatService) Chat(ctx context.Context, req ChatRequest) (*ChatResponse
 	return &ChatResponse{
 		Message:     cleanResponse,
 		MessageID:   messageID,
-		Personality: "friendly_chess_coach",
+		ReplyID:     replyID,
+		Personality: personalityName(conversation, "friendly_chess_coach"),
 		GameContext: cs.buildGameContext(req.MoveData),
 		Suggestions: suggestions,
+		Filtered:    filtered,
 		Timestamp:   time.Now(),
 	}, nil
 }
 
-// ReactToMove generates an AI reaction to a chess move.
+// ReactToMove generates an AI reaction to a chess move, from the cache if the
+// personality already reacted to it in the same position and language.
 func (cs *ChatService) ReactToMove(ctx context.Context, gameID int, move string, gameState *engine.Game, provider, apiKey string) (*ChatResponse, error) {
 	// Get or create conversation
-	conversation, exists := cs.conversations[gameID]
-	if !exists {
+	conversation := cs.conversation(ctx, gameID)
+	if conversation == nil {
 		conversation = cs.StartConversation(gameID)
 	}
 
@@ -346,50 +521,109 @@ func (cs *ChatService) ReactToMove(ctx context.Context, gameID int, move string,
 		InCheck:       gameState.Status() == engine.Check,
 	}
 
+	// A personality that only comments now and then stays silent
+	if p, ok := ai.LookupPersonality(conversation.Personality); ok && rand.Float64() >= p.ReactionRate {
+		return &ChatResponse{
+			MessageID:   fmt.Sprintf("reaction_%d_%d", gameID, time.Now().Unix()),
+			Personality: p.Name,
+			GameContext: cs.buildGameContext(moveData),
+			Timestamp:   time.Now(),
+		}, nil
+	}
+
+	key := ai.ReactionKey{
+		Provider:    provider,
+		Personality: conversation.Personality,
+		Language:    conversation.Language,
+		FEN:         moveData.Position,
+		Move:        move,
+	}
+	if key.Provider == "" {
+		key.Provider = cs.config.Model
+	}
+	cleanReaction, ok := cs.cache.Reaction(key)
+	if !ok {
+		var err error
+		if cleanReaction, err = cs.askReaction(ctx, gameID, move, moveData, conversation, provider, apiKey); err != nil {
+			return nil, err
+		}
+		cs.cache.PutReaction(key, cleanReaction)
+	}
+
+	// Add reaction to conversation
+	conversation = cs.update(ctx, gameID, conversation.Language, func(conversation *Conversation) {
+		cs.addMessage(conversation, "ai", cleanReaction, moveData)
+	})
+
+	return &ChatResponse{
+		Message:     cleanReaction,
+		MessageID:   fmt.Sprintf("reaction_%d_%d", gameID, time.Now().Unix()),
+		Personality: personalityName(conversation, "observant_chess_coach"),
+		GameContext: cs.buildGameContext(moveData),
+		Timestamp:   time.Now(),
+	}, nil
+}
+
+// askReaction asks the chatbot for a reaction to a move, spending the game's
+// tokens, and returns it cleaned and moderated.
+func (cs *ChatService) askReaction(ctx context.Context, gameID int, move string, moveData *MoveContext, conversation *Conversation, provider, apiKey string) (string, error) {
+	if err := cs.limiter.allowTokens(gameID); err != nil {
+		return "", err
+	}
+
 	// Generate contextual reaction prompt
-	reactionPrompt := cs.buildMoveReactionPrompt(move, moveData)
+	reactionPrompt := cs.buildMoveReactionPrompt(move, moveData, conversation)
 
 	// Get chatbot instance (custom or default)
-	chatbot, err := cs.createCustomChatbot(provider, apiKey)
+	chatbot, err := cs.createCustomChatbot(provider, apiKey, "")
 	if err != nil {
 		cs.logger.Error("Failed to create custom chatbot", zap.Error(err))
 		chatbot = cs.chatbot // Fallback to default
 	}
 
 	// Get AI reaction
-	reaction, err := chatbot.Ask(ctx, reactionPrompt)
+	reaction, err := cs.ask(ctx, chatbot, "reaction", provider, apiKey, "", reactionPrompt)
 	if err != nil {
 		cs.logger.Error("Failed to get AI reaction", zap.Error(err))
-		return nil, fmt.Errorf("failed to get AI reaction: %w", err)
+		return "", fmt.Errorf("failed to get AI reaction: %w", err)
 	}
+	cs.limiter.spend("", gameID, estimateTokens(reactionPrompt)+estimateTokens(reaction))
 
 	// Clean response
 	cleanReaction := cs.cleanResponse(reaction)
+	moderated := cs.moderator.Moderate(ctx, "reaction", cleanReaction, zap.Int("game_id", gameID))
+	cs.emitOutputModeration("reaction", gameID, moderated)
+	return moderated.Text, nil
+}
 
-	// Add reaction to conversation
-	cs.addMessage(conversation, "ai", cleanReaction, moveData)
+// SetModerator sets the moderator that screens responses before they are stored
+// and returned; nil disables moderation.
+func (cs *ChatService) SetModerator(m *Moderator) { cs.moderator = m }
 
-	return &ChatResponse{
-		Message:     cleanReaction,
-		MessageID:   fmt.Sprintf("reaction_%d_%d", gameID, time.Now().Unix()),
-		Personality: "observant_chess_coach",
-		GameContext: cs.buildGameContext(moveData),
-		Timestamp:   time.Now(),
-	}, nil
+// SetLanguage sets the language of a game's replies and canned strings, starting
+// its conversation if needed; "" is English.
+func (cs *ChatService) SetLanguage(gameID int, language string) {
+	cs.update(context.Background(), gameID, language, func(conversation *Conversation) {
+		conversation.Language = language
+	})
+}
+
+// SetPersonality sets the personality preset a game's AI plays in chat, starting
+// its conversation if needed; "" is the configured character.
+func (cs *ChatService) SetPersonality(gameID int, name string) {
+	cs.update(context.Background(), gameID, "", func(conversation *Conversation) {
+		conversation.Personality = name
+	})
 }
 
-// GetConversation returns the conversation for a game.
+// GetConversation returns a copy of the conversation for a game, or nil.
 func (cs *ChatService) GetConversation(gameID int) *Conversation {
-	cs.mu.RLock()
-	defer cs.mu.RUnlock()
-	return cs.conversations[gameID]
+	return cs.conversation(context.Background(), gameID)
 }
 
 // GetConversationHistory returns the message history for a game.
 func (cs *ChatService) GetConversationHistory(gameID int) []Message {
-	cs.mu.RLock()
-	conversation := cs.conversations[gameID]
-	cs.mu.RUnlock()
+	conversation := cs.conversation(context.Background(), gameID)
 	if conversation == nil {
 		return []Message{}
 	}
@@ -398,22 +632,17 @@ func (cs *ChatService) GetConversationHistory(gameID int) []Message {
 
 // ClearConversation removes the conversation for a game.
 func (cs *ChatService) ClearConversation(gameID int) {
-	cs.mu.Lock()
-	delete(cs.conversations, gameID)
-	cs.mu.Unlock()
+	if err := cs.conversations.DeleteConversation(context.Background(), gameID); err != nil {
+		cs.logger.Warn("Failed to delete conversation", zap.Int("game_id", gameID), zap.Error(err))
+		return
+	}
 	cs.logger.Info("Cleared conversation", zap.Int("game_id", gameID))
 }
 
 // Helper methods
 
-func (cs *ChatService) generateWelcomeMessage() string {
-	welcomeMessages := []string{
-		"Hello! I'm your AI chess companion. Ready for a great game? 😊",
-		"Welcome to our chess match! I'm excited to play and chat with you. 🎯",
-		"Hi there! Let's have some fun with chess. Feel free to ask me anything about the game! ♟️",
-		"Greetings, chess friend! I'm here to play, chat, and maybe share some chess wisdom. 🤔",
-		"Hello! Ready to make some great moves? I love discussing chess strategy and tactics! ⚡",
-	}
+func (cs *ChatService) generateWelcomeMessage(language string) string {
+	welcomeMessages := phrasesFor(language).welcome
 
 	// Simple random selection (could be improved with proper randomization)
 	index := int(time.Now().Unix()) % len(welcomeMessages)
@@ -442,15 +671,29 @@ func (cs *ChatService) addMessage(conversation *Conversation, msgType, content s
 		Timestamp: time.Now(),
 	}
 
-	cs.mu.Lock()
 	conversation.Messages = append(conversation.Messages, message)
 	conversation.UpdatedAt = time.Now()
-	cs.mu.Unlock()
 
 	return messageID
 }
 
-func (cs *ChatService) buildContextualMessage(userMessage string, conversation *Conversation, moveData *MoveContext) string {
+// addUserMessage adds a message userID sent to a conversation and returns its ID.
+func (cs *ChatService) addUserMessage(conversation *Conversation, userID, content string, moveData *MoveContext) string {
+	messageID := cs.addMessage(conversation, "user", content, moveData)
+	conversation.Messages[len(conversation.Messages)-1].UserID = userID
+	return messageID
+}
+
+// speaker names the sender of a user message in prompts, so that the AI can
+// tell the humans of a game apart.
+func speaker(userID string) string {
+	if userID == "" {
+		return "Human"
+	}
+	return fmt.Sprintf("Human (%s)", userID)
+}
+
+func (cs *ChatService) buildContextualMessage(userID, userMessage string, conversation *Conversation, moveData *MoveContext) string {
 	var contextBuilder strings.Builder
 
 	// Add game context if available
@@ -467,32 +710,30 @@ func (cs *ChatService) buildContextualMessage(userMessage string, conversation *
 		contextBuilder.WriteString("\n\n")
 	}
 
-	// Add recent conversation context (last 3 messages)
-	recentMessages := conversation.Messages
-	if len(recentMessages) > 6 { // Keep last 3 exchanges (6 messages)
-		recentMessages = recentMessages[len(recentMessages)-6:]
+	// Add the summary of older messages and the recent conversation
+	if conversation.Summary != "" {
+		contextBuilder.WriteString(fmt.Sprintf("[Summary of the earlier conversation: %s]\n\n", conversation.Summary))
 	}
-
-	if len(recentMessages) > 0 {
+	if recentMessages := cs.promptHistory(conversation); len(recentMessages) > 0 {
 		contextBuilder.WriteString("[Recent conversation:\n")
-		for _, msg := range recentMessages {
-			if msg.Type == "user" {
-				contextBuilder.WriteString(fmt.Sprintf("Human: %s\n", msg.Content))
-			} else if msg.Type == "ai" {
-				contextBuilder.WriteString(fmt.Sprintf("Assistant: %s\n", msg.Content))
-			}
-		}
+		writeMessages(&contextBuilder, recentMessages)
 		contextBuilder.WriteString("]\n\n")
 	}
 
 	// Add current user message
-	contextBuilder.WriteString(fmt.Sprintf("Human: %s", userMessage))
+	contextBuilder.WriteString(fmt.Sprintf("%s: %s", speaker(userID), userMessage))
+	if p, ok := ai.LookupPersonality(conversation.Personality); ok {
+		contextBuilder.WriteString(fmt.Sprintf("\n\nStay in character as %s.", p.Prompt))
+	}
+	if instruction := ai.LanguageInstruction(conversation.Language); instruction != "" {
+		contextBuilder.WriteString("\n\n" + instruction)
+	}
 
 	return contextBuilder.String()
 }
 
-func (cs *ChatService) buildMoveReactionPrompt(move string, moveData *MoveContext) string {
-	return fmt.Sprintf(`[Game Context: Move %d, %s just played %s, Status: %s]
+func (cs *ChatService) buildMoveReactionPrompt(move string, moveData *MoveContext, conversation *Conversation) string {
+	prompt := fmt.Sprintf(`[Game Context: Move %d, %s just played %s, Status: %s]
 
 Please give a brief, encouraging reaction to this chess move. Consider:
 - Is this a good opening move, tactical shot, or strategic decision?
@@ -502,6 +743,22 @@ Please give a brief, encouraging reaction to this chess move. Consider:
 
 The move played was: %s`,
 		moveData.MoveCount, moveData.CurrentPlayer, move, moveData.GameStatus, move)
+	if p, ok := ai.LookupPersonality(conversation.Personality); ok {
+		prompt += fmt.Sprintf("\n\nStay in character as %s.", p.Prompt)
+	}
+	if instruction := ai.LanguageInstruction(conversation.Language); instruction != "" {
+		prompt += "\n\n" + instruction
+	}
+	return prompt
+}
+
+// personalityName returns the name of the conversation's personality preset, or
+// fallback without one.
+func personalityName(conversation *Conversation, fallback string) string {
+	if p, ok := ai.LookupPersonality(conversation.Personality); ok {
+		return p.Name
+	}
+	return fallback
 }
 
 func (cs *ChatService) cleanResponse(response string) string {
@@ -540,22 +797,27 @@ func (cs *ChatService) cleanResponse(response string) string {
 	return cleaned
 }
 
-func (cs *ChatService) generateSuggestions(_ *Conversation, moveData *MoveContext) []string {
-	suggestions := []string{
-		"What do you think about this position?",
-		"Any tips for improvement?",
-		"What's your favorite opening?",
-		"How would you rate my play so far?",
+func (cs *ChatService) generateSuggestions(conversation *Conversation, moveData *MoveContext) []string {
+	language := ""
+	if conversation != nil {
+		language = conversation.Language
+	}
+	p := phrasesFor(language)
+	// Questions about the position itself come first
+	var suggestions []string
+	if moveData != nil {
+		suggestions = featureSuggestions(p, moveData.Features)
 	}
+	suggestions = append(suggestions, p.suggestions...)
 
 	// Add context-specific suggestions
 	if moveData != nil {
 		if moveData.MoveCount < 10 {
-			suggestions = append(suggestions, "Tell me about this opening")
+			suggestions = append(suggestions, p.opening)
 		} else if moveData.MoveCount > 30 {
-			suggestions = append(suggestions, "How's my endgame technique?")
+			suggestions = append(suggestions, p.endgame)
 		} else {
-			suggestions = append(suggestions, "Any tactical opportunities here?")
+			suggestions = append(suggestions, p.middlegame)
 		}
 	}
 
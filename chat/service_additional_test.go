@@ -2,9 +2,11 @@ package chat
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"go.rumenx.com/chess/ai"
 	"go.rumenx.com/chess/engine"
 	"go.uber.org/zap"
 )
@@ -79,7 +81,7 @@ func TestChatService_BuildContextualMessageRecentLimit(t *testing.T) {
 		svc.addMessage(conv, "user", "u", nil)
 		svc.addMessage(conv, "ai", "a", nil)
 	}
-	msg := svc.buildContextualMessage("final", conv, nil)
+	msg := svc.buildContextualMessage("", "final", conv, nil)
 	// Should contain limited recent history; ensure not excessively long
 	if len(msg) > 1500 {
 		t.Errorf("contextual message too long")
@@ -161,3 +163,45 @@ func TestChatService_ReactToMove_WithMock(t *testing.T) {
 		t.Errorf("expected reaction message")
 	}
 }
+
+func TestChatService_ExchangeLogger(t *testing.T) {
+	svc := newTestService(t)
+	svc.SetChatbotForTesting(&mockChatbot{reply: "Mail me at coach@example.com"})
+	var exchanges []ai.LLMExchange
+	svc.SetExchangeLogger(func(e ai.LLMExchange) { exchanges = append(exchanges, e) })
+
+	ctx := ai.WithCorrelationID(context.Background(), "game-5")
+	if _, err := svc.Chat(ctx, ChatRequest{GameID: 5, Message: "I'm at +359 888 123 456"}); err != nil {
+		t.Fatalf("Chat error: %v", err)
+	}
+	if len(exchanges) != 1 {
+		t.Fatalf("expected one exchange, got %d", len(exchanges))
+	}
+	e := exchanges[0]
+	if e.CorrelationID != "game-5" || e.Kind != "chat" || e.Response != "Mail me at [email]" || !strings.Contains(e.Prompt, "[phone]") {
+		t.Errorf("expected a redacted chat exchange, got %+v", e)
+	}
+}
+
+func TestChatService_MultipleUsers(t *testing.T) {
+	svc := newTestService(t)
+	recorder := &promptRecorder{}
+	svc.SetChatbotForTesting(recorder)
+
+	for _, req := range []ChatRequest{
+		{GameID: 9, UserID: "alice", Message: "Should I castle?"},
+		{GameID: 9, UserID: "spectator-7", Message: "What is Alice's plan?"},
+	} {
+		if _, err := svc.Chat(context.Background(), req); err != nil {
+			t.Fatalf("Chat error: %v", err)
+		}
+	}
+	history := svc.GetConversationHistory(9)
+	if len(history) != 5 || history[1].UserID != "alice" || history[3].UserID != "spectator-7" || history[2].UserID != "" {
+		t.Fatalf("expected the senders on their messages, got %+v", history)
+	}
+	prompt := recorder.prompts[1]
+	if !strings.Contains(prompt, "Human (alice): Should I castle?") || !strings.HasSuffix(prompt, "Human (spectator-7): What is Alice's plan?") {
+		t.Errorf("expected the speakers named in the prompt, got %q", prompt)
+	}
+}
@@ -3,8 +3,12 @@ package chat
 import (
 	"context"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
 )
 
 func TestNewChatService(t *testing.T) {
@@ -94,7 +98,8 @@ func TestConversationManagement(t *testing.T) {
 	gameID := 123
 
 	// Test that conversations are created for new games
-	if _, exists := service.conversations[gameID]; exists {
+	ctx := context.Background()
+	if conv, _ := service.conversations.LoadConversation(ctx, gameID); conv != nil {
 		t.Error("Expected no conversation to exist initially")
 	}
 
@@ -105,15 +110,12 @@ func TestConversationManagement(t *testing.T) {
 		Context:  make(map[string]interface{}),
 	}
 
-	service.conversations[gameID] = conv
-
-	// Verify conversation was stored
-	if _, exists := service.conversations[gameID]; !exists {
-		t.Error("Expected conversation to be stored")
+	if err := service.conversations.SaveConversation(ctx, conv); err != nil {
+		t.Fatalf("Failed to store conversation: %v", err)
 	}
 
 	// Test conversation retrieval
-	if storedConv, exists := service.conversations[gameID]; !exists {
+	if storedConv := service.GetConversation(gameID); storedConv == nil {
 		t.Error("Expected to retrieve stored conversation")
 	} else if storedConv.GameID != gameID {
 		t.Errorf("Expected game ID %d, got %d", gameID, storedConv.GameID)
@@ -182,3 +184,37 @@ func TestChatRequestValidation(t *testing.T) {
 		t.Errorf("Expected last move 'e2e4', got '%s'", req.MoveData.LastMove)
 	}
 }
+
+func TestChatService_ReactionsCached(t *testing.T) {
+	svc := newTestService(t)
+	recorder := &promptRecorder{}
+	svc.SetChatbotForTesting(recorder)
+	svc.SetCache(ai.NewLLMCache(time.Minute, 10))
+
+	g := engine.NewGame()
+	mv, _ := g.ParseMove("e2e4")
+	if err := g.MakeMove(mv); err != nil {
+		t.Fatalf("apply move: %v", err)
+	}
+	for _, gameID := range []int{5, 6} {
+		resp, err := svc.ReactToMove(context.Background(), gameID, mv.String(), g, "", "")
+		if err != nil || resp.Message != "¡Buena jugada!" {
+			t.Fatalf("ReactToMove: %+v (%v)", resp, err)
+		}
+	}
+	if len(recorder.prompts) != 1 {
+		t.Errorf("expected the second reaction from the cache, got %d prompts", len(recorder.prompts))
+	}
+	if history := svc.GetConversationHistory(6); history[len(history)-1].Content != "¡Buena jugada!" {
+		t.Errorf("expected the cached reaction in the conversation, got %+v", history)
+	}
+
+	// Another language is asked for again
+	svc.SetLanguage(6, "es")
+	if _, err := svc.ReactToMove(context.Background(), 6, mv.String(), g, "", ""); err != nil {
+		t.Fatalf("ReactToMove error: %v", err)
+	}
+	if len(recorder.prompts) != 2 {
+		t.Errorf("expected a Spanish reaction asked for, got %d prompts", len(recorder.prompts))
+	}
+}
@@ -0,0 +1,126 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ConversationStore holds the conversations of a ChatService, e.g. in memory or
+// in a database shared by several API servers. The service changes copies of
+// the stored conversations and saves them back, starting over when another
+// change was saved in between.
+type ConversationStore interface {
+	// LoadConversation returns a game's conversation, or nil if it has none.
+	LoadConversation(ctx context.Context, gameID int) (*Conversation, error)
+	// SaveConversation saves conversation and increments its Version if the
+	// stored one has the same Version, or none is stored and it is 0, and
+	// returns ErrConversationChanged otherwise.
+	SaveConversation(ctx context.Context, conversation *Conversation) error
+	DeleteConversation(ctx context.Context, gameID int) error
+}
+
+// ErrConversationChanged is returned by ConversationStore.SaveConversation when
+// the conversation was saved or deleted since it was loaded.
+var ErrConversationChanged = errors.New("conversation changed since it was loaded")
+
+// maxUpdateAttempts bounds how often update reloads a conversation that keeps
+// changing under it.
+const maxUpdateAttempts = 5
+
+// MemoryStore is a ConversationStore in the memory of the process, the default
+// of a ChatService.
+type MemoryStore struct {
+	conversations map[int]*Conversation
+	mu            sync.RWMutex
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{conversations: make(map[int]*Conversation)}
+}
+
+// LoadConversation returns a copy of a game's conversation, or nil.
+func (s *MemoryStore) LoadConversation(_ context.Context, gameID int) (*Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conversation, ok := s.conversations[gameID]
+	if !ok {
+		return nil, nil
+	}
+	return conversation.clone(), nil
+}
+
+// SaveConversation stores a copy of conversation unless it changed since it was
+// loaded.
+func (s *MemoryStore) SaveConversation(_ context.Context, conversation *Conversation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.conversations[conversation.GameID]
+	if ok && stored.Version != conversation.Version || !ok && conversation.Version != 0 {
+		return ErrConversationChanged
+	}
+	conversation.Version++
+	s.conversations[conversation.GameID] = conversation.clone()
+	return nil
+}
+
+// DeleteConversation removes a game's conversation.
+func (s *MemoryStore) DeleteConversation(_ context.Context, gameID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conversations, gameID)
+	return nil
+}
+
+// clone copies a conversation so that changes to either leave the other alone.
+func (c *Conversation) clone() *Conversation {
+	clone := *c
+	clone.Messages = append([]Message(nil), c.Messages...)
+	clone.Context = maps.Clone(c.Context)
+	return &clone
+}
+
+// SetConversationStore keeps the service's conversations in store instead of its
+// own memory.
+func (cs *ChatService) SetConversationStore(store ConversationStore) { cs.conversations = store }
+
+// conversation returns a game's conversation, or nil if it has none or the store
+// fails.
+func (cs *ChatService) conversation(ctx context.Context, gameID int) *Conversation {
+	conversation, err := cs.conversations.LoadConversation(ctx, gameID)
+	if err != nil {
+		cs.logger.Warn("Failed to load conversation", zap.Int("game_id", gameID), zap.Error(err))
+		return nil
+	}
+	if conversation != nil && conversation.Context == nil {
+		conversation.Context = make(map[string]interface{})
+	}
+	return conversation
+}
+
+// update applies change to a game's conversation, started in language if it has
+// none, saves it and returns it. When another change, perhaps by another API
+// server, was saved in between, it applies change again to the newer copy, so
+// change may run more than once. A failed save is logged and the chat goes on;
+// the save outlives a cancelled request.
+func (cs *ChatService) update(ctx context.Context, gameID int, language string, change func(*Conversation)) *Conversation {
+	for attempt := 1; ; attempt++ {
+		conversation := cs.conversation(ctx, gameID)
+		if conversation == nil {
+			conversation = cs.newConversation(gameID, language)
+		}
+		change(conversation)
+		err := cs.conversations.SaveConversation(context.WithoutCancel(ctx), conversation)
+		if errors.Is(err, ErrConversationChanged) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			cs.logger.Warn("Failed to save conversation", zap.Int("game_id", gameID), zap.Error(err))
+		}
+		return conversation
+	}
+}
@@ -0,0 +1,90 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestMemoryStore_Copies(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	conversation := &Conversation{GameID: 1, Messages: []Message{{ID: "ai_1", Content: "Hi"}}}
+	if err := store.SaveConversation(ctx, conversation); err != nil {
+		t.Fatal(err)
+	}
+	conversation.Messages[0].Content = "changed"
+
+	loaded, err := store.LoadConversation(ctx, 1)
+	if err != nil || loaded == nil || loaded.Messages[0].Content != "Hi" {
+		t.Fatalf("expected the saved copy, got %+v: %v", loaded, err)
+	}
+	loaded.Messages = append(loaded.Messages, Message{ID: "user_1"})
+	if again, _ := store.LoadConversation(ctx, 1); len(again.Messages) != 1 {
+		t.Errorf("expected unsaved changes to stay out of the store, got %d messages", len(again.Messages))
+	}
+
+	if err := store.DeleteConversation(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if gone, err := store.LoadConversation(ctx, 1); gone != nil || err != nil {
+		t.Errorf("expected no conversation after delete, got %+v: %v", gone, err)
+	}
+}
+
+func TestChatService_SharedStore(t *testing.T) {
+	store := NewMemoryStore()
+	svc := newTestService(t)
+	svc.SetConversationStore(store)
+	svc.SetChatbotForTesting(&mockChatbot{reply: "Nice move!"})
+
+	svc.SetPersonality(3, "grumpy-grandmaster")
+	if _, err := svc.Chat(context.Background(), ChatRequest{GameID: 3, Message: "Hi", Language: "fr"}); err != nil {
+		t.Fatalf("Chat error: %v", err)
+	}
+	if saved, _ := store.LoadConversation(context.Background(), 3); saved == nil || len(saved.Messages) != 3 || saved.Language != "fr" {
+		t.Fatalf("expected the welcome, the question and the reply saved in French, got %+v", saved)
+	}
+
+	// Another service on the store, e.g. another API server, continues the chat
+	replica := newTestService(t)
+	replica.SetConversationStore(store)
+	replica.SetChatbotForTesting(&mockChatbot{reply: "Still here."})
+	resp, err := replica.Chat(context.Background(), ChatRequest{GameID: 3, Message: "Again"})
+	if err != nil {
+		t.Fatalf("Chat error: %v", err)
+	}
+	if resp.Personality != "grumpy-grandmaster" || len(svc.GetConversationHistory(3)) != 5 {
+		t.Errorf("expected the shared conversation, got %q with %d messages", resp.Personality, len(svc.GetConversationHistory(3)))
+	}
+
+	svc.ClearConversation(3)
+	if replica.GetConversation(3) != nil {
+		t.Error("expected the cleared conversation to be gone for both services")
+	}
+}
+
+func TestChatService_UpdatesFromReplicasKept(t *testing.T) {
+	store := NewMemoryStore()
+	services := []*ChatService{newTestService(t), newTestService(t)}
+	for _, svc := range services {
+		svc.SetConversationStore(store)
+	}
+	services[0].StartConversation(4)
+
+	var wg sync.WaitGroup
+	for i := range maxUpdateAttempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			services[i%2].update(context.Background(), 4, "", func(conversation *Conversation) {
+				conversation.Messages = append(conversation.Messages, Message{ID: fmt.Sprint("user_", i)})
+			})
+		}()
+	}
+	wg.Wait()
+	if got := len(services[1].GetConversationHistory(4)); got != 1+maxUpdateAttempts {
+		t.Errorf("expected the welcome and every message kept, got %d messages", got)
+	}
+}
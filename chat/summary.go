@@ -0,0 +1,103 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// recentMessages is how many of a conversation's latest messages stay out of
+// its summary, word for word in chat prompts.
+const recentMessages = 6
+
+// DefaultHistoryBudget is the history budget of a new ChatService, in characters.
+const DefaultHistoryBudget = 4000
+
+// SetHistoryBudget sets how many characters of conversation history chat
+// prompts carry. Once a conversation outgrows it, the messages before the
+// latest six are summarized with the LLM, and prompts carry the summary and the
+// messages since. Zero turns summarizing off: prompts carry the latest six
+// messages only.
+func (cs *ChatService) SetHistoryBudget(chars int) { cs.historyBudget = chars }
+
+// historySize returns the characters of a conversation's summary and the
+// messages it does not cover.
+func historySize(conversation *Conversation) int {
+	size := len(conversation.Summary)
+	for _, msg := range unsummarized(conversation) {
+		size += len(msg.Content)
+	}
+	return size
+}
+
+// unsummarized returns the messages of a conversation its summary does not cover.
+func unsummarized(conversation *Conversation) []Message {
+	return conversation.Messages[min(conversation.Summarized, len(conversation.Messages)):]
+}
+
+// promptHistory returns the messages chat prompts carry: those the summary does
+// not cover, or only the latest six without summaries or if summarizing failed.
+func (cs *ChatService) promptHistory(conversation *Conversation) []Message {
+	messages := unsummarized(conversation)
+	if (cs.historyBudget <= 0 || historySize(conversation) > cs.historyBudget) && len(messages) > recentMessages {
+		messages = messages[len(messages)-recentMessages:]
+	}
+	return messages
+}
+
+// summarize folds the messages of a conversation before its latest six into its
+// summary with chatbot, once the conversation outgrows the history budget, and
+// returns the conversation. A failed summary is logged, and prompts fall back
+// to the latest messages.
+func (cs *ChatService) summarize(ctx context.Context, chatbot ChatbotClient, provider, apiKey, model, limitKey string, conversation *Conversation) *Conversation {
+	from, to := conversation.Summarized, len(conversation.Messages)-recentMessages
+	if cs.historyBudget <= 0 || to <= from || historySize(conversation) <= cs.historyBudget {
+		return conversation
+	}
+	prompt := summaryPrompt(conversation.Summary, conversation.Messages[from:to])
+	summary, err := cs.ask(ctx, chatbot, "summary", provider, apiKey, model, prompt)
+	if err != nil {
+		cs.logger.Warn("Failed to summarize conversation", zap.Int("game_id", conversation.GameID), zap.Error(err))
+		return conversation
+	}
+	cs.limiter.spend(limitKey, conversation.GameID, estimateTokens(prompt)+estimateTokens(summary))
+
+	return cs.update(ctx, conversation.GameID, conversation.Language, func(conversation *Conversation) {
+		// Unless another summary or a cleared chat got there first
+		if conversation.Summarized == from && len(conversation.Messages) >= to {
+			conversation.Summary = strings.TrimSpace(summary)
+			conversation.Summarized = to
+		}
+	})
+}
+
+// summaryPrompt asks for the previous summary of a conversation, if any, and
+// the messages that followed it to be summarized together.
+func summaryPrompt(previous string, messages []Message) string {
+	var b strings.Builder
+	b.WriteString("Summarize this chess chat between players and their AI coach for the coach to continue it. ")
+	b.WriteString("Keep the advice given, the players' questions, weaknesses and plans, and any promises made; drop greetings and small talk. ")
+	b.WriteString("Write at most 150 words, in the language of the chat.\n\n")
+	if previous != "" {
+		fmt.Fprintf(&b, "[Summary so far: %s]\n\n", previous)
+	}
+	b.WriteString("[Conversation:\n")
+	writeMessages(&b, messages)
+	b.WriteString("]")
+	return b.String()
+}
+
+// writeMessages writes the user and AI messages of a conversation to b, one per
+// line, as prompts quote them.
+func writeMessages(b *strings.Builder, messages []Message) {
+	for _, msg := range messages {
+		switch msg.Type {
+		case "user":
+			fmt.Fprintf(b, "%s: %s\n", speaker(msg.UserID), msg.Content)
+		case "ai":
+			fmt.Fprintf(b, "Assistant: %s\n", msg.Content)
+		}
+	}
+}
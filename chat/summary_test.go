@@ -0,0 +1,75 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChatService_Summarize(t *testing.T) {
+	svc := newTestService(t)
+	recorder := &promptRecorder{}
+	svc.SetChatbotForTesting(recorder)
+	svc.SetHistoryBudget(300)
+
+	ask := func(i int) {
+		t.Helper()
+		message := fmt.Sprintf("Question %d: should I push my h-pawn here or keep it back for the endgame?", i)
+		if _, err := svc.Chat(context.Background(), ChatRequest{GameID: 5, Message: message}); err != nil {
+			t.Fatalf("Chat error: %v", err)
+		}
+	}
+	for i := 1; i <= 3; i++ {
+		ask(i)
+	}
+	if conv := svc.GetConversation(5); conv.Summary != "" || len(recorder.prompts) != 3 {
+		t.Fatalf("expected no summary within the budget, got %q after %d prompts", conv.Summary, len(recorder.prompts))
+	}
+
+	ask(4)
+	conv := svc.GetConversation(5)
+	if len(recorder.prompts) != 5 || !strings.HasPrefix(recorder.prompts[3], "Summarize this chess chat") ||
+		!strings.Contains(recorder.prompts[3], "Question 1:") {
+		t.Fatalf("expected the older messages to be summarized, got %q", recorder.prompts)
+	}
+	if conv.Summary != "¡Buena jugada!" || conv.Summarized != len(conv.Messages)-1-recentMessages {
+		t.Errorf("expected the summary to cover all but the latest messages, got %q over %d of %d", conv.Summary, conv.Summarized, len(conv.Messages))
+	}
+	prompt := recorder.prompts[4]
+	if !strings.Contains(prompt, "[Summary of the earlier conversation: ¡Buena jugada!]") ||
+		strings.Contains(prompt, "Question 1:") || !strings.Contains(prompt, "Question 3:") {
+		t.Errorf("expected the summary and recent messages in the prompt:\n%s", prompt)
+	}
+
+	// The next summary builds on the previous one
+	ask(5)
+	ask(6)
+	if summary := recorder.prompts[len(recorder.prompts)-2]; !strings.Contains(summary, "[Summary so far: ¡Buena jugada!]") ||
+		strings.Contains(summary, "Question 1:") {
+		t.Errorf("expected the previous summary to be extended:\n%s", summary)
+	}
+}
+
+func TestChatService_SummarizeFailure(t *testing.T) {
+	svc := newTestService(t)
+	svc.SetHistoryBudget(100)
+	conv := svc.StartConversation(6)
+	for i := 0; i < 10; i++ {
+		svc.addUserMessage(conv, "", fmt.Sprintf("Message %d about the Sicilian", i), nil)
+	}
+
+	svc.summarize(context.Background(), &mockChatbot{err: errors.New("down")}, "", "", "", "", conv)
+	if conv.Summary != "" {
+		t.Fatalf("expected no summary after a failure")
+	}
+	if history := svc.promptHistory(conv); len(history) != recentMessages || history[0].Content != "Message 4 about the Sicilian" {
+		t.Errorf("expected prompts to fall back to the latest messages, got %+v", history)
+	}
+
+	svc.SetHistoryBudget(0)
+	if got := svc.summarize(context.Background(), &mockChatbot{reply: "summary"}, "", "", "", "", conv); got.Summary != "" {
+		t.Errorf("expected no summaries without a budget")
+	}
+}
@@ -0,0 +1,118 @@
+// Command exhibition plays an exhibition game between two LLM providers, printing
+// the moves and the players' banter as they happen and writing the annotated PGN.
+//
+// Providers are configured from the environment like the API server's LLM engines
+// (OPENAI_API_KEY, OLLAMA_ENDPOINT, ...). For example:
+//
+//	exhibition -white openai -black anthropic -level hard -pgn game.pgn
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/ai/match"
+)
+
+func main() {
+	white := flag.String("white", "openai", "LLM provider playing White")
+	black := flag.String("black", "anthropic", "LLM provider playing Black")
+	whiteModel := flag.String("white-model", "", "model for White (provider default if empty)")
+	blackModel := flag.String("black-model", "", "model for Black (provider default if empty)")
+	level := flag.String("level", "medium", "difficulty of both players: beginner, easy, medium, hard or expert")
+	moveTime := flag.Duration("move-time", 30*time.Second, "thinking time per move")
+	maxPlies := flag.Int("max-plies", 200, "plies after which the game is adjudicated drawn")
+	commentary := flag.Bool("commentary", true, "let the players react to each other's moves")
+	pgnPath := flag.String("pgn", "", "write the annotated PGN to this file instead of stdout")
+	flag.Parse()
+
+	if err := run(*white, *black, *whiteModel, *blackModel, *level, *moveTime, *maxPlies, *commentary, *pgnPath); err != nil {
+		fmt.Fprintln(os.Stderr, "exhibition:", err)
+		os.Exit(1)
+	}
+}
+
+func run(white, black, whiteModel, blackModel, level string, moveTime time.Duration, maxPlies int, commentary bool, pgnPath string) error {
+	difficulty, err := parseDifficulty(level)
+	if err != nil {
+		return err
+	}
+	whitePlayer, err := newPlayer(white, whiteModel, difficulty)
+	if err != nil {
+		return err
+	}
+	blackPlayer, err := newPlayer(black, blackModel, difficulty)
+	if err != nil {
+		return err
+	}
+	if whitePlayer.Name == blackPlayer.Name {
+		whitePlayer.Name += " (White)"
+		blackPlayer.Name += " (Black)"
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("%s vs %s\n\n", whitePlayer.Name, blackPlayer.Name)
+	result, err := match.Play(ctx, whitePlayer, blackPlayer, match.Options{
+		Games:      1,
+		MoveTime:   moveTime,
+		MaxPlies:   maxPlies,
+		Event:      "LLM Exhibition",
+		Commentary: commentary,
+		OnMove: func(e match.MoveEvent) {
+			plies := len(e.Game.MoveHistory())
+			if plies%2 == 1 {
+				fmt.Printf("%d. %s\n", plies/2+1, e.SAN)
+			} else {
+				fmt.Printf("%d... %s\n", plies/2, e.SAN)
+			}
+			if e.Comment != "" {
+				fmt.Printf("    %s: %s\n", e.Commentator, e.Comment)
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	game := result.Games[0]
+	fmt.Printf("\nResult: %s (%s)\n", game.Result, game.Result.PGNTermination())
+	if pgnPath == "" {
+		fmt.Printf("\n%s", game.PGN)
+		return nil
+	}
+	if err := os.WriteFile(pgnPath, []byte(game.PGN), 0o644); err != nil {
+		return err
+	}
+	fmt.Println("PGN written to", pgnPath)
+	return nil
+}
+
+// newPlayer configures an LLM engine from the environment, named after its
+// provider and model.
+func newPlayer(provider, model string, difficulty ai.Difficulty) (match.Player, error) {
+	engine, err := ai.NewLLMAIFromEnv(provider, difficulty)
+	if err != nil {
+		return match.Player{}, err
+	}
+	if model != "" {
+		engine.SetModel(model)
+	}
+	return match.Player{Name: provider + " " + engine.GetModel(), Engine: engine}, nil
+}
+
+// parseDifficulty returns the difficulty named level.
+func parseDifficulty(level string) (ai.Difficulty, error) {
+	for d := ai.DifficultyBeginner; d <= ai.DifficultyExpert; d++ {
+		if d.String() == level {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown level %q", level)
+}
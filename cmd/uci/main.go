@@ -0,0 +1,19 @@
+// Command uci runs the go-chess engine as a UCI engine on stdin/stdout, so it can be
+// loaded into GUIs such as Arena or Cute Chess for testing and engine matches.
+//
+// Supported commands: uci, isready, setoption, ucinewgame, position, go (movetime,
+// wtime/btime/winc/binc/movestogo, depth, infinite), stop and quit. The engine
+// exposes the Difficulty, OwnBook and Repertoire options.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := newUCIServer(os.Stdout).run(os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, "uci:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// Engine identification sent in reply to "uci".
+const (
+	engineName   = "go-chess"
+	engineAuthor = "Rumen Damyanov"
+)
+
+// difficultyNames lists the values of the Difficulty option, weakest first.
+var difficultyNames = []string{"beginner", "easy", "medium", "hard", "expert"}
+
+// uciServer speaks the Universal Chess Interface on behalf of the minimax engine.
+type uciServer struct {
+	outMu sync.Mutex
+	out   io.Writer
+
+	game       *engine.Game
+	difficulty ai.Difficulty
+	repertoire ai.Repertoire
+	ownBook    bool
+	rng        *rand.Rand
+
+	// Running search, if any
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newUCIServer creates a server writing its replies to out.
+func newUCIServer(out io.Writer) *uciServer {
+	return &uciServer{
+		out:        out,
+		game:       engine.NewGame(),
+		difficulty: ai.DifficultyMedium,
+		repertoire: ai.RepertoireBalanced,
+		ownBook:    true,
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// run processes commands from in until "quit" or end of input.
+func (u *uciServer) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if !u.handle(scanner.Text()) {
+			return nil
+		}
+	}
+	u.stop()
+	return scanner.Err()
+}
+
+// send writes one line of output.
+func (u *uciServer) send(format string, args ...any) {
+	u.outMu.Lock()
+	defer u.outMu.Unlock()
+	fmt.Fprintf(u.out, format+"\n", args...)
+}
+
+// handle executes a single command and reports whether to keep reading.
+func (u *uciServer) handle(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+	switch fields[0] {
+	case "uci":
+		u.send("id name %s", engineName)
+		u.send("id author %s", engineAuthor)
+		u.send("option name Difficulty type combo default %s var %s", u.difficulty, strings.Join(difficultyNames, " var "))
+		u.send("option name OwnBook type check default true")
+		u.send("option name Repertoire type combo default balanced var balanced var aggressive var solid")
+		u.send("uciok")
+	case "isready":
+		u.send("readyok")
+	case "setoption":
+		u.stop()
+		u.setOption(fields[1:])
+	case "ucinewgame":
+		u.stop()
+		u.game = engine.NewGame()
+	case "position":
+		u.stop()
+		if err := u.setPosition(fields[1:]); err != nil {
+			u.send("info string %v", err)
+		}
+	case "go":
+		u.stop()
+		u.startSearch(parseGoParams(fields[1:]))
+	case "stop":
+		u.stop()
+	case "quit":
+		u.stop()
+		return false
+	}
+	return true
+}
+
+// setOption handles "setoption name <id> [value <x>]".
+func (u *uciServer) setOption(args []string) {
+	var name, value []string
+	target := &name
+	for _, arg := range args {
+		switch arg {
+		case "name":
+			target = &name
+		case "value":
+			target = &value
+		default:
+			*target = append(*target, arg)
+		}
+	}
+	v := strings.ToLower(strings.Join(value, " "))
+	switch strings.ToLower(strings.Join(name, " ")) {
+	case "difficulty":
+		for i, d := range difficultyNames {
+			if d == v {
+				u.difficulty = ai.Difficulty(i)
+			}
+		}
+	case "ownbook":
+		u.ownBook = v == "true"
+	case "repertoire":
+		if r, err := ai.ParseRepertoire(v); err == nil {
+			u.repertoire = r
+		}
+	}
+}
+
+// setPosition handles "position [startpos | fen <fen>] [moves <m1> ...]".
+func (u *uciServer) setPosition(args []string) error {
+	game := engine.NewGame()
+	rest := args
+	switch {
+	case len(args) > 0 && args[0] == "startpos":
+		rest = args[1:]
+	case len(args) > 0 && args[0] == "fen":
+		end := len(args)
+		for i, arg := range args {
+			if arg == "moves" {
+				end = i
+				break
+			}
+		}
+		if err := game.ParseFEN(strings.Join(args[1:end], " ")); err != nil {
+			return fmt.Errorf("invalid fen: %w", err)
+		}
+		rest = args[end:]
+	default:
+		return fmt.Errorf("invalid position command")
+	}
+	if len(rest) > 0 && rest[0] == "moves" {
+		for _, uci := range rest[1:] {
+			move, err := engine.MoveFromUCI(game, uci)
+			if err != nil {
+				return err
+			}
+			if err := game.MakeMove(move); err != nil {
+				return fmt.Errorf("illegal move %s: %w", uci, err)
+			}
+		}
+	}
+	u.game = game
+	return nil
+}
+
+// goParams are the search limits of a "go" command.
+type goParams struct {
+	moveTime  time.Duration
+	timeLeft  [2]time.Duration // White, Black
+	increment [2]time.Duration
+	movesToGo int
+	depth     int
+	infinite  bool
+	hasClock  bool
+}
+
+// parseGoParams parses the arguments of "go".
+func parseGoParams(args []string) goParams {
+	var p goParams
+	for i := 0; i < len(args); i++ {
+		next := func() int {
+			if i+1 >= len(args) {
+				return 0
+			}
+			i++
+			n, _ := strconv.Atoi(args[i])
+			return n
+		}
+		switch args[i] {
+		case "movetime":
+			p.moveTime = time.Duration(next()) * time.Millisecond
+		case "wtime":
+			p.timeLeft[0], p.hasClock = time.Duration(next())*time.Millisecond, true
+		case "btime":
+			p.timeLeft[1], p.hasClock = time.Duration(next())*time.Millisecond, true
+		case "winc":
+			p.increment[0] = time.Duration(next()) * time.Millisecond
+		case "binc":
+			p.increment[1] = time.Duration(next()) * time.Millisecond
+		case "movestogo":
+			p.movesToGo = next()
+		case "depth":
+			p.depth = next()
+		case "infinite":
+			p.infinite = true
+		}
+	}
+	return p
+}
+
+// budget returns how long the side to move may think, or 0 for no limit.
+func (p goParams) budget(side engine.Color) time.Duration {
+	if p.moveTime > 0 {
+		return p.moveTime
+	}
+	if !p.hasClock || p.infinite {
+		return 0
+	}
+	i := 0
+	if side == engine.Black {
+		i = 1
+	}
+	movesToGo := p.movesToGo
+	if movesToGo <= 0 {
+		movesToGo = 30
+	}
+	budget := p.timeLeft[i]/time.Duration(movesToGo) + p.increment[i]/2
+	// Never plan to use more than half the clock
+	return max(min(budget, p.timeLeft[i]/2), 10*time.Millisecond)
+}
+
+// difficultyForDepth maps "go depth n" to the difficulty searching n plies.
+func difficultyForDepth(depth int) ai.Difficulty {
+	return ai.Difficulty(min(max(depth-1, int(ai.DifficultyBeginner)), int(ai.DifficultyExpert)))
+}
+
+// startSearch searches the current position in the background and reports
+// "bestmove" when done, when the time budget runs out or on "stop".
+func (u *uciServer) startSearch(p goParams) {
+	game := u.game.Clone()
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if budget := p.budget(game.ActiveColor()); budget > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), budget)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	difficulty := u.difficulty
+	if p.depth > 0 {
+		difficulty = difficultyForDepth(p.depth)
+	}
+	var book *ai.OpeningBook
+	if u.ownBook {
+		book = ai.BuiltinBook(u.repertoire)
+	}
+
+	u.cancel, u.done = cancel, make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		defer cancel()
+		move, ok := u.search(ctx, game, difficulty, book)
+		if p.infinite {
+			// Hold the result until the GUI asks for it
+			<-ctx.Done()
+		}
+		if !ok {
+			u.send("bestmove 0000")
+			return
+		}
+		u.send("bestmove %s", move.UCI())
+	}(u.done)
+}
+
+// search picks a move: from the book if possible, otherwise by searching. A search
+// cut short by "stop" or the clock plays the best move found so far, and one
+// stopped before it could start falls back to a one-ply search.
+func (u *uciServer) search(ctx context.Context, game *engine.Game, difficulty ai.Difficulty, book *ai.OpeningBook) (engine.Move, bool) {
+	if bm, ok := book.Pick(game, u.rng); ok {
+		u.send("info string book move (%s)", bm.Name)
+		return bm.Move, true
+	}
+	if len(game.GetAllLegalMoves()) == 0 {
+		return engine.Move{}, false
+	}
+
+	minimax := ai.NewMinimaxAI(difficulty)
+	lines, info, err := minimax.Analyze(ctx, game, 1)
+	if err != nil {
+		lines, info, err = ai.NewMinimaxAI(ai.DifficultyBeginner).Analyze(context.Background(), game, 1)
+		if err != nil {
+			return engine.Move{}, false
+		}
+	}
+	line := lines[0]
+	score := fmt.Sprintf("cp %d", line.Score)
+	if mate := line.MateIn(); mate != 0 {
+		score = fmt.Sprintf("mate %d", mate)
+	}
+	pv := make([]string, len(line.Moves))
+	for i, move := range line.Moves {
+		pv[i] = move.UCI()
+	}
+	u.send("info depth %d score %s nodes %d nps %d time %d pv %s",
+		info.Depth, score, info.Nodes, info.NPS(), info.Elapsed.Milliseconds(), strings.Join(pv, " "))
+	return line.Moves[0], true
+}
+
+// stop ends the running search, if any, and waits for its "bestmove".
+func (u *uciServer) stop() {
+	if u.cancel == nil {
+		return
+	}
+	u.cancel()
+	<-u.done
+	u.cancel, u.done = nil, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/engine"
+)
+
+// runCommands feeds commands to a fresh server, waits for any search to finish and
+// returns the output lines.
+func runCommands(t *testing.T, commands ...string) []string {
+	t.Helper()
+	var out bytes.Buffer
+	u := newUCIServer(&out)
+	for _, cmd := range commands {
+		u.handle(cmd)
+		if u.done != nil && !strings.Contains(cmd, "infinite") {
+			<-u.done
+		}
+	}
+	u.stop()
+	return strings.Split(strings.TrimSpace(out.String()), "\n")
+}
+
+// syncBuffer is a bytes.Buffer safe to read while a search writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func lastLine(lines []string) string {
+	return lines[len(lines)-1]
+}
+
+func TestUCIHandshake(t *testing.T) {
+	lines := runCommands(t, "uci", "isready")
+	if lines[0] != "id name go-chess" || lines[len(lines)-2] != "uciok" || lastLine(lines) != "readyok" {
+		t.Fatalf("unexpected handshake: %q", lines)
+	}
+}
+
+func TestUCIFindsMate(t *testing.T) {
+	lines := runCommands(t,
+		"setoption name OwnBook value false",
+		"position fen 6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1",
+		"go depth 3",
+	)
+	if lastLine(lines) != "bestmove a1a8" {
+		t.Fatalf("expected Ra8#, got %q", lines)
+	}
+	if info := lines[len(lines)-2]; !strings.Contains(info, "score mate 1") || !strings.Contains(info, "pv a1a8") {
+		t.Errorf("unexpected info line %q", info)
+	}
+}
+
+func TestUCIPositionWithMoves(t *testing.T) {
+	var out bytes.Buffer
+	u := newUCIServer(&out)
+	u.handle("position startpos moves e2e4 e7e5 g1f3")
+	if want := "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"; u.game.ToFEN() != want {
+		t.Fatalf("unexpected position %s", u.game.ToFEN())
+	}
+	u.handle("position startpos moves e2e5")
+	if !strings.Contains(out.String(), "info string") {
+		t.Errorf("expected an illegal move to be reported")
+	}
+
+	lines := runCommands(t, "position startpos moves e2e4", "go movetime 1000")
+	if !strings.HasPrefix(lastLine(lines), "bestmove ") || !strings.Contains(lines[0], "book move") {
+		t.Errorf("expected a book reply, got %q", lines)
+	}
+}
+
+func TestUCIStopInfinite(t *testing.T) {
+	var out syncBuffer
+	u := newUCIServer(&out)
+	u.handle("setoption name Difficulty value beginner")
+	if u.difficulty != ai.DifficultyBeginner {
+		t.Fatalf("expected beginner difficulty, got %s", u.difficulty)
+	}
+	u.handle("position fen 4k3/8/8/8/8/8/4P3/4K3 w - - 0 1")
+	u.handle("go infinite")
+	time.Sleep(50 * time.Millisecond)
+	if strings.Contains(out.String(), "bestmove") {
+		t.Fatalf("expected bestmove to wait for stop")
+	}
+	u.handle("stop")
+	if !strings.Contains(out.String(), "bestmove ") {
+		t.Errorf("expected bestmove after stop, got %q", out.String())
+	}
+	if u.handle("quit") {
+		t.Errorf("expected quit to end the session")
+	}
+}
+
+func TestUCIGoBudget(t *testing.T) {
+	p := parseGoParams(strings.Fields("wtime 60000 btime 30000 winc 1000 binc 0"))
+	if got := p.budget(engine.White); got != 2500*time.Millisecond {
+		t.Errorf("white budget %v, want 2.5s", got)
+	}
+	if got := p.budget(engine.Black); got != time.Second {
+		t.Errorf("black budget %v, want 1s", got)
+	}
+	if got := parseGoParams(strings.Fields("movetime 250")).budget(engine.White); got != 250*time.Millisecond {
+		t.Errorf("movetime budget %v", got)
+	}
+	if got := parseGoParams(strings.Fields("infinite")).budget(engine.White); got != 0 {
+		t.Errorf("expected no limit for infinite, got %v", got)
+	}
+	if d := difficultyForDepth(9); d != ai.DifficultyExpert {
+		t.Errorf("expected depth 9 to clamp to expert, got %s", d)
+	}
+}
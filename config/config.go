@@ -5,7 +5,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,6 +18,7 @@ type Config struct {
 	LLMAI    LLMAIConfig    `json:"llm_ai"`
 	Logging  LoggingConfig  `json:"logging"`
 	Database DatabaseConfig `json:"database"`
+	Tracing  TracingConfig  `json:"tracing"`
 }
 
 // ServerConfig contains HTTP server configuration.
@@ -27,15 +30,73 @@ type ServerConfig struct {
 	IdleTimeout     time.Duration `json:"idle_timeout"`
 	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
 	CORSEnabled     bool          `json:"cors_enabled"`
-	AllowedOrigins  []string      `json:"allowed_origins"`
+	// AllowedOrigins are the browser origins allowed to call the API and open
+	// WebSockets: "*" for any, exact origins such as "https://chess.example.com",
+	// or "https://*.example.com" for its subdomains.
+	AllowedOrigins   []string      `json:"allowed_origins"`
+	CORSCredentials  bool          `json:"cors_credentials"`   // let browsers send cookies and credentials
+	CORSMaxAge       time.Duration `json:"cors_max_age"`       // how long browsers may cache preflight answers
+	ResponseCacheTTL time.Duration `json:"response_cache_ttl"` // read-only response cache; 0 disables (ETags still sent)
+	// ReplayEvents is how many of a game's latest WebSocket messages are kept
+	// for clients that connect late; 0 keeps none.
+	ReplayEvents int `json:"replay_events"`
+	// GameIDs is how games are identified in URLs: "sequential" numbers, or
+	// "uuid" for unguessable IDs that can be shared as links.
+	GameIDs string `json:"game_ids"`
+	// Auth controls who may use the API; disabled, anyone may.
+	Auth AuthConfig `json:"auth"`
 }
 
+// AuthConfig requires API requests to carry a static API key or a JWT signed
+// with HS256, each granting a user some of the scopes ScopeRead, ScopePlay and
+// ScopeAdmin.
+type AuthConfig struct {
+	Enabled bool     `json:"enabled"`
+	APIKeys []APIKey `json:"api_keys,omitempty"`
+	// JWTSecret verifies bearer JWTs, whose "sub" claim names the user and
+	// "scope" claim lists the scopes, space-separated; empty refuses JWTs.
+	JWTSecret   string `json:"jwt_secret,omitempty"`
+	JWTIssuer   string `json:"jwt_issuer,omitempty"`   // the "iss" claim JWTs must have, if set
+	JWTAudience string `json:"jwt_audience,omitempty"` // the "aud" claim JWTs must have, if set
+}
+
+// APIKey is a static API key of a user.
+type APIKey struct {
+	Key    string   `json:"key"`
+	UserID string   `json:"user_id"`
+	Scopes []string `json:"scopes"`
+}
+
+// Scopes of API keys and JWTs. Reading needs ScopeRead, changing anything
+// ScopePlay; ScopeAdmin grants both and may change games of other users.
+const (
+	ScopeRead  = "read"
+	ScopePlay  = "play"
+	ScopeAdmin = "admin"
+)
+
+// Game ID strategies.
+const (
+	GameIDsSequential = "sequential"
+	GameIDsUUID       = "uuid"
+)
+
 // AIConfig contains AI engine configuration.
 type AIConfig struct {
-	DefaultDifficulty string        `json:"default_difficulty"`
-	MaxThinkTime      time.Duration `json:"max_think_time"`
-	EnableCaching     bool          `json:"enable_caching"`
-	CacheSize         int           `json:"cache_size"`
+	DefaultDifficulty string         `json:"default_difficulty"`
+	MaxThinkTime      time.Duration  `json:"max_think_time"` // longest search; caps per-request movetime_ms
+	MaxDepth          int            `json:"max_depth"`      // cap for per-request max_depth (plies)
+	MaxNodes          int            `json:"max_nodes"`      // cap for per-request max_nodes
+	EnableCaching     bool           `json:"enable_caching"` // share evaluated positions between searches
+	CacheSize         int            `json:"cache_size"`     // positions kept in the evaluation cache
+	Repertoire        string         `json:"repertoire"`     // opening book: balanced, aggressive or solid
+	UCIPath           string         `json:"uci_path"`       // external UCI engine binary for engine "uci"
+	UCIEngines        int            `json:"uci_engines"`    // processes of the UCI engine kept running, one per concurrent search
+	DifficultyElo     map[string]int `json:"difficulty_elo"` // target rating per difficulty level
+	EvalNetwork       string         `json:"eval_network"`   // NNUE network file replacing the classical evaluation
+	ResignScore       int            `json:"resign_score"`   // centipawns behind at which the AI resigns; 0 never resigns
+	DrawOffers        bool           `json:"draw_offers"`    // let the AI offer draws in dead-equal endings
+	TablebaseURL      string         `json:"tablebase_url"`  // Lichess-compatible tablebase API probed by analysis; off if empty
 }
 
 // LLMAIConfig contains LLM AI provider configuration.
@@ -43,7 +104,49 @@ type LLMAIConfig struct {
 	Enabled         bool                         `json:"enabled"`
 	DefaultProvider string                       `json:"default_provider"`
 	ChatEnabled     bool                         `json:"chat_enabled"`
+	CacheTTL        time.Duration                `json:"cache_ttl"`  // how long LLM moves and reactions are reused; 0 disables
+	CacheSize       int                          `json:"cache_size"` // answers kept in the LLM cache
 	Providers       map[string]LLMProviderConfig `json:"providers"`
+	Moderation      ModerationConfig             `json:"moderation"`
+	ChatLimits      ChatLimitsConfig             `json:"chat_limits"`
+	// ChatHistoryBudget is the characters of conversation history chat prompts
+	// carry; longer conversations have their older messages summarized by the
+	// LLM. 0 keeps only the latest six messages.
+	ChatHistoryBudget int `json:"chat_history_budget"`
+	// MaxExhibitions is the LLM vs LLM exhibition games played at once; 0
+	// disables exhibitions.
+	MaxExhibitions int `json:"max_exhibitions"`
+	// LogExchanges logs every prompt and response, with API keys and personal data
+	// redacted, for debugging.
+	LogExchanges bool `json:"log_exchanges"`
+}
+
+// ModerationConfig configures the filter applied to LLM chat and reaction output
+// before it reaches players, and the filter of players' chat messages.
+type ModerationConfig struct {
+	Enabled      bool     `json:"enabled"`
+	BlockedWords []string `json:"blocked_words,omitempty"` // responses containing one are replaced
+	MaskedWords  []string `json:"masked_words,omitempty"`  // starred out of responses
+	// Endpoint is an OpenAI-compatible moderation endpoint also consulted, e.g.
+	// https://api.openai.com/v1/moderations; empty uses the word lists only.
+	Endpoint    string `json:"endpoint,omitempty"`
+	APIKey      string `json:"api_key,omitempty"`
+	Replacement string `json:"replacement"` // shown instead of a blocked response
+	// InputAction is done to players' chat messages with profanity, aggression
+	// or links: "reject", "sanitize" or "warn"; empty lets them all through.
+	InputAction        string   `json:"input_action"`
+	Profanities        []string `json:"profanities,omitempty"`         // words replacing the input filter's default list
+	AggressionPatterns []string `json:"aggression_patterns,omitempty"` // regular expressions replacing the default list
+}
+
+// ChatLimitsConfig bounds LLM chat use, so that a public server does not run up
+// its operator's LLM bills. Zero disables a limit. Tokens are estimated from the
+// length of prompts and replies.
+type ChatLimitsConfig struct {
+	UserMessagesPerMinute int `json:"user_messages_per_minute"` // chat messages of one user
+	GameMessagesPerMinute int `json:"game_messages_per_minute"` // chat messages in one game
+	UserTokensPerDay      int `json:"user_tokens_per_day"`      // chat tokens of one user
+	GameTokensPerDay      int `json:"game_tokens_per_day"`      // chat and reaction tokens of one game
 }
 
 // LLMProviderConfig contains configuration for a specific LLM provider.
@@ -52,6 +155,13 @@ type LLMProviderConfig struct {
 	Model       string `json:"model"`
 	Endpoint    string `json:"endpoint"`
 	Personality string `json:"personality"`
+	APIVersion  string `json:"api_version,omitempty"` // Azure OpenAI only
+	// AllowedModels are the models requests may choose besides Model.
+	AllowedModels []string `json:"allowed_models,omitempty"`
+	// MaxTokens caps the length of answers and Timeout bounds calls without a
+	// deadline of their own; the engine's defaults if zero.
+	MaxTokens int           `json:"max_tokens,omitempty"`
+	Timeout   time.Duration `json:"timeout,omitempty"`
 }
 
 // LoggingConfig contains logging configuration.
@@ -62,8 +172,13 @@ type LoggingConfig struct {
 	ErrorPath  string `json:"error_path"`
 }
 
-// DatabaseConfig contains database configuration.
+// DatabaseConfig contains database configuration. When enabled, chat
+// conversations and LLM opponents' memory of a game are kept in the database:
+// SQLite ("sqlite3"), PostgreSQL ("postgres") or Redis ("redis", with a
+// redis:// URL as connection string). The games are kept too; in Redis, API
+// servers sharing it serve the same games, locking each while they change it.
 type DatabaseConfig struct {
+	Enabled          bool          `json:"enabled"`
 	Driver           string        `json:"driver"`
 	ConnectionString string        `json:"connection_string"`
 	MaxConnections   int           `json:"max_connections"`
@@ -71,59 +186,162 @@ type DatabaseConfig struct {
 	MigrationsPath   string        `json:"migrations_path"`
 }
 
+// TracingConfig contains OpenTelemetry tracing configuration. Spans of API
+// requests, engine searches and LLM calls are exported over OTLP/HTTP, or
+// printed to stdout for debugging.
+type TracingConfig struct {
+	Enabled     bool    `json:"enabled"`
+	Exporter    string  `json:"exporter"`     // otlp or stdout
+	Endpoint    string  `json:"endpoint"`     // OTLP/HTTP collector URL; empty uses OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318
+	ServiceName string  `json:"service_name"` // service.name of the spans
+	SampleRatio float64 `json:"sample_ratio"` // share of new traces recorded, 0 to 1; requests in a sampled trace always are
+}
+
 // Default returns a default configuration.
 func Default() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Host:            getEnvString("CHESS_HOST", "localhost"),
-			Port:            getEnvInt("CHESS_PORT", 8080),
-			ReadTimeout:     getEnvDuration("CHESS_READ_TIMEOUT", 30*time.Second),
-			WriteTimeout:    getEnvDuration("CHESS_WRITE_TIMEOUT", 30*time.Second),
-			IdleTimeout:     getEnvDuration("CHESS_IDLE_TIMEOUT", 120*time.Second),
-			ShutdownTimeout: getEnvDuration("CHESS_SHUTDOWN_TIMEOUT", 10*time.Second),
-			CORSEnabled:     getEnvBool("CHESS_CORS_ENABLED", true),
-			AllowedOrigins:  getEnvStringSlice("CHESS_ALLOWED_ORIGINS", []string{"*"}),
+			Host:             getEnvString("CHESS_HOST", "localhost"),
+			Port:             getEnvInt("CHESS_PORT", 8080),
+			ReadTimeout:      getEnvDuration("CHESS_READ_TIMEOUT", 30*time.Second),
+			WriteTimeout:     getEnvDuration("CHESS_WRITE_TIMEOUT", 30*time.Second),
+			IdleTimeout:      getEnvDuration("CHESS_IDLE_TIMEOUT", 120*time.Second),
+			ShutdownTimeout:  getEnvDuration("CHESS_SHUTDOWN_TIMEOUT", 10*time.Second),
+			CORSEnabled:      getEnvBool("CHESS_CORS_ENABLED", true),
+			AllowedOrigins:   getEnvStringSlice("CHESS_ALLOWED_ORIGINS", []string{"*"}),
+			CORSCredentials:  getEnvBool("CHESS_CORS_CREDENTIALS", false),
+			CORSMaxAge:       getEnvDuration("CHESS_CORS_MAX_AGE", 10*time.Minute),
+			ResponseCacheTTL: getEnvDuration("CHESS_RESPONSE_CACHE_TTL", 5*time.Second),
+			ReplayEvents:     getEnvInt("CHESS_REPLAY_EVENTS", 0),
+			GameIDs:          getEnvString("CHESS_GAME_IDS", GameIDsSequential),
+			Auth: AuthConfig{
+				Enabled:     getEnvBool("CHESS_AUTH_ENABLED", false),
+				APIKeys:     getEnvAPIKeys("CHESS_API_KEYS"),
+				JWTSecret:   getEnvString("CHESS_JWT_SECRET", ""),
+				JWTIssuer:   getEnvString("CHESS_JWT_ISSUER", ""),
+				JWTAudience: getEnvString("CHESS_JWT_AUDIENCE", ""),
+			},
 		},
 		AI: AIConfig{
 			DefaultDifficulty: getEnvString("CHESS_AI_DEFAULT_DIFFICULTY", "medium"),
 			MaxThinkTime:      getEnvDuration("CHESS_AI_MAX_THINK_TIME", 30*time.Second),
+			MaxDepth:          getEnvInt("CHESS_AI_MAX_DEPTH", 10),
+			MaxNodes:          getEnvInt("CHESS_AI_MAX_NODES", 5000000),
 			EnableCaching:     getEnvBool("CHESS_AI_ENABLE_CACHING", true),
-			CacheSize:         getEnvInt("CHESS_AI_CACHE_SIZE", 1000),
+			CacheSize:         getEnvInt("CHESS_AI_CACHE_SIZE", 100000),
+			Repertoire:        getEnvString("CHESS_AI_REPERTOIRE", "balanced"),
+			UCIPath:           getEnvString("CHESS_AI_UCI_PATH", ""),
+			UCIEngines:        getEnvInt("CHESS_AI_UCI_ENGINES", 2),
+			EvalNetwork:       getEnvString("CHESS_AI_EVAL_NETWORK", ""),
+			ResignScore:       getEnvInt("CHESS_AI_RESIGN_SCORE", 700),
+			DrawOffers:        getEnvBool("CHESS_AI_DRAW_OFFERS", true),
+			TablebaseURL:      getEnvString("CHESS_AI_TABLEBASE_URL", ""),
+			DifficultyElo: map[string]int{
+				"beginner": getEnvInt("CHESS_AI_ELO_BEGINNER", 800),
+				"easy":     getEnvInt("CHESS_AI_ELO_EASY", 1100),
+				"medium":   getEnvInt("CHESS_AI_ELO_MEDIUM", 1400),
+				"hard":     getEnvInt("CHESS_AI_ELO_HARD", 1700),
+				"expert":   getEnvInt("CHESS_AI_ELO_EXPERT", 2000),
+			},
 		},
 		LLMAI: LLMAIConfig{
-			Enabled:         getEnvBool("CHESS_LLMAI_ENABLED", false),
-			DefaultProvider: getEnvString("CHESS_LLMAI_PROVIDER", "openai"),
-			ChatEnabled:     getEnvBool("CHESS_LLMAI_CHAT", true),
+			Enabled:           getEnvBool("CHESS_LLMAI_ENABLED", false),
+			DefaultProvider:   getEnvString("CHESS_LLMAI_PROVIDER", "openai"),
+			ChatEnabled:       getEnvBool("CHESS_LLMAI_CHAT", true),
+			CacheTTL:          getEnvDuration("CHESS_LLMAI_CACHE_TTL", time.Hour),
+			CacheSize:         getEnvInt("CHESS_LLMAI_CACHE_SIZE", 10000),
+			LogExchanges:      getEnvBool("CHESS_LLMAI_LOG_EXCHANGES", false),
+			ChatHistoryBudget: getEnvInt("CHESS_CHAT_HISTORY_BUDGET", 4000),
+			MaxExhibitions:    getEnvInt("CHESS_LLMAI_MAX_EXHIBITIONS", 0),
+			Moderation: ModerationConfig{
+				Enabled:            getEnvBool("CHESS_MODERATION_ENABLED", true),
+				BlockedWords:       getEnvStringSlice("CHESS_MODERATION_BLOCKED_WORDS", nil),
+				MaskedWords:        getEnvStringSlice("CHESS_MODERATION_MASKED_WORDS", nil),
+				Endpoint:           getEnvString("CHESS_MODERATION_ENDPOINT", ""),
+				APIKey:             getEnvString("CHESS_MODERATION_API_KEY", getEnvString("OPENAI_API_KEY", "")),
+				Replacement:        getEnvString("CHESS_MODERATION_REPLACEMENT", "Let's keep our conversation about chess!"),
+				InputAction:        getEnvString("CHESS_MODERATION_INPUT_ACTION", "sanitize"),
+				Profanities:        getEnvStringSlice("CHESS_MODERATION_PROFANITIES", nil),
+				AggressionPatterns: getEnvStringSlice("CHESS_MODERATION_AGGRESSION_PATTERNS", nil),
+			},
+			ChatLimits: ChatLimitsConfig{
+				UserMessagesPerMinute: getEnvInt("CHESS_CHAT_USER_MESSAGES_PER_MINUTE", 20),
+				GameMessagesPerMinute: getEnvInt("CHESS_CHAT_GAME_MESSAGES_PER_MINUTE", 60),
+				UserTokensPerDay:      getEnvInt("CHESS_CHAT_USER_TOKENS_PER_DAY", 50000),
+				GameTokensPerDay:      getEnvInt("CHESS_CHAT_GAME_TOKENS_PER_DAY", 200000),
+			},
 			Providers: map[string]LLMProviderConfig{
 				"openai": {
-					APIKey:      getEnvString("OPENAI_API_KEY", ""),
-					Model:       getEnvString("OPENAI_MODEL", "gpt-3.5-turbo"),
-					Endpoint:    getEnvString("OPENAI_ENDPOINT", "https://api.openai.com/v1/chat/completions"),
-					Personality: getEnvString("OPENAI_PERSONALITY", "a friendly but competitive chess master"),
+					APIKey:        getEnvString("OPENAI_API_KEY", ""),
+					Model:         getEnvString("OPENAI_MODEL", "gpt-3.5-turbo"),
+					Endpoint:      getEnvString("OPENAI_ENDPOINT", "https://api.openai.com/v1/chat/completions"),
+					Personality:   getEnvString("OPENAI_PERSONALITY", "a friendly but competitive chess master"),
+					AllowedModels: getEnvStringSlice("OPENAI_ALLOWED_MODELS", nil),
+					MaxTokens:     getEnvInt("OPENAI_MAX_TOKENS", 0),
+					Timeout:       getEnvDuration("OPENAI_TIMEOUT", 0),
 				},
 				"anthropic": {
-					APIKey:      getEnvString("ANTHROPIC_API_KEY", ""),
-					Model:       getEnvString("ANTHROPIC_MODEL", "claude-3-haiku-20240307"),
-					Endpoint:    getEnvString("ANTHROPIC_ENDPOINT", "https://api.anthropic.com/v1/messages"),
-					Personality: getEnvString("ANTHROPIC_PERSONALITY", "a thoughtful and analytical chess strategist"),
+					APIKey:        getEnvString("ANTHROPIC_API_KEY", ""),
+					Model:         getEnvString("ANTHROPIC_MODEL", "claude-3-haiku-20240307"),
+					Endpoint:      getEnvString("ANTHROPIC_ENDPOINT", "https://api.anthropic.com/v1/messages"),
+					Personality:   getEnvString("ANTHROPIC_PERSONALITY", "a thoughtful and analytical chess strategist"),
+					AllowedModels: getEnvStringSlice("ANTHROPIC_ALLOWED_MODELS", nil),
+					MaxTokens:     getEnvInt("ANTHROPIC_MAX_TOKENS", 0),
+					Timeout:       getEnvDuration("ANTHROPIC_TIMEOUT", 0),
 				},
 				"gemini": {
-					APIKey:      getEnvString("GEMINI_API_KEY", ""),
-					Model:       getEnvString("GEMINI_MODEL", "gemini-1.5-flash"),
-					Endpoint:    getEnvString("GEMINI_ENDPOINT", "https://generativelanguage.googleapis.com/v1beta/models"),
-					Personality: getEnvString("GEMINI_PERSONALITY", "a creative and intuitive chess player"),
+					APIKey:        getEnvString("GEMINI_API_KEY", ""),
+					Model:         getEnvString("GEMINI_MODEL", "gemini-1.5-flash"),
+					Endpoint:      getEnvString("GEMINI_ENDPOINT", "https://generativelanguage.googleapis.com/v1beta/models"),
+					Personality:   getEnvString("GEMINI_PERSONALITY", "a creative and intuitive chess player"),
+					AllowedModels: getEnvStringSlice("GEMINI_ALLOWED_MODELS", nil),
+					MaxTokens:     getEnvInt("GEMINI_MAX_TOKENS", 0),
+					Timeout:       getEnvDuration("GEMINI_TIMEOUT", 0),
 				},
 				"xai": {
-					APIKey:      getEnvString("XAI_API_KEY", ""),
-					Model:       getEnvString("XAI_MODEL", "grok-beta"),
-					Endpoint:    getEnvString("XAI_ENDPOINT", "https://api.x.ai/v1/chat/completions"),
-					Personality: getEnvString("XAI_PERSONALITY", "a witty and clever chess opponent"),
+					APIKey:        getEnvString("XAI_API_KEY", ""),
+					Model:         getEnvString("XAI_MODEL", "grok-beta"),
+					Endpoint:      getEnvString("XAI_ENDPOINT", "https://api.x.ai/v1/chat/completions"),
+					Personality:   getEnvString("XAI_PERSONALITY", "a witty and clever chess opponent"),
+					AllowedModels: getEnvStringSlice("XAI_ALLOWED_MODELS", nil),
+					MaxTokens:     getEnvInt("XAI_MAX_TOKENS", 0),
+					Timeout:       getEnvDuration("XAI_TIMEOUT", 0),
 				},
 				"deepseek": {
-					APIKey:      getEnvString("DEEPSEEK_API_KEY", ""),
-					Model:       getEnvString("DEEPSEEK_MODEL", "deepseek-chat"),
-					Endpoint:    getEnvString("DEEPSEEK_ENDPOINT", "https://api.deepseek.com/v1/chat/completions"),
-					Personality: getEnvString("DEEPSEEK_PERSONALITY", "a deep-thinking and methodical chess AI"),
+					APIKey:        getEnvString("DEEPSEEK_API_KEY", ""),
+					Model:         getEnvString("DEEPSEEK_MODEL", "deepseek-chat"),
+					Endpoint:      getEnvString("DEEPSEEK_ENDPOINT", "https://api.deepseek.com/v1/chat/completions"),
+					Personality:   getEnvString("DEEPSEEK_PERSONALITY", "a deep-thinking and methodical chess AI"),
+					AllowedModels: getEnvStringSlice("DEEPSEEK_ALLOWED_MODELS", nil),
+					MaxTokens:     getEnvInt("DEEPSEEK_MAX_TOKENS", 0),
+					Timeout:       getEnvDuration("DEEPSEEK_TIMEOUT", 0),
+				},
+				"azure": {
+					APIKey:        getEnvString("AZURE_OPENAI_API_KEY", ""),
+					Model:         getEnvString("AZURE_OPENAI_DEPLOYMENT", ""),
+					Endpoint:      getEnvString("AZURE_OPENAI_ENDPOINT", ""),
+					Personality:   getEnvString("AZURE_OPENAI_PERSONALITY", "a friendly but competitive chess master"),
+					AllowedModels: getEnvStringSlice("AZURE_OPENAI_ALLOWED_MODELS", nil),
+					MaxTokens:     getEnvInt("AZURE_OPENAI_MAX_TOKENS", 0),
+					Timeout:       getEnvDuration("AZURE_OPENAI_TIMEOUT", 0),
+					APIVersion:    getEnvString("AZURE_OPENAI_API_VERSION", "2024-10-21"),
+				},
+				"openrouter": {
+					APIKey:        getEnvString("OPENROUTER_API_KEY", ""),
+					Model:         getEnvString("OPENROUTER_MODEL", "openai/gpt-4o-mini"),
+					Endpoint:      getEnvString("OPENROUTER_ENDPOINT", "https://openrouter.ai/api/v1/chat/completions"),
+					Personality:   getEnvString("OPENROUTER_PERSONALITY", "a versatile and curious chess player"),
+					AllowedModels: getEnvStringSlice("OPENROUTER_ALLOWED_MODELS", nil),
+					MaxTokens:     getEnvInt("OPENROUTER_MAX_TOKENS", 0),
+					Timeout:       getEnvDuration("OPENROUTER_TIMEOUT", 0),
+				},
+				"ollama": {
+					Model:         getEnvString("OLLAMA_MODEL", "llama3.2"),
+					Endpoint:      getEnvString("OLLAMA_ENDPOINT", "http://localhost:11434"),
+					Personality:   getEnvString("OLLAMA_PERSONALITY", "a patient and encouraging chess coach"),
+					AllowedModels: getEnvStringSlice("OLLAMA_ALLOWED_MODELS", nil),
+					MaxTokens:     getEnvInt("OLLAMA_MAX_TOKENS", 0),
+					Timeout:       getEnvDuration("OLLAMA_TIMEOUT", 0),
 				},
 			},
 		},
@@ -134,12 +352,20 @@ func Default() *Config {
 			ErrorPath:  getEnvString("CHESS_LOG_ERROR_PATH", "stderr"),
 		},
 		Database: DatabaseConfig{
+			Enabled:          getEnvBool("CHESS_DB_ENABLED", false),
 			Driver:           getEnvString("CHESS_DB_DRIVER", "sqlite3"),
 			ConnectionString: getEnvString("CHESS_DB_CONNECTION_STRING", "./chess.db"),
 			MaxConnections:   getEnvInt("CHESS_DB_MAX_CONNECTIONS", 10),
 			ConnMaxLifetime:  getEnvDuration("CHESS_DB_CONN_MAX_LIFETIME", 1*time.Hour),
 			MigrationsPath:   getEnvString("CHESS_DB_MIGRATIONS_PATH", "./migrations"),
 		},
+		Tracing: TracingConfig{
+			Enabled:     getEnvBool("CHESS_TRACING_ENABLED", false),
+			Exporter:    getEnvString("CHESS_TRACING_EXPORTER", "otlp"),
+			Endpoint:    getEnvString("CHESS_TRACING_ENDPOINT", ""),
+			ServiceName: getEnvString("CHESS_TRACING_SERVICE_NAME", "go-chess"),
+			SampleRatio: getEnvFloat("CHESS_TRACING_SAMPLE_RATIO", 1),
+		},
 	}
 }
 
@@ -158,18 +384,150 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server write timeout: %v (must be positive)", c.Server.WriteTimeout)
 	}
 
+	if c.Server.ResponseCacheTTL < 0 {
+		return fmt.Errorf("invalid response cache TTL: %v (must not be negative)", c.Server.ResponseCacheTTL)
+	}
+
+	if c.Server.ReplayEvents < 0 {
+		return fmt.Errorf("invalid replay events: %d (must not be negative)", c.Server.ReplayEvents)
+	}
+
+	if c.Server.CORSMaxAge < 0 {
+		return fmt.Errorf("invalid CORS max age: %v (must not be negative)", c.Server.CORSMaxAge)
+	}
+	// Credentials for any origin would let every site act as the signed-in user
+	if c.Server.CORSEnabled && c.Server.CORSCredentials && slices.Contains(c.Server.AllowedOrigins, "*") {
+		return fmt.Errorf("invalid CORS settings: credentials cannot be allowed for any origin (*); list the allowed origins")
+	}
+
+	if c.Server.GameIDs != GameIDsSequential && c.Server.GameIDs != GameIDsUUID {
+		return fmt.Errorf("invalid game ID strategy: %q (must be sequential or uuid)", c.Server.GameIDs)
+	}
+
+	if err := c.Server.Auth.validate(); err != nil {
+		return err
+	}
+
 	// Validate AI configuration
 	if c.AI.MaxThinkTime <= 0 {
 		return fmt.Errorf("invalid AI max think time: %v (must be positive)", c.AI.MaxThinkTime)
 	}
 
+	if c.AI.EnableCaching && c.AI.CacheSize <= 0 {
+		return fmt.Errorf("invalid AI cache size: %d (must be positive)", c.AI.CacheSize)
+	}
+
+	if c.AI.MaxDepth <= 0 {
+		return fmt.Errorf("invalid AI max depth: %d (must be positive)", c.AI.MaxDepth)
+	}
+
+	if c.AI.MaxNodes <= 0 {
+		return fmt.Errorf("invalid AI max nodes: %d (must be positive)", c.AI.MaxNodes)
+	}
+
+	for level, elo := range c.AI.DifficultyElo {
+		if elo <= 0 {
+			return fmt.Errorf("invalid AI target Elo for %s: %d (must be positive)", level, elo)
+		}
+	}
+
+	if c.AI.UCIPath != "" && c.AI.UCIEngines <= 0 {
+		return fmt.Errorf("invalid AI UCI engines: %d (must be positive)", c.AI.UCIEngines)
+	}
+
+	if c.AI.ResignScore < 0 {
+		return fmt.Errorf("invalid AI resign score: %d (must not be negative)", c.AI.ResignScore)
+	}
+
+	switch c.AI.Repertoire {
+	case "", "balanced", "aggressive", "solid":
+	default:
+		return fmt.Errorf("invalid AI repertoire: %q (must be balanced, aggressive or solid)", c.AI.Repertoire)
+	}
+
 	// Validate LLMAI configuration
 	if c.LLMAI.Enabled {
 		if c.LLMAI.DefaultProvider == "" {
 			return fmt.Errorf("LLMAI is enabled but no default provider is set")
 		}
+
+		if c.LLMAI.CacheTTL < 0 {
+			return fmt.Errorf("invalid LLMAI cache TTL: %v (must not be negative)", c.LLMAI.CacheTTL)
+		}
+
+		if c.LLMAI.CacheTTL > 0 && c.LLMAI.CacheSize <= 0 {
+			return fmt.Errorf("invalid LLMAI cache size: %d (must be positive)", c.LLMAI.CacheSize)
+		}
+
+		for name, provider := range c.LLMAI.Providers {
+			if provider.MaxTokens < 0 {
+				return fmt.Errorf("invalid LLMAI max tokens for %s: %d (must not be negative)", name, provider.MaxTokens)
+			}
+			if provider.Timeout < 0 {
+				return fmt.Errorf("invalid LLMAI timeout for %s: %v (must not be negative)", name, provider.Timeout)
+			}
+		}
+
+		if c.LLMAI.Moderation.Enabled && c.LLMAI.Moderation.Replacement == "" {
+			return fmt.Errorf("LLMAI moderation is enabled but no replacement message is set")
+		}
+	}
+
+	limits := c.LLMAI.ChatLimits
+	if limits.UserMessagesPerMinute < 0 || limits.GameMessagesPerMinute < 0 || limits.UserTokensPerDay < 0 || limits.GameTokensPerDay < 0 {
+		return fmt.Errorf("invalid chat limits: %+v (must not be negative)", limits)
+	}
+	if c.LLMAI.ChatHistoryBudget < 0 {
+		return fmt.Errorf("invalid chat history budget: %d (must not be negative)", c.LLMAI.ChatHistoryBudget)
+	}
+	if c.LLMAI.MaxExhibitions < 0 {
+		return fmt.Errorf("invalid max exhibitions: %d (must not be negative)", c.LLMAI.MaxExhibitions)
 	}
+	switch action := c.LLMAI.Moderation.InputAction; action {
+	case "", "reject", "sanitize", "warn":
+	default:
+		return fmt.Errorf("invalid moderation input action: %q (must be reject, sanitize or warn)", action)
+	}
+
+	// Validate database configuration
+	if c.Database.Enabled {
+		if c.Database.Driver == "" || c.Database.ConnectionString == "" {
+			return fmt.Errorf("database is enabled but its driver or connection string is empty")
+		}
+	}
+
+	// Validate tracing configuration
+	if c.Tracing.Enabled {
+		if c.Tracing.Exporter != "otlp" && c.Tracing.Exporter != "stdout" {
+			return fmt.Errorf("invalid tracing exporter: %q (must be otlp or stdout)", c.Tracing.Exporter)
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			return fmt.Errorf("invalid tracing sample ratio: %v (must be between 0 and 1)", c.Tracing.SampleRatio)
+		}
+	}
+
+	return nil
+}
 
+// validate checks that enabled auth can let someone in, with keys naming
+// their users and known scopes.
+func (a AuthConfig) validate() error {
+	if !a.Enabled {
+		return nil
+	}
+	if len(a.APIKeys) == 0 && a.JWTSecret == "" {
+		return fmt.Errorf("invalid auth configuration: API keys or a JWT secret are required")
+	}
+	for _, key := range a.APIKeys {
+		if key.Key == "" || key.UserID == "" {
+			return fmt.Errorf("invalid API key: keys and user IDs are required")
+		}
+		for _, scope := range key.Scopes {
+			if scope != ScopeRead && scope != ScopePlay && scope != ScopeAdmin {
+				return fmt.Errorf("invalid scope %q of the API key of %s (must be read, play or admin)", scope, key.UserID)
+			}
+		}
+	}
 	return nil
 }
 
@@ -195,14 +553,30 @@ func (c *Config) HasValidLLMProvider(provider string) bool {
 		return false
 	}
 
-	// DeepSeek might work without API key in some configurations
-	if provider == "deepseek" {
+	// DeepSeek might work without API key in some configurations, and local Ollama
+	// models never need one
+	if provider == "deepseek" || provider == "ollama" {
 		return true
 	}
 
+	// Azure deployments are the customer's own, with no default to fall back on
+	if provider == "azure" {
+		return cfg.APIKey != "" && cfg.Endpoint != "" && cfg.Model != ""
+	}
+
 	return cfg.APIKey != ""
 }
 
+// IsAllowedLLMModel reports whether requests may use model with provider: its
+// configured model or one of its allowed models.
+func (c *Config) IsAllowedLLMModel(provider, model string) bool {
+	cfg, exists := c.GetLLMProviderConfig(provider)
+	if !exists {
+		return false
+	}
+	return model == cfg.Model || slices.Contains(cfg.AllowedModels, model)
+}
+
 // GetAvailableLLMProviders returns a list of providers with valid API keys.
 func (c *Config) GetAvailableLLMProviders() []string {
 	if !c.LLMAI.Enabled {
@@ -245,6 +619,15 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -254,11 +637,38 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getEnvAPIKeys parses comma-separated "user:key:scopes" entries, scopes
+// separated by "+", e.g. "alice:s3cret:read+play,ops:t0ken:admin".
+func getEnvAPIKeys(key string) []APIKey {
+	var keys []APIKey
+	for _, entry := range getEnvStringSlice(key, nil) {
+		parts := strings.SplitN(entry, ":", 3)
+		apiKey := APIKey{UserID: strings.TrimSpace(parts[0])}
+		if len(parts) > 1 {
+			apiKey.Key = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 {
+			for _, scope := range strings.Split(parts[2], "+") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					apiKey.Scopes = append(apiKey.Scopes, scope)
+				}
+			}
+		}
+		keys = append(keys, apiKey)
+	}
+	return keys
+}
+
 func getEnvStringSlice(key string, defaultValue []string) []string {
 	if value := os.Getenv(key); value != "" {
-		// Simple comma-separated parsing
-		// For a production app, you might want more sophisticated parsing
-		return []string{value}
+		// Comma-separated, e.g. "gpt-4o, gpt-4o-mini"
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		return values
 	}
 	return defaultValue
 }
@@ -14,6 +14,20 @@ func TestConfig_LLMAIProviderHelpers(t *testing.T) {
 	if !c.HasValidLLMProvider("deepseek") {
 		t.Errorf("expected deepseek valid without key")
 	}
+	if !c.HasValidLLMProvider("ollama") {
+		t.Errorf("expected ollama valid without key")
+	}
+	if p, _ := c.GetLLMProviderConfig("ollama"); p.Endpoint != "http://localhost:11434" {
+		t.Errorf("expected the local Ollama server by default, got %q", p.Endpoint)
+	}
+	c.LLMAI.Providers["azure"] = LLMProviderConfig{APIKey: "x", Model: "chess-gpt"}
+	if c.HasValidLLMProvider("azure") {
+		t.Errorf("expected azure invalid without an endpoint")
+	}
+	c.LLMAI.Providers["azure"] = LLMProviderConfig{APIKey: "x", Model: "chess-gpt", Endpoint: "https://contoso.openai.azure.com"}
+	if !c.HasValidLLMProvider("azure") {
+		t.Errorf("expected azure valid with a key, endpoint and deployment")
+	}
 	if _, ok := c.GetLLMProviderConfig("openai"); !ok {
 		t.Errorf("expected openai provider present")
 	}
@@ -28,3 +42,19 @@ func TestConfig_LLMAIProviderHelpers(t *testing.T) {
 		t.Errorf("expected deepseek in available providers: %v", provs)
 	}
 }
+
+func TestConfig_IsAllowedLLMModel(t *testing.T) {
+	t.Setenv("OPENAI_ALLOWED_MODELS", "gpt-4o, gpt-4o-mini,")
+	c := Default()
+	if got := c.LLMAI.Providers["openai"].AllowedModels; len(got) != 2 || got[1] != "gpt-4o-mini" {
+		t.Fatalf("expected a trimmed comma-separated list, got %q", got)
+	}
+	for model, want := range map[string]bool{"gpt-3.5-turbo": true, "gpt-4o": true, "gpt-4o-mini": true, "o1": false, "": false} {
+		if got := c.IsAllowedLLMModel("openai", model); got != want {
+			t.Errorf("IsAllowedLLMModel(openai, %q) = %v, want %v", model, got, want)
+		}
+	}
+	if c.IsAllowedLLMModel("nonexistent", "gpt-4o") {
+		t.Errorf("expected no models for an unknown provider")
+	}
+}
@@ -1,6 +1,9 @@
 package config
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 // Covers validation branch: LLMAI enabled but no default provider.
 func TestConfig_Validate_LLMAIEnabledNoProvider(t *testing.T) {
@@ -12,6 +15,98 @@ func TestConfig_Validate_LLMAIEnabledNoProvider(t *testing.T) {
 	}
 }
 
+// Covers validation branch: unknown opening repertoire.
+func TestConfig_Validate_UnknownRepertoire(t *testing.T) {
+	c := Default()
+	c.AI.Repertoire = "hypermodern"
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for unknown repertoire")
+	}
+}
+
+// Covers validation branch: non-positive target Elo.
+func TestConfig_Validate_InvalidTargetElo(t *testing.T) {
+	c := Default()
+	c.AI.DifficultyElo["easy"] = 0
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for a zero target Elo")
+	}
+}
+
+// Covers validation branch: negative resign score.
+func TestConfig_Validate_NegativeResignScore(t *testing.T) {
+	c := Default()
+	c.AI.ResignScore = -1
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for a negative resign score")
+	}
+}
+
+// Covers validation branch: no UCI engine processes for a configured binary.
+func TestConfig_Validate_NoUCIEngines(t *testing.T) {
+	c := Default()
+	c.AI.UCIPath = "/usr/local/bin/stockfish"
+	c.AI.UCIEngines = 0
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for zero UCI engines")
+	}
+}
+
+// Covers validation branch: non-positive search limit caps.
+func TestConfig_Validate_InvalidSearchCaps(t *testing.T) {
+	c := Default()
+	c.AI.MaxDepth = 0
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for a zero max depth")
+	}
+	c = Default()
+	c.AI.MaxNodes = -1
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for negative max nodes")
+	}
+}
+
+// Covers validation branch: LLM cache settings when LLMAI is enabled.
+func TestConfig_Validate_InvalidLLMCache(t *testing.T) {
+	c := Default()
+	c.LLMAI.Enabled = true
+	c.LLMAI.CacheTTL = -time.Minute
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for a negative LLM cache TTL")
+	}
+	c.LLMAI.CacheTTL = time.Hour
+	c.LLMAI.CacheSize = 0
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for an empty LLM cache")
+	}
+	c.LLMAI.CacheTTL = 0
+	if err := c.Validate(); err != nil {
+		t.Fatalf("expected a disabled LLM cache to need no size: %v", err)
+	}
+}
+
+// Covers validation branch: negative provider max tokens and timeout.
+func TestConfig_Validate_InvalidProviderLimits(t *testing.T) {
+	t.Setenv("GEMINI_MAX_TOKENS", "512")
+	t.Setenv("GEMINI_TIMEOUT", "45s")
+	c := Default()
+	if p := c.LLMAI.Providers["gemini"]; p.MaxTokens != 512 || p.Timeout != 45*time.Second {
+		t.Fatalf("expected the env limits, got %d tokens and %v", p.MaxTokens, p.Timeout)
+	}
+	c.LLMAI.Enabled = true
+	openai := c.LLMAI.Providers["openai"]
+	openai.MaxTokens = -1
+	c.LLMAI.Providers["openai"] = openai
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for negative max tokens")
+	}
+	openai.MaxTokens, openai.Timeout = 0, -time.Second
+	c.LLMAI.Providers["openai"] = openai
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for a negative timeout")
+	}
+}
+
 // Covers GetLLMProviderConfig negative lookup and HasValidLLMProvider false path.
 func TestConfig_LLMProviderLookupFailures(t *testing.T) {
 	c := Default()
@@ -28,3 +123,52 @@ func TestConfig_LLMProviderLookupFailures(t *testing.T) {
 		t.Fatalf("expected false when LLMAI disabled regardless of provider")
 	}
 }
+
+// Covers validation branch: negative chat limits.
+func TestConfig_Validate_InvalidChatLimits(t *testing.T) {
+	t.Setenv("CHESS_CHAT_USER_TOKENS_PER_DAY", "50000")
+	c := Default()
+	if limits := c.LLMAI.ChatLimits; limits.UserTokensPerDay != 50000 || limits.UserMessagesPerMinute != 20 {
+		t.Fatalf("expected the env and default limits, got %+v", limits)
+	}
+	c.LLMAI.ChatLimits.GameMessagesPerMinute = -1
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for a negative chat limit")
+	}
+}
+
+func TestConfig_Validate_InvalidChatHistoryBudget(t *testing.T) {
+	c := Default()
+	if c.LLMAI.ChatHistoryBudget != 4000 {
+		t.Fatalf("expected a 4000 character default, got %d", c.LLMAI.ChatHistoryBudget)
+	}
+	c.LLMAI.ChatHistoryBudget = -1
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for a negative chat history budget")
+	}
+}
+
+func TestConfig_Validate_InvalidModerationInputAction(t *testing.T) {
+	c := Default()
+	if c.LLMAI.Moderation.InputAction != "sanitize" {
+		t.Fatalf("expected input messages sanitized by default, got %q", c.LLMAI.Moderation.InputAction)
+	}
+	c.LLMAI.Moderation.InputAction = "ban"
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for an unknown input action")
+	}
+}
+
+// Covers validation branch: credentials allowed for any origin.
+func TestConfig_Validate_CORSCredentialsAnyOrigin(t *testing.T) {
+	c := Default()
+	c.Server.AllowedOrigins = []string{"https://chess.example.com", "*"}
+	c.Server.CORSCredentials = true
+	if err := c.Validate(); err == nil {
+		t.Fatalf("expected validation error for credentials with any origin")
+	}
+	c.Server.AllowedOrigins = []string{"https://chess.example.com"}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("expected credentials for listed origins to be valid, got %v", err)
+	}
+}
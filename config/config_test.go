@@ -81,6 +81,77 @@ func TestConfigWithEnvironmentVariables(t *testing.T) {
 			},
 			validate: func(c *Config) bool { return c.AI.DefaultDifficulty == "hard" },
 		},
+		{
+			name: "custom repertoire",
+			envVars: map[string]string{
+				"CHESS_AI_REPERTOIRE": "aggressive",
+			},
+			validate: func(c *Config) bool { return c.AI.Repertoire == "aggressive" },
+		},
+		{
+			name: "evaluation network",
+			envVars: map[string]string{
+				"CHESS_AI_EVAL_NETWORK": "/var/lib/chess/eval.nnue",
+			},
+			validate: func(c *Config) bool { return c.AI.EvalNetwork == "/var/lib/chess/eval.nnue" },
+		},
+		{
+			name: "AI resignation and draw offers",
+			envVars: map[string]string{
+				"CHESS_AI_RESIGN_SCORE": "0",
+				"CHESS_AI_DRAW_OFFERS":  "false",
+			},
+			validate: func(c *Config) bool { return c.AI.ResignScore == 0 && !c.AI.DrawOffers },
+		},
+		{
+			name:     "tablebase",
+			envVars:  map[string]string{"CHESS_AI_TABLEBASE_URL": "https://tablebase.lichess.ovh/standard"},
+			validate: func(c *Config) bool { return c.AI.TablebaseURL == "https://tablebase.lichess.ovh/standard" },
+		},
+		{
+			name: "AI search limit caps",
+			envVars: map[string]string{
+				"CHESS_AI_MAX_DEPTH": "6",
+				"CHESS_AI_MAX_NODES": "100000",
+			},
+			validate: func(c *Config) bool { return c.AI.MaxDepth == 6 && c.AI.MaxNodes == 100000 },
+		},
+		{
+			name: "LLM cache",
+			envVars: map[string]string{
+				"CHESS_LLMAI_CACHE_TTL":  "10m",
+				"CHESS_LLMAI_CACHE_SIZE": "500",
+			},
+			validate: func(c *Config) bool { return c.LLMAI.CacheTTL == 10*time.Minute && c.LLMAI.CacheSize == 500 },
+		},
+		{
+			name: "LLM exchange logging",
+			envVars: map[string]string{
+				"CHESS_LLMAI_LOG_EXCHANGES": "true",
+			},
+			validate: func(c *Config) bool { return c.LLMAI.LogExchanges },
+		},
+		{
+			name: "moderation word lists",
+			envVars: map[string]string{
+				"CHESS_MODERATION_BLOCKED_WORDS": "idiot,stupid",
+				"CHESS_MODERATION_MASKED_WORDS":  "damn",
+				"CHESS_MODERATION_ENDPOINT":      "https://api.openai.com/v1/moderations",
+			},
+			validate: func(c *Config) bool {
+				m := c.LLMAI.Moderation
+				return m.Enabled && len(m.BlockedWords) == 2 && m.MaskedWords[0] == "damn" && m.Endpoint != "" && m.Replacement != ""
+			},
+		},
+		{
+			name: "custom target Elo",
+			envVars: map[string]string{
+				"CHESS_AI_ELO_EXPERT": "2300",
+			},
+			validate: func(c *Config) bool {
+				return c.AI.DifficultyElo["expert"] == 2300 && c.AI.DifficultyElo["beginner"] == 800
+			},
+		},
 		{
 			name: "disable CORS",
 			envVars: map[string]string{
@@ -88,6 +159,40 @@ func TestConfigWithEnvironmentVariables(t *testing.T) {
 			},
 			validate: func(c *Config) bool { return !c.Server.CORSEnabled },
 		},
+		{
+			name: "custom response cache TTL",
+			envVars: map[string]string{
+				"CHESS_RESPONSE_CACHE_TTL": "30s",
+			},
+			validate: func(c *Config) bool { return c.Server.ResponseCacheTTL == 30*time.Second },
+		},
+		{
+			name: "replayed WebSocket events",
+			envVars: map[string]string{
+				"CHESS_REPLAY_EVENTS": "20",
+			},
+			validate: func(c *Config) bool { return c.Server.ReplayEvents == 20 },
+		},
+		{
+			name: "API keys",
+			envVars: map[string]string{
+				"CHESS_AUTH_ENABLED": "true",
+				"CHESS_API_KEYS":     "alice:s3cret:read+play, ops:t0ken:admin",
+			},
+			validate: func(c *Config) bool {
+				keys := c.Server.Auth.APIKeys
+				return c.Server.Auth.Enabled && len(keys) == 2 &&
+					keys[0].UserID == "alice" && keys[0].Key == "s3cret" && len(keys[0].Scopes) == 2 && keys[0].Scopes[1] == ScopePlay &&
+					keys[1].UserID == "ops" && keys[1].Scopes[0] == ScopeAdmin
+			},
+		},
+		{
+			name: "UUID game IDs",
+			envVars: map[string]string{
+				"CHESS_GAME_IDS": "uuid",
+			},
+			validate: func(c *Config) bool { return c.Server.GameIDs == GameIDsUUID },
+		},
 		{
 			name: "enable LLM AI",
 			envVars: map[string]string{
@@ -95,6 +200,17 @@ func TestConfigWithEnvironmentVariables(t *testing.T) {
 			},
 			validate: func(c *Config) bool { return c.LLMAI.Enabled },
 		},
+		{
+			name: "tracing",
+			envVars: map[string]string{
+				"CHESS_TRACING_ENABLED":      "true",
+				"CHESS_TRACING_EXPORTER":     "stdout",
+				"CHESS_TRACING_SAMPLE_RATIO": "0.25",
+			},
+			validate: func(c *Config) bool {
+				return c.Tracing.Enabled && c.Tracing.Exporter == "stdout" && c.Tracing.SampleRatio == 0.25 && c.Tracing.ServiceName == "go-chess"
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -223,6 +339,72 @@ func TestConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unknown game ID strategy",
+			config: func() *Config {
+				c := Default()
+				c.Server.GameIDs = "random"
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "auth without keys or a JWT secret",
+			config: func() *Config {
+				c := Default()
+				c.Server.Auth.Enabled = true
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "API key with an unknown scope",
+			config: func() *Config {
+				c := Default()
+				c.Server.Auth = AuthConfig{Enabled: true, APIKeys: []APIKey{{Key: "k", UserID: "alice", Scopes: []string{"write"}}}}
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "API key without a user",
+			config: func() *Config {
+				c := Default()
+				c.Server.Auth = AuthConfig{Enabled: true, APIKeys: []APIKey{{Key: "k", Scopes: []string{ScopeRead}}}}
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "moderation without a replacement",
+			config: func() *Config {
+				c := Default()
+				c.LLMAI.Enabled = true
+				c.LLMAI.Moderation.Replacement = ""
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown tracing exporter",
+			config: func() *Config {
+				c := Default()
+				c.Tracing.Enabled = true
+				c.Tracing.Exporter = "zipkin"
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "tracing sample ratio above 1",
+			config: func() *Config {
+				c := Default()
+				c.Tracing.Enabled = true
+				c.Tracing.SampleRatio = 2
+				return c
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1,6 +1,9 @@
 package engine
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 // These tests target uncovered branches: StartedFromFEN/StartingFEN flags,
 // Evaluate central bonus, GenerateSAN with promotions / captures / check,
@@ -64,6 +67,46 @@ func TestGame_GenerateSANWithFENStart(t *testing.T) {
 	}
 }
 
+func TestGame_SANLine(t *testing.T) {
+	g := NewGame()
+	playAll(t, g, "e2e4", "e7e5")
+	var line []Move
+	scratch := g.Clone()
+	for _, n := range []string{"g1f3", "b8c6", "f1b5"} {
+		playAll(t, scratch, n)
+		line = append(line, scratch.MoveHistory()[len(line)+2])
+	}
+	san, err := g.SANLine(line)
+	if err != nil {
+		t.Fatalf("SANLine: %v", err)
+	}
+	if strings.Join(san, " ") != "Nf3 Nc6 Bb5" {
+		t.Errorf("unexpected SAN line %v", san)
+	}
+	if len(g.MoveHistory()) != 2 {
+		t.Errorf("SANLine must not change the game")
+	}
+
+	// An illegal move stops the conversion
+	san, err = g.SANLine([]Move{line[0], line[0]})
+	if err == nil || len(san) != 1 {
+		t.Errorf("expected error after first move, got %v %v", san, err)
+	}
+}
+
+func TestGame_CloneKeepsStartingFEN(t *testing.T) {
+	g := NewGame()
+	fen := "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"
+	if err := g.ParseFEN(fen); err != nil {
+		t.Fatal(err)
+	}
+	playAll(t, g, "e2e4")
+	clone := g.Clone()
+	if !clone.StartedFromFEN() || clone.StartingFEN() != fen {
+		t.Errorf("expected the clone to start from %q, got %v %q", fen, clone.StartedFromFEN(), clone.StartingFEN())
+	}
+}
+
 func TestGame_CastlingDenials(t *testing.T) {
 	g := NewGame()
 	// Start from empty board and construct minimal pieces to test castling denial reasons
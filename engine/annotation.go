@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Annotation is commentary attached to a single move: a free-text comment and PGN
+// Numeric Annotation Glyphs ($1 good move, $2 mistake, $14 White is slightly better, ...).
+type Annotation struct {
+	Comment string
+	NAGs    []int
+}
+
+// IsZero reports whether the annotation carries neither a comment nor NAGs.
+func (a Annotation) IsZero() bool {
+	return a.Comment == "" && len(a.NAGs) == 0
+}
+
+// PGN renders the annotation as it follows a move in PGN movetext, e.g. "$2 {Loses a
+// pawn}". Closing braces in the comment are replaced since PGN comments cannot nest.
+func (a Annotation) PGN() string {
+	parts := make([]string, 0, len(a.NAGs)+1)
+	for _, nag := range a.NAGs {
+		parts = append(parts, "$"+strconv.Itoa(nag))
+	}
+	if a.Comment != "" {
+		parts = append(parts, "{"+strings.ReplaceAll(a.Comment, "}", ")")+"}")
+	}
+	return strings.Join(parts, " ")
+}
+
+// moveSuffixNAGs maps traditional SAN suffixes to their NAG equivalents.
+var moveSuffixNAGs = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+// AnnotateMove sets the comment and NAGs of a ply (0-based, aligned with
+// MoveHistory), replacing any earlier annotation. An empty comment with no NAGs
+// clears it. NAGs must be in the PGN range 0-255.
+func (g *Game) AnnotateMove(idx int, comment string, nags []int) error {
+	if idx < 0 || idx >= len(g.moveHistory) {
+		return fmt.Errorf("ply %d out of range", idx)
+	}
+	for _, nag := range nags {
+		if nag < 0 || nag > 255 {
+			return fmt.Errorf("invalid NAG %d", nag)
+		}
+	}
+	a := Annotation{Comment: strings.TrimSpace(comment)}
+	if len(nags) > 0 {
+		a.NAGs = append([]int(nil), nags...)
+	}
+	// Annotations are stored lazily so unannotated games (and search copies) pay nothing
+	if a.IsZero() && idx >= len(g.annotations) {
+		return nil
+	}
+	for len(g.annotations) <= idx {
+		g.annotations = append(g.annotations, Annotation{})
+	}
+	g.annotations[idx] = a
+	return nil
+}
+
+// MoveAnnotation returns the annotation of a ply, or the zero Annotation if it has none.
+func (g *Game) MoveAnnotation(idx int) Annotation {
+	if idx < 0 || idx >= len(g.annotations) {
+		return Annotation{}
+	}
+	a := g.annotations[idx]
+	a.NAGs = append([]int(nil), a.NAGs...)
+	return a
+}
+
+// Annotations returns per-ply annotations aligned with MoveHistory. Entries are
+// zero for moves without commentary.
+func (g *Game) Annotations() []Annotation {
+	annotations := make([]Annotation, len(g.moveHistory))
+	for i := range annotations {
+		annotations[i] = g.MoveAnnotation(i)
+	}
+	return annotations
+}
+
+// addAnnotation merges imported commentary into a ply's existing annotation.
+func (g *Game) addAnnotation(idx int, comment string, nags ...int) {
+	a := g.MoveAnnotation(idx)
+	if comment = strings.TrimSpace(comment); comment != "" {
+		if a.Comment != "" {
+			a.Comment += " "
+		}
+		a.Comment += comment
+	}
+	_ = g.AnnotateMove(idx, a.Comment, append(a.NAGs, nags...))
+}
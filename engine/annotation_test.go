@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnnotateMove(t *testing.T) {
+	g := NewGame()
+	playAll(t, g, "e2e4", "e7e5", "g1f3")
+
+	if err := g.AnnotateMove(1, "Symmetrical reply", []int{1}); err != nil {
+		t.Fatalf("AnnotateMove: %v", err)
+	}
+	if err := g.AnnotateMove(3, "no such ply", nil); err == nil {
+		t.Fatalf("expected out of range error")
+	}
+	if err := g.AnnotateMove(0, "", []int{256}); err == nil {
+		t.Fatalf("expected invalid NAG error")
+	}
+
+	annotations := g.Annotations()
+	if len(annotations) != 3 {
+		t.Fatalf("expected annotations aligned with history, got %d", len(annotations))
+	}
+	if !annotations[0].IsZero() || !annotations[2].IsZero() {
+		t.Fatalf("expected unannotated plies to be zero: %+v", annotations)
+	}
+	if got := annotations[1].PGN(); got != "$1 {Symmetrical reply}" {
+		t.Fatalf("unexpected PGN %q", got)
+	}
+
+	// Clearing and undo
+	if err := g.AnnotateMove(1, "", nil); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if !g.MoveAnnotation(1).IsZero() {
+		t.Fatalf("expected annotation cleared")
+	}
+	if err := g.AnnotateMove(2, "Developing", nil); err != nil {
+		t.Fatalf("AnnotateMove: %v", err)
+	}
+	if _, err := g.UndoMove(); err != nil {
+		t.Fatalf("UndoMove: %v", err)
+	}
+	playAll(t, g, "b1c3")
+	if !g.MoveAnnotation(2).IsZero() {
+		t.Fatalf("expected undone move's annotation to be dropped")
+	}
+}
+
+func TestAnnotationPGNEscapesBraces(t *testing.T) {
+	a := Annotation{Comment: "see {note}"}
+	if got := a.PGN(); got != "{see {note)}" {
+		t.Fatalf("unexpected PGN %q", got)
+	}
+}
+
+func TestParsePGNAnnotations(t *testing.T) {
+	pgn := `1. e4! {Best by test} e5 $2 {[%clk 0:02:59] Weak} 2. Nf3?! (2. f4 {ignored}) *`
+	imported, err := ParsePGN(pgn)
+	if err != nil {
+		t.Fatalf("ParsePGN: %v", err)
+	}
+	want := []Annotation{
+		{Comment: "Best by test", NAGs: []int{1}},
+		{Comment: "Weak", NAGs: []int{2}},
+		{NAGs: []int{6}},
+	}
+	if got := imported.Game.Annotations(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("annotations = %+v, want %+v", got, want)
+	}
+	if !imported.Game.MoveTimings()[1].HasClock {
+		t.Fatalf("expected clock to be kept alongside the comment")
+	}
+}
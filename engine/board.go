@@ -34,6 +34,18 @@ func (c Color) String() string {
 	}
 }
 
+// Opposite returns the other player's color; None stays None.
+func (c Color) Opposite() Color {
+	switch c {
+	case White:
+		return Black
+	case Black:
+		return White
+	default:
+		return None
+	}
+}
+
 // PieceType represents the type of a chess piece.
 type PieceType int
 
@@ -225,6 +237,15 @@ func (s Square) Rank() int {
 	return int(s / 8)
 }
 
+// Relative returns the square as seen from the given side: unchanged for White and
+// mirrored across the board's middle rank for Black, so e.g. E1.Relative(Black) is E8.
+func (s Square) Relative(color Color) Square {
+	if color == Black {
+		return s ^ 56
+	}
+	return s
+}
+
 // Board represents a chess board with piece positions.
 type Board struct {
 	squares [64]Piece
@@ -290,24 +311,13 @@ func (b *Board) SetPiece(sq Square, piece Piece) {
 
 // String returns a string representation of the board.
 func (b *Board) String() string {
-	var sb strings.Builder
-
-	sb.WriteString("  a b c d e f g h\n")
-
-	for rank := 7; rank >= 0; rank-- {
-		sb.WriteString(fmt.Sprintf("%d ", rank+1))
-		for file := 0; file < 8; file++ {
-			square := Square(rank*8 + file)
-			piece := b.GetPiece(square)
-			sb.WriteString(piece.String())
-			sb.WriteString(" ")
-		}
-		sb.WriteString(fmt.Sprintf("%d\n", rank+1))
-	}
-
-	sb.WriteString("  a b c d e f g h\n")
+	return NewBoardRenderer(RenderOptions{Coordinates: true}).Render(b)
+}
 
-	return sb.String()
+// StringFlipped returns the board from Black's point of view, with rank 1 at the
+// top and the h-file on the left.
+func (b *Board) StringFlipped() string {
+	return NewBoardRenderer(RenderOptions{Coordinates: true, Flipped: true}).Render(b)
 }
 
 // Copy returns a deep copy of the board.
@@ -316,3 +326,27 @@ func (b *Board) Copy() *Board {
 	copy(newBoard.squares[:], b.squares[:])
 	return newBoard
 }
+
+// Mirror returns a copy of the board reflected left to right (the a-file becomes the
+// h-file). Piece colors are unchanged.
+func (b *Board) Mirror() *Board {
+	newBoard := &Board{}
+	for sq, piece := range b.squares {
+		newBoard.squares[sq^7] = piece
+	}
+	return newBoard
+}
+
+// Flip returns a copy of the board reflected top to bottom with piece colors swapped,
+// i.e. the same position with the sides exchanged. A symmetric evaluation of the
+// flipped board (with the other side to move) is the negation of the original.
+func (b *Board) Flip() *Board {
+	newBoard := &Board{}
+	for sq, piece := range b.squares {
+		if !piece.IsEmpty() {
+			piece.Color = piece.Color.Opposite()
+		}
+		newBoard.squares[sq^56] = piece
+	}
+	return newBoard
+}
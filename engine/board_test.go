@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -134,3 +135,42 @@ func BenchmarkBoardCopy(b *testing.B) {
 		_ = board.Copy()
 	}
 }
+
+func TestSquareRelative(t *testing.T) {
+	if E1.Relative(White) != E1 || E1.Relative(Black) != E8 || A7.Relative(Black) != A2 {
+		t.Error("unexpected relative squares")
+	}
+}
+
+func TestBoardMirrorAndFlip(t *testing.T) {
+	b := &Board{}
+	b.SetPiece(A1, Piece{Type: Rook, Color: White})
+	b.SetPiece(G8, Piece{Type: King, Color: Black})
+
+	m := b.Mirror()
+	if m.GetPiece(H1) != (Piece{Type: Rook, Color: White}) || m.GetPiece(B8) != (Piece{Type: King, Color: Black}) || !m.GetPiece(A1).IsEmpty() {
+		t.Errorf("unexpected mirrored board:\n%s", m)
+	}
+
+	f := b.Flip()
+	if f.GetPiece(A8) != (Piece{Type: Rook, Color: Black}) || f.GetPiece(G1) != (Piece{Type: King, Color: White}) || !f.GetPiece(A1).IsEmpty() {
+		t.Errorf("unexpected flipped board:\n%s", f)
+	}
+	if b.GetPiece(A1).Color != White {
+		t.Error("Flip must not modify the original board")
+	}
+
+	// The starting position is symmetric under both operations
+	start := NewBoard()
+	if start.Flip().String() != start.String() {
+		t.Error("flipped starting position should be identical")
+	}
+}
+
+func TestBoardStringFlipped(t *testing.T) {
+	got := NewBoard().StringFlipped()
+	lines := strings.Split(got, "\n")
+	if lines[0] != "  h g f e d c b a" || !strings.HasPrefix(lines[1], "1 R N B K Q B N R") || !strings.HasPrefix(lines[8], "8 r n b k q b n r") {
+		t.Errorf("unexpected flipped rendering:\n%s", got)
+	}
+}
@@ -0,0 +1,190 @@
+package engine
+
+// Castling is tracked as a small state machine per right (White/Black × kingside/
+// queenside). A right is available while the king and its castling rook have not
+// moved, and is lost for good when:
+//   - the king moves, including by castling (both rights of that side),
+//   - the rook leaves its origin square, or
+//   - a piece lands on the rook's origin square, i.e. the rook is captured there.
+//
+// While a right is available, castling is legal only if every square between the king
+// and the rook, and both destination squares, are empty, the king is not in check, and
+// no square the king crosses or lands on is attacked. The same rules cover Chess960
+// set-ups, where the king and rooks may start on any file: the king always ends on the
+// g- or c-file and the rook next to it on the f- or d-file.
+
+// standardCastlingRooks are the rook origin squares of the standard starting
+// position, in K, Q, k, q order.
+var standardCastlingRooks = [4]Square{H1, A1, H8, A8}
+
+// castlingPlan holds the squares involved in one castling move.
+type castlingPlan struct {
+	kingFrom, kingTo Square
+	rookFrom, rookTo Square
+}
+
+// castlingIndex returns the index of a castling right in K, Q, k, q order.
+func castlingIndex(color Color, kingside bool) int {
+	i := sideIndex(color) * 2
+	if !kingside {
+		i++
+	}
+	return i
+}
+
+// right returns the flag of the castling right with the given index.
+func (cr *CastlingRights) right(i int) *bool {
+	switch i {
+	case 0:
+		return &cr.WhiteKingside
+	case 1:
+		return &cr.WhiteQueenside
+	case 2:
+		return &cr.BlackKingside
+	default:
+		return &cr.BlackQueenside
+	}
+}
+
+// isKingsideCastling reports whether a castling move goes to the kingside; the king
+// always lands on the g-file there.
+func (m Move) isKingsideCastling() bool {
+	return m.To.File() == 6
+}
+
+// castlingPlan returns the squares for castling to the given side, or false if the
+// king is not on its back rank.
+func (g *Game) castlingPlan(color Color, kingside bool) (castlingPlan, bool) {
+	rank := 0
+	if color == Black {
+		rank = 7
+	}
+	kingFrom := g.board.kingSquare(color)
+	if kingFrom == -1 || kingFrom.Rank() != rank {
+		return castlingPlan{}, false
+	}
+	plan := castlingPlan{
+		kingFrom: kingFrom,
+		kingTo:   Square(rank*8 + 6),
+		rookFrom: g.castlingRooks[castlingIndex(color, kingside)],
+		rookTo:   Square(rank*8 + 5),
+	}
+	if !kingside {
+		plan.kingTo, plan.rookTo = Square(rank*8+2), Square(rank*8+3)
+	}
+	return plan, true
+}
+
+// castlingStatus runs the castling checks in order and returns the first one that
+// fails, or zero if castling to the given side is legal.
+func (g *Game) castlingStatus(color Color, kingside bool) IllegalMoveReason {
+	if !*g.castlingRights.right(castlingIndex(color, kingside)) {
+		return IllegalCastlingRights
+	}
+	plan, ok := g.castlingPlan(color, kingside)
+	if !ok {
+		return IllegalCastlingRights
+	}
+	king := g.board.GetPiece(plan.kingFrom)
+	rook := g.board.GetPiece(plan.rookFrom)
+	if rook.Type != Rook || rook.Color != color || plan.rookFrom.Rank() != plan.kingFrom.Rank() ||
+		(plan.rookFrom > plan.kingFrom) != kingside {
+		return IllegalCastlingRights // the rook is not where the right expects it
+	}
+
+	// Everything between and including the four squares must be empty, except the
+	// king and the castling rook themselves
+	lo, hi := plan.kingFrom, plan.kingFrom
+	for _, sq := range [3]Square{plan.kingTo, plan.rookFrom, plan.rookTo} {
+		if sq < lo {
+			lo = sq
+		}
+		if sq > hi {
+			hi = sq
+		}
+	}
+	for sq := lo; sq <= hi; sq++ {
+		if sq != plan.kingFrom && sq != plan.rookFrom && !g.board.GetPiece(sq).IsEmpty() {
+			return IllegalCastlingBlocked
+		}
+	}
+
+	opponent := color.Opposite()
+	if g.board.isAttacked(plan.kingFrom, opponent) {
+		return IllegalCastlingInCheck
+	}
+	// The squares the king crosses must be safe with the king and rook lifted off the
+	// board, and the destination must be safe once the rook has moved too
+	board := *g.board
+	board.SetPiece(plan.kingFrom, Piece{Type: Empty})
+	board.SetPiece(plan.rookFrom, Piece{Type: Empty})
+	step := Square(sign(int(plan.kingTo) - int(plan.kingFrom)))
+	for sq := plan.kingFrom; sq != plan.kingTo; {
+		sq += step
+		if board.isAttacked(sq, opponent) {
+			return IllegalCastlingThroughCheck
+		}
+	}
+	board.SetPiece(plan.rookTo, rook)
+	board.SetPiece(plan.kingTo, king)
+	if board.isAttacked(plan.kingTo, opponent) {
+		return IllegalCastlingThroughCheck
+	}
+	return 0
+}
+
+// canCastleKingside checks if kingside castling is possible for the given color
+func (g *Game) canCastleKingside(color Color) bool {
+	return g.castlingStatus(color, true) == 0
+}
+
+// canCastleQueenside checks if queenside castling is possible for the given color
+func (g *Game) canCastleQueenside(color Color) bool {
+	return g.castlingStatus(color, false) == 0
+}
+
+// applyCastling moves the king and the castling rook of a castling move on b. Both
+// are lifted first, since in Chess960 either may land on the other's origin square.
+func (g *Game) applyCastling(b *Board, move Move) {
+	plan, ok := g.castlingPlan(move.Piece.Color, move.isKingsideCastling())
+	if !ok {
+		return
+	}
+	rook := b.GetPiece(plan.rookFrom)
+	b.SetPiece(plan.kingFrom, Piece{Type: Empty})
+	b.SetPiece(plan.rookFrom, Piece{Type: Empty})
+	b.SetPiece(plan.rookTo, rook)
+	b.SetPiece(plan.kingTo, move.Piece)
+}
+
+// updateCastlingRights applies the castling state transitions for a move that is
+// about to be, or has just been, played: a king move loses both of its side's rights,
+// and a move from or onto a castling rook's origin square loses that right.
+func (g *Game) updateCastlingRights(move Move) {
+	for i, rookSquare := range g.castlingRooks {
+		color := White
+		if i >= 2 {
+			color = Black
+		}
+		kingMoved := move.Piece.Type == King && move.Piece.Color == color
+		if kingMoved || move.From == rookSquare || move.To == rookSquare {
+			*g.castlingRights.right(i) = false
+		}
+	}
+}
+
+// castlingRookFor returns whether the move is a king taking its own castling rook,
+// the Chess960 way of writing castling (e.g. "e1h1"), and the side it castles to.
+func (g *Game) castlingRookFor(move Move) (kingside, ok bool) {
+	if move.Piece.Type != King {
+		return false, false
+	}
+	for _, kingside := range [2]bool{true, false} {
+		i := castlingIndex(move.Piece.Color, kingside)
+		if *g.castlingRights.right(i) && g.castlingRooks[i] == move.To {
+			target := g.board.GetPiece(move.To)
+			return kingside, target.Type == Rook && target.Color == move.Piece.Color
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseCastlingField parses the FEN castling field. Besides the classic "KQkq" form it
+// accepts Shredder-FEN / X-FEN file letters ("HAha", "Kq", "Bg"), where a letter names
+// the file of the castling rook, so Chess960 set-ups can be loaded. Classic letters
+// refer to the outermost rook on that side of the king. Rights naming a file without a
+// rook on the king's back rank are dropped rather than rejected. The second result
+// holds the castling rook squares; the third reports whether file letters were used.
+func (g *Game) parseCastlingField(field string) (CastlingRights, [4]Square, bool, error) {
+	var rights CastlingRights
+	rooks := standardCastlingRooks
+	if field == "-" {
+		return rights, rooks, false, nil
+	}
+	shredder := false
+	for _, ch := range field {
+		switch {
+		case ch == 'K':
+			g.setClassicCastling(&rights, &rooks, White, true)
+		case ch == 'Q':
+			g.setClassicCastling(&rights, &rooks, White, false)
+		case ch == 'k':
+			g.setClassicCastling(&rights, &rooks, Black, true)
+		case ch == 'q':
+			g.setClassicCastling(&rights, &rooks, Black, false)
+		case ch >= 'A' && ch <= 'H':
+			shredder = true
+			g.setCastlingFromFile(&rights, &rooks, White, int(ch-'A'))
+		case ch >= 'a' && ch <= 'h':
+			shredder = true
+			g.setCastlingFromFile(&rights, &rooks, Black, int(ch-'a'))
+		default:
+			return CastlingRights{}, standardCastlingRooks, false, fmt.Errorf("invalid castling char: %c", ch)
+		}
+	}
+	return rights, rooks, shredder, nil
+}
+
+// setClassicCastling grants a "KQkq" right, using the outermost rook on that side of
+// the king. Without a king and rook on the back rank the standard squares are assumed.
+func (g *Game) setClassicCastling(rights *CastlingRights, rooks *[4]Square, color Color, kingside bool) {
+	i := castlingIndex(color, kingside)
+	*rights.right(i) = true
+	rank := 0
+	if color == Black {
+		rank = 7
+	}
+	king := g.board.kingSquare(color)
+	if king == -1 || king.Rank() != rank {
+		return
+	}
+	files := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	if kingside {
+		files = []int{7, 6, 5, 4, 3, 2, 1, 0}
+	}
+	for _, file := range files {
+		if file == king.File() {
+			return
+		}
+		if p := g.board.GetPiece(Square(rank*8 + file)); p.Type == Rook && p.Color == color {
+			rooks[i] = Square(rank*8 + file)
+			return
+		}
+	}
+}
+
+// setCastlingFromFile grants the right matching a rook file if the king and a rook of
+// that color stand on their back rank, with the rook on the given file.
+func (g *Game) setCastlingFromFile(rights *CastlingRights, rooks *[4]Square, color Color, file int) {
+	rank := 0
+	if color == Black {
+		rank = 7
+	}
+	king := g.board.kingSquare(color)
+	rook := Square(rank*8 + file)
+	if king == -1 || king.Rank() != rank || king == rook {
+		return
+	}
+	if p := g.board.GetPiece(rook); p.Type != Rook || p.Color != color {
+		return
+	}
+	i := castlingIndex(color, file > king.File())
+	*rights.right(i) = true
+	rooks[i] = rook
+}
+
+// castlingToFEN renders castling rights as "KQkq" or, in Shredder form, "HAha". Rights
+// for a rook outside the corner (Chess960) are always written as file letters.
+func (g *Game) castlingToFEN(shredder bool) string {
+	var sb strings.Builder
+	for i, classic := range "KQkq" {
+		if !*g.castlingRights.right(i) {
+			continue
+		}
+		rook := g.castlingRooks[i]
+		if shredder || rook != standardCastlingRooks[i] {
+			letter := byte('A' + rook.File())
+			if i >= 2 {
+				letter = byte('a' + rook.File())
+			}
+			sb.WriteByte(letter)
+		} else {
+			sb.WriteRune(classic)
+		}
+	}
+	if sb.Len() == 0 {
+		return "-"
+	}
+	return sb.String()
+}
+
+// ToShredderFEN returns the position in Shredder-FEN, i.e. with castling rights given
+// as rook files ("HAha") instead of "KQkq".
+func (g *Game) ToShredderFEN() string {
+	fields := strings.Fields(g.ToFEN())
+	fields[2] = g.castlingToFEN(true)
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,52 @@
+package engine
+
+import "testing"
+
+func TestParseShredderFENCastling(t *testing.T) {
+	g := NewGame()
+	shredder := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1"
+	if err := g.ParseFEN(shredder); err != nil {
+		t.Fatalf("parse Shredder-FEN: %v", err)
+	}
+	want := CastlingRights{WhiteKingside: true, WhiteQueenside: true, BlackKingside: true, BlackQueenside: true}
+	if g.castlingRights != want {
+		t.Fatalf("unexpected castling rights %+v", g.castlingRights)
+	}
+	if g.ToFEN() != shredder {
+		t.Fatalf("expected Shredder-FEN preserved, got %s", g.ToFEN())
+	}
+
+	// Castling still works and updates the file-letter field
+	playAll(t, g, "e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "g8f6", "O-O")
+	if got := g.ToFEN(); got != "r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQ1RK1 b ha - 5 4" {
+		t.Fatalf("unexpected FEN after castling: %s", got)
+	}
+}
+
+func TestParseXFENMixedCastling(t *testing.T) {
+	g := NewGame()
+	if err := g.ParseFEN("r3k2r/8/8/8/8/8/8/R3K2R w Hq - 0 1"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	want := CastlingRights{WhiteKingside: true, BlackQueenside: true}
+	if g.castlingRights != want {
+		t.Fatalf("unexpected castling rights %+v", g.castlingRights)
+	}
+	if got := NewGame().ToShredderFEN(); got != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1" {
+		t.Fatalf("unexpected Shredder-FEN %s", got)
+	}
+
+	// Chess960 rook files are kept; rights naming a file without a rook are dropped
+	if err := g.ParseFEN("1r2k3/8/8/8/8/8/8/4K2R w Hbc - 0 1"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	if g.castlingRights != (CastlingRights{WhiteKingside: true, BlackQueenside: true}) || g.castlingRooks[3] != B8 {
+		t.Fatalf("unexpected castling rights %+v", g.castlingRights)
+	}
+	if got := g.ToFEN(); got != "1r2k3/8/8/8/8/8/8/4K2R w Hb - 0 1" {
+		t.Fatalf("unexpected FEN %s", got)
+	}
+	if err := g.ParseFEN("4k3/8/8/8/8/8/8/4K3 w X - 0 1"); err == nil {
+		t.Fatal("expected error for invalid castling char")
+	}
+}
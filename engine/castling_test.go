@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCastlingStateMachine(t *testing.T) {
+	cases := []struct {
+		name   string
+		fen    string
+		played []string // moves played before castling
+		move   string
+		want   IllegalMoveReason // zero means legal
+		after  string            // expected FEN after a legal castling move
+	}{
+		{name: "kingside", fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", move: "O-O",
+			after: "r3k2r/8/8/8/8/8/8/R4RK1 b kq - 1 1"},
+		{name: "queenside", fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", move: "e1c1",
+			after: "r3k2r/8/8/8/8/8/8/2KR3R b kq - 1 1"},
+		{name: "black queenside", fen: "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", move: "O-O-O",
+			after: "2kr3r/8/8/8/8/8/8/R3K2R w KQ - 1 2"},
+		{name: "no right", fen: "r3k2r/8/8/8/8/8/8/R3K2R w Qkq - 0 1", move: "O-O", want: IllegalCastlingRights},
+		{name: "king moved and returned", fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			played: []string{"e1f1", "e8f8", "f1e1", "f8e8"}, move: "O-O", want: IllegalCastlingRights},
+		{name: "rook moved and returned", fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			played: []string{"h1h2", "a8b8", "h2h1", "b8a8"}, move: "O-O", want: IllegalCastlingRights},
+		{name: "rook captured on origin square", fen: "r3k2r/8/8/8/8/8/1B6/R3K2R w KQkq - 0 1",
+			played: []string{"b2h8", "a8a7", "h8b2", "a7h7", "e1e2", "h7h8", "e2e1"}, move: "O-O", want: IllegalCastlingRights},
+		{name: "own rook captured on origin square", fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			played: []string{"a1a8"}, move: "O-O-O", want: IllegalCastlingRights},
+		{name: "rights without a rook", fen: "4k3/p7/8/8/8/8/8/4K3 w K - 0 1", move: "O-O", want: IllegalCastlingRights},
+		{name: "blocked", fen: "4k3/8/8/8/8/8/8/4KB1R w K - 0 1", move: "O-O", want: IllegalCastlingBlocked},
+		{name: "b-file blocked", fen: "4k3/8/8/8/8/8/8/RN2K3 w Q - 0 1", move: "O-O-O", want: IllegalCastlingBlocked},
+		{name: "in check", fen: "4k3/4r3/8/8/8/8/8/4K2R w K - 0 1", move: "O-O", want: IllegalCastlingInCheck},
+		{name: "through check", fen: "4k3/8/8/8/8/4n3/8/4K2R w K - 0 1", move: "O-O", want: IllegalCastlingThroughCheck},
+		{name: "into check", fen: "4k3/6r1/8/8/8/8/8/4K2R w K - 0 1", move: "O-O", want: IllegalCastlingThroughCheck},
+		{name: "attacked b-file is fine", fen: "4k3/1r6/8/8/8/8/8/R3K3 w Q - 0 1", move: "O-O-O",
+			after: "4k3/1r6/8/8/8/8/8/2KR4 b - - 1 1"},
+		{name: "attacked rook is fine", fen: "4k3/7r/8/8/8/8/8/4K2R w K - 0 1", move: "O-O",
+			after: "4k3/7r/8/8/8/8/8/5RK1 b - - 1 1"},
+		{name: "chess960 king b1 queenside", fen: "4k2r/8/8/8/8/8/8/RK6 w Ah - 0 1", move: "O-O-O",
+			after: "4k2r/8/8/8/8/8/8/2KR4 b h - 1 1"},
+		{name: "chess960 king takes rook", fen: "4k2r/8/8/8/8/8/8/RK6 w Ah - 0 1", move: "b1a1",
+			after: "4k2r/8/8/8/8/8/8/2KR4 b h - 1 1"},
+		{name: "chess960 king and rook swap", fen: "4k3/8/8/8/8/8/8/5KR1 w G - 0 1", move: "O-O",
+			after: "4k3/8/8/8/8/8/8/5RK1 b - - 1 1"},
+		{name: "chess960 king already on g-file", fen: "4k3/8/8/8/8/8/8/6KR w H - 0 1", move: "O-O",
+			after: "4k3/8/8/8/8/8/8/5RK1 b - - 1 1"},
+		{name: "chess960 rook destination occupied", fen: "4k3/8/8/8/8/8/8/1K1N3R w H - 0 1", move: "O-O", want: IllegalCastlingBlocked},
+		{name: "chess960 king path attacked", fen: "4k3/8/8/8/8/8/4r3/1K5R w H - 0 1", move: "O-O", want: IllegalCastlingThroughCheck},
+		{name: "chess960 black kingside", fen: "1k4r1/8/8/8/8/8/8/4K3 b g - 0 1", move: "O-O",
+			after: "5rk1/8/8/8/8/8/8/4K3 w - - 1 2"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGame()
+			if err := g.ParseFEN(tc.fen); err != nil {
+				t.Fatal(err)
+			}
+			playAll(t, g, tc.played...)
+			move, err := g.ParseMove(tc.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if move.Type != Castling {
+				t.Fatalf("%s parsed as %v, want castling", tc.move, move.Type)
+			}
+
+			err = g.ExplainIllegalMove(move)
+			var illegal *IllegalMoveError
+			switch {
+			case tc.want == 0 && err != nil:
+				t.Fatalf("expected legal castling, got %v", err)
+			case tc.want != 0 && (!errors.As(err, &illegal) || illegal.Reason != tc.want):
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+
+			generated := false
+			for _, m := range g.GetAllLegalMoves() {
+				if m.Type == Castling && m == move {
+					generated = true
+				}
+			}
+			if generated != (tc.want == 0) {
+				t.Fatalf("castling generated = %v, want %v", generated, tc.want == 0)
+			}
+
+			if tc.want == 0 {
+				if err := g.MakeMove(move); err != nil {
+					t.Fatal(err)
+				}
+				if got := g.ToFEN(); got != tc.after {
+					t.Errorf("FEN after castling = %s, want %s", got, tc.after)
+				}
+			}
+		})
+	}
+}
+
+func TestPerftCastlingPosition(t *testing.T) {
+	if testing.Short() {
+		t.Skip("perft is slow")
+	}
+	g := NewGame()
+	if err := g.ParseFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	for depth, want := range []int{1, 26, 568, 13744} {
+		if got := perft(g, depth); got != want {
+			t.Errorf("perft(%d) = %d, want %d", depth, got, want)
+		}
+	}
+}
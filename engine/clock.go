@@ -0,0 +1,306 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeControl describes a base time per player plus a per-move increment.
+type TimeControl struct {
+	Base      time.Duration
+	Increment time.Duration
+}
+
+// ParseTimeControl parses the PGN TimeControl form "seconds[+increment]", e.g. "300+3".
+func ParseTimeControl(s string) (TimeControl, error) {
+	s = strings.TrimSpace(s)
+	basePart, incPart, hasInc := strings.Cut(s, "+")
+	base, err := strconv.ParseFloat(basePart, 64)
+	if err != nil || base <= 0 {
+		return TimeControl{}, fmt.Errorf("invalid time control: %q", s)
+	}
+	tc := TimeControl{Base: time.Duration(base * float64(time.Second))}
+	if hasInc {
+		inc, err := strconv.ParseFloat(incPart, 64)
+		if err != nil || inc < 0 {
+			return TimeControl{}, fmt.Errorf("invalid time control increment: %q", s)
+		}
+		tc.Increment = time.Duration(inc * float64(time.Second))
+	}
+	return tc, nil
+}
+
+// String returns the PGN TimeControl tag value ("300+3", or "300" without increment).
+func (tc TimeControl) String() string {
+	base := strconv.FormatFloat(tc.Base.Seconds(), 'f', -1, 64)
+	if tc.Increment == 0 {
+		return base
+	}
+	return base + "+" + strconv.FormatFloat(tc.Increment.Seconds(), 'f', -1, 64)
+}
+
+// ClockNotation formats and parses clock durations used in move comments.
+type ClockNotation interface {
+	Format(d time.Duration) string
+	Parse(s string) (time.Duration, error)
+}
+
+// PGNClockNotation is the H:MM:SS[.f] notation used by the %clk and %emt commands.
+// Tenths of a second are only written when non-zero.
+type PGNClockNotation struct{}
+
+// Format renders d as H:MM:SS or H:MM:SS.f.
+func (PGNClockNotation) Format(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	tenths := int64(d / (100 * time.Millisecond))
+	secs := tenths / 10
+	s := fmt.Sprintf("%d:%02d:%02d", secs/3600, (secs/60)%60, secs%60)
+	if t := tenths % 10; t != 0 {
+		s += fmt.Sprintf(".%d", t)
+	}
+	return s
+}
+
+// Parse accepts H:MM:SS, MM:SS or SS, each with an optional fractional second.
+func (PGNClockNotation) Parse(s string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 || parts[0] == "" {
+		return 0, fmt.Errorf("invalid clock value: %q", s)
+	}
+	var total float64
+	for i, p := range parts {
+		isSeconds := i == len(parts)-1
+		var v float64
+		var err error
+		if isSeconds {
+			v, err = strconv.ParseFloat(p, 64)
+		} else {
+			var n int
+			n, err = strconv.Atoi(p)
+			v = float64(n)
+		}
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid clock value: %q", s)
+		}
+		total = total*60 + v
+	}
+	return time.Duration(total * float64(time.Second)), nil
+}
+
+// DefaultClockNotation is used for PGN export and import.
+var DefaultClockNotation ClockNotation = PGNClockNotation{}
+
+// MoveTiming records clock data for a single ply.
+type MoveTiming struct {
+	Clock      time.Duration // remaining time of the mover after the move
+	Elapsed    time.Duration // time spent on the move
+	HasClock   bool
+	HasElapsed bool
+	PlayedAt   time.Time // when the move was played; zero if not recorded
+}
+
+// pgnTimestampLayout is the layout of the [%ts] command's timestamps.
+const pgnTimestampLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// IsZero reports whether no timing data was recorded.
+func (t MoveTiming) IsZero() bool {
+	return !t.HasClock && !t.HasElapsed && t.PlayedAt.IsZero()
+}
+
+// PGNComment renders the timing as PGN commands, e.g. "[%clk 0:05:03] [%emt 0:00:04]",
+// followed by "[%ts 2025-01-02T15:04:05.000Z]" when the move's time was recorded.
+// It returns an empty string when no timing data was recorded.
+func (t MoveTiming) PGNComment(n ClockNotation) string {
+	if n == nil {
+		n = DefaultClockNotation
+	}
+	var cmds []string
+	if t.HasClock {
+		cmds = append(cmds, "[%clk "+n.Format(t.Clock)+"]")
+	}
+	if t.HasElapsed {
+		cmds = append(cmds, "[%emt "+n.Format(t.Elapsed)+"]")
+	}
+	if !t.PlayedAt.IsZero() {
+		cmds = append(cmds, "[%ts "+t.PlayedAt.UTC().Format(pgnTimestampLayout)+"]")
+	}
+	return strings.Join(cmds, " ")
+}
+
+var clockCommandPattern = regexp.MustCompile(`\[%(clk|emt|ts)\s+([^\]\s]+)\s*\]`)
+
+// ParseMoveTiming extracts %clk, %emt and %ts commands from a PGN comment body.
+func ParseMoveTiming(comment string, n ClockNotation) (MoveTiming, error) {
+	if n == nil {
+		n = DefaultClockNotation
+	}
+	var t MoveTiming
+	for _, m := range clockCommandPattern.FindAllStringSubmatch(comment, -1) {
+		if m[1] == "ts" {
+			playedAt, err := time.Parse(time.RFC3339, m[2])
+			if err != nil {
+				return MoveTiming{}, err
+			}
+			t.PlayedAt = playedAt
+			continue
+		}
+		d, err := n.Parse(m[2])
+		if err != nil {
+			return MoveTiming{}, err
+		}
+		if m[1] == "clk" {
+			t.Clock, t.HasClock = d, true
+		} else {
+			t.Elapsed, t.HasElapsed = d, true
+		}
+	}
+	return t, nil
+}
+
+// Clock is a two-player chess clock. Only the side to move has a running clock.
+type Clock struct {
+	control   TimeControl
+	remaining [2]time.Duration
+	running   Color
+	started   time.Time // zero when stopped
+	now       func() time.Time
+}
+
+// NewClock creates a stopped clock with both sides on the base time.
+func NewClock(tc TimeControl) *Clock {
+	return &Clock{
+		control:   tc,
+		remaining: [2]time.Duration{tc.Base, tc.Base},
+		now:       time.Now,
+	}
+}
+
+// Control returns the clock's time control.
+func (c *Clock) Control() TimeControl {
+	return c.control
+}
+
+// Start runs the clock for the given color, stopping the other side.
+func (c *Clock) Start(color Color) {
+	c.Stop()
+	c.running = color
+	c.started = c.now()
+}
+
+// Stop halts the running side, charging it for the time used so far.
+func (c *Clock) Stop() {
+	if c.started.IsZero() {
+		return
+	}
+	c.remaining[sideIndex(c.running)] -= c.now().Sub(c.started)
+	c.started = time.Time{}
+}
+
+// Running reports whether a side's clock is currently ticking.
+func (c *Clock) Running() bool {
+	return !c.started.IsZero()
+}
+
+// Remaining returns the time left for color, including any time elapsing right now.
+func (c *Clock) Remaining(color Color) time.Duration {
+	r := c.remaining[sideIndex(color)]
+	if !c.started.IsZero() && c.running == color {
+		r -= c.now().Sub(c.started)
+	}
+	return r
+}
+
+// SetRemaining sets the time left for color, e.g. when a saved game is
+// restored. A running side's clock restarts from now.
+func (c *Clock) SetRemaining(color Color, d time.Duration) {
+	c.remaining[sideIndex(color)] = d
+	if !c.started.IsZero() && c.running == color {
+		c.started = c.now()
+	}
+}
+
+// Flagged reports whether color has run out of time.
+func (c *Clock) Flagged(color Color) bool {
+	return c.Remaining(color) <= 0
+}
+
+// Press ends color's turn: the elapsed time is charged, the increment is added
+// unless the flag fell, and the opponent's clock starts.
+func (c *Clock) Press(color Color) MoveTiming {
+	if c.running != color {
+		c.Stop()
+	}
+	var elapsed time.Duration
+	if !c.started.IsZero() {
+		elapsed = c.now().Sub(c.started)
+		c.remaining[sideIndex(color)] -= elapsed
+		c.started = time.Time{}
+	}
+	if c.remaining[sideIndex(color)] > 0 {
+		c.remaining[sideIndex(color)] += c.control.Increment
+	}
+	timing := MoveTiming{Clock: c.remaining[sideIndex(color)], Elapsed: elapsed, HasClock: true, HasElapsed: true}
+	if color == White {
+		c.Start(Black)
+	} else {
+		c.Start(White)
+	}
+	return timing
+}
+
+// sideIndex maps a color to its index in per-side arrays (White 0, Black 1).
+func sideIndex(color Color) int {
+	if color == Black {
+		return 1
+	}
+	return 0
+}
+
+// SetClock attaches a chess clock to the game and starts it for the side to move.
+// Every subsequent move records the mover's remaining and elapsed time. Pass nil to
+// detach the clock. Undoing a move does not give time back.
+func (g *Game) SetClock(c *Clock) {
+	g.clock = c
+	if c != nil && !g.IsGameOver() {
+		c.Start(g.activeColor)
+	}
+}
+
+// Clock returns the game's clock, or nil if the game is untimed.
+func (g *Game) Clock() *Clock {
+	return g.clock
+}
+
+// RecordTimestamps has every later move record when it was played and, when
+// the game has no clock to time it, how long the mover thought: since the last
+// move recorded, or else since now.
+func (g *Game) RecordTimestamps() {
+	g.timestamps = true
+	g.turnStarted = time.Now()
+	if n := len(g.timings); n > 0 && !g.timings[n-1].PlayedAt.IsZero() {
+		g.turnStarted = g.timings[n-1].PlayedAt
+	}
+}
+
+// MoveTimings returns per-ply timing data aligned with MoveHistory. Entries are
+// zero for moves played without a clock or timestamps.
+func (g *Game) MoveTimings() []MoveTiming {
+	timings := make([]MoveTiming, len(g.timings))
+	copy(timings, g.timings)
+	return timings
+}
+
+// SetMoveTiming overrides the timing recorded for a ply (0-based), e.g. when
+// importing clock comments from PGN.
+func (g *Game) SetMoveTiming(ply int, t MoveTiming) error {
+	if ply < 0 || ply >= len(g.timings) {
+		return fmt.Errorf("ply %d out of range", ply)
+	}
+	g.timings[ply] = t
+	return nil
+}
@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPGNClockNotation(t *testing.T) {
+	n := PGNClockNotation{}
+	cases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{5*time.Minute + 3*time.Second, "0:05:03"},
+		{time.Hour + 2*time.Minute, "1:02:00"},
+		{1500 * time.Millisecond, "0:00:01.5"},
+		{-time.Second, "0:00:00"},
+	}
+	for _, tc := range cases {
+		if got := n.Format(tc.d); got != tc.want {
+			t.Errorf("Format(%v) = %q, want %q", tc.d, got, tc.want)
+		}
+		if tc.d < 0 {
+			continue
+		}
+		back, err := n.Parse(tc.want)
+		if err != nil || back != tc.d {
+			t.Errorf("Parse(%q) = %v, %v; want %v", tc.want, back, err, tc.d)
+		}
+	}
+	if _, err := n.Parse("1:xx:00"); err == nil {
+		t.Error("expected error for malformed clock")
+	}
+}
+
+func TestParseTimeControl(t *testing.T) {
+	tc, err := ParseTimeControl("300+3")
+	if err != nil || tc.Base != 5*time.Minute || tc.Increment != 3*time.Second {
+		t.Fatalf("unexpected time control %+v err=%v", tc, err)
+	}
+	if tc.String() != "300+3" {
+		t.Fatalf("expected 300+3, got %s", tc.String())
+	}
+	if _, err := ParseTimeControl("-"); err == nil {
+		t.Fatal("expected error for unknown time control")
+	}
+}
+
+// TestGameClockRecordsTimings drives a clock with a fake time source.
+func TestGameClockRecordsTimings(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewClock(TimeControl{Base: 5 * time.Minute, Increment: 3 * time.Second})
+	clock.now = func() time.Time { return now }
+
+	g := NewGame()
+	g.SetClock(clock)
+
+	now = now.Add(4 * time.Second)
+	playAll(t, g, "e2e4")
+	now = now.Add(10 * time.Second)
+	playAll(t, g, "e7e5")
+
+	timings := g.MoveTimings()
+	if len(timings) != 2 {
+		t.Fatalf("expected 2 timings, got %d", len(timings))
+	}
+	if timings[0].Clock != 4*time.Minute+59*time.Second || timings[0].Elapsed != 4*time.Second {
+		t.Fatalf("unexpected white timing %+v", timings[0])
+	}
+	if timings[1].Clock != 4*time.Minute+53*time.Second || timings[1].Elapsed != 10*time.Second {
+		t.Fatalf("unexpected black timing %+v", timings[1])
+	}
+	if got := timings[0].PGNComment(nil); got != "[%clk 0:04:59] [%emt 0:00:04]" {
+		t.Fatalf("unexpected comment %q", got)
+	}
+
+	now = now.Add(time.Minute)
+	if clock.Remaining(White) != 3*time.Minute+59*time.Second {
+		t.Fatalf("expected white clock running, got %v", clock.Remaining(White))
+	}
+	if _, err := g.UndoMove(); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if len(g.MoveTimings()) != 1 {
+		t.Fatalf("expected timings popped on undo")
+	}
+}
+
+func TestClockSetRemaining(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewClock(TimeControl{Base: 5 * time.Minute})
+	clock.now = func() time.Time { return now }
+	clock.Start(White)
+
+	now = now.Add(30 * time.Second)
+	clock.SetRemaining(White, 2*time.Minute)
+	clock.SetRemaining(Black, time.Minute)
+	now = now.Add(10 * time.Second)
+	if clock.Remaining(White) != 110*time.Second || clock.Remaining(Black) != time.Minute {
+		t.Fatalf("expected 1:50 and 1:00 left, got %v and %v", clock.Remaining(White), clock.Remaining(Black))
+	}
+}
+
+func TestGameRecordsTimestamps(t *testing.T) {
+	g := NewGame()
+	g.RecordTimestamps()
+	before := time.Now()
+	playAll(t, g, "e2e4", "e7e5")
+
+	timings := g.MoveTimings()
+	for i, timing := range timings {
+		if timing.PlayedAt.Before(before) || !timing.HasElapsed || timing.HasClock {
+			t.Fatalf("unexpected timing of ply %d: %+v", i, timing)
+		}
+	}
+	if timings[1].PlayedAt.Before(timings[0].PlayedAt) {
+		t.Fatalf("expected timestamps in move order, got %v then %v", timings[0].PlayedAt, timings[1].PlayedAt)
+	}
+
+	comment := timings[0].PGNComment(nil)
+	parsed, err := ParseMoveTiming(comment, nil)
+	if err != nil {
+		t.Fatalf("parse %q: %v", comment, err)
+	}
+	if !parsed.PlayedAt.Equal(timings[0].PlayedAt.Truncate(time.Millisecond)) {
+		t.Fatalf("expected %v from %q, got %v", timings[0].PlayedAt, comment, parsed.PlayedAt)
+	}
+	if _, err := ParseMoveTiming("[%ts yesterday]", nil); err == nil {
+		t.Error("expected error for malformed timestamp")
+	}
+
+	// Copies used for look-ahead do not record
+	clone := g.Clone()
+	playAll(t, clone, "g1f3")
+	if timing := clone.MoveTimings()[2]; !timing.IsZero() {
+		t.Fatalf("expected a copy's move untimed, got %+v", timing)
+	}
+}
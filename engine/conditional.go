@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConditionalLine is a pre-registered sequence of alternating moves: the opponent's
+// expected move, the player's reply, the opponent's next move, and so on.
+type ConditionalLine struct {
+	ID    int
+	Moves []Move
+	SAN   []string
+}
+
+// ConditionalMoves holds a correspondence player's conditional lines ("if my opponent
+// plays X, I answer Y"). All lines are rooted at the position in which the opponent is
+// to move; together they form a tree that must never answer the same move two ways.
+type ConditionalMoves struct {
+	player Color
+	root   uint64 // hash of the position the lines start from
+	lines  []ConditionalLine
+	nextID int
+}
+
+// NewConditionalMoves creates an empty conditional move set for player.
+func NewConditionalMoves(player Color) *ConditionalMoves {
+	return &ConditionalMoves{player: player, nextID: 1}
+}
+
+// Player returns the color the conditional replies are played for.
+func (cm *ConditionalMoves) Player() Color {
+	return cm.player
+}
+
+// Lines returns the registered lines, with the moves already played trimmed off.
+func (cm *ConditionalMoves) Lines() []ConditionalLine {
+	lines := make([]ConditionalLine, len(cm.lines))
+	copy(lines, cm.lines)
+	return lines
+}
+
+// Add validates a line against the current position of g and registers it. Moves may
+// be given in SAN ("Nf3") or coordinate notation ("g1f3"); the first move is the
+// opponent's and every opponent move must be followed by the player's reply.
+func (cm *ConditionalMoves) Add(g *Game, notations []string) (ConditionalLine, error) {
+	if g.IsGameOver() {
+		return ConditionalLine{}, errors.New("game is over")
+	}
+	if g.activeColor == cm.player {
+		return ConditionalLine{}, errors.New("conditional moves can only be registered while the opponent is to move")
+	}
+	if len(notations) == 0 || len(notations)%2 != 0 {
+		return ConditionalLine{}, errors.New("a conditional line needs pairs of opponent move and reply")
+	}
+
+	line := ConditionalLine{Moves: make([]Move, 0, len(notations)), SAN: make([]string, 0, len(notations))}
+	replay := g.copy()
+	for i, notation := range notations {
+		move, err := replay.parseAnyMove(notation)
+		if err != nil {
+			return ConditionalLine{}, fmt.Errorf("move %d (%s): %w", i+1, notation, err)
+		}
+		line.SAN = append(line.SAN, replay.sanForMove(move))
+		line.Moves = append(line.Moves, move)
+		if err := replay.MakeMove(move); err != nil {
+			return ConditionalLine{}, fmt.Errorf("move %d (%s): %w", i+1, notation, err)
+		}
+		if replay.IsGameOver() && i < len(notations)-1 {
+			return ConditionalLine{}, fmt.Errorf("move %d (%s) ends the game", i+1, notation)
+		}
+	}
+
+	root := g.Hash()
+	if root != cm.root {
+		// Lines registered for an earlier position can never trigger again
+		cm.lines = nil
+		cm.root = root
+	}
+	for _, existing := range cm.lines {
+		if err := conditionalConflict(existing, line); err != nil {
+			return ConditionalLine{}, err
+		}
+	}
+
+	line.ID = cm.nextID
+	cm.nextID++
+	cm.lines = append(cm.lines, line)
+	return line, nil
+}
+
+// Remove deletes the line with the given ID and reports whether it existed.
+func (cm *ConditionalMoves) Remove(id int) bool {
+	for i, line := range cm.lines {
+		if line.ID == id {
+			cm.lines = append(cm.lines[:i], cm.lines[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Apply must be called after a move was made on g. If the opponent's move matches a
+// registered line, the player's reply is played on g and returned; lines that did not
+// match are discarded. Any other move (e.g. after an undo or FEN load) clears the set.
+func (cm *ConditionalMoves) Apply(g *Game, played Move) (Move, bool, error) {
+	if len(cm.lines) == 0 {
+		return Move{}, false, nil
+	}
+	if played.Piece.Color == cm.player || len(g.positionHashes) < 2 || g.positionHashes[len(g.positionHashes)-2] != cm.root {
+		cm.lines = nil
+		return Move{}, false, nil
+	}
+
+	var matched []ConditionalLine
+	for _, line := range cm.lines {
+		if sameMove(line.Moves[0], played) {
+			matched = append(matched, line)
+		}
+	}
+	cm.lines = nil
+	if len(matched) == 0 {
+		return Move{}, false, nil
+	}
+
+	reply := matched[0].Moves[1]
+	if err := g.MakeMove(reply); err != nil {
+		return Move{}, false, fmt.Errorf("conditional reply %s: %w", matched[0].SAN[1], err)
+	}
+	if !g.IsGameOver() {
+		cm.root = g.Hash()
+		for _, line := range matched {
+			if len(line.Moves) > 2 {
+				line.Moves, line.SAN = line.Moves[2:], line.SAN[2:]
+				cm.lines = append(cm.lines, line)
+			}
+		}
+	}
+	return reply, true, nil
+}
+
+// conditionalConflict reports an error if two lines answer the same opponent move
+// sequence with different replies.
+func conditionalConflict(a, b ConditionalLine) error {
+	for i := 0; i < len(a.Moves) && i < len(b.Moves); i++ {
+		if sameMove(a.Moves[i], b.Moves[i]) {
+			continue
+		}
+		if i%2 == 1 {
+			return fmt.Errorf("conflicts with line %d: after %s it answers %s", a.ID, a.SAN[i-1], a.SAN[i])
+		}
+		return nil // different opponent moves: separate branches
+	}
+	return nil
+}
+
+// sameMove compares moves by squares, promotion and drop piece.
+func sameMove(a, b Move) bool {
+	return a.From == b.From && a.To == b.To && a.Promotion == b.Promotion &&
+		(a.Type == Drop) == (b.Type == Drop) && (a.Type != Drop || a.Piece.Type == b.Piece.Type)
+}
+
+// parseAnyMove parses SAN or coordinate notation and checks legality.
+func (g *Game) parseAnyMove(notation string) (Move, error) {
+	if move, err := g.MoveFromSAN(notation); err == nil {
+		return move, nil
+	}
+	move, err := g.ParseMove(notation)
+	if err != nil {
+		return Move{}, err
+	}
+	if err := g.ExplainIllegalMove(move); err != nil {
+		return Move{}, err
+	}
+	return move, nil
+}
@@ -0,0 +1,55 @@
+package engine
+
+import "testing"
+
+func TestConditionalMovesApply(t *testing.T) {
+	g := NewGame()
+	playAll(t, g, "e2e4")
+
+	cm := NewConditionalMoves(White)
+	if _, err := cm.Add(g, []string{"e5", "Nf3", "Nc6", "Bb5"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := cm.Add(g, []string{"c7c5", "g1f3"}); err != nil {
+		t.Fatalf("add coordinate line: %v", err)
+	}
+	if _, err := cm.Add(g, []string{"e5", "Nc3"}); err == nil {
+		t.Fatal("expected conflict: e5 is already answered with Nf3")
+	}
+	if _, err := cm.Add(g, []string{"e5", "Nf3", "Nc6"}); err == nil {
+		t.Fatal("expected error for unpaired line")
+	}
+	if _, err := cm.Add(g, []string{"e5", "Ke3"}); err == nil {
+		t.Fatal("expected error for illegal reply")
+	}
+
+	playAll(t, g, "e7e5")
+	reply, ok, err := cm.Apply(g, g.moveHistory[len(g.moveHistory)-1])
+	if err != nil || !ok || reply.From != G1 || reply.To != F3 {
+		t.Fatalf("expected Nf3 reply, got %v ok=%v err=%v", reply, ok, err)
+	}
+	lines := cm.Lines()
+	if len(lines) != 1 || len(lines[0].Moves) != 2 || lines[0].SAN[1] != "Bb5" {
+		t.Fatalf("expected remaining Nc6/Bb5 branch, got %+v", lines)
+	}
+
+	// Opponent deviates: the remaining branch is discarded
+	playAll(t, g, "d7d6")
+	if _, ok, _ := cm.Apply(g, g.moveHistory[len(g.moveHistory)-1]); ok {
+		t.Fatal("unexpected reply to d6")
+	}
+	if len(cm.Lines()) != 0 {
+		t.Fatal("expected lines cleared after deviation")
+	}
+}
+
+func TestConditionalMovesRejectWrongTurn(t *testing.T) {
+	g := NewGame()
+	cm := NewConditionalMoves(White)
+	if _, err := cm.Add(g, []string{"e4", "e5"}); err == nil {
+		t.Fatal("expected error when the player is to move")
+	}
+	if cm.Remove(0) {
+		t.Fatal("unexpected removal")
+	}
+}
@@ -0,0 +1,281 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Variant identifies the rule set a game is played under.
+type Variant int
+
+const (
+	// Standard represents orthodox chess rules.
+	Standard Variant = iota
+	// Crazyhouse represents the drop variant where captured pieces change sides.
+	Crazyhouse
+)
+
+// String returns the string representation of a variant.
+func (v Variant) String() string {
+	switch v {
+	case Standard:
+		return "standard"
+	case Crazyhouse:
+		return "crazyhouse"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseVariant parses a variant name (case-insensitive). An empty string yields Standard.
+func ParseVariant(s string) (Variant, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "standard", "chess":
+		return Standard, nil
+	case "crazyhouse", "zh":
+		return Crazyhouse, nil
+	default:
+		return Standard, fmt.Errorf("unknown variant: %s", s)
+	}
+}
+
+// Pocket holds the captured pieces a Crazyhouse player may drop, indexed by PieceType.
+type Pocket [7]int
+
+// Count returns how many pieces of the given type are in the pocket.
+func (p Pocket) Count(pt PieceType) int {
+	if pt < Empty || pt > King {
+		return 0
+	}
+	return p[pt]
+}
+
+// IsEmpty returns true if the pocket holds no pieces.
+func (p Pocket) IsEmpty() bool {
+	for _, n := range p {
+		if n > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// pocketOrder is the conventional FEN ordering of pocket pieces.
+var pocketOrder = []PieceType{Queen, Rook, Bishop, Knight, Pawn}
+
+// NewGameWithVariant creates a new game in the starting position of the given variant.
+func NewGameWithVariant(v Variant) *Game {
+	g := NewGame()
+	g.variant = v
+	return g
+}
+
+// Variant returns the rule set the game is played under.
+func (g *Game) Variant() Variant {
+	return g.variant
+}
+
+// Pocket returns the droppable pieces held by the given color (Crazyhouse only).
+func (g *Game) Pocket(color Color) Pocket {
+	switch color {
+	case White:
+		return g.pockets[0]
+	case Black:
+		return g.pockets[1]
+	default:
+		return Pocket{}
+	}
+}
+
+// pocketFor returns a pointer to the pocket of the given color.
+func (g *Game) pocketFor(color Color) *Pocket {
+	if color == Black {
+		return &g.pockets[1]
+	}
+	return &g.pockets[0]
+}
+
+// parseDropMove parses drop notation such as "P@e4", "N@f3" or "@e4" (pawn).
+func (g *Game) parseDropMove(notation string) (Move, error) {
+	if g.variant != Crazyhouse {
+		return Move{}, errors.New("drops are only allowed in crazyhouse")
+	}
+	at := strings.IndexByte(notation, '@')
+	if at > 1 || len(notation) != at+3 {
+		return Move{}, errors.New("invalid drop notation")
+	}
+	pieceType := Pawn
+	if at == 1 {
+		switch strings.ToUpper(notation[:1]) {
+		case "P":
+			pieceType = Pawn
+		case "N":
+			pieceType = Knight
+		case "B":
+			pieceType = Bishop
+		case "R":
+			pieceType = Rook
+		case "Q":
+			pieceType = Queen
+		default:
+			return Move{}, errors.New("invalid drop piece")
+		}
+	}
+	to, err := SquareFromString(notation[at+1:])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid drop square: %w", err)
+	}
+	return Move{
+		From:  to,
+		To:    to,
+		Type:  Drop,
+		Piece: Piece{Type: pieceType, Color: g.activeColor},
+	}, nil
+}
+
+// isDropLegal checks pocket contents, target square and pawn-rank restrictions for a drop.
+// King safety is verified separately by IsLegalMove.
+func (g *Game) isDropLegal(move Move) bool {
+	if g.variant != Crazyhouse || move.Piece.Color != g.activeColor {
+		return false
+	}
+	if move.Piece.Type == King || g.Pocket(move.Piece.Color).Count(move.Piece.Type) == 0 {
+		return false
+	}
+	if !g.board.GetPiece(move.To).IsEmpty() {
+		return false
+	}
+	if move.Piece.Type == Pawn && (move.To.Rank() == 0 || move.To.Rank() == 7) {
+		return false
+	}
+	return true
+}
+
+// executeDrop places a piece from the pocket onto the board.
+func (g *Game) executeDrop(move Move) {
+	g.board.SetPiece(move.To, move.Piece)
+	g.pocketFor(move.Piece.Color)[move.Piece.Type]--
+	g.promoted &^= 1 << uint(move.To)
+}
+
+// recordCrazyhouseCapture moves a captured piece into the capturer's pocket and keeps
+// track of promoted pieces, which return to the pocket as pawns. It must run before the
+// move is applied to the board.
+func (g *Game) recordCrazyhouseCapture(move Move) {
+	if g.variant != Crazyhouse {
+		return
+	}
+
+	captureSq := move.To
+	if move.Type == EnPassant {
+		if move.Piece.Color == White {
+			captureSq = move.To - 8
+		} else {
+			captureSq = move.To + 8
+		}
+	}
+
+	target := g.board.GetPiece(captureSq)
+	if !target.IsEmpty() && target.Color != move.Piece.Color && target.Type != King {
+		pieceType := target.Type
+		if g.promoted&(1<<uint(captureSq)) != 0 {
+			pieceType = Pawn
+		}
+		g.pocketFor(move.Piece.Color)[pieceType]++
+	}
+	g.promoted &^= 1 << uint(captureSq)
+
+	// Carry the promoted marker along with the moving piece
+	if g.promoted&(1<<uint(move.From)) != 0 {
+		g.promoted &^= 1 << uint(move.From)
+		g.promoted |= 1 << uint(move.To)
+	}
+	if move.Type == Promotion {
+		g.promoted |= 1 << uint(move.To)
+	}
+}
+
+// appendDropMoves appends all pseudo-legal drops for the active color.
+func (g *Game) appendDropMoves(moves []Move) []Move {
+	if g.variant != Crazyhouse {
+		return moves
+	}
+	pocket := g.Pocket(g.activeColor)
+	for _, pt := range pocketOrder {
+		if pocket.Count(pt) == 0 {
+			continue
+		}
+		piece := Piece{Type: pt, Color: g.activeColor}
+		for sq := Square(0); sq < 64; sq++ {
+			move := Move{From: sq, To: sq, Type: Drop, Piece: piece}
+			if g.isDropLegal(move) {
+				moves = append(moves, move)
+			}
+		}
+	}
+	return moves
+}
+
+// pocketsToFEN renders both pockets in the bracketed Crazyhouse FEN form, e.g. "[QNpp]".
+func (g *Game) pocketsToFEN() string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for _, color := range []Color{White, Black} {
+		pocket := g.Pocket(color)
+		for _, pt := range pocketOrder {
+			for i := 0; i < pocket.Count(pt); i++ {
+				sb.WriteString(g.pieceToFENChar(Piece{Type: pt, Color: color}))
+			}
+		}
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// parsePocketsFEN parses the pocket section of a Crazyhouse FEN (without brackets).
+func parsePocketsFEN(s string) ([2]Pocket, error) {
+	var pockets [2]Pocket
+	if s == "-" {
+		return pockets, nil
+	}
+	for _, ch := range s {
+		var pt PieceType
+		switch ch {
+		case 'p', 'P':
+			pt = Pawn
+		case 'n', 'N':
+			pt = Knight
+		case 'b', 'B':
+			pt = Bishop
+		case 'r', 'R':
+			pt = Rook
+		case 'q', 'Q':
+			pt = Queen
+		default:
+			return pockets, fmt.Errorf("invalid FEN pocket character: %c", ch)
+		}
+		if ch >= 'A' && ch <= 'Z' {
+			pockets[0][pt]++
+		} else {
+			pockets[1][pt]++
+		}
+	}
+	return pockets, nil
+}
+
+// splitPocketFEN separates the pocket from a FEN piece-placement field. Both the
+// bracketed form ("...RNBQKBNR[Pp]") and the ninth-rank form ("...RNBQKBNR/Pp") are accepted.
+func splitPocketFEN(placement string) (board string, pocket string, hasPocket bool, err error) {
+	if open := strings.IndexByte(placement, '['); open != -1 {
+		if !strings.HasSuffix(placement, "]") {
+			return "", "", false, errors.New("invalid FEN: unterminated pocket")
+		}
+		return placement[:open], placement[open+1 : len(placement)-1], true, nil
+	}
+	if strings.Count(placement, "/") == 8 {
+		idx := strings.LastIndexByte(placement, '/')
+		return placement[:idx], placement[idx+1:], true, nil
+	}
+	return placement, "", false, nil
+}
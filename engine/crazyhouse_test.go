@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func playAll(t *testing.T, g *Game, moves ...string) {
+	t.Helper()
+	for _, s := range moves {
+		mv, err := g.ParseMove(s)
+		if err != nil {
+			t.Fatalf("parse %s: %v", s, err)
+		}
+		if err := g.MakeMove(mv); err != nil {
+			t.Fatalf("make %s: %v", s, err)
+		}
+	}
+}
+
+// TestCrazyhouseCaptureFillsPocket verifies captured pieces go to the capturer's pocket.
+func TestCrazyhouseCaptureFillsPocket(t *testing.T) {
+	g := NewGameWithVariant(Crazyhouse)
+	playAll(t, g, "e2e4", "d7d5", "e4d5")
+
+	if got := g.Pocket(White).Count(Pawn); got != 1 {
+		t.Fatalf("expected white pocket to hold 1 pawn, got %d", got)
+	}
+	if !g.Pocket(Black).IsEmpty() {
+		t.Fatalf("expected black pocket empty, got %v", g.Pocket(Black))
+	}
+	if !strings.Contains(g.ToFEN(), "[P]") {
+		t.Fatalf("expected pocket in FEN, got %s", g.ToFEN())
+	}
+}
+
+// TestCrazyhouseDrop verifies drop parsing, execution and undo.
+func TestCrazyhouseDrop(t *testing.T) {
+	g := NewGameWithVariant(Crazyhouse)
+	playAll(t, g, "e2e4", "d7d5", "e4d5", "d8d5")
+
+	// Black recaptured: black pocket holds a pawn, white still holds one
+	if g.Pocket(Black).Count(Pawn) != 1 {
+		t.Fatalf("expected black pocket pawn, got %v", g.Pocket(Black))
+	}
+
+	mv, err := g.ParseMove("P@e4")
+	if err != nil {
+		t.Fatalf("parse drop: %v", err)
+	}
+	if mv.Type != Drop || mv.String() != "P@e4" {
+		t.Fatalf("unexpected drop move %+v (%s)", mv, mv.String())
+	}
+	if err := g.MakeMove(mv); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	if p := g.Board().GetPiece(E4); p.Type != Pawn || p.Color != White {
+		t.Fatalf("expected white pawn on e4 after drop, got %v", p)
+	}
+	if !g.Pocket(White).IsEmpty() {
+		t.Fatalf("expected white pocket empty after drop")
+	}
+	if san := g.GenerateSAN(); san[len(san)-1] != "P@e4" {
+		t.Fatalf("expected SAN P@e4, got %v", san)
+	}
+
+	if _, err := g.UndoMove(); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if g.Pocket(White).Count(Pawn) != 1 || !g.Board().GetPiece(E4).IsEmpty() {
+		t.Fatalf("undo did not restore pocket/board")
+	}
+}
+
+// TestCrazyhouseDropRestrictions covers illegal drops.
+func TestCrazyhouseDropRestrictions(t *testing.T) {
+	g := NewGame()
+	if err := g.ParseFEN("4k3/8/8/8/8/8/8/4K3[PNp] w - - 0 1"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	if g.Variant() != Crazyhouse {
+		t.Fatalf("expected pocket FEN to switch variant to crazyhouse")
+	}
+	cases := []string{"P@e8", "P@a1", "B@c3", "N@e1"}
+	for _, c := range cases {
+		mv, err := g.ParseMove(c)
+		if err != nil {
+			continue
+		}
+		if g.IsLegalMove(mv) {
+			t.Errorf("expected %s to be illegal", c)
+		}
+	}
+	mv, _ := g.ParseMove("N@d3")
+	if !g.IsLegalMove(mv) {
+		t.Errorf("expected N@d3 to be legal")
+	}
+
+	drops := 0
+	for _, m := range g.GetAllLegalMoves() {
+		if m.Type == Drop {
+			drops++
+		}
+	}
+	// 62 empty squares for the knight, 48 for the pawn (ranks 2-7 only)
+	if drops != 62+48 {
+		t.Errorf("expected %d legal drops, got %d", 62+48, drops)
+	}
+}
+
+// TestCrazyhouseDropBlocksMate verifies drops are considered when detecting checkmate.
+func TestCrazyhouseDropBlocksMate(t *testing.T) {
+	g := NewGame()
+	// Back-rank mate in standard chess, but black can drop the pocket rook to block.
+	if err := g.ParseFEN("R5k1/5ppp/8/8/8/8/8/6K1[r] b - - 0 1"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	if g.Status() != Check {
+		t.Fatalf("expected check (drop can block), got %s", g.Status())
+	}
+	std := NewGame()
+	if err := std.ParseFEN("R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	if std.Status() != WhiteWins {
+		t.Fatalf("expected checkmate without pocket, got %s", std.Status())
+	}
+}
+
+// TestCrazyhousePromotedPieceReturnsAsPawn verifies the promoted marker round-trips.
+func TestCrazyhousePromotedPieceReturnsAsPawn(t *testing.T) {
+	g := NewGame()
+	if err := g.ParseFEN("3Q~k3/8/4K3/8/8/8/8/8[] b - - 0 1"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	if !strings.Contains(g.ToFEN(), "Q~") {
+		t.Fatalf("expected promoted marker in FEN, got %s", g.ToFEN())
+	}
+	playAll(t, g, "e8d8")
+	if g.Pocket(Black).Count(Pawn) != 1 || g.Pocket(Black).Count(Queen) != 0 {
+		t.Fatalf("expected captured promoted queen to become a pawn, got %v", g.Pocket(Black))
+	}
+}
+
+func TestParseVariant(t *testing.T) {
+	if v, err := ParseVariant("Crazyhouse"); err != nil || v != Crazyhouse {
+		t.Fatalf("expected crazyhouse, got %v %v", v, err)
+	}
+	if v, err := ParseVariant(""); err != nil || v != Standard {
+		t.Fatalf("expected standard default, got %v %v", v, err)
+	}
+	if _, err := ParseVariant("atomic"); err == nil {
+		t.Fatalf("expected error for unknown variant")
+	}
+	if _, err := NewGame().ParseMove("P@e4"); err == nil {
+		t.Fatalf("expected drop to be rejected in standard chess")
+	}
+}
+
+func TestParseFENResetsVariant(t *testing.T) {
+	g := NewGameWithVariant(Crazyhouse)
+	if err := g.ParseFEN("4k3/8/8/8/8/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if g.Variant() != Standard || strings.Contains(g.ToFEN(), "[") {
+		t.Fatalf("expected a FEN without pocket to load standard chess, got %v %s", g.Variant(), g.ToFEN())
+	}
+	if _, err := g.ParseMove("P@e4"); err == nil {
+		t.Fatal("expected drops rejected after loading a standard FEN")
+	}
+	if err := g.ParseFEN("4k3/8/8/8/8/8/8/4K3[P] w - - 0 1"); err != nil || g.Variant() != Crazyhouse {
+		t.Fatalf("expected a pocket to load Crazyhouse, got %v (%v)", g.Variant(), err)
+	}
+
+	// A PGN's Variant tag holds even when its FEN has no pocket
+	pgn, err := ParsePGN("[Variant \"Crazyhouse\"]\n[FEN \"4k3/8/8/8/8/8/8/4K3 w - - 0 1\"]\n\n*")
+	if err != nil || pgn.Game.Variant() != Crazyhouse {
+		t.Fatalf("expected the tagged variant, got %v (%v)", pgn, err)
+	}
+}
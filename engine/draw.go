@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DrawReason identifies why a game was drawn, or why a draw may be claimed.
+type DrawReason int
+
+const (
+	// NoDraw indicates the game has not been drawn.
+	NoDraw DrawReason = iota
+	// DrawStalemate is an automatic draw: the side to move has no legal moves and is not in check.
+	DrawStalemate
+	// DrawInsufficientMaterial is an automatic draw: neither side can deliver mate.
+	DrawInsufficientMaterial
+	// DrawFivefoldRepetition is an automatic draw: the same position occurred five times.
+	DrawFivefoldRepetition
+	// DrawSeventyFiveMoveRule is an automatic draw: 75 moves by each side without a pawn move or capture.
+	DrawSeventyFiveMoveRule
+	// DrawThreefoldRepetition is a claimable draw: the same position occurred three times.
+	DrawThreefoldRepetition
+	// DrawFiftyMoveRule is a claimable draw: 50 moves by each side without a pawn move or capture.
+	DrawFiftyMoveRule
+)
+
+// ErrDrawNotClaimable is returned by ClaimDraw when the requested draw cannot be claimed.
+var ErrDrawNotClaimable = errors.New("draw cannot be claimed")
+
+// String returns the string representation of the draw reason.
+func (r DrawReason) String() string {
+	switch r {
+	case NoDraw:
+		return "none"
+	case DrawStalemate:
+		return "stalemate"
+	case DrawInsufficientMaterial:
+		return "insufficient_material"
+	case DrawFivefoldRepetition:
+		return "fivefold_repetition"
+	case DrawSeventyFiveMoveRule:
+		return "seventy_five_move_rule"
+	case DrawThreefoldRepetition:
+		return "threefold_repetition"
+	case DrawFiftyMoveRule:
+		return "fifty_move_rule"
+	default:
+		return "unknown"
+	}
+}
+
+// Automatic reports whether the draw ends the game without a claim.
+func (r DrawReason) Automatic() bool {
+	return r >= DrawStalemate && r <= DrawSeventyFiveMoveRule
+}
+
+// ParseDrawReason parses the string form of a draw reason.
+func ParseDrawReason(s string) (DrawReason, error) {
+	for r := DrawStalemate; r <= DrawFiftyMoveRule; r++ {
+		if r.String() == s {
+			return r, nil
+		}
+	}
+	return NoDraw, fmt.Errorf("unknown draw reason: %q", s)
+}
+
+// DrawReason returns why the game was drawn, or NoDraw if it was not.
+func (g *Game) DrawReason() DrawReason {
+	if g.status != Draw {
+		return NoDraw
+	}
+	return g.drawReason
+}
+
+// ClaimableDraws returns the draws the side to move may claim in the current position:
+// threefold repetition and the fifty-move rule. Finished games have no claims.
+func (g *Game) ClaimableDraws() []DrawReason {
+	if g.IsGameOver() {
+		return nil
+	}
+	var claims []DrawReason
+	if g.RepetitionCount() >= 3 {
+		claims = append(claims, DrawThreefoldRepetition)
+	}
+	if g.halfMoveClock >= 100 {
+		claims = append(claims, DrawFiftyMoveRule)
+	}
+	return claims
+}
+
+// ClaimDraw ends the game as a draw for a claimable reason. It returns
+// ErrDrawNotClaimable if the condition is not met in the current position.
+func (g *Game) ClaimDraw(reason DrawReason) error {
+	if g.IsGameOver() {
+		return errors.New("game is over")
+	}
+	for _, claim := range g.ClaimableDraws() {
+		if claim == reason {
+			g.endGame(Draw, TerminationDraw, reason)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrDrawNotClaimable, reason)
+}
+
+// automaticDraw returns the automatic draw that applies to the current position
+// (other than stalemate, which needs the legal move count), or NoDraw.
+func (g *Game) automaticDraw() DrawReason {
+	switch {
+	case g.hasInsufficientMaterial():
+		return DrawInsufficientMaterial
+	case g.RepetitionCount() >= 5:
+		return DrawFivefoldRepetition
+	case g.halfMoveClock >= 150:
+		return DrawSeventyFiveMoveRule
+	default:
+		return NoDraw
+	}
+}
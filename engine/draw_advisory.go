@@ -0,0 +1,110 @@
+package engine
+
+// DrawAdvisoryOptions configures the drawn-out game detector.
+type DrawAdvisoryOptions struct {
+	// EvalMargin is the maximum absolute evaluation (centipawns) still considered equal.
+	EvalMargin int
+	// QuietPlies is the number of plies without pawn moves or captures that counts as shuffling.
+	QuietPlies int
+	// Repetitions is how many times the current position must have occurred to be flagged.
+	Repetitions int
+}
+
+// DefaultDrawAdvisoryOptions returns conservative defaults suitable for UI prompts:
+// a ±0.5 pawn window, 30 quiet plies and a single repeat of the current position.
+func DefaultDrawAdvisoryOptions() DrawAdvisoryOptions {
+	return DrawAdvisoryOptions{
+		EvalMargin:  50,
+		QuietPlies:  30,
+		Repetitions: 2,
+	}
+}
+
+// Draw advisory reasons.
+const (
+	AdvisoryRepetition           = "repetition"
+	AdvisoryNoProgress           = "no_progress"
+	AdvisoryInsufficientMaterial = "insufficient_material"
+)
+
+// DrawAdvisory is a non-binding hint that the game is dead drawn or shuffling.
+// Clients can use it to prompt players to agree a draw or to adjudicate bot games.
+type DrawAdvisory struct {
+	ConsiderDraw bool
+	Reasons      []string
+	Evaluation   int // centipawns from White's perspective
+	QuietPlies   int // plies since the last pawn move or capture
+	Repetitions  int // occurrences of the current position
+}
+
+// DrawAdvisory inspects the current position for drawn-out play. Finished games never
+// produce an advisory.
+func (g *Game) DrawAdvisory(opts DrawAdvisoryOptions) DrawAdvisory {
+	adv := DrawAdvisory{
+		Evaluation:  g.Evaluate(),
+		QuietPlies:  g.halfMoveClock,
+		Repetitions: g.RepetitionCount(),
+	}
+	if g.IsGameOver() {
+		return adv
+	}
+
+	if opts.Repetitions > 0 && adv.Repetitions >= opts.Repetitions {
+		adv.Reasons = append(adv.Reasons, AdvisoryRepetition)
+	}
+	if opts.QuietPlies > 0 && adv.QuietPlies >= opts.QuietPlies && abs(adv.Evaluation) <= opts.EvalMargin {
+		adv.Reasons = append(adv.Reasons, AdvisoryNoProgress)
+	}
+	if g.hasInsufficientMaterial() {
+		adv.Reasons = append(adv.Reasons, AdvisoryInsufficientMaterial)
+	}
+	adv.ConsiderDraw = len(adv.Reasons) > 0
+	return adv
+}
+
+// RepetitionCount returns how many times the current position has occurred,
+// including the current occurrence.
+func (g *Game) RepetitionCount() int {
+	if len(g.positionHashes) == 0 {
+		return 1
+	}
+	current := g.positionHashes[len(g.positionHashes)-1]
+	count := 0
+	for _, hash := range g.positionHashes {
+		if hash == current {
+			count++
+		}
+	}
+	return count
+}
+
+// hasInsufficientMaterial reports whether neither side can possibly deliver mate:
+// K vs K, K+minor vs K, or K+B vs K+B with same-colored bishops.
+func (g *Game) hasInsufficientMaterial() bool {
+	if g.variant == Crazyhouse && (!g.Pocket(White).IsEmpty() || !g.Pocket(Black).IsEmpty()) {
+		return false
+	}
+	minors := 0
+	bishopSquareColors := map[int]bool{}
+	bishops := 0
+	for sq := Square(0); sq < 64; sq++ {
+		p := g.board.GetPiece(sq)
+		switch p.Type {
+		case Empty, King:
+			continue
+		case Knight:
+			minors++
+		case Bishop:
+			minors++
+			bishops++
+			bishopSquareColors[(sq.File()+sq.Rank())%2] = true
+		default:
+			return false
+		}
+	}
+	if minors <= 1 {
+		return true
+	}
+	// Only bishops, all on the same square color
+	return bishops == minors && len(bishopSquareColors) == 1
+}
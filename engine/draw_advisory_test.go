@@ -0,0 +1,83 @@
+package engine
+
+import "testing"
+
+func hasReason(adv DrawAdvisory, reason string) bool {
+	for _, r := range adv.Reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// TestDrawAdvisoryRepetition flags knight shuffling back to the start position.
+func TestDrawAdvisoryRepetition(t *testing.T) {
+	g := NewGame()
+	if adv := g.DrawAdvisory(DefaultDrawAdvisoryOptions()); adv.ConsiderDraw {
+		t.Fatalf("unexpected advisory in starting position: %+v", adv)
+	}
+	playAll(t, g, "g1f3", "g8f6", "f3g1", "f6g8")
+	if g.RepetitionCount() != 2 {
+		t.Fatalf("expected repetition count 2, got %d", g.RepetitionCount())
+	}
+	adv := g.DrawAdvisory(DefaultDrawAdvisoryOptions())
+	if !adv.ConsiderDraw || !hasReason(adv, AdvisoryRepetition) {
+		t.Fatalf("expected repetition advisory, got %+v", adv)
+	}
+
+	if _, err := g.UndoMove(); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if g.RepetitionCount() != 1 {
+		t.Fatalf("expected repetition count reset after undo, got %d", g.RepetitionCount())
+	}
+}
+
+// TestDrawAdvisoryNoProgress flags long quiet stretches in equal positions only.
+func TestDrawAdvisoryNoProgress(t *testing.T) {
+	g := NewGame()
+	if err := g.ParseFEN("4k3/4r3/8/8/8/8/4R3/4K3 w - - 40 80"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	adv := g.DrawAdvisory(DefaultDrawAdvisoryOptions())
+	if !hasReason(adv, AdvisoryNoProgress) {
+		t.Fatalf("expected no_progress advisory, got %+v", adv)
+	}
+
+	// Same clock but White is a full rook up: not drawish
+	if err := g.ParseFEN("4k3/8/8/8/8/8/4R3/4K3 w - - 40 80"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	if adv := g.DrawAdvisory(DefaultDrawAdvisoryOptions()); adv.ConsiderDraw {
+		t.Fatalf("unexpected advisory with decisive material: %+v", adv)
+	}
+}
+
+// TestInsufficientMaterial covers dead-drawn material, which now ends the game
+// automatically instead of producing an advisory.
+func TestInsufficientMaterial(t *testing.T) {
+	cases := []struct {
+		fen  string
+		want bool
+	}{
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/2B1K3 w - - 0 1", true},
+		{"2b1k3/8/8/8/8/8/8/2B1K3 w - - 0 1", false}, // opposite colored bishops (c8 light, c1 dark)
+		{"3bk3/8/8/8/8/8/8/2B1K3 w - - 0 1", true},   // same colored bishops
+		{"4k3/8/8/8/8/8/8/1NN1K3 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", false},
+	}
+	for _, tc := range cases {
+		g := NewGame()
+		if err := g.ParseFEN(tc.fen); err != nil {
+			t.Fatalf("parse FEN %s: %v", tc.fen, err)
+		}
+		if got := g.DrawReason() == DrawInsufficientMaterial; got != tc.want {
+			t.Errorf("%s: insufficient material = %v, want %v", tc.fen, got, tc.want)
+		}
+		if tc.want && g.DrawAdvisory(DefaultDrawAdvisoryOptions()).ConsiderDraw {
+			t.Errorf("%s: finished game should not produce an advisory", tc.fen)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClaimDrawThreefoldAndFivefold(t *testing.T) {
+	g := NewGame()
+	if err := g.ClaimDraw(DrawThreefoldRepetition); !errors.Is(err, ErrDrawNotClaimable) {
+		t.Fatalf("expected ErrDrawNotClaimable, got %v", err)
+	}
+
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	playAll(t, g, shuffle...)
+	playAll(t, g, shuffle...)
+	claims := g.ClaimableDraws()
+	if len(claims) != 1 || claims[0] != DrawThreefoldRepetition {
+		t.Fatalf("expected threefold claim, got %v", claims)
+	}
+
+	// Not claimed: play continues until the fivefold repetition ends the game
+	playAll(t, g, shuffle...)
+	playAll(t, g, shuffle...)
+	if g.Status() != Draw || g.DrawReason() != DrawFivefoldRepetition {
+		t.Fatalf("expected automatic fivefold draw, got %v / %v", g.Status(), g.DrawReason())
+	}
+	if !g.DrawReason().Automatic() || g.ClaimableDraws() != nil {
+		t.Fatal("finished game should have no claims")
+	}
+
+	if _, err := g.UndoMove(); err != nil {
+		t.Fatal(err)
+	}
+	if g.Status() == Draw || g.DrawReason() != NoDraw {
+		t.Fatalf("undo should restore the game, got %v / %v", g.Status(), g.DrawReason())
+	}
+	if err := g.ClaimDraw(DrawThreefoldRepetition); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if g.Status() != Draw || g.DrawReason() != DrawThreefoldRepetition || g.DrawReason().Automatic() {
+		t.Fatalf("expected claimed threefold draw, got %v / %v", g.Status(), g.DrawReason())
+	}
+}
+
+func TestMoveRuleDraws(t *testing.T) {
+	g := NewGame()
+	if err := g.ParseFEN("4k3/8/8/8/8/8/4P3/R3K3 w - - 99 80"); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.ClaimableDraws()) != 0 {
+		t.Fatal("unexpected claim before the fiftieth move")
+	}
+	playAll(t, g, "a1a2")
+	if err := g.ClaimDraw(DrawFiftyMoveRule); err != nil {
+		t.Fatalf("claim fifty-move rule: %v", err)
+	}
+	if err := g.ClaimDraw(DrawFiftyMoveRule); err == nil {
+		t.Fatal("expected error claiming a finished game")
+	}
+
+	g = NewGame()
+	if err := g.ParseFEN("4k3/8/8/8/8/8/4P3/R3K3 w - - 149 100"); err != nil {
+		t.Fatal(err)
+	}
+	playAll(t, g, "a1a2")
+	if g.Status() != Draw || g.DrawReason() != DrawSeventyFiveMoveRule {
+		t.Fatalf("expected automatic 75-move draw, got %v / %v", g.Status(), g.DrawReason())
+	}
+}
+
+func TestParseDrawReason(t *testing.T) {
+	for r := DrawStalemate; r <= DrawFiftyMoveRule; r++ {
+		got, err := ParseDrawReason(r.String())
+		if err != nil || got != r {
+			t.Errorf("round trip %v: got %v, %v", r, got, err)
+		}
+	}
+	if _, err := ParseDrawReason("agreement"); err == nil {
+		t.Error("expected error for unknown reason")
+	}
+}
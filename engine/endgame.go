@@ -0,0 +1,239 @@
+package engine
+
+import "strings"
+
+// EndgameClass classifies a position by the material left on the board.
+type EndgameClass int
+
+const (
+	// EndgameNone indicates too much material is left for an endgame.
+	EndgameNone EndgameClass = iota
+	// EndgameInsufficient indicates neither side can possibly mate.
+	EndgameInsufficient
+	// EndgameKXK is a bare king against a queen, rook or bishop pair (plus anything).
+	EndgameKXK
+	// EndgameKBNK is a bare king against king, bishop and knight.
+	EndgameKBNK
+	// EndgameKPK is a bare king against king and a single pawn.
+	EndgameKPK
+	// EndgamePawn has only kings and pawns.
+	EndgamePawn
+	// EndgameMinor has only bishops, knights and pawns.
+	EndgameMinor
+	// EndgameRook has only rooks and pawns.
+	EndgameRook
+	// EndgameQueen has only queens and pawns.
+	EndgameQueen
+	// EndgameMixed is any other ending with reduced material.
+	EndgameMixed
+)
+
+// String returns the string representation of the endgame class.
+func (e EndgameClass) String() string {
+	switch e {
+	case EndgameNone:
+		return "none"
+	case EndgameInsufficient:
+		return "insufficient_material"
+	case EndgameKXK:
+		return "kxk"
+	case EndgameKBNK:
+		return "kbnk"
+	case EndgameKPK:
+		return "kpk"
+	case EndgamePawn:
+		return "pawn"
+	case EndgameMinor:
+		return "minor_piece"
+	case EndgameRook:
+		return "rook"
+	case EndgameQueen:
+		return "queen"
+	case EndgameMixed:
+		return "mixed"
+	default:
+		return "unknown"
+	}
+}
+
+// endgameMaterialLimit is the most non-pawn material (centipawns) a side may have
+// for the position to count as an endgame, e.g. a queen and a minor piece.
+const endgameMaterialLimit = 1300
+
+// signatureOrder is the piece order used in material signatures.
+var signatureOrder = [...]PieceType{Queen, Rook, Bishop, Knight, Pawn}
+
+// materialCounts returns the number of pieces of each type per side ([0] White).
+func (b *Board) materialCounts() [2][7]int {
+	var counts [2][7]int
+	for sq := Square(0); sq < 64; sq++ {
+		if p := b.GetPiece(sq); !p.IsEmpty() {
+			counts[sideIndex(p.Color)][p.Type]++
+		}
+	}
+	return counts
+}
+
+// MaterialSignature describes the material on the board with White first, e.g.
+// "KRPvKR" for king, rook and pawn against king and rook. Crazyhouse pockets are
+// not included.
+func (g *Game) MaterialSignature() string {
+	counts := g.board.materialCounts()
+	var sb strings.Builder
+	for side := range counts {
+		if side == 1 {
+			sb.WriteByte('v')
+		}
+		sb.WriteByte('K')
+		for _, pt := range signatureOrder {
+			sb.WriteString(strings.Repeat(Piece{Type: pt, Color: White}.String(), counts[side][pt]))
+		}
+	}
+	return sb.String()
+}
+
+// EndgameClass classifies the current position. Crazyhouse positions with pieces in
+// hand are never endgames.
+func (g *Game) EndgameClass() EndgameClass {
+	if g.variant == Crazyhouse && (!g.Pocket(White).IsEmpty() || !g.Pocket(Black).IsEmpty()) {
+		return EndgameNone
+	}
+	if g.hasInsufficientMaterial() {
+		return EndgameInsufficient
+	}
+	return classifyEndgame(g.board.materialCounts())
+}
+
+// classifyEndgame classifies material counts that allow a mate.
+func classifyEndgame(counts [2][7]int) EndgameClass {
+	var nonPawn [2]int
+	for side := range counts {
+		for _, pt := range []PieceType{Knight, Bishop, Rook, Queen} {
+			nonPawn[side] += counts[side][pt] * pieceValues[pt]
+		}
+	}
+	for side := range counts {
+		strong, weak := counts[side], counts[1-side]
+		if nonPawn[1-side] != 0 || weak[Pawn] != 0 {
+			continue
+		}
+		switch {
+		case nonPawn[side] == 0 && strong[Pawn] == 1:
+			return EndgameKPK
+		case strong[Pawn] == 0 && nonPawn[side] == pieceValues[Bishop]+pieceValues[Knight] &&
+			strong[Bishop] == 1 && strong[Knight] == 1:
+			return EndgameKBNK
+		case strong[Queen] > 0 || strong[Rook] > 0 || strong[Bishop] >= 2:
+			return EndgameKXK
+		}
+	}
+	if nonPawn[0] > endgameMaterialLimit || nonPawn[1] > endgameMaterialLimit {
+		return EndgameNone
+	}
+
+	minors := counts[0][Knight] + counts[0][Bishop] + counts[1][Knight] + counts[1][Bishop]
+	rooks := counts[0][Rook] + counts[1][Rook]
+	queens := counts[0][Queen] + counts[1][Queen]
+	switch {
+	case minors == 0 && rooks == 0 && queens == 0:
+		return EndgamePawn
+	case rooks == 0 && queens == 0:
+		return EndgameMinor
+	case minors == 0 && queens == 0:
+		return EndgameRook
+	case minors == 0 && rooks == 0:
+		return EndgameQueen
+	default:
+		return EndgameMixed
+	}
+}
+
+// TablebaseApplicable reports whether the position can be probed in endgame
+// tablebases covering up to maxPieces pieces (kings included), e.g. 7 for Syzygy.
+// Tablebases assume standard chess without castling rights.
+func (g *Game) TablebaseApplicable(maxPieces int) bool {
+	if g.variant != Standard || g.EndgameClass() == EndgameNone {
+		return false
+	}
+	cr := g.castlingRights
+	if cr.WhiteKingside || cr.WhiteQueenside || cr.BlackKingside || cr.BlackQueenside {
+		return false
+	}
+	pieces := 0
+	for sq := Square(0); sq < 64; sq++ {
+		if !g.board.GetPiece(sq).IsEmpty() {
+			pieces++
+		}
+	}
+	return pieces <= maxPieces
+}
+
+// endgameScore is the specialized evaluation term for an endgame class (centipawns,
+// White's perspective). base is the rest of the evaluation.
+func (g *Game) endgameScore(class EndgameClass, base int, pawns PawnStructure) int {
+	switch class {
+	case EndgameInsufficient:
+		// Dead draw: cancel everything else
+		return -base
+	case EndgameKXK, EndgameKBNK:
+		return g.mopUpScore(class)
+	case EndgamePawn, EndgameKPK:
+		// Passed pawns decide pawn endings
+		return pawns.Passed.Score
+	default:
+		return 0
+	}
+}
+
+// mopUpScore rewards the side with mating material for driving the bare king to
+// the edge (to a corner of the bishop's color in KBNK) and approaching it.
+func (g *Game) mopUpScore(class EndgameClass) int {
+	strong := White
+	if g.board.materialCounts()[0] == ([7]int{King: 1}) {
+		strong = Black
+	}
+	weakKing := g.board.kingSquare(strong.Opposite())
+	strongKing := g.board.kingSquare(strong)
+	if weakKing < 0 || strongKing < 0 {
+		return 0
+	}
+
+	edge := centerDistance(weakKing)
+	if class == EndgameKBNK {
+		edge = 7 - bishopCornerDistance(g.board, strong, weakKing)
+	}
+	score := 10*edge + 4*(14-squareDistance(weakKing, strongKing))
+	if strong == Black {
+		return -score
+	}
+	return score
+}
+
+// centerDistance is the Manhattan distance of a square from the central four squares (0-6).
+func centerDistance(sq Square) int {
+	return max(3-sq.File(), sq.File()-4) + max(3-sq.Rank(), sq.Rank()-4)
+}
+
+// squareDistance is the Manhattan distance between two squares.
+func squareDistance(a, b Square) int {
+	return abs(a.File()-b.File()) + abs(a.Rank()-b.Rank())
+}
+
+// bishopCornerDistance is the Chebyshev distance from sq to the nearest corner that
+// color's bishop controls, where a KBNK mate must be delivered.
+func bishopCornerDistance(b *Board, color Color, sq Square) int {
+	corners := [2][2]Square{{A1, H8}, {H1, A8}} // dark, light
+	light := 0
+	for s := Square(0); s < 64; s++ {
+		if p := b.GetPiece(s); p.Type == Bishop && p.Color == color {
+			light = (s.File() + s.Rank()) % 2
+			break
+		}
+	}
+	best := 7
+	for _, corner := range corners[light] {
+		d := max(abs(sq.File()-corner.File()), abs(sq.Rank()-corner.Rank()))
+		best = min(best, d)
+	}
+	return best
+}
@@ -0,0 +1,92 @@
+package engine
+
+import "testing"
+
+func TestMaterialSignatureAndClass(t *testing.T) {
+	tests := []struct {
+		fen       string
+		signature string
+		class     EndgameClass
+	}{
+		{"", "KQRRBBNNPPPPPPPPvKQRRBBNNPPPPPPPP", EndgameNone},
+		{"8/8/4k3/8/8/3K4/8/8 w - - 0 1", "KvK", EndgameInsufficient},
+		{"8/8/4k3/8/8/3K4/8/6N1 w - - 0 1", "KNvK", EndgameInsufficient},
+		{"8/8/4k3/8/8/3K4/8/6R1 w - - 0 1", "KRvK", EndgameKXK},
+		{"8/8/4k3/8/8/3K4/8/5BN1 w - - 0 1", "KBNvK", EndgameKBNK},
+		{"8/8/4k3/8/4P3/3K4/8/8 w - - 0 1", "KPvK", EndgameKPK},
+		{"8/5p2/4k3/8/4P3/3K4/8/8 w - - 0 1", "KPvKP", EndgamePawn},
+		{"8/5p2/4k3/3n4/4P3/3K1B2/8/8 w - - 0 1", "KBPvKNP", EndgameMinor},
+		{"r7/5p2/4k3/8/4P3/3K4/8/R7 w - - 0 1", "KRPvKRP", EndgameRook},
+		{"8/8/1q2k3/8/8/2K5/7Q/8 w - - 0 1", "KQvKQ", EndgameQueen},
+		{"3rr3/8/4k3/8/4P3/3K4/8/R3B3 w - - 0 1", "KRBPvKRR", EndgameMixed},
+		{"3qr3/8/4k3/8/4P3/3K4/8/R2QB3 w - - 0 1", "KQRBPvKQR", EndgameNone},
+	}
+	for _, tt := range tests {
+		g := NewGame()
+		if tt.fen != "" {
+			if err := g.ParseFEN(tt.fen); err != nil {
+				t.Fatalf("ParseFEN %s: %v", tt.fen, err)
+			}
+		}
+		if got := g.MaterialSignature(); got != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.fen, got, tt.signature)
+		}
+		if got := g.EndgameClass(); got != tt.class {
+			t.Errorf("%s: class %s, want %s", tt.fen, got, tt.class)
+		}
+	}
+}
+
+func TestTablebaseApplicable(t *testing.T) {
+	tests := []struct {
+		fen  string
+		want bool
+	}{
+		{"", false},
+		{"r7/5p2/4k3/8/4P3/3K4/8/R7 w - - 0 1", true},
+		{"r3k3/5p2/8/8/4P3/8/8/R3K3 w Qq - 0 1", false}, // castling rights
+		{"8/8/4k3/8/8/3K4/8/8 w - - 0 1", true},
+	}
+	for _, tt := range tests {
+		g := NewGame()
+		if tt.fen != "" {
+			if err := g.ParseFEN(tt.fen); err != nil {
+				t.Fatalf("ParseFEN %s: %v", tt.fen, err)
+			}
+		}
+		if got := g.TablebaseApplicable(7); got != tt.want {
+			t.Errorf("%s: TablebaseApplicable = %v, want %v", tt.fen, got, tt.want)
+		}
+	}
+	g := NewGame()
+	if err := g.ParseFEN("r7/5p2/4k3/8/4P3/3K4/8/R7 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if g.TablebaseApplicable(5) {
+		t.Errorf("expected 6 pieces not to fit a 5-piece tablebase")
+	}
+}
+
+func TestEndgameEvaluation(t *testing.T) {
+	// Insufficient material is a dead draw whatever the piece values say
+	if eb := evalFEN(t, "8/8/4k3/8/8/3K4/8/6N1 w - - 0 1"); eb.Total != 0 || eb.Class != EndgameInsufficient {
+		t.Fatalf("expected drawn evaluation, got %+v", eb)
+	}
+
+	// Mop-up: the bare king on the edge is worse for the defender than in the centre
+	edge := evalFEN(t, "4k3/8/4K3/8/8/8/8/R7 w - - 0 1")
+	center := evalFEN(t, "8/8/8/4k3/8/8/8/R3K3 w - - 0 1")
+	if edge.Endgame <= center.Endgame || edge.Total <= center.Total {
+		t.Fatalf("expected mop-up bonus for cornered king: edge %+v center %+v", edge, center)
+	}
+	if mirrored := evalFEN(t, "r7/8/8/8/8/4k3/8/4K3 b - - 0 1"); mirrored.Endgame != -edge.Endgame {
+		t.Fatalf("expected symmetric mop-up term: %d vs %d", mirrored.Endgame, edge.Endgame)
+	}
+
+	// KBNK: the right corner is the bishop's color
+	right := evalFEN(t, "7k/8/5K2/8/8/8/8/4BN2 w - - 0 1") // dark-squared bishop, h8 is dark
+	wrong := evalFEN(t, "k7/8/2K5/8/8/8/8/4BN2 w - - 0 1")
+	if right.Endgame <= wrong.Endgame {
+		t.Fatalf("expected bishop-colored corner to score higher: right %+v wrong %+v", right, wrong)
+	}
+}
@@ -0,0 +1,226 @@
+package engine
+
+// pieceValues are the material values used by Evaluate, in centipawns.
+var pieceValues = map[PieceType]int{
+	Pawn:   100,
+	Knight: 320,
+	Bishop: 330,
+	Rook:   500,
+	Queen:  900,
+	King:   0,
+}
+
+// Pawn-structure weights in centipawns.
+const (
+	doubledPawnPenalty  = 15 // per extra pawn on a file
+	isolatedPawnPenalty = 12
+	backwardPawnPenalty = 8
+	pawnChainBonus      = 5 // per pawn defended by a pawn
+	pawnShieldBonus     = 8 // per pawn in front of a castled king
+	centerBonus         = 5
+)
+
+// passedPawnBonus is indexed by the pawn's rank from its own side (0-7).
+var passedPawnBonus = [8]int{0, 5, 10, 20, 35, 60, 100, 0}
+
+// PawnTerm is one pawn-structure feature: how many pawns of each side have it and
+// its contribution to the evaluation (centipawns, White's perspective).
+type PawnTerm struct {
+	White int
+	Black int
+	Score int
+}
+
+// PawnStructure breaks down the pawn-structure part of the evaluation.
+type PawnStructure struct {
+	Doubled  PawnTerm // extra pawns on a file
+	Isolated PawnTerm // no friendly pawns on adjacent files
+	Backward PawnTerm // cannot be supported and the advance square is controlled by an enemy pawn
+	Passed   PawnTerm // no enemy pawns ahead on the same or adjacent files
+	Chain    PawnTerm // defended by a friendly pawn
+	Shield   PawnTerm // in front of a king on its first two ranks
+}
+
+// Score returns the total pawn-structure contribution.
+func (ps PawnStructure) Score() int {
+	return ps.Doubled.Score + ps.Isolated.Score + ps.Backward.Score +
+		ps.Passed.Score + ps.Chain.Score + ps.Shield.Score
+}
+
+// EvaluationBreakdown splits Evaluate into its terms (centipawns, White's perspective).
+type EvaluationBreakdown struct {
+	Material int // pieces on the board and in hand
+	Center   int // pieces on the 16 central squares
+	Pawns    PawnStructure
+	Endgame  int          // specialized endgame term, see EndgameClass
+	Class    EndgameClass // endgame classification of the position
+	Total    int
+}
+
+// Evaluate returns a material, activity and pawn-structure evaluation (centipawns
+// from White's perspective), with specialized terms for recognized endgames.
+func (g *Game) Evaluate() int {
+	return g.EvaluateBreakdown().Total
+}
+
+// EvaluateBreakdown returns the evaluation split into its terms, so callers can
+// explain why a side is better.
+func (g *Game) EvaluateBreakdown() EvaluationBreakdown {
+	var eb EvaluationBreakdown
+	// Pieces in hand count as material (Crazyhouse)
+	for _, pt := range pocketOrder {
+		eb.Material += pieceValues[pt] * (g.Pocket(White).Count(pt) - g.Pocket(Black).Count(pt))
+	}
+	for sq := Square(0); sq < 64; sq++ {
+		p := g.board.GetPiece(sq)
+		if p.IsEmpty() {
+			continue
+		}
+		sign := 1
+		if p.Color == Black {
+			sign = -1
+		}
+		eb.Material += sign * pieceValues[p.Type]
+		if file, rank := sq.File(), sq.Rank(); file >= 2 && file <= 5 && rank >= 2 && rank <= 5 {
+			eb.Center += sign * centerBonus
+		}
+	}
+	eb.Pawns = g.pawnStructure()
+	eb.Class = g.EndgameClass()
+	base := eb.Material + eb.Center + eb.Pawns.Score()
+	eb.Endgame = g.endgameScore(eb.Class, base, eb.Pawns)
+	eb.Total = base + eb.Endgame
+	return eb
+}
+
+// pawnStructure detects doubled, isolated, backward, passed, chained and shield pawns.
+func (g *Game) pawnStructure() PawnStructure {
+	// pawns[side][file] lists the ranks of that side's pawns on the file
+	var pawns [2][8][]int
+	var kings [2]Square
+	for sq := Square(0); sq < 64; sq++ {
+		p := g.board.GetPiece(sq)
+		switch p.Type {
+		case Pawn:
+			side := sideIndex(p.Color)
+			pawns[side][sq.File()] = append(pawns[side][sq.File()], sq.Rank())
+		case King:
+			kings[sideIndex(p.Color)] = sq
+		}
+	}
+
+	var ps PawnStructure
+	for side, color := range []Color{White, Black} {
+		own, enemy := pawns[side], pawns[1-side]
+		dir := 1
+		if color == Black {
+			dir = -1
+		}
+		count := func(term *PawnTerm, n, weight int) {
+			if color == White {
+				term.White += n
+				term.Score += n * weight
+			} else {
+				term.Black += n
+				term.Score -= n * weight
+			}
+		}
+
+		for file := 0; file < 8; file++ {
+			if n := len(own[file]); n > 1 {
+				count(&ps.Doubled, n-1, -doubledPawnPenalty)
+			}
+			for _, rank := range own[file] {
+				isolated := !hasPawn(own, file-1) && !hasPawn(own, file+1)
+				passed := true
+				for f := file - 1; f <= file+1; f++ {
+					if anyPawnAhead(enemy, f, rank, dir) {
+						passed = false
+					}
+				}
+				switch {
+				case isolated:
+					count(&ps.Isolated, 1, -isolatedPawnPenalty)
+				case !passed && isBackward(own, enemy, file, rank, dir):
+					count(&ps.Backward, 1, -backwardPawnPenalty)
+				}
+				if passed {
+					relative := rank
+					if color == Black {
+						relative = 7 - rank
+					}
+					count(&ps.Passed, 1, passedPawnBonus[relative])
+				}
+				if hasPawnAt(own, file-1, rank-dir) || hasPawnAt(own, file+1, rank-dir) {
+					count(&ps.Chain, 1, pawnChainBonus)
+				}
+			}
+		}
+
+		// Pawn shield: own pawns one or two ranks in front of a king on its back two ranks
+		king := kings[side]
+		kingRank := king.Rank()
+		if color == Black {
+			kingRank = 7 - kingRank
+		}
+		if kingRank <= 1 {
+			shield := 0
+			for f := king.File() - 1; f <= king.File()+1; f++ {
+				if hasPawnAt(own, f, king.Rank()+dir) || hasPawnAt(own, f, king.Rank()+2*dir) {
+					shield++
+				}
+			}
+			count(&ps.Shield, shield, pawnShieldBonus)
+		}
+	}
+	return ps
+}
+
+// hasPawn reports whether the file holds any pawn of the given set.
+func hasPawn(pawns [8][]int, file int) bool {
+	return file >= 0 && file < 8 && len(pawns[file]) > 0
+}
+
+// hasPawnAt reports whether the set has a pawn on the given file and rank.
+func hasPawnAt(pawns [8][]int, file, rank int) bool {
+	if file < 0 || file > 7 {
+		return false
+	}
+	for _, r := range pawns[file] {
+		if r == rank {
+			return true
+		}
+	}
+	return false
+}
+
+// anyPawnAhead reports whether the set has a pawn on file strictly ahead of rank
+// in direction dir.
+func anyPawnAhead(pawns [8][]int, file, rank, dir int) bool {
+	if file < 0 || file > 7 {
+		return false
+	}
+	for _, r := range pawns[file] {
+		if (r-rank)*dir > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isBackward reports whether a pawn has no friendly pawns beside or behind it on the
+// adjacent files and its advance square is attacked by an enemy pawn.
+func isBackward(own, enemy [8][]int, file, rank, dir int) bool {
+	for _, f := range []int{file - 1, file + 1} {
+		if f < 0 || f > 7 {
+			continue
+		}
+		for _, r := range own[f] {
+			if (rank-r)*dir >= 0 {
+				return false // a neighbour can still support the advance
+			}
+		}
+	}
+	stop := rank + dir
+	return hasPawnAt(enemy, file-1, stop+dir) || hasPawnAt(enemy, file+1, stop+dir)
+}
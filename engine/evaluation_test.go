@@ -0,0 +1,68 @@
+package engine
+
+import "testing"
+
+func evalFEN(t *testing.T, fen string) EvaluationBreakdown {
+	t.Helper()
+	g := NewGame()
+	if fen != "" {
+		if err := g.ParseFEN(fen); err != nil {
+			t.Fatalf("parse FEN %s: %v", fen, err)
+		}
+	}
+	eb := g.EvaluateBreakdown()
+	if eb.Total != eb.Material+eb.Center+eb.Pawns.Score()+eb.Endgame || eb.Total != g.Evaluate() {
+		t.Fatalf("breakdown does not add up: %+v", eb)
+	}
+	return eb
+}
+
+func TestEvaluateBreakdownStart(t *testing.T) {
+	eb := evalFEN(t, "")
+	if eb.Total != 0 || eb.Material != 0 {
+		t.Fatalf("expected balanced start, got %+v", eb)
+	}
+	if eb.Pawns.Shield.White != 3 || eb.Pawns.Shield.Black != 3 || eb.Pawns.Shield.Score != 0 {
+		t.Fatalf("unexpected shield term: %+v", eb.Pawns.Shield)
+	}
+}
+
+func TestPawnStructureTerms(t *testing.T) {
+	// Doubled, isolated and passed c-pawns
+	ps := evalFEN(t, "4k3/8/8/8/8/2P5/2P5/4K3 w - - 0 1").Pawns
+	if ps.Doubled.White != 1 || ps.Isolated.White != 2 || ps.Passed.White != 2 {
+		t.Fatalf("unexpected doubled/isolated/passed: %+v", ps)
+	}
+	if ps.Doubled.Score != -doubledPawnPenalty || ps.Passed.Score != passedPawnBonus[1]+passedPawnBonus[2] {
+		t.Fatalf("unexpected scores: %+v", ps)
+	}
+
+	// d2 is backward: its neighbours have advanced and e4 controls d3
+	ps = evalFEN(t, "4k3/8/8/8/4p3/2P1P3/3P4/4K3 w - - 0 1").Pawns
+	if ps.Backward.White != 1 || ps.Chain.White != 2 || ps.Passed.Black != 0 || ps.Isolated.Black != 1 {
+		t.Fatalf("unexpected backward/chain: %+v", ps)
+	}
+
+	// A far advanced passed pawn is worth more for Black when mirrored
+	white := evalFEN(t, "4k3/6P1/8/8/8/8/8/4K3 w - - 0 1")
+	black := evalFEN(t, "4k3/8/8/8/8/8/6p1/4K3 w - - 0 1")
+	if white.Pawns.Passed.Score != passedPawnBonus[6] || black.Pawns.Passed.Score != -passedPawnBonus[6] {
+		t.Fatalf("unexpected passed pawn scores: %d / %d", white.Pawns.Passed.Score, black.Pawns.Passed.Score)
+	}
+}
+
+func TestEvaluateSymmetry(t *testing.T) {
+	g := NewGame()
+	if err := g.ParseFEN("r1bqk2r/pp3ppp/2n2n2/3pp3/1b1P4/2N1PN2/PP3PPP/R1BQKB1R w KQkq - 0 7"); err != nil {
+		t.Fatal(err)
+	}
+	eval := g.Evaluate()
+	g.board = g.board.Flip()
+	if got := g.Evaluate(); got != -eval {
+		t.Errorf("flipped evaluation = %d, want %d", got, -eval)
+	}
+	g.board = g.board.Mirror()
+	if got := g.Evaluate(); got != -eval {
+		t.Errorf("mirrored evaluation = %d, want %d", got, -eval)
+	}
+}
@@ -0,0 +1,111 @@
+package engine
+
+// EventType identifies a game event.
+type EventType int
+
+const (
+	// EventMoveMade is emitted after every move.
+	EventMoveMade EventType = iota
+	// EventCapture is emitted when a move captures a piece (including en passant).
+	EventCapture
+	// EventCheck is emitted when a move gives check without ending the game.
+	EventCheck
+	// EventPromotion is emitted when a pawn promotes.
+	EventPromotion
+	// EventGameEnded is emitted when a move, resignation, timeout or draw ends the game.
+	EventGameEnded
+)
+
+// String returns the string representation of an event type.
+func (et EventType) String() string {
+	switch et {
+	case EventMoveMade:
+		return "move_made"
+	case EventCapture:
+		return "capture"
+	case EventCheck:
+		return "check"
+	case EventPromotion:
+		return "promotion"
+	case EventGameEnded:
+		return "game_ended"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes something that happened in a game.
+type Event struct {
+	Type   EventType
+	Move   Move       // zero when the game ended without a move (e.g. resignation)
+	Status GameStatus // status after the move
+	Ply    int        // 1-based ply number of the move
+}
+
+type observer struct {
+	id int
+	fn func(Event)
+}
+
+// Subscribe registers fn to be called synchronously for every event, in the order
+// MoveMade, Capture, Promotion, Check, GameEnded. It returns a function that removes
+// the subscription. Like the rest of Game, subscriptions are not safe for concurrent
+// use; callbacks must not make moves on the same game.
+func (g *Game) Subscribe(fn func(Event)) (unsubscribe func()) {
+	g.nextObserverID++
+	id := g.nextObserverID
+	g.observers = append(g.observers, observer{id: id, fn: fn})
+	return func() {
+		for i, o := range g.observers {
+			if o.id == id {
+				g.observers = append(g.observers[:i:i], g.observers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emitMoveEvents notifies observers about a move that was just made.
+func (g *Game) emitMoveEvents(move Move, captured Piece) {
+	if len(g.observers) == 0 {
+		return
+	}
+	move.Captured = captured
+	base := Event{Move: move, Status: g.status, Ply: len(g.moveHistory)}
+	events := []EventType{EventMoveMade}
+	if !captured.IsEmpty() {
+		events = append(events, EventCapture)
+	}
+	if move.Type == Promotion {
+		events = append(events, EventPromotion)
+	}
+	if g.IsGameOver() {
+		events = append(events, EventGameEnded)
+	} else if g.isInCheck(g.activeColor) {
+		events = append(events, EventCheck)
+	}
+	for _, t := range events {
+		ev := base
+		ev.Type = t
+		for _, o := range g.observers {
+			o.fn(ev)
+		}
+	}
+}
+
+// capturedBy returns the piece a move would capture in the current position, if any.
+func (g *Game) capturedBy(move Move) Piece {
+	if move.Type == Drop || move.Type == Castling {
+		return Piece{}
+	}
+	if target := g.board.GetPiece(move.To); !target.IsEmpty() {
+		return target
+	}
+	if move.Type == EnPassant {
+		if move.Piece.Color == White {
+			return g.board.GetPiece(move.To - 8)
+		}
+		return g.board.GetPiece(move.To + 8)
+	}
+	return Piece{}
+}
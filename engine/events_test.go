@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubscribeEmitsEvents(t *testing.T) {
+	g := NewGame()
+	var got []string
+	unsubscribe := g.Subscribe(func(e Event) {
+		got = append(got, e.Type.String())
+		if e.Type == EventCapture && e.Move.Captured.Type != Pawn {
+			t.Errorf("expected captured pawn, got %v", e.Move.Captured)
+		}
+	})
+
+	playAll(t, g, "e2e4", "d7d5", "e4d5")
+	want := []string{"move_made", "move_made", "move_made", "capture"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+
+	// Fool's mate ends with check-mate: GameEnded, not Check
+	got = nil
+	g = NewGame()
+	g.Subscribe(func(e Event) { got = append(got, e.Type.String()) })
+	playAll(t, g, "f2f3", "e7e5", "g2g4", "d8h4")
+	if last := got[len(got)-1]; last != "game_ended" {
+		t.Fatalf("expected game_ended last, got %v", got)
+	}
+
+	unsubscribe()
+}
+
+func TestSubscribeCheckPromotionAndUnsubscribe(t *testing.T) {
+	g := NewGame()
+	if err := g.ParseFEN("8/4P3/8/8/8/8/k7/4K3 w - - 0 1"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	var got []EventType
+	unsubscribe := g.Subscribe(func(e Event) { got = append(got, e.Type) })
+	playAll(t, g, "e7e8q")
+	if !reflect.DeepEqual(got, []EventType{EventMoveMade, EventPromotion}) {
+		t.Fatalf("unexpected events %v", got)
+	}
+
+	unsubscribe()
+	got = nil
+	playAll(t, g, "a2b2", "e8b5")
+	if len(got) != 0 {
+		t.Fatalf("expected no events after unsubscribe, got %v", got)
+	}
+
+	g.Subscribe(func(e Event) { got = append(got, e.Type) })
+	playAll(t, g, "b2a2")
+	playAll(t, g, "b5a4")
+	if !reflect.DeepEqual(got, []EventType{EventMoveMade, EventMoveMade, EventCheck}) {
+		t.Fatalf("unexpected events %v", got)
+	}
+}
+
+// TestReplaceKeepsObservers takes another game's position and keeps notifying
+// the observers of the replaced one.
+func TestReplaceKeepsObservers(t *testing.T) {
+	g := NewGame()
+	moves := 0
+	g.Subscribe(func(e Event) {
+		if e.Type == EventMoveMade {
+			moves++
+		}
+	})
+	other := NewGame()
+	playAll(t, other, "e2e4", "e7e5")
+	g.Replace(other)
+	if len(g.MoveHistory()) != 2 || moves != 0 {
+		t.Fatalf("expected the two moves of the other game and no events, got %d moves, %d events", len(g.MoveHistory()), moves)
+	}
+	playAll(t, g, "g1f3")
+	if moves != 1 {
+		t.Fatalf("expected the observer notified of Nf3, got %d events", moves)
+	}
+}
@@ -0,0 +1,60 @@
+package engine
+
+import "strings"
+
+// NormalizeFEN returns the canonical form of a FEN string: whitespace collapsed,
+// missing clock fields filled in (0 1), castling rights in KQkq order and the en
+// passant square dropped unless an en passant capture is actually legal.
+func NormalizeFEN(fen string) (string, error) {
+	g := NewGame()
+	if err := g.ParseFEN(fen); err != nil {
+		return "", err
+	}
+	if g.enPassantSquare >= 0 && !g.hasLegalEnPassant() {
+		g.enPassantSquare = -1
+	}
+	return g.ToFEN(), nil
+}
+
+// SameFENPosition reports whether two FEN strings describe the same position
+// (placement, side to move, castling rights and en passant), ignoring the halfmove
+// clock and fullmove number. Invalid FENs never match.
+func SameFENPosition(a, b string) bool {
+	na, err := NormalizeFEN(a)
+	if err != nil {
+		return false
+	}
+	nb, err := NormalizeFEN(b)
+	if err != nil {
+		return false
+	}
+	return fenPosition(na) == fenPosition(nb)
+}
+
+// fenPosition returns the first four fields of a normalized FEN.
+func fenPosition(fen string) string {
+	return strings.Join(strings.Fields(fen)[:4], " ")
+}
+
+// hasLegalEnPassant reports whether the side to move can capture en passant.
+func (g *Game) hasLegalEnPassant() bool {
+	ep := g.enPassantSquare
+	if ep < 0 {
+		return false
+	}
+	pawn := Piece{Type: Pawn, Color: g.activeColor}
+	fromRank := ep.Rank() - 1
+	if g.activeColor == Black {
+		fromRank = ep.Rank() + 1
+	}
+	for _, file := range []int{ep.File() - 1, ep.File() + 1} {
+		if file < 0 || file > 7 || fromRank < 0 || fromRank > 7 {
+			continue
+		}
+		from := Square(fromRank*8 + file)
+		if g.board.GetPiece(from) == pawn && g.IsLegalMove(Move{From: from, To: ep, Type: EnPassant, Piece: pawn}) {
+			return true
+		}
+	}
+	return false
+}
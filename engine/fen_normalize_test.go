@@ -0,0 +1,51 @@
+package engine
+
+import "testing"
+
+func TestNormalizeFEN(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"clocks filled in", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{"whitespace", "  8/8/8/4k3/8/8/8/4K3   b  -  - 3 40 ", "8/8/8/4k3/8/8/8/4K3 b - - 3 40"},
+		{"castling order", "r3k2r/8/8/8/8/8/8/R3K2R w qkQK - 0 1", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"},
+		{"unusable en passant dropped", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"},
+		{"capturable en passant kept", "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3", "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeFEN(tt.in)
+			if err != nil {
+				t.Fatalf("NormalizeFEN: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if _, err := NormalizeFEN("not a fen"); err == nil {
+		t.Error("expected error for invalid FEN")
+	}
+}
+
+func TestSameFENPosition(t *testing.T) {
+	// Same position reached by different move orders, with different clocks
+	a := NewGame()
+	playAll(t, a, "g1f3", "g8f6", "b1c3")
+	b := NewGame()
+	playAll(t, b, "b1c3", "b8c6", "g1f3", "g8f6", "c3b1", "c6b8", "b1c3")
+	if a.ToFEN() == b.ToFEN() || !SameFENPosition(a.ToFEN(), b.ToFEN()) {
+		t.Errorf("expected transposed positions to match: %s vs %s", a.ToFEN(), b.ToFEN())
+	}
+	if SameFENPosition(a.ToFEN(), NewGame().ToFEN()) {
+		t.Error("different positions should not match")
+	}
+	if SameFENPosition("8/8/8/4k3/8/8/8/4K3 w - - 0 1", "8/8/8/4k3/8/8/8/4K3 b - - 0 1") {
+		t.Error("side to move should matter")
+	}
+	if SameFENPosition("bad", "bad") {
+		t.Error("invalid FENs should not match")
+	}
+}
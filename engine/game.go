@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // MoveType represents the type of chess move.
@@ -21,6 +22,8 @@ const (
 	EnPassant
 	// Promotion represents a pawn promotion.
 	Promotion
+	// Drop represents placing a pocket piece on the board (Crazyhouse).
+	Drop
 )
 
 // String returns the string representation of a move type.
@@ -36,12 +39,14 @@ func (mt MoveType) String() string {
 		return "en_passant"
 	case Promotion:
 		return "promotion"
+	case Drop:
+		return "drop"
 	default:
 		return "unknown"
 	}
 }
 
-// Move represents a chess move.
+// Move represents a chess move. For drops, From equals To.
 type Move struct {
 	From      Square
 	To        Square
@@ -54,12 +59,16 @@ type Move struct {
 // String returns the string representation of a move in algebraic notation.
 func (m Move) String() string {
 	if m.Type == Castling {
-		if m.To.File() > m.From.File() {
+		if m.isKingsideCastling() {
 			return "O-O" // Kingside castling
 		}
 		return "O-O-O" // Queenside castling
 	}
 
+	if m.Type == Drop {
+		return strings.ToUpper(m.Piece.String()) + "@" + m.To.String()
+	}
+
 	notation := m.From.String() + m.To.String()
 
 	if m.Type == Promotion {
@@ -128,20 +137,54 @@ type CastlingRights struct {
 
 // Game represents a chess game state.
 type Game struct {
-	board           *Board
-	activeColor     Color
-	castlingRights  CastlingRights
+	board          *Board
+	activeColor    Color
+	castlingRights CastlingRights
+	// castlingRooks holds the origin squares of the castling rooks in K, Q, k, q
+	// order; they differ from the corners only in Chess960 set-ups.
+	castlingRooks   [4]Square
 	enPassantSquare Square
 	halfMoveClock   int
 	moveCount       int
 	moveHistory     []Move
 	status          GameStatus
+	// drawReason explains a Draw status (automatic or claimed)
+	drawReason DrawReason
+	// termination records how the game ended when it was not by a move (resignation etc.)
+	termination Termination
 	// startedFromFEN indicates the game began (or was reset) from a custom FEN
 	startedFromFEN bool
 	// startingFEN stores the original FEN the current game was loaded from (if any)
 	startingFEN string
 	// stateStack holds snapshots prior to each executed move to enable UndoMove.
 	stateStack []gameState
+	// nullMoves holds snapshots for pending null moves made during analysis.
+	nullMoves []nullMove
+	// variant is the rule set in use (standard or crazyhouse)
+	variant Variant
+	// pockets holds droppable pieces for white [0] and black [1] in Crazyhouse
+	pockets [2]Pocket
+	// promoted is a bitboard of squares holding promoted pieces (Crazyhouse)
+	promoted uint64
+	// positionHashes records the Zobrist hash of every position reached since the
+	// game started or was loaded, for repetition detection.
+	positionHashes []uint64
+	// timings holds per-ply clock data, parallel to moveHistory.
+	timings []MoveTiming
+	// annotations holds per-ply commentary; it may be shorter than moveHistory.
+	annotations []Annotation
+	// shredderCastling makes ToFEN write castling rights as rook files ("HAha"),
+	// set when the game was loaded from a Shredder-FEN / X-FEN string.
+	shredderCastling bool
+	// clock is the optional running chess clock; copies used for look-ahead never share it.
+	clock *Clock
+	// timestamps makes moves record when they were played, measuring think
+	// time from turnStarted; like the clock it is not copied.
+	timestamps  bool
+	turnStarted time.Time
+	// observers receive events from MakeMove; like the clock they are not copied.
+	observers      []observer
+	nextObserverID int
 }
 
 // gameState is an internal snapshot of reversible game state for undo.
@@ -153,11 +196,15 @@ type gameState struct {
 	halfMoveClock   int
 	moveCount       int
 	status          GameStatus
+	drawReason      DrawReason
+	termination     Termination
+	pockets         [2]Pocket
+	promoted        uint64
 }
 
 // NewGame creates a new chess game with the standard starting position.
 func NewGame() *Game {
-	return &Game{
+	g := &Game{
 		board:       NewBoard(),
 		activeColor: White,
 		castlingRights: CastlingRights{
@@ -166,6 +213,7 @@ func NewGame() *Game {
 			BlackKingside:  true,
 			BlackQueenside: true,
 		},
+		castlingRooks:   standardCastlingRooks,
 		enPassantSquare: -1,
 		halfMoveClock:   0,
 		moveCount:       1,
@@ -175,6 +223,8 @@ func NewGame() *Game {
 		startingFEN:     "",
 		stateStack:      make([]gameState, 0),
 	}
+	g.positionHashes = []uint64{g.Hash()}
+	return g
 }
 
 // Board returns a copy of the current board.
@@ -182,6 +232,11 @@ func (g *Game) Board() *Board {
 	return g.board.Copy()
 }
 
+// PieceAt returns the piece on a square without copying the board.
+func (g *Game) PieceAt(sq Square) Piece {
+	return g.board.GetPiece(sq)
+}
+
 // ActiveColor returns the color of the player whose turn it is.
 func (g *Game) ActiveColor() Color {
 	return g.activeColor
@@ -202,6 +257,24 @@ func (g *Game) MoveCount() int {
 	return g.moveCount
 }
 
+// HalfMoveClock returns the number of plies since the last capture or pawn move,
+// as used by the fifty-move rule.
+func (g *Game) HalfMoveClock() int {
+	return g.halfMoveClock
+}
+
+// EnPassantSquare returns the square a pawn may capture onto en passant, as written
+// in FEN. The second result is false if the last move was not a double pawn push.
+func (g *Game) EnPassantSquare() (Square, bool) {
+	return g.enPassantSquare, g.enPassantSquare >= 0
+}
+
+// CastlingRights returns the castling rights still held by each side. In Chess960 a
+// right may refer to a rook off the corner; ToFEN reports which one.
+func (g *Game) CastlingRights() CastlingRights {
+	return g.castlingRights
+}
+
 // MoveHistory returns a copy of the move history.
 func (g *Game) MoveHistory() []Move {
 	history := make([]Move, len(g.moveHistory))
@@ -213,6 +286,11 @@ func (g *Game) MoveHistory() []Move {
 func (g *Game) ParseMove(notation string) (Move, error) {
 	notation = strings.TrimSpace(notation)
 
+	// Handle drop notation (Crazyhouse): P@e4, N@f3
+	if strings.Contains(notation, "@") {
+		return g.parseDropMove(notation)
+	}
+
 	// Handle castling notation
 	if notation == "O-O" || notation == "0-0" {
 		return g.parseCastlingMove(true)
@@ -240,8 +318,20 @@ func (g *Game) ParseMove(notation string) (Move, error) {
 	}
 
 	piece := g.board.GetPiece(from)
-	if piece.IsEmpty() || piece.Color != g.activeColor {
-		return Move{}, errors.New("no piece to move or wrong color")
+	if piece.IsEmpty() {
+		return Move{}, &IllegalMoveError{Move: Move{From: from, To: to}, Reason: IllegalNoPiece}
+	}
+	if piece.Color != g.activeColor {
+		return Move{}, &IllegalMoveError{Move: Move{From: from, To: to, Piece: piece}, Reason: IllegalWrongTurn}
+	}
+
+	// A king moving two files from its home square is castling (e1g1, e8c8), as is a
+	// king taking its own castling rook (Chess960 style, e.g. b1a1)
+	if piece.Type == King && abs(to.File()-from.File()) == 2 && from.File() == 4 && from.Rank() == to.Rank() {
+		return g.parseCastlingMove(to.File() > from.File())
+	}
+	if kingside, ok := g.castlingRookFor(Move{From: from, To: to, Piece: piece}); ok {
+		return g.parseCastlingMove(kingside)
 	}
 
 	captured := g.board.GetPiece(to)
@@ -294,39 +384,25 @@ func (g *Game) ParseMove(notation string) (Move, error) {
 
 // parseCastlingMove parses a castling move.
 func (g *Game) parseCastlingMove(kingside bool) (Move, error) {
-	var kingFrom, kingTo Square
-
-	if g.activeColor == White {
-		kingFrom = E1
-		if kingside {
-			kingTo = G1
-		} else {
-			kingTo = C1
-		}
-	} else {
-		kingFrom = E8
-		if kingside {
-			kingTo = G8
-		} else {
-			kingTo = C8
-		}
-	}
-
-	king := g.board.GetPiece(kingFrom)
-	if king.Type != King || king.Color != g.activeColor {
+	plan, ok := g.castlingPlan(g.activeColor, kingside)
+	if !ok {
 		return Move{}, errors.New("king not in position for castling")
 	}
 
 	return Move{
-		From:  kingFrom,
-		To:    kingTo,
+		From:  plan.kingFrom,
+		To:    plan.kingTo,
 		Type:  Castling,
-		Piece: king,
+		Piece: g.board.GetPiece(plan.kingFrom),
 	}, nil
 }
 
 // IsLegalMove checks if a move is legal in the current position.
 func (g *Game) IsLegalMove(move Move) bool {
+	if move.Type == Drop {
+		return g.isDropLegal(move) && !g.wouldBeInCheckAfterMove(move, g.activeColor)
+	}
+
 	// Basic validation
 	piece := g.board.GetPiece(move.From)
 	if piece.IsEmpty() || piece.Color != g.activeColor {
@@ -335,37 +411,54 @@ func (g *Game) IsLegalMove(move Move) bool {
 
 	// Check if the destination contains a king - capturing the king should never be allowed
 	targetPiece := g.board.GetPiece(move.To)
-	if !targetPiece.IsEmpty() && targetPiece.Type == King {
+	if targetPiece.Type == King && move.Type != Castling {
 		return false // Cannot capture the king
 	}
+	if !targetPiece.IsEmpty() && targetPiece.Color == piece.Color && move.Type != Castling {
+		return false // Cannot capture own pieces
+	}
+	if move.Type == Promotion && !validPromotion(move) || move.Type != Promotion && reachesLastRank(piece, move.To) {
+		return false // promotions must name a piece, and a pawn on the last rank must promote
+	}
 
 	// Check if the move is pseudo-legal for the piece type
 	if !g.isPseudoLegalMove(move) {
 		return false
 	}
 
-	// Make a copy of the game to test the move
-	gameCopy := g.copy()
-	gameCopy.makeMoveWithoutStatusUpdate(move)
-
-	// Check if the king is in check after the move
-	inCheck := gameCopy.isInCheck(g.activeColor)
-
-	return !inCheck
+	// The king must not be in check after the move
+	return !g.wouldBeInCheckAfterMove(move, g.activeColor)
 }
 
 // MakeMove makes a move if it's legal.
 func (g *Game) MakeMove(move Move) error {
-	if !g.IsLegalMove(move) {
-		return errors.New("illegal move")
+	if err := g.ExplainIllegalMove(move); err != nil {
+		return err
 	}
 
+	// Generated moves may not carry the captured piece; resolve it for observers
+	captured := g.capturedBy(move)
+
 	// snapshot state for undo BEFORE applying move
 	g.pushState()
 
 	g.makeMove(move)
 	g.moveHistory = append(g.moveHistory, move)
 
+	var timing MoveTiming
+	if g.clock != nil {
+		timing = g.clock.Press(g.activeColor)
+	}
+	if g.timestamps {
+		now := time.Now()
+		timing.PlayedAt = now
+		if !timing.HasElapsed {
+			timing.Elapsed, timing.HasElapsed = now.Sub(g.turnStarted), true
+		}
+		g.turnStarted = now
+	}
+	g.timings = append(g.timings, timing)
+
 	// Switch active color
 	if g.activeColor == White {
 		g.activeColor = Black
@@ -374,57 +467,31 @@ func (g *Game) MakeMove(move Move) error {
 		g.moveCount++
 	}
 
+	g.positionHashes = append(g.positionHashes, g.Hash())
+
 	// Update game status
 	g.updateGameStatus()
+	if g.clock != nil && g.IsGameOver() {
+		g.clock.Stop()
+	}
+
+	g.emitMoveEvents(move, captured)
 
 	return nil
 }
 
-// makeMoveWithoutStatusUpdate executes a move without validation or status update.
-// This is used internally for move validation to avoid infinite recursion.
-func (g *Game) makeMoveWithoutStatusUpdate(move Move) {
-	// Handle castling
-	if move.Type == Castling {
-		g.executeCastling(move)
-		// Remove castling rights for the moving side (king moved)
-		g.updateCastlingRights(move)
-		return
-	}
-
-	// Handle en passant
-	if move.Type == EnPassant {
-		g.executeEnPassant(move)
+// makeMove executes a move without validation.
+func (g *Game) makeMove(move Move) {
+	// Handle drops
+	if move.Type == Drop {
+		g.executeDrop(move)
+		g.updateEnPassantSquare(move)
+		g.updateHalfMoveClock(move)
 		return
 	}
 
-	// Regular move
-	g.board.SetPiece(move.To, move.Piece)
-	g.board.SetPiece(move.From, Piece{Type: Empty})
-
-	// Handle promotion
-	if move.Type == Promotion {
-		g.board.SetPiece(move.To, Piece{Type: move.Promotion, Color: move.Piece.Color})
-	}
-
-	// Update castling rights
-	g.updateCastlingRights(move)
-
-	// Update en passant square
-	g.updateEnPassantSquare(move)
-
-	// Update half-move clock
-	g.updateHalfMoveClock(move)
+	g.recordCrazyhouseCapture(move)
 
-	// Switch active color for the copy
-	if g.activeColor == White {
-		g.activeColor = Black
-	} else {
-		g.activeColor = White
-	}
-}
-
-// makeMove executes a move without validation.
-func (g *Game) makeMove(move Move) {
 	// Handle castling
 	if move.Type == Castling {
 		g.executeCastling(move)
@@ -439,6 +506,8 @@ func (g *Game) makeMove(move Move) {
 	// Handle en passant
 	if move.Type == EnPassant {
 		g.executeEnPassant(move)
+		g.updateEnPassantSquare(move)
+		g.updateHalfMoveClock(move)
 		return
 	}
 
@@ -463,29 +532,7 @@ func (g *Game) makeMove(move Move) {
 
 // executeCastling executes a castling move.
 func (g *Game) executeCastling(move Move) {
-	// Move the king
-	g.board.SetPiece(move.To, move.Piece)
-	g.board.SetPiece(move.From, Piece{Type: Empty})
-
-	// Move the rook
-	var rookFrom, rookTo Square
-	if move.To.File() > move.From.File() { // Kingside
-		if g.activeColor == White {
-			rookFrom, rookTo = H1, F1
-		} else {
-			rookFrom, rookTo = H8, F8
-		}
-	} else { // Queenside
-		if g.activeColor == White {
-			rookFrom, rookTo = A1, D1
-		} else {
-			rookFrom, rookTo = A8, D8
-		}
-	}
-
-	rook := g.board.GetPiece(rookFrom)
-	g.board.SetPiece(rookTo, rook)
-	g.board.SetPiece(rookFrom, Piece{Type: Empty})
+	g.applyCastling(g.board, move)
 }
 
 // executeEnPassant executes an en passant capture.
@@ -496,7 +543,7 @@ func (g *Game) executeEnPassant(move Move) {
 
 	// Remove the captured pawn
 	var capturedSquare Square
-	if g.activeColor == White {
+	if move.Piece.Color == White {
 		capturedSquare = Square(int(move.To) - 8)
 	} else {
 		capturedSquare = Square(int(move.To) + 8)
@@ -553,7 +600,8 @@ func (g *Game) isPawnMoveLegal(move Move) bool {
 		}
 		// Two squares from starting position
 		if (fromRank == 1 && move.Piece.Color == White) || (fromRank == 6 && move.Piece.Color == Black) {
-			if toRank-fromRank == 2*direction && g.board.GetPiece(move.To).IsEmpty() {
+			between := Square((fromRank+direction)*8 + move.From.File())
+			if toRank-fromRank == 2*direction && g.board.GetPiece(between).IsEmpty() && g.board.GetPiece(move.To).IsEmpty() {
 				return true
 			}
 		}
@@ -565,9 +613,10 @@ func (g *Game) isPawnMoveLegal(move Move) bool {
 		if !target.IsEmpty() && target.Color != move.Piece.Color {
 			return true
 		}
-		// En passant capture
-		if move.Type == EnPassant && move.To == g.enPassantSquare {
-			return true
+		// En passant capture: the pawn that just made a double step sits beside the mover
+		if move.Type == EnPassant && move.To == g.enPassantSquare && target.IsEmpty() {
+			captured := g.board.GetPiece(Square(move.From.Rank()*8 + move.To.File()))
+			return captured.Type == Pawn && captured.Color != move.Piece.Color
 		}
 	}
 
@@ -575,8 +624,8 @@ func (g *Game) isPawnMoveLegal(move Move) bool {
 }
 
 func (g *Game) isRookMoveLegal(move Move) bool {
-	return g.isPathClear(move.From, move.To) &&
-		(move.From.Rank() == move.To.Rank() || move.From.File() == move.To.File())
+	return (move.From.Rank() == move.To.Rank() || move.From.File() == move.To.File()) &&
+		g.isPathClear(move.From, move.To)
 }
 
 func (g *Game) isKnightMoveLegal(move Move) bool {
@@ -597,7 +646,10 @@ func (g *Game) isQueenMoveLegal(move Move) bool {
 
 func (g *Game) isKingMoveLegal(move Move) bool {
 	if move.Type == Castling {
-		return g.canCastle(move.To.File() > move.From.File())
+		kingside := move.isKingsideCastling()
+		plan, ok := g.castlingPlan(move.Piece.Color, kingside)
+		return ok && plan.kingFrom == move.From && plan.kingTo == move.To &&
+			g.castlingStatus(move.Piece.Color, kingside) == 0
 	}
 
 	fileDiff := abs(move.To.File() - move.From.File())
@@ -617,10 +669,14 @@ func (g *Game) isKingMoveLegal(move Move) bool {
 	return true
 }
 
-// isPathClear checks if the path between two squares is clear.
+// isPathClear checks if the path between two squares is clear. Squares that
+// share no rank, file or diagonal have no path.
 func (g *Game) isPathClear(from, to Square) bool {
 	fileDiff := to.File() - from.File()
 	rankDiff := to.Rank() - from.Rank()
+	if fileDiff != 0 && rankDiff != 0 && abs(fileDiff) != abs(rankDiff) {
+		return false
+	}
 
 	fileStep := sign(fileDiff)
 	rankStep := sign(rankDiff)
@@ -655,50 +711,6 @@ func sign(x int) int {
 	return 0
 }
 
-// Placeholder implementations for complex methods
-func (g *Game) updateCastlingRights(move Move) {
-	// If a king moves, remove both rights for that color
-	if move.Piece.Type == King {
-		if move.Piece.Color == White {
-			g.castlingRights.WhiteKingside = false
-			g.castlingRights.WhiteQueenside = false
-		} else {
-			g.castlingRights.BlackKingside = false
-			g.castlingRights.BlackQueenside = false
-		}
-	}
-
-	// If a rook moves from its original square, remove that side's right
-	if move.Piece.Type == Rook {
-		switch move.From {
-		case H1:
-			g.castlingRights.WhiteKingside = false
-		case A1:
-			g.castlingRights.WhiteQueenside = false
-		case H8:
-			g.castlingRights.BlackKingside = false
-		case A8:
-			g.castlingRights.BlackQueenside = false
-		}
-	}
-
-	// If a rook is captured on its original square, remove that side's right
-	if move.Type == Capture && move.Captured.Type == Rook {
-		switch move.To { // capture destination square contains captured rook
-		case H1:
-			g.castlingRights.WhiteKingside = false
-		case A1:
-			g.castlingRights.WhiteQueenside = false
-		case H8:
-			g.castlingRights.BlackKingside = false
-		case A8:
-			g.castlingRights.BlackQueenside = false
-		}
-	}
-
-	// If castling move, move the rook implicitly handled elsewhere; rights removed via king move logic above
-}
-
 func (g *Game) updateEnPassantSquare(move Move) {
 	g.enPassantSquare = -1 // Reset en passant square
 
@@ -716,129 +728,75 @@ func (g *Game) updateHalfMoveClock(move Move) {
 	}
 }
 
-func (g *Game) canCastle(kingside bool) bool {
-	// Use the detailed castling validation functions
-	if kingside {
-		return g.canCastleKingside(g.activeColor)
-	}
-	return g.canCastleQueenside(g.activeColor)
-}
-
 func (g *Game) isInCheck(color Color) bool {
-	// Find the king
-	kingSquare := Square(-1)
-	for sq := Square(0); sq < 64; sq++ {
-		piece := g.board.GetPiece(sq)
-		if piece.Type == King && piece.Color == color {
-			kingSquare = sq
-			break
-		}
-	}
-
+	kingSquare := g.board.kingSquare(color)
 	if kingSquare == -1 {
 		return false // King not found
 	}
-
-	// Check if any opponent piece can attack the king
-	opponentColor := White
-	if color == White {
-		opponentColor = Black
-	}
-
-	// Check all opponent pieces to see if they can attack the king
-	for sq := Square(0); sq < 64; sq++ {
-		piece := g.board.GetPiece(sq)
-		if piece.IsEmpty() || piece.Color != opponentColor {
-			continue
-		}
-
-		// Generate pseudo-legal moves for the opponent piece
-		moves := g.generatePseudoLegalMoves(sq, piece)
-
-		// Check if any move attacks the king
-		for _, move := range moves {
-			if move.To == kingSquare {
-				return true
-			}
-		}
-	}
-
-	return false
+	return g.board.isAttacked(kingSquare, color.Opposite())
 }
 
 // GetAllLegalMoves generates all legal moves for the current player
 func (g *Game) GetAllLegalMoves() []Move {
-	var legalMoves []Move
-
-	// Iterate through all squares
-	for rank := 0; rank < 8; rank++ {
-		for file := 0; file < 8; file++ {
-			square := Square(rank*8 + file)
-			piece := g.board.GetPiece(square)
-
-			// Skip empty squares and opponent pieces
-			if piece.IsEmpty() || piece.Color != g.activeColor {
-				continue
-			}
-
-			// Generate pseudo-legal moves for this piece
-			moves := g.generatePseudoLegalMoves(square, piece)
-
-			// Filter out illegal moves (those that leave king in check)
-			for _, move := range moves {
-				if g.IsLegalMove(move) {
-					legalMoves = append(legalMoves, move)
-				}
-			}
-		}
-	}
-
-	return legalMoves
+	return g.GenerateLegalMoves(nil)
 }
 
-// generatePseudoLegalMoves generates all pseudo-legal moves for a piece at the given square
-func (g *Game) generatePseudoLegalMoves(from Square, piece Piece) []Move {
-	var moves []Move
-	pieceType := piece.Type
-
-	switch pieceType {
+// appendPseudoLegalMoves appends all pseudo-legal moves for a piece at the given square
+func (g *Game) appendPseudoLegalMoves(moves []Move, from Square, piece Piece) []Move {
+	switch piece.Type {
 	case Pawn:
-		moves = append(moves, g.generatePawnMoves(from)...)
+		return g.appendPawnMoves(moves, from, piece)
 	case Rook:
-		moves = append(moves, g.generateRookMoves(from)...)
+		return g.appendSlidingMoves(moves, from, piece, rookDirections)
 	case Knight:
-		moves = append(moves, g.generateKnightMoves(from)...)
+		return g.appendStepMoves(moves, from, piece, knightOffsets)
 	case Bishop:
-		moves = append(moves, g.generateBishopMoves(from)...)
+		return g.appendSlidingMoves(moves, from, piece, bishopDirections)
 	case Queen:
-		moves = append(moves, g.generateQueenMoves(from)...)
+		return g.appendSlidingMoves(moves, from, piece, queenDirections)
 	case King:
-		moves = append(moves, g.generateKingMoves(from)...)
+		moves = g.appendStepMoves(moves, from, piece, kingOffsets)
+		return g.appendCastlingMoves(moves, from, piece)
 	}
-
 	return moves
 }
 
-// generatePawnMoves generates all pseudo-legal pawn moves
-func (g *Game) generatePawnMoves(from Square) []Move {
-	var moves []Move
-	piece := g.board.GetPiece(from)
+// promotionPieces lists the promotion choices, best first.
+var promotionPieces = [4]PieceType{Queen, Rook, Bishop, Knight}
+
+// appendPawnMoves appends all pseudo-legal pawn moves
+func (g *Game) appendPawnMoves(moves []Move, from Square, piece Piece) []Move {
 	color := piece.Color
 
 	direction := 1
 	startRank := 1
+	lastRank := 7
 	if color == Black {
 		direction = -1
 		startRank = 6
+		lastRank = 0
 	}
 
 	rank := int(from / 8)
 	file := int(from % 8)
 
+	// appendStep adds a move, or the four promotions when the pawn reaches the last rank
+	appendStep := func(moves []Move, move Move) []Move {
+		if move.To.Rank() != lastRank {
+			return append(moves, move)
+		}
+		move.Type = Promotion
+		for _, pt := range promotionPieces {
+			move.Promotion = pt
+			moves = append(moves, move)
+		}
+		return moves
+	}
+
 	// Forward move
 	toSquare := Square((rank+direction)*8 + file)
 	if rank+direction >= 0 && rank+direction < 8 && g.board.GetPiece(toSquare).IsEmpty() {
-		moves = append(moves, Move{From: from, To: toSquare, Type: Normal, Piece: piece})
+		moves = appendStep(moves, Move{From: from, To: toSquare, Type: Normal, Piece: piece})
 
 		// Double move from starting position
 		if rank == startRank {
@@ -850,14 +808,17 @@ func (g *Game) generatePawnMoves(from Square) []Move {
 	}
 
 	// Captures
-	for _, fileOffset := range []int{-1, 1} {
+	for _, fileOffset := range [2]int{-1, 1} {
 		newFile := file + fileOffset
 		newRank := rank + direction
 		if newFile >= 0 && newFile < 8 && newRank >= 0 && newRank < 8 {
 			toSquare := Square(newRank*8 + newFile)
 			targetPiece := g.board.GetPiece(toSquare)
 			if !targetPiece.IsEmpty() && targetPiece.Color != color {
-				moves = append(moves, Move{From: from, To: toSquare, Type: Normal, Piece: piece})
+				moves = appendStep(moves, Move{From: from, To: toSquare, Type: Capture, Piece: piece, Captured: targetPiece})
+			} else if toSquare == g.enPassantSquare && color == g.activeColor {
+				moves = append(moves, Move{From: from, To: toSquare, Type: EnPassant, Piece: piece,
+					Captured: Piece{Type: Pawn, Color: color.Opposite()}})
 			}
 		}
 	}
@@ -865,12 +826,8 @@ func (g *Game) generatePawnMoves(from Square) []Move {
 	return moves
 }
 
-// generateSlidingMoves generates moves for sliding pieces (rook, bishop, queen)
-func (g *Game) generateSlidingMoves(from Square, directions [][]int) []Move {
-	var moves []Move
-	piece := g.board.GetPiece(from)
-	color := piece.Color
-
+// appendSlidingMoves appends moves for sliding pieces (rook, bishop, queen)
+func (g *Game) appendSlidingMoves(moves []Move, from Square, piece Piece, directions [][2]int) []Move {
 	rank := int(from / 8)
 	file := int(from % 8)
 
@@ -889,8 +846,8 @@ func (g *Game) generateSlidingMoves(from Square, directions [][]int) []Move {
 			if targetPiece.IsEmpty() {
 				moves = append(moves, Move{From: from, To: toSquare, Type: Normal, Piece: piece})
 			} else {
-				if targetPiece.Color != color {
-					moves = append(moves, Move{From: from, To: toSquare, Type: Normal, Piece: piece})
+				if targetPiece.Color != piece.Color {
+					moves = append(moves, Move{From: from, To: toSquare, Type: Capture, Piece: piece, Captured: targetPiece})
 				}
 				break
 			}
@@ -900,214 +857,61 @@ func (g *Game) generateSlidingMoves(from Square, directions [][]int) []Move {
 	return moves
 }
 
-// generateKnightMoves generates all pseudo-legal knight moves
-func (g *Game) generateKnightMoves(from Square) []Move {
-	var moves []Move
-	piece := g.board.GetPiece(from)
-	color := piece.Color
-
-	rank := int(from / 8)
-	file := int(from % 8)
-
-	knightMoves := [][]int{{2, 1}, {2, -1}, {-2, 1}, {-2, -1}, {1, 2}, {1, -2}, {-1, 2}, {-1, -2}}
-
-	for _, move := range knightMoves {
-		newRank := rank + move[0]
-		newFile := file + move[1]
-
-		if newRank >= 0 && newRank < 8 && newFile >= 0 && newFile < 8 {
-			toSquare := Square(newRank*8 + newFile)
-			targetPiece := g.board.GetPiece(toSquare)
-
-			if targetPiece.IsEmpty() || targetPiece.Color != color {
-				moves = append(moves, Move{From: from, To: toSquare, Type: Normal, Piece: piece})
-			}
-		}
-	}
-
-	return moves
-}
-
-// generateRookMoves generates all pseudo-legal rook moves
-func (g *Game) generateRookMoves(from Square) []Move {
-	return g.generateSlidingMoves(from, [][]int{{0, 1}, {0, -1}, {1, 0}, {-1, 0}})
-}
-
-// generateBishopMoves generates all pseudo-legal bishop moves
-func (g *Game) generateBishopMoves(from Square) []Move {
-	return g.generateSlidingMoves(from, [][]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}})
-}
-
-// generateQueenMoves generates all pseudo-legal queen moves
-func (g *Game) generateQueenMoves(from Square) []Move {
-	return g.generateSlidingMoves(from, [][]int{{0, 1}, {0, -1}, {1, 0}, {-1, 0}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}})
-}
-
-// generateKingMoves generates all pseudo-legal king moves
-func (g *Game) generateKingMoves(from Square) []Move {
-	var moves []Move
-	piece := g.board.GetPiece(from)
-	color := piece.Color
-
+// appendStepMoves appends single-step moves for knights and kings (without castling)
+func (g *Game) appendStepMoves(moves []Move, from Square, piece Piece, offsets [][2]int) []Move {
 	rank := int(from / 8)
 	file := int(from % 8)
 
-	kingMoves := [][]int{{0, 1}, {0, -1}, {1, 0}, {-1, 0}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
-
-	for _, move := range kingMoves {
-		newRank := rank + move[0]
-		newFile := file + move[1]
+	for _, offset := range offsets {
+		newRank := rank + offset[0]
+		newFile := file + offset[1]
 
 		if newRank >= 0 && newRank < 8 && newFile >= 0 && newFile < 8 {
 			toSquare := Square(newRank*8 + newFile)
 			targetPiece := g.board.GetPiece(toSquare)
 
-			if targetPiece.IsEmpty() || targetPiece.Color != color {
+			if targetPiece.IsEmpty() {
 				moves = append(moves, Move{From: from, To: toSquare, Type: Normal, Piece: piece})
+			} else if targetPiece.Color != piece.Color {
+				moves = append(moves, Move{From: from, To: toSquare, Type: Capture, Piece: piece, Captured: targetPiece})
 			}
 		}
 	}
 
-	// Add castling moves
-	moves = append(moves, g.generateCastlingMoves(from)...)
-
 	return moves
 }
 
-// generateCastlingMoves generates castling moves for the king
-func (g *Game) generateCastlingMoves(from Square) []Move {
-	var moves []Move
-	piece := g.board.GetPiece(from)
-	color := piece.Color
-
-	// Only generate castling moves if the king is on its starting square
-	expectedKingSquare := E1
-	if color == Black {
-		expectedKingSquare = E8
-	}
-
-	if from != expectedKingSquare {
-		return moves
-	}
-
-	// Try kingside castling
-	if g.canCastleKingside(color) {
-		kingsideMove, err := g.parseCastlingMove(true)
-		if err == nil {
-			moves = append(moves, kingsideMove)
+// appendCastlingMoves appends castling moves for the king
+func (g *Game) appendCastlingMoves(moves []Move, from Square, piece Piece) []Move {
+	for _, kingside := range [2]bool{true, false} {
+		if g.castlingStatus(piece.Color, kingside) != 0 {
+			continue
 		}
-	}
-
-	// Try queenside castling
-	if g.canCastleQueenside(color) {
-		queensideMove, err := g.parseCastlingMove(false)
-		if err == nil {
-			moves = append(moves, queensideMove)
+		if plan, ok := g.castlingPlan(piece.Color, kingside); ok && plan.kingFrom == from {
+			moves = append(moves, Move{From: from, To: plan.kingTo, Type: Castling, Piece: piece})
 		}
 	}
-
 	return moves
 }
 
-// canCastleKingside checks if kingside castling is possible for the given color
-func (g *Game) canCastleKingside(color Color) bool {
-	// Check castling rights
-	if color == White && !g.castlingRights.WhiteKingside {
-		return false
-	}
-	if color == Black && !g.castlingRights.BlackKingside {
-		return false
-	}
-
-	// Check if squares between king and rook are empty
-	kingSquare := E1
-	rookSquare := H1
-	if color == Black {
-		kingSquare = E8
-		rookSquare = H8
-	}
-
-	// Check squares F and G (between king and rook)
-	for square := kingSquare + 1; square < rookSquare; square++ {
-		if !g.board.GetPiece(square).IsEmpty() {
-			return false
-		}
-	}
-
-	// Check if king is currently in check
-	if g.isInCheck(color) {
-		return false
-	}
-
-	// Check if king passes through or ends up in check
-	for square := kingSquare; square <= kingSquare+2; square++ {
-		// Create a temporary move to test
-		tempMove := Move{From: kingSquare, To: square, Type: Normal, Piece: g.board.GetPiece(kingSquare)}
-		if square != kingSquare && g.wouldBeInCheckAfterMove(tempMove, color) {
-			return false
-		}
-	}
-
-	return true
-}
-
-// canCastleQueenside checks if queenside castling is possible for the given color
-func (g *Game) canCastleQueenside(color Color) bool {
-	// Check castling rights
-	if color == White && !g.castlingRights.WhiteQueenside {
-		return false
-	}
-	if color == Black && !g.castlingRights.BlackQueenside {
-		return false
-	}
-
-	// Check if squares between king and rook are empty
-	kingSquare := E1
-	rookSquare := A1
-	if color == Black {
-		kingSquare = E8
-		rookSquare = A8
-	}
-
-	// Check squares B, C, D (between rook and king)
-	for square := rookSquare + 1; square < kingSquare; square++ {
-		if !g.board.GetPiece(square).IsEmpty() {
-			return false
-		}
-	}
-
-	// Check if king is currently in check
-	if g.isInCheck(color) {
-		return false
-	}
-
-	// Check if king passes through or ends up in check
-	for square := kingSquare; square >= kingSquare-2; square-- {
-		// Create a temporary move to test
-		tempMove := Move{From: kingSquare, To: square, Type: Normal, Piece: g.board.GetPiece(kingSquare)}
-		if square != kingSquare && g.wouldBeInCheckAfterMove(tempMove, color) {
-			return false
-		}
-	}
-
-	return true
-}
-
 // wouldBeInCheckAfterMove checks if the king would be in check after a given move
 func (g *Game) wouldBeInCheckAfterMove(move Move, kingColor Color) bool {
-	// Create a copy of the game to test the move
-	gameCopy := g.copy()
-
-	// Make the move on the copy using makeMoveWithoutStatusUpdate to avoid recursion
-	gameCopy.makeMoveWithoutStatusUpdate(move)
-
-	// Check if the king is in check in the resulting position
-	return gameCopy.isInCheck(kingColor)
+	// Play the move on a scratch board; the game itself is never touched
+	board := *g.board
+	if move.Type == Castling {
+		g.applyCastling(&board, move)
+	} else {
+		board.applyMove(move)
+	}
+	kingSquare := board.kingSquare(kingColor)
+	return kingSquare != -1 && board.isAttacked(kingSquare, kingColor.Opposite())
 }
 
 func (g *Game) updateGameStatus() {
 	// Check for checkmate, stalemate, draw conditions
 	legalMoves := g.GetAllLegalMoves()
+	g.drawReason = NoDraw
+	g.termination = TerminationNone
 
 	if len(legalMoves) == 0 {
 		// No legal moves available
@@ -1121,7 +925,12 @@ func (g *Game) updateGameStatus() {
 		} else {
 			// King is not in check but has no legal moves = stalemate
 			g.status = Draw
+			g.drawReason = DrawStalemate
 		}
+	} else if reason := g.automaticDraw(); reason != NoDraw {
+		// Checkmate takes precedence; otherwise these draws need no claim
+		g.status = Draw
+		g.drawReason = reason
 	} else {
 		// Game continues - check if king is in check
 		if g.isInCheck(g.activeColor) {
@@ -1151,6 +960,9 @@ func (g *Game) ToFEN() string {
 					emptyCount = 0
 				}
 				fen.WriteString(g.pieceToFENChar(piece))
+				if g.variant == Crazyhouse && g.promoted&(1<<uint(square)) != 0 {
+					fen.WriteString("~")
+				}
 			}
 		}
 		if emptyCount > 0 {
@@ -1160,6 +972,9 @@ func (g *Game) ToFEN() string {
 			fen.WriteString("/")
 		}
 	}
+	if g.variant == Crazyhouse {
+		fen.WriteString(g.pocketsToFEN())
+	}
 
 	// 2. Active color
 	fen.WriteString(" ")
@@ -1171,23 +986,7 @@ func (g *Game) ToFEN() string {
 
 	// 3. Castling rights
 	fen.WriteString(" ")
-	castling := ""
-	if g.castlingRights.WhiteKingside {
-		castling += "K"
-	}
-	if g.castlingRights.WhiteQueenside {
-		castling += "Q"
-	}
-	if g.castlingRights.BlackKingside {
-		castling += "k"
-	}
-	if g.castlingRights.BlackQueenside {
-		castling += "q"
-	}
-	if castling == "" {
-		castling = "-"
-	}
-	fen.WriteString(castling)
+	fen.WriteString(g.castlingToFEN(g.shredderCastling))
 
 	// 4. En passant square
 	fen.WriteString(" ")
@@ -1235,14 +1034,32 @@ func (g *Game) pieceToFENChar(piece Piece) string {
 // ParseFEN loads a position from a FEN string into the current game.
 // Supported fields: piece placement, active color, castling rights, en passant square,
 // halfmove clock, fullmove number. Move history and status are reset and then status recalculated.
+// The game is then played under the variant the FEN describes: Crazyhouse with a
+// pocket ("[Qp]" or a ninth "/Qp" rank), standard chess otherwise.
 func (g *Game) ParseFEN(fen string) error {
 	parts := strings.Fields(strings.TrimSpace(fen))
 	if len(parts) < 4 {
 		return fmt.Errorf("invalid FEN: expected at least 4 fields, got %d", len(parts))
 	}
 
-	// 1. Piece placement
-	ranks := strings.Split(parts[0], "/")
+	// 1. Piece placement (plus optional Crazyhouse pocket)
+	placement, pocketStr, hasPocket, err := splitPocketFEN(parts[0])
+	if err != nil {
+		return err
+	}
+	g.pockets = [2]Pocket{}
+	g.promoted = 0
+	g.variant = Standard
+	if hasPocket {
+		pockets, err := parsePocketsFEN(pocketStr)
+		if err != nil {
+			return err
+		}
+		g.pockets = pockets
+		g.variant = Crazyhouse
+	}
+
+	ranks := strings.Split(placement, "/")
 	if len(ranks) != 8 {
 		return fmt.Errorf("invalid FEN: expected 8 ranks, got %d", len(ranks))
 	}
@@ -1263,6 +1080,14 @@ func (g *Game) ParseFEN(fen string) error {
 				file += skip
 				continue
 			}
+			if ch == '~' {
+				// Promoted-piece marker (Crazyhouse) applies to the previous square
+				if file == 0 {
+					return fmt.Errorf("invalid FEN rank %d: misplaced promotion marker", 8-rankIdx)
+				}
+				g.promoted |= 1 << uint((7-rankIdx)*8+file-1)
+				continue
+			}
 			var pieceType PieceType
 			var color Color
 			switch ch {
@@ -1306,24 +1131,13 @@ func (g *Game) ParseFEN(fen string) error {
 	}
 
 	// 3. Castling rights
-	castling := parts[2]
-	g.castlingRights = CastlingRights{}
-	if castling != "-" {
-		for _, ch := range castling {
-			switch ch {
-			case 'K':
-				g.castlingRights.WhiteKingside = true
-			case 'Q':
-				g.castlingRights.WhiteQueenside = true
-			case 'k':
-				g.castlingRights.BlackKingside = true
-			case 'q':
-				g.castlingRights.BlackQueenside = true
-			default:
-				return fmt.Errorf("invalid castling char: %c", ch)
-			}
-		}
+	rights, rooks, shredder, err := g.parseCastlingField(parts[2])
+	if err != nil {
+		return err
 	}
+	g.castlingRights = rights
+	g.castlingRooks = rooks
+	g.shredderCastling = shredder
 
 	// 4. En passant square
 	enPassant := parts[3]
@@ -1360,6 +1174,10 @@ func (g *Game) ParseFEN(fen string) error {
 
 	// Reset move history and recalc status
 	g.moveHistory = nil
+	g.timings = nil
+	g.annotations = nil
+	g.positionHashes = []uint64{g.Hash()}
+	g.nullMoves = nil
 	g.status = InProgress
 	g.startedFromFEN = true
 	g.startingFEN = fen
@@ -1373,42 +1191,6 @@ func (g *Game) StartedFromFEN() bool { return g.startedFromFEN }
 // StartingFEN returns the original starting FEN if provided.
 func (g *Game) StartingFEN() string { return g.startingFEN }
 
-// Evaluate returns a simple material + activity evaluation (centipawns from White's perspective).
-func (g *Game) Evaluate() int {
-	values := map[PieceType]int{
-		Pawn:   100,
-		Knight: 320,
-		Bishop: 330,
-		Rook:   500,
-		Queen:  900,
-		King:   0,
-	}
-	score := 0
-	for sq := Square(0); sq < 64; sq++ {
-		p := g.board.GetPiece(sq)
-		if p.IsEmpty() {
-			continue
-		}
-		v := values[p.Type]
-		if p.Color == White {
-			score += v
-		} else {
-			score -= v
-		}
-		// Small central control bonus
-		file := sq.File()
-		rank := sq.Rank()
-		if file >= 2 && file <= 5 && rank >= 2 && rank <= 5 { // 16 central squares
-			if p.Color == White {
-				score += 5
-			} else {
-				score -= 5
-			}
-		}
-	}
-	return score
-}
-
 // GenerateSAN returns SAN strings for the game's move history.
 // It reconstructs moves from the starting position (initial or loaded FEN) to ensure correctness.
 func (g *Game) GenerateSAN() []string {
@@ -1416,10 +1198,10 @@ func (g *Game) GenerateSAN() []string {
 	// Recreate starting position
 	var replay *Game
 	if g.startedFromFEN && g.startingFEN != "" {
-		replay = NewGame()
+		replay = NewGameWithVariant(g.variant)
 		_ = replay.ParseFEN(g.startingFEN) // ignore error: stored FEN assumed valid
 	} else {
-		replay = NewGame()
+		replay = NewGameWithVariant(g.variant)
 	}
 	for _, mv := range g.moveHistory {
 		san = append(san, replay.sanForMove(mv))
@@ -1429,11 +1211,30 @@ func (g *Game) GenerateSAN() []string {
 	return san
 }
 
+// SANLine converts moves played in sequence from the current position, e.g. a
+// principal variation, to SAN. It stops with an error at the first illegal move.
+func (g *Game) SANLine(moves []Move) ([]string, error) {
+	replay := g.copy()
+	san := make([]string, 0, len(moves))
+	for _, mv := range moves {
+		notation := replay.sanForMove(mv)
+		if err := replay.MakeMove(mv); err != nil {
+			return san, err
+		}
+		san = append(san, notation)
+	}
+	return san, nil
+}
+
 // sanForMove computes SAN for a move given the current position (before move is applied).
 func (g *Game) sanForMove(m Move) string {
+	if m.Type == Drop {
+		return m.String() + g.checkSuffix(m)
+	}
+
 	piece := g.board.GetPiece(m.From)
 	if piece.Type == King && m.Type == Castling {
-		if m.To.File() > m.From.File() {
+		if m.isKingsideCastling() {
 			return "O-O"
 		}
 		return "O-O-O"
@@ -1512,15 +1313,21 @@ func (g *Game) sanForMove(m Move) string {
 		}
 	}
 
+	sb.WriteString(g.checkSuffix(m))
+	return sb.String()
+}
+
+// checkSuffix returns "#", "+" or "" depending on the position after the move.
+func (g *Game) checkSuffix(m Move) string {
 	// Determine check / mate after move using full MakeMove (which switches side & updates status)
 	gameCopy := g.copy()
 	_ = gameCopy.MakeMove(m) // ignore error; original move already known legal
 	if gameCopy.status == WhiteWins || gameCopy.status == BlackWins {
-		sb.WriteByte('#')
+		return "#"
 	} else if gameCopy.isInCheck(gameCopy.activeColor) { // after MakeMove, activeColor is opponent
-		sb.WriteByte('+')
+		return "+"
 	}
-	return sb.String()
+	return ""
 }
 
 // disambiguation determines if file/rank disambiguation is needed for a piece move.
@@ -1538,7 +1345,7 @@ func (g *Game) disambiguation(piece Piece, move Move) (needFile bool, needRank b
 			continue
 		}
 		// Generate pseudo-legal moves for that piece
-		candidates := g.generatePseudoLegalMoves(sq, p)
+		candidates := g.appendPseudoLegalMoves(nil, sq, p)
 		for _, cand := range candidates {
 			if cand.To != move.To || !g.IsLegalMove(cand) {
 				continue
@@ -1560,26 +1367,68 @@ func (g *Game) disambiguation(piece Piece, move Move) (needFile bool, needRank b
 	return
 }
 
+// Clone returns an independent copy of the game for look-ahead, e.g. by a search
+// that plays and undoes moves. The position, move history and repetition state are
+// copied; the clock, observers and undo snapshots are not, so moves made before the
+// clone cannot be undone on it.
+func (g *Game) Clone() *Game {
+	return g.copy()
+}
+
 func (g *Game) copy() *Game {
 	newGame := &Game{
 		board:           g.board.Copy(),
 		activeColor:     g.activeColor,
 		castlingRights:  g.castlingRights,
+		castlingRooks:   g.castlingRooks,
 		enPassantSquare: g.enPassantSquare,
 		halfMoveClock:   g.halfMoveClock,
 		moveCount:       g.moveCount,
 		status:          g.status,
+		drawReason:      g.drawReason,
+		termination:     g.termination,
+		variant:         g.variant,
+		pockets:         g.pockets,
+		promoted:        g.promoted,
 	}
+	newGame.shredderCastling = g.shredderCastling
+	newGame.startedFromFEN = g.startedFromFEN
+	newGame.startingFEN = g.startingFEN
 
 	newGame.moveHistory = make([]Move, len(g.moveHistory))
 	copy(newGame.moveHistory, g.moveHistory)
+	newGame.timings = make([]MoveTiming, len(g.timings))
+	copy(newGame.timings, g.timings)
+	if len(g.annotations) > 0 {
+		newGame.annotations = make([]Annotation, len(g.annotations))
+		copy(newGame.annotations, g.annotations)
+	}
+	// Share the hash history; the capped slice forces a reallocation on append.
+	newGame.positionHashes = g.positionHashes[:len(g.positionHashes):len(g.positionHashes)]
 
 	return newGame
 }
 
+// Replace gives the game the whole state of other, clock included, as when
+// another server changed a shared game, keeping its own observers. Other must
+// not be used afterwards.
+func (g *Game) Replace(other *Game) {
+	observers, nextObserverID, timestamps := g.observers, g.nextObserverID, g.timestamps
+	*g = *other
+	g.observers, g.nextObserverID = observers, nextObserverID
+	if timestamps {
+		g.RecordTimestamps()
+	}
+}
+
 // pushState saves a lightweight snapshot for undo before a move is applied.
 func (g *Game) pushState() {
-	st := gameState{
+	g.stateStack = append(g.stateStack, g.snapshot())
+}
+
+// snapshot captures the position and status for later restoreState.
+func (g *Game) snapshot() gameState {
+	return gameState{
 		board:           g.board.Copy(),
 		activeColor:     g.activeColor,
 		castlingRights:  g.castlingRights,
@@ -1587,8 +1436,11 @@ func (g *Game) pushState() {
 		halfMoveClock:   g.halfMoveClock,
 		moveCount:       g.moveCount,
 		status:          g.status,
+		drawReason:      g.drawReason,
+		termination:     g.termination,
+		pockets:         g.pockets,
+		promoted:        g.promoted,
 	}
-	g.stateStack = append(g.stateStack, st)
 }
 
 // UndoMove reverts the last move if possible, restoring prior game state.
@@ -1596,11 +1448,31 @@ func (g *Game) UndoMove() (Move, error) {
 	if len(g.moveHistory) == 0 || len(g.stateStack) == 0 {
 		return Move{}, errors.New("no move to undo")
 	}
+	if g.pendingNullMove() {
+		return Move{}, errors.New("undo the null move first")
+	}
 	mv := g.moveHistory[len(g.moveHistory)-1]
 	g.moveHistory = g.moveHistory[:len(g.moveHistory)-1]
+	if len(g.timings) > 0 {
+		g.timings = g.timings[:len(g.timings)-1]
+	}
+	if len(g.annotations) > len(g.moveHistory) {
+		g.annotations = g.annotations[:len(g.moveHistory)]
+	}
+	if len(g.positionHashes) > 1 {
+		g.positionHashes = g.positionHashes[:len(g.positionHashes)-1]
+	}
 	st := g.stateStack[len(g.stateStack)-1]
 	g.stateStack = g.stateStack[:len(g.stateStack)-1]
-	// Restore snapshot
+	g.restoreState(st)
+	if g.timestamps {
+		g.turnStarted = time.Now()
+	}
+	return mv, nil
+}
+
+// restoreState restores a snapshot taken by pushState or MakeNullMove.
+func (g *Game) restoreState(st gameState) {
 	g.board = st.board.Copy()
 	g.activeColor = st.activeColor
 	g.castlingRights = st.castlingRights
@@ -1608,7 +1480,10 @@ func (g *Game) UndoMove() (Move, error) {
 	g.halfMoveClock = st.halfMoveClock
 	g.moveCount = st.moveCount
 	g.status = st.status
-	return mv, nil
+	g.drawReason = st.drawReason
+	g.termination = st.termination
+	g.pockets = st.pockets
+	g.promoted = st.promoted
 }
 
 // popState removes the latest snapshot without restoring (unused but kept for completeness).
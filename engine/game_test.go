@@ -135,6 +135,88 @@ func TestBasicMove(t *testing.T) {
 	}
 }
 
+func TestStateAccessors(t *testing.T) {
+	game := NewGame()
+	if _, ok := game.EnPassantSquare(); ok {
+		t.Error("Expected no en passant square at the start")
+	}
+	if game.CastlingRights() != (CastlingRights{true, true, true, true}) {
+		t.Errorf("Expected full castling rights, got %+v", game.CastlingRights())
+	}
+
+	playAll(t, game, "e2e4", "g8f6", "e1e2")
+	if game.HalfMoveClock() != 2 {
+		t.Errorf("Expected half-move clock 2 after knight and king moves, got %d", game.HalfMoveClock())
+	}
+	if cr := game.CastlingRights(); cr.WhiteKingside || cr.WhiteQueenside || !cr.BlackKingside {
+		t.Errorf("Expected White to lose castling rights, got %+v", cr)
+	}
+
+	playAll(t, game, "d7d5")
+	if sq, ok := game.EnPassantSquare(); !ok || sq != D6 {
+		t.Errorf("Expected en passant square d6, got %v %v", sq, ok)
+	}
+	if game.HalfMoveClock() != 0 {
+		t.Errorf("Expected pawn move to reset the half-move clock, got %d", game.HalfMoveClock())
+	}
+}
+
+func TestPawnDoubleStepBlocked(t *testing.T) {
+	tests := []struct {
+		fen, move string
+		legal     bool
+	}{
+		{"r1bqkbnr/1pp3pp/p1p2p2/8/3NP3/8/PPP2PPP/RNBQ1RK1 b kq - 0 7", "c7c5", false},
+		{"r1bqkbnr/1pp3pp/p1p2p2/8/3NP3/8/PPP2PPP/RNBQ1RK1 b kq - 0 7", "c6c5", true},
+		{"4k3/8/8/8/8/4N3/4P3/4K3 w - - 0 1", "e2e4", false},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", "e2e4", true},
+	}
+	for _, tt := range tests {
+		game := NewGame()
+		if err := game.ParseFEN(tt.fen); err != nil {
+			t.Fatalf("ParseFEN %s: %v", tt.fen, err)
+		}
+		mv, err := game.ParseMove(tt.move)
+		if err != nil {
+			t.Fatalf("ParseMove %s: %v", tt.move, err)
+		}
+		if got := game.IsLegalMove(mv); got != tt.legal {
+			t.Errorf("%s in %s: legal = %v, want %v", tt.move, tt.fen, got, tt.legal)
+		}
+	}
+}
+
+func TestQueenMoveOffLine(t *testing.T) {
+	game := NewGame()
+	if err := game.ParseFEN("r2qkb1r/2p2p2/1pnpb1p1/4p2p/2n5/8/3P1PPP/1q2N1KR b kq - 1 27"); err != nil {
+		t.Fatalf("ParseFEN: %v", err)
+	}
+	// Qd8-e1 is neither straight nor diagonal and must be rejected, not searched
+	if mv, err := game.ParseMove("d8e1"); err == nil && game.IsLegalMove(mv) {
+		t.Errorf("expected d8e1 to be illegal")
+	}
+	mv, err := game.MoveFromSAN("Qxe1#")
+	if err != nil || mv.From.String() != "b1" {
+		t.Errorf("expected Qxe1 from b1, got %v, %v", mv, err)
+	}
+}
+
+func TestPathClearOffLine(t *testing.T) {
+	game := NewGame()
+	if err := game.ParseFEN("4k3/8/8/8/8/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatalf("ParseFEN: %v", err)
+	}
+	// Squares off a line have no path, rather than one walking off the board
+	for _, pair := range [][2]Square{{A1, B3}, {D8, E1}, {H1, A2}} {
+		if game.isPathClear(pair[0], pair[1]) {
+			t.Errorf("expected no path from %s to %s", pair[0], pair[1])
+		}
+	}
+	if !game.isPathClear(A1, H8) || !game.isPathClear(A1, A8) {
+		t.Error("expected clear paths along the empty diagonal and file")
+	}
+}
+
 // Benchmark tests
 func BenchmarkNewGame(b *testing.B) {
 	for i := 0; i < b.N; i++ {
@@ -0,0 +1,266 @@
+package engine
+
+import "errors"
+
+// ErrIllegalMove matches every *IllegalMoveError with errors.Is.
+var ErrIllegalMove = errors.New("illegal move")
+
+// IllegalMoveReason classifies why a move is illegal.
+type IllegalMoveReason int
+
+const (
+	// IllegalGameOver means the game has already ended.
+	IllegalGameOver IllegalMoveReason = iota + 1
+	// IllegalNoPiece means the origin square is empty.
+	IllegalNoPiece
+	// IllegalWrongTurn means the piece belongs to the side not to move.
+	IllegalWrongTurn
+	// IllegalInvalidPattern means the piece cannot move that way.
+	IllegalInvalidPattern
+	// IllegalPathBlocked means another piece stands in the way.
+	IllegalPathBlocked
+	// IllegalOwnPiece means the destination holds a piece of the same color.
+	IllegalOwnPiece
+	// IllegalKingCapture means the move would capture a king.
+	IllegalKingCapture
+	// IllegalInvalidPromotion means the promotion piece or square is wrong.
+	IllegalInvalidPromotion
+	// IllegalPiecePinned means moving the piece would expose the king to check.
+	IllegalPiecePinned
+	// IllegalKingInCheck means the move does not get the king out of check.
+	IllegalKingInCheck
+	// IllegalKingIntoCheck means the king would move onto an attacked square.
+	IllegalKingIntoCheck
+	// IllegalCastlingRights means the king or rook has already moved.
+	IllegalCastlingRights
+	// IllegalCastlingBlocked means pieces stand between king and rook.
+	IllegalCastlingBlocked
+	// IllegalCastlingInCheck means castling is not allowed while in check.
+	IllegalCastlingInCheck
+	// IllegalCastlingThroughCheck means the king would pass through or land on an attacked square.
+	IllegalCastlingThroughCheck
+	// IllegalInvalidDrop means the piece cannot be dropped there (Crazyhouse).
+	IllegalInvalidDrop
+)
+
+// String returns the machine-readable code of the reason.
+func (r IllegalMoveReason) String() string {
+	switch r {
+	case IllegalGameOver:
+		return "game_over"
+	case IllegalNoPiece:
+		return "no_piece"
+	case IllegalWrongTurn:
+		return "wrong_turn"
+	case IllegalInvalidPattern:
+		return "invalid_pattern"
+	case IllegalPathBlocked:
+		return "path_blocked"
+	case IllegalOwnPiece:
+		return "own_piece"
+	case IllegalKingCapture:
+		return "king_capture"
+	case IllegalInvalidPromotion:
+		return "invalid_promotion"
+	case IllegalPiecePinned:
+		return "piece_pinned"
+	case IllegalKingInCheck:
+		return "king_in_check"
+	case IllegalKingIntoCheck:
+		return "king_into_check"
+	case IllegalCastlingRights:
+		return "castling_rights"
+	case IllegalCastlingBlocked:
+		return "castling_blocked"
+	case IllegalCastlingInCheck:
+		return "castling_in_check"
+	case IllegalCastlingThroughCheck:
+		return "castling_through_check"
+	case IllegalInvalidDrop:
+		return "invalid_drop"
+	default:
+		return "unknown"
+	}
+}
+
+// Description returns a human-readable explanation of the reason.
+func (r IllegalMoveReason) Description() string {
+	switch r {
+	case IllegalGameOver:
+		return "the game is over"
+	case IllegalNoPiece:
+		return "there is no piece on the origin square"
+	case IllegalWrongTurn:
+		return "it is the other side's turn"
+	case IllegalInvalidPattern:
+		return "the piece cannot move that way"
+	case IllegalPathBlocked:
+		return "the path is blocked"
+	case IllegalOwnPiece:
+		return "the destination is occupied by your own piece"
+	case IllegalKingCapture:
+		return "kings cannot be captured"
+	case IllegalInvalidPromotion:
+		return "invalid promotion"
+	case IllegalPiecePinned:
+		return "the piece is pinned to the king"
+	case IllegalKingInCheck:
+		return "the king remains in check"
+	case IllegalKingIntoCheck:
+		return "the king would move into check"
+	case IllegalCastlingRights:
+		return "castling rights have been lost"
+	case IllegalCastlingBlocked:
+		return "pieces stand between the king and the rook"
+	case IllegalCastlingInCheck:
+		return "cannot castle out of check"
+	case IllegalCastlingThroughCheck:
+		return "the king would pass through or land on an attacked square"
+	case IllegalInvalidDrop:
+		return "the piece cannot be dropped there"
+	default:
+		return "illegal move"
+	}
+}
+
+// IllegalMoveError explains why a move was rejected.
+type IllegalMoveError struct {
+	Move   Move
+	Reason IllegalMoveReason
+}
+
+// Error implements the error interface.
+func (e *IllegalMoveError) Error() string {
+	return "illegal move " + e.Move.String() + ": " + e.Reason.Description()
+}
+
+// Is makes errors.Is(err, ErrIllegalMove) true for every illegal move error.
+func (e *IllegalMoveError) Is(target error) bool {
+	return target == ErrIllegalMove
+}
+
+// ExplainIllegalMove returns nil if move is legal, and otherwise an *IllegalMoveError
+// with the first reason that applies.
+func (g *Game) ExplainIllegalMove(move Move) error {
+	if g.IsGameOver() {
+		return &IllegalMoveError{Move: move, Reason: IllegalGameOver}
+	}
+	if g.IsLegalMove(move) {
+		return nil
+	}
+	return &IllegalMoveError{Move: move, Reason: g.illegalMoveReason(move)}
+}
+
+// illegalMoveReason diagnoses a move that IsLegalMove rejected.
+func (g *Game) illegalMoveReason(move Move) IllegalMoveReason {
+	if move.Type == Drop {
+		if !g.isDropLegal(move) {
+			return IllegalInvalidDrop
+		}
+		return g.checkReason(move)
+	}
+
+	piece := g.board.GetPiece(move.From)
+	switch {
+	case piece.IsEmpty():
+		return IllegalNoPiece
+	case piece.Color != g.activeColor:
+		return IllegalWrongTurn
+	}
+	if move.Type == Castling {
+		if reason := g.castlingStatus(piece.Color, move.isKingsideCastling()); reason != 0 {
+			return reason
+		}
+		return IllegalInvalidPattern // castling squares do not match the position
+	}
+	target := g.board.GetPiece(move.To)
+	switch {
+	case !target.IsEmpty() && target.Color == piece.Color:
+		return IllegalOwnPiece
+	case target.Type == King:
+		return IllegalKingCapture
+	}
+	if move.Type == Promotion && !validPromotion(move) || move.Type != Promotion && reachesLastRank(piece, move.To) {
+		return IllegalInvalidPromotion
+	}
+	if !movesLike(piece, move.From, move.To) {
+		return IllegalInvalidPattern
+	}
+	if !g.isPseudoLegalMove(move) {
+		if piece.Type == Pawn && move.From.File() != move.To.File() {
+			return IllegalInvalidPattern // diagonal step without anything to capture
+		}
+		return IllegalPathBlocked
+	}
+	return g.checkReason(move)
+}
+
+// checkReason explains a pseudo-legal move that leaves the own king in check.
+func (g *Game) checkReason(move Move) IllegalMoveReason {
+	switch {
+	case move.Piece.Type == King && move.Type != Drop:
+		return IllegalKingIntoCheck
+	case g.isInCheck(g.activeColor):
+		return IllegalKingInCheck
+	default:
+		return IllegalPiecePinned
+	}
+}
+
+// validPromotion reports whether a promotion move reaches the last rank with a
+// knight, bishop, rook or queen.
+func validPromotion(move Move) bool {
+	lastRank := 7
+	if move.Piece.Color == Black {
+		lastRank = 0
+	}
+	switch move.Promotion {
+	case Knight, Bishop, Rook, Queen:
+		return move.Piece.Type == Pawn && move.To.Rank() == lastRank
+	default:
+		return false
+	}
+}
+
+// reachesLastRank reports whether a pawn moving to the square would have to promote.
+func reachesLastRank(piece Piece, to Square) bool {
+	if piece.Type != Pawn {
+		return false
+	}
+	if piece.Color == Black {
+		return to.Rank() == 0
+	}
+	return to.Rank() == 7
+}
+
+// movesLike reports whether a piece could move from one square to another on an
+// empty board (pawns may step diagonally forward).
+func movesLike(piece Piece, from, to Square) bool {
+	fileDiff := abs(to.File() - from.File())
+	rankDiff := abs(to.Rank() - from.Rank())
+	if fileDiff == 0 && rankDiff == 0 {
+		return false
+	}
+	switch piece.Type {
+	case Pawn:
+		direction, startRank := 1, 1
+		if piece.Color == Black {
+			direction, startRank = -1, 6
+		}
+		forward := (to.Rank() - from.Rank()) * direction
+		return (fileDiff == 0 && (forward == 1 || (forward == 2 && from.Rank() == startRank))) ||
+			(fileDiff == 1 && forward == 1)
+	case Knight:
+		return (fileDiff == 2 && rankDiff == 1) || (fileDiff == 1 && rankDiff == 2)
+	case Bishop:
+		return fileDiff == rankDiff
+	case Rook:
+		return fileDiff == 0 || rankDiff == 0
+	case Queen:
+		return fileDiff == rankDiff || fileDiff == 0 || rankDiff == 0
+	case King:
+		return fileDiff <= 1 && rankDiff <= 1
+	default:
+		return false
+	}
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExplainIllegalMove(t *testing.T) {
+	cases := []struct {
+		name string
+		fen  string
+		move string
+		want IllegalMoveReason
+	}{
+		{"pattern", "", "e2e5", IllegalInvalidPattern},
+		{"pawn diagonal", "", "e2d3", IllegalInvalidPattern},
+		{"blocked", "", "a1a3", IllegalPathBlocked},
+		{"double step blocked", "4k3/8/8/8/8/4N3/4P3/4K3 w - - 0 1", "e2e4", IllegalPathBlocked},
+		{"double step onto a piece", "4k3/8/8/8/4n3/8/4P3/4K3 w - - 0 1", "e2e4", IllegalPathBlocked},
+		{"own piece", "", "a1a2", IllegalOwnPiece},
+		{"promotion", "", "e2e3q", IllegalInvalidPromotion},
+		{"pinned", "4k3/4r3/8/8/8/8/4B3/4K3 w - - 0 1", "e2d3", IllegalPiecePinned},
+		{"in check", "4k3/8/8/8/8/8/3P4/r3K3 w - - 0 1", "d2d3", IllegalKingInCheck},
+		{"into check", "4k3/8/8/8/8/8/r7/4K3 w - - 0 1", "e1e2", IllegalKingIntoCheck},
+		{"castling rights", "4k3/8/8/8/8/8/8/4K2R w - - 0 1", "e1g1", IllegalCastlingRights},
+		{"castling blocked", "", "O-O", IllegalCastlingBlocked},
+		{"castling in check", "4k3/4r3/8/8/8/8/8/4K2R w K - 0 1", "O-O", IllegalCastlingInCheck},
+		{"castling through check", "4k3/5r2/8/8/8/8/8/4K2R w K - 0 1", "e1g1", IllegalCastlingThroughCheck},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGame()
+			if tc.fen != "" {
+				if err := g.ParseFEN(tc.fen); err != nil {
+					t.Fatal(err)
+				}
+			}
+			move, err := g.ParseMove(tc.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = g.ExplainIllegalMove(move)
+			var illegal *IllegalMoveError
+			if !errors.As(err, &illegal) || illegal.Reason != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+			if !errors.Is(err, ErrIllegalMove) {
+				t.Fatal("expected errors.Is(err, ErrIllegalMove)")
+			}
+			if err := g.MakeMove(move); err == nil || err.Error() != illegal.Error() {
+				t.Fatalf("MakeMove should report the same reason, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExplainIllegalMoveTurnAndGameOver(t *testing.T) {
+	g := NewGame()
+	if _, err := g.ParseMove("e7e5"); !errors.Is(err, ErrIllegalMove) {
+		t.Fatalf("expected wrong turn error from ParseMove, got %v", err)
+	}
+	black := Move{From: E7, To: E5, Piece: Piece{Type: Pawn, Color: Black}}
+	var illegal *IllegalMoveError
+	if err := g.ExplainIllegalMove(black); !errors.As(err, &illegal) || illegal.Reason != IllegalWrongTurn {
+		t.Fatalf("expected wrong turn, got %v", err)
+	}
+	if err := g.ExplainIllegalMove(Move{From: E4, To: E5}); !errors.As(err, &illegal) || illegal.Reason != IllegalNoPiece {
+		t.Fatalf("expected no piece, got %v", err)
+	}
+	castle, err := g.ParseMove("e1g1")
+	if err != nil || castle.Type != Castling {
+		t.Fatalf("expected e1g1 to parse as castling, got %+v err=%v", castle, err)
+	}
+
+	playAll(t, g, "f2f3", "e7e5", "g2g4", "d8h4")
+	move, _ := g.ParseMove("a2a3")
+	if err := g.ExplainIllegalMove(move); !errors.As(err, &illegal) || illegal.Reason != IllegalGameOver {
+		t.Fatalf("expected game over, got %v", err)
+	}
+
+	g = NewGame()
+	if err := g.Resign(Black); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.MakeMove(move); !errors.Is(err, ErrIllegalMove) {
+		t.Fatalf("expected moves after resignation to be rejected, got %v", err)
+	}
+}
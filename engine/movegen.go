@@ -0,0 +1,130 @@
+package engine
+
+// Direction and offset tables as {rank, file} deltas.
+var (
+	rookDirections   = [][2]int{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+	bishopDirections = [][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+	queenDirections  = [][2]int{{0, 1}, {0, -1}, {1, 0}, {-1, 0}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+	knightOffsets    = [][2]int{{2, 1}, {2, -1}, {-2, 1}, {-2, -1}, {1, 2}, {1, -2}, {-1, 2}, {-1, -2}}
+	kingOffsets      = queenDirections
+)
+
+// GenerateLegalMoves appends the legal moves of the side to move to buf[:0] and
+// returns the result. Passing the slice from a previous call lets search loops and
+// request handlers generate moves without allocating.
+func (g *Game) GenerateLegalMoves(buf []Move) []Move {
+	moves := buf[:0]
+	for sq := Square(0); sq < 64; sq++ {
+		piece := g.board.GetPiece(sq)
+		if piece.IsEmpty() || piece.Color != g.activeColor {
+			continue
+		}
+		moves = g.appendPseudoLegalMoves(moves, sq, piece)
+	}
+	// Pocket drops (Crazyhouse)
+	moves = g.appendDropMoves(moves)
+
+	// Filter in place: drop moves that capture a king or leave the own king in check
+	legal := moves[:0]
+	for _, move := range moves {
+		if move.Type != Drop && move.Type != Castling && g.board.GetPiece(move.To).Type == King {
+			continue
+		}
+		if !g.wouldBeInCheckAfterMove(move, g.activeColor) {
+			legal = append(legal, move)
+		}
+	}
+	return legal
+}
+
+// applyMove plays a move on the board only: pieces are moved, captured, promoted or
+// dropped, but no game state (castling rights, clocks, pockets) is updated. It is
+// used to test moves for check without copying the game. Castling needs the rook
+// squares kept by the game, see Game.applyCastling.
+func (b *Board) applyMove(move Move) {
+	switch move.Type {
+	case Drop:
+		b.SetPiece(move.To, move.Piece)
+		return
+	case EnPassant:
+		captured := move.To - 8
+		if move.Piece.Color == Black {
+			captured = move.To + 8
+		}
+		b.SetPiece(captured, Piece{Type: Empty})
+	}
+	b.SetPiece(move.From, Piece{Type: Empty})
+	if move.Type == Promotion {
+		b.SetPiece(move.To, Piece{Type: move.Promotion, Color: move.Piece.Color})
+	} else {
+		b.SetPiece(move.To, move.Piece)
+	}
+}
+
+// kingSquare returns the square of the given side's king, or -1 if there is none.
+func (b *Board) kingSquare(color Color) Square {
+	for sq := Square(0); sq < 64; sq++ {
+		if piece := b.squares[sq]; piece.Type == King && piece.Color == color {
+			return sq
+		}
+	}
+	return -1
+}
+
+// isAttacked reports whether any piece of the given side attacks the square, by
+// looking outward from the square instead of generating the attacker's moves.
+func (b *Board) isAttacked(sq Square, by Color) bool {
+	rank, file := sq.Rank(), sq.File()
+
+	// Pawns attack diagonally forward, so look one rank back from the attacker's side
+	pawnRank := rank - 1
+	if by == Black {
+		pawnRank = rank + 1
+	}
+	for _, df := range [2]int{-1, 1} {
+		if b.attackerAt(rank, file, pawnRank-rank, df, by, Pawn) {
+			return true
+		}
+	}
+	for _, o := range knightOffsets {
+		if b.attackerAt(rank, file, o[0], o[1], by, Knight) {
+			return true
+		}
+	}
+	for _, o := range kingOffsets {
+		if b.attackerAt(rank, file, o[0], o[1], by, King) {
+			return true
+		}
+	}
+	return b.slidingAttack(rank, file, rookDirections, by, Rook) ||
+		b.slidingAttack(rank, file, bishopDirections, by, Bishop)
+}
+
+// attackerAt reports whether the square at the given offset holds a piece of the
+// given side and type.
+func (b *Board) attackerAt(rank, file, dr, df int, by Color, pt PieceType) bool {
+	r, f := rank+dr, file+df
+	if r < 0 || r > 7 || f < 0 || f > 7 {
+		return false
+	}
+	piece := b.squares[r*8+f]
+	return piece.Type == pt && piece.Color == by
+}
+
+// slidingAttack reports whether the first piece along any of the directions is an
+// attacker of the given type or a queen.
+func (b *Board) slidingAttack(rank, file int, directions [][2]int, by Color, pt PieceType) bool {
+	for _, d := range directions {
+		for r, f := rank+d[0], file+d[1]; r >= 0 && r < 8 && f >= 0 && f < 8; r, f = r+d[0], f+d[1] {
+			piece := b.squares[r*8+f]
+			if piece.IsEmpty() {
+				continue
+			}
+			if piece.Color == by && (piece.Type == pt || piece.Type == Queen) {
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
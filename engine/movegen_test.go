@@ -0,0 +1,218 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+// perft counts the leaf nodes of the legal move tree to the given depth.
+func perft(g *Game, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	moves := g.GenerateLegalMoves(nil)
+	if depth == 1 {
+		return len(moves)
+	}
+	nodes := 0
+	for _, m := range moves {
+		if err := g.MakeMove(m); err != nil {
+			panic(err)
+		}
+		nodes += perft(g, depth-1)
+		if _, err := g.UndoMove(); err != nil {
+			panic(err)
+		}
+	}
+	return nodes
+}
+
+func TestPerftStartPosition(t *testing.T) {
+	if testing.Short() {
+		t.Skip("perft is slow")
+	}
+	g := NewGame()
+	for depth, want := range []int{1, 20, 400, 8902} {
+		if got := perft(g, depth); got != want {
+			t.Errorf("perft(%d) = %d, want %d", depth, got, want)
+		}
+	}
+}
+
+func TestGenerateLegalMovesReusesBuffer(t *testing.T) {
+	g := NewGame()
+	if err := g.ParseFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]Move, 0, 256)
+	moves := g.GenerateLegalMoves(buf)
+	if len(moves) != 48 || len(g.GetAllLegalMoves()) != 48 {
+		t.Fatalf("expected 48 legal moves, got %d", len(moves))
+	}
+	if &moves[0] != &buf[:1][0] {
+		t.Error("expected moves to be written into the buffer")
+	}
+	allocs := testing.AllocsPerRun(20, func() {
+		buf = g.GenerateLegalMoves(buf)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations with a reused buffer, got %v", allocs)
+	}
+}
+
+func TestIsAttacked(t *testing.T) {
+	g := NewGame()
+	if err := g.ParseFEN("4k3/8/8/3p4/8/1n6/8/R3K2B w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	b := g.Board()
+	tests := []struct {
+		sq   Square
+		by   Color
+		want bool
+	}{
+		{C4, Black, true},  // pawn on d5
+		{E4, Black, true},  // pawn on d5
+		{D4, Black, true},  // knight on b3
+		{A8, White, true},  // rook up the a-file
+		{E4, White, true},  // bishop on h1
+		{C6, White, false}, // bishop ray blocked by d5
+		{D2, White, true},  // king
+		{D6, Black, false},
+	}
+	for _, tt := range tests {
+		if got := b.isAttacked(tt.sq, tt.by); got != tt.want {
+			t.Errorf("isAttacked(%s, %s) = %v, want %v", tt.sq, tt.by, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkGenerateLegalMoves(b *testing.B) {
+	g := NewGame()
+	_ = g.ParseFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	buf := make([]Move, 0, 256)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = g.GenerateLegalMoves(buf)
+	}
+}
+
+func TestPerftEnPassantPositions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("perft is slow")
+	}
+	cases := []struct {
+		name string
+		fen  string
+		want []int
+	}{
+		// Kiwipete: castling, en passant and pins
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", []int{1, 48, 2039}},
+		// En passant along a rank pinned to the king
+		{"rank pin", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", []int{1, 14, 191, 2812}},
+		// Promotions, promotion captures and castling rights lost to captures
+		{"promotions", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", []int{1, 6, 264, 9467}},
+		{"promotion captures", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", []int{1, 44, 1486, 62379}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGame()
+			if err := g.ParseFEN(tc.fen); err != nil {
+				t.Fatal(err)
+			}
+			for depth, want := range tc.want {
+				if got := perft(g, depth); got != want {
+					t.Errorf("perft(%d) = %d, want %d", depth, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnPassantGeneration(t *testing.T) {
+	g := NewGame()
+	playAll(t, g, "e2e4", "a7a6", "e4e5", "d7d5")
+	var ep []Move
+	for _, m := range g.GetAllLegalMoves() {
+		if m.Type == EnPassant {
+			ep = append(ep, m)
+		}
+	}
+	if len(ep) != 1 || ep[0].From != E5 || ep[0].To != D6 {
+		t.Fatalf("expected exd6 en passant, got %v", ep)
+	}
+	if err := g.MakeMove(ep[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := g.ToFEN(); got != "rnbqkbnr/1pp1pppp/p2P4/8/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 3" {
+		t.Errorf("unexpected FEN after en passant: %s", got)
+	}
+
+	// Capturing en passant would expose the king along the fifth rank
+	pinned := NewGame()
+	if err := pinned.ParseFEN("8/8/8/K2pP2r/8/8/8/7k w - d6 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range pinned.GetAllLegalMoves() {
+		if m.Type == EnPassant {
+			t.Fatalf("en passant exposing the king was generated: %v", m)
+		}
+	}
+	move, err := pinned.ParseMove("e5d6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var illegal *IllegalMoveError
+	if err := pinned.ExplainIllegalMove(move); !errors.As(err, &illegal) || illegal.Reason != IllegalPiecePinned {
+		t.Fatalf("expected piece_pinned, got %v", err)
+	}
+}
+
+func TestHashIgnoresUncapturableEnPassant(t *testing.T) {
+	a, b := NewGame(), NewGame()
+	_ = a.ParseFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	_ = b.ParseFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")
+	if a.Hash() != b.Hash() {
+		t.Error("an en passant square without a capture should not change the hash")
+	}
+	_ = a.ParseFEN("rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3")
+	_ = b.ParseFEN("rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 3")
+	if a.Hash() == b.Hash() {
+		t.Error("a capturable en passant square should change the hash")
+	}
+}
+
+func TestPromotionGeneration(t *testing.T) {
+	g := NewGame()
+	if err := g.ParseFEN("1n2k3/P7/8/8/8/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	var promotions []Move
+	for _, m := range g.GetAllLegalMoves() {
+		if m.Piece.Type == Pawn {
+			promotions = append(promotions, m)
+		}
+	}
+	if len(promotions) != 8 {
+		t.Fatalf("expected 4 promotions and 4 promotion captures, got %v", promotions)
+	}
+	for i, m := range promotions {
+		if m.Type != Promotion || m.Promotion != promotionPieces[i%4] {
+			t.Errorf("unexpected promotion move %v", m)
+		}
+		if m.To == B8 && m.Captured.Type != Knight {
+			t.Errorf("promotion capture should record the captured knight: %v", m)
+		}
+	}
+
+	// A pawn reaching the last rank must promote
+	move, err := g.ParseMove("a7a8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var illegal *IllegalMoveError
+	if err := g.ExplainIllegalMove(move); !errors.As(err, &illegal) || illegal.Reason != IllegalInvalidPromotion {
+		t.Fatalf("expected invalid_promotion, got %v", err)
+	}
+}
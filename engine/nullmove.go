@@ -0,0 +1,54 @@
+package engine
+
+import "errors"
+
+// ErrNullMoveInCheck is returned by MakeNullMove when the side to move is in check.
+var ErrNullMoveInCheck = errors.New("cannot pass while in check")
+
+// nullMove is the state saved by MakeNullMove, keyed by the ply it was made at.
+type nullMove struct {
+	ply   int
+	state gameState
+}
+
+// MakeNullMove passes the turn without moving a piece, for analysis such as
+// null-move pruning or showing what the opponent threatens. It clears the en passant
+// square and records the position for repetition detection, but does not touch the
+// move history, the clock, the game status or observers. Undo it with UndoNullMove
+// before undoing earlier moves.
+func (g *Game) MakeNullMove() error {
+	if g.IsGameOver() {
+		return errors.New("game is over")
+	}
+	if g.isInCheck(g.activeColor) {
+		return ErrNullMoveInCheck
+	}
+	g.nullMoves = append(g.nullMoves, nullMove{ply: len(g.moveHistory), state: g.snapshot()})
+	g.enPassantSquare = -1
+	g.halfMoveClock++
+	if g.activeColor == White {
+		g.activeColor = Black
+	} else {
+		g.activeColor = White
+		g.moveCount++
+	}
+	g.positionHashes = append(g.positionHashes, g.Hash())
+	return nil
+}
+
+// UndoNullMove reverts the last null move. Moves made after it must be undone first.
+func (g *Game) UndoNullMove() error {
+	if !g.pendingNullMove() {
+		return errors.New("no null move to undo")
+	}
+	nm := g.nullMoves[len(g.nullMoves)-1]
+	g.nullMoves = g.nullMoves[:len(g.nullMoves)-1]
+	g.positionHashes = g.positionHashes[:len(g.positionHashes)-1]
+	g.restoreState(nm.state)
+	return nil
+}
+
+// pendingNullMove reports whether the most recent change to the position was a null move.
+func (g *Game) pendingNullMove() bool {
+	return len(g.nullMoves) > 0 && g.nullMoves[len(g.nullMoves)-1].ply == len(g.moveHistory)
+}
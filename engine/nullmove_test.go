@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNullMoveRoundTrip(t *testing.T) {
+	g := NewGame()
+	playAll(t, g, "e2e4")
+	before := g.ToFEN()
+
+	if err := g.MakeNullMove(); err != nil {
+		t.Fatalf("null move: %v", err)
+	}
+	if g.ActiveColor() != White {
+		t.Fatal("null move should pass the turn back to White")
+	}
+	if g.ToFEN() != "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 1 2" {
+		t.Fatalf("unexpected FEN after null move: %s", g.ToFEN())
+	}
+	if len(g.MoveHistory()) != 1 {
+		t.Fatal("null move must not be recorded in the move history")
+	}
+
+	// Moves on top of the null move are undone first
+	playAll(t, g, "d2d4")
+	if err := g.UndoNullMove(); err == nil {
+		t.Fatal("expected error undoing a null move under a real move")
+	}
+	if _, err := g.UndoMove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.UndoMove(); err == nil {
+		t.Fatal("expected error undoing a move under a null move")
+	}
+	if err := g.UndoNullMove(); err != nil {
+		t.Fatalf("undo null move: %v", err)
+	}
+	if g.ToFEN() != before || len(g.PositionHistory()) != 2 {
+		t.Fatalf("position not restored: %s", g.ToFEN())
+	}
+	if err := g.UndoNullMove(); err == nil {
+		t.Fatal("expected error with no null move pending")
+	}
+}
+
+func TestNullMoveInCheck(t *testing.T) {
+	g := NewGame()
+	playAll(t, g, "e2e4", "f7f6", "d1h5")
+	if err := g.MakeNullMove(); !errors.Is(err, ErrNullMoveInCheck) {
+		t.Fatalf("expected ErrNullMoveInCheck, got %v", err)
+	}
+}
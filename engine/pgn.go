@@ -0,0 +1,268 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PGNGame is a game imported from PGN text.
+type PGNGame struct {
+	Tags   map[string]string
+	Game   *Game
+	Result string // "1-0", "0-1", "1/2-1/2" or "*"
+}
+
+var pgnTagPattern = regexp.MustCompile(`^\[\s*(\w+)\s+"((?:[^"\\]|\\.)*)"\s*\]$`)
+
+// ParsePGN imports a single game from PGN text. Tags are parsed, the SetUp/FEN and
+// Variant tags select the starting position, and SAN movetext is replayed. Clock
+// commands ([%clk], [%emt]) become move timings; the remaining comment text, NAGs
+// and "!"/"?" suffixes become move annotations. Variations are skipped. A decisive
+// or drawn result on a position that is not over is applied as resignation,
+// agreement or, per the Termination tag, timeout or abandonment.
+func ParsePGN(text string) (*PGNGame, error) {
+	pgn := &PGNGame{Tags: map[string]string{}, Result: "*"}
+
+	var movetext strings.Builder
+	inTags := true
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inTags {
+			if trimmed == "" {
+				continue
+			}
+			if m := pgnTagPattern.FindStringSubmatch(trimmed); m != nil {
+				pgn.Tags[m[1]] = strings.ReplaceAll(strings.ReplaceAll(m[2], `\"`, `"`), `\\`, `\`)
+				continue
+			}
+			inTags = false
+		}
+		if strings.HasPrefix(trimmed, "%") { // escape line
+			continue
+		}
+		movetext.WriteString(line)
+		movetext.WriteByte('\n')
+	}
+
+	variant, err := ParseVariant(pgn.Tags["Variant"])
+	if err != nil {
+		return nil, err
+	}
+	game := NewGameWithVariant(variant)
+	if fen, ok := pgn.Tags["FEN"]; ok {
+		if err := game.ParseFEN(fen); err != nil {
+			return nil, fmt.Errorf("invalid FEN tag: %w", err)
+		}
+		if variant != Standard {
+			game.variant = variant // the tag names it even if the FEN has no pocket
+		}
+	}
+	pgn.Game = game
+
+	if err := pgn.playMovetext(movetext.String()); err != nil {
+		return nil, err
+	}
+	if r, ok := pgn.Tags["Result"]; ok && pgn.Result == "*" {
+		pgn.Result = r
+	}
+	if err := game.applyPGNResult(pgn.Result, pgn.Tags["Termination"]); err != nil {
+		return nil, err
+	}
+	return pgn, nil
+}
+
+// playMovetext tokenizes movetext and applies the mainline moves.
+func (p *PGNGame) playMovetext(text string) error {
+	g := p.Game
+	depth := 0 // variation nesting
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return errors.New("unterminated comment")
+			}
+			comment := text[i+1 : i+end]
+			i += end + 1
+			if depth > 0 || len(g.moveHistory) == 0 {
+				continue
+			}
+			ply := len(g.moveHistory) - 1
+			timing, err := ParseMoveTiming(comment, DefaultClockNotation)
+			if err != nil {
+				return err
+			}
+			if !timing.IsZero() {
+				g.timings[ply] = timing
+			}
+			g.addAnnotation(ply, clockCommandPattern.ReplaceAllString(comment, ""))
+		case c == ';':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			i += end
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+			i++
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		default:
+			end := i
+			for end < len(text) && !strings.ContainsRune(" \t\r\n{}();", rune(text[end])) {
+				end++
+			}
+			token := text[i:end]
+			i = end
+			if depth > 0 {
+				continue
+			}
+			if err := p.playToken(token); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// playToken applies a single movetext token (move number, NAG, result or SAN move).
+func (p *PGNGame) playToken(token string) error {
+	switch token {
+	case "1-0", "0-1", "1/2-1/2", "*":
+		p.Result = token
+		return nil
+	}
+	if strings.HasPrefix(token, "$") {
+		if nag, err := strconv.Atoi(token[1:]); err == nil && nag >= 0 && nag <= 255 && len(p.Game.moveHistory) > 0 {
+			p.Game.addAnnotation(len(p.Game.moveHistory)-1, "", nag)
+		}
+		return nil
+	}
+	// Strip a leading move number such as "12." or "12..." (possibly glued to the move)
+	if j := strings.IndexFunc(token, func(r rune) bool { return r < '0' || r > '9' }); j > 0 && token[j] == '.' {
+		token = strings.TrimLeft(token[j:], ".")
+	}
+	if token == "" {
+		return nil
+	}
+	move, err := p.Game.MoveFromSAN(token)
+	if err != nil {
+		return fmt.Errorf("move %d (%s): %w", len(p.Game.moveHistory)+1, token, err)
+	}
+	if err := p.Game.MakeMove(move); err != nil {
+		return err
+	}
+	if j := strings.IndexAny(token, "!?"); j >= 0 {
+		if nag, ok := moveSuffixNAGs[token[j:]]; ok {
+			p.Game.addAnnotation(len(p.Game.moveHistory)-1, "", nag)
+		}
+	}
+	return nil
+}
+
+// MoveFromSAN resolves a move in Standard Algebraic Notation (e.g. "Nbd7", "exd5",
+// "e8=Q+", "O-O", "N@f3") against the current position. Check and annotation
+// suffixes are ignored and over-specified origins ("Ng1f3") are accepted.
+func (g *Game) MoveFromSAN(san string) (Move, error) {
+	s := strings.TrimRight(strings.TrimSpace(san), "+#!?")
+	if s == "" {
+		return Move{}, errors.New("empty move")
+	}
+
+	switch s {
+	case "O-O", "0-0", "O-O-O", "0-0-0":
+		move, err := g.ParseMove(s)
+		if err != nil {
+			return Move{}, err
+		}
+		if !g.IsLegalMove(move) {
+			return Move{}, errors.New("illegal castling move")
+		}
+		return move, nil
+	}
+	if strings.Contains(s, "@") {
+		move, err := g.ParseMove(s)
+		if err != nil {
+			return Move{}, err
+		}
+		if !g.IsLegalMove(move) {
+			return Move{}, errors.New("illegal drop")
+		}
+		return move, nil
+	}
+
+	// Promotion suffix: "=Q" or a bare trailing piece letter ("e8Q")
+	var promotion string
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		promotion, s = s[i+1:], s[:i]
+	} else if n := len(s); n > 2 && strings.ContainsRune("QRBNqrbn", rune(s[n-1])) && s[n-2] >= '1' && s[n-2] <= '8' {
+		promotion, s = s[n-1:], s[:n-1]
+	}
+	if len(promotion) > 1 || len(s) < 2 {
+		return Move{}, fmt.Errorf("invalid SAN: %s", san)
+	}
+
+	to, err := SquareFromString(s[len(s)-2:])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid SAN destination: %s", san)
+	}
+	prefix := strings.ReplaceAll(s[:len(s)-2], "x", "")
+
+	pieceType := Pawn
+	if prefix != "" && strings.ContainsRune("KQRBN", rune(prefix[0])) {
+		pieceType = map[byte]PieceType{'K': King, 'Q': Queen, 'R': Rook, 'B': Bishop, 'N': Knight}[prefix[0]]
+		prefix = prefix[1:]
+	}
+	fromFile, fromRank := -1, -1
+	for _, r := range prefix {
+		switch {
+		case r >= 'a' && r <= 'h':
+			fromFile = int(r - 'a')
+		case r >= '1' && r <= '8':
+			fromRank = int(r - '1')
+		default:
+			return Move{}, fmt.Errorf("invalid SAN: %s", san)
+		}
+	}
+	if pieceType == Pawn && fromFile < 0 {
+		fromFile = to.File()
+	}
+
+	var found []Move
+	for from := Square(0); from < 64; from++ {
+		piece := g.board.GetPiece(from)
+		if piece.IsEmpty() || piece.Color != g.activeColor || piece.Type != pieceType {
+			continue
+		}
+		if (fromFile >= 0 && from.File() != fromFile) || (fromRank >= 0 && from.Rank() != fromRank) {
+			continue
+		}
+		move, err := g.ParseMove(from.String() + to.String() + strings.ToLower(promotion))
+		if err != nil {
+			continue
+		}
+		if promotion == "" && pieceType == Pawn && (to.Rank() == 0 || to.Rank() == 7) {
+			continue // promotion piece is mandatory
+		}
+		if g.IsLegalMove(move) {
+			found = append(found, move)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Move{}, fmt.Errorf("illegal move: %s", san)
+	case 1:
+		return found[0], nil
+	default:
+		return Move{}, fmt.Errorf("ambiguous move: %s", san)
+	}
+}
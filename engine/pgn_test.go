@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMoveFromSAN(t *testing.T) {
+	g := NewGame()
+	for _, san := range []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Bxc6", "dxc6", "O-O", "Bg4", "d3", "Qd6"} {
+		m, err := g.MoveFromSAN(san)
+		if err != nil {
+			t.Fatalf("%s: %v", san, err)
+		}
+		if err := g.MakeMove(m); err != nil {
+			t.Fatalf("%s: %v", san, err)
+		}
+	}
+	if _, err := g.MoveFromSAN("Nd2"); err == nil {
+		t.Fatal("expected ambiguity error for Nd2")
+	}
+	if _, err := g.MoveFromSAN("Nbd2"); err != nil {
+		t.Fatalf("Nbd2: %v", err)
+	}
+	if _, err := g.MoveFromSAN("Qh5"); err == nil {
+		t.Fatal("expected illegal move error for Qh5")
+	}
+
+	if err := g.ParseFEN("8/4P3/8/8/8/8/k7/4K3 w - - 0 1"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	m, err := g.MoveFromSAN("e8=N")
+	if err != nil || m.Promotion != Knight {
+		t.Fatalf("expected knight promotion, got %+v err=%v", m, err)
+	}
+	if _, err := g.MoveFromSAN("e8"); err == nil {
+		t.Fatal("expected error for promotion without piece")
+	}
+}
+
+// TestParsePGNClockRoundTrip imports clock comments and re-emits them.
+func TestParsePGNClockRoundTrip(t *testing.T) {
+	pgn := `[Event "Blitz"]
+[TimeControl "180+2"]
+[Result "*"]
+
+1. e4 {[%clk 0:03:01] [%emt 0:00:01]} 1... c5 {[%clk 0:02:59.5]} 2. Nf3 $1 (2. c3 {sidelines ignored}) d6 {no clock here} *`
+	imported, err := ParsePGN(pgn)
+	if err != nil {
+		t.Fatalf("ParsePGN: %v", err)
+	}
+	if imported.Tags["TimeControl"] != "180+2" || imported.Result != "*" {
+		t.Fatalf("unexpected tags/result: %+v %s", imported.Tags, imported.Result)
+	}
+	timings := imported.Game.MoveTimings()
+	if len(timings) != 4 {
+		t.Fatalf("expected 4 plies, got %d", len(timings))
+	}
+	if timings[0].Clock != 3*time.Minute+time.Second || timings[0].Elapsed != time.Second {
+		t.Fatalf("unexpected first timing %+v", timings[0])
+	}
+	if got := timings[1].PGNComment(nil); got != "[%clk 0:02:59.5]" {
+		t.Fatalf("unexpected re-emitted comment %q", got)
+	}
+	if !timings[2].IsZero() || !timings[3].IsZero() {
+		t.Fatalf("expected no timing for moves without clock comments")
+	}
+}
+
+func TestParsePGNFromFEN(t *testing.T) {
+	pgn := `[SetUp "1"]
+[FEN "7k/8/6K1/8/8/8/8/R7 w - - 0 1"]
+
+1. Ra8# 1-0`
+	imported, err := ParsePGN(pgn)
+	if err != nil {
+		t.Fatalf("ParsePGN: %v", err)
+	}
+	if imported.Game.Status() != WhiteWins || imported.Result != "1-0" {
+		t.Fatalf("expected white win, got %v %s", imported.Game.Status(), imported.Result)
+	}
+	if _, err := ParsePGN("1. e4 e5 2. Ke3"); err == nil {
+		t.Fatal("expected error for illegal move")
+	}
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ANSI escape sequences used by BoardRenderer when Color is enabled.
+const (
+	ansiReset          = "\x1b[0m"
+	ansiLightSquare    = "\x1b[48;5;180m"
+	ansiDarkSquare     = "\x1b[48;5;137m"
+	ansiHighlight      = "\x1b[48;5;185m"
+	ansiWhitePiece     = "\x1b[1;97m"
+	ansiBlackPiece     = "\x1b[1;30m"
+	ansiCoordinateText = "\x1b[2m"
+)
+
+// unicodeGlyphs maps piece types to their Unicode chess symbols [white, black].
+var unicodeGlyphs = map[PieceType][2]string{
+	King:   {"♔", "♚"},
+	Queen:  {"♕", "♛"},
+	Rook:   {"♖", "♜"},
+	Bishop: {"♗", "♝"},
+	Knight: {"♘", "♞"},
+	Pawn:   {"♙", "♟"},
+}
+
+// RenderOptions configures a BoardRenderer.
+type RenderOptions struct {
+	// Unicode draws pieces as chess glyphs (♔♛…) instead of letters (K, q…).
+	Unicode bool
+	// Color paints squares and pieces with ANSI escape codes for terminals.
+	Color bool
+	// Coordinates prints file letters above and below and rank numbers on both sides.
+	Coordinates bool
+	// Flipped draws the board from Black's point of view.
+	Flipped bool
+	// Highlight marks squares, typically the last move's origin and destination. With
+	// Color they get a highlighted background; otherwise the piece is followed by '*'.
+	Highlight []Square
+}
+
+// BoardRenderer draws boards as text for terminals, logs and prompts.
+type BoardRenderer struct {
+	opts RenderOptions
+}
+
+// NewBoardRenderer creates a renderer with the given options.
+func NewBoardRenderer(opts RenderOptions) *BoardRenderer {
+	return &BoardRenderer{opts: opts}
+}
+
+// Render draws the board.
+func (r *BoardRenderer) Render(b *Board) string {
+	var sb strings.Builder
+
+	files := "abcdefgh"
+	if r.opts.Flipped {
+		files = "hgfedcba"
+	}
+	writeFiles := func() {
+		if !r.opts.Coordinates {
+			return
+		}
+		line := " " + strings.Join(strings.Split(files, ""), " ")
+		if r.opts.Color {
+			line = ansiCoordinateText + line + ansiReset
+		}
+		sb.WriteString(" " + line + "\n")
+	}
+
+	writeFiles()
+	for i := 0; i < 8; i++ {
+		rank := 7 - i
+		if r.opts.Flipped {
+			rank = i
+		}
+		if r.opts.Coordinates {
+			sb.WriteString(fmt.Sprintf("%d ", rank+1))
+		}
+		for j := 0; j < 8; j++ {
+			file := j
+			if r.opts.Flipped {
+				file = 7 - j
+			}
+			sb.WriteString(r.cell(b, Square(rank*8+file)))
+		}
+		if r.opts.Coordinates {
+			sb.WriteString(fmt.Sprintf("%d", rank+1))
+		}
+		sb.WriteString("\n")
+	}
+	writeFiles()
+
+	return sb.String()
+}
+
+// RenderGame draws the game's board, highlighting the last move unless the options
+// already name squares to highlight.
+func (r *BoardRenderer) RenderGame(g *Game) string {
+	opts := r.opts
+	if opts.Highlight == nil && len(g.moveHistory) > 0 {
+		last := g.moveHistory[len(g.moveHistory)-1]
+		opts.Highlight = []Square{last.To}
+		if last.Type != Drop {
+			opts.Highlight = append(opts.Highlight, last.From)
+		}
+	}
+	return (&BoardRenderer{opts: opts}).Render(g.board)
+}
+
+// cell renders one square, two characters wide plus any escape codes.
+func (r *BoardRenderer) cell(b *Board, sq Square) string {
+	highlighted := false
+	for _, h := range r.opts.Highlight {
+		if h == sq {
+			highlighted = true
+			break
+		}
+	}
+
+	piece := b.GetPiece(sq)
+	symbol := piece.String()
+	if r.opts.Unicode && !piece.IsEmpty() {
+		symbol = unicodeGlyphs[piece.Type][sideIndex(piece.Color)]
+	}
+
+	if !r.opts.Color {
+		if highlighted {
+			return symbol + "*"
+		}
+		return symbol + " "
+	}
+
+	if piece.IsEmpty() {
+		symbol = " "
+	} else if piece.Color == White {
+		symbol = ansiWhitePiece + symbol
+	} else {
+		symbol = ansiBlackPiece + symbol
+	}
+	background := ansiDarkSquare
+	switch {
+	case highlighted:
+		background = ansiHighlight
+	case (sq.File()+sq.Rank())%2 == 1:
+		background = ansiLightSquare
+	}
+	return background + symbol + " " + ansiReset
+}
@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBoardRendererMatchesString(t *testing.T) {
+	b := NewBoard()
+	if got := NewBoardRenderer(RenderOptions{Coordinates: true}).Render(b); got != b.String() {
+		t.Errorf("default rendering differs from Board.String:\n%s", got)
+	}
+	plain := NewBoardRenderer(RenderOptions{}).Render(b)
+	if lines := strings.Split(strings.TrimSuffix(plain, "\n"), "\n"); len(lines) != 8 || lines[0] != "r n b q k b n r " {
+		t.Errorf("unexpected rendering without coordinates:\n%s", plain)
+	}
+}
+
+func TestBoardRendererUnicodeAndHighlight(t *testing.T) {
+	g := NewGame()
+	playAll(t, g, "e2e4")
+
+	got := NewBoardRenderer(RenderOptions{Unicode: true}).RenderGame(g)
+	lines := strings.Split(got, "\n")
+	if lines[0] != "♜ ♞ ♝ ♛ ♚ ♝ ♞ ♜ " {
+		t.Errorf("unexpected black back rank: %q", lines[0])
+	}
+	if lines[4] != ". . . . ♙*. . . " || lines[6] != "♙ ♙ ♙ ♙ .*♙ ♙ ♙ " {
+		t.Errorf("last move not highlighted:\n%s", got)
+	}
+
+	colored := NewBoardRenderer(RenderOptions{Color: true}).RenderGame(g)
+	if strings.Count(colored, ansiHighlight) != 2 || !strings.Contains(colored, ansiReset) {
+		t.Errorf("expected two highlighted squares in ANSI output")
+	}
+}
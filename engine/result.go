@@ -0,0 +1,208 @@
+package engine
+
+import "errors"
+
+// Termination describes how a game ended.
+type Termination int
+
+const (
+	// TerminationNone indicates the game has not ended.
+	TerminationNone Termination = iota
+	// TerminationCheckmate indicates the loser was checkmated.
+	TerminationCheckmate
+	// TerminationDraw indicates a draw by rule (see Result.DrawReason).
+	TerminationDraw
+	// TerminationResignation indicates the loser resigned.
+	TerminationResignation
+	// TerminationTimeout indicates a player ran out of time.
+	TerminationTimeout
+	// TerminationAgreement indicates the players agreed to a draw.
+	TerminationAgreement
+	// TerminationAbandonment indicates the loser abandoned the game.
+	TerminationAbandonment
+)
+
+// String returns the string representation of the termination.
+func (t Termination) String() string {
+	switch t {
+	case TerminationNone:
+		return "none"
+	case TerminationCheckmate:
+		return "checkmate"
+	case TerminationDraw:
+		return "draw"
+	case TerminationResignation:
+		return "resignation"
+	case TerminationTimeout:
+		return "timeout"
+	case TerminationAgreement:
+		return "agreement"
+	case TerminationAbandonment:
+		return "abandonment"
+	default:
+		return "unknown"
+	}
+}
+
+// Result describes the outcome of a game.
+type Result struct {
+	Winner      Color // None for draws and unfinished games
+	Termination Termination
+	DrawReason  DrawReason // set for draws by rule
+}
+
+// String returns the PGN result marker: "1-0", "0-1", "1/2-1/2" or "*".
+func (r Result) String() string {
+	switch {
+	case r.Termination == TerminationNone:
+		return "*"
+	case r.Winner == White:
+		return "1-0"
+	case r.Winner == Black:
+		return "0-1"
+	default:
+		return "1/2-1/2"
+	}
+}
+
+// PGNTermination returns the value of the PGN Termination tag.
+func (r Result) PGNTermination() string {
+	switch r.Termination {
+	case TerminationNone:
+		return "unterminated"
+	case TerminationTimeout:
+		return "time forfeit"
+	case TerminationAbandonment:
+		return "abandoned"
+	default:
+		return "normal"
+	}
+}
+
+// Result returns the outcome of the game.
+func (g *Game) Result() Result {
+	switch g.status {
+	case WhiteWins, BlackWins:
+		winner := White
+		if g.status == BlackWins {
+			winner = Black
+		}
+		termination := g.termination
+		if termination == TerminationNone {
+			termination = TerminationCheckmate
+		}
+		return Result{Winner: winner, Termination: termination}
+	case Draw:
+		if g.termination != TerminationNone {
+			return Result{Termination: g.termination, DrawReason: g.drawReason}
+		}
+		return Result{Termination: TerminationDraw, DrawReason: g.drawReason}
+	default:
+		return Result{}
+	}
+}
+
+// Resign ends the game with a win for color's opponent.
+func (g *Game) Resign(color Color) error {
+	return g.forfeit(color, TerminationResignation)
+}
+
+// Abandon ends the game with a win for the opponent of the color who left.
+func (g *Game) Abandon(color Color) error {
+	return g.forfeit(color, TerminationAbandonment)
+}
+
+// Timeout ends the game because color ran out of time. The opponent wins unless
+// they only have a bare king, in which case the game is drawn.
+func (g *Game) Timeout(color Color) error {
+	if color != White && color != Black {
+		return errors.New("invalid color")
+	}
+	if g.IsGameOver() {
+		return errors.New("game is over")
+	}
+	if g.hasBareKing(color.Opposite()) {
+		g.endGame(Draw, TerminationTimeout, NoDraw)
+		return nil
+	}
+	return g.forfeit(color, TerminationTimeout)
+}
+
+// AgreeDraw ends the game as a draw by mutual agreement.
+func (g *Game) AgreeDraw() error {
+	if g.IsGameOver() {
+		return errors.New("game is over")
+	}
+	g.endGame(Draw, TerminationAgreement, NoDraw)
+	return nil
+}
+
+// forfeit ends the game with a loss for color.
+func (g *Game) forfeit(color Color, termination Termination) error {
+	if color != White && color != Black {
+		return errors.New("invalid color")
+	}
+	if g.IsGameOver() {
+		return errors.New("game is over")
+	}
+	status := WhiteWins
+	if color == White {
+		status = BlackWins
+	}
+	g.endGame(status, termination, NoDraw)
+	return nil
+}
+
+// endGame finishes the game without a move, stopping the clock and notifying observers.
+func (g *Game) endGame(status GameStatus, termination Termination, reason DrawReason) {
+	g.status = status
+	g.termination = termination
+	g.drawReason = reason
+	if g.clock != nil {
+		g.clock.Stop()
+	}
+	ev := Event{Type: EventGameEnded, Status: status, Ply: len(g.moveHistory)}
+	for _, o := range g.observers {
+		o.fn(ev)
+	}
+}
+
+// hasBareKing reports whether color has no pieces besides the king.
+func (g *Game) hasBareKing(color Color) bool {
+	if g.variant == Crazyhouse && !g.Pocket(color).IsEmpty() {
+		return false
+	}
+	for sq := Square(0); sq < 64; sq++ {
+		if p := g.board.GetPiece(sq); p.Color == color && p.Type != King && p.Type != Empty {
+			return false
+		}
+	}
+	return true
+}
+
+// applyPGNResult ends an imported game that stopped before mate or a draw by rule,
+// using the PGN Result and Termination tags.
+func (g *Game) applyPGNResult(result, termination string) error {
+	if g.IsGameOver() {
+		return nil
+	}
+	loser := None
+	switch result {
+	case "1-0":
+		loser = Black
+	case "0-1":
+		loser = White
+	case "1/2-1/2":
+		return g.AgreeDraw()
+	default:
+		return nil
+	}
+	switch termination {
+	case "time forfeit":
+		return g.Timeout(loser)
+	case "abandoned":
+		return g.Abandon(loser)
+	default:
+		return g.Resign(loser)
+	}
+}
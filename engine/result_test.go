@@ -0,0 +1,104 @@
+package engine
+
+import "testing"
+
+func TestResultCheckmateAndDraw(t *testing.T) {
+	g := NewGame()
+	if r := g.Result(); r.Termination != TerminationNone || r.String() != "*" || r.PGNTermination() != "unterminated" {
+		t.Fatalf("unexpected result for a new game: %+v", r)
+	}
+	playAll(t, g, "f2f3", "e7e5", "g2g4", "d8h4")
+	r := g.Result()
+	if r.Winner != Black || r.Termination != TerminationCheckmate || r.String() != "0-1" || r.PGNTermination() != "normal" {
+		t.Fatalf("unexpected checkmate result: %+v", r)
+	}
+
+	g = NewGame()
+	if err := g.ParseFEN("7k/5Q2/6K1/8/8/8/8/8 b - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if r := g.Result(); r.Termination != TerminationDraw || r.DrawReason != DrawStalemate || r.String() != "1/2-1/2" {
+		t.Fatalf("unexpected stalemate result: %+v", r)
+	}
+}
+
+func TestResignAgreeAbandon(t *testing.T) {
+	events := 0
+	g := NewGame()
+	g.Subscribe(func(e Event) {
+		if e.Type == EventGameEnded {
+			events++
+		}
+	})
+	if err := g.Resign(None); err == nil {
+		t.Fatal("expected error for invalid color")
+	}
+	if err := g.Resign(White); err != nil {
+		t.Fatal(err)
+	}
+	if r := g.Result(); r.Winner != Black || r.Termination != TerminationResignation || g.Status() != BlackWins {
+		t.Fatalf("unexpected resignation result: %+v", r)
+	}
+	if err := g.AgreeDraw(); err == nil {
+		t.Fatal("expected error after the game ended")
+	}
+	if events != 1 {
+		t.Fatalf("expected one game_ended event, got %d", events)
+	}
+
+	g = NewGame()
+	if err := g.AgreeDraw(); err != nil {
+		t.Fatal(err)
+	}
+	if r := g.Result(); r.Winner != None || r.Termination != TerminationAgreement || r.String() != "1/2-1/2" {
+		t.Fatalf("unexpected agreement result: %+v", r)
+	}
+
+	g = NewGame()
+	if err := g.Abandon(Black); err != nil {
+		t.Fatal(err)
+	}
+	if r := g.Result(); r.Winner != White || r.PGNTermination() != "abandoned" {
+		t.Fatalf("unexpected abandonment result: %+v", r)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	g := NewGame()
+	if err := g.Timeout(White); err != nil {
+		t.Fatal(err)
+	}
+	if r := g.Result(); r.Winner != Black || r.PGNTermination() != "time forfeit" {
+		t.Fatalf("unexpected timeout result: %+v", r)
+	}
+
+	// The opponent has a bare king and cannot win on time
+	g = NewGame()
+	if err := g.ParseFEN("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Timeout(White); err != nil {
+		t.Fatal(err)
+	}
+	if r := g.Result(); r.Winner != None || r.Termination != TerminationTimeout || r.String() != "1/2-1/2" {
+		t.Fatalf("unexpected timeout draw: %+v", r)
+	}
+}
+
+func TestParsePGNAppliesResult(t *testing.T) {
+	pgn, err := ParsePGN("[Result \"1-0\"]\n[Termination \"time forfeit\"]\n\n1. e4 e5 2. Nf3 1-0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := pgn.Game.Result(); r.Winner != White || r.Termination != TerminationTimeout {
+		t.Fatalf("unexpected imported result: %+v", r)
+	}
+
+	pgn, err = ParsePGN("1. d4 d5 1/2-1/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := pgn.Game.Result(); r.Termination != TerminationAgreement {
+		t.Fatalf("expected drawn import, got %+v", r)
+	}
+}
@@ -0,0 +1,185 @@
+package engine
+
+import "sort"
+
+// Threat is a piece the opponent attacks.
+type Threat struct {
+	Square    Square
+	Piece     Piece
+	Attackers []Square // the opponent's pieces attacking it, cheapest first
+	Defenders []Square // own pieces defending it
+	// Hanging reports whether the opponent wins material by taking the piece: it
+	// is undefended, or its cheapest attacker is worth less. Pins and x-rays are
+	// not considered.
+	Hanging bool
+}
+
+// Threats returns the pieces of color, other than the king, that the opponent
+// attacks, the most valuable first.
+func (g *Game) Threats(color Color) []Threat {
+	var threats []Threat
+	for sq := Square(0); sq < 64; sq++ {
+		piece := g.board.GetPiece(sq)
+		if piece.IsEmpty() || piece.Color != color || piece.Type == King {
+			continue
+		}
+		attackers := g.board.attackers(sq, color.Opposite())
+		if len(attackers) == 0 {
+			continue
+		}
+		defenders := g.board.attackers(sq, color)
+		threats = append(threats, Threat{
+			Square:    sq,
+			Piece:     piece,
+			Attackers: attackers,
+			Defenders: defenders,
+			Hanging:   len(defenders) == 0 || attackValue(g.board.GetPiece(attackers[0]).Type) < attackValue(piece.Type),
+		})
+	}
+	sort.SliceStable(threats, func(i, j int) bool {
+		return pieceValues[threats[i].Piece.Type] > pieceValues[threats[j].Piece.Type]
+	})
+	return threats
+}
+
+// HangingPieces returns the pieces of color the opponent wins material by
+// taking, the most valuable first.
+func (g *Game) HangingPieces(color Color) []Threat {
+	var hanging []Threat
+	for _, threat := range g.Threats(color) {
+		if threat.Hanging {
+			hanging = append(hanging, threat)
+		}
+	}
+	return hanging
+}
+
+// CaptureGain estimates the material, in centipawns, a legal capture wins once
+// the opponent takes the capturing piece back, if that wins it material (see
+// Threat.Hanging), and the capturing side retakes with its cheapest defender.
+// Longer exchanges are not followed; moves that capture nothing gain 0.
+func (g *Game) CaptureGain(move Move) int {
+	if move.Captured.IsEmpty() {
+		return 0
+	}
+	after := g.Clone()
+	if err := after.MakeMove(move); err != nil {
+		return 0
+	}
+	gain := pieceValues[move.Captured.Type]
+	for _, threat := range after.Threats(move.Piece.Color) {
+		if threat.Square != move.To || !threat.Hanging {
+			continue
+		}
+		gain -= pieceValues[threat.Piece.Type]
+		if len(threat.Defenders) > 0 {
+			gain += pieceValues[after.board.GetPiece(threat.Attackers[0]).Type]
+		}
+	}
+	return gain
+}
+
+// attackValue is the value of a piece when it attacks: the king is the most
+// valuable, since it can only take undefended pieces.
+func attackValue(pt PieceType) int {
+	if pt == King {
+		return 10000
+	}
+	return pieceValues[pt]
+}
+
+// attackers returns the squares of the pieces of the given side that attack sq,
+// cheapest first, looking outward from the square as isAttacked does.
+func (b *Board) attackers(sq Square, by Color) []Square {
+	rank, file := sq.Rank(), sq.File()
+	var found []Square
+	add := func(r, f int, types ...PieceType) {
+		if r < 0 || r > 7 || f < 0 || f > 7 {
+			return
+		}
+		piece := b.squares[r*8+f]
+		if piece.Color != by {
+			return
+		}
+		for _, pt := range types {
+			if piece.Type == pt {
+				found = append(found, Square(r*8+f))
+				return
+			}
+		}
+	}
+
+	pawnRank := rank - 1
+	if by == Black {
+		pawnRank = rank + 1
+	}
+	for _, df := range [2]int{-1, 1} {
+		add(pawnRank, file+df, Pawn)
+	}
+	for _, o := range knightOffsets {
+		add(rank+o[0], file+o[1], Knight)
+	}
+	for _, o := range kingOffsets {
+		add(rank+o[0], file+o[1], King)
+	}
+	slide := func(directions [][2]int, pt PieceType) {
+		for _, d := range directions {
+			for r, f := rank+d[0], file+d[1]; r >= 0 && r < 8 && f >= 0 && f < 8; r, f = r+d[0], f+d[1] {
+				if b.squares[r*8+f].IsEmpty() {
+					continue
+				}
+				add(r, f, pt, Queen)
+				break
+			}
+		}
+	}
+	slide(rookDirections, Rook)
+	slide(bishopDirections, Bishop)
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return attackValue(b.squares[found[i]].Type) < attackValue(b.squares[found[j]].Type)
+	})
+	return found
+}
+
+// Pin is a piece that cannot move off the line between its king and an
+// opponent's rook, bishop or queen without exposing the king.
+type Pin struct {
+	Square Square
+	Piece  Piece
+	Pinner Square // the opponent's piece pinning it
+}
+
+// Pins returns the pieces of color pinned to their king.
+func (g *Game) Pins(color Color) []Pin {
+	king := g.board.kingSquare(color)
+	if king == -1 {
+		return nil
+	}
+	var pins []Pin
+	find := func(directions [][2]int, pt PieceType) {
+		for _, d := range directions {
+			pinned := Square(-1)
+			for r, f := king.Rank()+d[0], king.File()+d[1]; r >= 0 && r < 8 && f >= 0 && f < 8; r, f = r+d[0], f+d[1] {
+				piece := g.board.squares[r*8+f]
+				if piece.IsEmpty() {
+					continue
+				}
+				if piece.Color == color {
+					if pinned != -1 {
+						break // two pieces shield the king
+					}
+					pinned = Square(r*8 + f)
+					continue
+				}
+				if pinned != -1 && (piece.Type == pt || piece.Type == Queen) {
+					pins = append(pins, Pin{Square: pinned, Piece: g.board.squares[pinned], Pinner: Square(r*8 + f)})
+				}
+				break
+			}
+		}
+	}
+	find(rookDirections, Rook)
+	find(bishopDirections, Bishop)
+	return pins
+}
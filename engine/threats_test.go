@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"maps"
+	"testing"
+)
+
+// TestThreats finds attacked pieces, their attackers and defenders, and which hang.
+func TestThreats(t *testing.T) {
+	g := NewGame()
+	if threats := g.Threats(White); len(threats) != 0 {
+		t.Fatalf("expected no threats in the starting position, got %+v", threats)
+	}
+
+	playAll(t, g, "e2e4", "e7e5", "g1f3")
+	hanging := g.HangingPieces(Black)
+	if len(hanging) != 1 || hanging[0].Square.String() != "e5" || hanging[0].Attackers[0].String() != "f3" {
+		t.Fatalf("expected the e5 pawn hanging to the f3 knight, got %+v", hanging)
+	}
+
+	playAll(t, g, "b8c6")
+	threats := g.Threats(Black)
+	if len(threats) != 1 || threats[0].Hanging || len(threats[0].Defenders) != 1 || threats[0].Defenders[0].String() != "c6" {
+		t.Fatalf("expected the e5 pawn defended by the c6 knight, got %+v", threats)
+	}
+
+	// A defended queen still hangs to a cheaper attacker
+	g = NewGame()
+	playAll(t, g, "e2e4", "c7c6", "d2d4", "d7d5", "e4d5", "d8d5", "b1c3")
+	hanging = g.HangingPieces(Black)
+	if len(hanging) != 1 || hanging[0].Piece.Type != Queen || len(hanging[0].Defenders) != 1 {
+		t.Fatalf("expected the queen hanging to the knight, got %+v", hanging)
+	}
+}
+
+// TestPins finds pieces pinned to their king along files and diagonals.
+func TestPins(t *testing.T) {
+	g := NewGame()
+	playAll(t, g, "e2e4", "e7e5", "g1f3", "b8c6", "f1b5", "d7d6")
+	pins := g.Pins(Black)
+	if len(pins) != 1 || pins[0].Square.String() != "c6" || pins[0].Pinner.String() != "b5" || pins[0].Piece.Type != Knight {
+		t.Fatalf("expected the c6 knight pinned by the b5 bishop, got %+v", pins)
+	}
+	if pins := g.Pins(White); len(pins) != 0 {
+		t.Fatalf("expected no white pins, got %+v", pins)
+	}
+
+	// Two pieces between the king and the rook are not pinned
+	if err := g.ParseFEN("4k3/4n3/4p3/8/8/8/8/4RK2 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if pins := g.Pins(Black); len(pins) != 0 {
+		t.Fatalf("expected no pins behind two pieces, got %+v", pins)
+	}
+}
+
+// TestCaptureGain weighs captures by the recapture they allow.
+func TestCaptureGain(t *testing.T) {
+	g := NewGame()
+	playAll(t, g, "e2e4", "e7e5", "g1f3", "b8c6", "f1b5", "a7a6")
+	gains := map[string]int{}
+	for _, move := range g.GetAllLegalMoves() {
+		if !move.Captured.IsEmpty() {
+			gains[move.UCI()] = g.CaptureGain(move)
+		}
+	}
+	want := map[string]int{
+		"b5c6": pieceValues[Knight] - pieceValues[Bishop], // bxc6 trades
+		"b5a6": pieceValues[Pawn] - pieceValues[Bishop],   // bxa6
+		"f3e5": pieceValues[Pawn] - pieceValues[Knight],   // Nxe5 and the knight is not defended
+	}
+	if !maps.Equal(gains, want) {
+		t.Fatalf("expected %v, got %v", want, gains)
+	}
+
+	// Nxe5 takes a pawn the d6 pawn defends
+	g = NewGame()
+	playAll(t, g, "e2e4", "e7e5", "g1f3", "d7d6", "d2d4", "b8d7")
+	for _, move := range g.GetAllLegalMoves() {
+		switch move.UCI() {
+		case "f3e5":
+			if gain := g.CaptureGain(move); gain != pieceValues[Pawn]-pieceValues[Knight]+pieceValues[Pawn] {
+				t.Errorf("expected Nxe5 dxe5 dxe5 to lose the knight for two pawns, got %d", gain)
+			}
+		case "d4e5":
+			if gain := g.CaptureGain(move); gain != pieceValues[Pawn] {
+				t.Errorf("expected dxe5 to win a pawn, got %d", gain)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// UCI returns the move in UCI long algebraic notation: source and target square with
+// a lowercase promotion suffix ("e2e4", "e7e8q"). Castling is written as the king's
+// move ("e1g1") and drops use the Crazyhouse form "P@e4".
+func (m Move) UCI() string {
+	if m.Type == Drop {
+		return m.String()
+	}
+	notation := m.From.String() + m.To.String()
+	if m.Promotion != Empty {
+		switch m.Promotion {
+		case Queen:
+			notation += "q"
+		case Rook:
+			notation += "r"
+		case Bishop:
+			notation += "b"
+		case Knight:
+			notation += "n"
+		}
+	}
+	return notation
+}
+
+// MoveFromUCI parses a UCI move string such as "e2e4", "e7e8q", "e1g1" or "P@e4" in the
+// context of game and returns it only if it is legal in the current position.
+func MoveFromUCI(game *Game, uci string) (Move, error) {
+	if len(uci) == 4 && uci[1] == '@' {
+		move, err := game.parseDropMove(uci)
+		if err != nil {
+			return Move{}, err
+		}
+		return legalUCIMove(game, move, uci)
+	}
+	if len(uci) != 4 && len(uci) != 5 {
+		return Move{}, fmt.Errorf("invalid UCI move: %q", uci)
+	}
+	if len(uci) == 5 && (uci[4] < 'a' || uci[4] > 'z') {
+		return Move{}, fmt.Errorf("invalid UCI promotion (must be lowercase): %q", uci)
+	}
+
+	from, err := SquareFromString(uci[:2])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid UCI move %q: %w", uci, err)
+	}
+	to, err := SquareFromString(uci[2:4])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid UCI move %q: %w", uci, err)
+	}
+
+	// The king moving two files is castling
+	if piece := game.board.GetPiece(from); piece.Type == King && piece.Color == game.activeColor &&
+		from.Rank() == to.Rank() && abs(to.File()-from.File()) == 2 {
+		move, err := game.parseCastlingMove(to.File() > from.File())
+		if err != nil {
+			return Move{}, err
+		}
+		if move.From != from || move.To != to {
+			return Move{}, fmt.Errorf("invalid UCI castling move: %q", uci)
+		}
+		return legalUCIMove(game, move, uci)
+	}
+
+	move, err := game.ParseMove(uci)
+	if err != nil {
+		return Move{}, err
+	}
+	return legalUCIMove(game, move, uci)
+}
+
+func legalUCIMove(game *Game, move Move, uci string) (Move, error) {
+	if !game.IsLegalMove(move) {
+		return Move{}, errors.New("illegal move: " + uci)
+	}
+	return move, nil
+}
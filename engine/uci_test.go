@@ -0,0 +1,49 @@
+package engine
+
+import "testing"
+
+func TestMoveUCIRoundTrip(t *testing.T) {
+	g := NewGame()
+	playAll(t, g, "e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "g8f6")
+
+	castle, err := MoveFromUCI(g, "e1g1")
+	if err != nil {
+		t.Fatalf("MoveFromUCI castling: %v", err)
+	}
+	if castle.Type != Castling || castle.String() != "O-O" || castle.UCI() != "e1g1" {
+		t.Fatalf("unexpected castling move %+v (%s / %s)", castle, castle.String(), castle.UCI())
+	}
+	if _, err := MoveFromUCI(g, "e1c1"); err == nil {
+		t.Fatal("expected queenside castling to be illegal")
+	}
+
+	if err := g.ParseFEN("8/4P3/8/8/8/8/k7/4K3 w - - 0 1"); err != nil {
+		t.Fatalf("parse FEN: %v", err)
+	}
+	promo, err := MoveFromUCI(g, "e7e8q")
+	if err != nil || promo.Promotion != Queen {
+		t.Fatalf("expected queen promotion, got %+v err=%v", promo, err)
+	}
+	if promo.UCI() != "e7e8q" || promo.String() != "e7e8Q" {
+		t.Fatalf("unexpected notation %s / %s", promo.UCI(), promo.String())
+	}
+	if _, err := MoveFromUCI(g, "e7e8Q"); err == nil {
+		t.Fatal("expected uppercase promotion to be rejected")
+	}
+	if _, err := MoveFromUCI(g, "e1e3"); err == nil {
+		t.Fatal("expected illegal move error")
+	}
+}
+
+func TestMoveUCIDrop(t *testing.T) {
+	g := NewGameWithVariant(Crazyhouse)
+	playAll(t, g, "e2e4", "d7d5", "e4d5")
+	playAll(t, g, "d8d5")
+	drop, err := MoveFromUCI(g, "P@e4")
+	if err != nil {
+		t.Fatalf("MoveFromUCI drop: %v", err)
+	}
+	if drop.UCI() != "P@e4" {
+		t.Fatalf("unexpected drop UCI %s", drop.UCI())
+	}
+}
@@ -0,0 +1,113 @@
+package engine
+
+import "errors"
+
+// Zobrist keys, generated deterministically so hashes are stable across runs.
+var (
+	zobristPieces    [2][7][64]uint64 // [side][piece type][square]
+	zobristBlack     uint64           // side to move is Black
+	zobristCastling  [4]uint64        // K, Q, k, q
+	zobristEnPassant [8]uint64        // en passant file
+	zobristPockets   [2][7][17]uint64 // [side][piece type][count, capped at 16]
+	zobristPromoted  [64]uint64       // promoted piece marker (Crazyhouse)
+)
+
+func init() {
+	state := uint64(0x9E3779B97F4A7C15)
+	next := func() uint64 { // splitmix64
+		state += 0x9E3779B97F4A7C15
+		z := state
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		return z ^ (z >> 31)
+	}
+	for side := range zobristPieces {
+		for pt := range zobristPieces[side] {
+			for sq := range zobristPieces[side][pt] {
+				zobristPieces[side][pt][sq] = next()
+			}
+		}
+	}
+	zobristBlack = next()
+	for i := range zobristCastling {
+		zobristCastling[i] = next()
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = next()
+	}
+	for side := range zobristPockets {
+		for pt := range zobristPockets[side] {
+			for n := range zobristPockets[side][pt] {
+				zobristPockets[side][pt][n] = next()
+			}
+		}
+	}
+	for sq := range zobristPromoted {
+		zobristPromoted[sq] = next()
+	}
+}
+
+// Hash returns the Zobrist hash of the current position: piece placement (and
+// Crazyhouse pockets), side to move, castling rights and a legal en passant capture.
+// Positions that are equal for repetition purposes have equal hashes.
+func (g *Game) Hash() uint64 {
+	var h uint64
+	for sq := Square(0); sq < 64; sq++ {
+		p := g.board.GetPiece(sq)
+		if p.IsEmpty() {
+			continue
+		}
+		h ^= zobristPieces[sideIndex(p.Color)][p.Type][sq]
+		if g.promoted&(1<<uint(sq)) != 0 {
+			h ^= zobristPromoted[sq]
+		}
+	}
+	if g.activeColor == Black {
+		h ^= zobristBlack
+	}
+	for i, right := range []bool{
+		g.castlingRights.WhiteKingside, g.castlingRights.WhiteQueenside,
+		g.castlingRights.BlackKingside, g.castlingRights.BlackQueenside,
+	} {
+		if right {
+			h ^= zobristCastling[i]
+		}
+	}
+	// The en passant square only distinguishes positions if the capture is possible
+	if g.hasLegalEnPassant() {
+		h ^= zobristEnPassant[g.enPassantSquare.File()]
+	}
+	if g.variant == Crazyhouse {
+		for side := range g.pockets {
+			for pt, n := range g.pockets[side] {
+				if n > 16 {
+					n = 16
+				}
+				h ^= zobristPockets[side][pt][n]
+			}
+		}
+	}
+	return h
+}
+
+// PositionHistory returns the hashes of every position reached since the game started
+// or was loaded, ending with the current position.
+func (g *Game) PositionHistory() []uint64 {
+	history := make([]uint64, len(g.positionHashes))
+	copy(history, g.positionHashes)
+	return history
+}
+
+// SetPositionHistory replaces the repetition history, e.g. after ParseFEN of a
+// position taken from another game, so repetition draws still count earlier
+// occurrences. The last hash must be the current position.
+func (g *Game) SetPositionHistory(history []uint64) error {
+	if len(history) == 0 || history[len(history)-1] != g.Hash() {
+		return errors.New("position history must end with the current position")
+	}
+	g.positionHashes = append([]uint64(nil), history...)
+	if !g.IsGameOver() {
+		g.updateGameStatus()
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package engine
+
+import "testing"
+
+func TestHashTranspositionsAndSideToMove(t *testing.T) {
+	g := NewGame()
+	start := g.Hash()
+	playAll(t, g, "g1f3")
+	if g.Hash() == start {
+		t.Fatal("hash should change after a move")
+	}
+	playAll(t, g, "g8f6", "f3g1", "f6g8")
+	if g.Hash() != start {
+		t.Fatal("returning to the start position should restore the hash")
+	}
+
+	white, black := NewGame(), NewGame()
+	_ = white.ParseFEN("8/8/8/4k3/8/8/8/4K3 w - - 0 1")
+	_ = black.ParseFEN("8/8/8/4k3/8/8/8/4K3 b - - 0 1")
+	if white.Hash() == black.Hash() {
+		t.Fatal("side to move should change the hash")
+	}
+}
+
+func TestSetPositionHistorySurvivesFENReload(t *testing.T) {
+	g := NewGame()
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	playAll(t, g, shuffle...)
+	playAll(t, g, shuffle[:3]...)
+
+	tmp := NewGame()
+	if err := tmp.ParseFEN(g.ToFEN()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tmp.SetPositionHistory(g.PositionHistory()); err != nil {
+		t.Fatalf("set history: %v", err)
+	}
+	playAll(t, tmp, "f6g8")
+	playAll(t, g, "f6g8")
+	if tmp.RepetitionCount() != 3 || tmp.RepetitionCount() != g.RepetitionCount() {
+		t.Fatalf("expected threefold repetition after reload, got %d", tmp.RepetitionCount())
+	}
+	if claims := tmp.ClaimableDraws(); len(claims) != 1 || claims[0] != DrawThreefoldRepetition {
+		t.Fatalf("expected threefold claim after reload, got %v", claims)
+	}
+
+	if err := tmp.SetPositionHistory(g.PositionHistory()[:2]); err == nil {
+		t.Fatal("expected error for history not ending in the current position")
+	}
+	if err := tmp.SetPositionHistory(nil); err == nil {
+		t.Fatal("expected error for empty history")
+	}
+}
@@ -1,17 +1,31 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.rumenx.com/chess/api"
 	"go.rumenx.com/chess/config"
+	"go.rumenx.com/chess/telemetry"
 )
 
 func main() {
 	// Create configuration
 	cfg := config.Default()
 
+	// Trace requests, if enabled
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
+	}
+
 	// Create API server
 	server := api.NewServer(cfg)
 
@@ -21,10 +35,31 @@ func main() {
 	// Setup routes
 	server.SetupRoutes(r)
 
-	// Start server
+	// Start server, until interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	addr := cfg.GetServerAddress()
+	srv := &http.Server{Addr: addr, Handler: r}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
 	log.Printf("Starting chess API server on %s", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatal("Failed to start server:", err)
+
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		log.Print("Shutting down chess API server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = srv.Shutdown(shutdownCtx)
+		cancel()
+	}
+
+	// Flush the spans still batched before exiting, log.Fatal skips deferred calls
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Print("Failed to flush traces:", err)
+	}
+	cancel()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal("Failed to run server:", err)
 	}
 }
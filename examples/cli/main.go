@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -12,7 +13,29 @@ import (
 	"go.rumenx.com/chess/engine"
 )
 
+// renderer draws the board; configured from the command-line flags.
+var renderer *engine.BoardRenderer
+
+// training warns before mistakes and blunders; warned holds the move the player
+// was last warned about, which is played if entered again.
+var (
+	training bool
+	warned   string
+)
+
 func main() {
+	unicode := flag.Bool("unicode", false, "draw pieces as Unicode chess glyphs")
+	color := flag.Bool("color", false, "color the board with ANSI escape codes")
+	flip := flag.Bool("flip", false, "show the board from Black's side")
+	flag.BoolVar(&training, "training", false, "warn before playing mistakes and blunders")
+	flag.Parse()
+	renderer = engine.NewBoardRenderer(engine.RenderOptions{
+		Unicode:     *unicode,
+		Color:       *color,
+		Coordinates: true,
+		Flipped:     *flip,
+	})
+
 	fmt.Println("Welcome to go-chess CLI!")
 	fmt.Println("Type 'help' for commands, 'quit' to exit")
 	fmt.Println()
@@ -26,7 +49,7 @@ func main() {
 	scanner := bufio.NewScanner(os.Stdin)
 
 	fmt.Println("Starting position:")
-	fmt.Println(game.Board().String())
+	fmt.Println(renderer.RenderGame(game))
 	fmt.Printf("%s to move. Enter your move (e.g., 'e2e4'): ", game.ActiveColor().String())
 
 	for scanner.Scan() {
@@ -39,7 +62,7 @@ func main() {
 		case "help", "h":
 			printHelp()
 		case "board", "b":
-			fmt.Println(game.Board().String())
+			fmt.Println(renderer.RenderGame(game))
 		case "status", "s":
 			printGameStatus(game)
 		case "history":
@@ -47,7 +70,7 @@ func main() {
 		case "new":
 			game = engine.NewGame()
 			fmt.Println("New game started!")
-			fmt.Println(game.Board().String())
+			fmt.Println(renderer.RenderGame(game))
 		default:
 			if input == "" {
 				// Empty input, just continue
@@ -74,7 +97,7 @@ func main() {
 								fmt.Printf("AI move error: %v\n", err)
 							} else {
 								fmt.Printf("AI plays: %s\n", aiMove.String())
-								fmt.Println(game.Board().String())
+								fmt.Println(renderer.RenderGame(game))
 
 								// Check game status again
 								if game.IsGameOver() {
@@ -86,7 +109,7 @@ func main() {
 						}
 					}
 
-					fmt.Println(game.Board().String())
+					fmt.Println(renderer.RenderGame(game))
 				}
 			}
 		}
@@ -109,6 +132,20 @@ func handleMove(game *engine.Game, input string) error {
 		return fmt.Errorf("invalid move notation: %v", err)
 	}
 
+	if training && input != warned && game.IsLegalMove(move) {
+		ma, flagged, err := ai.CheckMove(context.Background(), game, move, ai.AnalysisOptions{})
+		if err == nil && flagged {
+			warned = input
+			best := "another move"
+			if len(ma.BestLine) > 0 {
+				best = ma.BestLine[0]
+			}
+			return fmt.Errorf("%s looks like a %s (loses %d centipawns); consider %s, or enter it again to play it anyway",
+				ma.SAN, ma.Class, ma.CentipawnLoss, best)
+		}
+	}
+	warned = ""
+
 	if err := game.MakeMove(move); err != nil {
 		return fmt.Errorf("illegal move: %v", err)
 	}
@@ -426,7 +426,10 @@ func (u *uiGame) computeLegalTargets() {
 	for _, mv := range all {
 		if mv.From == *u.selected {
 			u.legalTargets[mv.To] = true
-			u.legalMoves[mv.To] = mv
+			// Promotions come queen first; keep that one for auto-queening
+			if _, seen := u.legalMoves[mv.To]; !seen {
+				u.legalMoves[mv.To] = mv
+			}
 		}
 	}
 }
@@ -0,0 +1,79 @@
+package puzzle
+
+import (
+	"math"
+	"time"
+)
+
+// Glicko rating parameters.
+const (
+	// InitialRating and InitialDeviation describe a solver without attempts.
+	InitialRating    = 1500
+	InitialDeviation = 350
+	// MinDeviation keeps ratings responsive after many attempts.
+	MinDeviation = 45
+	// PuzzleDeviation is the rating deviation assumed for puzzles, whose ratings
+	// are fixed.
+	PuzzleDeviation = 75
+)
+
+// deviationGrowth is Glicko's c² per day: the deviation of an inactive solver
+// grows from MinDeviation back to InitialDeviation in about three years.
+const deviationGrowth = (InitialDeviation*InitialDeviation - MinDeviation*MinDeviation) / (3 * 365.0)
+
+// glickoQ is ln(10)/400.
+var glickoQ = math.Ln10 / 400
+
+// Rating is a solver's Glicko rating.
+type Rating struct {
+	Rating    float64
+	Deviation float64
+	Attempts  int
+	Solved    int
+	// Updated is the time of the last rated attempt.
+	Updated time.Time
+}
+
+// NewRating returns the rating of a new solver.
+func NewRating() Rating {
+	return Rating{Rating: InitialRating, Deviation: InitialDeviation}
+}
+
+// Update rates an attempt at a puzzle rated puzzleRating, solved or not, made at
+// now, with a single-game Glicko rating period. The deviation first grows with
+// the time since the previous attempt.
+func (r Rating) Update(puzzleRating int, solved bool, now time.Time) Rating {
+	rd := r.Deviation
+	if !r.Updated.IsZero() && now.After(r.Updated) {
+		days := now.Sub(r.Updated).Hours() / 24
+		rd = math.Min(math.Sqrt(rd*rd+deviationGrowth*days), InitialDeviation)
+	}
+
+	score := 0.0
+	if solved {
+		score = 1
+		r.Solved++
+	}
+	g := glickoG(PuzzleDeviation)
+	e := r.Expected(puzzleRating)
+	d2 := 1 / (glickoQ * glickoQ * g * g * e * (1 - e))
+	precision := 1/(rd*rd) + 1/d2
+
+	r.Rating += glickoQ / precision * g * (score - e)
+	r.Deviation = math.Max(math.Sqrt(1/precision), MinDeviation)
+	r.Attempts++
+	r.Updated = now
+	return r
+}
+
+// Expected returns the probability that the solver solves a puzzle rated
+// puzzleRating.
+func (r Rating) Expected(puzzleRating int) float64 {
+	g := glickoG(PuzzleDeviation)
+	return 1 / (1 + math.Pow(10, -g*(r.Rating-float64(puzzleRating))/400))
+}
+
+// glickoG discounts a result by the opponent's rating deviation.
+func glickoG(rd float64) float64 {
+	return 1 / math.Sqrt(1+3*glickoQ*glickoQ*rd*rd/(math.Pi*math.Pi))
+}
@@ -0,0 +1,60 @@
+package puzzle
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRatingUpdate(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRating()
+	if got := r.Expected(InitialRating); math.Abs(got-0.5) > 1e-9 {
+		t.Fatalf("expected an even chance against an equal puzzle, got %v", got)
+	}
+
+	won := r.Update(1500, true, now)
+	lost := r.Update(1500, false, now)
+	if won.Rating <= InitialRating || lost.Rating >= InitialRating {
+		t.Fatalf("expected solving to gain and failing to lose, got %v and %v", won.Rating, lost.Rating)
+	}
+	if math.Abs((won.Rating-InitialRating)+(lost.Rating-InitialRating)) > 1e-6 {
+		t.Errorf("expected symmetric changes at equal ratings, got %v and %v", won.Rating, lost.Rating)
+	}
+	if won.Deviation >= InitialDeviation || won.Attempts != 1 || won.Solved != 1 || lost.Solved != 0 {
+		t.Errorf("unexpected rating after one attempt: %+v", won)
+	}
+	// Glickman's example magnitude: a new player beating an equal opponent gains
+	// well over 100 points
+	if gain := won.Rating - InitialRating; gain < 100 || gain > 250 {
+		t.Errorf("unexpected gain %v", gain)
+	}
+
+	// Beating an easy puzzle gains less than beating a hard one
+	easy := r.Update(1000, true, now)
+	hard := r.Update(2000, true, now)
+	if easy.Rating-InitialRating >= hard.Rating-InitialRating {
+		t.Errorf("expected a larger gain for the harder puzzle, got %v and %v", easy.Rating, hard.Rating)
+	}
+}
+
+func TestRatingDeviation(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRating()
+	for i := 0; i < 200; i++ {
+		r = r.Update(int(r.Rating), i%2 == 0, now)
+	}
+	if r.Deviation != MinDeviation {
+		t.Fatalf("expected the deviation to settle at the minimum, got %v", r.Deviation)
+	}
+
+	// After a long break the next result counts more again
+	fresh := r.Update(int(r.Rating), true, now)
+	later := r.Update(int(r.Rating), true, now.AddDate(1, 0, 0))
+	if later.Rating-r.Rating <= fresh.Rating-r.Rating {
+		t.Errorf("expected inactivity to widen the deviation, got %v and %v", later.Rating, fresh.Rating)
+	}
+	if idle := r.Update(int(r.Rating), true, now.AddDate(10, 0, 0)); idle.Deviation >= InitialDeviation {
+		t.Errorf("expected the deviation to stay below the initial value, got %v", idle.Deviation)
+	}
+}
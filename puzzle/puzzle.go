@@ -0,0 +1,281 @@
+// Package puzzle provides tactical puzzles: a built-in puzzle set, move-by-move
+// checking of solution attempts and Glicko ratings for solvers.
+package puzzle
+
+import (
+	"bufio"
+	_ "embed" // for the built-in puzzle set
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// Puzzle is a position with a forced solution line.
+type Puzzle struct {
+	ID     string
+	FEN    string
+	Rating int
+	Themes []string
+	// Solution alternates the solver's moves and the opponent's replies in UCI
+	// notation, starting and ending with a solver move.
+	Solution []string
+	// Color is the solver's side, the side to move in FEN.
+	Color engine.Color
+}
+
+// HasTheme reports whether the puzzle is tagged with theme (case-insensitive).
+func (p *Puzzle) HasTheme(theme string) bool {
+	for _, t := range p.Themes {
+		if strings.EqualFold(t, theme) {
+			return true
+		}
+	}
+	return false
+}
+
+// Game returns a new game at the puzzle position.
+func (p *Puzzle) Game() *engine.Game {
+	game := engine.NewGame()
+	_ = game.ParseFEN(p.FEN) // validated when the puzzle was loaded
+	return game
+}
+
+// Collection is an immutable set of puzzles, ordered by rating.
+type Collection struct {
+	puzzles []*Puzzle
+	byID    map[string]*Puzzle
+}
+
+//go:embed puzzles.txt
+var builtinPuzzles string
+
+var (
+	builtinOnce sync.Once
+	builtin     *Collection
+)
+
+// Builtin returns the embedded puzzle set. It is loaded on first use and shared.
+func Builtin() *Collection {
+	builtinOnce.Do(func() {
+		c, err := Read(strings.NewReader(builtinPuzzles))
+		if err != nil {
+			panic("puzzle: invalid built-in puzzle set: " + err.Error())
+		}
+		builtin = c
+	})
+	return builtin
+}
+
+// Read parses a puzzle set, one puzzle per line in the form
+// "<id> | <rating> | <themes> | <FEN> | <UCI solution>". Themes are comma
+// separated; blank lines and lines starting with '#' are ignored. Every solution
+// is replayed to make sure it is legal.
+func Read(r io.Reader) (*Collection, error) {
+	c := &Collection{byID: make(map[string]*Puzzle)}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := parsePuzzle(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if _, dup := c.byID[p.ID]; dup {
+			return nil, fmt.Errorf("line %d: duplicate puzzle id %q", n, p.ID)
+		}
+		c.byID[p.ID] = p
+		c.puzzles = append(c.puzzles, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(c.puzzles, func(i, j int) bool { return c.puzzles[i].Rating < c.puzzles[j].Rating })
+	return c, nil
+}
+
+// parsePuzzle parses and validates one puzzle line.
+func parsePuzzle(line string) (*Puzzle, error) {
+	fields := strings.Split(line, "|")
+	if len(fields) != 5 {
+		return nil, errors.New(`expected "<id> | <rating> | <themes> | <FEN> | <solution>"`)
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	p := &Puzzle{ID: fields[0], FEN: fields[3], Solution: strings.Fields(fields[4])}
+	if p.ID == "" {
+		return nil, errors.New("missing puzzle id")
+	}
+	rating, err := strconv.Atoi(fields[1])
+	if err != nil || rating <= 0 {
+		return nil, fmt.Errorf("invalid rating %q", fields[1])
+	}
+	p.Rating = rating
+	for _, theme := range strings.Split(fields[2], ",") {
+		if theme = strings.TrimSpace(theme); theme != "" {
+			p.Themes = append(p.Themes, theme)
+		}
+	}
+	if len(p.Solution)%2 == 0 {
+		return nil, errors.New("solution must end with a solver move")
+	}
+
+	game := engine.NewGame()
+	if err := game.ParseFEN(p.FEN); err != nil {
+		return nil, fmt.Errorf("invalid FEN: %w", err)
+	}
+	p.Color = game.ActiveColor()
+	for _, uci := range p.Solution {
+		move, err := engine.MoveFromUCI(game, uci)
+		if err != nil {
+			return nil, fmt.Errorf("solution: %w", err)
+		}
+		if err := game.MakeMove(move); err != nil {
+			return nil, fmt.Errorf("solution: %s: %w", uci, err)
+		}
+	}
+	return p, nil
+}
+
+// Len returns the number of puzzles.
+func (c *Collection) Len() int {
+	return len(c.puzzles)
+}
+
+// Get returns the puzzle with the given id.
+func (c *Collection) Get(id string) (*Puzzle, bool) {
+	p, ok := c.byID[id]
+	return p, ok
+}
+
+// Puzzles returns the puzzles tagged with theme, or all puzzles for an empty
+// theme, ordered by rating.
+func (c *Collection) Puzzles(theme string) []*Puzzle {
+	var result []*Puzzle
+	for _, p := range c.puzzles {
+		if theme == "" || p.HasTheme(theme) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Themes returns the distinct themes of the collection, sorted.
+func (c *Collection) Themes() []string {
+	seen := make(map[string]bool)
+	var themes []string
+	for _, p := range c.puzzles {
+		for _, t := range p.Themes {
+			if !seen[t] {
+				seen[t] = true
+				themes = append(themes, t)
+			}
+		}
+	}
+	sort.Strings(themes)
+	return themes
+}
+
+// Closest returns the puzzle rated closest to rating among those tagged with
+// theme (any theme if empty) for which skip, if non-nil, returns false.
+func (c *Collection) Closest(rating int, theme string, skip func(*Puzzle) bool) (*Puzzle, bool) {
+	var best *Puzzle
+	for _, p := range c.Puzzles(theme) {
+		if skip != nil && skip(p) {
+			continue
+		}
+		if best == nil || abs(p.Rating-rating) < abs(best.Rating-rating) {
+			best = p
+		}
+	}
+	return best, best != nil
+}
+
+// Progress is the outcome of checking a solution attempt.
+type Progress struct {
+	// Correct counts the solver moves that matched the solution.
+	Correct int
+	// Solved is set once the whole solution was played, or a solver move gave
+	// checkmate.
+	Solved bool
+	// Failed is set when a solver move deviated from the solution; Expected is
+	// the move that was wanted instead.
+	Failed   bool
+	Expected string
+	// Reply is the opponent's answer to the last correct move while the puzzle
+	// is neither solved nor failed.
+	Reply string
+}
+
+// Done reports whether the attempt is over.
+func (pr Progress) Done() bool {
+	return pr.Solved || pr.Failed
+}
+
+// Check validates the solver's moves so far, in UCI or SAN, against the solution
+// line, playing the opponent's replies in between. A move that checkmates is
+// accepted even where the solution has another mate. Moves must be legal;
+// moves after the attempt is over are an error.
+func (p *Puzzle) Check(moves []string) (Progress, error) {
+	var pr Progress
+	game := p.Game()
+	for i, notation := range moves {
+		if pr.Done() {
+			return pr, fmt.Errorf("move %d: the puzzle is already over", i+1)
+		}
+		move, err := parseMove(game, notation)
+		if err != nil {
+			return pr, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		expected := p.Solution[2*i]
+		if err := game.MakeMove(move); err != nil {
+			return pr, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		mate := game.Status() == engine.WhiteWins || game.Status() == engine.BlackWins
+		if move.UCI() != expected && !mate {
+			pr.Failed, pr.Expected = true, expected
+			continue
+		}
+		pr.Correct++
+		if mate || 2*i+1 == len(p.Solution) {
+			pr.Solved = true
+			continue
+		}
+		pr.Reply = p.Solution[2*i+1]
+		reply, err := engine.MoveFromUCI(game, pr.Reply)
+		if err == nil {
+			err = game.MakeMove(reply)
+		}
+		if err != nil {
+			return pr, fmt.Errorf("solution: %w", err)
+		}
+	}
+	if pr.Done() {
+		pr.Reply = ""
+	}
+	return pr, nil
+}
+
+// parseMove parses a legal move in UCI or SAN notation.
+func parseMove(game *engine.Game, notation string) (engine.Move, error) {
+	notation = strings.TrimSpace(notation)
+	if move, err := engine.MoveFromUCI(game, notation); err == nil {
+		return move, nil
+	}
+	return game.MoveFromSAN(notation)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,125 @@
+package puzzle
+
+import (
+	"strings"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestBuiltin(t *testing.T) {
+	c := Builtin()
+	if c.Len() < 10 {
+		t.Fatalf("expected at least 10 built-in puzzles, got %d", c.Len())
+	}
+	for _, p := range c.Puzzles("") {
+		if !p.HasTheme("mateIn1") && !p.HasTheme("mateIn2") {
+			continue
+		}
+		pr, err := p.Check(solverMoves(p))
+		if err != nil || !pr.Solved {
+			t.Errorf("%s: expected the solution to solve the puzzle, got %+v, %v", p.ID, pr, err)
+		}
+		game := p.Game()
+		for _, uci := range p.Solution {
+			mv, _ := engine.MoveFromUCI(game, uci)
+			_ = game.MakeMove(mv)
+		}
+		if s := game.Status(); s != engine.WhiteWins && s != engine.BlackWins {
+			t.Errorf("%s: expected the solution to end in mate, got %s", p.ID, s)
+		}
+	}
+
+	forks := c.Puzzles("FORK")
+	if len(forks) == 0 || !forks[0].HasTheme("fork") {
+		t.Errorf("expected theme matching to ignore case, got %d puzzles", len(forks))
+	}
+	if _, ok := c.Get("smothered-mate"); !ok {
+		t.Errorf("expected to find a puzzle by id")
+	}
+	themes := c.Themes()
+	for i := 1; i < len(themes); i++ {
+		if themes[i-1] >= themes[i] {
+			t.Fatalf("expected sorted distinct themes, got %v", themes)
+		}
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"fields":       "a | 1000 | fork | 8/8/8/8/8/8/8/k1K5 w - - 0 1",
+		"rating":       "a | x | fork | 4k3/8/8/8/8/8/8/4K2R w - - 0 1 | h1h8",
+		"fen":          "a | 1000 | fork | not a fen | h1h8",
+		"illegal":      "a | 1000 | fork | 4k3/8/8/8/8/8/8/4K2R w - - 0 1 | h1a8",
+		"even":         "a | 1000 | fork | 4k3/8/8/8/8/8/8/4K2R w - - 0 1 | h1h8 e8e7",
+		"duplicate id": "a | 1000 | x | 4k3/8/8/8/8/8/8/4K2R w - - 0 1 | h1h8\na | 1000 | y | 4k3/8/8/8/8/8/8/4K2R w - - 0 1 | h1h7",
+	} {
+		if _, err := Read(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p, _ := Builtin().Get("smothered-mate")
+
+	pr, err := p.Check([]string{"Qg8+"})
+	if err != nil || pr.Correct != 1 || pr.Done() || pr.Reply != "f8g8" {
+		t.Fatalf("expected the first move to be correct with reply f8g8, got %+v, %v", pr, err)
+	}
+	pr, err = p.Check([]string{"d5g8", "h6f7"})
+	if err != nil || !pr.Solved || pr.Correct != 2 || pr.Reply != "" {
+		t.Fatalf("expected the puzzle to be solved, got %+v, %v", pr, err)
+	}
+
+	pr, err = p.Check([]string{"h6f7"})
+	if err != nil || !pr.Failed || pr.Expected != "d5g8" || pr.Correct != 0 {
+		t.Fatalf("expected a wrong first move to fail, got %+v, %v", pr, err)
+	}
+	if _, err := p.Check([]string{"h6f7", "d5g8"}); err == nil {
+		t.Errorf("expected an error for moves after a failed attempt")
+	}
+	if _, err := p.Check([]string{"d5d1d1"}); err == nil {
+		t.Errorf("expected an error for an unparsable move")
+	}
+	if _, err := p.Check([]string{"d5h1"}); err == nil {
+		t.Errorf("expected an error for an illegal move")
+	}
+}
+
+func TestCheck_AlternativeMate(t *testing.T) {
+	// Both promotions to a queen and to a rook mate
+	p, _ := Builtin().Get("pawn-promotes-mate")
+	pr, err := p.Check([]string{"c7c8r"})
+	if err != nil || !pr.Solved {
+		t.Fatalf("expected an alternative mate to solve the puzzle, got %+v, %v", pr, err)
+	}
+}
+
+func TestClosest(t *testing.T) {
+	c := Builtin()
+	p, ok := c.Closest(1000, "", nil)
+	if !ok || p.ID != "knight-fork" {
+		t.Fatalf("expected knight-fork closest to 1000, got %+v", p)
+	}
+	p, ok = c.Closest(1000, "", func(p *Puzzle) bool { return p.ID == "knight-fork" })
+	if !ok || p.ID == "knight-fork" {
+		t.Errorf("expected skipped puzzles to be excluded, got %s", p.ID)
+	}
+	p, ok = c.Closest(3000, "mateIn1", nil)
+	if !ok || !p.HasTheme("mateIn1") {
+		t.Errorf("expected a mateIn1 puzzle, got %+v", p)
+	}
+	if _, ok := c.Closest(1000, "no-such-theme", nil); ok {
+		t.Errorf("expected no puzzle for an unknown theme")
+	}
+}
+
+// solverMoves returns the solver's moves of the solution.
+func solverMoves(p *Puzzle) []string {
+	var moves []string
+	for i := 0; i < len(p.Solution); i += 2 {
+		moves = append(moves, p.Solution[i])
+	}
+	return moves
+}
@@ -0,0 +1,299 @@
+// Package render draws chess positions as images: SVG, and PNG rasterized
+// without external tools, with the last move and a king in check highlighted.
+// The pieces are the Cburnett set (GPL-2.0+ or CC BY-SA 3.0), embedded.
+package render
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+	"sync"
+
+	"go.rumenx.com/chess/engine"
+)
+
+// Image sizes, in pixels.
+const (
+	MinSize     = 128
+	MaxSize     = 2048
+	DefaultSize = 512
+)
+
+// svgSquare is the side of a square in SVG user units, that of the piece art.
+const svgSquare = 45
+
+// Theme is the colors of a board.
+type Theme struct {
+	Name     string
+	Light    color.NRGBA
+	Dark     color.NRGBA
+	LastMove color.NRGBA // drawn over the squares of the last move
+	Check    color.NRGBA // drawn over the square of a king in check
+}
+
+// Built-in themes.
+var (
+	LightTheme = Theme{
+		Name:     "light",
+		Light:    color.NRGBA{0xf0, 0xd9, 0xb5, 0xff},
+		Dark:     color.NRGBA{0xb5, 0x88, 0x63, 0xff},
+		LastMove: color.NRGBA{0x9b, 0xc7, 0x00, 0x69},
+		Check:    color.NRGBA{0xff, 0x00, 0x00, 0x99},
+	}
+	DarkTheme = Theme{
+		Name:     "dark",
+		Light:    color.NRGBA{0x8c, 0x9a, 0xa8, 0xff},
+		Dark:     color.NRGBA{0x4b, 0x58, 0x68, 0xff},
+		LastMove: color.NRGBA{0x3c, 0xa0, 0xc8, 0x80},
+		Check:    color.NRGBA{0xe0, 0x30, 0x30, 0xb0},
+	}
+)
+
+// ThemeByName returns the built-in theme called name, light or dark.
+func ThemeByName(name string) (Theme, bool) {
+	switch name {
+	case LightTheme.Name:
+		return LightTheme, true
+	case DarkTheme.Name:
+		return DarkTheme, true
+	}
+	return Theme{}, false
+}
+
+// Options control how a position is drawn.
+type Options struct {
+	Size  int   // width and height in pixels; DefaultSize if zero
+	Theme Theme // LightTheme if zero
+	Flip  bool  // draw the board from Black's side
+}
+
+func (o Options) size() int {
+	if o.Size == 0 {
+		return DefaultSize
+	}
+	return min(max(o.Size, MinSize), MaxSize)
+}
+
+func (o Options) theme() Theme {
+	if o.Theme.Name == "" {
+		return LightTheme
+	}
+	return o.Theme
+}
+
+//go:embed pieces
+var pieceFiles embed.FS
+
+// pieceFile returns the name of the art of a piece, e.g. w_n for a white knight.
+func pieceFile(p engine.Piece) string {
+	prefix := "w_"
+	if p.Color == engine.Black {
+		prefix = "b_"
+	}
+	return prefix + strings.ToLower(p.String())
+}
+
+// highlights returns the squares of the game's last move and, if the side to
+// move is in check or mated, of its king, or -1.
+func highlights(game *engine.Game) (last []engine.Square, check engine.Square) {
+	if history := game.MoveHistory(); len(history) > 0 {
+		move := history[len(history)-1]
+		if move.Type == engine.Drop {
+			last = []engine.Square{move.To}
+		} else {
+			last = []engine.Square{move.From, move.To}
+		}
+	}
+	check = -1
+	if game.Status() == engine.Check || game.Result().Termination == engine.TerminationCheckmate {
+		board := game.Board()
+		for sq := engine.A1; sq <= engine.H8; sq++ {
+			if p := board.GetPiece(sq); p.Type == engine.King && p.Color == game.ActiveColor() {
+				check = sq
+			}
+		}
+	}
+	return last, check
+}
+
+// cell returns the column and row, from the top left, a square is drawn at.
+func (o Options) cell(sq engine.Square) (int, int) {
+	if o.Flip {
+		return 7 - sq.File(), sq.Rank()
+	}
+	return sq.File(), 7 - sq.Rank()
+}
+
+// SVG draws the current position of a game as an SVG image.
+func SVG(game *engine.Game, opts Options) []byte {
+	size, theme := opts.size(), opts.theme()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, size, size, 8*svgSquare, 8*svgSquare)
+	fill := func(c color.NRGBA) string {
+		return fmt.Sprintf(`fill="#%02x%02x%02x" fill-opacity="%.3g"`, c.R, c.G, c.B, float64(c.A)/255)
+	}
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" %s/>`, 8*svgSquare, 8*svgSquare, fill(theme.Light))
+	square := func(sq engine.Square, c color.NRGBA) {
+		col, row := opts.cell(sq)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" %s/>`, col*svgSquare, row*svgSquare, svgSquare, svgSquare, fill(c))
+	}
+	for sq := engine.A1; sq <= engine.H8; sq++ {
+		if (sq.File()+sq.Rank())%2 == 0 {
+			square(sq, theme.Dark)
+		}
+	}
+	last, check := highlights(game)
+	for _, sq := range last {
+		square(sq, theme.LastMove)
+	}
+	if check >= 0 {
+		square(check, theme.Check)
+	}
+	board := game.Board()
+	for sq := engine.A1; sq <= engine.H8; sq++ {
+		if p := board.GetPiece(sq); !p.IsEmpty() {
+			col, row := opts.cell(sq)
+			fmt.Fprintf(&b, `<g transform="translate(%d %d)">%s</g>`, col*svgSquare, row*svgSquare, svgPieces()[pieceFile(p)])
+		}
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+var (
+	svgPiecesOnce sync.Once
+	svgPieceArt   map[string]string
+	pngPiecesOnce sync.Once
+	pngPieceArt   map[string]*image.RGBA
+)
+
+// svgPieces returns the contents of the piece SVGs, without their svg elements,
+// by name. They are drawn in a 45x45 box.
+func svgPieces() map[string]string {
+	svgPiecesOnce.Do(func() {
+		svgPieceArt = make(map[string]string)
+		entries, _ := pieceFiles.ReadDir("pieces")
+		for _, entry := range entries {
+			name, ok := strings.CutSuffix(entry.Name(), ".svg")
+			if !ok {
+				continue
+			}
+			data, _ := pieceFiles.ReadFile("pieces/" + entry.Name())
+			art := string(data)
+			start := strings.Index(art, "<svg")
+			start += strings.Index(art[start:], ">") + 1
+			svgPieceArt[name] = strings.TrimSpace(art[start:strings.LastIndex(art, "</svg>")])
+		}
+	})
+	return svgPieceArt
+}
+
+// pngPieces returns the decoded piece PNGs by name.
+func pngPieces() map[string]*image.RGBA {
+	pngPiecesOnce.Do(func() {
+		pngPieceArt = make(map[string]*image.RGBA)
+		entries, _ := pieceFiles.ReadDir("pieces")
+		for _, entry := range entries {
+			name, ok := strings.CutSuffix(entry.Name(), ".png")
+			if !ok {
+				continue
+			}
+			data, _ := pieceFiles.ReadFile("pieces/" + entry.Name())
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				panic("render: invalid piece image " + entry.Name() + ": " + err.Error())
+			}
+			rgba := image.NewRGBA(img.Bounds())
+			draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+			pngPieceArt[name] = rgba
+		}
+	})
+	return pngPieceArt
+}
+
+// PNG draws the current position of a game as a PNG image.
+func PNG(game *engine.Game, opts Options) ([]byte, error) {
+	size, theme := opts.size(), opts.theme()
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	// Square edges are spread over the size, which need not divide by 8
+	bounds := func(sq engine.Square) image.Rectangle {
+		col, row := opts.cell(sq)
+		return image.Rect(col*size/8, row*size/8, (col+1)*size/8, (row+1)*size/8)
+	}
+	paint := func(sq engine.Square, c color.NRGBA) {
+		draw.Draw(img, bounds(sq), image.NewUniform(c), image.Point{}, draw.Over)
+	}
+	for sq := engine.A1; sq <= engine.H8; sq++ {
+		if (sq.File()+sq.Rank())%2 == 0 {
+			paint(sq, theme.Dark)
+		} else {
+			paint(sq, theme.Light)
+		}
+	}
+	last, check := highlights(game)
+	for _, sq := range last {
+		paint(sq, theme.LastMove)
+	}
+	if check >= 0 {
+		paint(check, theme.Check)
+	}
+	board := game.Board()
+	for sq := engine.A1; sq <= engine.H8; sq++ {
+		if p := board.GetPiece(sq); !p.IsEmpty() {
+			r := bounds(sq)
+			art := scale(pngPieces()[pieceFile(p)], r.Dx(), r.Dy())
+			draw.Draw(img, r, art, image.Point{}, draw.Over)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// scale resizes an image to w x h, averaging bilinear samples so that
+// shrinking does not alias.
+func scale(src *image.RGBA, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	fx, fy := float64(sw)/float64(w), float64(sh)/float64(h)
+	n := max(int(math.Ceil(fx)), int(math.Ceil(fy)), 1) // samples per axis
+	at := func(x, y int) []uint8 {
+		x, y = min(max(x, 0), sw-1), min(max(y, 0), sh-1)
+		i := src.PixOffset(x, y)
+		return src.Pix[i : i+4]
+	}
+	var sum [4]float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sum = [4]float64{}
+			for j := 0; j < n; j++ {
+				for i := 0; i < n; i++ {
+					sx := (float64(x)+(float64(i)+0.5)/float64(n))*fx - 0.5
+					sy := (float64(y)+(float64(j)+0.5)/float64(n))*fy - 0.5
+					x0, y0 := int(math.Floor(sx)), int(math.Floor(sy))
+					ax, ay := sx-float64(x0), sy-float64(y0)
+					p00, p10, p01, p11 := at(x0, y0), at(x0+1, y0), at(x0, y0+1), at(x0+1, y0+1)
+					for c := range sum {
+						top := float64(p00[c])*(1-ax) + float64(p10[c])*ax
+						bottom := float64(p01[c])*(1-ax) + float64(p11[c])*ax
+						sum[c] += top*(1-ay) + bottom*ay
+					}
+				}
+			}
+			o := dst.PixOffset(x, y)
+			for c := range sum {
+				dst.Pix[o+c] = uint8(math.Round(sum[c] / float64(n*n)))
+			}
+		}
+	}
+	return dst
+}
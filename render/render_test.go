@@ -0,0 +1,75 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"go.rumenx.com/chess/engine"
+)
+
+func TestSVG(t *testing.T) {
+	game := engine.NewGame()
+	for _, move := range []string{"e2e4", "f7f6", "d2d4", "g7g5", "d1h5"} {
+		mv, err := game.ParseMove(move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := game.MakeMove(mv); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svg := string(SVG(game, Options{Size: 256}))
+	if !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256"`) {
+		t.Fatalf("expected a 256px SVG, got %.100s", svg)
+	}
+	if n := strings.Count(svg, "<g transform="); n != 32 {
+		t.Fatalf("expected 32 pieces, got %d", n)
+	}
+	// Qh5# highlights d1, h5 and the mated king on e8
+	for _, rect := range []string{`x="135" y="315"`, `x="315" y="135"`, `x="180" y="0"`} {
+		if !strings.Contains(svg, rect) {
+			t.Fatalf("expected a highlight at %s", rect)
+		}
+	}
+	if !strings.Contains(svg, `fill="#ff0000"`) {
+		t.Fatal("expected the king in check highlighted")
+	}
+
+	// From Black's side e8 is in the bottom row
+	if flipped := string(SVG(game, Options{Flip: true})); !strings.Contains(flipped, `x="135" y="315"`) || !strings.Contains(flipped, `width="512"`) {
+		t.Fatal("expected the flipped board to have e8 at the bottom at the default size")
+	}
+}
+
+func TestPNG(t *testing.T) {
+	game := engine.NewGame()
+	data, err := PNG(game, Options{Size: 200, Theme: DarkTheme})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 200 {
+		t.Fatalf("expected 200x200, got %v", b)
+	}
+	// e4 is empty, a light square of the dark theme
+	r, g, b, _ := img.At(4*200/8+12, 4*200/8+12).RGBA()
+	if want := DarkTheme.Light; r>>8 != uint32(want.R) || g>>8 != uint32(want.G) || b>>8 != uint32(want.B) {
+		t.Fatalf("expected e4 in the light square color, got %d,%d,%d", r>>8, g>>8, b>>8)
+	}
+	// The a1 rook covers the middle of its dark square
+	r, g, b, _ = img.At(12, 200-12).RGBA()
+	if want := DarkTheme.Dark; r>>8 == uint32(want.R) && g>>8 == uint32(want.G) && b>>8 == uint32(want.B) {
+		t.Fatal("expected a rook drawn on a1")
+	}
+
+	small, _ := PNG(game, Options{Size: 1})
+	if img, _ := png.Decode(bytes.NewReader(small)); img.Bounds().Dx() != MinSize {
+		t.Fatalf("expected sizes clamped to %d, got %d", MinSize, img.Bounds().Dx())
+	}
+}
@@ -0,0 +1,300 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/chat"
+	"go.rumenx.com/chess/config"
+)
+
+// RedisStore is a Store, a GameStore and a Coordinator in Redis. It holds
+// each game's conversation, LLM history and record as JSON under
+// "chess:conversation:<id>", "chess:llm-history:<id>" and "chess:game:<id>",
+// the IDs of the saved games in the set "chess:games", the last game ID handed
+// out in "chess:game-id" and game locks under "chess:lock:game:<id>".
+type RedisStore struct {
+	client *redis.Client
+}
+
+// Game locks expire unless renewed, so that the lock of a server that died
+// is freed; the server holding a lock renews it every third of gameLockTTL.
+const (
+	gameLockTTL   = 30 * time.Second
+	gameLockRetry = 20 * time.Millisecond // between attempts to take a held lock
+)
+
+// Game locks are released and renewed only by their holder, known by the
+// random token it set the lock to.
+var (
+	unlockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+	renewScript  = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`)
+)
+
+// openRedis connects to the Redis server at the URL of cfg.
+func openRedis(cfg config.DatabaseConfig) (*RedisStore, error) {
+	options, err := redis.ParseURL(cfg.ConnectionString)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	if cfg.MaxConnections > 0 {
+		options.PoolSize = cfg.MaxConnections
+	}
+	options.ConnMaxLifetime = cfg.ConnMaxLifetime
+
+	client := redis.NewClient(options)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return &RedisStore{client: client}, nil
+}
+
+func conversationKey(gameID int) string { return fmt.Sprintf("chess:conversation:%d", gameID) }
+func llmHistoryKey(gameID int) string   { return fmt.Sprintf("chess:llm-history:%d", gameID) }
+func gameKey(gameID int) string         { return fmt.Sprintf("chess:game:%d", gameID) }
+func gameLockKey(gameID int) string     { return fmt.Sprintf("chess:lock:game:%d", gameID) }
+
+const (
+	gamesKey  = "chess:games"
+	gameIDKey = "chess:game-id"
+)
+
+// LoadConversation returns a game's conversation, or nil if none is saved.
+func (s *RedisStore) LoadConversation(ctx context.Context, gameID int) (*chat.Conversation, error) {
+	var conversation chat.Conversation
+	found, err := s.load(ctx, conversationKey(gameID), &conversation)
+	if !found || err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// SaveConversation saves a conversation, replacing its game's earlier one if
+// that has the same version. The key is watched from reading the stored version
+// to writing, so that a save in between fails the write.
+func (s *RedisStore) SaveConversation(ctx context.Context, conversation *chat.Conversation) error {
+	key := conversationKey(conversation.GameID)
+	err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+		var stored struct {
+			Version int `json:"version"`
+		}
+		found, err := s.loadWith(ctx, tx, key, &stored)
+		if err != nil {
+			return err
+		}
+		if found && stored.Version != conversation.Version || !found && conversation.Version != 0 {
+			return chat.ErrConversationChanged
+		}
+		conversation.Version++
+		data, err := json.Marshal(conversation)
+		conversation.Version--
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", key, err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, 0)
+			return nil
+		})
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return chat.ErrConversationChanged
+	}
+	if err != nil {
+		return err
+	}
+	conversation.Version++
+	return nil
+}
+
+// DeleteConversation removes a game's conversation.
+func (s *RedisStore) DeleteConversation(ctx context.Context, gameID int) error {
+	return s.client.Del(ctx, conversationKey(gameID)).Err()
+}
+
+// LoadLLMHistory returns the messages an LLM engine remembers of a game, or nil
+// if none are saved.
+func (s *RedisStore) LoadLLMHistory(ctx context.Context, gameID int) ([]ai.ChatMessage, error) {
+	var messages []ai.ChatMessage
+	_, err := s.load(ctx, llmHistoryKey(gameID), &messages)
+	return messages, err
+}
+
+// SaveLLMHistory saves the messages an LLM engine remembers of a game.
+func (s *RedisStore) SaveLLMHistory(ctx context.Context, gameID int, messages []ai.ChatMessage) error {
+	return s.save(ctx, llmHistoryKey(gameID), messages)
+}
+
+// DeleteLLMHistory removes what LLM engines remember of a game.
+func (s *RedisStore) DeleteLLMHistory(ctx context.Context, gameID int) error {
+	return s.client.Del(ctx, llmHistoryKey(gameID)).Err()
+}
+
+// SaveGame saves a game, replacing its earlier record.
+func (s *RedisStore) SaveGame(ctx context.Context, game *GameRecord) error {
+	game.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(game)
+	if err != nil {
+		return fmt.Errorf("failed to encode game %d: %w", game.ID, err)
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, gameKey(game.ID), data, 0)
+		pipe.SAdd(ctx, gamesKey, game.ID)
+		return nil
+	})
+	return err
+}
+
+// LoadGame returns a game, or nil if none is saved under the ID.
+func (s *RedisStore) LoadGame(ctx context.Context, id int) (*GameRecord, error) {
+	var game GameRecord
+	found, err := s.load(ctx, gameKey(id), &game)
+	if !found || err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+// LoadGames returns every saved game, by ID.
+func (s *RedisStore) LoadGames(ctx context.Context) ([]GameRecord, error) {
+	members, err := s.client.SMembers(ctx, gamesKey).Result()
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+	ids := make([]int, 0, len(members))
+	for _, member := range members {
+		if id, err := strconv.Atoi(member); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = gameKey(id)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	var games []GameRecord
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok { // deleted meanwhile
+			continue
+		}
+		var game GameRecord
+		if err := json.Unmarshal([]byte(data), &game); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", keys[i], err)
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+// DeleteGame removes a game.
+func (s *RedisStore) DeleteGame(ctx context.Context, id int) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, gameKey(id))
+		pipe.SRem(ctx, gamesKey, id)
+		return nil
+	})
+	return err
+}
+
+// NextGameID returns a game ID no server has used.
+func (s *RedisStore) NextGameID(ctx context.Context) (int, error) {
+	id, err := s.client.Incr(ctx, gameIDKey).Result()
+	return int(id), err
+}
+
+// LockGame waits until it holds the lock of a game, or ctx is done, and
+// returns the function that releases it. The lock is renewed until then.
+func (s *RedisStore) LockGame(ctx context.Context, gameID int) (func(), error) {
+	key := gameLockKey(gameID)
+	var random [16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(random[:])
+	for {
+		locked, err := s.client.SetNX(ctx, key, token, gameLockTTL).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock game %d: %w", gameID, err)
+		}
+		if locked {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to lock game %d: %w", gameID, ctx.Err())
+		case <-time.After(gameLockRetry):
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(gameLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				renewScript.Run(context.Background(), s.client, []string{key}, token, gameLockTTL.Milliseconds())
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			unlockScript.Run(context.Background(), s.client, []string{key}, token)
+		})
+	}, nil
+}
+
+// Close closes the connections to Redis.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// load decodes the JSON value at key into v, reporting whether the key exists.
+func (s *RedisStore) load(ctx context.Context, key string, v any) (bool, error) {
+	return s.loadWith(ctx, s.client, key, v)
+}
+
+// loadWith is load reading with client, e.g. in a transaction.
+func (s *RedisStore) loadWith(ctx context.Context, client redis.Cmdable, key string, v any) (bool, error) {
+	data, err := client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// save stores v as JSON at key.
+func (s *RedisStore) save(ctx context.Context, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	return s.client.Set(ctx, key, data, 0).Err()
+}
@@ -0,0 +1,318 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/chat"
+)
+
+// migrations upgrade the schema of a SQLStore, one version each, in order.
+// Conversations and histories are stored as JSON, one row per game; games as
+// PGN and JSON metadata, with their moves one row per ply.
+var migrations = [][]string{
+	{ // 1: chat conversations and LLM histories
+		`CREATE TABLE IF NOT EXISTS chat_conversations (
+			game_id    INTEGER PRIMARY KEY,
+			data       TEXT NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS llm_histories (
+			game_id    INTEGER PRIMARY KEY,
+			messages   TEXT NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	},
+	{ // 2: games and their move history
+		`CREATE TABLE games (
+			id         INTEGER PRIMARY KEY,
+			pgn        TEXT NOT NULL,
+			metadata   TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE TABLE game_moves (
+			game_id INTEGER NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+			ply     INTEGER NOT NULL,
+			uci     TEXT NOT NULL,
+			san     TEXT NOT NULL,
+			PRIMARY KEY (game_id, ply)
+		)`,
+	},
+	{ // 3: conversation versions, so that changes from several servers are not lost
+		`ALTER TABLE chat_conversations ADD COLUMN version INTEGER NOT NULL DEFAULT 0`,
+	},
+}
+
+// SQLStore is a Store and a GameStore in a SQL database.
+type SQLStore struct {
+	db       *sql.DB
+	numbered bool // placeholders are $1, $2, ... (PostgreSQL) rather than ?
+}
+
+// migrate brings the schema to the latest version, recording the versions
+// applied in schema_migrations.
+func (s *SQLStore) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create the migrations table: %w", err)
+	}
+	var version int
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read the schema version: %w", err)
+	}
+	for v := version + 1; v <= len(migrations); v++ {
+		if err := s.applyMigration(ctx, v); err != nil {
+			return fmt.Errorf("failed to migrate the database to version %d: %w", v, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs a migration and records it in one transaction.
+func (s *SQLStore) applyMigration(ctx context.Context, version int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, statement := range migrations[version-1] {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`), version, time.Now().UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rebind rewrites the ? placeholders of query for the database.
+func (s *SQLStore) rebind(query string) string {
+	if !s.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// LoadConversation returns a game's conversation, or nil if none is saved.
+func (s *SQLStore) LoadConversation(ctx context.Context, gameID int) (*chat.Conversation, error) {
+	var conversation chat.Conversation
+	found, err := s.load(ctx, `SELECT data FROM chat_conversations WHERE game_id = ?`, gameID, &conversation)
+	if !found || err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// SaveConversation saves a conversation, replacing its game's earlier one if
+// that has the same version.
+func (s *SQLStore) SaveConversation(ctx context.Context, conversation *chat.Conversation) error {
+	conversation.Version++
+	data, err := json.Marshal(conversation)
+	conversation.Version--
+	if err != nil {
+		return fmt.Errorf("failed to encode data of game %d: %w", conversation.GameID, err)
+	}
+	result, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO chat_conversations (game_id, data, updated_at, version) VALUES (?, ?, ?, ?)
+		ON CONFLICT (game_id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at, version = excluded.version
+		WHERE chat_conversations.version = ?`),
+		conversation.GameID, string(data), time.Now().UTC(), conversation.Version+1, conversation.Version)
+	if err != nil {
+		return err
+	}
+	saved, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if saved == 0 {
+		return chat.ErrConversationChanged
+	}
+	conversation.Version++
+	return nil
+}
+
+// DeleteConversation removes a game's conversation.
+func (s *SQLStore) DeleteConversation(ctx context.Context, gameID int) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM chat_conversations WHERE game_id = ?`), gameID)
+	return err
+}
+
+// LoadLLMHistory returns the messages an LLM engine remembers of a game, or nil
+// if none are saved.
+func (s *SQLStore) LoadLLMHistory(ctx context.Context, gameID int) ([]ai.ChatMessage, error) {
+	var messages []ai.ChatMessage
+	_, err := s.load(ctx, `SELECT messages FROM llm_histories WHERE game_id = ?`, gameID, &messages)
+	return messages, err
+}
+
+// SaveLLMHistory saves the messages an LLM engine remembers of a game.
+func (s *SQLStore) SaveLLMHistory(ctx context.Context, gameID int, messages []ai.ChatMessage) error {
+	return s.save(ctx, `INSERT INTO llm_histories (game_id, messages, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (game_id) DO UPDATE SET messages = excluded.messages, updated_at = excluded.updated_at`,
+		gameID, messages)
+}
+
+// DeleteLLMHistory removes what LLM engines remember of a game.
+func (s *SQLStore) DeleteLLMHistory(ctx context.Context, gameID int) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM llm_histories WHERE game_id = ?`), gameID)
+	return err
+}
+
+// SaveGame saves a game with its moves, replacing its earlier record.
+func (s *SQLStore) SaveGame(ctx context.Context, game *GameRecord) error {
+	metadata := game.Metadata
+	if metadata == nil {
+		metadata = json.RawMessage("{}")
+	}
+	game.UpdatedAt = time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO games (id, pgn, metadata, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET pgn = excluded.pgn, metadata = excluded.metadata, updated_at = excluded.updated_at`),
+		game.ID, game.PGN, string(metadata), game.CreatedAt.UTC(), game.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save game %d: %w", game.ID, err)
+	}
+	if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM game_moves WHERE game_id = ?`), game.ID); err != nil {
+		return fmt.Errorf("failed to save the moves of game %d: %w", game.ID, err)
+	}
+	for ply, move := range game.Moves {
+		if _, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO game_moves (game_id, ply, uci, san) VALUES (?, ?, ?, ?)`),
+			game.ID, ply+1, move.UCI, move.SAN); err != nil {
+			return fmt.Errorf("failed to save the moves of game %d: %w", game.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadGame returns a game, or nil if none is saved under the ID.
+func (s *SQLStore) LoadGame(ctx context.Context, id int) (*GameRecord, error) {
+	games, err := s.loadGames(ctx, `WHERE id = ?`, id)
+	if len(games) == 0 || err != nil {
+		return nil, err
+	}
+	return &games[0], nil
+}
+
+// LoadGames returns every saved game, by ID.
+func (s *SQLStore) LoadGames(ctx context.Context) ([]GameRecord, error) {
+	return s.loadGames(ctx, ``)
+}
+
+// loadGames returns the games the WHERE clause where selects, if any, by ID,
+// with their moves.
+func (s *SQLStore) loadGames(ctx context.Context, where string, args ...any) ([]GameRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT id, pgn, metadata, created_at, updated_at FROM games `+where+` ORDER BY id`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load games: %w", err)
+	}
+	defer rows.Close()
+	var games []GameRecord
+	byID := make(map[int]int) // game ID -> index in games
+	for rows.Next() {
+		var game GameRecord
+		var metadata string
+		if err := rows.Scan(&game.ID, &game.PGN, &metadata, &game.CreatedAt, &game.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to load games: %w", err)
+		}
+		game.Metadata = json.RawMessage(metadata)
+		byID[game.ID] = len(games)
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load games: %w", err)
+	}
+	if len(games) == 0 {
+		return nil, nil
+	}
+
+	moveWhere := ""
+	if where != "" {
+		moveWhere = `WHERE game_id IN (SELECT id FROM games ` + where + `)`
+	}
+	moves, err := s.db.QueryContext(ctx, s.rebind(`SELECT game_id, uci, san FROM game_moves `+moveWhere+` ORDER BY game_id, ply`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load moves: %w", err)
+	}
+	defer moves.Close()
+	for moves.Next() {
+		var gameID int
+		var move MoveRecord
+		if err := moves.Scan(&gameID, &move.UCI, &move.SAN); err != nil {
+			return nil, fmt.Errorf("failed to load moves: %w", err)
+		}
+		if i, ok := byID[gameID]; ok {
+			games[i].Moves = append(games[i].Moves, move)
+		}
+	}
+	return games, moves.Err()
+}
+
+// DeleteGame removes a game and its moves.
+func (s *SQLStore) DeleteGame(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, statement := range []string{`DELETE FROM game_moves WHERE game_id = ?`, `DELETE FROM games WHERE id = ?`} {
+		if _, err := tx.ExecContext(ctx, s.rebind(statement), id); err != nil {
+			return fmt.Errorf("failed to delete game %d: %w", id, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Close closes the database.
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// load decodes the JSON column selected by query for gameID into v, reporting
+// whether the game has a row.
+func (s *SQLStore) load(ctx context.Context, query string, gameID int, v any) (bool, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, s.rebind(query), gameID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		return false, fmt.Errorf("failed to decode stored data of game %d: %w", gameID, err)
+	}
+	return true, nil
+}
+
+// save upserts v as JSON with the upsert statement for gameID.
+func (s *SQLStore) save(ctx context.Context, statement string, gameID int, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode data of game %d: %w", gameID, err)
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(statement), gameID, string(data), time.Now().UTC())
+	return err
+}
@@ -0,0 +1,116 @@
+// Package store persists server state in the configured database, SQLite,
+// PostgreSQL or Redis, so that games, an in-progress game's chat conversation
+// and its LLM opponent's memory survive a restart and are shared by API servers
+// on the same database.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/chat"
+	"go.rumenx.com/chess/config"
+
+	_ "github.com/lib/pq"  // registers the "postgres" driver
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// Store keeps chat conversations and the histories of LLM engines by game.
+type Store interface {
+	chat.ConversationStore
+
+	// LoadLLMHistory returns the messages an LLM engine remembers of a game, or
+	// nil if none are saved.
+	LoadLLMHistory(ctx context.Context, gameID int) ([]ai.ChatMessage, error)
+	SaveLLMHistory(ctx context.Context, gameID int, messages []ai.ChatMessage) error
+	DeleteLLMHistory(ctx context.Context, gameID int) error
+
+	Close() error
+}
+
+// GameRecord is a game as a GameStore keeps it.
+type GameRecord struct {
+	ID int `json:"id"`
+	// PGN holds the variant, the starting position, the moves with their
+	// annotations and clock comments, and the result, as engine.ParsePGN reads
+	// them back.
+	PGN string `json:"pgn"`
+	// Moves is the move history, kept beside the PGN for queries.
+	Moves []MoveRecord `json:"moves"`
+	// Metadata is what the API server knows of the game besides its moves, as JSON.
+	Metadata  json.RawMessage `json:"metadata"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"` // set by SaveGame
+}
+
+// MoveRecord is a move of a GameRecord.
+type MoveRecord struct {
+	UCI string `json:"uci"`
+	SAN string `json:"san"`
+}
+
+// GameStore keeps games, so that they survive a restart.
+type GameStore interface {
+	// SaveGame saves a game, replacing its earlier record.
+	SaveGame(ctx context.Context, game *GameRecord) error
+	// LoadGame returns a game, or nil if none is saved under the ID.
+	LoadGame(ctx context.Context, id int) (*GameRecord, error)
+	// LoadGames returns every saved game, by ID.
+	LoadGames(ctx context.Context) ([]GameRecord, error)
+	DeleteGame(ctx context.Context, id int) error
+}
+
+// Coordinator lets API servers on the same database serve the same games: it
+// hands out game IDs and holds per-game locks across servers.
+type Coordinator interface {
+	// NextGameID returns a game ID no server has used.
+	NextGameID(ctx context.Context) (int, error)
+	// LockGame waits until it holds the lock of a game on every server, or ctx
+	// is done, and returns the function that releases it.
+	LockGame(ctx context.Context, gameID int) (unlock func(), err error)
+}
+
+// sqlDrivers maps the configured driver names to registered database/sql drivers.
+var sqlDrivers = map[string]string{
+	"sqlite":     "sqlite",
+	"sqlite3":    "sqlite",
+	"postgres":   "postgres",
+	"postgresql": "postgres",
+}
+
+// Open connects to the database of cfg: Redis for the "redis" driver, whose
+// connection string is a URL such as redis://localhost:6379/0, or a SQL database
+// that it migrates to the latest schema. Both are GameStores, and Redis stores
+// are Coordinators too.
+func Open(cfg config.DatabaseConfig) (Store, error) {
+	if cfg.Driver == "redis" {
+		redisStore, err := openRedis(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return redisStore, nil
+	}
+	driver, ok := sqlDrivers[cfg.Driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+	db, err := sql.Open(driver, cfg.ConnectionString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if cfg.MaxConnections > 0 {
+		db.SetMaxOpenConns(cfg.MaxConnections)
+	}
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	store := &SQLStore{db: db, numbered: driver == "postgres"}
+	if err := store.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
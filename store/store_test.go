@@ -0,0 +1,298 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"go.rumenx.com/chess/ai"
+	"go.rumenx.com/chess/chat"
+	"go.rumenx.com/chess/config"
+)
+
+// testSurvivesReopen saves a game's conversation and LLM history with one store
+// and reads them back with another opened on the same database.
+func testSurvivesReopen(t *testing.T, cfg config.DatabaseConfig) {
+	ctx := context.Background()
+	open := func() Store {
+		t.Helper()
+		db, err := Open(cfg)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		return db
+	}
+	db := open()
+
+	conversation := &chat.Conversation{
+		GameID:      7,
+		Language:    "de",
+		Personality: "silent-assassin",
+		Messages:    []chat.Message{{ID: "ai_7_1", Type: "ai", Content: "Hallo!", Timestamp: time.Now()}},
+	}
+	if err := db.SaveConversation(ctx, conversation); err != nil {
+		t.Fatalf("save conversation: %v", err)
+	}
+	conversation.Messages = append(conversation.Messages, chat.Message{ID: "user_7_2", Type: "user", Content: "e4?"})
+	if err := db.SaveConversation(ctx, conversation); err != nil {
+		t.Fatalf("replace conversation: %v", err)
+	}
+	// A copy loaded before the last save, e.g. by another server, is not saved over it
+	stale := *conversation
+	stale.Version--
+	if err := db.SaveConversation(ctx, &stale); !errors.Is(err, chat.ErrConversationChanged) {
+		t.Fatalf("expected a stale conversation refused, got %v", err)
+	}
+	history := []ai.ChatMessage{{Role: "user", Content: "Your move"}, {Role: "assistant", Content: `{"from":"e7","to":"e5"}`}}
+	if err := db.SaveLLMHistory(ctx, 7, history); err != nil {
+		t.Fatalf("save history: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db = open()
+	defer db.Close()
+	loaded, err := db.LoadConversation(ctx, 7)
+	if err != nil || loaded == nil {
+		t.Fatalf("expected the saved conversation, got %v: %v", loaded, err)
+	}
+	if loaded.Language != "de" || loaded.Personality != "silent-assassin" || len(loaded.Messages) != 2 || loaded.Messages[1].Content != "e4?" {
+		t.Errorf("unexpected conversation %+v", loaded)
+	}
+	if got, err := db.LoadLLMHistory(ctx, 7); err != nil || len(got) != 2 || got[1] != history[1] {
+		t.Errorf("expected the saved history, got %v: %v", got, err)
+	}
+
+	// Unknown and deleted games have nothing stored
+	if c, err := db.LoadConversation(ctx, 8); c != nil || err != nil {
+		t.Errorf("expected no conversation for an unknown game, got %v: %v", c, err)
+	}
+	if err := db.DeleteConversation(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeleteLLMHistory(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := db.LoadConversation(ctx, 7); c != nil {
+		t.Errorf("expected the conversation to be deleted, got %+v", c)
+	}
+	if h, _ := db.LoadLLMHistory(ctx, 7); h != nil {
+		t.Errorf("expected the history to be deleted, got %v", h)
+	}
+}
+
+// testGamesSurviveReopen saves games with one store and reads them back with
+// another opened on the same database.
+func testGamesSurviveReopen(t *testing.T, cfg config.DatabaseConfig) {
+	ctx := context.Background()
+	open := func() GameStore {
+		t.Helper()
+		db, err := Open(cfg)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		games, ok := db.(GameStore)
+		if !ok {
+			t.Fatalf("expected a %s store to keep games", cfg.Driver)
+		}
+		return games
+	}
+	db := open()
+
+	created := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	game := &GameRecord{
+		ID:        3,
+		PGN:       "[Variant \"Standard\"]\n\n1. e4 e5 *\n",
+		Moves:     []MoveRecord{{UCI: "e2e4", SAN: "e4"}, {UCI: "e7e5", SAN: "e5"}},
+		Metadata:  json.RawMessage(`{"ai_color":"black"}`),
+		CreatedAt: created,
+	}
+	if err := db.SaveGame(ctx, game); err != nil {
+		t.Fatalf("save game: %v", err)
+	}
+	game.Moves = append(game.Moves, MoveRecord{UCI: "g1f3", SAN: "Nf3"})
+	if err := db.SaveGame(ctx, game); err != nil {
+		t.Fatalf("replace game: %v", err)
+	}
+	if err := db.SaveGame(ctx, &GameRecord{ID: 1, PGN: "*", CreatedAt: created}); err != nil {
+		t.Fatalf("save game: %v", err)
+	}
+	db.(Store).Close()
+
+	db = open()
+	defer db.(Store).Close()
+	games, err := db.LoadGames(ctx)
+	if err != nil || len(games) != 2 || games[0].ID != 1 || games[1].ID != 3 {
+		t.Fatalf("expected games 1 and 3, got %+v: %v", games, err)
+	}
+	loaded, err := db.LoadGame(ctx, 3)
+	if err != nil || loaded == nil {
+		t.Fatalf("expected game 3, got %v: %v", loaded, err)
+	}
+	if loaded.PGN != game.PGN || len(loaded.Moves) != 3 || loaded.Moves[2].SAN != "Nf3" || !loaded.CreatedAt.Equal(created) || loaded.UpdatedAt.IsZero() {
+		t.Errorf("unexpected game %+v", loaded)
+	}
+	var metadata map[string]string
+	if err := json.Unmarshal(loaded.Metadata, &metadata); err != nil || metadata["ai_color"] != "black" {
+		t.Errorf("expected the metadata kept, got %s: %v", loaded.Metadata, err)
+	}
+
+	if err := db.DeleteGame(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if g, err := db.LoadGame(ctx, 3); g != nil || err != nil {
+		t.Errorf("expected game 3 deleted, got %+v: %v", g, err)
+	}
+}
+
+func TestSQLStore_SurvivesReopen(t *testing.T) {
+	testSurvivesReopen(t, config.DatabaseConfig{Driver: "sqlite3", ConnectionString: filepath.Join(t.TempDir(), "chess.db"), MaxConnections: 1})
+}
+
+func TestSQLStore_Games(t *testing.T) {
+	testGamesSurviveReopen(t, config.DatabaseConfig{Driver: "sqlite3", ConnectionString: filepath.Join(t.TempDir(), "chess.db"), MaxConnections: 1})
+}
+
+// TestSQLStore_Postgres runs against the PostgreSQL database of
+// CHESS_TEST_POSTGRES_URL, if set.
+func TestSQLStore_Postgres(t *testing.T) {
+	url := os.Getenv("CHESS_TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("CHESS_TEST_POSTGRES_URL not set")
+	}
+	cfg := config.DatabaseConfig{Driver: "postgres", ConnectionString: url}
+	testSurvivesReopen(t, cfg)
+	testGamesSurviveReopen(t, cfg)
+}
+
+// TestSQLStore_Migrations upgrades a database of the first schema version,
+// which had no migrations table, and leaves a current one alone.
+func TestSQLStore_Migrations(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "chess.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range migrations[0] {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO llm_histories (game_id, messages, updated_at) VALUES (1, '[]', CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	cfg := config.DatabaseConfig{Driver: "sqlite3", ConnectionString: path, MaxConnections: 1}
+	for range 2 {
+		opened, err := Open(cfg)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		var version, count int
+		sqlStore := opened.(*SQLStore)
+		if err := sqlStore.db.QueryRowContext(ctx, `SELECT MAX(version), COUNT(*) FROM schema_migrations`).Scan(&version, &count); err != nil || version != len(migrations) || count != len(migrations) {
+			t.Fatalf("expected versions 1 to %d applied once, got %d of %d: %v", len(migrations), count, version, err)
+		}
+		if history, err := opened.LoadLLMHistory(ctx, 1); err != nil || history == nil {
+			t.Errorf("expected the history kept, got %v: %v", history, err)
+		}
+		opened.Close()
+	}
+}
+
+func TestSQLStore_Rebind(t *testing.T) {
+	s := &SQLStore{numbered: true}
+	if got := s.rebind(`SELECT a FROM t WHERE b = ? AND c = ?`); got != `SELECT a FROM t WHERE b = $1 AND c = $2` {
+		t.Errorf("unexpected query %s", got)
+	}
+}
+
+func TestRedisStore_SurvivesReopen(t *testing.T) {
+	server := miniredis.RunT(t)
+	testSurvivesReopen(t, config.DatabaseConfig{Driver: "redis", ConnectionString: "redis://" + server.Addr() + "/0"})
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("expected deletes to remove the keys, got %v", keys)
+	}
+}
+
+func TestRedisStore_Games(t *testing.T) {
+	server := miniredis.RunT(t)
+	testGamesSurviveReopen(t, config.DatabaseConfig{Driver: "redis", ConnectionString: "redis://" + server.Addr() + "/0"})
+}
+
+func TestRedisStore_Coordinator(t *testing.T) {
+	server := miniredis.RunT(t)
+	db, err := Open(config.DatabaseConfig{Driver: "redis", ConnectionString: "redis://" + server.Addr() + "/0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	coordinator, ok := db.(Coordinator)
+	if !ok {
+		t.Fatal("expected a Redis store to coordinate servers")
+	}
+	ctx := context.Background()
+
+	for want := 1; want <= 2; want++ {
+		if id, err := coordinator.NextGameID(ctx); err != nil || id != want {
+			t.Fatalf("expected game ID %d, got %d: %v", want, id, err)
+		}
+	}
+
+	unlock, err := coordinator.LockGame(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL("chess:lock:game:5"); ttl <= 0 {
+		t.Errorf("expected the lock to expire, got a TTL of %v", ttl)
+	}
+	waiting, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := coordinator.LockGame(waiting, 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a held lock to time out, got %v", err)
+	}
+	if other, err := coordinator.LockGame(ctx, 6); err != nil {
+		t.Errorf("expected other games unaffected, got %v", err)
+	} else {
+		other()
+	}
+
+	acquired := make(chan func())
+	go func() {
+		unlock, err := coordinator.LockGame(ctx, 5)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- unlock
+	}()
+	unlock()
+	unlock() // harmless
+	select {
+	case unlock := <-acquired:
+		unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the lock taken once released")
+	}
+	if server.Exists("chess:lock:game:5") {
+		t.Error("expected the lock released")
+	}
+}
+
+func TestOpen_Failures(t *testing.T) {
+	if _, err := Open(config.DatabaseConfig{Driver: "oracle", ConnectionString: "x"}); err == nil {
+		t.Error("expected an error for an unsupported driver")
+	}
+	if db, err := Open(config.DatabaseConfig{Driver: "redis", ConnectionString: "redis://127.0.0.1:1/0"}); err == nil || db != nil {
+		t.Errorf("expected an error and no store without a Redis server, got %v", db)
+	}
+}
@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.rumenx.com/chess/config"
+)
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	shutdown, err := Setup(context.Background(), config.TracingConfig{})
+	if err != nil || shutdown(context.Background()) != nil {
+		t.Fatalf("expected disabled tracing to set up nothing, got %v", err)
+	}
+	if _, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); ok {
+		t.Fatal("expected no tracer provider with tracing disabled")
+	}
+
+	cfg := config.TracingConfig{Enabled: true, Exporter: "stdout", ServiceName: "go-chess", SampleRatio: 1}
+	shutdown, err = Setup(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); !ok {
+		t.Fatal("expected the SDK tracer provider")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Setup(context.Background(), config.TracingConfig{Enabled: true, Exporter: "zipkin"}); err == nil {
+		t.Error("expected an unknown exporter to fail")
+	}
+}
+
+func TestSetupIgnoresRemoteSampling(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	cfg := config.TracingConfig{Enabled: true, Exporter: "stdout", ServiceName: "go-chess", SampleRatio: 0}
+	shutdown, err := Setup(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	ctx, span := otel.Tracer("test").Start(trace.ContextWithRemoteSpanContext(context.Background(), remote), "request")
+	defer span.End()
+	if span.SpanContext().IsSampled() {
+		t.Error("expected a caller's sampled flag not to force a span to be recorded")
+	}
+	_, child := otel.Tracer("test").Start(ctx, "child")
+	defer child.End()
+	if child.SpanContext().IsSampled() {
+		t.Error("expected a local child to follow its unsampled parent")
+	}
+}